@@ -53,6 +53,7 @@ const (
 	HeaderIndex           = "X-Vault-Index"
 	HeaderForward         = "X-Vault-Forward"
 	HeaderInconsistent    = "X-Vault-Inconsistent"
+	HeaderIdempotencyKey  = "X-Vault-Idempotency-Key"
 )
 
 // Deprecated values
@@ -69,6 +70,12 @@ const (
 // value is not the empty string.
 type WrappingLookupFunc func(operation, path string) string
 
+// ReauthFunc is a function that is called when Vault rejects a request with
+// a 403, given the token the request was made with. It returns a new token to
+// retry the request with, or an empty string if the request must not be
+// retried, e.g. because the token is still valid and was denied by a policy.
+type ReauthFunc func(ctx context.Context, token string) (string, error)
+
 // Config is used to configure the creation of the client.
 type Config struct {
 	modifyLock sync.RWMutex
@@ -457,6 +464,7 @@ type Client struct {
 	token                 string
 	headers               http.Header
 	wrappingLookupFunc    WrappingLookupFunc
+	reauthFunc            ReauthFunc
 	mfaCreds              []string
 	policyOverride        bool
 	requestCallbacks      []RequestCallback
@@ -776,6 +784,23 @@ func (c *Client) SetWrappingLookupFunc(lookupFunc WrappingLookupFunc) {
 	c.wrappingLookupFunc = lookupFunc
 }
 
+// CurrentReauthFunc returns the function used to re-authenticate when a
+// request is rejected with a 403.
+func (c *Client) CurrentReauthFunc() ReauthFunc {
+	c.modifyLock.RLock()
+	defer c.modifyLock.RUnlock()
+	return c.reauthFunc
+}
+
+// SetReauthFunc sets a function used to re-authenticate when a request made
+// with the token of the client is rejected with a 403. The request is retried
+// once with the new token, which also replaces the token of the client.
+func (c *Client) SetReauthFunc(reauthFunc ReauthFunc) {
+	c.modifyLock.Lock()
+	defer c.modifyLock.Unlock()
+	c.reauthFunc = reauthFunc
+}
+
 // SetMFACreds sets the MFA credentials supplied either via the environment
 // variable or via the command line.
 func (c *Client) SetMFACreds(creds []string) {
@@ -1099,6 +1124,7 @@ func (c *Client) RawRequest(r *Request) (*Response, error) {
 func (c *Client) RawRequestWithContext(ctx context.Context, r *Request) (*Response, error) {
 	c.modifyLock.RLock()
 	token := c.token
+	reauthFunc := c.reauthFunc
 
 	c.config.modifyLock.RLock()
 	limiter := c.config.Limiter
@@ -1136,6 +1162,7 @@ func (c *Client) RawRequestWithContext(ctx context.Context, r *Request) (*Respon
 	}
 
 	redirectCount := 0
+	reauthenticated := false
 START:
 	req, err := r.toRetryableHTTP()
 	if err != nil {
@@ -1233,6 +1260,24 @@ START:
 		goto START
 	}
 
+	// Re-authenticate once if the token of the client was rejected, unless the
+	// body was streamed and can't be sent again
+	if resp.StatusCode == 403 && reauthFunc != nil && !reauthenticated &&
+		token != "" && r.ClientToken == token && (r.Body == nil || r.BodyBytes != nil) {
+		reauthenticated = true
+		newToken, err := reauthFunc(ctx, token)
+		if err != nil {
+			return result, fmt.Errorf("%w\n\nError re-authenticating: %s", result.Error(), err)
+		}
+		if newToken != "" {
+			resp.Body.Close()
+			c.SetToken(newToken)
+			token = newToken
+			r.ClientToken = newToken
+			goto START
+		}
+	}
+
 	if result != nil {
 		for _, cb := range c.responseCallbacks {
 			cb(result)
@@ -202,6 +202,76 @@ func TestClientRedirect(t *testing.T) {
 	}
 }
 
+func TestClientReauthFunc(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		var body bytes.Buffer
+		io.Copy(&body, req.Body)
+		if req.Header.Get("X-Vault-Token") != "new" {
+			w.WriteHeader(403)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		w.Write(body.Bytes())
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client.SetToken("expired")
+
+	var calls int
+	client.SetReauthFunc(func(ctx context.Context, token string) (string, error) {
+		calls++
+		if token != "expired" {
+			t.Errorf("bad token: %s", token)
+		}
+		return "new", nil
+	})
+
+	req := client.NewRequest("PUT", "/")
+	if err := req.SetJSONBody(map[string]string{"foo": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.RawRequest(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var buf bytes.Buffer
+	io.Copy(&buf, resp.Body)
+
+	// The request is retried with its body and the new token
+	if buf.String() != `{"foo":"bar"}` {
+		t.Fatalf("bad: %s", buf.String())
+	}
+	if calls != 1 || client.Token() != "new" {
+		t.Fatalf("bad: %d calls, token %s", calls, client.Token())
+	}
+
+	// Tokens denied by a policy are not replaced, and the request is not
+	// retried again
+	client.SetToken("denied")
+	client.SetReauthFunc(func(ctx context.Context, token string) (string, error) {
+		calls++
+		return "", nil
+	})
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected a permission denied error, got %v", err)
+	}
+	if calls != 2 || client.Token() != "denied" {
+		t.Fatalf("bad: %d calls, token %s", calls, client.Token())
+	}
+
+	client.SetReauthFunc(func(ctx context.Context, token string) (string, error) {
+		return "", fmt.Errorf("no credentials")
+	})
+	if _, err := client.RawRequest(client.NewRequest("GET", "/")); err == nil || !strings.Contains(err.Error(), "Error re-authenticating: no credentials") {
+		t.Fatalf("expected a re-authentication error, got %v", err)
+	}
+}
+
 func TestDefaulRetryPolicy(t *testing.T) {
 	cases := map[string]struct {
 		resp      *http.Response
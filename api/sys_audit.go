@@ -1,9 +1,11 @@
 package api
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -132,3 +134,40 @@ type Audit struct {
 	Local       bool              `json:"local" mapstructure:"local"`
 	Path        string            `json:"path" mapstructure:"path"`
 }
+
+// AuditStream returns a channel which receives the entries the audit device
+// at path logs from now on, each as a line of JSON, until ctx is done or the
+// server ends the stream. The server drops entries which are not read fast
+// enough.
+func (c *Sys) AuditStream(ctx context.Context, path string) (chan string, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/audit-stream/%s", path))
+
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	entryCh := make(chan string, 64)
+
+	go func() {
+		defer close(entryCh)
+		defer resp.Body.Close()
+
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if line = strings.TrimSpace(line); line != "" {
+				select {
+				case entryCh <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return entryCh, nil
+}
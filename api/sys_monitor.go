@@ -9,6 +9,14 @@ import (
 // Monitor returns a channel that outputs strings containing the log messages
 // coming from the server.
 func (c *Sys) Monitor(ctx context.Context, logLevel string) (chan string, error) {
+	return c.MonitorWithFilter(ctx, logLevel, "")
+}
+
+// MonitorWithFilter returns a channel that outputs strings containing the log
+// messages coming from the server that match the filter expression, such as
+// "subsystem == core.expiration and level >= warn". The filter is applied by
+// the server, before the messages are sent.
+func (c *Sys) MonitorWithFilter(ctx context.Context, logLevel, filter string) (chan string, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/monitor")
 
 	if logLevel == "" {
@@ -16,6 +24,9 @@ func (c *Sys) Monitor(ctx context.Context, logLevel string) (chan string, error)
 	} else {
 		r.Params.Add("log_level", logLevel)
 	}
+	if filter != "" {
+		r.Params.Add("filter", filter)
+	}
 
 	resp, err := c.c.RawRequestWithContext(ctx, r)
 	if err != nil {
@@ -15,6 +15,10 @@ import (
 type ListPluginsInput struct {
 	// Type of the plugin. Required.
 	Type consts.PluginType `json:"type"`
+
+	// Detailed requests the details of the plugins along with their names.
+	// It is only supported when listing plugins of every type.
+	Detailed bool `json:"-"`
 }
 
 // ListPluginsResponse is the response from the ListPlugins call.
@@ -27,6 +31,21 @@ type ListPluginsResponse struct {
 	// Deprecated: Newer server responses should be returning PluginsByType (json:
 	// "types") instead.
 	Names []string `json:"names"`
+
+	// Details are the details of the plugins, if they were requested with
+	// ListPluginsInput.Detailed.
+	Details []PluginDetails `json:"detailed,omitempty"`
+}
+
+// PluginDetails are the details of a plugin of the catalog.
+type PluginDetails struct {
+	Name              string   `json:"name" mapstructure:"name"`
+	Type              string   `json:"type" mapstructure:"type"`
+	Builtin           bool     `json:"builtin" mapstructure:"builtin"`
+	Version           string   `json:"version,omitempty" mapstructure:"version"`
+	DeprecationStatus string   `json:"deprecation_status,omitempty" mapstructure:"deprecation_status"`
+	Mounts            []string `json:"mounts" mapstructure:"mounts"`
+	OutdatedMounts    []string `json:"outdated_mounts" mapstructure:"outdated_mounts"`
 }
 
 // ListPlugins lists all plugins in the catalog and returns their names as a
@@ -43,6 +62,9 @@ func (c *Sys) ListPlugins(i *ListPluginsInput) (*ListPluginsResponse, error) {
 	}
 
 	req := c.c.NewRequest(method, path)
+	if i.Detailed && i.Type == consts.PluginTypeUnknown {
+		req.Params.Set("detailed", "true")
+	}
 	if method == "LIST" {
 		// Set this for broader compatibility, but we use LIST above to be able
 		// to handle the wrapping lookup function
@@ -95,6 +117,13 @@ func (c *Sys) ListPlugins(i *ListPluginsInput) (*ListPluginsResponse, error) {
 	}
 	if i.Type == consts.PluginTypeUnknown {
 		for pluginTypeStr, pluginsRaw := range secret.Data {
+			if pluginTypeStr == "detailed" {
+				if err := mapstructure.Decode(pluginsRaw, &result.Details); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
 			pluginType, err := consts.ParsePluginType(pluginTypeStr)
 			if err != nil {
 				return nil, err
@@ -135,11 +164,13 @@ type GetPluginInput struct {
 
 // GetPluginResponse is the response from the GetPlugin call.
 type GetPluginResponse struct {
-	Args    []string `json:"args"`
-	Builtin bool     `json:"builtin"`
-	Command string   `json:"command"`
-	Name    string   `json:"name"`
-	SHA256  string   `json:"sha256"`
+	Args              []string `json:"args"`
+	Builtin           bool     `json:"builtin"`
+	Command           string   `json:"command"`
+	Name              string   `json:"name"`
+	SHA256            string   `json:"sha256"`
+	Version           string   `json:"version,omitempty"`
+	DeprecationStatus string   `json:"deprecation_status,omitempty"`
 }
 
 // GetPlugin retrieves information about the plugin.
@@ -181,6 +212,9 @@ type RegisterPluginInput struct {
 
 	// SHA256 is the shasum of the plugin.
 	SHA256 string `json:"sha256,omitempty"`
+
+	// Version is the semantic version of the plugin. Optional.
+	Version string `json:"version,omitempty"`
 }
 
 // RegisterPlugin registers the plugin with the given information.
@@ -214,15 +214,21 @@ func (c *Sys) RaftSnapshot(snapWriter io.Writer) error {
 		return err
 	}
 
-	// Make sure that the last file in the archive, SHA256SUMS.sealed, is present
-	// and non-empty.  This is to catch cases where the snapshot failed midstream,
-	// e.g. due to a problem with the seal that prevented encryption of that file.
+	return copySealedSnapshot(snapWriter, resp.Body)
+}
+
+// copySealedSnapshot copies a full or delta snapshot archive from the
+// response body to the supplied io.Writer, making sure that the last file in
+// the archive, SHA256SUMS.sealed, is present and non-empty. This is to catch
+// cases where the snapshot failed midstream, e.g. due to a problem with the
+// seal that prevented encryption of that file.
+func copySealedSnapshot(snapWriter io.Writer, body io.Reader) error {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	var verified bool
 
 	rPipe, wPipe := io.Pipe()
-	dup := io.TeeReader(resp.Body, wPipe)
+	dup := io.TeeReader(body, wPipe)
 	go func() {
 		defer func() {
 			io.Copy(ioutil.Discard, rPipe)
@@ -271,6 +277,26 @@ func (c *Sys) RaftSnapshot(snapWriter io.Writer) error {
 	return nil
 }
 
+// RaftSnapshotDelta invokes the API that takes a delta snapshot of the raft
+// cluster holding the changes made since the parent snapshot, and writes it to
+// the supplied io.Writer. The manifest describes the storage at the parent
+// snapshot and is read from the supplied io.Reader.
+func (c *Sys) RaftSnapshotDelta(parent string, manifest io.Reader, snapWriter io.Writer) error {
+	r := c.c.NewRequest("POST", "/v1/sys/storage/raft/snapshot-delta")
+	r.Params.Set("parent", parent)
+	r.Body = manifest
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return copySealedSnapshot(snapWriter, resp.Body)
+}
+
 // RaftSnapshotRestore reads the snapshot from the io.Reader and installs that
 // snapshot, returning the cluster to the state defined by it.
 func (c *Sys) RaftSnapshotRestore(snapReader io.Reader, force bool) error {
@@ -293,6 +319,69 @@ func (c *Sys) RaftSnapshotRestore(snapReader io.Reader, force bool) error {
 	return nil
 }
 
+// RaftSnapshotChainRestore reads a snapshot chain from the io.Reader and
+// installs it, returning the cluster to the state defined by its last
+// snapshot. The chain is a tar stream holding a full snapshot followed by the
+// delta snapshots to apply on top of it, in order.
+func (c *Sys) RaftSnapshotChainRestore(chainReader io.Reader, force bool) error {
+	path := "/v1/sys/storage/raft/snapshot-chain"
+	if force {
+		path = "/v1/sys/storage/raft/snapshot-chain-force"
+	}
+	r := c.c.NewRequest("POST", path)
+
+	r.Body = chainReader
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// RaftSnapshotRestoreStatus returns the progress of the last snapshot restore
+// run by the node the client talks to, or nil if the node does not report it.
+func (c *Sys) RaftSnapshotRestoreStatus() (*RaftSnapshotRestoreStatus, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/storage/raft/snapshot-restore-status")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result RaftSnapshotRestoreStatus
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
+// RaftSnapshotRestoreStatus reports the progress of a snapshot restore. The
+// snapshot is first received by the node, then applied to raft; the bytes
+// are counted again for each of these phases.
+type RaftSnapshotRestoreStatus struct {
+	State           string    `json:"state"`
+	StartTime       time.Time `json:"start_time"`
+	PhaseStartTime  time.Time `json:"phase_start_time"`
+	EndTime         time.Time `json:"end_time"`
+	BytesTotal      int64     `json:"bytes_total"`
+	BytesProcessed  int64     `json:"bytes_processed"`
+	BytesPerSecond  float64   `json:"bytes_per_second"`
+	PercentComplete float64   `json:"percent_complete"`
+	ETASeconds      int64     `json:"eta_seconds"`
+	Error           string    `json:"error"`
+}
+
 // RaftAutopilotState returns the state of the raft cluster as seen by autopilot.
 func (c *Sys) RaftAutopilotState() (*AutopilotState, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/storage/raft/autopilot/state")
@@ -1,6 +1,12 @@
 package api
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
 
 func (c *Sys) SealStatus() (*SealStatusResponse, error) {
 	r := c.c.NewRequest("GET", "/v1/sys/seal-status")
@@ -64,6 +70,48 @@ func sealStatusRequest(c *Sys, r *Request) (*SealStatusResponse, error) {
 	return &result, err
 }
 
+// SealMigrationStatus returns the progress of the rewrap of the storage entries
+// after a seal migration.
+func (c *Sys) SealMigrationStatus() (*SealMigrationStatusResponse, error) {
+	r := c.c.NewRequest("GET", "/v1/sys/sealwrap/migration-status")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	resp, err := c.c.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result SealMigrationStatusResponse
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeHookFunc(time.RFC3339),
+		WeaklyTypedInput: true,
+		Result:           &result,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Decode(secret.Data); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 type SealStatusResponse struct {
 	Type         string `json:"type"`
 	Initialized  bool   `json:"initialized"`
@@ -85,3 +133,21 @@ type UnsealOpts struct {
 	Reset   bool   `json:"reset"`
 	Migrate bool   `json:"migrate"`
 }
+
+type SealMigrationStatusResponse struct {
+	State            string                     `json:"state" mapstructure:"state"`
+	StartTime        time.Time                  `json:"start_time" mapstructure:"start_time"`
+	EndTime          time.Time                  `json:"end_time" mapstructure:"end_time"`
+	Entries          SealMigrationStatusEntries `json:"entries" mapstructure:"entries"`
+	EntriesPerSecond float64                    `json:"entries_per_second" mapstructure:"entries_per_second"`
+	PercentComplete  float64                    `json:"percent_complete" mapstructure:"percent_complete"`
+	ETASeconds       int64                      `json:"eta_seconds" mapstructure:"eta_seconds"`
+	Error            string                     `json:"error,omitempty" mapstructure:"error"`
+}
+
+type SealMigrationStatusEntries struct {
+	Total     int `json:"total" mapstructure:"total"`
+	Processed int `json:"processed" mapstructure:"processed"`
+	Rewrapped int `json:"rewrapped" mapstructure:"rewrapped"`
+	Failed    int `json:"failed" mapstructure:"failed"`
+}
@@ -460,6 +460,10 @@ func NewTemporaryFormatter(format, prefix string) *AuditFormatter {
 			Prefix:   prefix,
 			SaltFunc: temporarySalt,
 		}
+	case "cbor":
+		ret.AuditFormatWriter = &CBORFormatWriter{
+			SaltFunc: temporarySalt,
+		}
 	default:
 		ret.AuditFormatWriter = &JSONFormatWriter{
 			Prefix:   prefix,
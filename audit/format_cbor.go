@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+)
+
+// CBORFormatWriter is an AuditFormatWriter implementation that structures data
+// into CBOR, a compact binary encoding of the JSON data model. Entries have the
+// same fields as in the JSON format, and are written back to back as a CBOR
+// sequence, which CBORDecoder reads.
+type CBORFormatWriter struct {
+	SaltFunc func(context.Context) (*salt.Salt, error)
+}
+
+func (f *CBORFormatWriter) WriteRequest(w io.Writer, req *AuditRequestEntry) error {
+	if req == nil {
+		return fmt.Errorf("request entry was nil, cannot encode")
+	}
+
+	return writeCBOR(w, req)
+}
+
+func (f *CBORFormatWriter) WriteResponse(w io.Writer, resp *AuditResponseEntry) error {
+	if resp == nil {
+		return fmt.Errorf("response entry was nil, cannot encode")
+	}
+
+	return writeCBOR(w, resp)
+}
+
+func (f *CBORFormatWriter) Salt(ctx context.Context) (*salt.Salt, error) {
+	return f.SaltFunc(ctx)
+}
+
+func newCBORHandle() *codec.CborHandle {
+	h := &codec.CborHandle{}
+	h.Canonical = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}
+
+// writeCBOR encodes an entry through its JSON encoding, so that its values
+// are encoded like in the JSON format. Request data, for instance, holds
+// numbers as json.Number, which would otherwise be encoded as strings.
+func writeCBOR(w io.Writer, entry interface{}) error {
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(jsonBytes))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, newCBORHandle()).Encode(cborNumbers(value)); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// cborNumbers replaces the JSON numbers in value with integers or floats.
+func cborNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = cborNumbers(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = cborNumbers(elem)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return value
+}
+
+// CBORDecoder reads the entries written by CBORFormatWriter.
+type CBORDecoder struct {
+	r   *bufio.Reader
+	dec *codec.Decoder
+}
+
+func NewCBORDecoder(r io.Reader) *CBORDecoder {
+	br := bufio.NewReader(r)
+	return &CBORDecoder{
+		r:   br,
+		dec: codec.NewDecoder(br, newCBORHandle()),
+	}
+}
+
+// Decode returns the next entry. Request entries are returned as response
+// entries without a response, which have the same JSON encoding. It returns
+// io.EOF after the last entry, and io.ErrUnexpectedEOF if the last entry is
+// incomplete.
+func (d *CBORDecoder) Decode() (*AuditResponseEntry, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+
+	var entry AuditResponseEntry
+	if err := d.dec.Decode(&entry); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestFormatCBOR_roundTrip(t *testing.T) {
+	salter, err := salt.NewSalt(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saltFunc := func(context.Context) (*salt.Salt, error) {
+		return salter, nil
+	}
+
+	in := &logical.LogInput{
+		Auth: &logical.Auth{
+			ClientToken: "foo",
+			Accessor:    "bar",
+			DisplayName: "testtoken",
+			EntityID:    "foobarentity",
+			Policies:    []string{"root"},
+			TokenType:   logical.TokenTypeService,
+			LeaseOptions: logical.LeaseOptions{
+				TTL: time.Hour * 4,
+			},
+		},
+		Request: &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "secret/foo",
+			Connection: &logical.Connection{
+				RemoteAddr: "127.0.0.1",
+			},
+			Data: map[string]interface{}{
+				"count":   json.Number("42"),
+				"big":     json.Number("18446744073709551615"),
+				"ratio":   json.Number("0.5"),
+				"enabled": true,
+				"nested": map[string]interface{}{
+					"list": []interface{}{"a", json.Number("-1")},
+				},
+			},
+			Headers: map[string][]string{
+				"foo": {"bar"},
+			},
+		},
+		Response: &logical.Response{
+			Data: map[string]interface{}{
+				"ttl": 3600,
+			},
+		},
+		OuterErr: errors.New("this is an error"),
+	}
+
+	// Request data is hashed unless log_raw is set, which covers numbers
+	for name, config := range map[string]FormatterConfig{
+		"hmac": {HMACAccessor: true},
+		"raw":  {Raw: true},
+	} {
+		config := config
+
+		t.Run(name, func(t *testing.T) {
+			jsonFormatter := AuditFormatter{
+				AuditFormatWriter: &JSONFormatWriter{SaltFunc: saltFunc},
+			}
+			cborFormatter := AuditFormatter{
+				AuditFormatWriter: &CBORFormatWriter{SaltFunc: saltFunc},
+			}
+
+			var jsonBuf, cborBuf bytes.Buffer
+			for _, f := range []struct {
+				formatter AuditFormatter
+				buf       *bytes.Buffer
+			}{
+				{jsonFormatter, &jsonBuf},
+				{cborFormatter, &cborBuf},
+			} {
+				ctx := namespace.RootContext(nil)
+				if err := f.formatter.FormatRequest(ctx, f.buf, config, in); err != nil {
+					t.Fatal(err)
+				}
+				if err := f.formatter.FormatResponse(ctx, f.buf, config, in); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if cborBuf.Len() >= jsonBuf.Len() {
+				t.Errorf("expected the CBOR entries (%d bytes) to be smaller than the JSON entries (%d bytes)", cborBuf.Len(), jsonBuf.Len())
+			}
+
+			// Decoding the CBOR entries and encoding them as JSON gives the JSON
+			// entries, apart from the time of the entries
+			expected := bytes.Split(bytes.TrimSpace(jsonBuf.Bytes()), []byte("\n"))
+			dec := NewCBORDecoder(&cborBuf)
+			for i := 0; ; i++ {
+				entry, err := dec.Decode()
+				if err == io.EOF {
+					if i != len(expected) {
+						t.Fatalf("expected %d entries, got %d", len(expected), i)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i >= len(expected) {
+					t.Fatalf("expected %d entries, got more", len(expected))
+				}
+
+				var jsonEntry AuditResponseEntry
+				if err := json.Unmarshal(expected[i], &jsonEntry); err != nil {
+					t.Fatal(err)
+				}
+				entry.Time = jsonEntry.Time
+
+				actual, err := json.Marshal(entry)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(actual, expected[i]) {
+					t.Errorf("entry %d:\nexpected %s\ngot      %s", i, expected[i], actual)
+				}
+			}
+		})
+	}
+}
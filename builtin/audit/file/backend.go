@@ -47,6 +47,11 @@ func Factory(ctx context.Context, conf *audit.BackendConfig) (audit.Backend, err
 	}
 	switch format {
 	case "json", "jsonx":
+	case "cbor":
+		// Entries are written back to back without a separator
+		if conf.Config["prefix"] != "" {
+			return nil, fmt.Errorf("prefix is not supported with format %q", format)
+		}
 	default:
 		return nil, fmt.Errorf("unknown format type %q", format)
 	}
@@ -110,6 +115,10 @@ func Factory(ctx context.Context, conf *audit.BackendConfig) (audit.Backend, err
 			Prefix:   conf.Config["prefix"],
 			SaltFunc: b.Salt,
 		}
+	case "cbor":
+		b.formatter.AuditFormatWriter = &audit.CBORFormatWriter{
+			SaltFunc: b.Salt,
+		}
 	}
 
 	switch path {
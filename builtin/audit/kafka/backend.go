@@ -0,0 +1,479 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/go-secure-stdlib/tlsutil"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// defaultRetryInterval is how long the backend waits after failing to
+	// publish before trying to reach the brokers again. In between, entries
+	// are buffered or rejected right away.
+	defaultRetryInterval = 5 * time.Second
+
+	defaultBufferMaxSize = 100 * 1024 * 1024
+
+	// drainBatchSize is the maximum size of the buffered entries handed over
+	// to the producer at once, so that sending them fits in the write
+	// timeout.
+	drainBatchSize = 512 * 1024
+)
+
+func Factory(ctx context.Context, conf *audit.BackendConfig) (audit.Backend, error) {
+	if conf.SaltConfig == nil {
+		return nil, fmt.Errorf("nil salt config")
+	}
+	if conf.SaltView == nil {
+		return nil, fmt.Errorf("nil salt view")
+	}
+
+	brokersRaw, ok := conf.Config["brokers"]
+	if !ok {
+		return nil, fmt.Errorf("brokers is required")
+	}
+	brokers, err := parseutil.ParseCommaStringSlice(brokersRaw)
+	if err != nil {
+		return nil, err
+	}
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("brokers is required")
+	}
+
+	topic, ok := conf.Config["topic"]
+	if !ok || topic == "" {
+		return nil, fmt.Errorf("topic is required")
+	}
+
+	partitionKey := conf.Config["partition_key"]
+	switch partitionKey {
+	case "", "entity", "namespace":
+	default:
+		return nil, fmt.Errorf("unknown partition_key %q, must be \"entity\" or \"namespace\"", partitionKey)
+	}
+
+	writeTimeout, ok := conf.Config["write_timeout"]
+	if !ok {
+		writeTimeout = "5s"
+	}
+	writeDuration, err := parseutil.ParseDurationSecond(writeTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	format, ok := conf.Config["format"]
+	if !ok {
+		format = "json"
+	}
+	switch format {
+	case "json", "jsonx":
+	case "cbor":
+		if conf.Config["prefix"] != "" {
+			return nil, fmt.Errorf("prefix is not supported with format %q", format)
+		}
+	default:
+		return nil, fmt.Errorf("unknown format type %q", format)
+	}
+
+	// Check if hashing of accessor is disabled
+	hmacAccessor := true
+	if hmacAccessorRaw, ok := conf.Config["hmac_accessor"]; ok {
+		value, err := strconv.ParseBool(hmacAccessorRaw)
+		if err != nil {
+			return nil, err
+		}
+		hmacAccessor = value
+	}
+
+	// Check if raw logging is enabled
+	logRaw := false
+	if raw, ok := conf.Config["log_raw"]; ok {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, err
+		}
+		logRaw = b
+	}
+
+	tlsConfig, err := parseTLSConfig(conf.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	config := producerConfig{
+		brokers:       brokers,
+		topic:         topic,
+		timeout:       writeDuration,
+		tlsConfig:     tlsConfig,
+		saslMechanism: conf.Config["sasl_mechanism"],
+		saslUsername:  conf.Config["sasl_username"],
+	}
+	switch config.saslMechanism {
+	case "":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if config.saslUsername == "" {
+			return nil, fmt.Errorf("sasl_username is required with sasl_mechanism")
+		}
+		// The password is read from a file, as the options of audit devices
+		// are returned when listing them
+		passwordFile, ok := conf.Config["sasl_password_file"]
+		if !ok {
+			return nil, fmt.Errorf("sasl_password_file is required with sasl_mechanism")
+		}
+		password, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SASL password file: %w", err)
+		}
+		config.saslPassword = strings.TrimSpace(string(password))
+	default:
+		return nil, fmt.Errorf("unknown sasl_mechanism %q", config.saslMechanism)
+	}
+
+	b := &Backend{
+		saltConfig: conf.SaltConfig,
+		saltView:   conf.SaltView,
+		formatConfig: audit.FormatterConfig{
+			Raw:          logRaw,
+			HMACAccessor: hmacAccessor,
+		},
+
+		format:        format,
+		producer:      newProducer(config),
+		partitionKey:  partitionKey,
+		retryInterval: defaultRetryInterval,
+	}
+
+	if bufferPath, ok := conf.Config["buffer_path"]; ok {
+		maxSize := uint64(defaultBufferMaxSize)
+		if maxSizeRaw, ok := conf.Config["buffer_max_size"]; ok {
+			maxSize, err = parseutil.ParseCapacityString(maxSizeRaw)
+			if err != nil {
+				return nil, err
+			}
+		}
+		b.buffer, err = newDiskBuffer(bufferPath, int64(maxSize))
+		if err != nil {
+			return nil, fmt.Errorf("sanity check failed; unable to open %q for writing: %w", bufferPath, err)
+		}
+	}
+
+	switch format {
+	case "json":
+		b.formatter.AuditFormatWriter = &audit.JSONFormatWriter{
+			Prefix:   conf.Config["prefix"],
+			SaltFunc: b.Salt,
+		}
+	case "jsonx":
+		b.formatter.AuditFormatWriter = &audit.JSONxFormatWriter{
+			Prefix:   conf.Config["prefix"],
+			SaltFunc: b.Salt,
+		}
+	case "cbor":
+		b.formatter.AuditFormatWriter = &audit.CBORFormatWriter{
+			SaltFunc: b.Salt,
+		}
+	}
+
+	return b, nil
+}
+
+// parseTLSConfig returns the TLS configuration used to connect to the
+// brokers, or nil if TLS is not enabled.
+func parseTLSConfig(conf map[string]string) (*tls.Config, error) {
+	enabled := false
+	if raw, ok := conf["tls"]; ok {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, err
+		}
+		enabled = value
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	tlsMinVersionStr, ok := conf["tls_min_version"]
+	if !ok {
+		tlsMinVersionStr = "tls12"
+	}
+	tlsMinVersion, ok := tlsutil.TLSLookup[tlsMinVersionStr]
+	if !ok {
+		return nil, fmt.Errorf("invalid 'tls_min_version'")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tlsMinVersion,
+		ServerName: conf["tls_server_name"],
+	}
+
+	if raw, ok := conf["tls_skip_verify"]; ok {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.InsecureSkipVerify = value
+	}
+
+	certFile, okCert := conf["tls_cert_file"]
+	keyFile, okKey := conf["tls_key_file"]
+	switch {
+	case okCert && okKey:
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client tls setup failed for Kafka: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case okCert || okKey:
+		return nil, fmt.Errorf("both tls_cert_file and tls_key_file must be set")
+	}
+
+	if caFile, ok := conf["tls_ca_file"]; ok {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kafka CA file: %w", err)
+		}
+		caPool := x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("failed to parse Kafka CA certificate")
+		}
+		tlsConfig.RootCAs = caPool
+	}
+
+	return tlsConfig, nil
+}
+
+// Backend is the audit backend which publishes entries to a Kafka topic.
+//
+// Entries are published synchronously: logging an entry returns once all
+// in-sync replicas acknowledged it, or once it was appended to the buffer
+// file, and fails otherwise, which fails the request being audited. The
+// entries of concurrent requests are queued and sent together by the
+// producer, without holding the lock of the backend. The queue is bounded;
+// entries which do not find room in it before the write timeout are handled
+// like entries the brokers did not acknowledge.
+//
+// When the brokers cannot be reached and buffer_path is set, entries are
+// appended to the buffer file instead, and sent before the next entry once
+// the brokers are back. Entries are published under the lock while the
+// buffer is not empty, to keep their order.
+type Backend struct {
+	format       string
+	formatter    audit.AuditFormatter
+	formatConfig audit.FormatterConfig
+
+	producer     *producer
+	buffer       *diskBuffer
+	partitionKey string
+
+	// retryAt is when the brokers are tried again after lastErr.
+	retryAt       time.Time
+	retryInterval time.Duration
+	lastErr       error
+
+	sync.Mutex
+
+	saltMutex  sync.RWMutex
+	salt       *salt.Salt
+	saltConfig *salt.Config
+	saltView   logical.Storage
+}
+
+var _ audit.Backend = (*Backend)(nil)
+
+func (b *Backend) GetHash(ctx context.Context, data string) (string, error) {
+	salt, err := b.Salt(ctx)
+	if err != nil {
+		return "", err
+	}
+	return audit.HashString(salt, data), nil
+}
+
+func (b *Backend) LogRequest(ctx context.Context, in *logical.LogInput) error {
+	var buf bytes.Buffer
+	if err := b.formatter.FormatRequest(ctx, &buf, b.formatConfig, in); err != nil {
+		return err
+	}
+
+	return b.publish(b.record(ctx, in, buf.Bytes()))
+}
+
+func (b *Backend) LogResponse(ctx context.Context, in *logical.LogInput) error {
+	var buf bytes.Buffer
+	if err := b.formatter.FormatResponse(ctx, &buf, b.formatConfig, in); err != nil {
+		return err
+	}
+
+	return b.publish(b.record(ctx, in, buf.Bytes()))
+}
+
+func (b *Backend) LogTestMessage(ctx context.Context, in *logical.LogInput, config map[string]string) error {
+	var buf bytes.Buffer
+	temporaryFormatter := audit.NewTemporaryFormatter(config["format"], config["prefix"])
+	if err := temporaryFormatter.FormatRequest(ctx, &buf, b.formatConfig, in); err != nil {
+		return err
+	}
+
+	// Bypass the buffer so that the device cannot be enabled with brokers
+	// which it cannot publish to
+	return b.producer.produce([]record{b.record(ctx, in, buf.Bytes())})
+}
+
+// record returns the record for a formatted entry, keyed according to the
+// partition_key option.
+func (b *Backend) record(ctx context.Context, in *logical.LogInput, entry []byte) record {
+	r := record{
+		value: entry,
+	}
+	// Each record holds a single entry, so the newline ending JSON entries
+	// is not needed
+	if b.format != "cbor" {
+		r.value = bytes.TrimSuffix(entry, []byte("\n"))
+	}
+
+	switch b.partitionKey {
+	case "entity":
+		if in.Auth != nil && in.Auth.EntityID != "" {
+			r.key = []byte(in.Auth.EntityID)
+		}
+	case "namespace":
+		// The root namespace has an empty, but not a nil, key
+		if ns, err := namespace.FromContext(ctx); err == nil {
+			r.key = append([]byte{}, ns.Path...)
+		}
+	}
+
+	return r
+}
+
+func (b *Backend) publish(r record) error {
+	b.Lock()
+	if !time.Now().Before(b.retryAt) && (b.buffer == nil || b.buffer.empty()) {
+		b.Unlock()
+
+		err := b.producer.produce([]record{r})
+		if err == nil {
+			return nil
+		}
+
+		b.Lock()
+		b.failed(err)
+	}
+	defer b.Unlock()
+
+	if !time.Now().Before(b.retryAt) {
+		err := b.drain()
+		if err == nil {
+			err = b.producer.produce([]record{r})
+		}
+		if err == nil {
+			return nil
+		}
+		b.failed(err)
+	}
+
+	err := fmt.Errorf("error publishing to Kafka: %w", b.lastErr)
+	if b.buffer == nil {
+		return err
+	}
+	if bErr := b.buffer.append(r); bErr != nil {
+		return multierror.Append(err, fmt.Errorf("error buffering entry: %w", bErr))
+	}
+	return nil
+}
+
+// failed records an error publishing entries, after which the brokers are
+// not tried again until the retry interval passed. It is called with the
+// lock held.
+func (b *Backend) failed(err error) {
+	// Concurrent entries failing together only close the producer once
+	if time.Now().Before(b.retryAt) {
+		return
+	}
+	b.producer.close()
+	b.lastErr = err
+	b.retryAt = time.Now().Add(b.retryInterval)
+}
+
+// drain sends the buffered entries, in the order they were buffered. The
+// entries which could not be sent are kept in the buffer.
+func (b *Backend) drain() error {
+	if b.buffer == nil || b.buffer.empty() {
+		return nil
+	}
+
+	records, err := b.buffer.read()
+	if err != nil {
+		return fmt.Errorf("error reading buffer: %w", err)
+	}
+
+	for len(records) > 0 {
+		n, size := 0, 0
+		for n < len(records) && (n == 0 || size+len(records[n].value) <= drainBatchSize) {
+			size += len(records[n].value)
+			n++
+		}
+
+		if err := b.producer.produce(records[:n]); err != nil {
+			if rErr := b.buffer.replace(records); rErr != nil {
+				return multierror.Append(err, rErr)
+			}
+			return err
+		}
+		records = records[n:]
+	}
+
+	return b.buffer.replace(nil)
+}
+
+// Reload closes the connections to the brokers, which are reopened for the
+// next entry.
+func (b *Backend) Reload(_ context.Context) error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.producer.close()
+	b.retryAt = time.Time{}
+
+	return nil
+}
+
+func (b *Backend) Salt(ctx context.Context) (*salt.Salt, error) {
+	b.saltMutex.RLock()
+	if b.salt != nil {
+		defer b.saltMutex.RUnlock()
+		return b.salt, nil
+	}
+	b.saltMutex.RUnlock()
+	b.saltMutex.Lock()
+	defer b.saltMutex.Unlock()
+	if b.salt != nil {
+		return b.salt, nil
+	}
+	salt, err := salt.NewSalt(ctx, b.saltView, b.saltConfig)
+	if err != nil {
+		return nil, err
+	}
+	b.salt = salt
+	return salt, nil
+}
+
+func (b *Backend) Invalidate(_ context.Context) {
+	b.saltMutex.Lock()
+	defer b.saltMutex.Unlock()
+	b.salt = nil
+}
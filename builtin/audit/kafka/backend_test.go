@@ -0,0 +1,600 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/xdg-go/scram"
+)
+
+// testCluster stands for the brokers of a topic. It hands out producers
+// which keep the records they are given, by partition.
+type testCluster struct {
+	partitions int32
+
+	mu   sync.Mutex
+	down bool
+	// batch is the number of records acknowledged together: records are
+	// held until as many were queued.
+	batch   int
+	records map[int32][]record
+}
+
+func newTestCluster(partitions int32) *testCluster {
+	return &testCluster{
+		partitions: partitions,
+		batch:      1,
+		records:    make(map[int32][]record),
+	}
+}
+
+// setDown makes the cluster unreachable, failing new producers and the
+// records queued in existing ones.
+func (c *testCluster) setDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down = down
+}
+
+func (c *testCluster) isDown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.down
+}
+
+func (c *testCluster) received() map[int32][]record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	received := make(map[int32][]record)
+	for partition, records := range c.records {
+		received[partition] = append([]record(nil), records...)
+	}
+	return received
+}
+
+func (c *testCluster) newAsyncProducer(_ []string, conf *sarama.Config) (sarama.AsyncProducer, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	if c.isDown() {
+		return nil, sarama.ErrOutOfBrokers
+	}
+
+	p := &testAsyncProducer{
+		cluster:     c,
+		partitioner: conf.Producer.Partitioner("audit"),
+		input:       make(chan *sarama.ProducerMessage, conf.ChannelBufferSize),
+		successes:   make(chan *sarama.ProducerMessage, conf.ChannelBufferSize),
+		errors:      make(chan *sarama.ProducerError, conf.ChannelBufferSize),
+	}
+	go p.run()
+	return p, nil
+}
+
+type testAsyncProducer struct {
+	cluster     *testCluster
+	partitioner sarama.Partitioner
+
+	input     chan *sarama.ProducerMessage
+	successes chan *sarama.ProducerMessage
+	errors    chan *sarama.ProducerError
+	closeOnce sync.Once
+}
+
+func (p *testAsyncProducer) run() {
+	var pending []*sarama.ProducerMessage
+	for msg := range p.input {
+		pending = append(pending, msg)
+
+		p.cluster.mu.Lock()
+		if len(pending) < p.cluster.batch {
+			p.cluster.mu.Unlock()
+			continue
+		}
+		for _, msg := range pending {
+			if p.cluster.down {
+				p.errors <- &sarama.ProducerError{Msg: msg, Err: sarama.ErrOutOfBrokers}
+				continue
+			}
+
+			partition, err := p.partitioner.Partition(msg, p.cluster.partitions)
+			if err != nil {
+				p.errors <- &sarama.ProducerError{Msg: msg, Err: err}
+				continue
+			}
+			var r record
+			if msg.Key != nil {
+				r.key, _ = msg.Key.Encode()
+			}
+			r.value, _ = msg.Value.Encode()
+			p.cluster.records[partition] = append(p.cluster.records[partition], r)
+			p.successes <- msg
+		}
+		p.cluster.mu.Unlock()
+		pending = nil
+	}
+
+	for _, msg := range pending {
+		p.errors <- &sarama.ProducerError{Msg: msg, Err: sarama.ErrShuttingDown}
+	}
+	close(p.successes)
+	close(p.errors)
+}
+
+func (p *testAsyncProducer) AsyncClose() {
+	p.closeOnce.Do(func() { close(p.input) })
+}
+
+func (p *testAsyncProducer) Close() error {
+	p.AsyncClose()
+	return nil
+}
+
+func (p *testAsyncProducer) Input() chan<- *sarama.ProducerMessage {
+	return p.input
+}
+
+func (p *testAsyncProducer) Successes() <-chan *sarama.ProducerMessage {
+	return p.successes
+}
+
+func (p *testAsyncProducer) Errors() <-chan *sarama.ProducerError {
+	return p.errors
+}
+
+// testBackend returns a backend publishing to cluster, or to the brokers
+// of the configuration if cluster is nil.
+func testBackend(t *testing.T, cluster *testCluster, config map[string]string) *Backend {
+	t.Helper()
+
+	b, err := Factory(context.Background(), &audit.BackendConfig{
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Config:     config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster != nil {
+		b.(*Backend).producer.newAsyncProducer = cluster.newAsyncProducer
+	}
+	return b.(*Backend)
+}
+
+func testLogInput(entityID string) *logical.LogInput {
+	return &logical.LogInput{
+		Auth: &logical.Auth{
+			EntityID: entityID,
+		},
+		Request: &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "secret/foo",
+		},
+	}
+}
+
+func TestAuditKafka_Factory(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := ioutil.WriteFile(passwordFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		config map[string]string
+		err    string
+	}{
+		{"no_brokers", map[string]string{"topic": "audit"}, "brokers is required"},
+		{"no_topic", map[string]string{"brokers": "127.0.0.1:9092"}, "topic is required"},
+		{"partition_key", map[string]string{"brokers": "127.0.0.1:9092", "topic": "audit", "partition_key": "path"}, `unknown partition_key "path"`},
+		{"format", map[string]string{"brokers": "127.0.0.1:9092", "topic": "audit", "format": "xml"}, `unknown format type "xml"`},
+		{"sasl_mechanism", map[string]string{"brokers": "127.0.0.1:9092", "topic": "audit", "sasl_mechanism": "GSSAPI"}, `unknown sasl_mechanism "GSSAPI"`},
+		{"sasl_username", map[string]string{"brokers": "127.0.0.1:9092", "topic": "audit", "sasl_mechanism": "PLAIN", "sasl_password_file": passwordFile}, "sasl_username is required"},
+		{"sasl_password_file", map[string]string{"brokers": "127.0.0.1:9092", "topic": "audit", "sasl_mechanism": "PLAIN", "sasl_username": "vault"}, "sasl_password_file is required"},
+		{"tls_cert_file", map[string]string{"brokers": "127.0.0.1:9092", "topic": "audit", "tls": "true", "tls_cert_file": "cert.pem"}, "both tls_cert_file and tls_key_file"},
+		{"tls_min_version", map[string]string{"brokers": "127.0.0.1:9092", "topic": "audit", "tls": "true", "tls_min_version": "ssl3"}, "invalid 'tls_min_version'"},
+		{"buffer_max_size", map[string]string{"brokers": "127.0.0.1:9092", "topic": "audit", "buffer_path": filepath.Join(t.TempDir(), "buffer"), "buffer_max_size": "lots"}, "could not parse capacity"},
+		{"valid", map[string]string{"brokers": "127.0.0.1:9092,127.0.0.1:9093", "topic": "audit", "partition_key": "entity", "sasl_mechanism": "SCRAM-SHA-512", "sasl_username": "vault", "sasl_password_file": passwordFile, "tls": "true"}, ""},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Factory(context.Background(), &audit.BackendConfig{
+				SaltConfig: &salt.Config{},
+				SaltView:   &logical.InmemStorage{},
+				Config:     tc.config,
+			})
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatal(err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestAuditKafka_partitionKey(t *testing.T) {
+	ctx := namespace.RootContext(context.Background())
+
+	cases := []struct {
+		partitionKey string
+		key          []byte
+	}{
+		{"", nil},
+		{"entity", []byte("e1")},
+		{"namespace", []byte{}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.partitionKey, func(t *testing.T) {
+			cluster := newTestCluster(4)
+			b := testBackend(t, cluster, map[string]string{
+				"brokers":       "127.0.0.1:9092",
+				"topic":         "audit",
+				"partition_key": tc.partitionKey,
+			})
+
+			if err := b.LogRequest(ctx, testLogInput("e1")); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.LogResponse(ctx, testLogInput("e1")); err != nil {
+				t.Fatal(err)
+			}
+
+			var records []record
+			for partition, received := range cluster.received() {
+				if tc.key != nil {
+					if expected := int32(murmur2(tc.key)&0x7fffffff) % 4; partition != expected {
+						t.Errorf("expected partition %d, got %d", expected, partition)
+					}
+				}
+				records = append(records, received...)
+			}
+			if len(records) != 2 {
+				t.Fatalf("expected 2 records, got %d", len(records))
+			}
+
+			types := make(map[string]bool)
+			for _, r := range records {
+				if (r.key == nil) != (tc.key == nil) || string(r.key) != string(tc.key) {
+					t.Errorf("expected key %q, got %q", tc.key, r.key)
+				}
+				var entry map[string]interface{}
+				if err := json.Unmarshal(r.value, &entry); err != nil {
+					t.Fatal(err)
+				}
+				types[entry["type"].(string)] = true
+			}
+			if !types["request"] || !types["response"] {
+				t.Errorf("expected a request and a response, got %v", types)
+			}
+		})
+	}
+}
+
+func TestAuditKafka_buffer(t *testing.T) {
+	ctx := namespace.RootContext(context.Background())
+
+	cluster := newTestCluster(1)
+	cluster.setDown(true)
+
+	bufferPath := filepath.Join(t.TempDir(), "buffer")
+	config := map[string]string{
+		"brokers":       "127.0.0.1:9092",
+		"topic":         "audit",
+		"partition_key": "entity",
+		"write_timeout": "1s",
+		"buffer_path":   bufferPath,
+	}
+	b := testBackend(t, cluster, config)
+
+	for _, entityID := range []string{"e1", "e2"} {
+		if err := b.LogRequest(ctx, testLogInput(entityID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if received := cluster.received(); len(received) != 0 {
+		t.Fatalf("expected no records, got %v", received)
+	}
+	if b.buffer.empty() {
+		t.Fatal("expected buffered entries")
+	}
+
+	// The buffer is kept when the backend is created again
+	if b := testBackend(t, cluster, config); b.buffer.empty() {
+		t.Fatal("expected buffered entries after a restart")
+	}
+
+	cluster.setDown(false)
+
+	// Entries are buffered until the retry interval passed
+	if err := b.LogRequest(ctx, testLogInput("e3")); err != nil {
+		t.Fatal(err)
+	}
+	if received := cluster.received(); len(received) != 0 {
+		t.Fatalf("expected no records, got %v", received)
+	}
+
+	b.retryAt = time.Time{}
+	if err := b.LogRequest(ctx, testLogInput("e4")); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, r := range cluster.received()[0] {
+		keys = append(keys, string(r.key))
+	}
+	if actual, expected := strings.Join(keys, ","), "e1,e2,e3,e4"; actual != expected {
+		t.Fatalf("expected keys %q, got %q", expected, actual)
+	}
+	if !b.buffer.empty() {
+		t.Fatal("expected the buffer to be empty")
+	}
+	if info, err := os.Stat(bufferPath); err != nil || info.Size() != 0 {
+		t.Fatalf("expected an empty buffer file, got %v %v", info, err)
+	}
+}
+
+func TestAuditKafka_unreachable(t *testing.T) {
+	ctx := namespace.RootContext(context.Background())
+
+	cluster := newTestCluster(1)
+	cluster.setDown(true)
+
+	t.Run("no_buffer", func(t *testing.T) {
+		b := testBackend(t, cluster, map[string]string{
+			"brokers": "127.0.0.1:9092",
+			"topic":   "audit",
+		})
+		for i := 0; i < 2; i++ {
+			err := b.LogRequest(ctx, testLogInput("e1"))
+			if err == nil || !strings.Contains(err.Error(), "error publishing to Kafka") {
+				t.Fatalf("expected an error publishing to Kafka, got %v", err)
+			}
+		}
+	})
+
+	t.Run("buffer_full", func(t *testing.T) {
+		b := testBackend(t, cluster, map[string]string{
+			"brokers":         "127.0.0.1:9092",
+			"topic":           "audit",
+			"buffer_path":     filepath.Join(t.TempDir(), "buffer"),
+			"buffer_max_size": "10",
+		})
+		err := b.LogRequest(ctx, testLogInput("e1"))
+		if err == nil || !strings.Contains(err.Error(), "buffer is full") {
+			t.Fatalf("expected the buffer to be full, got %v", err)
+		}
+	})
+
+	t.Run("test_message", func(t *testing.T) {
+		b := testBackend(t, cluster, map[string]string{
+			"brokers":     "127.0.0.1:9092",
+			"topic":       "audit",
+			"buffer_path": filepath.Join(t.TempDir(), "buffer"),
+		})
+		if err := b.LogTestMessage(ctx, testLogInput("e1"), nil); err == nil {
+			t.Fatal("expected the test message to fail")
+		}
+		if !b.buffer.empty() {
+			t.Fatal("expected the test message not to be buffered")
+		}
+	})
+}
+
+func TestAuditKafka_concurrent(t *testing.T) {
+	ctx := namespace.RootContext(context.Background())
+
+	// The records are only acknowledged once all of them were queued, which
+	// times out if the entries are published one after the other
+	const n = 8
+	cluster := newTestCluster(1)
+	cluster.batch = n
+
+	b := testBackend(t, cluster, map[string]string{
+		"brokers":       "127.0.0.1:9092",
+		"topic":         "audit",
+		"write_timeout": "5s",
+	})
+
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			errs <- b.LogRequest(ctx, testLogInput("e1"))
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if received := cluster.received(); len(received[0]) != n {
+		t.Fatalf("expected %d records, got %d", n, len(received[0]))
+	}
+}
+
+func TestAuditKafka_timeout(t *testing.T) {
+	ctx := namespace.RootContext(context.Background())
+
+	// The record is never acknowledged
+	cluster := newTestCluster(1)
+	cluster.batch = 2
+
+	b := testBackend(t, cluster, map[string]string{
+		"brokers":       "127.0.0.1:9092",
+		"topic":         "audit",
+		"write_timeout": "1s",
+	})
+
+	err := b.LogRequest(ctx, testLogInput("e1"))
+	if err == nil || !strings.Contains(err.Error(), "timed out waiting for the brokers") {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
+
+// TestAuditKafka_broker publishes to a mock broker, through the Kafka
+// protocol.
+func TestAuditKafka_broker(t *testing.T) {
+	ctx := namespace.RootContext(context.Background())
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := ioutil.WriteFile(passwordFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		mechanism string
+		handshake *sarama.MockSaslHandshakeResponse
+		err       string
+	}{
+		{
+			"no_sasl",
+			"",
+			nil,
+			"",
+		},
+		{
+			"sasl",
+			"PLAIN",
+			sarama.NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{"PLAIN"}),
+			"",
+		},
+		{
+			"sasl_mechanism",
+			"SCRAM-SHA-512",
+			sarama.NewMockSaslHandshakeResponse(t).SetEnabledMechanisms([]string{"PLAIN"}).SetError(sarama.ErrUnsupportedSASLMechanism),
+			"error publishing to Kafka",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			broker := sarama.NewMockBroker(t, 1)
+			defer broker.Close()
+
+			handlers := map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(t).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader("audit", 0, broker.BrokerID()),
+				"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+			}
+			config := map[string]string{
+				"brokers":       broker.Addr(),
+				"topic":         "audit",
+				"write_timeout": "2s",
+			}
+			if tc.mechanism != "" {
+				handlers["SaslHandshakeRequest"] = tc.handshake
+				handlers["SaslAuthenticateRequest"] = sarama.NewMockSaslAuthenticateResponse(t)
+				config["sasl_mechanism"] = tc.mechanism
+				config["sasl_username"] = "vault"
+				config["sasl_password_file"] = passwordFile
+			}
+			broker.SetHandlerByMap(handlers)
+
+			b := testBackend(t, nil, config)
+			defer b.producer.close()
+
+			err := b.LogRequest(ctx, testLogInput("e1"))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			produced := false
+			for _, rr := range broker.History() {
+				if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+					produced = true
+				}
+			}
+			if !produced {
+				t.Fatal("expected a produce request")
+			}
+		})
+	}
+}
+
+func TestScramClient(t *testing.T) {
+	for name, hashGen := range map[string]scram.HashGeneratorFcn{
+		"SCRAM-SHA-256": scram.SHA256,
+		"SCRAM-SHA-512": scramSHA512,
+	} {
+		hashGen := hashGen
+
+		t.Run(name, func(t *testing.T) {
+			for _, password := range []string{"secret", "wrong"} {
+				server, err := hashGen.NewServer(func(username string) (scram.StoredCredentials, error) {
+					client, err := hashGen.NewClient(username, "secret", "")
+					if err != nil {
+						return scram.StoredCredentials{}, err
+					}
+					return client.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096}), nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				conv := server.NewConversation()
+
+				client := &scramClient{hashGen: hashGen}
+				if err := client.Begin("vault", password, ""); err != nil {
+					t.Fatal(err)
+				}
+
+				var challenge, msg string
+				for err == nil {
+					if msg, err = client.Step(challenge); err != nil || client.Done() {
+						break
+					}
+					challenge, err = conv.Step(msg)
+				}
+				if valid := err == nil && conv.Valid(); valid != (password == "secret") {
+					t.Fatalf("password %q: expected the conversation to be valid: %t, got %v", password, password == "secret", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMurmur2(t *testing.T) {
+	// Test vectors from the Java client
+	cases := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+
+	for input, expected := range cases {
+		if actual := int32(murmur2([]byte(input))); actual != expected {
+			t.Errorf("murmur2(%q): expected %d, got %d", input, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+var errBufferFull = errors.New("buffer is full")
+
+// diskBuffer keeps the records which could not be delivered in a file, in
+// the order they were logged, until they are sent. Each record is stored as
+// its key and value, both prefixed with their length as a 32-bit integer; a
+// length of -1 stands for a nil key.
+type diskBuffer struct {
+	path    string
+	maxSize int64
+	size    int64
+}
+
+// newDiskBuffer returns the buffer stored at path, which keeps the records
+// buffered before Vault was restarted.
+func newDiskBuffer(path string, maxSize int64) (*diskBuffer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &diskBuffer{
+		path:    path,
+		maxSize: maxSize,
+		size:    info.Size(),
+	}, nil
+}
+
+func (b *diskBuffer) empty() bool {
+	return b.size == 0
+}
+
+// append adds records to the end of the buffer, syncing the file before
+// returning.
+func (b *diskBuffer) append(records ...record) error {
+	buf := encodeBufferRecords(records)
+	if b.size+int64(len(buf)) > b.maxSize {
+		return errBufferFull
+	}
+
+	f, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(buf)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		// Remove what was written of the records, so that the next records
+		// are appended to complete ones
+		f.Truncate(b.size)
+		return err
+	}
+
+	b.size += int64(len(buf))
+	return nil
+}
+
+// read returns the buffered records. A truncated record at the end of the
+// file, left by a failed write, is ignored.
+func (b *diskBuffer) read() ([]record, error) {
+	buf, err := ioutil.ReadFile(b.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []record
+	for len(buf) > 0 {
+		var r record
+		var ok bool
+		if r.key, buf, ok = decodeBufferBytes(buf); !ok {
+			break
+		}
+		if r.value, buf, ok = decodeBufferBytes(buf); !ok || r.value == nil {
+			break
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// replace replaces the buffered records with the given ones, which are the
+// records left after sending the others.
+func (b *diskBuffer) replace(records []record) error {
+	buf := encodeBufferRecords(records)
+
+	tmp := b.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("error replacing buffer: %w", err)
+	}
+	b.size = int64(len(buf))
+	return nil
+}
+
+func encodeBufferRecords(records []record) []byte {
+	var buf []byte
+	for _, r := range records {
+		buf = appendBufferBytes(buf, r.key)
+		buf = appendBufferBytes(buf, r.value)
+	}
+	return buf
+}
+
+func appendBufferBytes(buf, b []byte) []byte {
+	n := int32(len(b))
+	if b == nil {
+		n = -1
+	}
+	buf = append(buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(n))
+	return append(buf, b...)
+}
+
+// decodeBufferBytes returns the bytes at the start of buf and the rest of
+// buf, or false if buf is truncated.
+func decodeBufferBytes(buf []byte) ([]byte, []byte, bool) {
+	if len(buf) < 4 {
+		return nil, nil, false
+	}
+	n := int32(binary.BigEndian.Uint32(buf))
+	buf = buf[4:]
+	if n < 0 {
+		return nil, buf, true
+	}
+	if int(n) > len(buf) {
+		return nil, nil, false
+	}
+	return append([]byte{}, buf[:n]...), buf[n:], true
+}
@@ -0,0 +1,324 @@
+package kafka
+
+import (
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+)
+
+const (
+	clientID = "vault"
+
+	// queueSize bounds the number of records waiting to be sent to the
+	// brokers. Records of concurrent calls to produce are queued together
+	// and sent in the same requests.
+	queueSize = 1024
+)
+
+var scramSHA512 scram.HashGeneratorFcn = sha512.New
+
+// record is a message published to the topic. A nil key lets the producer
+// choose the partition.
+type record struct {
+	key   []byte
+	value []byte
+}
+
+type producerConfig struct {
+	brokers   []string
+	topic     string
+	timeout   time.Duration
+	tlsConfig *tls.Config
+
+	saslMechanism string
+	saslUsername  string
+	saslPassword  string
+}
+
+// saramaConfig returns the configuration of the client, which waits for all
+// in-sync replicas to acknowledge each record.
+func (c producerConfig) saramaConfig() *sarama.Config {
+	conf := sarama.NewConfig()
+	conf.ClientID = clientID
+	conf.Version = sarama.V1_0_0_0
+	conf.ChannelBufferSize = queueSize
+
+	conf.Net.DialTimeout = c.timeout
+	conf.Net.ReadTimeout = c.timeout
+	conf.Net.WriteTimeout = c.timeout
+	// Keep the order of the records of a partition when a request is
+	// retried
+	conf.Net.MaxOpenRequests = 1
+
+	if c.tlsConfig != nil {
+		conf.Net.TLS.Enable = true
+		conf.Net.TLS.Config = c.tlsConfig
+	}
+
+	if c.saslMechanism != "" {
+		conf.Net.SASL.Enable = true
+		conf.Net.SASL.Version = sarama.SASLHandshakeV1
+		conf.Net.SASL.Mechanism = sarama.SASLMechanism(c.saslMechanism)
+		conf.Net.SASL.User = c.saslUsername
+		conf.Net.SASL.Password = c.saslPassword
+		switch c.saslMechanism {
+		case sarama.SASLTypeSCRAMSHA256:
+			conf.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hashGen: scram.SHA256}
+			}
+		case sarama.SASLTypeSCRAMSHA512:
+			conf.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hashGen: scramSHA512}
+			}
+		}
+	}
+
+	conf.Metadata.Full = false
+	conf.Metadata.Retry.Max = 1
+
+	conf.Producer.RequiredAcks = sarama.WaitForAll
+	conf.Producer.Timeout = c.timeout
+	conf.Producer.Retry.Max = 1
+	conf.Producer.Return.Successes = true
+	conf.Producer.Return.Errors = true
+	conf.Producer.Partitioner = newKeyPartitioner
+
+	return conf
+}
+
+// producer publishes records to the partitions of a single topic. It is
+// safe for concurrent use.
+type producer struct {
+	config producerConfig
+
+	// newAsyncProducer creates the client, and is replaced in tests.
+	newAsyncProducer func(addrs []string, conf *sarama.Config) (sarama.AsyncProducer, error)
+
+	// l is held for reading while records are queued, so that the client is
+	// not closed in between.
+	l     sync.RWMutex
+	async sarama.AsyncProducer
+}
+
+func newProducer(config producerConfig) *producer {
+	return &producer{
+		config:           config,
+		newAsyncProducer: sarama.NewAsyncProducer,
+	}
+}
+
+// produce publishes records, and returns once all in-sync replicas
+// acknowledged them, or with an error once one of them failed or the write
+// timeout elapsed. The records are not sent in the order they were given
+// when they end up in different partitions. Records may be published twice
+// when the client retries after a partial failure, or still publishes them
+// after the timeout.
+func (p *producer) produce(records []record) error {
+	timer := time.NewTimer(p.config.timeout)
+	defer timer.Stop()
+
+	results := make(chan error, len(records))
+	if err := p.queue(records, results, timer.C); err != nil {
+		return err
+	}
+
+	var err error
+	for range records {
+		select {
+		case rErr := <-results:
+			if rErr != nil && err == nil {
+				err = rErr
+			}
+		case <-timer.C:
+			return fmt.Errorf("timed out waiting for the brokers to acknowledge the entries")
+		}
+	}
+	return err
+}
+
+// queue hands records over to the client, opening it first if needed. The
+// result of each record is sent to results.
+func (p *producer) queue(records []record, results chan<- error, timeout <-chan time.Time) error {
+	p.l.RLock()
+	for p.async == nil {
+		p.l.RUnlock()
+		if err := p.open(); err != nil {
+			return err
+		}
+		p.l.RLock()
+	}
+	defer p.l.RUnlock()
+
+	for _, r := range records {
+		msg := &sarama.ProducerMessage{
+			Topic:    p.config.topic,
+			Value:    sarama.ByteEncoder(r.value),
+			Metadata: results,
+		}
+		if r.key != nil {
+			msg.Key = sarama.ByteEncoder(r.key)
+		}
+
+		select {
+		case p.async.Input() <- msg:
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for room in the queue of entries sent to the brokers")
+		}
+	}
+	return nil
+}
+
+// open creates the client, which connects to the brokers and reads the
+// metadata of the topic.
+func (p *producer) open() error {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if p.async != nil {
+		return nil
+	}
+
+	async, err := p.newAsyncProducer(p.config.brokers, p.config.saramaConfig())
+	if err != nil {
+		return err
+	}
+	go dispatchResults(async)
+
+	p.async = async
+	return nil
+}
+
+// close closes the client. The records it still holds are sent, or fail,
+// in the background.
+func (p *producer) close() {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if p.async != nil {
+		p.async.AsyncClose()
+		p.async = nil
+	}
+}
+
+// dispatchResults sends the result of each record to the channel it was
+// queued with, until the client is closed.
+func dispatchResults(async sarama.AsyncProducer) {
+	successes, errs := async.Successes(), async.Errors()
+	for successes != nil || errs != nil {
+		select {
+		case msg, ok := <-successes:
+			if !ok {
+				successes = nil
+				continue
+			}
+			msg.Metadata.(chan<- error) <- nil
+		case pErr, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			pErr.Msg.Metadata.(chan<- error) <- pErr.Err
+		}
+	}
+}
+
+// keyPartitioner hashes keys like the default partitioner of the Java
+// client, so that records with the same key end up in the same partition
+// regardless of the producer. Records without key are spread over the
+// partitions at random.
+type keyPartitioner struct {
+	random sarama.Partitioner
+}
+
+func newKeyPartitioner(topic string) sarama.Partitioner {
+	return &keyPartitioner{
+		random: sarama.NewRandomPartitioner(topic),
+	}
+}
+
+func (p *keyPartitioner) Partition(msg *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if msg.Key == nil {
+		return p.random.Partition(msg, numPartitions)
+	}
+	key, err := msg.Key.Encode()
+	if err != nil {
+		return -1, err
+	}
+	return int32(murmur2(key)&0x7fffffff) % numPartitions, nil
+}
+
+func (p *keyPartitioner) RequiresConsistency() bool {
+	return true
+}
+
+// murmur2 is the hash function of the default partitioner of the Java
+// client.
+func murmur2(data []byte) uint32 {
+	const (
+		seed = 0x9747b28c
+		m    = 0x5bd1e995
+		r    = 24
+	)
+
+	length := len(data)
+	h := uint32(seed) ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// scramClient runs the client side of SCRAM authentication for the client.
+type scramClient struct {
+	hashGen scram.HashGeneratorFcn
+	conv    *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(username, password, authzID string) error {
+	client, err := c.hashGen.NewClient(username, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conv = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	if c.conv == nil {
+		return "", errors.New("SCRAM conversation not started")
+	}
+	return c.conv.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conv != nil && c.conv.Done()
+}
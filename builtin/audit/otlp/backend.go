@@ -0,0 +1,349 @@
+package otlp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/go-secure-stdlib/tlsutil"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// scopeName is the instrumentation scope of the exported log records.
+const scopeName = "vault.audit"
+
+func Factory(ctx context.Context, conf *audit.BackendConfig) (audit.Backend, error) {
+	if conf.SaltConfig == nil {
+		return nil, fmt.Errorf("nil salt config")
+	}
+	if conf.SaltView == nil {
+		return nil, fmt.Errorf("nil salt view")
+	}
+
+	endpoint, ok := conf.Config["endpoint"]
+	if !ok {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("endpoint must be an http or https URL")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/logs"
+
+	writeTimeout, ok := conf.Config["write_timeout"]
+	if !ok {
+		writeTimeout = "5s"
+	}
+	writeDuration, err := parseutil.ParseDurationSecond(writeTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if hashing of accessor is disabled
+	hmacAccessor := true
+	if hmacAccessorRaw, ok := conf.Config["hmac_accessor"]; ok {
+		value, err := strconv.ParseBool(hmacAccessorRaw)
+		if err != nil {
+			return nil, err
+		}
+		hmacAccessor = value
+	}
+
+	// Check if raw logging is enabled
+	logRaw := false
+	if raw, ok := conf.Config["log_raw"]; ok {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, err
+		}
+		logRaw = b
+	}
+
+	headers := make(http.Header)
+	if headersFile, ok := conf.Config["headers_file"]; ok {
+		headers, err = readHeadersFile(headersFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tlsConfig, err := parseTLSConfig(conf.Config)
+	if err != nil {
+		return nil, err
+	}
+	client := cleanhttp.DefaultPooledClient()
+	client.Timeout = writeDuration
+	client.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+
+	serviceName, ok := conf.Config["service_name"]
+	if !ok {
+		serviceName = "vault"
+	}
+	var resourceAttrs attributes
+	resourceAttrs.string("service.name", serviceName)
+	if hostname, err := os.Hostname(); err == nil {
+		resourceAttrs.string("host.name", hostname)
+	}
+
+	b := &Backend{
+		saltConfig: conf.SaltConfig,
+		saltView:   conf.SaltView,
+		formatConfig: audit.FormatterConfig{
+			Raw:          logRaw,
+			HMACAccessor: hmacAccessor,
+		},
+
+		client:   client,
+		url:      u.String(),
+		headers:  headers,
+		resource: resource{Attributes: resourceAttrs},
+	}
+
+	// Entries are always formatted as JSON, which is mapped to the log record
+	b.formatter.AuditFormatWriter = &audit.JSONFormatWriter{
+		SaltFunc: b.Salt,
+	}
+
+	return b, nil
+}
+
+// readHeadersFile reads the HTTP headers sent with every export from a file
+// with one "Name: value" header per line. Empty lines and lines starting
+// with "#" are ignored.
+func readHeadersFile(path string) (http.Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read headers file: %w", err)
+	}
+	defer f.Close()
+
+	headers := make(http.Header)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid header %q in headers file, expected \"Name: value\"", line)
+		}
+		headers.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read headers file: %w", err)
+	}
+	return headers, nil
+}
+
+// parseTLSConfig returns the TLS configuration used to connect to an https
+// endpoint.
+func parseTLSConfig(conf map[string]string) (*tls.Config, error) {
+	tlsMinVersionStr, ok := conf["tls_min_version"]
+	if !ok {
+		tlsMinVersionStr = "tls12"
+	}
+	tlsMinVersion, ok := tlsutil.TLSLookup[tlsMinVersionStr]
+	if !ok {
+		return nil, fmt.Errorf("invalid 'tls_min_version'")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tlsMinVersion,
+		ServerName: conf["tls_server_name"],
+	}
+
+	if raw, ok := conf["tls_skip_verify"]; ok {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.InsecureSkipVerify = value
+	}
+
+	certFile, okCert := conf["tls_cert_file"]
+	keyFile, okKey := conf["tls_key_file"]
+	switch {
+	case okCert && okKey:
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client tls setup failed for OTLP: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case okCert || okKey:
+		return nil, fmt.Errorf("both tls_cert_file and tls_key_file must be set")
+	}
+
+	if caFile, ok := conf["tls_ca_file"]; ok {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OTLP CA file: %w", err)
+		}
+		caPool := x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("failed to parse OTLP CA certificate")
+		}
+		tlsConfig.RootCAs = caPool
+	}
+
+	return tlsConfig, nil
+}
+
+// Backend is the audit backend which exports entries as OpenTelemetry log
+// records to a collector, using OTLP over HTTP with JSON encoding. Each
+// entry is exported synchronously in its own request.
+type Backend struct {
+	formatter    audit.AuditFormatter
+	formatConfig audit.FormatterConfig
+
+	client   *http.Client
+	url      string
+	headers  http.Header
+	resource resource
+
+	saltMutex  sync.RWMutex
+	salt       *salt.Salt
+	saltConfig *salt.Config
+	saltView   logical.Storage
+}
+
+var _ audit.Backend = (*Backend)(nil)
+
+func (b *Backend) GetHash(ctx context.Context, data string) (string, error) {
+	salt, err := b.Salt(ctx)
+	if err != nil {
+		return "", err
+	}
+	return audit.HashString(salt, data), nil
+}
+
+func (b *Backend) LogRequest(ctx context.Context, in *logical.LogInput) error {
+	var buf bytes.Buffer
+	if err := b.formatter.FormatRequest(ctx, &buf, b.formatConfig, in); err != nil {
+		return err
+	}
+
+	return b.export(ctx, buf.Bytes())
+}
+
+func (b *Backend) LogResponse(ctx context.Context, in *logical.LogInput) error {
+	var buf bytes.Buffer
+	if err := b.formatter.FormatResponse(ctx, &buf, b.formatConfig, in); err != nil {
+		return err
+	}
+
+	return b.export(ctx, buf.Bytes())
+}
+
+func (b *Backend) LogTestMessage(ctx context.Context, in *logical.LogInput, config map[string]string) error {
+	var buf bytes.Buffer
+	temporaryFormatter := audit.NewTemporaryFormatter("json", "")
+	if err := temporaryFormatter.FormatRequest(ctx, &buf, b.formatConfig, in); err != nil {
+		return err
+	}
+
+	return b.export(ctx, buf.Bytes())
+}
+
+func (b *Backend) export(ctx context.Context, entry []byte) error {
+	record, err := newLogRecord(bytes.TrimSuffix(entry, []byte("\n")), time.Now())
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(&exportLogsServiceRequest{
+		ResourceLogs: []resourceLogs{{
+			Resource: b.resource,
+			ScopeLogs: []scopeLogs{{
+				Scope:      instrumentationScope{Name: scopeName},
+				LogRecords: []logRecord{record},
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range b.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error exporting to OTLP endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return fmt.Errorf("error reading OTLP response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OTLP endpoint returned %s: %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+
+	var exportResp exportLogsServiceResponse
+	if len(respBody) > 0 && json.Unmarshal(respBody, &exportResp) == nil && exportResp.PartialSuccess != nil {
+		rejected := strings.Trim(string(exportResp.PartialSuccess.RejectedLogRecords), `"`)
+		if rejected != "" && rejected != "0" {
+			return fmt.Errorf("OTLP endpoint rejected the entry: %s", exportResp.PartialSuccess.ErrorMessage)
+		}
+	}
+
+	return nil
+}
+
+func (b *Backend) Reload(_ context.Context) error {
+	return nil
+}
+
+func (b *Backend) Salt(ctx context.Context) (*salt.Salt, error) {
+	b.saltMutex.RLock()
+	if b.salt != nil {
+		defer b.saltMutex.RUnlock()
+		return b.salt, nil
+	}
+	b.saltMutex.RUnlock()
+	b.saltMutex.Lock()
+	defer b.saltMutex.Unlock()
+	if b.salt != nil {
+		return b.salt, nil
+	}
+	salt, err := salt.NewSalt(ctx, b.saltView, b.saltConfig)
+	if err != nil {
+		return nil, err
+	}
+	b.salt = salt
+	return salt, nil
+}
+
+func (b *Backend) Invalidate(_ context.Context) {
+	b.saltMutex.Lock()
+	defer b.saltMutex.Unlock()
+	b.salt = nil
+}
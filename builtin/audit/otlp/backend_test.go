@@ -0,0 +1,262 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// testCollector is an OTLP/HTTP collector which keeps the requests it
+// receives and answers with the given status and body.
+type testCollector struct {
+	*httptest.Server
+
+	status int
+	body   string
+
+	mu       sync.Mutex
+	requests []*http.Request
+	exports  []exportLogsServiceRequest
+}
+
+func newTestCollector(t *testing.T, status int, body string) *testCollector {
+	t.Helper()
+
+	c := &testCollector{status: status, body: body}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var export exportLogsServiceRequest
+		if err := json.NewDecoder(r.Body).Decode(&export); err != nil {
+			t.Error(err)
+		}
+
+		c.mu.Lock()
+		c.requests = append(c.requests, r)
+		c.exports = append(c.exports, export)
+		c.mu.Unlock()
+
+		w.WriteHeader(c.status)
+		w.Write([]byte(c.body))
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func testBackend(t *testing.T, config map[string]string) *Backend {
+	t.Helper()
+
+	b, err := Factory(context.Background(), &audit.BackendConfig{
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Config:     config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.(*Backend)
+}
+
+func TestAuditOTLP_Factory(t *testing.T) {
+	headersFile := filepath.Join(t.TempDir(), "headers")
+	if err := ioutil.WriteFile(headersFile, []byte("Authorization Bearer foo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		config map[string]string
+		err    string
+	}{
+		{"no_endpoint", map[string]string{}, "endpoint is required"},
+		{"endpoint_scheme", map[string]string{"endpoint": "collector:4318"}, "endpoint must be an http or https URL"},
+		{"headers_file", map[string]string{"endpoint": "http://collector:4318", "headers_file": headersFile}, "invalid header"},
+		{"headers_file_missing", map[string]string{"endpoint": "http://collector:4318", "headers_file": headersFile + ".missing"}, "failed to read headers file"},
+		{"tls_key_file", map[string]string{"endpoint": "https://collector:4318", "tls_key_file": "key.pem"}, "both tls_cert_file and tls_key_file"},
+		{"valid", map[string]string{"endpoint": "https://collector:4318", "service_name": "vault-eu"}, ""},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Factory(context.Background(), &audit.BackendConfig{
+				SaltConfig: &salt.Config{},
+				SaltView:   &logical.InmemStorage{},
+				Config:     tc.config,
+			})
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatal(err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestAuditOTLP_export(t *testing.T) {
+	ctx := namespace.RootContext(context.Background())
+
+	collector := newTestCollector(t, http.StatusOK, "{}")
+
+	headersFile := filepath.Join(t.TempDir(), "headers")
+	if err := ioutil.WriteFile(headersFile, []byte("# API key\nX-Api-Key: foo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b := testBackend(t, map[string]string{
+		"endpoint":     collector.URL + "/otlp/",
+		"headers_file": headersFile,
+	})
+
+	in := &logical.LogInput{
+		Auth: &logical.Auth{
+			EntityID: "e1",
+			Policies: []string{"default", "web"},
+		},
+		Request: &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "secret/foo",
+			Headers: map[string][]string{
+				"traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+			},
+		},
+		OuterErr: errors.New("permission denied"),
+	}
+	if err := b.LogRequest(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.LogResponse(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	if len(collector.exports) != 2 {
+		t.Fatalf("expected 2 exports, got %d", len(collector.exports))
+	}
+	r := collector.requests[0]
+	if r.URL.Path != "/otlp/v1/logs" {
+		t.Errorf("expected path /otlp/v1/logs, got %s", r.URL.Path)
+	}
+	if r.Header.Get("X-Api-Key") != "foo" || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", r.Header)
+	}
+
+	for i, typ := range []string{"request", "response"} {
+		rl := collector.exports[i].ResourceLogs[0]
+		if name := *rl.Resource.Attributes[0].Value.StringValue; rl.Resource.Attributes[0].Key != "service.name" || name != "vault" {
+			t.Errorf("expected service.name vault, got %v", rl.Resource.Attributes[0])
+		}
+		if rl.ScopeLogs[0].Scope.Name != scopeName {
+			t.Errorf("expected scope %s, got %s", scopeName, rl.ScopeLogs[0].Scope.Name)
+		}
+
+		record := rl.ScopeLogs[0].LogRecords[0]
+		if record.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || record.SpanID != "00f067aa0ba902b7" || record.Flags != 1 {
+			t.Errorf("unexpected trace context %q %q %d", record.TraceID, record.SpanID, record.Flags)
+		}
+		if record.SeverityText != "ERROR" || record.SeverityNumber != severityError {
+			t.Errorf("expected severity ERROR, got %s %d", record.SeverityText, record.SeverityNumber)
+		}
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(*record.Body.StringValue), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry["type"] != typ {
+			t.Errorf("expected body of type %s, got %v", typ, entry["type"])
+		}
+
+		attrs := make(map[string]anyValue)
+		for _, kv := range record.Attributes {
+			attrs[kv.Key] = kv.Value
+		}
+		for key, expected := range map[string]string{
+			"vault.audit.type":        typ,
+			"vault.request.operation": "update",
+			"vault.request.path":      "secret/foo",
+			"vault.auth.entity_id":    "e1",
+			"vault.error":             "permission denied",
+		} {
+			if v := attrs[key].StringValue; v == nil || *v != expected {
+				t.Errorf("expected attribute %s to be %q, got %v", key, expected, v)
+			}
+		}
+		if policies := attrs["vault.auth.policies"].ArrayValue; policies == nil || len(policies.Values) != 2 {
+			t.Errorf("expected 2 policies, got %v", policies)
+		}
+	}
+}
+
+func TestAuditOTLP_exportErrors(t *testing.T) {
+	ctx := namespace.RootContext(context.Background())
+
+	in := &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "secret/foo",
+		},
+	}
+
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		err    string
+	}{
+		{"status", http.StatusServiceUnavailable, "collector is down", "503 Service Unavailable: collector is down"},
+		{"rejected", http.StatusOK, `{"partialSuccess":{"rejectedLogRecords":"1","errorMessage":"too old"}}`, "rejected the entry: too old"},
+		{"partial_success_none", http.StatusOK, `{"partialSuccess":{"rejectedLogRecords":0,"errorMessage":"slow down"}}`, ""},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			collector := newTestCollector(t, tc.status, tc.body)
+			b := testBackend(t, map[string]string{"endpoint": collector.URL})
+
+			err := b.LogRequest(ctx, in)
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatal(err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	cases := []struct {
+		header string
+		ok     bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", false},
+		{"hmac-sha256:5f0cf3b8", false},
+	}
+
+	for _, tc := range cases {
+		if _, _, _, ok := parseTraceParent(tc.header); ok != tc.ok {
+			t.Errorf("%q: expected %t, got %t", tc.header, tc.ok, ok)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/audit"
+)
+
+// The types below are the JSON encoding of the OTLP logs service messages.
+// Only the fields used by the backend are included.
+
+type exportLogsServiceRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type exportLogsServiceResponse struct {
+	PartialSuccess *struct {
+		// RejectedLogRecords is an int64, which may be encoded as a string
+		RejectedLogRecords json.RawMessage `json:"rejectedLogRecords"`
+		ErrorMessage       string          `json:"errorMessage"`
+	} `json:"partialSuccess"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeLogs struct {
+	Scope      instrumentationScope `json:"scope"`
+	LogRecords []logRecord          `json:"logRecords"`
+}
+
+type instrumentationScope struct {
+	Name string `json:"name"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes"`
+	Flags                uint32     `json:"flags,omitempty"`
+	TraceID              string     `json:"traceId,omitempty"`
+	SpanID               string     `json:"spanId,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	ArrayValue  *arrayValue `json:"arrayValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []anyValue `json:"values"`
+}
+
+// Severity numbers of the OTel log data model.
+const (
+	severityInfo  = 9
+	severityError = 17
+)
+
+func stringValue(v string) anyValue {
+	return anyValue{StringValue: &v}
+}
+
+// attributes builds the attributes of a log record, skipping empty values.
+type attributes []keyValue
+
+func (a *attributes) string(key, value string) {
+	if value != "" {
+		*a = append(*a, keyValue{Key: key, Value: stringValue(value)})
+	}
+}
+
+func (a *attributes) int(key string, value int64) {
+	if value != 0 {
+		v := strconv.FormatInt(value, 10)
+		*a = append(*a, keyValue{Key: key, Value: anyValue{IntValue: &v}})
+	}
+}
+
+func (a *attributes) bool(key string, value bool) {
+	if value {
+		*a = append(*a, keyValue{Key: key, Value: anyValue{BoolValue: &value}})
+	}
+}
+
+func (a *attributes) strings(key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	array := &arrayValue{}
+	for _, v := range values {
+		array.Values = append(array.Values, stringValue(v))
+	}
+	*a = append(*a, keyValue{Key: key, Value: anyValue{ArrayValue: array}})
+}
+
+// newLogRecord maps a formatted audit entry to a log record. The entry
+// itself is the body of the record, and its main fields are copied to
+// attributes. The trace context is taken from the traceparent request
+// header, if it is audited without HMAC.
+func newLogRecord(entry []byte, observed time.Time) (logRecord, error) {
+	var e audit.AuditResponseEntry
+	if err := json.Unmarshal(entry, &e); err != nil {
+		return logRecord{}, err
+	}
+
+	timestamp := observed
+	if t, err := time.Parse(time.RFC3339Nano, e.Time); err == nil {
+		timestamp = t
+	}
+
+	record := logRecord{
+		TimeUnixNano:         strconv.FormatInt(timestamp.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(observed.UnixNano(), 10),
+		SeverityNumber:       severityInfo,
+		SeverityText:         "INFO",
+		Body:                 stringValue(string(entry)),
+	}
+	if e.Error != "" {
+		record.SeverityNumber = severityError
+		record.SeverityText = "ERROR"
+	}
+
+	var attrs attributes
+	attrs.string("vault.audit.type", e.Type)
+	attrs.string("vault.error", e.Error)
+	if r := e.Request; r != nil {
+		attrs.string("vault.request.id", r.ID)
+		attrs.string("vault.request.operation", string(r.Operation))
+		attrs.string("vault.request.path", r.Path)
+		attrs.string("vault.request.mount_type", r.MountType)
+		attrs.string("vault.request.client_token_accessor", r.ClientTokenAccessor)
+		attrs.string("vault.request.client_id", r.ClientID)
+		if r.Namespace != nil {
+			attrs.string("vault.namespace.id", r.Namespace.ID)
+			attrs.string("vault.namespace.path", r.Namespace.Path)
+		}
+		attrs.string("client.address", r.RemoteAddr)
+		attrs.int("client.port", int64(r.RemotePort))
+
+		if values := r.Headers["traceparent"]; len(values) > 0 {
+			if traceID, spanID, flags, ok := parseTraceParent(values[0]); ok {
+				record.TraceID, record.SpanID, record.Flags = traceID, spanID, flags
+			}
+		}
+	}
+	if a := e.Auth; a != nil {
+		attrs.string("vault.auth.entity_id", a.EntityID)
+		attrs.string("vault.auth.display_name", a.DisplayName)
+		attrs.string("vault.auth.accessor", a.Accessor)
+		attrs.string("vault.auth.token_type", a.TokenType)
+		attrs.strings("vault.auth.policies", a.Policies)
+	}
+	if r := e.Response; r != nil {
+		attrs.string("vault.response.mount_type", r.MountType)
+		attrs.bool("vault.response.wrapped", r.WrapInfo != nil)
+		if r.Secret != nil {
+			attrs.string("vault.response.lease_id", r.Secret.LeaseID)
+		}
+	}
+	record.Attributes = attrs
+
+	return record, nil
+}
+
+// parseTraceParent parses a W3C traceparent header, returning the trace ID,
+// span ID and trace flags in the encoding of OTLP/JSON.
+func parseTraceParent(header string) (traceID, spanID string, flags uint32, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", 0, false
+	}
+	// Version 00 has exactly four fields; later versions may add more
+	if parts[0] == "00" && len(parts) != 4 {
+		return "", "", 0, false
+	}
+
+	traceID, spanID = strings.ToLower(parts[1]), strings.ToLower(parts[2])
+	if !validTraceHex(traceID, 32) || !validTraceHex(spanID, 16) {
+		return "", "", 0, false
+	}
+	f, err := hex.DecodeString(parts[3])
+	if err != nil || len(f) != 1 {
+		return "", "", 0, false
+	}
+	return traceID, spanID, uint32(f[0]), true
+}
+
+// validTraceHex returns true if s is a hex-encoded ID of the given length
+// which is not all zeros.
+func validTraceHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	for _, c := range b {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
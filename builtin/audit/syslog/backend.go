@@ -39,6 +39,8 @@ func Factory(ctx context.Context, conf *audit.BackendConfig) (audit.Backend, err
 	}
 	switch format {
 	case "json", "jsonx":
+	case "cbor":
+		return nil, fmt.Errorf("format %q is not supported by the syslog audit device", format)
 	default:
 		return nil, fmt.Errorf("unknown format type %q", format)
 	}
@@ -0,0 +1,96 @@
+package webauthn
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	cache "github.com/patrickmn/go-cache"
+)
+
+// challengeTTL is how long a login challenge can be answered.
+const challengeTTL = 2 * time.Minute
+
+// Challenges are issued to unauthenticated clients, so the number of
+// outstanding ones is capped for each username and overall to bound the
+// memory they use.
+const (
+	maxUserChallenges = 5
+	maxChallenges     = 10000
+)
+
+func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(ctx, conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	b := backend{
+		challenges:     cache.New(challengeTTL, time.Minute),
+		userChallenges: make(map[string]int),
+	}
+	b.challenges.OnEvicted(b.challengeEvicted)
+	b.Backend = &framework.Backend{
+		Help: backendHelp,
+
+		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				"login",
+				"login/*",
+			},
+		},
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			pathUsers(&b),
+			pathUsersList(&b),
+			pathCredentials(&b),
+			pathCredentialsList(&b),
+			pathLoginChallenge(&b),
+			pathLogin(&b),
+		},
+
+		AuthRenew:   b.pathLoginRenew,
+		BackendType: logical.TypeCredential,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// challenges holds the outstanding login challenges, keyed by the
+	// challenge, with the username they were issued for as the value.
+	challenges *cache.Cache
+
+	// challengeLock makes issuing a challenge and claiming one atomic, so
+	// that a challenge can only be answered once.
+	challengeLock sync.Mutex
+
+	// userChallenges counts the outstanding challenges of each username. It
+	// is guarded by userChallengesLock rather than challengeLock, as it is
+	// updated when the cache evicts challenges.
+	userChallenges     map[string]int
+	userChallengesLock sync.Mutex
+
+	// userLock serializes updates of user entries, so that the signature
+	// counters of their credentials only move forward.
+	userLock sync.Mutex
+}
+
+const backendHelp = `
+The "webauthn" credential provider allows authentication with FIDO2
+security keys and passkeys using the Web Authentication (WebAuthn)
+assertion ceremony, without passwords or one-time codes.
+
+The relying party is configured at the "config" endpoint, and users
+and their registered credentials at the "users/" endpoints. A client
+first requests a challenge at "login/challenge", has the authenticator
+sign it, and then sends the assertion to "login".
+`
@@ -0,0 +1,452 @@
+package webauthn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// testAuthenticator is a software authenticator holding a single ECDSA
+// credential.
+type testAuthenticator struct {
+	id        []byte
+	key       *ecdsa.PrivateKey
+	hash      crypto.Hash
+	signCount uint32
+}
+
+// newTestAuthenticator returns an authenticator with an ES256 credential.
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+
+	return newTestAuthenticatorWithCurve(t, elliptic.P256(), crypto.SHA256)
+}
+
+func newTestAuthenticatorWithCurve(t *testing.T, curve elliptic.Curve, hash crypto.Hash) *testAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{id: []byte("credential-1"), key: key, hash: hash}
+}
+
+func (a *testAuthenticator) publicKeyPEM(t *testing.T) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(&a.key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func (a *testAuthenticator) assert(rpID string, clientDataHash []byte, flags byte) (*assertion, error) {
+	a.signCount++
+
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	authData := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(authData[33:], a.signCount)
+
+	h := a.hash.New()
+	h.Write(authData)
+	h.Write(clientDataHash)
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return &assertion{AuthenticatorData: authData, Signature: signature}, nil
+}
+
+func testBackend(t *testing.T) (*backend, logical.Storage) {
+	t.Helper()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.(*backend), config.StorageView
+}
+
+func testRequest(t *testing.T, b *backend, s logical.Storage, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      path,
+		Storage:   s,
+		Data:      data,
+	})
+	if err != nil && err != logical.ErrInvalidRequest {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func testSetup(t *testing.T, b *backend, s logical.Storage, a *testAuthenticator) {
+	t.Helper()
+
+	for path, data := range map[string]map[string]interface{}{
+		"config": {"rp_id": "example.com"},
+		"users/sally": {
+			"token_policies": "dev",
+		},
+	} {
+		if resp := testRequest(t, b, s, path, data); resp != nil && resp.IsError() {
+			t.Fatalf("bad: %#v", resp)
+		}
+	}
+	resp := testRequest(t, b, s, "users/sally/credentials/yubikey", map[string]interface{}{
+		"credential_id": base64.StdEncoding.EncodeToString(a.id),
+		"public_key":    a.publicKeyPEM(t),
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func testLogin(t *testing.T, b *backend, s logical.Storage, a *testAuthenticator, origin string, flags byte) *logical.Response {
+	t.Helper()
+
+	resp := testRequest(t, b, s, "login/challenge", map[string]interface{}{"username": "sally"})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	clientDataJSON, _ := json.Marshal(map[string]string{
+		"type":      "webauthn.get",
+		"challenge": resp.Data["challenge"].(string),
+		"origin":    origin,
+	})
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	result, err := a.assert(resp.Data["rp_id"].(string), clientDataHash[:], flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testRequest(t, b, s, "login", map[string]interface{}{
+		"username":           "sally",
+		"credential_id":      base64.RawURLEncoding.EncodeToString(a.id),
+		"client_data_json":   base64.RawURLEncoding.EncodeToString(clientDataJSON),
+		"authenticator_data": base64.RawURLEncoding.EncodeToString(result.AuthenticatorData),
+		"signature":          base64.RawURLEncoding.EncodeToString(result.Signature),
+	})
+}
+
+func TestBackend_login(t *testing.T) {
+	b, s := testBackend(t)
+	a := newTestAuthenticator(t)
+	testSetup(t, b, s, a)
+
+	resp := testLogin(t, b, s, a, "https://example.com", flagUserPresent)
+	if resp == nil || resp.IsError() || resp.Auth == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Auth.Alias.Name != "sally" || resp.Auth.Metadata["credential"] != "yubikey" {
+		t.Fatalf("bad auth: %#v", resp.Auth)
+	}
+	if len(resp.Auth.Policies) != 1 || resp.Auth.Policies[0] != "dev" {
+		t.Fatalf("bad policies: %#v", resp.Auth.Policies)
+	}
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "users/sally/credentials/yubikey",
+		Storage:   s,
+	})
+	if err != nil || resp == nil {
+		t.Fatalf("bad: %#v %v", resp, err)
+	}
+	if resp.Data["sign_count"].(uint32) != 1 {
+		t.Fatalf("expected the signature counter to be stored, got %v", resp.Data["sign_count"])
+	}
+}
+
+func TestBackend_loginErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		setup  func(*testAuthenticator)
+		origin string
+		flags  byte
+		err    string
+	}{
+		{
+			"origin",
+			nil,
+			"https://evil.example.com",
+			flagUserPresent,
+			"is not allowed",
+		},
+		{
+			"no_user_presence",
+			nil,
+			"https://example.com",
+			0,
+			"user presence",
+		},
+		{
+			"wrong_key",
+			func(a *testAuthenticator) {
+				a.key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			},
+			"https://example.com",
+			flagUserPresent,
+			"invalid signature",
+		},
+		{
+			"cloned",
+			func(a *testAuthenticator) {
+				a.signCount = 0
+			},
+			"https://example.com",
+			flagUserPresent,
+			"invalid signature counter",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			b, s := testBackend(t)
+			a := newTestAuthenticator(t)
+			testSetup(t, b, s, a)
+
+			// Log in once so that the signature counter is set.
+			if resp := testLogin(t, b, s, a, "https://example.com", flagUserPresent); resp.IsError() {
+				t.Fatalf("bad: %#v", resp)
+			}
+
+			if tc.setup != nil {
+				tc.setup(a)
+			}
+			resp := testLogin(t, b, s, a, tc.origin, tc.flags)
+			if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), tc.err) {
+				t.Fatalf("expected error containing %q, got %#v", tc.err, resp)
+			}
+		})
+	}
+}
+
+func TestBackend_challengeReplay(t *testing.T) {
+	b, s := testBackend(t)
+	a := newTestAuthenticator(t)
+	testSetup(t, b, s, a)
+
+	resp := testRequest(t, b, s, "login/challenge", map[string]interface{}{"username": "sally"})
+	clientDataJSON, _ := json.Marshal(map[string]string{
+		"type":      "webauthn.get",
+		"challenge": resp.Data["challenge"].(string),
+		"origin":    "https://example.com",
+	})
+	clientDataHash := sha256.Sum256(clientDataJSON)
+
+	for i, expectErr := range []bool{false, true} {
+		result, err := a.assert("example.com", clientDataHash[:], flagUserPresent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp := testRequest(t, b, s, "login", map[string]interface{}{
+			"username":           "sally",
+			"credential_id":      base64.RawURLEncoding.EncodeToString(a.id),
+			"client_data_json":   base64.RawURLEncoding.EncodeToString(clientDataJSON),
+			"authenticator_data": base64.RawURLEncoding.EncodeToString(result.AuthenticatorData),
+			"signature":          base64.RawURLEncoding.EncodeToString(result.Signature),
+		})
+		if resp.IsError() != expectErr {
+			t.Fatalf("attempt %d: bad: %#v", i, resp)
+		}
+	}
+}
+
+func TestBackend_loginCurves(t *testing.T) {
+	for name, a := range map[string]*testAuthenticator{
+		"P-384": newTestAuthenticatorWithCurve(t, elliptic.P384(), crypto.SHA384),
+		"P-521": newTestAuthenticatorWithCurve(t, elliptic.P521(), crypto.SHA512),
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, s := testBackend(t)
+			testSetup(t, b, s, a)
+
+			resp := testLogin(t, b, s, a, "https://example.com", flagUserPresent)
+			if resp == nil || resp.IsError() || resp.Auth == nil {
+				t.Fatalf("bad: %#v", resp)
+			}
+		})
+	}
+
+	// Curves without a COSE algorithm are rejected when registered
+	b, s := testBackend(t)
+	a := newTestAuthenticatorWithCurve(t, elliptic.P224(), crypto.SHA256)
+	testRequest(t, b, s, "users/sally", map[string]interface{}{"token_policies": "dev"})
+	resp := testRequest(t, b, s, "users/sally/credentials/yubikey", map[string]interface{}{
+		"credential_id": base64.StdEncoding.EncodeToString(a.id),
+		"public_key":    a.publicKeyPEM(t),
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error registering a P-224 key, got %#v", resp)
+	}
+}
+
+func TestBackend_challengeConcurrentClaim(t *testing.T) {
+	b, _ := testBackend(t)
+	if err := b.issueChallenge("challenge", "sally"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	claims := make(chan bool, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claims <- b.claimChallenge("challenge", "sally")
+		}()
+	}
+	wg.Wait()
+	close(claims)
+
+	claimed := 0
+	for ok := range claims {
+		if ok {
+			claimed++
+		}
+	}
+	if claimed != 1 {
+		t.Fatalf("expected the challenge to be claimed once, got %d", claimed)
+	}
+}
+
+func TestBackend_challengeLimits(t *testing.T) {
+	b, s := testBackend(t)
+	a := newTestAuthenticator(t)
+	testSetup(t, b, s, a)
+
+	var challenges []string
+	for i := 0; i < maxUserChallenges; i++ {
+		resp := testRequest(t, b, s, "login/challenge", map[string]interface{}{"username": "sally"})
+		if resp == nil || resp.IsError() {
+			t.Fatalf("bad: %#v", resp)
+		}
+		challenges = append(challenges, resp.Data["challenge"].(string))
+	}
+
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "login/challenge",
+		Storage:   s,
+		Data:      map[string]interface{}{"username": "sally"},
+	})
+	codedErr, ok := err.(logical.HTTPCodedError)
+	if !ok || codedErr.Code() != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429 error, got %v", err)
+	}
+
+	// Other users are not limited by the challenges of sally
+	if resp := testRequest(t, b, s, "login/challenge", map[string]interface{}{"username": "bob"}); resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Claiming a challenge allows another one
+	if !b.claimChallenge(challenges[0], "sally") {
+		t.Fatal("expected to claim the challenge")
+	}
+	if resp := testRequest(t, b, s, "login/challenge", map[string]interface{}{"username": "sally"}); resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestCLIHandler(t *testing.T) {
+	b, s := testBackend(t)
+	a := newTestAuthenticator(t)
+	testSetup(t, b, s, a)
+
+	// Serve the backend over HTTP so that the handler can use a real client.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&data)
+
+		resp := testRequest(t, b, s, strings.TrimPrefix(r.URL.Path, "/v1/auth/my-webauthn/"), data)
+		switch {
+		case resp.IsError():
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{resp.Error().Error()}})
+		case resp.Auth != nil:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"auth": map[string]interface{}{"client_token": "s.test", "metadata": resp.Auth.Metadata},
+			})
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": resp.Data})
+		}
+	}))
+	defer server.Close()
+
+	config := api.DefaultConfig()
+	config.Address = server.URL
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &CLIHandler{
+		DefaultMount: "webauthn",
+		assert: func(device, rpID string, credentialID, clientDataHash []byte, userVerification bool) (*assertion, error) {
+			if device != "/dev/test" || string(credentialID) != string(a.id) {
+				return nil, fmt.Errorf("unexpected device %q or credential %q", device, credentialID)
+			}
+			return a.assert(rpID, clientDataHash, flagUserPresent)
+		},
+	}
+	secret, err := h.Auth(client, map[string]string{
+		"username": "sally",
+		"mount":    "my-webauthn",
+		"device":   "/dev/test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Auth.ClientToken != "s.test" || secret.Auth.Metadata["credential"] != "yubikey" {
+		t.Fatalf("bad: %#v", secret.Auth)
+	}
+}
+
+func TestUnwrapCBORBytes(t *testing.T) {
+	long := make([]byte, 300)
+	cases := []struct {
+		name string
+		in   []byte
+		out  []byte
+	}{
+		{"short", []byte{0x43, 1, 2, 3}, []byte{1, 2, 3}},
+		{"one_byte_length", append([]byte{0x58, 37}, make([]byte, 37)...), make([]byte, 37)},
+		{"two_byte_length", append([]byte{0x59, 0x01, 0x2c}, long...), long},
+		{"raw", []byte{0x01, 0x02}, []byte{0x01, 0x02}},
+		{"bad_length", []byte{0x43, 1}, []byte{0x43, 1}},
+	}
+
+	for _, tc := range cases {
+		if got := unwrapCBORBytes(tc.in); string(got) != string(tc.out) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.out, got)
+		}
+	}
+}
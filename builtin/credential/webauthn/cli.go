@@ -0,0 +1,261 @@
+package webauthn
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/mapstructure"
+)
+
+// assertion is an assertion made by an authenticator.
+type assertion struct {
+	AuthenticatorData []byte
+	Signature         []byte
+}
+
+// assertFunc has the authenticator on device sign clientDataHash with the
+// credential with the given ID.
+type assertFunc func(device, rpID string, credentialID, clientDataHash []byte, userVerification bool) (*assertion, error)
+
+type CLIHandler struct {
+	DefaultMount string
+
+	// assert is used instead of fido2-assert in tests.
+	assert assertFunc
+}
+
+func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, error) {
+	var data struct {
+		Username         string `mapstructure:"username"`
+		Mount            string `mapstructure:"mount"`
+		Device           string `mapstructure:"device"`
+		Origin           string `mapstructure:"origin"`
+		UserVerification bool   `mapstructure:"user_verification"`
+	}
+	if err := mapstructure.WeakDecode(m, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Username == "" {
+		return nil, fmt.Errorf("'username' must be specified")
+	}
+	if data.Mount == "" {
+		data.Mount = h.DefaultMount
+	}
+	assert := h.assert
+	if assert == nil {
+		assert = fido2Assert
+	}
+
+	challenge, err := c.Logical().Write(fmt.Sprintf("auth/%s/login/challenge", data.Mount), map[string]interface{}{
+		"username": data.Username,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		return nil, fmt.Errorf("empty response from credential provider")
+	}
+
+	var ceremony struct {
+		Challenge        string   `mapstructure:"challenge"`
+		RPID             string   `mapstructure:"rp_id"`
+		CredentialIDs    []string `mapstructure:"credential_ids"`
+		UserVerification bool     `mapstructure:"user_verification"`
+	}
+	if err := mapstructure.WeakDecode(challenge.Data, &ceremony); err != nil {
+		return nil, err
+	}
+	if len(ceremony.CredentialIDs) == 0 {
+		return nil, fmt.Errorf("no credentials are registered for %q", data.Username)
+	}
+	if data.Origin == "" {
+		data.Origin = "https://" + ceremony.RPID
+	}
+
+	if data.Device == "" {
+		data.Device, err = fido2Device()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	clientDataJSON, err := json.Marshal(map[string]interface{}{
+		"type":        "webauthn.get",
+		"challenge":   ceremony.Challenge,
+		"origin":      data.Origin,
+		"crossOrigin": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+
+	fmt.Fprintf(os.Stderr, "Touch your security key to log in...\n")
+
+	// The assertion can only be made with a credential the authenticator
+	// holds, so try each registered credential in turn.
+	var credentialID []byte
+	var result *assertion
+	for _, id := range ceremony.CredentialIDs {
+		credentialID, err = decodeBase64(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential ID %q: %w", id, err)
+		}
+		result, err = assert(data.Device, ceremony.RPID, credentialID, clientDataHash[:], data.UserVerification || ceremony.UserVerification)
+		if err == nil {
+			break
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("error getting assertion from %s: %w", data.Device, err)
+	}
+
+	path := fmt.Sprintf("auth/%s/login", data.Mount)
+	secret, err := c.Logical().Write(path, map[string]interface{}{
+		"username":           data.Username,
+		"credential_id":      base64.RawURLEncoding.EncodeToString(credentialID),
+		"client_data_json":   base64.RawURLEncoding.EncodeToString(clientDataJSON),
+		"authenticator_data": base64.RawURLEncoding.EncodeToString(result.AuthenticatorData),
+		"signature":          base64.RawURLEncoding.EncodeToString(result.Signature),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("empty response from credential provider")
+	}
+
+	return secret, nil
+}
+
+// fido2Device returns the path of the first FIDO2 device libfido2 finds.
+func fido2Device() (string, error) {
+	out, err := exec.Command("fido2-token", "-L").Output()
+	if err != nil {
+		return "", fmt.Errorf("error listing FIDO2 devices with fido2-token, is libfido2 installed? %w", err)
+	}
+
+	// Each line is of the form "<path>: vendor=..., product=... (<name>)".
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if i := strings.Index(scanner.Text(), ": "); i > 0 {
+			return scanner.Text()[:i], nil
+		}
+	}
+	return "", errors.New("no FIDO2 device found")
+}
+
+// fido2Assert gets an assertion from the authenticator on device with the
+// fido2-assert tool of libfido2.
+func fido2Assert(device, rpID string, credentialID, clientDataHash []byte, userVerification bool) (*assertion, error) {
+	args := []string{"-G", "-p"}
+	if userVerification {
+		args = append(args, "-v")
+	}
+	args = append(args, device)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("fido2-assert", args...)
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("%s\n%s\n%s\n",
+		base64.StdEncoding.EncodeToString(clientDataHash),
+		rpID,
+		base64.StdEncoding.EncodeToString(credentialID)))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("fido2-assert: %s %w", strings.TrimSpace(stderr.String()), err)
+	}
+
+	// The output is the client data hash, the relying party ID, the
+	// authenticator data and the signature, one per line.
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) < 4 {
+		return nil, errors.New("unexpected output from fido2-assert")
+	}
+	authData, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding authenticator data: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding signature: %w", err)
+	}
+
+	return &assertion{
+		AuthenticatorData: unwrapCBORBytes(authData),
+		Signature:         signature,
+	}, nil
+}
+
+// unwrapCBORBytes returns the contents of b if it is a CBOR byte string, as
+// libfido2 returns the authenticator data, and b itself otherwise.
+func unwrapCBORBytes(b []byte) []byte {
+	if len(b) == 0 || b[0]>>5 != 2 {
+		return b
+	}
+
+	var n, header int
+	switch info := int(b[0] & 0x1f); {
+	case info < 24:
+		n, header = info, 1
+	case info == 24 && len(b) >= 2:
+		n, header = int(b[1]), 2
+	case info == 25 && len(b) >= 3:
+		n, header = int(b[1])<<8|int(b[2]), 3
+	default:
+		return b
+	}
+	if len(b) != header+n {
+		return b
+	}
+	return b[header:]
+}
+
+func (h *CLIHandler) Help() string {
+	help := `
+Usage: vault login -method=webauthn [CONFIG K=V...]
+
+  The webauthn auth method allows users to authenticate with a FIDO2 security
+  key registered for them, without a password.
+
+  The CLI performs the WebAuthn assertion ceremony with the fido2-assert and
+  fido2-token tools of libfido2, which must be installed. Platform passkeys
+  are not reachable from the CLI and can only be used through a WebAuthn
+  client such as a browser, which sends the assertion to the same login
+  endpoint.
+
+  Authenticate as "sally" with the first security key found:
+
+      $ vault login -method=webauthn username=sally
+      Touch your security key to log in...
+
+Configuration:
+
+  device=<string>
+      Path of the FIDO2 device to use, as listed by "fido2-token -L". Defaults
+      to the first device found.
+
+  origin=<string>
+      Origin to assert for. Defaults to "https://<rp_id>" of the auth method,
+      and must be one of its allowed origins.
+
+  user_verification=<bool>
+      Have the authenticator verify the user with a PIN in addition to
+      checking for presence. Always done if the auth method requires it.
+
+  username=<string>
+      Username to use for authentication.
+`
+
+	return strings.TrimSpace(help)
+}
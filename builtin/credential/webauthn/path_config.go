@@ -0,0 +1,135 @@
+package webauthn
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config",
+		Fields: map[string]*framework.FieldSchema{
+			"rp_id": {
+				Type: framework.TypeString,
+				Description: `The relying party ID credentials are scoped to,
+usually the domain name of the service, such as "example.com".`,
+				Required: true,
+			},
+			"allowed_origins": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `The origins assertions may be made for. Defaults to
+"https://<rp_id>".`,
+			},
+			"require_user_verification": {
+				Type: framework.TypeBool,
+				Description: `If set, the authenticator must verify the user,
+for example with a PIN or biometric, in addition to checking for presence.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathConfigWrite,
+			logical.ReadOperation:   b.pathConfigRead,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+type config struct {
+	RPID                    string   `json:"rp_id"`
+	AllowedOrigins          []string `json:"allowed_origins"`
+	RequireUserVerification bool     `json:"require_user_verification"`
+}
+
+// origins returns the origins assertions may be made for.
+func (c *config) origins() []string {
+	if len(c.AllowedOrigins) > 0 {
+		return c.AllowedOrigins
+	}
+	return []string{"https://" + c.RPID}
+}
+
+func (b *backend) config(ctx context.Context, s logical.Storage) (*config, error) {
+	entry, err := s.Get(ctx, "config")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result config
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, fmt.Errorf("error reading configuration: %w", err)
+	}
+	return &result, nil
+}
+
+func (b *backend) pathConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	c, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		c = &config{}
+	}
+
+	if rpIDRaw, ok := d.GetOk("rp_id"); ok {
+		c.RPID = rpIDRaw.(string)
+	}
+	if c.RPID == "" {
+		return logical.ErrorResponse("rp_id is a required parameter"), nil
+	}
+
+	if originsRaw, ok := d.GetOk("allowed_origins"); ok {
+		c.AllowedOrigins = originsRaw.([]string)
+	}
+	for _, origin := range c.AllowedOrigins {
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			return logical.ErrorResponse(fmt.Sprintf("invalid origin %q", origin)), nil
+		}
+	}
+
+	if uvRaw, ok := d.GetOk("require_user_verification"); ok {
+		c.RequireUserVerification = uvRaw.(bool)
+	}
+
+	entry, err := logical.StorageEntryJSON("config", c)
+	if err != nil {
+		return nil, err
+	}
+	return nil, req.Storage.Put(ctx, entry)
+}
+
+func (b *backend) pathConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	c, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"rp_id":                     c.RPID,
+			"allowed_origins":           c.origins(),
+			"require_user_verification": c.RequireUserVerification,
+		},
+	}, nil
+}
+
+const pathConfigHelpSyn = `
+Configure the WebAuthn relying party.
+`
+
+const pathConfigHelpDesc = `
+This endpoint configures the relying party ID and origins that assertions
+are verified against. It must be configured before users can log in.
+`
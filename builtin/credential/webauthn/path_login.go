@@ -0,0 +1,423 @@
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/cidrutil"
+	"github.com/hashicorp/vault/sdk/helper/policyutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	cache "github.com/patrickmn/go-cache"
+)
+
+// Flags of the authenticator data.
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+)
+
+func pathLoginChallenge(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "login/challenge",
+		Fields: map[string]*framework.FieldSchema{
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username of the user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathLoginChallenge,
+		},
+
+		HelpSynopsis:    pathLoginChallengeSyn,
+		HelpDescription: pathLoginChallengeDesc,
+	}
+}
+
+func pathLogin(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "login$",
+		Fields: map[string]*framework.FieldSchema{
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username of the user.",
+			},
+			"credential_id": {
+				Type:        framework.TypeString,
+				Description: "Base64 encoded ID of the credential used.",
+			},
+			"client_data_json": {
+				Type:        framework.TypeString,
+				Description: "Base64 encoded client data JSON the authenticator signed the hash of.",
+			},
+			"authenticator_data": {
+				Type:        framework.TypeString,
+				Description: "Base64 encoded authenticator data of the assertion.",
+			},
+			"signature": {
+				Type:        framework.TypeString,
+				Description: "Base64 encoded signature of the assertion.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation:         b.pathLogin,
+			logical.AliasLookaheadOperation: b.pathLoginAliasLookahead,
+		},
+
+		HelpSynopsis:    pathLoginSyn,
+		HelpDescription: pathLoginDesc,
+	}
+}
+
+// collectedClientData is the client data of an assertion.
+type collectedClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+func (b *backend) pathLoginChallenge(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := strings.ToLower(d.Get("username").(string))
+	if username == "" {
+		return logical.ErrorResponse("missing username"), nil
+	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("webauthn auth method is not configured"), nil
+	}
+
+	user, err := b.user(ctx, req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unknown users get a challenge too, so that the endpoint does not tell
+	// which users exist.
+	credentialIDs := []string{}
+	if user != nil {
+		for _, cred := range user.Credentials {
+			credentialIDs = append(credentialIDs, base64.RawURLEncoding.EncodeToString(cred.ID))
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	challenge := base64.RawURLEncoding.EncodeToString(raw)
+	if err := b.issueChallenge(challenge, username); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"challenge":         challenge,
+			"rp_id":             config.RPID,
+			"credential_ids":    credentialIDs,
+			"user_verification": config.RequireUserVerification,
+			"timeout_seconds":   int64(challengeTTL.Seconds()),
+		},
+	}, nil
+}
+
+// issueChallenge records challenge as issued to username, unless username or
+// all users already have as many outstanding challenges as allowed.
+func (b *backend) issueChallenge(challenge, username string) error {
+	b.challengeLock.Lock()
+	defer b.challengeLock.Unlock()
+	b.userChallengesLock.Lock()
+	defer b.userChallengesLock.Unlock()
+
+	if b.userChallenges[username] >= maxUserChallenges || b.challenges.ItemCount() >= maxChallenges {
+		return logical.CodedError(http.StatusTooManyRequests, "too many outstanding challenges, try again later")
+	}
+	b.challenges.Set(challenge, username, cache.DefaultExpiration)
+	b.userChallenges[username]++
+	return nil
+}
+
+// claimChallenge removes challenge and returns whether it was outstanding
+// for username. Challenges can only be answered once, so concurrent logins
+// with the same challenge cannot both claim it.
+func (b *backend) claimChallenge(challenge, username string) bool {
+	b.challengeLock.Lock()
+	defer b.challengeLock.Unlock()
+
+	challengeUser, ok := b.challenges.Get(challenge)
+	if !ok || challengeUser.(string) != username {
+		return false
+	}
+	b.challenges.Delete(challenge)
+	return true
+}
+
+// challengeEvicted is called when a challenge is claimed or expires.
+func (b *backend) challengeEvicted(_ string, username interface{}) {
+	b.userChallengesLock.Lock()
+	defer b.userChallengesLock.Unlock()
+
+	name := username.(string)
+	if b.userChallenges[name] <= 1 {
+		delete(b.userChallenges, name)
+		return
+	}
+	b.userChallenges[name]--
+}
+
+func (b *backend) pathLoginAliasLookahead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := strings.ToLower(d.Get("username").(string))
+	if username == "" {
+		return nil, fmt.Errorf("missing username")
+	}
+
+	return &logical.Response{
+		Auth: &logical.Auth{
+			Alias: &logical.Alias{
+				Name: username,
+			},
+		},
+	}, nil
+}
+
+func (b *backend) pathLogin(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := strings.ToLower(d.Get("username").(string))
+	if username == "" {
+		return logical.ErrorResponse("missing username"), nil
+	}
+
+	var credentialID, clientDataJSON, authData, signature []byte
+	for field, target := range map[string]*[]byte{
+		"credential_id":      &credentialID,
+		"client_data_json":   &clientDataJSON,
+		"authenticator_data": &authData,
+		"signature":          &signature,
+	} {
+		value, err := decodeBase64(d.Get(field).(string))
+		if err != nil || len(value) == 0 {
+			return logical.ErrorResponse(fmt.Sprintf("%s must be set to a base64 encoded value", field)), nil
+		}
+		*target = value
+	}
+
+	config, err := b.config(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return logical.ErrorResponse("webauthn auth method is not configured"), nil
+	}
+
+	var clientData collectedClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return logical.ErrorResponse("invalid client_data_json"), nil
+	}
+	if clientData.Type != "webauthn.get" {
+		return logical.ErrorResponse("client data is not of an assertion"), nil
+	}
+	if !strutil.StrListContains(config.origins(), clientData.Origin) {
+		return logical.ErrorResponse(fmt.Sprintf("origin %q is not allowed", clientData.Origin)), nil
+	}
+
+	if !b.claimChallenge(clientData.Challenge, username) {
+		return logical.ErrorResponse("unknown or expired challenge"), nil
+	}
+
+	b.userLock.Lock()
+	defer b.userLock.Unlock()
+
+	user, err := b.user(ctx, req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return logical.ErrorResponse("invalid username or credential"), nil
+	}
+	credName, cred := user.credential(credentialID)
+	if cred == nil {
+		return logical.ErrorResponse("invalid username or credential"), nil
+	}
+
+	signCount, err := verifyAssertion(config, cred, clientDataJSON, authData, signature)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// A counter that does not move forward means the authenticator may
+	// have been cloned. Authenticators without a counter always report 0.
+	if (signCount != 0 || cred.SignCount != 0) && signCount <= cred.SignCount {
+		b.Logger().Warn("signature counter did not increase, the authenticator may be cloned", "username", username, "credential", credName)
+		return logical.ErrorResponse("invalid signature counter"), nil
+	}
+	if signCount != cred.SignCount {
+		cred.SignCount = signCount
+		if err := b.setUser(ctx, req.Storage, username, user); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check for a CIDR match.
+	if len(user.TokenBoundCIDRs) > 0 {
+		if req.Connection == nil {
+			b.Logger().Warn("token bound CIDRs found but no connection information available for validation")
+			return nil, logical.ErrPermissionDenied
+		}
+		if !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, user.TokenBoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
+	auth := &logical.Auth{
+		Metadata: map[string]string{
+			"username":   username,
+			"credential": credName,
+		},
+		DisplayName: username,
+		Alias: &logical.Alias{
+			Name: username,
+		},
+	}
+	user.PopulateTokenAuth(auth)
+
+	return &logical.Response{
+		Auth: auth,
+	}, nil
+}
+
+// verifyAssertion checks the authenticator data and signature of an
+// assertion made with cred, and returns the signature counter of the
+// authenticator.
+func verifyAssertion(config *config, cred *Credential, clientDataJSON, authData, signature []byte) (uint32, error) {
+	// The authenticator data starts with the SHA-256 hash of the relying
+	// party ID, one byte of flags and the four byte signature counter.
+	if len(authData) < 37 {
+		return 0, errors.New("authenticator data is too short")
+	}
+	rpIDHash := sha256.Sum256([]byte(config.RPID))
+	if !bytes.Equal(authData[:32], rpIDHash[:]) {
+		return 0, errors.New("the assertion was made for another relying party")
+	}
+	flags := authData[32]
+	if flags&flagUserPresent == 0 {
+		return 0, errors.New("the authenticator did not check for user presence")
+	}
+	if config.RequireUserVerification && flags&flagUserVerified == 0 {
+		return 0, errors.New("the authenticator did not verify the user")
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	key, err := x509.ParsePKIXPublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing public key of the credential: %w", err)
+	}
+	var valid bool
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		hash, err := ecdsaHash(key)
+		if err != nil {
+			return 0, err
+		}
+		h := hash.New()
+		h.Write(signed)
+		valid = ecdsa.VerifyASN1(key, h.Sum(nil), signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, signed, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	if !valid {
+		return 0, errors.New("invalid signature")
+	}
+
+	return binary.BigEndian.Uint32(authData[33:37]), nil
+}
+
+// ecdsaHash returns the hash that ECDSA signatures are made with for the
+// curve of key, following the COSE algorithms ES256, ES384 and ES512.
+func ecdsaHash(key *ecdsa.PublicKey) (crypto.Hash, error) {
+	switch key.Curve {
+	case elliptic.P256():
+		return crypto.SHA256, nil
+	case elliptic.P384():
+		return crypto.SHA384, nil
+	case elliptic.P521():
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported curve %s", key.Curve.Params().Name)
+	}
+}
+
+func (b *backend) pathLoginRenew(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	// Get the user
+	user, err := b.user(ctx, req.Storage, req.Auth.Metadata["username"])
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		// User no longer exists, do not renew
+		return nil, nil
+	}
+	if _, ok := user.Credentials[req.Auth.Metadata["credential"]]; !ok {
+		// The credential has been removed, do not renew
+		return nil, nil
+	}
+
+	if !policyutil.EquivalentPolicies(user.TokenPolicies, req.Auth.TokenPolicies) {
+		return nil, fmt.Errorf("policies have changed, not renewing")
+	}
+
+	resp := &logical.Response{Auth: req.Auth}
+	resp.Auth.Period = user.TokenPeriod
+	resp.Auth.TTL = user.TokenTTL
+	resp.Auth.MaxTTL = user.TokenMaxTTL
+	return resp, nil
+}
+
+const pathLoginChallengeSyn = `
+Request a challenge to log in with.
+`
+
+const pathLoginChallengeDesc = `
+This endpoint returns a random challenge for the given user, along with
+the relying party ID and the IDs of the credentials registered for the
+user. The challenge must be signed by one of those credentials and sent to
+the "login" endpoint within two minutes, and can only be used once. A user
+can have at most five outstanding challenges; further requests fail with
+429 until challenges are used or expire.
+`
+
+const pathLoginSyn = `
+Log in with a WebAuthn assertion.
+`
+
+const pathLoginDesc = `
+This endpoint authenticates using a WebAuthn assertion over a challenge
+from the "login/challenge" endpoint. The client data JSON, authenticator
+data and signature are sent as returned by the authenticator.
+`
@@ -0,0 +1,387 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/tokenutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func pathUsersList(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "users/?",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathUserList,
+		},
+
+		HelpSynopsis:    pathUserHelpSyn,
+		HelpDescription: pathUserHelpDesc,
+	}
+}
+
+func pathUsers(b *backend) *framework.Path {
+	p := &framework.Path{
+		Pattern: "users/" + framework.GenericNameRegex("username"),
+		Fields: map[string]*framework.FieldSchema{
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username for this user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.DeleteOperation: b.pathUserDelete,
+			logical.ReadOperation:   b.pathUserRead,
+			logical.UpdateOperation: b.pathUserWrite,
+			logical.CreateOperation: b.pathUserWrite,
+		},
+
+		ExistenceCheck: b.userExistenceCheck,
+
+		HelpSynopsis:    pathUserHelpSyn,
+		HelpDescription: pathUserHelpDesc,
+	}
+
+	tokenutil.AddTokenFields(p.Fields)
+	return p
+}
+
+func pathCredentialsList(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "users/" + framework.GenericNameRegex("username") + "/credentials/?",
+		Fields: map[string]*framework.FieldSchema{
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username of the user.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathCredentialList,
+		},
+
+		HelpSynopsis:    pathCredentialHelpSyn,
+		HelpDescription: pathCredentialHelpDesc,
+	}
+}
+
+func pathCredentials(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "users/" + framework.GenericNameRegex("username") + "/credentials/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"username": {
+				Type:        framework.TypeString,
+				Description: "Username of the user.",
+			},
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the credential, such as the model of the security key.",
+			},
+			"credential_id": {
+				Type:        framework.TypeString,
+				Description: "Base64 encoded ID of the credential, as returned by the authenticator on registration.",
+			},
+			"public_key": {
+				Type:        framework.TypeString,
+				Description: "PEM encoded public key of the credential. ECDSA P-256, Ed25519 and RSA keys are supported.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.DeleteOperation: b.pathCredentialDelete,
+			logical.ReadOperation:   b.pathCredentialRead,
+			logical.UpdateOperation: b.pathCredentialWrite,
+		},
+
+		HelpSynopsis:    pathCredentialHelpSyn,
+		HelpDescription: pathCredentialHelpDesc,
+	}
+}
+
+// UserEntry is a user and the credentials registered for them.
+type UserEntry struct {
+	tokenutil.TokenParams
+
+	Credentials map[string]*Credential `json:"credentials"`
+}
+
+// Credential is a WebAuthn public key credential of a user.
+type Credential struct {
+	ID        []byte `json:"id"`
+	PublicKey []byte `json:"public_key"`
+
+	// SignCount is the last signature counter reported by the
+	// authenticator, used to detect cloned authenticators.
+	SignCount uint32 `json:"sign_count"`
+}
+
+// credential returns the credential of the user with the given ID.
+func (u *UserEntry) credential(id []byte) (string, *Credential) {
+	for name, cred := range u.Credentials {
+		if string(cred.ID) == string(id) {
+			return name, cred
+		}
+	}
+	return "", nil
+}
+
+func (b *backend) userExistenceCheck(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+	userEntry, err := b.user(ctx, req.Storage, d.Get("username").(string))
+	if err != nil {
+		return false, err
+	}
+
+	return userEntry != nil, nil
+}
+
+func (b *backend) user(ctx context.Context, s logical.Storage, username string) (*UserEntry, error) {
+	if username == "" {
+		return nil, fmt.Errorf("missing username")
+	}
+
+	entry, err := s.Get(ctx, "user/"+strings.ToLower(username))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result UserEntry
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) setUser(ctx context.Context, s logical.Storage, username string, userEntry *UserEntry) error {
+	entry, err := logical.StorageEntryJSON("user/"+strings.ToLower(username), userEntry)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+func (b *backend) pathUserList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	users, err := req.Storage.List(ctx, "user/")
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(users), nil
+}
+
+func (b *backend) pathUserDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	err := req.Storage.Delete(ctx, "user/"+strings.ToLower(d.Get("username").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathUserRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	user, err := b.user(ctx, req.Storage, d.Get("username").(string))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	data := map[string]interface{}{}
+	user.PopulateTokenData(data)
+
+	credentials := make([]string, 0, len(user.Credentials))
+	for name := range user.Credentials {
+		credentials = append(credentials, name)
+	}
+	sort.Strings(credentials)
+	data["credentials"] = credentials
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+func (b *backend) pathUserWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.userLock.Lock()
+	defer b.userLock.Unlock()
+
+	username := d.Get("username").(string)
+	userEntry, err := b.user(ctx, req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+	// Due to existence check, user will only be nil if it's a create operation
+	if userEntry == nil {
+		userEntry = &UserEntry{}
+	}
+
+	if err := userEntry.ParseTokenFields(req, d); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.setUser(ctx, req.Storage, username, userEntry)
+}
+
+func (b *backend) pathCredentialList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	user, err := b.user(ctx, req.Storage, d.Get("username").(string))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return logical.ListResponse(nil), nil
+	}
+
+	names := make([]string, 0, len(user.Credentials))
+	for name := range user.Credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return logical.ListResponse(names), nil
+}
+
+func (b *backend) pathCredentialRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	user, err := b.user(ctx, req.Storage, d.Get("username").(string))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+	cred, ok := user.Credentials[d.Get("name").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"credential_id": base64.RawURLEncoding.EncodeToString(cred.ID),
+			"public_key": string(pem.EncodeToMemory(&pem.Block{
+				Type:  "PUBLIC KEY",
+				Bytes: cred.PublicKey,
+			})),
+			"sign_count": cred.SignCount,
+		},
+	}, nil
+}
+
+func (b *backend) pathCredentialWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.userLock.Lock()
+	defer b.userLock.Unlock()
+
+	username := d.Get("username").(string)
+	user, err := b.user(ctx, req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return logical.ErrorResponse(fmt.Sprintf("user %q does not exist", username)), nil
+	}
+
+	id, err := decodeBase64(d.Get("credential_id").(string))
+	if err != nil || len(id) == 0 {
+		return logical.ErrorResponse("credential_id must be set to the base64 encoded credential ID"), nil
+	}
+	publicKey, err := parsePublicKey(d.Get("public_key").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	name := d.Get("name").(string)
+	if other, _ := user.credential(id); other != "" && other != name {
+		return logical.ErrorResponse(fmt.Sprintf("the credential is already registered as %q", other)), nil
+	}
+
+	if user.Credentials == nil {
+		user.Credentials = make(map[string]*Credential)
+	}
+	user.Credentials[name] = &Credential{
+		ID:        id,
+		PublicKey: publicKey,
+	}
+
+	return nil, b.setUser(ctx, req.Storage, username, user)
+}
+
+func (b *backend) pathCredentialDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.userLock.Lock()
+	defer b.userLock.Unlock()
+
+	username := d.Get("username").(string)
+	user, err := b.user(ctx, req.Storage, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	delete(user.Credentials, d.Get("name").(string))
+	return nil, b.setUser(ctx, req.Storage, username, user)
+}
+
+// parsePublicKey parses a PEM encoded public key and returns it in DER form.
+func parsePublicKey(s string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("public_key must be a PEM encoded public key")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public_key: %w", err)
+	}
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if _, err := ecdsaHash(key); err != nil {
+			return nil, fmt.Errorf("error parsing public_key: %w", err)
+		}
+	case ed25519.PublicKey, *rsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return block.Bytes, nil
+}
+
+// decodeBase64 decodes standard or URL-safe base64, with or without padding,
+// since authenticators and WebAuthn clients use both.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+const pathUserHelpSyn = `
+Manage users allowed to authenticate.
+`
+
+const pathUserHelpDesc = `
+This endpoint allows you to create, read, update, and delete users that
+are allowed to authenticate, and the token settings of their logins.
+Credentials are registered for a user at "users/<username>/credentials/".
+`
+
+const pathCredentialHelpSyn = `
+Manage the WebAuthn credentials of a user.
+`
+
+const pathCredentialHelpDesc = `
+This endpoint registers the public key credentials a user can log in
+with. The credential ID and public key are returned by the authenticator
+when the credential is created, for example by "fido2-cred -M" followed
+by "fido2-cred -V" for security keys.
+`
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -57,6 +58,7 @@ func Backend() *backend {
 		WALRollbackMinAge: minAwsUserRollbackAge,
 		BackendType:       logical.TypeLogical,
 	}
+	b.chainedClientSTS = b.nonCachedChainedClientSTS
 
 	return &b
 }
@@ -74,6 +76,10 @@ type backend struct {
 	// to enable mocking with AWS iface for tests
 	iamClient iamiface.IAMAPI
 	stsClient stsiface.STSAPI
+
+	// chainedClientSTS returns an sts client using the credentials of a role
+	// of a role chain, and can be replaced to mock it in tests
+	chainedClientSTS func(ctx context.Context, s logical.Storage, creds *sts.Credentials) (stsiface.STSAPI, error)
 }
 
 const backendHelp = `
@@ -156,3 +162,10 @@ func (b *backend) clientSTS(ctx context.Context, s logical.Storage) (stsiface.ST
 
 	return b.stsClient, nil
 }
+
+func (b *backend) nonCachedChainedClientSTS(ctx context.Context, s logical.Storage, creds *sts.Credentials) (stsiface.STSAPI, error) {
+	b.clientMutex.RLock()
+	defer b.clientMutex.RUnlock()
+
+	return nonCachedClientSTSWithCredentials(ctx, s, b.Logger(), creds)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/helper/testhelpers"
 	logicaltest "github.com/hashicorp/vault/helper/testhelpers/logical"
@@ -39,6 +40,32 @@ func (m *mockIAMClient) CreateUser(input *iam.CreateUserInput) (*iam.CreateUserO
 	return nil, awserr.New("Throttling", "", nil)
 }
 
+// mockSTSClient assumes roles with the credentials named by its access key,
+// recording each call.
+type mockSTSClient struct {
+	stsiface.STSAPI
+
+	accessKey string
+	calls     *[]string
+	inputs    *[]*sts.AssumeRoleInput
+}
+
+func (m *mockSTSClient) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	*m.calls = append(*m.calls, m.accessKey+" -> "+*input.RoleArn)
+	*m.inputs = append(*m.inputs, input)
+	return &sts.AssumeRoleOutput{
+		AssumedRoleUser: &sts.AssumedRoleUser{
+			Arn: aws.String(*input.RoleArn + "/" + *input.RoleSessionName),
+		},
+		Credentials: &sts.Credentials{
+			AccessKeyId:     aws.String(*input.RoleArn),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("token"),
+			Expiration:      aws.Time(time.Now().Add(time.Duration(*input.DurationSeconds) * time.Second)),
+		},
+	}, nil
+}
+
 func getBackend(t *testing.T) logical.Backend {
 	be, _ := Factory(context.Background(), logical.TestBackendConfig())
 	return be
@@ -194,6 +221,96 @@ func TestBackend_throttled(t *testing.T) {
 	}
 }
 
+func TestBackend_assumeRoleChain(t *testing.T) {
+	t.Parallel()
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		calls  []string
+		inputs []*sts.AssumeRoleInput
+	)
+	b.stsClient = &mockSTSClient{accessKey: "root", calls: &calls, inputs: &inputs}
+	b.chainedClientSTS = func(_ context.Context, _ logical.Storage, creds *sts.Credentials) (stsiface.STSAPI, error) {
+		return &mockSTSClient{accessKey: *creds.AccessKeyId, calls: &calls, inputs: &inputs}, nil
+	}
+
+	roleReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/chained",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"credential_type": assumedRoleCred,
+			"role_arns":       []string{"arn:aws:iam::333333333333:role/Target"},
+			"role_chain": []interface{}{
+				map[string]interface{}{
+					"role_arn":     "arn:aws:iam::111111111111:role/Jump",
+					"external_id":  "jump-ext",
+					"session_tags": map[string]interface{}{"team": "a"},
+				},
+				"arn:aws:iam::222222222222:role/Jump",
+			},
+			"external_id":  "target-ext",
+			"session_tags": []string{"project=vault", "env=prod"},
+		},
+	}
+	resp, err := b.HandleRequest(context.Background(), roleReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("failed to write role: resp:%#v err:%s", resp, err)
+	}
+
+	credReq := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sts/chained",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"ttl": "4h",
+		},
+	}
+	resp, err = b.HandleRequest(context.Background(), credReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("failed to assume role: resp:%#v err:%s", resp, err)
+	}
+
+	expectedCalls := []string{
+		"root -> arn:aws:iam::111111111111:role/Jump",
+		"arn:aws:iam::111111111111:role/Jump -> arn:aws:iam::222222222222:role/Jump",
+		"arn:aws:iam::222222222222:role/Jump -> arn:aws:iam::333333333333:role/Target",
+	}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Fatalf("expected calls %q, got %q", expectedCalls, calls)
+	}
+	if resp.Data["access_key"] != "arn:aws:iam::333333333333:role/Target" {
+		t.Fatalf("expected the credentials of the target role, got %#v", resp.Data)
+	}
+
+	// Chained sessions are limited to one hour
+	for _, input := range inputs {
+		if *input.DurationSeconds != 3600 {
+			t.Fatalf("expected a duration of 3600 seconds, got %d", *input.DurationSeconds)
+		}
+	}
+
+	if *inputs[0].ExternalId != "jump-ext" || len(inputs[0].Tags) != 1 || *inputs[0].Tags[0].Key != "team" {
+		t.Fatalf("unexpected input of the first role: %s", inputs[0])
+	}
+	if inputs[1].ExternalId != nil || inputs[1].Tags != nil {
+		t.Fatalf("unexpected input of the second role: %s", inputs[1])
+	}
+	expectedTags := []*sts.Tag{
+		{Key: aws.String("env"), Value: aws.String("prod")},
+		{Key: aws.String("project"), Value: aws.String("vault")},
+	}
+	if *inputs[2].ExternalId != "target-ext" || !reflect.DeepEqual(inputs[2].Tags, expectedTags) {
+		t.Fatalf("unexpected input of the target role: %s", inputs[2])
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	initSetup.Do(func() {
 		if v := os.Getenv("AWS_DEFAULT_REGION"); v == "" {
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
@@ -102,3 +103,27 @@ func nonCachedClientSTS(ctx context.Context, s logical.Storage, logger hclog.Log
 	}
 	return client, nil
 }
+
+// nonCachedClientSTSWithCredentials returns an sts client using the given
+// temporary credentials instead of the configured ones, e.g. to assume the
+// next role of a role chain.
+func nonCachedClientSTSWithCredentials(ctx context.Context, s logical.Storage, logger hclog.Logger, creds *sts.Credentials) (*sts.STS, error) {
+	awsConfig, err := getRootConfig(ctx, s, "sts", logger)
+	if err != nil {
+		return nil, err
+	}
+	awsConfig.Credentials = credentials.NewStaticCredentials(
+		aws.StringValue(creds.AccessKeyId),
+		aws.StringValue(creds.SecretAccessKey),
+		aws.StringValue(creds.SessionToken),
+	)
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+	client := sts.New(sess)
+	if client == nil {
+		return nil, fmt.Errorf("could not obtain sts client")
+	}
+	return client, nil
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
 )
 
 var userPathRegex = regexp.MustCompile(`^\/([\x21-\x7F]{0,510}\/)?$`)
@@ -58,6 +59,35 @@ func pathRoles(b *backend) *framework.Path {
 				},
 			},
 
+			"role_chain": {
+				Type: framework.TypeSlice,
+				Description: `Roles assumed in order before the role given by role_arns, each as an object
+with a role_arn and optional external_id and session_tags. Each role is assumed
+with the credentials of the previous one. Only valid when credential_type is
+` + assumedRoleCred,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "Role Chain",
+				},
+			},
+
+			"external_id": {
+				Type:        framework.TypeString,
+				Description: "External ID passed when assuming the role given by role_arns. Only valid when credential_type is " + assumedRoleCred,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name: "External ID",
+				},
+			},
+
+			"session_tags": {
+				Type: framework.TypeKVPairs,
+				Description: `Session tags passed when assuming the role given by role_arns. These must be
+presented as Key-Value pairs. Only valid when credential_type is ` + assumedRoleCred,
+				DisplayAttrs: &framework.DisplayAttributes{
+					Name:  "Session Tags",
+					Value: "[key1=value1, key2=value2]",
+				},
+			},
+
 			"policy_arns": {
 				Type: framework.TypeCommaStringSlice,
 				Description: fmt.Sprintf(`ARNs of AWS policies. Behavior varies by credential_type. When credential_type is
@@ -256,6 +286,31 @@ func (b *backend) pathRolesWrite(ctx context.Context, req *logical.Request, d *f
 		roleEntry.RoleArns = roleArnsRaw.([]string)
 	}
 
+	if roleChainRaw, ok := d.GetOk("role_chain"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with role_chain"), nil
+		}
+		roleChain, err := parseRoleChain(roleChainRaw.([]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("cannot parse role_chain: %s", err)), nil
+		}
+		roleEntry.RoleChain = roleChain
+	}
+
+	if externalIDRaw, ok := d.GetOk("external_id"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with external_id"), nil
+		}
+		roleEntry.ExternalID = externalIDRaw.(string)
+	}
+
+	if sessionTagsRaw, ok := d.GetOk("session_tags"); ok {
+		if legacyRole != "" {
+			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with session_tags"), nil
+		}
+		roleEntry.SessionTags = sessionTagsRaw.(map[string]string)
+	}
+
 	if policyArnsRaw, ok := d.GetOk("policy_arns"); ok {
 		if legacyRole != "" {
 			return logical.ErrorResponse("cannot supply deprecated role or policy parameters with policy_arns"), nil
@@ -473,6 +528,41 @@ func validateAWSManagedPolicy(policyARN string) error {
 	return nil
 }
 
+// parseRoleChain parses the hops of the role_chain parameter, given either as
+// objects or as role ARNs.
+func parseRoleChain(raw []interface{}) ([]awsRoleChainHop, error) {
+	roleChain := make([]awsRoleChainHop, 0, len(raw))
+	for i, hopRaw := range raw {
+		var hop awsRoleChainHop
+		switch hopRaw := hopRaw.(type) {
+		case string:
+			hop.RoleArn = hopRaw
+		case map[string]interface{}:
+			if err := mapstructure.WeakDecode(hopRaw, &hop); err != nil {
+				return nil, fmt.Errorf("invalid role %d: %w", i, err)
+			}
+		default:
+			return nil, fmt.Errorf("invalid role %d: expected an object or a role ARN", i)
+		}
+		roleChain = append(roleChain, hop)
+	}
+	return roleChain, nil
+}
+
+func validateRoleARN(roleARN string) error {
+	parsedARN, err := arn.Parse(roleARN)
+	if err != nil {
+		return err
+	}
+	if parsedARN.Service != "iam" {
+		return fmt.Errorf("expected a service of iam but got %s", parsedARN.Service)
+	}
+	if !strings.HasPrefix(parsedARN.Resource, "role/") {
+		return fmt.Errorf("expected a resource type of role but got %s", parsedARN.Resource)
+	}
+	return nil
+}
+
 func setAwsRole(ctx context.Context, s logical.Storage, roleName string, roleEntry *awsRoleEntry) error {
 	if roleName == "" {
 		return fmt.Errorf("empty role name")
@@ -507,6 +597,17 @@ type awsRoleEntry struct {
 	MaxSTSTTL                time.Duration     `json:"max_sts_ttl"`                           // Max allowed TTL for STS credentials
 	UserPath                 string            `json:"user_path"`                             // The path for the IAM user when using "iam_user" credential type
 	PermissionsBoundaryARN   string            `json:"permissions_boundary_arn"`              // ARN of an IAM policy to attach as a permissions boundary
+	RoleChain                []awsRoleChainHop `json:"role_chain,omitempty"`                  // Roles assumed in order before the role of the AssumedRole credentials
+	ExternalID               string            `json:"external_id,omitempty"`                 // External ID passed when assuming the role of the AssumedRole credentials
+	SessionTags              map[string]string `json:"session_tags,omitempty"`                // Session tags passed when assuming the role of the AssumedRole credentials
+}
+
+// awsRoleChainHop is a role assumed before the role of AssumedRole
+// credentials.
+type awsRoleChainHop struct {
+	RoleArn     string            `json:"role_arn" mapstructure:"role_arn"`
+	ExternalID  string            `json:"external_id,omitempty" mapstructure:"external_id"`
+	SessionTags map[string]string `json:"session_tags,omitempty" mapstructure:"session_tags"`
 }
 
 func (r *awsRoleEntry) toResponseData() map[string]interface{} {
@@ -521,8 +622,20 @@ func (r *awsRoleEntry) toResponseData() map[string]interface{} {
 		"max_sts_ttl":              int64(r.MaxSTSTTL.Seconds()),
 		"user_path":                r.UserPath,
 		"permissions_boundary_arn": r.PermissionsBoundaryARN,
+		"external_id":              r.ExternalID,
+		"session_tags":             r.SessionTags,
 	}
 
+	roleChain := make([]map[string]interface{}, 0, len(r.RoleChain))
+	for _, hop := range r.RoleChain {
+		roleChain = append(roleChain, map[string]interface{}{
+			"role_arn":     hop.RoleArn,
+			"external_id":  hop.ExternalID,
+			"session_tags": hop.SessionTags,
+		})
+	}
+	respData["role_chain"] = roleChain
+
 	if r.InvalidData != "" {
 		respData["invalid_data"] = r.InvalidData
 	}
@@ -579,6 +692,21 @@ func (r *awsRoleEntry) validate() error {
 		errors = multierror.Append(errors, fmt.Errorf("cannot supply role_arns when credential_type isn't %s", assumedRoleCred))
 	}
 
+	if (len(r.RoleChain) > 0 || r.ExternalID != "" || len(r.SessionTags) > 0) && !strutil.StrListContains(r.CredentialTypes, assumedRoleCred) {
+		errors = multierror.Append(errors, fmt.Errorf("cannot supply role_chain, external_id or session_tags when credential_type isn't %s", assumedRoleCred))
+	}
+
+	for i, hop := range r.RoleChain {
+		if err := validateRoleARN(hop.RoleArn); err != nil {
+			errors = multierror.Append(errors, fmt.Errorf("invalid role_arn of role %d of role_chain: %v", i, err))
+		}
+	}
+
+	// AWS limits sessions of chained roles to one hour
+	if len(r.RoleChain) > 0 && (r.DefaultSTSTTL > maxRoleChainTTL || r.MaxSTSTTL > maxRoleChainTTL) {
+		errors = multierror.Append(errors, fmt.Errorf("default_sts_ttl and max_sts_ttl cannot exceed %s with role_chain", maxRoleChainTTL))
+	}
+
 	return errors.ErrorOrNil()
 }
 
@@ -594,6 +722,10 @@ const (
 	federationTokenCred = "federation_token"
 )
 
+// maxRoleChainTTL is the longest session AWS allows for a role assumed with
+// the credentials of another role.
+const maxRoleChainTTL = time.Hour
+
 const pathListRolesHelpSyn = `List the existing roles in this backend`
 
 const pathListRolesHelpDesc = `Roles will be listed by the role name.`
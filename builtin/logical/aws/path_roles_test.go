@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -446,3 +447,114 @@ func TestRoleEntryValidationFederationTokenCred(t *testing.T) {
 		t.Errorf("bad: invalid roleEntry with unrecognized PermissionsBoundary %#v passed validation", roleEntry)
 	}
 }
+
+func TestRoleEntryValidationRoleChain(t *testing.T) {
+	roleEntry := awsRoleEntry{
+		CredentialTypes: []string{assumedRoleCred},
+		RoleArns:        []string{"arn:aws:iam::123456789012:role/SomeRole"},
+		RoleChain: []awsRoleChainHop{
+			{RoleArn: "arn:aws:iam::123456789012:role/Jump"},
+			{RoleArn: "arn:aws:iam::210987654321:role/Jump", ExternalID: "ext", SessionTags: map[string]string{"team": "a"}},
+		},
+		ExternalID:    "ext",
+		SessionTags:   map[string]string{"team": "a"},
+		DefaultSTSTTL: 15 * time.Minute,
+		MaxSTSTTL:     time.Hour,
+	}
+	if err := roleEntry.validate(); err != nil {
+		t.Errorf("bad: valid roleEntry %#v failed validation: %v", roleEntry, err)
+	}
+
+	roleEntry.MaxSTSTTL = 2 * time.Hour
+	if roleEntry.validate() == nil {
+		t.Errorf("bad: invalid roleEntry with MaxSTSTTL above the role chain limit %#v passed validation", roleEntry)
+	}
+	roleEntry.MaxSTSTTL = 0
+	roleEntry.RoleChain[1].RoleArn = adminAccessPolicyARN
+	if roleEntry.validate() == nil {
+		t.Errorf("bad: invalid roleEntry with a policy in its role chain %#v passed validation", roleEntry)
+	}
+	roleEntry.RoleChain[1].RoleArn = ""
+	if roleEntry.validate() == nil {
+		t.Errorf("bad: invalid roleEntry with an empty role in its role chain %#v passed validation", roleEntry)
+	}
+	roleEntry.RoleChain[1].RoleArn = "arn:aws:iam::210987654321:role/Jump"
+	roleEntry.CredentialTypes = []string{federationTokenCred}
+	roleEntry.RoleArns = nil
+	if roleEntry.validate() == nil {
+		t.Errorf("bad: invalid roleEntry with role_chain and credential type %s %#v passed validation", federationTokenCred, roleEntry)
+	}
+}
+
+func TestRoleCRUDWithRoleChain(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+
+	roleData := map[string]interface{}{
+		"credential_type": assumedRoleCred,
+		"role_arns":       []string{"arn:aws:iam::123456789012:role/Target"},
+		"role_chain": []interface{}{
+			"arn:aws:iam::123456789012:role/Jump",
+			map[string]interface{}{
+				"role_arn":     "arn:aws:iam::210987654321:role/Jump",
+				"external_id":  "ext",
+				"session_tags": map[string]interface{}{"team": "a"},
+			},
+		},
+		"external_id":  "target-ext",
+		"session_tags": []string{"project=vault"},
+	}
+	request := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/test",
+		Storage:   config.StorageView,
+		Data:      roleData,
+	}
+	resp, err := b.HandleRequest(context.Background(), request)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: role creation failed. resp:%#v\nerr:%v", resp, err)
+	}
+
+	request.Operation = logical.ReadOperation
+	request.Data = nil
+	resp, err = b.HandleRequest(context.Background(), request)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: reading role failed. resp:%#v\nerr:%v", resp, err)
+	}
+
+	expectedChain := []map[string]interface{}{
+		{
+			"role_arn":     "arn:aws:iam::123456789012:role/Jump",
+			"external_id":  "",
+			"session_tags": map[string]string(nil),
+		},
+		{
+			"role_arn":     "arn:aws:iam::210987654321:role/Jump",
+			"external_id":  "ext",
+			"session_tags": map[string]string{"team": "a"},
+		},
+	}
+	if !reflect.DeepEqual(resp.Data["role_chain"], expectedChain) {
+		t.Errorf("bad: expected role_chain %#v, got %#v", expectedChain, resp.Data["role_chain"])
+	}
+	if resp.Data["external_id"] != "target-ext" {
+		t.Errorf("bad: expected external_id %q, got %#v", "target-ext", resp.Data["external_id"])
+	}
+	if !reflect.DeepEqual(resp.Data["session_tags"], map[string]string{"project": "vault"}) {
+		t.Errorf("bad: unexpected session_tags %#v", resp.Data["session_tags"])
+	}
+
+	request.Operation = logical.UpdateOperation
+	request.Data = map[string]interface{}{
+		"role_chain": []interface{}{"arn:aws:iam::123456789012:policy/NotARole"},
+	}
+	resp, err = b.HandleRequest(context.Background(), request)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("bad: expected an error writing a role chain with a policy ARN. resp:%#v\nerr:%v", resp, err)
+	}
+}
@@ -83,6 +83,10 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 		ttl = maxTTL
 	}
 
+	if len(role.RoleChain) > 0 && ttl > int64(maxRoleChainTTL.Seconds()) {
+		ttl = int64(maxRoleChainTTL.Seconds())
+	}
+
 	roleArn := d.Get("role_arn").(string)
 	roleSessionName := d.Get("role_session_name").(string)
 
@@ -130,7 +134,11 @@ func (b *backend) pathCredsRead(ctx context.Context, req *logical.Request, d *fr
 		case !strutil.StrListContains(role.RoleArns, roleArn):
 			return logical.ErrorResponse(fmt.Sprintf("role_arn %q not in allowed role arns for Vault role %q", roleArn, roleName)), nil
 		}
-		return b.assumeRole(ctx, req.Storage, req.DisplayName, roleName, roleArn, role.PolicyDocument, role.PolicyArns, role.IAMGroups, ttl, roleSessionName)
+		return b.assumeRole(ctx, req.Storage, req.DisplayName, roleName, role.RoleChain, awsRoleChainHop{
+			RoleArn:     roleArn,
+			ExternalID:  role.ExternalID,
+			SessionTags: role.SessionTags,
+		}, role.PolicyDocument, role.PolicyArns, role.IAMGroups, ttl, roleSessionName)
 	case federationTokenCred:
 		return b.getFederationToken(ctx, req.Storage, req.DisplayName, roleName, role.PolicyDocument, role.PolicyArns, role.IAMGroups, ttl)
 	default:
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/awsutil"
@@ -14,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/hashicorp/errwrap"
 )
 
@@ -174,9 +176,12 @@ func (b *backend) getFederationToken(ctx context.Context, s logical.Storage,
 	return resp, nil
 }
 
+// assumeRole assumes the role of target, first assuming each role of
+// roleChain in order with the credentials of the previous one.
 func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
-	displayName, roleName, roleArn, policy string, policyARNs []string,
-	iamGroups []string, lifeTimeInSeconds int64, roleSessionName string) (*logical.Response, error,
+	displayName, roleName string, roleChain []awsRoleChainHop, target awsRoleChainHop,
+	policy string, policyARNs []string, iamGroups []string, lifeTimeInSeconds int64,
+	roleSessionName string) (*logical.Response, error,
 ) {
 	// grab any IAM group policies associated with the vault role, both inline
 	// and managed
@@ -195,7 +200,8 @@ func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
 		policyARNs = append(policyARNs, groupPolicyARNs...)
 	}
 
-	stsClient, err := b.clientSTS(ctx, s)
+	var stsClient stsiface.STSAPI
+	stsClient, err = b.clientSTS(ctx, s)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), nil
 	}
@@ -222,11 +228,29 @@ func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
 		roleSessionName = normalizeDisplayName(roleSessionName)
 	}
 
+	for _, hop := range roleChain {
+		hopInput := &sts.AssumeRoleInput{
+			RoleSessionName: aws.String(roleSessionName),
+			RoleArn:         aws.String(hop.RoleArn),
+			DurationSeconds: &lifeTimeInSeconds,
+		}
+		setAssumeRoleHopInput(hopInput, hop)
+		hopResp, err := stsClient.AssumeRole(hopInput)
+		if err != nil {
+			return logical.ErrorResponse("Error assuming role %q of the role chain: %s", hop.RoleArn, err), awsutil.CheckAWSError(err)
+		}
+		stsClient, err = b.chainedClientSTS(ctx, s, hopResp.Credentials)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
 	assumeRoleInput := &sts.AssumeRoleInput{
 		RoleSessionName: aws.String(roleSessionName),
-		RoleArn:         aws.String(roleArn),
+		RoleArn:         aws.String(target.RoleArn),
 		DurationSeconds: &lifeTimeInSeconds,
 	}
+	setAssumeRoleHopInput(assumeRoleInput, target)
 	if policy != "" {
 		assumeRoleInput.SetPolicy(policy)
 	}
@@ -245,7 +269,7 @@ func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
 		"arn":            *tokenResp.AssumedRoleUser.Arn,
 	}, map[string]interface{}{
 		"username": roleSessionName,
-		"policy":   roleArn,
+		"policy":   target.RoleArn,
 		"is_sts":   true,
 	})
 
@@ -258,6 +282,30 @@ func (b *backend) assumeRole(ctx context.Context, s logical.Storage,
 	return resp, nil
 }
 
+// setAssumeRoleHopInput sets the external ID and the session tags of hop on
+// the input of its AssumeRole call.
+func setAssumeRoleHopInput(input *sts.AssumeRoleInput, hop awsRoleChainHop) {
+	if hop.ExternalID != "" {
+		input.SetExternalId(hop.ExternalID)
+	}
+	if len(hop.SessionTags) > 0 {
+		keys := make([]string, 0, len(hop.SessionTags))
+		for key := range hop.SessionTags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		tags := make([]*sts.Tag, 0, len(keys))
+		for _, key := range keys {
+			tags = append(tags, &sts.Tag{
+				Key:   aws.String(key),
+				Value: aws.String(hop.SessionTags[key]),
+			})
+		}
+		input.SetTags(tags)
+	}
+}
+
 func readConfig(ctx context.Context, storage logical.Storage) (rootConfig, error) {
 	entry, err := storage.Get(ctx, storageKey)
 	if err != nil {
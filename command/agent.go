@@ -223,7 +223,7 @@ func (c *AgentCommand) Run(args []string) int {
 	// Load the configuration
 	config, err := agentConfig.LoadConfig(c.flagConfigs[0])
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfigs[0], err))
+		outputError(c.UI, fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfigs[0], err), err)
 		return 1
 	}
 
@@ -353,9 +353,10 @@ func (c *AgentCommand) Run(args []string) int {
 		DisplayName: "Vault",
 		UserAgent:   useragent.String(),
 		ClusterName: config.ClusterName,
+		Logger:      c.logger.Named("telemetry"),
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error initializing telemetry: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error initializing telemetry: %s", err), err)
 		return 1
 	}
 	c.metricsHelper = metricsutil.NewMetricsHelper(inmemMetrics, prometheusEnabled)
@@ -498,20 +499,21 @@ func (c *AgentCommand) Run(args []string) int {
 			WhenInconsistentAction: whenInconsistent,
 		})
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error creating API proxy: %v", err))
+			outputError(c.UI, fmt.Sprintf("Error creating API proxy: %v", err), err)
 			return 1
 		}
 
 		// Create the lease cache proxier and set its underlying proxier to
 		// the API proxier.
 		leaseCache, err = cache.NewLeaseCache(&cache.LeaseCacheConfig{
-			Client:      client,
-			BaseContext: ctx,
-			Proxier:     apiProxy,
-			Logger:      cacheLogger.Named("leasecache"),
+			Client:          client,
+			BaseContext:     ctx,
+			Proxier:         apiProxy,
+			Logger:          cacheLogger.Named("leasecache"),
+			StaticSecretTTL: config.Cache.StaticSecretTTL,
 		})
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error creating lease cache: %v", err))
+			outputError(c.UI, fmt.Sprintf("Error creating lease cache: %v", err), err)
 			return 1
 		}
 
@@ -528,7 +530,7 @@ func (c *AgentCommand) Run(args []string) int {
 			case "kubernetes":
 				aad, err = getServiceAccountJWT(config.Cache.Persist.ServiceAccountTokenFile)
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("failed to read service account token from %s: %s", config.Cache.Persist.ServiceAccountTokenFile, err))
+					outputError(c.UI, fmt.Sprintf("failed to read service account token from %s: %s", config.Cache.Persist.ServiceAccountTokenFile, err), err)
 					return 1
 				}
 			default:
@@ -539,7 +541,7 @@ func (c *AgentCommand) Run(args []string) int {
 			// Check if bolt file exists already
 			dbFileExists, err := cacheboltdb.DBFileExists(config.Cache.Persist.Path)
 			if err != nil {
-				c.UI.Error(fmt.Sprintf("failed to check if bolt file exists at path %s: %s", config.Cache.Persist.Path, err))
+				outputError(c.UI, fmt.Sprintf("failed to check if bolt file exists at path %s: %s", config.Cache.Persist.Path, err), err)
 				return 1
 			}
 			if dbFileExists {
@@ -549,7 +551,7 @@ func (c *AgentCommand) Run(args []string) int {
 					Logger: cacheLogger.Named("cacheboltdb"),
 				})
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error opening persistent cache: %v", err))
+					outputError(c.UI, fmt.Sprintf("Error opening persistent cache: %v", err), err)
 					return 1
 				}
 
@@ -557,7 +559,7 @@ func (c *AgentCommand) Run(args []string) int {
 				// then setup encryption so that restore is possible
 				token, err := ps.GetRetrievalToken()
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error getting retrieval token from persistent cache: %v", err))
+					outputError(c.UI, fmt.Sprintf("Error getting retrieval token from persistent cache: %v", err), err)
 				}
 
 				if err := ps.Close(); err != nil {
@@ -566,7 +568,7 @@ func (c *AgentCommand) Run(args []string) int {
 
 				km, err := keymanager.NewPassthroughKeyManager(token)
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("failed to configure persistence encryption for cache: %s", err))
+					outputError(c.UI, fmt.Sprintf("failed to configure persistence encryption for cache: %s", err), err)
 					return 1
 				}
 
@@ -578,13 +580,13 @@ func (c *AgentCommand) Run(args []string) int {
 					AAD:     aad,
 				})
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error opening persistent cache with wrapper: %v", err))
+					outputError(c.UI, fmt.Sprintf("Error opening persistent cache with wrapper: %v", err), err)
 					return 1
 				}
 
 				// Restore anything in the persistent cache to the memory cache
 				if err := leaseCache.Restore(ctx, ps); err != nil {
-					c.UI.Error(fmt.Sprintf("Error restoring in-memory cache from persisted file: %v", err))
+					outputError(c.UI, fmt.Sprintf("Error restoring in-memory cache from persisted file: %v", err), err)
 					if config.Cache.Persist.ExitOnErr {
 						return 1
 					}
@@ -594,7 +596,7 @@ func (c *AgentCommand) Run(args []string) int {
 				// Check for previous auto-auth token
 				oldTokenBytes, err := ps.GetAutoAuthToken(ctx)
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error in fetching previous auto-auth token: %s", err))
+					outputError(c.UI, fmt.Sprintf("Error in fetching previous auto-auth token: %s", err), err)
 					if config.Cache.Persist.ExitOnErr {
 						return 1
 					}
@@ -602,7 +604,7 @@ func (c *AgentCommand) Run(args []string) int {
 				if len(oldTokenBytes) > 0 {
 					oldToken, err := cachememdb.Deserialize(oldTokenBytes)
 					if err != nil {
-						c.UI.Error(fmt.Sprintf("Error in deserializing previous auto-auth token cache entry: %s", err))
+						outputError(c.UI, fmt.Sprintf("Error in deserializing previous auto-auth token cache entry: %s", err), err)
 						if config.Cache.Persist.ExitOnErr {
 							return 1
 						}
@@ -621,7 +623,7 @@ func (c *AgentCommand) Run(args []string) int {
 					}
 					dbFile := filepath.Join(config.Cache.Persist.Path, cacheboltdb.DatabaseFileName)
 					if err := os.Remove(dbFile); err != nil {
-						c.UI.Error(fmt.Sprintf("failed to remove persistent storage file %s: %s", dbFile, err))
+						outputError(c.UI, fmt.Sprintf("failed to remove persistent storage file %s: %s", dbFile, err), err)
 						if config.Cache.Persist.ExitOnErr {
 							return 1
 						}
@@ -630,7 +632,7 @@ func (c *AgentCommand) Run(args []string) int {
 			} else {
 				km, err := keymanager.NewPassthroughKeyManager(nil)
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("failed to configure persistence encryption for cache: %s", err))
+					outputError(c.UI, fmt.Sprintf("failed to configure persistence encryption for cache: %s", err), err)
 					return 1
 				}
 				ps, err := cacheboltdb.NewBoltStorage(&cacheboltdb.BoltStorageConfig{
@@ -640,7 +642,7 @@ func (c *AgentCommand) Run(args []string) int {
 					AAD:     aad,
 				})
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error creating persistent cache: %v", err))
+					outputError(c.UI, fmt.Sprintf("Error creating persistent cache: %v", err), err)
 					return 1
 				}
 				cacheLogger.Info("configured persistent storage", "path", config.Cache.Persist.Path)
@@ -648,11 +650,11 @@ func (c *AgentCommand) Run(args []string) int {
 				// Stash the key material in bolt
 				token, err := km.RetrievalToken()
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error getting persistent key: %s", err))
+					outputError(c.UI, fmt.Sprintf("Error getting persistent key: %s", err), err)
 					return 1
 				}
 				if err := ps.StoreRetrievalToken(token); err != nil {
-					c.UI.Error(fmt.Sprintf("Error setting key in persistent cache: %v", err))
+					outputError(c.UI, fmt.Sprintf("Error setting key in persistent cache: %v", err), err)
 					return 1
 				}
 
@@ -668,7 +670,7 @@ func (c *AgentCommand) Run(args []string) int {
 				Logger: cacheLogger,
 			}, leaseCache)
 			if err != nil {
-				c.UI.Error(fmt.Sprintf("Error creating inmem sink for cache: %v", err))
+				outputError(c.UI, fmt.Sprintf("Error creating inmem sink for cache: %v", err), err)
 				return 1
 			}
 			sinks = append(sinks, &sink.SinkConfig{
@@ -682,6 +684,22 @@ func (c *AgentCommand) Run(args []string) int {
 		// Create the request handler
 		cacheHandler := cache.Handler(ctx, cacheLogger, leaseCache, inmemSink, proxyVaultToken)
 
+		// Give each client with a verified client certificate its own token
+		// instead of the auto-auth token, if roles are configured for them
+		var clientCertTokens *cache.ClientCertTokens
+		if len(config.Cache.ClientCertTokenRoles) > 0 {
+			clientCertTokens, err = cache.NewClientCertTokens(&cache.ClientCertTokensConfig{
+				Client: client,
+				Logger: cacheLogger.Named("client-cert"),
+				Sink:   inmemSink.(sink.SinkReader),
+				Roles:  config.Cache.ClientCertTokenRoles,
+			})
+			if err != nil {
+				outputError(c.UI, fmt.Sprintf("Error configuring client certificate tokens: %v", err), err)
+				return 1
+			}
+		}
+
 		var listeners []net.Listener
 
 		// If there are templates, add an in-process listener
@@ -699,7 +717,7 @@ func (c *AgentCommand) Run(args []string) int {
 			} else {
 				ln, tlsConf, err = cache.StartListener(lnConfig)
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error starting listener: %v", err))
+					outputError(c.UI, fmt.Sprintf("Error starting listener: %v", err), err)
 					return 1
 				}
 			}
@@ -709,6 +727,9 @@ func (c *AgentCommand) Run(args []string) int {
 			// Parse 'require_request_header' listener config option, and wrap
 			// the request handler if necessary
 			muxHandler := cacheHandler
+			if clientCertTokens != nil && lnConfig.Type != listenerutil.BufConnType {
+				muxHandler = clientCertTokens.Handler(muxHandler)
+			}
 			if lnConfig.RequireRequestHeader {
 				muxHandler = verifyRequestHeader(muxHandler)
 			}
@@ -889,13 +910,13 @@ func (c *AgentCommand) Run(args []string) int {
 
 	// Write out the PID to the file now that server has successfully started
 	if err := c.storePidFile(config.PidFile); err != nil {
-		c.UI.Error(fmt.Sprintf("Error storing PID: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error storing PID: %s", err), err)
 		return 1
 	}
 
 	defer func() {
 		if err := c.removePidFile(config.PidFile); err != nil {
-			c.UI.Error(fmt.Sprintf("Error deleting the PID file: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error deleting the PID file: %s", err), err)
 		}
 	}()
 
@@ -1027,14 +1048,15 @@ func (c *AgentCommand) handleMetrics() http.Handler {
 			return
 		}
 
+		req := &logical.Request{
+			Headers: r.Header,
+		}
 		format := r.Form.Get("format")
 		if format == "" {
-			format = metricsutil.FormatFromRequest(&logical.Request{
-				Headers: r.Header,
-			})
+			format = metricsutil.FormatFromRequest(req)
 		}
 
-		resp := c.metricsHelper.ResponseForFormat(format)
+		resp := c.metricsHelper.ResponseForRequest(format, req)
 
 		status := resp.Data[logical.HTTPStatusCode].(int)
 		w.Header().Set("Content-Type", resp.Data[logical.HTTPContentType].(string))
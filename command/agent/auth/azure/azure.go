@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 
 	cleanhttp "github.com/hashicorp/go-cleanhttp"
 	hclog "github.com/hashicorp/go-hclog"
@@ -22,6 +26,10 @@ const (
 	// minimum version 2018-02-01 needed for identity metadata
 	// regional availability: https://docs.microsoft.com/en-us/azure/virtual-machines/windows/instance-metadata-service
 	apiVersion = "2018-02-01"
+
+	// defaultAuthorityHost is the Microsoft Entra ID endpoint federated
+	// tokens are exchanged at, unless AZURE_AUTHORITY_HOST says otherwise.
+	defaultAuthorityHost = "https://login.microsoftonline.com/"
 )
 
 type azureMethod struct {
@@ -32,6 +40,13 @@ type azureMethod struct {
 	resource string
 	objectID string
 	clientID string
+
+	// Set when authenticating with AKS workload identity instead of the
+	// instance metadata service.
+	workloadIdentity   bool
+	federatedTokenFile string
+	tenantID           string
+	authorityHost      string
 }
 
 func NewAzureAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
@@ -81,6 +96,35 @@ func NewAzureAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
 		}
 	}
 
+	if workloadIdentityRaw, ok := conf.Config["workload_identity"]; ok {
+		var err error
+		switch v := workloadIdentityRaw.(type) {
+		case bool:
+			a.workloadIdentity = v
+		case string:
+			a.workloadIdentity, err = strconv.ParseBool(v)
+		default:
+			err = errors.New("unexpected type")
+		}
+		if err != nil {
+			return nil, errors.New("could not convert 'workload_identity' config value to bool")
+		}
+	}
+
+	for key, target := range map[string]*string{
+		"federated_token_file": &a.federatedTokenFile,
+		"tenant_id":            &a.tenantID,
+	} {
+		raw, ok := conf.Config[key]
+		if !ok {
+			continue
+		}
+		*target, ok = raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("could not convert '%s' config value to string", key)
+		}
+	}
+
 	switch {
 	case a.role == "":
 		return nil, errors.New("'role' value is empty")
@@ -90,12 +134,44 @@ func NewAzureAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
 		return nil, errors.New("only one of 'object_id' or 'client_id' may be provided")
 	}
 
+	if a.workloadIdentity {
+		// The workload identity webhook sets these in the pod.
+		if a.federatedTokenFile == "" {
+			a.federatedTokenFile = os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+		}
+		if a.tenantID == "" {
+			a.tenantID = os.Getenv("AZURE_TENANT_ID")
+		}
+		if a.clientID == "" {
+			a.clientID = os.Getenv("AZURE_CLIENT_ID")
+		}
+		a.authorityHost = os.Getenv("AZURE_AUTHORITY_HOST")
+		if a.authorityHost == "" {
+			a.authorityHost = defaultAuthorityHost
+		}
+
+		switch {
+		case a.objectID != "":
+			return nil, errors.New("'object_id' cannot be used with workload identity, use 'client_id'")
+		case a.federatedTokenFile == "":
+			return nil, errors.New("'federated_token_file' value is empty and AZURE_FEDERATED_TOKEN_FILE is not set")
+		case a.tenantID == "":
+			return nil, errors.New("'tenant_id' value is empty and AZURE_TENANT_ID is not set")
+		case a.clientID == "":
+			return nil, errors.New("'client_id' value is empty and AZURE_CLIENT_ID is not set")
+		}
+	}
+
 	return a, nil
 }
 
 func (a *azureMethod) Authenticate(ctx context.Context, client *api.Client) (retPath string, header http.Header, retData map[string]interface{}, retErr error) {
 	a.logger.Trace("beginning authentication")
 
+	if a.workloadIdentity {
+		return a.authenticateWorkloadIdentity(ctx)
+	}
+
 	// Fetch instance data
 	var instance struct {
 		Compute struct {
@@ -148,6 +224,64 @@ func (a *azureMethod) Authenticate(ctx context.Context, client *api.Client) (ret
 	return fmt.Sprintf("%s/login", a.mountPath), nil, data, nil
 }
 
+// authenticateWorkloadIdentity exchanges the federated token projected into
+// the pod for an access token for the resource. The token file is read on
+// every attempt, since the kubelet rotates it before it expires.
+func (a *azureMethod) authenticateWorkloadIdentity(ctx context.Context) (string, http.Header, map[string]interface{}, error) {
+	assertion, err := ioutil.ReadFile(a.federatedTokenFile)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error reading federated token file: %w", err)
+	}
+	if len(assertion) == 0 {
+		return "", nil, nil, errors.New("federated token file is empty")
+	}
+
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {a.clientID},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+		"scope":                 {strings.TrimSuffix(a.resource, "/") + "/.default"},
+	}
+	endpoint := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(a.authorityHost, "/"), url.PathEscape(a.tenantID))
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", useragent.String())
+	req = req.WithContext(ctx)
+
+	resp, err := cleanhttp.DefaultClient().Do(req)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error exchanging federated token at %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("error reading token response from %s: %w", endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, nil, fmt.Errorf("error response exchanging federated token at %s: %s", endpoint, body)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := jsonutil.DecodeJSON(body, &token); err != nil {
+		return "", nil, nil, fmt.Errorf("error parsing token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", nil, nil, errors.New("token response does not include an access token")
+	}
+
+	return fmt.Sprintf("%s/login", a.mountPath), nil, map[string]interface{}{
+		"role": a.role,
+		"jwt":  token.AccessToken,
+	}, nil
+}
+
 func (a *azureMethod) NewCreds() chan struct{} {
 	return nil
 }
@@ -0,0 +1,130 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+)
+
+func TestAzureAuth_workloadIdentity(t *testing.T) {
+	// The token endpoint hands out an access token derived from the
+	// federated token, so that rotation of the file can be observed.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/my-tenant/oauth2/v2.0/token" ||
+			r.FormValue("client_id") != "my-client" ||
+			r.FormValue("scope") != "https://management.azure.com/.default" ||
+			r.FormValue("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-" + r.FormValue("client_assertion"),
+		})
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "azure-identity-token")
+	if err := ioutil.WriteFile(tokenFile, []byte("federated-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", tokenFile)
+	t.Setenv("AZURE_TENANT_ID", "my-tenant")
+	t.Setenv("AZURE_CLIENT_ID", "my-client")
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL+"/")
+
+	a, err := NewAzureAuthMethod(&auth.AuthConfig{
+		Logger:    logging.NewVaultLogger(hclog.Trace),
+		MountPath: "auth/azure",
+		Config: map[string]interface{}{
+			"role":              "dev",
+			"resource":          "https://management.azure.com/",
+			"workload_identity": true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, federated := range []string{"federated-1", "federated-2"} {
+		if err := ioutil.WriteFile(tokenFile, []byte(federated), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		path, _, data, err := a.Authenticate(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != "auth/azure/login" {
+			t.Errorf("unexpected path %q", path)
+		}
+		if data["role"] != "dev" || data["jwt"] != "access-"+federated {
+			t.Errorf("unexpected login data %#v", data)
+		}
+	}
+}
+
+func TestAzureAuth_workloadIdentityConfig(t *testing.T) {
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("AZURE_CLIENT_ID", "")
+
+	testCases := map[string]struct {
+		config map[string]interface{}
+		err    string
+	}{
+		"no_token_file": {
+			config: map[string]interface{}{"tenant_id": "t", "client_id": "c"},
+			err:    "'federated_token_file' value is empty",
+		},
+		"no_tenant": {
+			config: map[string]interface{}{"federated_token_file": "/token", "client_id": "c"},
+			err:    "'tenant_id' value is empty",
+		},
+		"no_client_id": {
+			config: map[string]interface{}{"federated_token_file": "/token", "tenant_id": "t"},
+			err:    "'client_id' value is empty",
+		},
+		"object_id": {
+			config: map[string]interface{}{"object_id": "o"},
+			err:    "'object_id' cannot be used",
+		},
+		"valid": {
+			config: map[string]interface{}{"federated_token_file": "/token", "tenant_id": "t", "client_id": "c"},
+		},
+	}
+
+	for k, tc := range testCases {
+		t.Run(k, func(t *testing.T) {
+			config := map[string]interface{}{
+				"role":              "dev",
+				"resource":          "https://management.azure.com/",
+				"workload_identity": "true",
+			}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+
+			_, err := NewAzureAuthMethod(&auth.AuthConfig{
+				Logger:    logging.NewVaultLogger(hclog.Trace),
+				MountPath: "auth/azure",
+				Config:    config,
+			})
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatal(err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected %q to contain %q", err, tc.err)
+			}
+		})
+	}
+}
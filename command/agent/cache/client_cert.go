@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// ClientCertTokensConfig is the configuration of ClientCertTokens.
+type ClientCertTokensConfig struct {
+	Client *api.Client
+	Logger hclog.Logger
+
+	// Sink holds the auto-auth token the per-client tokens are created with.
+	Sink sink.SinkReader
+
+	// Roles maps the identity of a client certificate, its common name, a
+	// DNS name or a URI, to the token role its tokens are created from.
+	Roles map[string]string
+}
+
+// ClientCertTokens hands out a separate token to each client which connects
+// with a verified client certificate, instead of the auto-auth token, so
+// that clients of the same agent cannot act as each other.
+type ClientCertTokens struct {
+	client *api.Client
+	logger hclog.Logger
+	sink   sink.SinkReader
+	roles  map[string]string
+
+	l      sync.Mutex
+	tokens map[string]*clientCertToken
+}
+
+type clientCertToken struct {
+	token string
+
+	// parent is the auto-auth token the token was created with. When it
+	// changes, a new token is created.
+	parent string
+
+	// renewAt is when a new token is created, before this one expires.
+	renewAt time.Time
+}
+
+// NewClientCertTokens creates a new ClientCertTokens.
+func NewClientCertTokens(conf *ClientCertTokensConfig) (*ClientCertTokens, error) {
+	if conf == nil || conf.Client == nil || conf.Sink == nil || conf.Logger == nil {
+		return nil, errors.New("missing configuration required params")
+	}
+	if len(conf.Roles) == 0 {
+		return nil, errors.New("no client certificate roles configured")
+	}
+
+	return &ClientCertTokens{
+		client: conf.Client,
+		logger: conf.Logger,
+		sink:   conf.Sink,
+		roles:  conf.Roles,
+		tokens: make(map[string]*clientCertToken),
+	}, nil
+}
+
+// Handler wraps h so that requests without a Vault token are sent with the
+// token of the client certificate they were made with. Requests without a
+// token or a client certificate with a configured role are rejected.
+func (c *ClientCertTokens) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(consts.AuthHeaderName) != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		identity, role := c.role(r)
+		if role == "" {
+			logical.RespondError(w, http.StatusForbidden, errors.New("a Vault token or a client certificate with a configured role is required"))
+			return
+		}
+
+		token, err := c.token(identity, role)
+		if err != nil {
+			c.logger.Error("failed to create token for client certificate", "identity", identity, "role", role, "error", err)
+			logical.RespondError(w, http.StatusInternalServerError, fmt.Errorf("failed to create token for client certificate: %w", err))
+			return
+		}
+
+		r.Header.Set(consts.AuthHeaderName, token)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// role returns the identity of the verified client certificate of r that has
+// a role configured, and that role.
+func (c *ClientCertTokens) role(r *http.Request) (string, string) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", ""
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+
+	identities := []string{cert.Subject.CommonName}
+	identities = append(identities, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	for _, identity := range identities {
+		if role, ok := c.roles[identity]; ok && identity != "" {
+			return identity, role
+		}
+	}
+	return "", ""
+}
+
+// token returns the token of identity, creating it from role if there is no
+// current one.
+func (c *ClientCertTokens) token(identity, role string) (string, error) {
+	parent := c.sink.Token()
+	if parent == "" {
+		return "", errors.New("no auto-auth token available")
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if t, ok := c.tokens[identity]; ok && t.parent == parent && time.Now().Before(t.renewAt) {
+		return t.token, nil
+	}
+
+	client, err := c.client.Clone()
+	if err != nil {
+		return "", err
+	}
+	client.SetToken(parent)
+
+	secret, err := client.Auth().Token().CreateWithRole(&api.TokenCreateRequest{
+		DisplayName: identity,
+		Metadata: map[string]string{
+			"client_cert_identity": identity,
+		},
+	}, role)
+	if err != nil {
+		return "", err
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return "", errors.New("empty response creating token")
+	}
+
+	// Create a new token once two thirds of the TTL have passed, so that
+	// clients never get one which is about to expire.
+	ttl := time.Duration(secret.Auth.LeaseDuration) * time.Second
+	renewAt := time.Now().Add(ttl * 2 / 3)
+	if ttl == 0 {
+		renewAt = time.Now().Add(time.Duration(1<<63 - 1))
+	}
+
+	c.logger.Debug("created token for client certificate", "identity", identity, "role", role, "ttl", ttl)
+	c.tokens[identity] = &clientCertToken{
+		token:   secret.Auth.ClientToken,
+		parent:  parent,
+		renewAt: renewAt,
+	}
+	return secret.Auth.ClientToken, nil
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/command/agent/sink/mock"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+)
+
+func TestClientCertTokens(t *testing.T) {
+	// Vault creates a new token for every request, named after the role,
+	// the parent token and the count, and records the display name.
+	var created int
+	displayNames := make(map[string]string)
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req api.TokenCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		created++
+		token := fmt.Sprintf("%s-%s-%d", r.URL.Path, r.Header.Get(consts.AuthHeaderName), created)
+		displayNames[token] = req.DisplayName
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{
+				"client_token":   token,
+				"lease_duration": 3600,
+			},
+		})
+	}))
+	defer vault.Close()
+
+	config := api.DefaultConfig()
+	config.Address = vault.URL
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	autoAuthSink := mock.NewSink("parent-1")
+	c, err := NewClientCertTokens(&ClientCertTokensConfig{
+		Client: client,
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		Sink:   autoAuthSink.(sink.SinkReader),
+		Roles: map[string]string{
+			"billing.apps.internal":     "billing",
+			"spiffe://internal/reports": "reports",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(consts.AuthHeaderName)))
+	}))
+
+	reportsURI, _ := url.Parse("spiffe://internal/reports")
+	testCases := []struct {
+		name   string
+		cert   *x509.Certificate
+		token  string
+		parent string
+		status int
+		result string
+	}{
+		{
+			name:   "common_name",
+			cert:   &x509.Certificate{Subject: pkix.Name{CommonName: "billing.apps.internal"}},
+			status: http.StatusOK,
+			result: "/v1/auth/token/create/billing-parent-1-1",
+		},
+		{
+			name:   "cached",
+			cert:   &x509.Certificate{Subject: pkix.Name{CommonName: "billing.apps.internal"}},
+			status: http.StatusOK,
+			result: "/v1/auth/token/create/billing-parent-1-1",
+		},
+		{
+			name:   "uri",
+			cert:   &x509.Certificate{Subject: pkix.Name{CommonName: "reports"}, URIs: []*url.URL{reportsURI}},
+			status: http.StatusOK,
+			result: "/v1/auth/token/create/reports-parent-1-2",
+		},
+		{
+			name:   "new_parent",
+			cert:   &x509.Certificate{DNSNames: []string{"billing.apps.internal"}},
+			parent: "parent-2",
+			status: http.StatusOK,
+			result: "/v1/auth/token/create/billing-parent-2-3",
+		},
+		{
+			name:   "own_token",
+			cert:   &x509.Certificate{Subject: pkix.Name{CommonName: "billing.apps.internal"}},
+			token:  "my-token",
+			status: http.StatusOK,
+			result: "my-token",
+		},
+		{
+			name:   "own_token_no_cert",
+			token:  "my-token",
+			status: http.StatusOK,
+			result: "my-token",
+		},
+		{
+			name:   "unknown_cert",
+			cert:   &x509.Certificate{Subject: pkix.Name{CommonName: "other.apps.internal"}},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "no_cert",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.parent != "" {
+			autoAuthSink.WriteToken(tc.parent)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/secret/data/foo", nil)
+		if tc.token != "" {
+			req.Header.Set(consts.AuthHeaderName, tc.token)
+		}
+		if tc.cert != nil {
+			req.TLS = &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{tc.cert}},
+			}
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != tc.status {
+			t.Fatalf("%s: expected status %d, got %d: %s", tc.name, tc.status, rr.Code, rr.Body.String())
+		}
+		if tc.status == http.StatusOK && rr.Body.String() != tc.result {
+			t.Fatalf("%s: expected token %q, got %q", tc.name, tc.result, rr.Body.String())
+		}
+	}
+
+	if name := displayNames["/v1/auth/token/create/reports-parent-1-2"]; name != "spiffe://internal/reports" {
+		t.Fatalf("unexpected display name %q", name)
+	}
+}
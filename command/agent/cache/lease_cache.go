@@ -93,6 +93,10 @@ type LeaseCache struct {
 	// shuttingDown is used to determine if cache needs to be evicted or not
 	// when the context is cancelled
 	shuttingDown atomic.Bool
+
+	// staticSecretTTL is how long KV v2 reads are cached. Zero disables
+	// caching them.
+	staticSecretTTL time.Duration
 }
 
 // LeaseCacheConfig is the configuration for initializing a new
@@ -103,6 +107,9 @@ type LeaseCacheConfig struct {
 	Proxier     Proxier
 	Logger      hclog.Logger
 	Storage     *cacheboltdb.BoltStorage
+
+	// StaticSecretTTL enables caching of KV v2 reads for the given duration.
+	StaticSecretTTL time.Duration
 }
 
 type inflightRequest struct {
@@ -145,15 +152,16 @@ func NewLeaseCache(conf *LeaseCacheConfig) (*LeaseCache, error) {
 	baseCtxInfo := cachememdb.NewContextInfo(conf.BaseContext)
 
 	return &LeaseCache{
-		client:        conf.Client,
-		proxier:       conf.Proxier,
-		logger:        conf.Logger,
-		db:            db,
-		baseCtxInfo:   baseCtxInfo,
-		l:             &sync.RWMutex{},
-		idLocks:       locksutil.CreateLocks(),
-		inflightCache: gocache.New(gocache.NoExpiration, gocache.NoExpiration),
-		ps:            conf.Storage,
+		client:          conf.Client,
+		proxier:         conf.Proxier,
+		logger:          conf.Logger,
+		db:              db,
+		baseCtxInfo:     baseCtxInfo,
+		l:               &sync.RWMutex{},
+		idLocks:         locksutil.CreateLocks(),
+		inflightCache:   gocache.New(gocache.NoExpiration, gocache.NoExpiration),
+		ps:              conf.Storage,
+		staticSecretTTL: conf.StaticSecretTTL,
 	}, nil
 }
 
@@ -280,24 +288,20 @@ func (c *LeaseCache) Send(ctx context.Context, req *SendRequest) (*SendResponse,
 		return resp, err
 	}
 
+	if c.staticSecretTTL > 0 && resp.Response.StatusCode < 300 {
+		c.invalidateStaticSecrets(req)
+	}
+
 	// If this is a non-2xx or if the returned response does not contain JSON payload,
 	// we skip caching
 	if resp.Response.StatusCode >= 300 || resp.Response.Header.Get("Content-Type") != "application/json" {
 		return resp, err
 	}
 
-	// Get the namespace from the request header
-	namespace := req.Request.Header.Get(consts.NamespaceHeaderName)
-	// We need to populate an empty value since go-memdb will skip over indexes
-	// that contain empty values.
-	if namespace == "" {
-		namespace = "root/"
-	}
-
 	// Build the index to cache based on the response received
 	index := &cachememdb.Index{
 		ID:          id,
-		Namespace:   namespace,
+		Namespace:   requestNamespace(req),
 		RequestPath: req.Request.URL.Path,
 		LastRenewed: time.Now().UTC(),
 	}
@@ -325,6 +329,11 @@ func (c *LeaseCache) Send(ctx context.Context, req *SendRequest) (*SendResponse,
 		return resp, nil
 	}
 
+	// KV v2 reads are cached for a fixed time, if enabled
+	if c.staticSecretTTL > 0 && req.Request.Method == http.MethodGet && isKVv2Read(secret) {
+		return c.cacheStaticSecret(ctx, index, req, resp)
+	}
+
 	// Short-circuit if the secret is not renewable
 	tokenRenewable, err := secret.TokenIsRenewable()
 	if err != nil {
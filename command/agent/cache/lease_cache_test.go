@@ -1217,3 +1217,129 @@ func TestLeaseCacheRestore_expired(t *testing.T) {
 	assert.Equal(t, "autoauthtoken", afterDB[0].Token)
 	assert.Equal(t, cacheboltdb.TokenType, afterDB[0].Type)
 }
+
+func TestLeaseCache_StaticSecret(t *testing.T) {
+	kvRead := func(value string) *SendResponse {
+		return newTestSendResponse(http.StatusOK, fmt.Sprintf(`{"data": {"data": {"value": %q}, "metadata": {"version": 1}}}`, value))
+	}
+	responses := []*SendResponse{
+		kvRead("v1"),
+		newTestSendResponse(http.StatusNoContent, ""),
+		kvRead("v2"),
+	}
+
+	client, err := api.NewClient(api.DefaultConfig())
+	require.NoError(t, err)
+	proxier := newMockProxier(responses)
+	lc, err := NewLeaseCache(&LeaseCacheConfig{
+		Client:          client,
+		BaseContext:     context.Background(),
+		Proxier:         proxier,
+		Logger:          logging.NewVaultLogger(hclog.Trace).Named("cache.leasecache"),
+		StaticSecretTTL: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, lc.RegisterAutoAuthToken("autoauthtoken"))
+
+	send := func(method, path string) *SendResponse {
+		t.Helper()
+
+		resp, err := lc.Send(context.Background(), &SendRequest{
+			Token:   "autoauthtoken",
+			Request: httptest.NewRequest(method, "http://example.com"+path, nil),
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	// The first read is proxied and the second is served from the cache
+	resp := send("GET", "/v1/secret/data/foo")
+	assert.Contains(t, string(resp.ResponseBody), "v1")
+	resp = send("GET", "/v1/secret/data/foo")
+	assert.True(t, resp.CacheMeta.Hit)
+	assert.Equal(t, 1, proxier.ResponseIndex())
+
+	// Deleting the latest version through the agent invalidates the entry
+	send("DELETE", "/v1/secret/delete/foo")
+	resp = send("GET", "/v1/secret/data/foo")
+	assert.Equal(t, 3, proxier.ResponseIndex())
+	assert.Contains(t, string(resp.ResponseBody), "v2")
+
+	// Clearing the cache of the token evicts the entry
+	require.NoError(t, lc.handleCacheClear(context.Background(), &cacheClearInput{
+		Type:  "token",
+		Token: "autoauthtoken",
+	}))
+	require.Eventually(t, func() bool {
+		index, err := lc.db.GetByPrefix(cachememdb.IndexNameRequestPath, "root/", "/v1/secret/data/foo")
+		return err == nil && len(index) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLeaseCache_StaticSecretNamespace(t *testing.T) {
+	kvRead := func(value string) *SendResponse {
+		return newTestSendResponse(http.StatusOK, fmt.Sprintf(`{"data": {"data": {"value": %q}, "metadata": {"version": 1}}}`, value))
+	}
+	responses := []*SendResponse{
+		kvRead("v1"),
+		newTestSendResponse(http.StatusNoContent, ""),
+		kvRead("v2"),
+	}
+
+	client, err := api.NewClient(api.DefaultConfig())
+	require.NoError(t, err)
+	proxier := newMockProxier(responses)
+	lc, err := NewLeaseCache(&LeaseCacheConfig{
+		Client:          client,
+		BaseContext:     context.Background(),
+		Proxier:         proxier,
+		Logger:          logging.NewVaultLogger(hclog.Trace).Named("cache.leasecache"),
+		StaticSecretTTL: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, lc.RegisterAutoAuthToken("autoauthtoken"))
+
+	send := func(method, namespace, path string) *SendResponse {
+		t.Helper()
+
+		req := httptest.NewRequest(method, "http://example.com"+path, nil)
+		if namespace != "" {
+			req.Header.Set(consts.NamespaceHeaderName, namespace)
+		}
+		resp, err := lc.Send(context.Background(), &SendRequest{
+			Token:   "autoauthtoken",
+			Request: req,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	send("GET", "ns1/", "/v1/secret/data/foo")
+	resp := send("GET", "ns1/", "/v1/secret/data/foo")
+	assert.True(t, resp.CacheMeta.Hit)
+
+	// Writing with the namespace in the path invalidates the entry read with
+	// the namespace in the header
+	send("PUT", "", "/v1/ns1/secret/data/foo")
+	resp = send("GET", "ns1/", "/v1/secret/data/foo")
+	assert.Equal(t, 3, proxier.ResponseIndex())
+	assert.Contains(t, string(resp.ResponseBody), "v2")
+}
+
+func TestLeaseCache_StaticSecretDisabled(t *testing.T) {
+	responses := []*SendResponse{
+		newTestSendResponse(http.StatusOK, `{"data": {"data": {"value": "v1"}, "metadata": {"version": 1}}}`),
+		newTestSendResponse(http.StatusOK, `{"data": {"data": {"value": "v2"}, "metadata": {"version": 2}}}`),
+	}
+	lc := testNewLeaseCache(t, responses)
+	require.NoError(t, lc.RegisterAutoAuthToken("autoauthtoken"))
+
+	for _, expected := range []string{"v1", "v2"} {
+		resp, err := lc.Send(context.Background(), &SendRequest{
+			Token:   "autoauthtoken",
+			Request: httptest.NewRequest("GET", "http://example.com/v1/secret/data/foo", nil),
+		})
+		require.NoError(t, err)
+		assert.Contains(t, string(resp.ResponseBody), expected)
+	}
+}
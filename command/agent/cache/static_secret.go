@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/cache/cachememdb"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+// staticSecretType is the index type of cached KV v2 reads. These entries
+// are only held in memory and never persisted. They are bounded by the static
+// secret TTL rather than invalidated by the server, so changes made without
+// going through the agent are only seen once the entry expires.
+const staticSecretType = "static-secret"
+
+// kvv2MutatingSegments are the path segments of KV v2 endpoints which change
+// the secret that is read at the corresponding "data" path.
+var kvv2MutatingSegments = []string{"/data/", "/metadata/", "/delete/", "/undelete/", "/destroy/"}
+
+// isKVv2Read reports whether secret is the response of a KV v2 read.
+func isKVv2Read(secret *api.Secret) bool {
+	if secret.LeaseID != "" || secret.Auth != nil || len(secret.Data) != 2 {
+		return false
+	}
+	if _, ok := secret.Data["data"]; !ok {
+		return false
+	}
+	metadata, ok := secret.Data["metadata"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = metadata["version"]
+	return ok
+}
+
+// requestNamespace returns the namespace of req, as used in the index.
+func requestNamespace(req *SendRequest) string {
+	// We need to populate an empty value since go-memdb will skip over
+	// indexes that contain empty values.
+	if ns := req.Request.Header.Get(consts.NamespaceHeaderName); ns != "" {
+		return ns
+	}
+	return "root/"
+}
+
+// staticSecretPath returns the request path of req prefixed with the namespace
+// of the header, so that a secret read or written with the namespace in the
+// header or in the path, as in /v1/ns1/secret/data/foo, has the same path.
+// Static secrets are indexed by this path in the root namespace.
+func staticSecretPath(req *SendRequest) string {
+	path := strings.TrimPrefix(req.Request.URL.Path, "/v1/")
+	if ns := strings.Trim(req.Request.Header.Get(consts.NamespaceHeaderName), "/"); ns != "" {
+		path = ns + "/" + path
+	}
+	return "/v1/" + path
+}
+
+// cacheStaticSecret caches the response of a KV v2 read for the configured
+// static secret TTL. The entry is evicted early when the token which read it
+// is evicted, or when the secret is changed through the agent.
+func (c *LeaseCache) cacheStaticSecret(ctx context.Context, index *cachememdb.Index, req *SendRequest, resp *SendResponse) (*SendResponse, error) {
+	// As for leases, only responses for tokens managed by the agent are
+	// cached, so that revoking the token also evicts what it read.
+	entry, err := c.db.Get(cachememdb.IndexNameToken, req.Token)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		c.logger.Debug("pass-through static secret response; token not managed by agent", "path", req.Request.URL.Path)
+		return resp, nil
+	}
+
+	var respBytes bytes.Buffer
+	if err := resp.Response.Write(&respBytes); err != nil {
+		c.logger.Error("failed to serialize response", "error", err)
+		return nil, err
+	}
+	if resp.Response.Body != nil {
+		resp.Response.Body.Close()
+	}
+	resp.Response.Body = ioutil.NopCloser(bytes.NewReader(resp.ResponseBody))
+
+	expireCtx, cancel := context.WithTimeout(entry.RenewCtxInfo.Ctx, c.staticSecretTTL)
+	index.Type = staticSecretType
+	index.Namespace = "root/"
+	index.RequestPath = staticSecretPath(req)
+	index.Response = respBytes.Bytes()
+	index.RequestMethod = req.Request.Method
+	index.RequestToken = req.Token
+	index.RenewCtxInfo = &cachememdb.ContextInfo{
+		Ctx:        expireCtx,
+		CancelFunc: cancel,
+		DoneCh:     make(chan struct{}),
+	}
+
+	c.logger.Debug("storing static secret into the cache", "path", req.Request.URL.Path, "ttl", c.staticSecretTTL)
+	if err := c.db.Set(index); err != nil {
+		cancel()
+		c.logger.Error("failed to cache the proxied response", "error", err)
+		return nil, err
+	}
+
+	go func() {
+		<-expireCtx.Done()
+		if c.shuttingDown.Load() {
+			return
+		}
+		if err := c.db.Evict(cachememdb.IndexNameID, index.ID); err != nil {
+			c.logger.Error("failed to evict static secret", "id", index.ID, "error", err)
+		}
+	}()
+
+	return resp, nil
+}
+
+// invalidateStaticSecrets evicts the cached reads of every KV v2 secret that
+// a successful write, patch or delete of req may have changed, for all
+// tokens.
+func (c *LeaseCache) invalidateStaticSecrets(req *SendRequest) {
+	switch req.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return
+	}
+
+	// The mount is not known, so every KV v2 segment in the path is taken as
+	// the one following the mount.
+	path := staticSecretPath(req)
+	candidates := []string{path}
+	for _, segment := range kvv2MutatingSegments {
+		for i := strings.Index(path, segment); i >= 0; {
+			candidates = append(candidates, path[:i]+"/data/"+path[i+len(segment):])
+			next := strings.Index(path[i+1:], segment)
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+	}
+
+	for _, candidate := range candidates {
+		indexes, err := c.db.GetByPrefix(cachememdb.IndexNameRequestPath, "root/", candidate)
+		if err != nil {
+			c.logger.Error("failed to look up cached static secrets", "path", candidate, "error", err)
+			continue
+		}
+		for _, index := range indexes {
+			if index.Type != staticSecretType || index.RequestPath != candidate {
+				continue
+			}
+			c.logger.Debug("invalidating cached static secret", "path", candidate)
+			index.RenewCtxInfo.CancelFunc()
+			if err := c.db.Evict(cachememdb.IndexNameID, index.ID); err != nil {
+				c.logger.Error("failed to evict static secret", "id", index.ID, "error", err)
+			}
+		}
+	}
+}
@@ -85,6 +85,13 @@ type Cache struct {
 	WhenInconsistent    string          `hcl:"when_inconsistent"`
 	Persist             *Persist        `hcl:"persist"`
 	InProcDialer        transportDialer `hcl:"-"`
+	StaticSecretTTLRaw  interface{}     `hcl:"static_secret_ttl"`
+	StaticSecretTTL     time.Duration   `hcl:"-"`
+
+	// ClientCertTokenRoles maps the identity of a verified client
+	// certificate to the token role that the tokens of that client are
+	// created from.
+	ClientCertTokenRoles map[string]string `hcl:"client_cert_token_roles"`
 }
 
 // Persist contains configuration needed for persistent caching
@@ -228,6 +235,21 @@ func LoadConfig(path string) (*Config, error) {
 				return nil, fmt.Errorf("cache.use_auto_auth_token is true and auto_auth uses wrapping")
 			}
 		}
+
+		if len(result.Cache.ClientCertTokenRoles) > 0 {
+			if !result.Cache.UseAutoAuthToken || result.Cache.ForceAutoAuthToken {
+				return nil, fmt.Errorf("cache.client_cert_token_roles requires cache.use_auto_auth_token to be true")
+			}
+			var verifiesClientCerts bool
+			for _, l := range result.Listeners {
+				if l.TLSRequireAndVerifyClientCert {
+					verifiesClientCerts = true
+				}
+			}
+			if !verifiesClientCerts {
+				return nil, fmt.Errorf("cache.client_cert_token_roles requires a listener with tls_require_and_verify_client_cert")
+			}
+		}
 	}
 
 	if result.AutoAuth != nil {
@@ -366,6 +388,14 @@ func parseCache(result *Config, list *ast.ObjectList) error {
 			}
 		}
 	}
+	if c.StaticSecretTTLRaw != nil {
+		if c.StaticSecretTTL, err = parseutil.ParseDurationSecond(c.StaticSecretTTLRaw); err != nil {
+			return fmt.Errorf("error parsing static_secret_ttl: %w", err)
+		}
+		if c.StaticSecretTTL < 0 {
+			return errors.New("static_secret_ttl cannot be negative")
+		}
+	}
 	result.Cache = &c
 
 	subs, ok := item.Val.(*ast.ObjectType)
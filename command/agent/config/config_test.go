@@ -957,6 +957,62 @@ func TestLoadConfigFile_Vault_Retry_Empty(t *testing.T) {
 	}
 }
 
+func TestLoadConfigFile_StaticSecretTTL(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config-cache-static-secret-ttl.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Config{
+		SharedConfig: &configutil.SharedConfig{
+			Listeners: []*configutil.Listener{
+				{
+					Type:       "tcp",
+					Address:    "127.0.0.1:8300",
+					TLSDisable: true,
+				},
+			},
+			PidFile: "",
+		},
+		Cache: &Cache{
+			StaticSecretTTLRaw: "5m",
+			StaticSecretTTL:    5 * time.Minute,
+		},
+		Vault: &Vault{
+			Retry: &Retry{
+				NumRetries: 12,
+			},
+		},
+	}
+
+	config.Prune()
+	if diff := deep.Equal(config, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestLoadConfigFile_ClientCertTokenRoles(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config-cache-client-cert-token-roles.hcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"billing.apps.internal":     "billing",
+		"spiffe://internal/reports": "reports",
+	}
+	if diff := deep.Equal(config.Cache.ClientCertTokenRoles, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
+func TestLoadConfigFile_Bad_ClientCertTokenRoles_NoClientCerts(t *testing.T) {
+	_, err := LoadConfig("./test-fixtures/bad-config-cache-client-cert-token-roles-no-mtls.hcl")
+	if err == nil {
+		t.Fatal("LoadConfig should return an error when cache.client_cert_token_roles is set and no listener verifies client certificates")
+	}
+}
+
 func TestLoadConfigFile_EnforceConsistency(t *testing.T) {
 	config, err := LoadConfig("./test-fixtures/config-consistency.hcl")
 	if err != nil {
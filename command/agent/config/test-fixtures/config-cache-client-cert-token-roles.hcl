@@ -0,0 +1,26 @@
+pid_file = "./pidfile"
+
+auto_auth {
+	method {
+		type = "aws"
+		config = {
+			role = "foobar"
+		}
+	}
+}
+
+cache {
+	use_auto_auth_token = true
+	client_cert_token_roles = {
+		"billing.apps.internal" = "billing"
+		"spiffe://internal/reports" = "reports"
+	}
+}
+
+listener "tcp" {
+	address = "127.0.0.1:8300"
+	tls_cert_file = "/path/to/cert.pem"
+	tls_key_file = "/path/to/key.pem"
+	tls_client_ca_file = "/path/to/ca.pem"
+	tls_require_and_verify_client_cert = true
+}
@@ -0,0 +1,8 @@
+cache {
+	static_secret_ttl = "5m"
+}
+
+listener "tcp" {
+	address = "127.0.0.1:8300"
+	tls_disable = true
+}
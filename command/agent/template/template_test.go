@@ -400,6 +400,71 @@ func TestServerRun(t *testing.T) {
 	}
 }
 
+// TestServerRun_transitDecrypt renders the plaintext of a ciphertext stored
+// in KV, by passing it to the transit decrypt endpoint with "secret".
+func TestServerRun_transitDecrypt(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/myapp/encrypted", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"data": {"data": {"password": "vault:v1:abcd"}, "metadata": {"deletion_time": "", "version": 1}}}`)
+	})
+	mux.HandleFunc("/v1/transit/decrypt/my-key", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["ciphertext"] != "vault:v1:abcd" {
+			w.WriteHeader(400)
+			fmt.Fprintln(w, `{"errors":["bad ciphertext"]}`)
+			return
+		}
+		// base64 of "hunter2"
+		fmt.Fprintln(w, `{"data": {"plaintext": "aHVudGVyMg=="}}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	dstFile := fmt.Sprintf("%s/render", t.TempDir())
+	templatesToRender := []*ctconfig.TemplateConfig{
+		{
+			Contents: pointerutil.StringPtr(`{{ with secret "kv/myapp/encrypted" }}` +
+				`{{ with secret "transit/decrypt/my-key" (printf "ciphertext=%s" .Data.data.password) }}` +
+				`{{ .Data.plaintext | base64Decode }}{{ end }}{{ end }}`),
+			Destination: pointerutil.StringPtr(dstFile),
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	server := NewServer(&ServerConfig{
+		Logger: logging.NewVaultLogger(hclog.Trace),
+		AgentConfig: &config.Config{
+			Vault: &config.Vault{
+				Address: ts.URL,
+				Retry: &config.Retry{
+					NumRetries: 3,
+				},
+			},
+			TemplateConfig: &config.TemplateConfig{
+				ExitOnRetryFailure: true,
+			},
+		},
+		LogLevel:      hclog.Trace,
+		LogWriter:     hclog.DefaultOutput,
+		ExitAfterAuth: true,
+	})
+
+	templateTokenCh := make(chan string, 1)
+	templateTokenCh <- "test"
+	if err := server.Run(ctx, templateTokenCh, templatesToRender); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(dstFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hunter2" {
+		t.Fatalf("expected the plaintext to be rendered, got %q", content)
+	}
+}
+
 var jsonResponse = `
 {
   "request_id": "8af096e9-518c-7351-eff5-5ba20554b21f",
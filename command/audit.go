@@ -31,6 +31,18 @@ Usage: vault audit <subcommand> [options] [args]
 
        $ vault audit enable file file_path=/var/log/audit.log
 
+  Stream the entries the audit device "file" logs:
+
+      $ vault audit tail file/
+
+  Convert an audit log written with format=cbor to JSON:
+
+      $ vault audit decode /var/log/vault_audit.cbor
+
+  Test a filter expression against an audit entry:
+
+      $ vault audit test-filter 'request.path =~ "^secret/"' entry.json
+
   Please see the individual subcommand help for detailed usage information.
 `
 
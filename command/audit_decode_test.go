@@ -0,0 +1,195 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/cli"
+)
+
+func testAuditDecodeCommand(tb testing.TB) (*cli.MockUi, *AuditDecodeCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AuditDecodeCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testCBORAuditLog returns the entries of a request, formatted as CBOR.
+func testCBORAuditLog(tb testing.TB) []byte {
+	tb.Helper()
+
+	salter, err := salt.NewSalt(context.Background(), nil, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	formatter := audit.AuditFormatter{
+		AuditFormatWriter: &audit.CBORFormatWriter{
+			SaltFunc: func(context.Context) (*salt.Salt, error) {
+				return salter, nil
+			},
+		},
+	}
+
+	in := &logical.LogInput{
+		Auth: &logical.Auth{
+			ClientToken: "foo",
+			EntityID:    "e1",
+		},
+		Request: &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "secret/foo",
+		},
+		Response: &logical.Response{
+			Data: map[string]interface{}{
+				"ttl": 3600,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	ctx := namespace.RootContext(nil)
+	if err := formatter.FormatRequest(ctx, &buf, audit.FormatterConfig{}, in); err != nil {
+		tb.Fatal(err)
+	}
+	if err := formatter.FormatResponse(ctx, &buf, audit.FormatterConfig{}, in); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAuditDecodeCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"missing_file",
+			[]string{filepath.Join(t.TempDir(), "audit.cbor")},
+			"Error opening file",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testAuditDecodeCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "audit.cbor")
+		if err := ioutil.WriteFile(path, testCBORAuditLog(t), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testAuditDecodeCommand(t)
+
+		code := cmd.Run([]string{path})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 entries, got %d: %q", len(lines), lines)
+		}
+		for i, typ := range []string{"request", "response"} {
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry["type"] != typ {
+				t.Errorf("expected entry %d to be a %s, got %v", i, typ, entry["type"])
+			}
+			if path := entry["request"].(map[string]interface{})["path"]; path != "secret/foo" {
+				t.Errorf("expected path secret/foo, got %v", path)
+			}
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testAuditDecodeCommand(t)
+		cmd.testStdin = bytes.NewReader(testCBORAuditLog(t))
+
+		code := cmd.Run([]string{})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		if n := strings.Count(ui.OutputWriter.String(), "\n"); n != 2 {
+			t.Errorf("expected 2 entries, got %d", n)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		t.Parallel()
+
+		log := testCBORAuditLog(t)
+
+		ui, cmd := testAuditDecodeCommand(t)
+		cmd.testStdin = bytes.NewReader(log[:len(log)-10])
+
+		code := cmd.Run([]string{"-"})
+		if exp := 2; code != exp {
+			t.Fatalf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error decoding audit entry 2"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testAuditDecodeCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -79,7 +79,7 @@ func (c *AuditDisableCommand) Run(args []string) int {
 	}
 
 	if err := client.Sys().DisableAudit(path); err != nil {
-		c.UI.Error(fmt.Sprintf("Error disabling audit device: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error disabling audit device: %s", err), err)
 		return 2
 	}
 
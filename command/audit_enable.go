@@ -91,6 +91,8 @@ func (c *AuditEnableCommand) Flags() *FlagSets {
 func (c *AuditEnableCommand) AutocompleteArgs() complete.Predictor {
 	return complete.PredictSet(
 		"file",
+		"kafka",
+		"otlp",
 		"syslog",
 		"socket",
 	)
@@ -131,7 +133,7 @@ func (c *AuditEnableCommand) Run(args []string) int {
 
 	options, err := parseArgsDataString(stdin, args[1:])
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse K=V data: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
 		return 1
 	}
 
@@ -147,7 +149,7 @@ func (c *AuditEnableCommand) Run(args []string) int {
 		Options:     options,
 		Local:       c.flagLocal,
 	}); err != nil {
-		c.UI.Error(fmt.Sprintf("Error enabling audit device: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error enabling audit device: %s", err), err)
 		return 2
 	}
 
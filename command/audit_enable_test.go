@@ -188,6 +188,12 @@ func TestAuditEnableCommand_Run(t *testing.T) {
 			switch b {
 			case "file":
 				args = append(args, "file_path=discard")
+			case "kafka":
+				args = append(args, "brokers=127.0.0.1:9092", "topic=vault-audit",
+					"skip_test=true")
+			case "otlp":
+				args = append(args, "endpoint=http://127.0.0.1:4318",
+					"skip_test=true")
 			case "socket":
 				args = append(args, "address=127.0.0.1:8888",
 					"skip_test=true")
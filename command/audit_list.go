@@ -92,7 +92,7 @@ func (c *AuditListCommand) Run(args []string) int {
 
 	audits, err := client.Sys().ListAudit()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing audits: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error listing audits: %s", err), err)
 		return 2
 	}
 
@@ -104,11 +104,9 @@ func (c *AuditListCommand) Run(args []string) int {
 		}
 
 		if c.flagDetailed {
-			c.UI.Output(tableOutput(c.detailedAudits(audits), nil))
-			return 0
+			return OutputTable(c.UI, c.detailedAudits(audits), nil)
 		}
-		c.UI.Output(tableOutput(c.simpleAudits(audits), nil))
-		return 0
+		return OutputTable(c.UI, c.simpleAudits(audits), nil)
 	default:
 		return OutputData(c.UI, audits)
 	}
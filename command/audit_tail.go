@@ -0,0 +1,246 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*AuditTailCommand)(nil)
+	_ cli.CommandAutocomplete = (*AuditTailCommand)(nil)
+)
+
+type AuditTailCommand struct {
+	*BaseCommand
+
+	flagPath       string
+	flagOperations []string
+	flagEntityID   string
+	flagMount      string
+	flagRequests   bool
+
+	// ShutdownCh is used to capture interrupt signal and end streaming
+	ShutdownCh chan struct{}
+}
+
+func (c *AuditTailCommand) Synopsis() string {
+	return "Streams the entries logged by an audit device"
+}
+
+func (c *AuditTailCommand) Help() string {
+	helpText := `
+Usage: vault audit tail [options] PATH
+
+  Streams the entries the audit device at PATH logs from now on, until
+  interrupted. Only requests handled by the server the command connects to
+  are streamed. Entries are hashed like in the device's log, so hashed values
+  can be compared with "vault write sys/audit-hash/PATH input=...".
+
+  By default, one line is printed per response, with its time, operation,
+  path, entity, remote address and error. With -format=jsonl, every entry is
+  printed as a JSON object on its own line. Entries which the command does
+  not read fast enough are dropped by the server.
+
+  Show who writes to secret/ right now:
+
+      $ vault audit tail -mount=secret -operation=create -operation=update file/
+
+  Stream the entries of an entity as JSON Lines:
+
+      $ vault audit tail -format=jsonl -entity-id=5f0cf3b8-... file/
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AuditTailCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "path",
+		Target:     &c.flagPath,
+		Completion: complete.PredictAnything,
+		Usage: "Only show requests to this path. A trailing \"*\" matches " +
+			"all paths with the given prefix.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "operation",
+		Target:     &c.flagOperations,
+		Completion: complete.PredictSet("create", "read", "update", "delete", "list", "patch"),
+		Usage: "Only show requests with this operation. This can be " +
+			"specified multiple times.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "entity-id",
+		Target:     &c.flagEntityID,
+		Completion: complete.PredictAnything,
+		Usage:      "Only show requests made by the entity with this ID.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Completion: c.PredictVaultMounts(),
+		Usage:      "Only show requests to the secrets engine or auth method mounted at this path.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "requests",
+		Target:  &c.flagRequests,
+		Default: false,
+		Usage: "Also show the entries logged for requests before they are " +
+			"handled. By default only the entries of responses are shown, " +
+			"which include the request.",
+	})
+
+	return set
+}
+
+func (c *AuditTailCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultAudits()
+}
+
+func (c *AuditTailCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AuditTailCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+	path := ensureTrailingSlash(sanitizePath(args[0]))
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	// Remove the default 60 second timeout so we can stream indefinitely
+	client.SetClientTimeout(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entryCh, err := client.Sys().AuditStream(ctx, path)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error streaming audit device %s: %s", path, err), err)
+		return 2
+	}
+
+	for {
+		select {
+		case line, ok := <-entryCh:
+			if !ok {
+				c.UI.Error("The server ended the stream")
+				return 2
+			}
+
+			var entry audit.AuditResponseEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				outputError(c.UI, fmt.Sprintf("Error decoding audit entry: %s", err), err)
+				continue
+			}
+			if !c.matches(&entry) {
+				continue
+			}
+
+			if Format(c.UI) == "table" {
+				c.UI.Output(auditTailLine(&entry))
+				continue
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &data); err != nil {
+				outputError(c.UI, fmt.Sprintf("Error decoding audit entry: %s", err), err)
+				continue
+			}
+			OutputData(c.UI, data)
+		case <-c.ShutdownCh:
+			return 0
+		}
+	}
+}
+
+// matches returns true if entry passes the filters given by the flags.
+func (c *AuditTailCommand) matches(entry *audit.AuditResponseEntry) bool {
+	if entry.Type != "response" && !c.flagRequests {
+		return false
+	}
+
+	var operation, path string
+	if entry.Request != nil {
+		operation, path = string(entry.Request.Operation), entry.Request.Path
+	}
+	var entityID string
+	if entry.Auth != nil {
+		entityID = entry.Auth.EntityID
+	}
+
+	switch {
+	case c.flagPath != "" && !auditTailMatchPath(c.flagPath, path):
+		return false
+	case c.flagMount != "" && !strings.HasPrefix(path, ensureTrailingSlash(sanitizePath(c.flagMount))):
+		return false
+	case len(c.flagOperations) > 0 && !strutil.StrListContains(c.flagOperations, operation):
+		return false
+	case c.flagEntityID != "" && c.flagEntityID != entityID:
+		return false
+	}
+	return true
+}
+
+// auditTailMatchPath returns true if path matches pattern, which ends with
+// "*" to match a prefix.
+func auditTailMatchPath(pattern, path string) bool {
+	pattern = sanitizePath(pattern)
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(path, strings.TrimSuffix(pattern, "*"))
+	}
+	return path == pattern
+}
+
+// auditTailLine returns the compact line printed for entry.
+func auditTailLine(entry *audit.AuditResponseEntry) string {
+	fields := []string{entry.Time, entry.Type}
+	if entry.Request != nil {
+		fields = append(fields, string(entry.Request.Operation), entry.Request.Path)
+		if entry.Request.Namespace != nil && entry.Request.Namespace.Path != "" {
+			fields = append(fields, "namespace="+entry.Request.Namespace.Path)
+		}
+	}
+	if entry.Auth != nil && entry.Auth.EntityID != "" {
+		fields = append(fields, "entity="+entry.Auth.EntityID)
+	} else if entry.Auth != nil && entry.Auth.DisplayName != "" {
+		fields = append(fields, "display_name="+entry.Auth.DisplayName)
+	}
+	if entry.Request != nil && entry.Request.RemoteAddr != "" {
+		fields = append(fields, "remote="+entry.Request.RemoteAddr)
+	}
+	if entry.Error != "" {
+		fields = append(fields, fmt.Sprintf("error=%q", entry.Error))
+	}
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,196 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/cli"
+)
+
+func testAuditTailCommand(tb testing.TB) (*cli.MockUi, *AuditTailCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AuditTailCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+}
+
+func TestAuditTailCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testAuditTailCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("unknown_device", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testAuditTailCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"file"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := `unknown audit backend "file/"`
+		if combined := ui.OutputWriter.String() + ui.ErrorWriter.String(); !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().EnableAuditWithOptions("file", &api.EnableAuditOptions{
+			Type: "file",
+			Options: map[string]string{
+				"file_path": "discard",
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testAuditTailCommand(t)
+		cmd.client = client
+
+		codeCh := make(chan int, 1)
+		go func() {
+			codeCh <- cmd.Run([]string{"-path=secret/foo*", "-operation=update", "file"})
+		}()
+
+		// Write until the stream is established and the entry is printed.
+		deadline := time.Now().Add(10 * time.Second)
+		for !strings.Contains(ui.OutputWriter.String(), "secret/foo") {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected a streamed entry, got %q %q", ui.OutputWriter.String(), ui.ErrorWriter.String())
+			}
+			for _, path := range []string{"secret/bar", "secret/foo"} {
+				if _, err := client.Logical().Write(path, map[string]interface{}{"a": "b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		close(cmd.ShutdownCh)
+		if code := <-codeCh; code != 0 {
+			t.Errorf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+
+		output := ui.OutputWriter.String()
+		if !strings.Contains(output, " response update secret/foo ") {
+			t.Errorf("expected %q to contain the response to secret/foo", output)
+		}
+		if strings.Contains(output, "secret/bar") || strings.Contains(output, " request ") {
+			t.Errorf("expected %q to be filtered", output)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testAuditTailCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestAuditTailCommand_matches(t *testing.T) {
+	t.Parallel()
+
+	entry := &audit.AuditResponseEntry{
+		Type: "response",
+		Auth: &audit.AuditAuth{EntityID: "e1"},
+		Request: &audit.AuditRequest{
+			Operation: logical.ReadOperation,
+			Path:      "secret/data/foo",
+		},
+	}
+
+	cases := []struct {
+		name    string
+		args    []string
+		matches bool
+	}{
+		{"none", nil, true},
+		{"path", []string{"-path=secret/data/foo"}, true},
+		{"path_prefix", []string{"-path=secret/data/*"}, true},
+		{"path_mismatch", []string{"-path=secret/data/bar"}, false},
+		{"mount", []string{"-mount=secret"}, true},
+		{"mount_mismatch", []string{"-mount=sec"}, false},
+		{"operation", []string{"-operation=update", "-operation=read"}, true},
+		{"operation_mismatch", []string{"-operation=update"}, false},
+		{"entity", []string{"-entity-id=e1"}, true},
+		{"entity_mismatch", []string{"-entity-id=e2"}, false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, cmd := testAuditTailCommand(t)
+			if err := cmd.Flags().Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+			if matches := cmd.matches(entry); matches != tc.matches {
+				t.Errorf("expected %t to be %t", matches, tc.matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/monitor"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*AuditTestFilterCommand)(nil)
+	_ cli.CommandAutocomplete = (*AuditTestFilterCommand)(nil)
+)
+
+type AuditTestFilterCommand struct {
+	*BaseCommand
+
+	testStdin io.Reader // for tests
+}
+
+func (c *AuditTestFilterCommand) Synopsis() string {
+	return "Tests a filter expression against an audit entry"
+}
+
+func (c *AuditTestFilterCommand) Help() string {
+	helpText := `
+Usage: vault audit test-filter [options] FILTER [PATH]
+
+  Reports whether an audit entry matches a filter expression, and which
+  matcher of the expression it fails otherwise. The entry is read as JSON,
+  like audit devices with format=json write it, from the file at PATH. If
+  PATH is "-" or not given, the entry is read from stdin. This command does
+  not contact the Vault server.
+
+  Filters use the syntax of "vault monitor -filter": matchers comparing a
+  field with ==, !=, =~ or !~, joined with "and". The fields of the entry
+  are named by their path in the JSON object, such as request.path,
+  request.operation, request.namespace.path or auth.policies. Lists are
+  compared as their elements joined with commas.
+
+  The command exits with 0 if the entry matches the filter, and with 2 if it
+  does not.
+
+  Test a filter against the last entry of an audit log:
+
+      $ tail -n 1 /var/log/vault_audit.log | \
+          vault audit test-filter 'type == response and request.path =~ "^secret/"'
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AuditTestFilterCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetNone)
+}
+
+func (c *AuditTestFilterCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *AuditTestFilterCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AuditTestFilterCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1-2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1-2, got %d)", len(args)))
+		return 1
+	}
+
+	filter, err := monitor.ParseFilter(args[0])
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing filter: %s", err), err)
+		return 1
+	}
+	for _, field := range filter.Fields() {
+		switch field {
+		case "level", "subsystem", "message":
+			c.UI.Error(fmt.Sprintf("Invalid filter: %s is a field of log entries, audit entries have no such field", field))
+			return 1
+		}
+	}
+
+	path := "-"
+	if len(args) == 2 {
+		path = strings.TrimSpace(args[1])
+	}
+
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+		if c.testStdin != nil {
+			r = c.testStdin
+		}
+	} else {
+		expanded, err := homedir.Expand(path)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+			return 1
+		}
+		file, err := os.Open(expanded)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error opening file: %s", err), err)
+			return 1
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var entry map[string]interface{}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&entry); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error decoding audit entry: %s", err), err)
+		return 1
+	}
+
+	fields := auditFilterFields("", entry, nil)
+	matcher, field, found := filter.Mismatch("", log.NoLevel, "", fields...)
+	if !found {
+		c.UI.Output("The audit entry matches the filter.")
+		return 0
+	}
+
+	value := "the entry has no such field"
+	for i := 0; i < len(fields); i += 2 {
+		if strings.EqualFold(fields[i].(string), field) {
+			value = fmt.Sprintf("the value of the entry is %q", fields[i+1])
+			break
+		}
+	}
+	c.UI.Output(fmt.Sprintf("The audit entry does not match the filter: %s is not satisfied, %s.", matcher, value))
+	return 2
+}
+
+// auditFilterFields flattens an audit entry into the alternating keys and
+// values matched by filters. Nested keys are joined with dots, and lists are
+// joined with commas. Null values are left out.
+func auditFilterFields(prefix string, value interface{}, fields []interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			fields = auditFilterFields(key, v[k], fields)
+		}
+	case []interface{}:
+		elems := make([]string, 0, len(v))
+		for _, elem := range v {
+			switch elem.(type) {
+			case map[string]interface{}, []interface{}:
+				b, _ := json.Marshal(elem)
+				elems = append(elems, string(b))
+			default:
+				elems = append(elems, fmt.Sprint(elem))
+			}
+		}
+		fields = append(fields, prefix, strings.Join(elems, ","))
+	default:
+		fields = append(fields, prefix, fmt.Sprint(v))
+	}
+	return fields
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testAuditTestFilterCommand(tb testing.TB) (*cli.MockUi, *AuditTestFilterCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AuditTestFilterCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestAuditTestFilterCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	entry := `{
+  "type": "response",
+  "auth": {"policies": ["default", "admin"], "entity_id": "e1"},
+  "request": {"operation": "update", "path": "sys/mounts/kv", "namespace": {"id": "root"}},
+  "response": {"data": {"ttl": 3600}},
+  "error": null
+}`
+
+	cases := []struct {
+		name  string
+		args  []string
+		stdin string
+		out   string
+		code  int
+	}{
+		{
+			"not_enough_args",
+			nil,
+			"",
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"type == response", "foo", "bar"},
+			"",
+			"Too many arguments",
+			1,
+		},
+		{
+			"invalid_filter",
+			[]string{"type"},
+			entry,
+			"Error parsing filter",
+			1,
+		},
+		{
+			"log_field",
+			[]string{"level >= warn"},
+			entry,
+			"level is a field of log entries",
+			1,
+		},
+		{
+			"invalid_entry",
+			[]string{"type == response"},
+			"not json",
+			"Error decoding audit entry",
+			1,
+		},
+		{
+			"match",
+			[]string{`type == response and request.path =~ "^sys/" and auth.policies =~ "(^|,)admin(,|$)" and response.data.ttl == 3600`},
+			entry,
+			"The audit entry matches the filter.",
+			0,
+		},
+		{
+			"mismatch",
+			[]string{`type == response and request.path =~ "^secret/"`, "-"},
+			entry,
+			`request.path =~ "^secret/" is not satisfied, the value of the entry is "sys/mounts/kv"`,
+			2,
+		},
+		{
+			"missing_field",
+			[]string{"error =~ denied"},
+			entry,
+			"error =~ denied is not satisfied, the entry has no such field",
+			2,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := testAuditTestFilterCommand(t)
+			cmd.testStdin = strings.NewReader(tc.stdin)
+
+			code := cmd.Run(tc.args)
+			if code != tc.code {
+				t.Errorf("expected %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
@@ -80,7 +80,7 @@ func (c *AuthDisableCommand) Run(args []string) int {
 	}
 
 	if err := client.Sys().DisableAuth(path); err != nil {
-		c.UI.Error(fmt.Sprintf("Error disabling auth method at %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error disabling auth method at %s: %s", path, err), err)
 		return 2
 	}
 
@@ -301,7 +301,7 @@ func (c *AuthEnableCommand) Run(args []string) int {
 	})
 
 	if err := client.Sys().EnableAuthWithOptions(authPath, authOpts); err != nil {
-		c.UI.Error(fmt.Sprintf("Error enabling %s auth: %s", authType, err))
+		outputError(c.UI, fmt.Sprintf("Error enabling %s auth: %s", authType, err), err)
 		return 2
 	}
 
@@ -99,7 +99,7 @@ func (c *AuthHelpCommand) Run(args []string) int {
 		// There was no auth type by that name, see if it's a mount
 		auths, err := client.Sys().ListAuth()
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error listing auth methods: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error listing auth methods: %s", err), err)
 			return 2
 		}
 
@@ -94,18 +94,16 @@ func (c *AuthListCommand) Run(args []string) int {
 
 	auths, err := client.Sys().ListAuth()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing enabled authentications: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error listing enabled authentications: %s", err), err)
 		return 2
 	}
 
 	switch Format(c.UI) {
 	case "table":
 		if c.flagDetailed {
-			c.UI.Output(tableOutput(c.detailedMounts(auths), nil))
-			return 0
+			return OutputTable(c.UI, c.detailedMounts(auths), nil)
 		}
-		c.UI.Output(tableOutput(c.simpleMounts(auths), nil))
-		return 0
+		return OutputTable(c.UI, c.simpleMounts(auths), nil)
 	default:
 		return OutputData(c.UI, auths)
 	}
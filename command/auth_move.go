@@ -93,7 +93,7 @@ func (c *AuthMoveCommand) Run(args []string) int {
 
 	remountResp, err := client.Sys().StartRemount(source, destination)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error moving auth method %s to %s: %s", source, destination, err))
+		outputError(c.UI, fmt.Sprintf("Error moving auth method %s to %s: %s", source, destination, err), err)
 		return 2
 	}
 
@@ -104,7 +104,7 @@ func (c *AuthMoveCommand) Run(args []string) int {
 	for {
 		remountStatusResp, err := client.Sys().RemountStatus(remountResp.MigrationID)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error checking migration status of auth method %s to %s: %s", source, destination, err))
+			outputError(c.UI, fmt.Sprintf("Error checking migration status of auth method %s to %s: %s", source, destination, err), err)
 			return 2
 		}
 		if remountStatusResp.MigrationInfo.MigrationStatus == MountMigrationStatusSuccess {
@@ -228,7 +228,7 @@ func (c *AuthTuneCommand) Run(args []string) int {
 	mountPath := ensureTrailingSlash(sanitizePath(args[0]))
 
 	if err := client.Sys().TuneMount("/auth/"+mountPath, mountConfigInput); err != nil {
-		c.UI.Error(fmt.Sprintf("Error tuning auth method %s: %s", mountPath, err))
+		outputError(c.UI, fmt.Sprintf("Error tuning auth method %s: %s", mountPath, err), err)
 		return 2
 	}
 
@@ -2,10 +2,13 @@ package command
 
 import (
 	"bytes"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
@@ -13,8 +16,10 @@ import (
 	"time"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/config"
 	"github.com/hashicorp/vault/command/token"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/mitchellh/cli"
 	"github.com/pkg/errors"
 	"github.com/posener/complete"
@@ -40,6 +45,7 @@ type BaseCommand struct {
 
 	flagAddress        string
 	flagAgentAddress   string
+	flagProfile        string
 	flagCACert         string
 	flagCAPath         string
 	flagClientCert     string
@@ -51,10 +57,31 @@ type BaseCommand struct {
 	flagTLSSkipVerify  bool
 	flagWrapTTL        time.Duration
 	flagUnlockKey      string
+	flagRetries        int
+	flagRetryMinWait   time.Duration
+	flagRetryMaxWait   time.Duration
+	flagRateLimit      string
+
+	flagHTTPVersion     string
+	flagMaxIdleConns    int
+	flagIdleConnTimeout time.Duration
+	flagTCPKeepAlive    time.Duration
+	flagSSHJump         string
+	flagCacheTTL        time.Duration
 
 	flagFormat           string
+	flagTemplate         string
+	flagEnvPrefix        string
+	flagEnvUppercase     bool
+	flagColumns          []string
+	flagSortBy           string
+	flagRedact           bool
+	flagReveal           bool
 	flagField            string
+	flagOutputFile       string
+	flagDecodeBase64     bool
 	flagOutputCurlString bool
+	flagOutputRequest    string
 	flagNonInteractive   bool
 
 	flagMFA []string
@@ -63,6 +90,17 @@ type BaseCommand struct {
 
 	tokenHelper token.TokenHelper
 
+	// loginHandlers are the handlers of the auth methods, used to log in
+	// again when the token expired.
+	loginHandlers map[string]LoginHandler
+
+	// telemetry records the metrics of the requests of the invocation. It is
+	// nil if telemetry is disabled.
+	telemetry *cliTelemetry
+
+	// cliConfigLoader loads the CLI configuration once per invocation
+	cliConfigLoader *cliConfigLoader
+
 	client *api.Client
 }
 
@@ -74,6 +112,10 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 		return c.client, nil
 	}
 
+	if err := c.applyProfile(); err != nil {
+		return nil, err
+	}
+
 	config := api.DefaultConfig()
 
 	if err := config.ReadEnvironment(); err != nil {
@@ -87,8 +129,8 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 		config.Address = c.flagAgentAddress
 	}
 
-	if c.flagOutputCurlString {
-		config.OutputCurlString = c.flagOutputCurlString
+	if c.flagOutputCurlString || c.flagOutputRequest != "" {
+		config.OutputCurlString = true
 	}
 
 	// If we need custom TLS configuration, then set it
@@ -109,20 +151,49 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 		}
 	}
 
+	if err := c.configureTransport(config.HttpClient.Transport.(*http.Transport)); err != nil {
+		return nil, errors.Wrap(err, "failed to configure the HTTP transport")
+	}
+
 	// Build the client
 	client, err := api.NewClient(config)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create client")
 	}
 
-	// Turn off retries on the CLI
-	if os.Getenv(api.EnvVaultMaxRetries) == "" {
-		client.SetMaxRetries(0)
+	// Turn off retries on the CLI unless they were requested
+	if c.flagRetries > 0 || os.Getenv(api.EnvVaultMaxRetries) == "" {
+		client.SetMaxRetries(c.flagRetries)
+	}
+	if c.flagRetryMinWait > 0 {
+		client.SetMinRetryWait(c.flagRetryMinWait)
+	}
+	if c.flagRetryMaxWait > 0 {
+		client.SetMaxRetryWait(c.flagRetryMaxWait)
+	}
+	client.SetBackoff(c.telemetry.countRetries(exponentialJitterBackoff))
+	client.SetCheckRetry(c.telemetry.recordResponses(cliRetryPolicy))
+
+	if c.flagRateLimit != "" {
+		rateLimit, burst, err := parseRateLimit(c.flagRateLimit)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid rate limit")
+		}
+		client.SetLimiter(rateLimit, burst)
 	}
 
 	// Set the wrapping function
 	client.SetWrappingLookupFunc(c.DefaultWrappingLookupFunc)
 
+	// flagNS takes precedence over flagNamespace. After resolution, point both
+	// flags to the same value to be able to use them interchangeably anywhere.
+	if c.flagNS != notSetValue {
+		c.flagNamespace = c.flagNS
+	}
+	if c.flagNamespace != notSetValue {
+		client.SetNamespace(namespace.Canonicalize(c.flagNamespace))
+	}
+
 	// Get the token if it came in from the environment
 	token := client.Token()
 
@@ -132,7 +203,7 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get token helper")
 		}
-		token, err = helper.Get()
+		token, err = scopeTokenHelper(helper, client).Get()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get token from token helper")
 		}
@@ -145,14 +216,6 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 
 	client.SetMFACreds(c.flagMFA)
 
-	// flagNS takes precedence over flagNamespace. After resolution, point both
-	// flags to the same value to be able to use them interchangeably anywhere.
-	if c.flagNS != notSetValue {
-		c.flagNamespace = c.flagNS
-	}
-	if c.flagNamespace != notSetValue {
-		client.SetNamespace(namespace.Canonicalize(c.flagNamespace))
-	}
 	if c.flagPolicyOverride {
 		client.SetPolicyOverride(c.flagPolicyOverride)
 	}
@@ -162,7 +225,7 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 		var forbiddenHeaders []string
 		for key, val := range c.flagHeader {
 
-			if strings.HasPrefix(key, "X-Vault-") {
+			if strings.HasPrefix(http.CanonicalHeaderKey(key), "X-Vault-") {
 				forbiddenHeaders = append(forbiddenHeaders, key)
 				continue
 			}
@@ -174,11 +237,68 @@ func (c *BaseCommand) Client() (*api.Client, error) {
 		}
 	}
 
+	if err := c.configureReauth(client); err != nil {
+		return nil, err
+	}
+
 	c.client = client
 
 	return client, nil
 }
 
+// configureTransport applies the transport flags to the transport of the
+// client, which api.DefaultConfig sets up to negotiate HTTP/2 over TLS.
+func (c *BaseCommand) configureTransport(transport *http.Transport) error {
+	switch c.flagHTTPVersion {
+	case "":
+	case "1.1":
+		// An empty, non-nil TLSNextProto disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		if transport.TLSClientConfig != nil {
+			transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		}
+	case "2":
+		// Fail rather than fall back to HTTP/1.1 when the server or a proxy
+		// does not negotiate HTTP/2
+		if transport.TLSClientConfig != nil {
+			transport.TLSClientConfig.NextProtos = []string{"h2"}
+		}
+	default:
+		return fmt.Errorf("invalid HTTP version %q, must be \"1.1\" or \"2\"", c.flagHTTPVersion)
+	}
+
+	if c.flagMaxIdleConns < 0 {
+		return fmt.Errorf("maximum number of idle connections must not be negative")
+	}
+	if c.flagMaxIdleConns > 0 {
+		transport.MaxIdleConns = c.flagMaxIdleConns
+		transport.MaxIdleConnsPerHost = c.flagMaxIdleConns
+	}
+	if c.flagIdleConnTimeout < 0 {
+		return fmt.Errorf("idle connection timeout must not be negative")
+	}
+	if c.flagIdleConnTimeout > 0 {
+		transport.IdleConnTimeout = c.flagIdleConnTimeout
+	}
+	if c.flagTCPKeepAlive != 0 {
+		// Same dialer as cleanhttp, except for the keep-alive interval
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: c.flagTCPKeepAlive,
+			DualStack: true,
+		}).DialContext
+	}
+	if c.flagSSHJump != "" {
+		dialer, err := newSSHJumpDialer(c.flagSSHJump, os.Getenv("SSH_AUTH_SOCK"), defaultKnownHostsFile())
+		if err != nil {
+			return err
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	return nil
+}
+
 // SetAddress sets the token helper on the command; useful for the demo server and other outside cases.
 func (c *BaseCommand) SetAddress(addr string) {
 	c.flagAddress = addr
@@ -189,19 +309,179 @@ func (c *BaseCommand) SetTokenHelper(th token.TokenHelper) {
 	c.tokenHelper = th
 }
 
-// TokenHelper returns the token helper attached to the command.
+// TokenHelper returns the token helper attached to the command. Once the
+// client has been created, helpers which store a token per server and
+// namespace are scoped to those of the client.
 func (c *BaseCommand) TokenHelper() (token.TokenHelper, error) {
-	if c.tokenHelper != nil {
-		return c.tokenHelper, nil
+	helper := c.tokenHelper
+	if helper == nil {
+		var err error
+		helper, err = config.ProfileTokenHelper(c.flagProfile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	helper, err := DefaultTokenHelper()
-	if err != nil {
-		return nil, err
+	if c.client != nil {
+		helper = scopeTokenHelper(helper, c.client)
 	}
 	return helper, nil
 }
 
+// scopeTokenHelper scopes helper to the address and namespace of client if it
+// stores a token per server and namespace.
+func scopeTokenHelper(helper token.TokenHelper, client *api.Client) token.TokenHelper {
+	if scoped, ok := helper.(token.ScopedTokenHelper); ok {
+		return scoped.ForScope(client.Address(), client.Headers().Get(consts.NamespaceHeaderName))
+	}
+	return helper
+}
+
+// applyProfile sets the HTTP flags from the profile selected with -profile.
+// Flags which were given on the command line or through their environment
+// variable take precedence over the profile.
+func (c *BaseCommand) applyProfile() error {
+	if c.flagProfile == "" {
+		return nil
+	}
+
+	conf, err := c.cliConfig()
+	if err != nil {
+		return err
+	}
+	profile, err := conf.Profile(c.flagProfile)
+	if err != nil {
+		return err
+	}
+
+	set := make(map[string]bool)
+	if c.flags != nil {
+		c.flags.Visit(func(f *flag.Flag) {
+			set[f.Name] = true
+		})
+	}
+	explicit := func(name, envVar string) bool {
+		_, ok := os.LookupEnv(envVar)
+		return set[name] || ok
+	}
+
+	for _, s := range []struct {
+		name   string
+		envVar string
+		value  string
+		target *string
+	}{
+		{flagNameAddress, api.EnvVaultAddress, profile.Address, &c.flagAddress},
+		{"namespace", api.EnvVaultNamespace, profile.Namespace, &c.flagNamespace},
+		{flagNameCACert, api.EnvVaultCACert, profile.CACert, &c.flagCACert},
+		{flagNameCAPath, api.EnvVaultCAPath, profile.CAPath, &c.flagCAPath},
+		{flagNameClientCert, api.EnvVaultClientCert, profile.ClientCert, &c.flagClientCert},
+		{flagNameClientKey, api.EnvVaultClientKey, profile.ClientKey, &c.flagClientKey},
+		{flagTLSServerName, api.EnvVaultTLSServerName, profile.TLSServerName, &c.flagTLSServerName},
+	} {
+		if s.value != "" && !explicit(s.name, s.envVar) {
+			*s.target = s.value
+		}
+	}
+	if profile.TLSSkipVerify && !explicit(flagNameTLSSkipVerify, api.EnvVaultSkipVerify) {
+		c.flagTLSSkipVerify = true
+	}
+
+	return nil
+}
+
+// cliConfigLoader loads the CLI configuration on first use, so that the
+// settings read from it during an invocation load it only once.
+type cliConfigLoader struct {
+	once sync.Once
+	conf *config.DefaultConfig
+	err  error
+}
+
+func (l *cliConfigLoader) load() (*config.DefaultConfig, error) {
+	l.once.Do(func() {
+		l.conf, l.err = config.LoadConfig("")
+		if l.err != nil {
+			l.err = errors.Wrap(l.err, "failed to load CLI configuration")
+		}
+	})
+	return l.conf, l.err
+}
+
+// cliConfig returns the CLI configuration.
+func (c *BaseCommand) cliConfig() (*config.DefaultConfig, error) {
+	if c.cliConfigLoader == nil {
+		c.cliConfigLoader = &cliConfigLoader{}
+	}
+	return c.cliConfigLoader.load()
+}
+
+// applyOutputOptions configures the UI with the output flags of the parsed
+// command. The output format of the profile selected with -profile, and the
+// redact setting of the CLI configuration, apply unless set with a flag or an
+// environment variable.
+func (c *BaseCommand) applyOutputOptions() error {
+	ui, ok := c.UI.(*VaultUI)
+	if !ok {
+		return nil
+	}
+
+	set := make(map[string]bool)
+	c.flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	ui.template = c.flagTemplate
+	ui.envPrefix = c.flagEnvPrefix
+	ui.envUppercase = c.flagEnvUppercase
+	ui.outputFile = c.flagOutputFile
+	ui.outputDecodeBase64 = c.flagDecodeBase64
+	ui.tableColumns = nil
+	for _, columns := range c.flagColumns {
+		for _, column := range strings.Split(columns, ",") {
+			ui.tableColumns = append(ui.tableColumns, strings.TrimSpace(column))
+		}
+	}
+	ui.tableSortBy = c.flagSortBy
+	ui.outputRequest = strings.ToLower(c.flagOutputRequest)
+
+	if c.flags.mainSet.Lookup("format") == nil {
+		return nil
+	}
+
+	if _, ok := os.LookupEnv(EnvVaultFormat); c.flagProfile != "" && !set["format"] && !ok {
+		// Errors loading the profile are reported once the client is built
+		if conf, err := c.cliConfig(); err == nil {
+			if profile, err := conf.Profile(c.flagProfile); err == nil && profile.Format != "" {
+				format := strings.ToLower(profile.Format)
+				if _, ok := Formatters[format]; !ok {
+					return fmt.Errorf("Invalid output format in profile %q: %s", c.flagProfile, format)
+				}
+				ui.format = format
+				c.flagFormat = format
+			}
+		}
+	}
+
+	// -reveal takes precedence over -redact and VAULT_REDACT, which take
+	// precedence over the redact setting of the CLI configuration
+	_, envRedact := os.LookupEnv(EnvVaultRedact)
+	switch {
+	case c.flagReveal:
+		ui.redact = false
+	case set["redact"] || envRedact:
+		ui.redact = c.flagRedact
+	default:
+		conf, err := c.cliConfig()
+		if err != nil {
+			return err
+		}
+		ui.redact = conf.Redact
+	}
+
+	return nil
+}
+
 // DefaultWrappingLookupFunc is the default wrapping function based on the
 // CLI flag.
 func (c *BaseCommand) DefaultWrappingLookupFunc(operation, path string) string {
@@ -258,6 +538,18 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 			}
 			f.StringVar(agentAddrStringVar)
 
+			f.StringVar(&StringVar{
+				Name:       "profile",
+				Target:     &c.flagProfile,
+				Default:    "",
+				EnvVar:     config.ProfileEnv,
+				Completion: complete.PredictAnything,
+				Usage: "Name of a profile in the CLI configuration file to read " +
+					"the address, namespace, TLS settings, token helper and output " +
+					"format from. Flags and environment variables take precedence " +
+					"over the profile.",
+			})
+
 			f.StringVar(&StringVar{
 				Name:       flagNameCACert,
 				Target:     &c.flagCACert,
@@ -377,6 +669,15 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 					"command string and exit.",
 			})
 
+			f.StringVar(&StringVar{
+				Name:       "output-request",
+				Target:     &c.flagOutputRequest,
+				Completion: complete.PredictSet(outputRequestLanguages...),
+				Usage: "Instead of executing the request, print code making the " +
+					"equivalent API call in the given language and exit. Supported " +
+					"languages are \"go\", \"powershell\" and \"python\".",
+			})
+
 			f.StringVar(&StringVar{
 				Name:       "unlock-key",
 				Target:     &c.flagUnlockKey,
@@ -385,13 +686,112 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 				Usage:      "Key to unlock a namespace API lock.",
 			})
 
-			f.StringMapVar(&StringMapVar{
+			f.VarFlag(&VarFlag{
 				Name:       "header",
-				Target:     &c.flagHeader,
+				Value:      (*headerMapValue)(&c.flagHeader),
+				Completion: complete.PredictAnything,
+				Usage: "HTTP header to add to every request made by the CLI, given " +
+					"as \"Name: value\" or \"Name=value\". Headers starting with " +
+					"\"X-Vault-\" are reserved and make the command fail. This can " +
+					"be specified multiple times.",
+			})
+
+			f.IntVar(&IntVar{
+				Name:       "retries",
+				Target:     &c.flagRetries,
+				Default:    0,
+				EnvVar:     api.EnvVaultMaxRetries,
+				Completion: complete.PredictAnything,
+				Usage: "Number of times to retry a request which failed with a " +
+					"connection error, a 412, 429 or 5xx response, or because a " +
+					"standby could not reach the active node. Retries are spaced " +
+					"with jittered exponential backoff.",
+			})
+
+			f.DurationVar(&DurationVar{
+				Name:       "retry-min-wait",
+				Target:     &c.flagRetryMinWait,
+				Default:    0,
+				EnvVar:     EnvVaultRetryMinWait,
+				Completion: complete.PredictAnything,
+				Usage: "Minimum time to wait before retrying a request. The " +
+					"default is 1s.",
+			})
+
+			f.DurationVar(&DurationVar{
+				Name:       "retry-max-wait",
+				Target:     &c.flagRetryMaxWait,
+				Default:    0,
+				EnvVar:     EnvVaultRetryMaxWait,
+				Completion: complete.PredictAnything,
+				Usage: "Maximum time to wait before retrying a request. The " +
+					"default is 1.5s.",
+			})
+
+			f.StringVar(&StringVar{
+				Name:       "rate-limit",
+				Target:     &c.flagRateLimit,
+				Default:    "",
+				EnvVar:     api.EnvRateLimit,
+				Completion: complete.PredictAnything,
+				Usage: "Maximum number of requests per second the CLI sends to " +
+					"Vault, given as \"RATE\" or \"RATE:BURST\". This is useful to " +
+					"stay under rate limit quotas when operating on many secrets.",
+			})
+
+			f.StringVar(&StringVar{
+				Name:       "http-version",
+				Target:     &c.flagHTTPVersion,
+				Default:    "",
+				EnvVar:     EnvVaultHTTPVersion,
+				Completion: complete.PredictSet("1.1", "2"),
+				Usage: "HTTP version to use with the Vault server, either \"1.1\" " +
+					"or \"2\". By default, HTTP/2 is negotiated over TLS and HTTP/1.1 " +
+					"is used otherwise. Forcing HTTP/1.1 works around proxies and " +
+					"middleboxes which break HTTP/2.",
+			})
+
+			f.IntVar(&IntVar{
+				Name:       "max-idle-conns",
+				Target:     &c.flagMaxIdleConns,
+				Default:    0,
+				EnvVar:     EnvVaultMaxIdleConns,
+				Completion: complete.PredictAnything,
+				Usage: "Maximum number of idle connections to the Vault server " +
+					"kept open for reuse. The default is the number of CPUs plus one.",
+			})
+
+			f.DurationVar(&DurationVar{
+				Name:       "idle-conn-timeout",
+				Target:     &c.flagIdleConnTimeout,
+				Default:    0,
+				EnvVar:     EnvVaultIdleConnTimeout,
+				Completion: complete.PredictAnything,
+				Usage: "How long an idle connection to the Vault server is kept " +
+					"open before being closed. The default is 90s.",
+			})
+
+			f.DurationVar(&DurationVar{
+				Name:       "tcp-keepalive",
+				Target:     &c.flagTCPKeepAlive,
+				Default:    0,
+				EnvVar:     EnvVaultTCPKeepAlive,
+				Completion: complete.PredictAnything,
+				Usage: "Interval between the TCP keep-alive probes sent on the " +
+					"connections to the Vault server. A negative value disables " +
+					"keep-alive probes. The default is 30s.",
+			})
+
+			f.StringVar(&StringVar{
+				Name:       "ssh-jump",
+				Target:     &c.flagSSHJump,
+				Default:    "",
+				EnvVar:     EnvVaultSSHJump,
 				Completion: complete.PredictAnything,
-				Usage: "Key-value pair provided as key=value to provide http header added to any request done by the CLI." +
-					"Trying to add headers starting with 'X-Vault-' is forbidden and will make the command fail " +
-					"This can be specified multiple times.",
+				Usage: "SSH jump host, given as \"[user@]host[:port]\", through " +
+					"which to connect to the Vault server. The user is authenticated " +
+					"with the keys of the SSH agent and the key of the jump host " +
+					"must be in ~/.ssh/known_hosts.",
 			})
 
 			f.BoolVar(&BoolVar{
@@ -417,6 +817,24 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 						"directives. The result will not have a trailing newline " +
 						"making it ideal for piping to other processes.",
 				})
+
+				f.StringVar(&StringVar{
+					Name:       "output-file",
+					Target:     &c.flagOutputFile,
+					Default:    "",
+					Completion: complete.PredictFiles("*"),
+					Usage: "When used with -field, write the raw value of the field " +
+						"to the given file, readable only by the current user, instead " +
+						"of printing it.",
+				})
+
+				f.BoolVar(&BoolVar{
+					Name:    "decode-base64",
+					Target:  &c.flagDecodeBase64,
+					Default: false,
+					Usage: "When used with -output-file, base64-decode the field " +
+						"value before writing it to the file.",
+				})
 			}
 
 			if bit&FlagSetOutputFormat != 0 {
@@ -425,13 +843,80 @@ func (c *BaseCommand) flagSet(bit FlagSetBit) *FlagSets {
 					Target:     &c.flagFormat,
 					Default:    "table",
 					EnvVar:     EnvVaultFormat,
-					Completion: complete.PredictSet("table", "json", "yaml", "pretty"),
+					Completion: complete.PredictSet("table", "json", "jsonl", "yaml", "pretty", "template", "env"),
 					Usage: `Print the output in the given format. Valid formats
-						are "table", "json", "yaml", or "pretty".`,
+						are "table", "json", "jsonl", "yaml", "pretty", "template",
+						or "env".`,
+				})
+
+				f.StringVar(&StringVar{
+					Name:       "template",
+					Target:     &c.flagTemplate,
+					Default:    "",
+					EnvVar:     EnvVaultFormatTemplate,
+					Completion: complete.PredictFiles("*"),
+					Usage: "Go template used to render the output when -format is " +
+						"\"template\". If the value begins with \"@\", the template is " +
+						"read from the given file.",
+				})
+
+				f.StringVar(&StringVar{
+					Name:       "env-prefix",
+					Target:     &c.flagEnvPrefix,
+					Default:    "",
+					Completion: complete.PredictAnything,
+					Usage: "Prefix prepended to every variable name when -format " +
+						"is \"env\".",
+				})
+
+				f.BoolVar(&BoolVar{
+					Name:    "env-uppercase",
+					Target:  &c.flagEnvUppercase,
+					Default: false,
+					Usage:   "Uppercase variable names when -format is \"env\".",
+				})
+
+				f.StringSliceVar(&StringSliceVar{
+					Name:       "columns",
+					Target:     &c.flagColumns,
+					Default:    nil,
+					Completion: complete.PredictAnything,
+					Usage: "Comma-separated list of columns to display, in order, " +
+						"when -format is \"table\". For key/value output, this " +
+						"selects the keys to display.",
+				})
+
+				f.StringVar(&StringVar{
+					Name:       "sort-by",
+					Target:     &c.flagSortBy,
+					Default:    "",
+					Completion: complete.PredictAnything,
+					Usage: "Name of the column used to sort rows when -format is " +
+						"\"table\".",
+				})
+
+				f.BoolVar(&BoolVar{
+					Name:    "redact",
+					Target:  &c.flagRedact,
+					Default: false,
+					EnvVar:  EnvVaultRedact,
+					Usage: "Mask the secret values when -format is \"table\", " +
+						"showing only their length. This can also be enabled with " +
+						"the \"redact\" setting of the CLI configuration.",
+				})
+
+				f.BoolVar(&BoolVar{
+					Name:    "reveal",
+					Target:  &c.flagReveal,
+					Default: false,
+					Usage: "Show the secret values in table output even if " +
+						"-redact is set in the environment or the CLI configuration.",
 				})
 			}
 		}
 
+		set.parseHooks = append(set.parseHooks, c.applyOutputOptions)
+
 		c.flags = set
 	})
 
@@ -444,6 +929,9 @@ type FlagSets struct {
 	mainSet     *flag.FlagSet
 	hiddens     map[string]struct{}
 	completions complete.Flags
+
+	// parseHooks are called once the flags are parsed successfully
+	parseHooks []func() error
 }
 
 // NewFlagSets creates a new flag sets.
@@ -478,7 +966,15 @@ func (f *FlagSets) Completions() complete.Flags {
 
 // Parse parses the given flags, returning any errors.
 func (f *FlagSets) Parse(args []string) error {
-	return f.mainSet.Parse(args)
+	if err := f.mainSet.Parse(args); err != nil {
+		return err
+	}
+	for _, hook := range f.parseHooks {
+		if err := hook(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Parsed reports whether the command-line flags have been parsed.
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"net/http"
 	"os"
 	"sort"
 	"strconv"
@@ -773,6 +774,28 @@ func (s *stringMapValue) String() string   { return mapToKV(*s.target) }
 func (s *stringMapValue) Example() string  { return "key=value" }
 func (s *stringMapValue) Hidden() bool     { return s.hidden }
 
+// headerMapValue is a map of HTTP headers which accepts both "Name: value"
+// and "Name=value" pairs. Header names are canonicalized.
+type headerMapValue map[string]string
+
+func (h *headerMapValue) Set(val string) error {
+	idx := strings.IndexAny(val, ":=")
+	if idx < 1 {
+		return fmt.Errorf("header must be given as \"Name: value\" or \"Name=value\": %q", val)
+	}
+
+	if *h == nil {
+		*h = make(map[string]string)
+	}
+
+	k := http.CanonicalHeaderKey(strings.TrimSpace(val[:idx]))
+	(*h)[k] = strings.TrimSpace(val[idx+1:])
+	return nil
+}
+
+func (h *headerMapValue) String() string  { return mapToKV(*h) }
+func (h *headerMapValue) Example() string { return "Name: value" }
+
 func mapToKV(m map[string]string) string {
 	list := make([]string, 0, len(m))
 	for k := range m {
@@ -152,3 +152,20 @@ func Test_TimeParsing(t *testing.T) {
 		}
 	}
 }
+
+func Test_HeaderMapValue(t *testing.T) {
+	var headers map[string]string
+	value := (*headerMapValue)(&headers)
+
+	require.NoError(t, value.Set("X-Trace-Id: abc:123"))
+	require.NoError(t, value.Set("authorization=Basic Zm9vOmJhcg=="))
+	require.NoError(t, value.Set("x-empty:"))
+	require.Error(t, value.Set("no-separator"))
+	require.Error(t, value.Set(": value"))
+
+	require.Equal(t, map[string]string{
+		"X-Trace-Id":    "abc:123",
+		"Authorization": "Basic Zm9vOmJhcg==",
+		"X-Empty":       "",
+	}, headers)
+}
@@ -1,16 +1,31 @@
 package command
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
 	kvbuilder "github.com/hashicorp/go-secure-stdlib/kv-builder"
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/kr/text"
+	"github.com/mitchellh/cli"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -130,12 +145,233 @@ func tableOutput(list []string, c *columnize.Config) string {
 // parseArgsData parses the given args in the format key=value into a map of
 // the provided arguments. The given reader can also supply key=value pairs.
 func parseArgsData(stdin io.Reader, args []string) (map[string]interface{}, error) {
+	args, err := expandBase64FileArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
 	builder := &kvbuilder.Builder{Stdin: stdin}
 	if err := builder.Add(args...); err != nil {
 		return nil, err
 	}
 
-	return builder.Map(), nil
+	return parseTypedKeys(builder.Map(), literalArgKeys(args))
+}
+
+// literalArgKeys returns the keys given as key=value arguments, as opposed to
+// the keys of the JSON objects read from stdin with "-" or from files with
+// "@file".
+func literalArgKeys(args []string) map[string]bool {
+	keys := make(map[string]bool, len(args))
+	for _, arg := range args {
+		if parts := strings.SplitN(arg, "=", 2); len(parts) == 2 {
+			keys[parts[0]] = true
+		}
+	}
+	return keys
+}
+
+// base64FilePrefix is the value prefix which causes a file to be read and
+// base64-encoded before it is sent, e.g. "plaintext=@b64:file.bin".
+const base64FilePrefix = "@b64:"
+
+// expandBase64FileArgs replaces the value of any key=value argument using the
+// base64FilePrefix with the base64-encoded contents of the named file.
+func expandBase64FileArgs(args []string) ([]string, error) {
+	result := make([]string, len(args))
+	for i, arg := range args {
+		result[i] = arg
+
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[1], base64FilePrefix) {
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(expandPath(strings.TrimPrefix(parts[1], base64FilePrefix)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key/value pair %q: error reading file: %w", arg, err)
+		}
+		result[i] = parts[0] + "=" + base64.StdEncoding.EncodeToString(contents)
+	}
+
+	return result, nil
+}
+
+// typedValueParsers maps the type suffixes accepted in K=V arguments, such as
+// "ttl:int=300", to the function which converts the raw string value.
+var typedValueParsers = map[string]func(string) (interface{}, error){
+	"string": func(s string) (interface{}, error) {
+		return s, nil
+	},
+	"int": func(s string) (interface{}, error) {
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	},
+	"float": func(s string) (interface{}, error) {
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	},
+	"bool": func(s string) (interface{}, error) {
+		return strconv.ParseBool(strings.TrimSpace(s))
+	},
+	"json": func(s string) (interface{}, error) {
+		var v interface{}
+		dec := json.NewDecoder(strings.NewReader(s))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	},
+}
+
+// parseTypedKeys converts the values of the given literal keys carrying an
+// explicit type suffix (e.g. "enabled:bool") and strips the suffix from the
+// key. Keys with an unknown suffix, and keys read as JSON, are left untouched.
+func parseTypedKeys(data map[string]interface{}, literal map[string]bool) (map[string]interface{}, error) {
+	// Iterate over a sorted copy of the keys, as data is modified below
+	keys := make([]string, 0, len(literal))
+	for key := range literal {
+		if _, ok := data[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		raw := data[key]
+		idx := strings.LastIndex(key, ":")
+		if idx <= 0 {
+			continue
+		}
+
+		name, typ := key[:idx], key[idx+1:]
+		parser, ok := typedValueParsers[typ]
+		if !ok {
+			continue
+		}
+		if _, ok := data[name]; ok {
+			return nil, fmt.Errorf("key %q specified both with and without a type", name)
+		}
+
+		var value interface{}
+		switch raw := raw.(type) {
+		case string:
+			v, err := parser(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s value for key %q: %w", typ, name, err)
+			}
+			value = v
+		case []interface{}:
+			values := make([]interface{}, 0, len(raw))
+			for _, elem := range raw {
+				s, ok := elem.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid %s value for key %q", typ, name)
+				}
+				v, err := parser(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s value for key %q: %w", typ, name, err)
+				}
+				values = append(values, v)
+			}
+			value = values
+		default:
+			// Values decoded from JSON input already carry their type
+			value = raw
+		}
+
+		delete(data, key)
+		data[name] = value
+	}
+
+	return data, nil
+}
+
+// splitNestedKey splits a key using dotted ("a.b.c") or bracketed
+// ("a[b][c]") syntax into its individual segments.
+func splitNestedKey(key string) ([]string, error) {
+	if key == "" {
+		return nil, errors.New("empty key")
+	}
+
+	var segments []string
+
+	rest := key
+	for rest != "" {
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated bracket in key %q", key)
+			}
+			segments = append(segments, rest[1:end])
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		}
+
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, fmt.Errorf("empty segment in key %q", key)
+			}
+		}
+	}
+
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("empty segment in key %q", key)
+		}
+	}
+
+	return segments, nil
+}
+
+// expandNestedKeys expands dotted or bracketed keys, such as
+// "config.connection.url", into nested maps.
+func expandNestedKeys(data map[string]interface{}) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]interface{}, len(data))
+	for _, key := range keys {
+		segments, err := splitNestedKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		current := result
+		for i, segment := range segments[:len(segments)-1] {
+			next, ok := current[segment]
+			if !ok {
+				m := make(map[string]interface{})
+				current[segment] = m
+				current = m
+				continue
+			}
+
+			m, ok := next.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("key %q conflicts with existing value at %q",
+					key, strings.Join(segments[:i+1], "."))
+			}
+			current = m
+		}
+
+		last := segments[len(segments)-1]
+		if _, ok := current[last]; ok {
+			return nil, fmt.Errorf("key %q conflicts with an existing value", key)
+		}
+		current[last] = data[key]
+	}
+
+	return result, nil
 }
 
 // parseArgsDataString parses the args data and returns the values as strings.
@@ -210,6 +446,54 @@ func expandPath(s string) string {
 	return e
 }
 
+// createOutputFile creates a temporary file next to path with the given mode,
+// to be renamed to path by commitOutputFile once it is complete. The mode is
+// set explicitly, so that an existing file at path never keeps a looser mode
+// once it is replaced.
+func createOutputFile(path string, mode os.FileMode) (*os.File, error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// commitOutputFile syncs and closes a file created by createOutputFile, and
+// renames it to path. The temporary file is removed on failure.
+func commitOutputFile(f *os.File, path string) error {
+	err := f.Sync()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// writeOutputFile atomically replaces the file at path with the contents, with
+// the given mode. Files holding secrets must be written with mode 0600.
+func writeOutputFile(path string, contents []byte, mode os.FileMode) error {
+	f, err := createOutputFile(path, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	return commitOutputFile(f, path)
+}
+
 // wrapAtLengthWithPadding wraps the given text at the maxLineLength, taking
 // into account any provided left padding.
 func wrapAtLengthWithPadding(s string, pad int) string {
@@ -292,3 +576,468 @@ func parseFlagFile(raw string) (string, error) {
 
 	return raw, nil
 }
+
+// dryRunRedactedHeaders are the headers carrying credentials, whose values are
+// redacted from the output of dry runs. Headers whose name mentions a token, a
+// secret or a password are redacted too.
+var dryRunRedactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"X-Api-Key":           true,
+	"X-Vault-Token":       true,
+}
+
+// dryRunRedactHeader returns whether the value of the given header is
+// redacted from the output of dry runs.
+func dryRunRedactHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	if dryRunRedactedHeaders[name] {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, s := range []string{"token", "secret", "password"} {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputDryRun prints the request that would be sent for the given operation
+// without sending it. Sensitive headers, like the client token, are never
+// included in the output, and the values of the headers carrying credentials
+// are redacted.
+func outputDryRun(ui cli.Ui, client *api.Client, method, path string, data interface{}) int {
+	req := client.NewRequest(method, "/v1/"+path)
+
+	headers := make(map[string]string, len(req.Headers))
+	for k, v := range req.Headers {
+		if dryRunRedactHeader(k) {
+			headers[k] = "<redacted>"
+			continue
+		}
+		headers[k] = strings.Join(v, ",")
+	}
+	if req.WrapTTL != "" {
+		headers["X-Vault-Wrap-TTL"] = req.WrapTTL
+	}
+	if req.PolicyOverride {
+		headers["X-Vault-Policy-Override"] = "true"
+	}
+	if len(req.MFAHeaderVals) > 0 {
+		headers["X-Vault-MFA"] = "<redacted>"
+	}
+
+	if Format(ui) == "table" {
+		ui.Info("Dry run enabled; no request was sent to Vault.\n")
+
+		out := []string{
+			"Key | Value",
+			fmt.Sprintf("method | %s", method),
+			fmt.Sprintf("path | %s", path),
+			fmt.Sprintf("url | %s", req.URL.String()),
+		}
+
+		keys := make([]string, 0, len(headers))
+		for k := range headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out = append(out, fmt.Sprintf("header %s | %s", k, headers[k]))
+		}
+
+		ui.Output(tableOutput(out, nil))
+
+		if data != nil {
+			b, err := json.MarshalIndent(data, "", "  ")
+			if err != nil {
+				ui.Error(fmt.Sprintf("Failed to encode payload: %s", err))
+				return 1
+			}
+			ui.Output("\nPayload:\n" + string(b))
+		}
+		return 0
+	}
+
+	return OutputData(ui, map[string]interface{}{
+		"method":  method,
+		"path":    path,
+		"url":     req.URL.String(),
+		"headers": headers,
+		"data":    data,
+	})
+}
+
+// unwrapResponse unwraps the given secret if it is a response-wrapping token,
+// returning the inner secret. If verifyPath is non-empty, the creation path
+// of the wrapping token is looked up and must match it before the token is
+// unwrapped. Secrets which are not wrapped are returned unchanged.
+func unwrapResponse(client *api.Client, secret *api.Secret, verifyPath string) (*api.Secret, error) {
+	if secret == nil || secret.WrapInfo == nil || secret.WrapInfo.Token == "" {
+		return secret, nil
+	}
+
+	// Make sure the lookup and unwrap calls themselves are not wrapped
+	currentWrappingLookupFunc := client.CurrentWrappingLookupFunc()
+	client.SetWrappingLookupFunc(func(string, string) string { return "" })
+	defer client.SetWrappingLookupFunc(currentWrappingLookupFunc)
+
+	token := secret.WrapInfo.Token
+
+	if verifyPath != "" {
+		if err := verifyWrappingToken(client, token, verifyPath, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	unwrapped, err := client.Logical().Unwrap(token)
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping response: %w", err)
+	}
+	return unwrapped, nil
+}
+
+// verifyWrappingToken looks up the given response-wrapping token, or the
+// client token if it is empty, before it is unwrapped. The lookup fails if the
+// token was already unwrapped or expired. If expectedPath is non-empty, the
+// token must have been created for it, and if maxAge is non-zero, it must
+// have been created at most maxAge ago.
+func verifyWrappingToken(client *api.Client, token, expectedPath string, maxAge time.Duration) error {
+	lookup, err := client.Logical().Write("sys/wrapping/lookup", map[string]interface{}{
+		"token": token,
+	})
+	if err != nil {
+		return fmt.Errorf("error looking up wrapping token: %w", err)
+	}
+	if lookup == nil || lookup.Data == nil {
+		return errors.New("no wrapping token information returned")
+	}
+
+	if expectedPath != "" {
+		creationPath, _ := lookup.Data["creation_path"].(string)
+		if sanitizePath(creationPath) != sanitizePath(expectedPath) {
+			return fmt.Errorf("wrapping token creation path %q does not match expected path %q", creationPath, expectedPath)
+		}
+	}
+
+	if maxAge > 0 {
+		raw, _ := lookup.Data["creation_time"].(string)
+		created, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return fmt.Errorf("error parsing wrapping token creation time %q: %w", raw, err)
+		}
+		if age := time.Since(created); age > maxAge {
+			return fmt.Errorf("wrapping token was created %s ago, more than the maximum age of %s", humanDuration(age.Truncate(time.Second)), humanDuration(maxAge))
+		}
+	}
+
+	return nil
+}
+
+// clientForNamespace returns a copy of the client which sends requests to the
+// given namespace. The client is returned unchanged if ns is empty.
+func clientForNamespace(client *api.Client, ns string) (*api.Client, error) {
+	if ns == "" {
+		return client, nil
+	}
+
+	nsClient, err := client.CloneWithHeaders()
+	if err != nil {
+		return nil, err
+	}
+	nsClient.SetToken(client.Token())
+	nsClient.SetNamespace(namespace.Canonicalize(ns))
+	return nsClient, nil
+}
+
+// defaultListConcurrency is the number of list requests made at once when
+// listing recursively.
+const defaultListConcurrency = 8
+
+// listRecursive lists the given folder and all of its sub-folders, calling
+// list to fetch the entries of each folder, and returns the full paths of
+// every entry which is not itself a folder, sorted. Up to concurrency folders
+// are listed at a time.
+func listRecursive(folder string, concurrency int, list func(folder string) ([]string, error)) ([]string, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		sem    = make(chan struct{}, concurrency)
+		result []string
+		errs   error
+	)
+
+	var walk func(folder string)
+	walk = func(folder string) {
+		defer wg.Done()
+
+		sem <- struct{}{}
+		entries, err := list(folder)
+		<-sem
+
+		lock.Lock()
+		defer lock.Unlock()
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
+		for _, entry := range entries {
+			if strings.HasSuffix(entry, "/") {
+				wg.Add(1)
+				go walk(folder + entry)
+				continue
+			}
+			result = append(result, folder+entry)
+		}
+	}
+
+	wg.Add(1)
+	walk(ensureTrailingSlash(folder))
+	wg.Wait()
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// listEntries lists the given path and returns its entries.
+func listEntries(client *api.Client, path string) ([]string, error) {
+	secret, err := client.Logical().List(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error listing %s: %s", path, err)
+	}
+
+	entries, _ := extractListData(secret)
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if key, ok := entry.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// listPathFilter returns a function which reports whether a path listed by
+// listRecursive should be included. The glob is matched against both the
+// full path and its last segment. Empty patterns match everything.
+func listPathFilter(glob, regex string) (func(string) bool, error) {
+	if glob != "" {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %w", glob, err)
+		}
+	}
+
+	var re *regexp.Regexp
+	if regex != "" {
+		var err error
+		re, err = regexp.Compile(regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter regex %q: %w", regex, err)
+		}
+	}
+
+	return func(p string) bool {
+		if glob != "" {
+			full, _ := path.Match(glob, p)
+			base, _ := path.Match(glob, path.Base(p))
+			if !full && !base {
+				return false
+			}
+		}
+		if re != nil && !re.MatchString(p) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// outputListRecursive lists the given folder recursively using list and
+// outputs the full paths which match the given filters.
+func outputListRecursive(ui cli.Ui, folder string, concurrency int, glob, regex string, list func(folder string) ([]string, error)) int {
+	match, err := listPathFilter(glob, regex)
+	if err != nil {
+		ui.Error(err.Error())
+		return 1
+	}
+
+	progress := newProgress(ui, "Listing keys", 0)
+	paths, err := listRecursive(folder, concurrency, progress.countKeys(list))
+	progress.Stop()
+	if err != nil {
+		ui.Error(err.Error())
+		return 2
+	}
+
+	keys := make([]interface{}, 0, len(paths))
+	for _, p := range paths {
+		if match(p) {
+			keys = append(keys, p)
+		}
+	}
+
+	if len(keys) == 0 {
+		if Format(ui) != "table" {
+			OutputData(ui, map[string]interface{}{})
+		} else {
+			ui.Error(fmt.Sprintf("No entries found under %s", folder))
+		}
+		return 2
+	}
+
+	return OutputList(ui, &api.Secret{
+		Data: map[string]interface{}{
+			"keys": keys,
+		},
+	})
+}
+
+// confirm asks the user a yes/no question and reports whether they answered
+// yes. Anything other than "y" or "yes" is treated as no.
+func confirm(ui cli.Ui, question string) (bool, error) {
+	answer, err := ui.Ask(fmt.Sprintf("%s [y/N]:", question))
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// runConcurrently calls f for each of the given items, with up to concurrency
+// calls running at once. All items are processed even if some fail; the
+// errors are returned together.
+func runConcurrently(items []string, concurrency int, f func(item string) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		sem  = make(chan struct{}, concurrency)
+		errs error
+	)
+	for _, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(item string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := f(item); err != nil {
+				lock.Lock()
+				errs = multierror.Append(errs, err)
+				lock.Unlock()
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// jsonMergePatch applies patch to target as described by RFC 7386: keys set to
+// null in the patch are removed, objects are merged recursively, and any
+// other value replaces the existing one. target is modified in place and
+// returned.
+func jsonMergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = make(map[string]interface{}, len(patch))
+	}
+
+	for k, v := range patch {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+
+		patchObj, ok := v.(map[string]interface{})
+		if !ok {
+			target[k] = v
+			continue
+		}
+		targetObj, _ := target[k].(map[string]interface{})
+		target[k] = jsonMergePatch(targetObj, patchObj)
+	}
+
+	return target
+}
+
+// cliRetryPolicy extends api.DefaultRetryPolicy to also retry 429 responses,
+// which are returned by rate limit quotas and by performance standbys that
+// are not yet able to serve requests.
+func cliRetryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	retry, err := api.DefaultRetryPolicy(ctx, resp, err)
+	if err != nil || retry {
+		return retry, err
+	}
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true, nil
+	}
+	return false, nil
+}
+
+// exponentialJitterBackoff doubles the wait after every attempt, starting at
+// min and capped at max, and then picks a random duration between half of
+// that wait and the full wait. A Retry-After header on a 429 or 503 response
+// takes precedence, as long as it is within max.
+func exponentialJitterBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if s := resp.Header.Get("Retry-After"); s != "" {
+			if secs, err := strconv.Atoi(s); err == nil && secs >= 0 {
+				if wait := time.Duration(secs) * time.Second; wait <= max {
+					return wait
+				}
+			}
+		}
+	}
+
+	if max <= min {
+		return min
+	}
+
+	wait := float64(min) * math.Pow(2, float64(attemptNum))
+	if wait > float64(max) || math.IsInf(wait, 0) {
+		wait = float64(max)
+	}
+
+	half := int64(wait / 2)
+	return time.Duration(half + rand.Int63n(int64(wait)-half+1))
+}
+
+// parseRateLimit parses a rate limit given as "RATE" or "RATE:BURST". If no
+// burst is given, it defaults to the rate, rounded up.
+func parseRateLimit(val string) (float64, int, error) {
+	rateStr, burstStr := val, ""
+	if i := strings.Index(val, ":"); i >= 0 {
+		rateStr, burstStr = val[:i], val[i+1:]
+	}
+
+	rateLimit, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+	if err != nil || rateLimit <= 0 || math.IsInf(rateLimit, 0) {
+		return 0, 0, fmt.Errorf("rate %q must be a positive number", rateStr)
+	}
+
+	burst := int(math.Ceil(rateLimit))
+	if burstStr != "" {
+		burst, err = strconv.Atoi(strings.TrimSpace(burstStr))
+		if err != nil || burst < 1 {
+			return 0, 0, fmt.Errorf("burst %q must be a positive integer", burstStr)
+		}
+	}
+
+	return rateLimit, burst, nil
+}
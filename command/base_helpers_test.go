@@ -4,9 +4,16 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
 )
 
 func TestParseArgsData(t *testing.T) {
@@ -104,6 +111,177 @@ func TestParseArgsData(t *testing.T) {
 			t.Errorf("expected %q to be %q", v, "@")
 		}
 	})
+
+	t.Run("file_value_base64", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := ioutil.TempFile("", "vault")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte{0x00, 0xff, 'b', 'a', 'r'})
+		f.Close()
+		defer os.Remove(f.Name())
+
+		m, err := parseArgsData(os.Stdin, []string{"foo=@b64:" + f.Name()})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if v, ok := m["foo"]; !ok || v != "AP9iYXI=" {
+			t.Errorf("expected %q to be %q", v, "AP9iYXI=")
+		}
+	})
+
+	t.Run("typed_values", func(t *testing.T) {
+		t.Parallel()
+
+		m, err := parseArgsData(os.Stdin, []string{
+			"ttl:int=300",
+			"enabled:bool=true",
+			"ratio:float=0.5",
+			`tags:json=["a","b"]`,
+			"port:string=8200",
+			"url=http://127.0.0.1:8200",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]interface{}{
+			"ttl":     int64(300),
+			"enabled": true,
+			"ratio":   0.5,
+			"tags":    []interface{}{"a", "b"},
+			"port":    "8200",
+			"url":     "http://127.0.0.1:8200",
+		}
+		if !reflect.DeepEqual(m, expected) {
+			t.Errorf("expected %#v to be %#v", m, expected)
+		}
+	})
+
+	t.Run("typed_values_invalid", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := parseArgsData(os.Stdin, []string{"ttl:int=abc"}); err == nil {
+			t.Error("expected error parsing invalid int")
+		}
+		if _, err := parseArgsData(os.Stdin, []string{"ttl=1", "ttl:int=2"}); err == nil {
+			t.Error("expected error for duplicate typed key")
+		}
+	})
+
+	t.Run("typed_values_json", func(t *testing.T) {
+		t.Parallel()
+
+		// Keys of JSON input are taken as is, only key=value args are typed
+		m, err := parseArgsData(strings.NewReader(`{"ratio:int": "abc", "port:bool": "yes"}`), []string{
+			"-",
+			"ttl:int=300",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]interface{}{
+			"ratio:int": "abc",
+			"port:bool": "yes",
+			"ttl":       int64(300),
+		}
+		if !reflect.DeepEqual(m, expected) {
+			t.Errorf("expected %#v to be %#v", m, expected)
+		}
+	})
+}
+
+func TestExpandNestedKeys(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		in       map[string]interface{}
+		expected map[string]interface{}
+		err      bool
+	}{
+		{
+			"flat",
+			map[string]interface{}{"foo": "bar"},
+			map[string]interface{}{"foo": "bar"},
+			false,
+		},
+		{
+			"dotted",
+			map[string]interface{}{
+				"config.connection.url":  "postgres://",
+				"config.connection.user": "vault",
+				"name":                   "db",
+			},
+			map[string]interface{}{
+				"config": map[string]interface{}{
+					"connection": map[string]interface{}{
+						"url":  "postgres://",
+						"user": "vault",
+					},
+				},
+				"name": "db",
+			},
+			false,
+		},
+		{
+			"bracketed",
+			map[string]interface{}{
+				"config[connection][url]": "postgres://",
+				"config[connection].user": "vault",
+			},
+			map[string]interface{}{
+				"config": map[string]interface{}{
+					"connection": map[string]interface{}{
+						"url":  "postgres://",
+						"user": "vault",
+					},
+				},
+			},
+			false,
+		},
+		{
+			"conflict",
+			map[string]interface{}{
+				"config":     "foo",
+				"config.url": "bar",
+			},
+			nil,
+			true,
+		},
+		{
+			"empty_segment",
+			map[string]interface{}{"config..url": "bar"},
+			nil,
+			true,
+		},
+		{
+			"unterminated",
+			map[string]interface{}{"config[url": "bar"},
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := expandNestedKeys(tc.in)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %t, got %v", tc.err, err)
+			}
+			if !tc.err && !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %#v to be %#v", actual, tc.expected)
+			}
+		})
+	}
 }
 
 func TestTruncateToSeconds(t *testing.T) {
@@ -209,3 +387,275 @@ func TestParseFlagFile(t *testing.T) {
 		})
 	}
 }
+
+func TestListRecursive(t *testing.T) {
+	t.Parallel()
+
+	tree := map[string][]string{
+		"secret/":     {"a", "b/", "c/"},
+		"secret/b/":   {"d", "e/"},
+		"secret/b/e/": {"f"},
+		"secret/c/":   {},
+	}
+	list := func(folder string) ([]string, error) {
+		entries, ok := tree[folder]
+		if !ok {
+			return nil, fmt.Errorf("Error listing %s", folder)
+		}
+		return entries, nil
+	}
+
+	for _, concurrency := range []int{0, 1, 4} {
+		paths, err := listRecursive("secret", concurrency, list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{"secret/a", "secret/b/d", "secret/b/e/f"}
+		if !reflect.DeepEqual(paths, exp) {
+			t.Errorf("concurrency %d: expected %q to be %q", concurrency, paths, exp)
+		}
+	}
+
+	if _, err := listRecursive("secret/broken", 2, list); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestListPathFilter(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		glob  string
+		regex string
+		path  string
+		match bool
+	}{
+		{"", "", "secret/foo", true},
+		{"*-key", "", "secret/app/api-key", true},
+		{"secret/*/api-key", "", "secret/app/api-key", true},
+		{"*-key", "", "secret/app/token", false},
+		{"", "^secret/app/", "secret/app/token", true},
+		{"", "^secret/app/", "secret/other/token", false},
+		{"*-key", "^secret/app/", "secret/other/api-key", false},
+	}
+
+	for _, tc := range cases {
+		match, err := listPathFilter(tc.glob, tc.regex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := match(tc.path); act != tc.match {
+			t.Errorf("glob %q, regex %q, path %q: expected %t to be %t", tc.glob, tc.regex, tc.path, act, tc.match)
+		}
+	}
+
+	if _, err := listPathFilter("[", ""); err == nil {
+		t.Error("expected error for invalid glob")
+	}
+	if _, err := listPathFilter("", "("); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+}
+
+func TestJSONMergePatch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		target map[string]interface{}
+		patch  map[string]interface{}
+		exp    map[string]interface{}
+	}{
+		{
+			"nil_target",
+			nil,
+			map[string]interface{}{"a": "b"},
+			map[string]interface{}{"a": "b"},
+		},
+		{
+			"replace_and_add",
+			map[string]interface{}{"a": "b", "c": "d"},
+			map[string]interface{}{"a": "z", "e": "f"},
+			map[string]interface{}{"a": "z", "c": "d", "e": "f"},
+		},
+		{
+			"null_removes",
+			map[string]interface{}{"a": "b", "c": "d"},
+			map[string]interface{}{"a": nil, "missing": nil},
+			map[string]interface{}{"c": "d"},
+		},
+		{
+			"nested_merge",
+			map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": "e"}},
+			map[string]interface{}{"a": map[string]interface{}{"b": nil, "f": "g"}},
+			map[string]interface{}{"a": map[string]interface{}{"d": "e", "f": "g"}},
+		},
+		{
+			"object_replaces_scalar",
+			map[string]interface{}{"a": "b"},
+			map[string]interface{}{"a": map[string]interface{}{"c": "d", "e": nil}},
+			map[string]interface{}{"a": map[string]interface{}{"c": "d"}},
+		},
+		{
+			"array_replaced",
+			map[string]interface{}{"a": []interface{}{"b", "c"}},
+			map[string]interface{}{"a": []interface{}{"d"}},
+			map[string]interface{}{"a": []interface{}{"d"}},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if act := jsonMergePatch(tc.target, tc.patch); !reflect.DeepEqual(act, tc.exp) {
+				t.Errorf("expected %#v to be %#v", act, tc.exp)
+			}
+		})
+	}
+}
+
+func TestExponentialJitterBackoff(t *testing.T) {
+	t.Parallel()
+
+	min, max := 100*time.Millisecond, 2*time.Second
+	for attempt := 0; attempt < 10; attempt++ {
+		ceil := min << uint(attempt)
+		if ceil > max {
+			ceil = max
+		}
+		for i := 0; i < 20; i++ {
+			wait := exponentialJitterBackoff(min, max, attempt, nil)
+			if wait < ceil/2 || wait > ceil {
+				t.Fatalf("attempt %d: wait %s not in [%s, %s]", attempt, wait, ceil/2, ceil)
+			}
+		}
+	}
+
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"1"}},
+	}
+	if wait := exponentialJitterBackoff(min, max, 0, resp); wait != time.Second {
+		t.Errorf("expected Retry-After to be honored, got %s", wait)
+	}
+	resp.Header.Set("Retry-After", "60")
+	if wait := exponentialJitterBackoff(min, max, 0, resp); wait > min {
+		t.Errorf("expected Retry-After above max to be ignored, got %s", wait)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in    string
+		rate  float64
+		burst int
+		err   bool
+	}{
+		{"10", 10, 10, false},
+		{"0.5", 0.5, 1, false},
+		{"10:20", 10, 20, false},
+		{"2.5:1", 2.5, 1, false},
+		{"", 0, 0, true},
+		{"0", 0, 0, true},
+		{"-1", 0, 0, true},
+		{"abc", 0, 0, true},
+		{"10:", 0, 0, true},
+		{"10:0", 0, 0, true},
+		{"10:abc", 0, 0, true},
+	}
+
+	for _, tc := range cases {
+		rate, burst, err := parseRateLimit(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%q: expected an error", tc.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", tc.in, err)
+			continue
+		}
+		if rate != tc.rate || burst != tc.burst {
+			t.Errorf("%q: expected %v:%d, got %v:%d", tc.in, tc.rate, tc.burst, rate, burst)
+		}
+	}
+}
+
+func TestWriteOutputFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "vault-output-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// An existing world-readable file doesn't keep its mode
+	path := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutputFile(path, []byte("new"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "new" {
+		t.Errorf("expected %q, got %q, %v", "new", b, err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected the temporary file to be removed, got %d files", len(files))
+	}
+}
+
+func TestOutputDryRun(t *testing.T) {
+	t.Parallel()
+
+	client, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("s.token")
+	client.SetHeaders(http.Header{
+		"Authorization":       []string{"Bearer jwt"},
+		"Proxy-Authorization": []string{"Basic cHJveHk="},
+		"Cookie":              []string{"session=abcd"},
+		"X-Vault-Token":       []string{"s.header"},
+		"X-Custom-Secret":     []string{"hunter2"},
+		"X-Request-Id":        []string{"1234"},
+	})
+
+	ui := cli.NewMockUi()
+	if code := outputDryRun(ui, client, "PUT", "secret/foo", map[string]interface{}{"foo": "bar"}); code != 0 {
+		t.Fatalf("expected 0 to be %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	out := ui.OutputWriter.String()
+	for _, secret := range []string{"s.token", "jwt", "cHJveHk=", "abcd", "s.header", "hunter2"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %q to be redacted in %q", secret, out)
+		}
+	}
+	for _, exp := range []string{"header Authorization", "<redacted>", "header X-Request-Id", "1234", `"foo": "bar"`} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("expected %q to contain %q", out, exp)
+		}
+	}
+}
@@ -2,18 +2,36 @@ package command
 
 import (
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/posener/complete"
 )
 
+// predictRequestTimeout is the maximum amount of time a single request made
+// for prediction may take. Completion must stay responsive, so slow or
+// unreachable servers simply produce no predictions.
+const predictRequestTimeout = 2 * time.Second
+
+// predictCacheTTL is how long the results of the requests made for prediction
+// are cached. Each completion runs in a new process, so they are cached on
+// disk for the completions of the following keystrokes. The expired entries
+// are removed when new ones are written, and "vault cache purge" removes all
+// of them.
+const predictCacheTTL = 30 * time.Second
+
 type Predict struct {
 	client     *api.Client
 	clientOnce sync.Once
+
+	// cache holds the results of the requests made for prediction, or is nil
+	// if they are not cached.
+	cache *readCache
 }
 
 func NewPredict() *Predict {
@@ -42,7 +60,10 @@ func (p *Predict) Client() *api.Client {
 				client.SetMaxRetries(0)
 			}
 
+			client.SetClientTimeout(predictRequestTimeout)
+
 			p.client = client
+			p.cache = newPredictCache(client)
 		}
 	})
 	return p.client
@@ -113,6 +134,7 @@ func (b *BaseCommand) PredictVaultAvailableAuths() complete.Predictor {
 		"plugin",
 		"radius",
 		"userpass",
+		"webauthn",
 	)
 }
 
@@ -421,19 +443,113 @@ func (p *Predict) policies() []string {
 	return policies
 }
 
+// newPredictCache returns the cache of the requests made for prediction with
+// client, in the user cache directory. Like the read cache, the entries are
+// encrypted with a key derived from the token and named after the address and
+// namespace of the client, so that they are only found with the same token on
+// the same server. This function returns nil if there is no token or if any
+// errors occur.
+func newPredictCache(client *api.Client) *readCache {
+	token := client.Token()
+	if token == "" {
+		return nil
+	}
+
+	dir, err := predictCacheDir()
+	if err != nil {
+		return nil
+	}
+	prefix := client.Address() + "|" + client.Headers().Get(consts.NamespaceHeaderName)
+	cache, err := newReadCache(dir, predictCacheTTL, token, prefix)
+	if err != nil {
+		return nil
+	}
+	return cache
+}
+
+// predictCacheDir returns the directory of the cache of the requests made for
+// prediction.
+func predictCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "vault", "predict"), nil
+}
+
+// cached returns the cached result for the given key, calling f to compute
+// and store it if it is not already cached. Empty results are not cached, as
+// they are also returned when the request fails.
+func (p *Predict) cached(key string, f func() []string) []string {
+	if p.cache == nil {
+		return f()
+	}
+
+	var list []string
+	if p.cache.get(key, &list) {
+		return list
+	}
+
+	list = f()
+	if len(list) > 0 {
+		// Prediction works the same without the cache
+		p.cache.put(key, list)
+	}
+	return list
+}
+
 // mounts returns a sorted list of the mount paths for Vault server for
-// which the client is configured to communicate with. This function returns the
-// default list of mounts if an error occurs.
+// which the client is configured to communicate with. The mounts visible to
+// the token via sys/internal/ui/mounts are preferred, since listing sys/mounts
+// requires elevated privileges. This function returns the default list of
+// mounts if an error occurs.
 func (p *Predict) mounts() []string {
 	client := p.Client()
 	if client == nil {
 		return nil
 	}
 
-	mounts, err := client.Sys().ListMounts()
-	if err != nil {
+	list := p.cached("mounts", func() []string {
+		if list := p.uiMounts(); len(list) > 0 {
+			return list
+		}
+
+		mounts, err := client.Sys().ListMounts()
+		if err != nil {
+			return nil
+		}
+
+		list := make([]string, 0, len(mounts))
+		for m := range mounts {
+			list = append(list, m)
+		}
+		sort.Strings(list)
+		return list
+	})
+	if len(list) == 0 {
 		return defaultPredictVaultMounts
 	}
+	return list
+}
+
+// uiMounts returns a sorted list of the secret mounts that the token has
+// access to, as reported by sys/internal/ui/mounts. This function returns an
+// empty list if any errors occur.
+func (p *Predict) uiMounts() []string {
+	client := p.Client()
+	if client == nil {
+		return nil
+	}
+
+	secret, err := client.Logical().Read("sys/internal/ui/mounts")
+	if err != nil || secret == nil || secret.Data == nil {
+		return nil
+	}
+
+	mounts, ok := secret.Data["secret"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
 
 	list := make([]string, 0, len(mounts))
 	for m := range mounts {
@@ -481,24 +597,26 @@ func (p *Predict) listPaths(path string) []string {
 		return nil
 	}
 
-	secret, err := client.Logical().List(path)
-	if err != nil || secret == nil || secret.Data == nil {
-		return nil
-	}
+	return p.cached("list:"+path, func() []string {
+		secret, err := client.Logical().List(path)
+		if err != nil || secret == nil || secret.Data == nil {
+			return nil
+		}
 
-	paths, ok := secret.Data["keys"].([]interface{})
-	if !ok {
-		return nil
-	}
+		paths, ok := secret.Data["keys"].([]interface{})
+		if !ok {
+			return nil
+		}
 
-	list := make([]string, 0, len(paths))
-	for _, p := range paths {
-		if str, ok := p.(string); ok {
-			list = append(list, str)
+		list := make([]string, 0, len(paths))
+		for _, p := range paths {
+			if str, ok := p.(string); ok {
+				list = append(list, str)
+			}
 		}
-	}
-	sort.Strings(list)
-	return list
+		sort.Strings(list)
+		return list
+	})
 }
 
 // hasPathArg determines if the args have already accepted a path.
@@ -3,6 +3,7 @@ package command
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/api"
@@ -352,6 +353,7 @@ func TestPredict_Plugins(t *testing.T) {
 				"centrify",
 				"cert",
 				"cf",
+				"clickhouse-database-plugin",
 				"consul",
 				"couchbase-database-plugin",
 				"elasticsearch-database-plugin",
@@ -397,6 +399,7 @@ func TestPredict_Plugins(t *testing.T) {
 				"transform",
 				"transit",
 				"userpass",
+				"webauthn",
 			},
 		},
 	}
@@ -622,6 +625,60 @@ func TestPredict_ListPaths(t *testing.T) {
 	})
 }
 
+func TestPredict_Cached(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	newPredict := func(token string) *Predict {
+		t.Helper()
+
+		cache, err := newReadCache(dir, time.Minute, token, "https://127.0.0.1:8200|")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Predict{cache: cache}
+	}
+
+	calls := 0
+	f := func() []string {
+		calls++
+		return []string{"foo/"}
+	}
+
+	// Each completion runs in a new process, with a new predictor
+	for i := 0; i < 3; i++ {
+		act := newPredict("token").cached("key", f)
+		if exp := []string{"foo/"}; !reflect.DeepEqual(act, exp) {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	newPredict("token").cached("other", f)
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+
+	// Another token does not find the entries
+	newPredict("other-token").cached("key", f)
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	// Empty results are not cached
+	for i := 0; i < 2; i++ {
+		newPredict("token").cached("empty", func() []string {
+			calls++
+			return nil
+		})
+	}
+	if calls != 5 {
+		t.Errorf("expected 5 calls, got %d", calls)
+	}
+}
+
 func TestPredict_HasPathArg(t *testing.T) {
 	t.Parallel()
 
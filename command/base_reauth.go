@@ -0,0 +1,100 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/config"
+)
+
+// configureReauth sets up client to log in again when its token expired, if
+// the CLI configuration has a reauth block.
+func (c *BaseCommand) configureReauth(client *api.Client) error {
+	conf, err := c.cliConfig()
+	if err != nil {
+		return err
+	}
+	if conf.Reauth != nil {
+		client.SetReauthFunc(c.reauthFunc(client, conf.Reauth))
+	}
+	return nil
+}
+
+// reauthFunc returns the function which logs in again with the auth method of
+// the reauth block of the CLI configuration when a request of client is
+// rejected because its token expired. The new token is stored with the token
+// helper, so that the following commands use it as well.
+func (c *BaseCommand) reauthFunc(client *api.Client, reauth *config.Reauth) api.ReauthFunc {
+	return func(ctx context.Context, token string) (string, error) {
+		login, err := client.CloneWithHeaders()
+		if err != nil {
+			return "", err
+		}
+
+		// Vault responds with a 403 both to invalid tokens and to valid tokens
+		// denied by a policy, and logging in again only helps with the former
+		login.SetToken(token)
+		resp, err := login.RawRequestWithContext(ctx, login.NewRequest("GET", "/v1/auth/token/lookup-self"))
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err == nil {
+			return "", nil
+		}
+		var respErr *api.ResponseError
+		if !errors.As(err, &respErr) || respErr.StatusCode != 403 {
+			return "", fmt.Errorf("error looking up token: %w", err)
+		}
+
+		path := reauth.Path
+		if path == "" {
+			path = reauth.Method
+		}
+		args := make([]string, 0, len(reauth.Params))
+		for k, v := range reauth.Params {
+			args = append(args, k+"="+v)
+		}
+		sort.Strings(args)
+		params, err := parseArgsDataString(nil, args)
+		if err != nil {
+			return "", fmt.Errorf("error parsing reauth params: %w", err)
+		}
+
+		login.SetToken("")
+		var secret *api.Secret
+		if handler, ok := c.loginHandlers[reauth.Method]; ok {
+			if params["mount"] == "" {
+				params["mount"] = path
+			}
+			secret, err = handler.Auth(login, params)
+		} else {
+			// Methods without a CLI handler, e.g. approle, are logged in to
+			// directly
+			data := make(map[string]interface{}, len(params))
+			for k, v := range params {
+				data[k] = v
+			}
+			secret, err = login.Logical().Write("auth/"+ensureNoTrailingSlash(path)+"/login", data)
+		}
+		if err != nil {
+			return "", fmt.Errorf("error logging in with %s: %w", reauth.Method, err)
+		}
+		if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+			return "", fmt.Errorf("logging in with %s returned no token", reauth.Method)
+		}
+
+		helper, err := c.TokenHelper()
+		if err != nil {
+			return "", err
+		}
+		if err := helper.Store(secret.Auth.ClientToken); err != nil {
+			c.UI.Warn(fmt.Sprintf("Error storing the token obtained by re-authenticating: %s", err))
+		}
+		c.UI.Warn(fmt.Sprintf("The token was rejected; re-authenticated with %s", reauth.Method))
+
+		return secret.Auth.ClientToken, nil
+	}
+}
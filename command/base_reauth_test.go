@@ -0,0 +1,93 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+
+	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	"github.com/hashicorp/vault/command/config"
+	"github.com/hashicorp/vault/command/token"
+)
+
+func TestBaseCommand_reauthFunc(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().EnableAuth("userpass", "userpass", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("auth/userpass/users/test", map[string]interface{}{
+		"password": "test",
+		"policies": "default",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := client.Auth().Token().Create(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := secret.Auth.ClientToken
+	if err := client.Auth().Token().RevokeOrphan(expired); err != nil {
+		t.Fatal(err)
+	}
+
+	userClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userClient.SetToken(expired)
+
+	ui := cli.NewMockUi()
+	tokenHelper := token.NewTestingTokenHelper()
+	cmd := &BaseCommand{
+		UI:          ui,
+		tokenHelper: tokenHelper,
+		loginHandlers: map[string]LoginHandler{
+			"userpass": &credUserpass.CLIHandler{},
+		},
+		client: userClient,
+	}
+	userClient.SetReauthFunc(cmd.reauthFunc(userClient, &config.Reauth{
+		Method: "userpass",
+		Params: map[string]string{
+			"username": "test",
+			"password": "test",
+		},
+	}))
+
+	// The expired token is replaced, and the request retried with the new one
+	secret, err = userClient.Auth().Token().LookupSelf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["display_name"] != "userpass-test" {
+		t.Errorf("expected to be logged in as userpass-test, got %v", secret.Data["display_name"])
+	}
+	newToken := userClient.Token()
+	if newToken == expired {
+		t.Fatal("expected the token to be replaced")
+	}
+	stored, err := tokenHelper.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored != newToken {
+		t.Errorf("expected the new token to be stored, got %q", stored)
+	}
+	if exp := "re-authenticated with userpass"; !strings.Contains(ui.ErrorWriter.String(), exp) {
+		t.Errorf("expected %q to contain %q", ui.ErrorWriter.String(), exp)
+	}
+
+	// A valid token denied by a policy is kept
+	if _, err := userClient.Logical().Read("sys/mounts"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected a permission denied error, got %v", err)
+	}
+	if userClient.Token() != newToken {
+		t.Error("expected the token to be kept")
+	}
+}
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net"
+	osuser "os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshJumpDialer dials the connections to Vault through an SSH connection to a
+// jump host, like ssh -J does. The SSH connection is established on the first
+// dial and shared by the following ones. The user is authenticated with the
+// keys of the SSH agent, and the key of the jump host must be in the known
+// hosts file.
+type sshJumpDialer struct {
+	user           string
+	addr           string
+	agentSocket    string
+	knownHostsFile string
+
+	l      sync.Mutex
+	client *ssh.Client
+}
+
+// newSSHJumpDialer returns a dialer for the jump host given as
+// [user@]host[:port]. The user defaults to the current user and the port to
+// 22.
+func newSSHJumpDialer(jump, agentSocket, knownHostsFile string) (*sshJumpDialer, error) {
+	user, addr, err := parseSSHJump(jump)
+	if err != nil {
+		return nil, err
+	}
+	if agentSocket == "" {
+		return nil, fmt.Errorf("no SSH agent found: SSH_AUTH_SOCK is not set")
+	}
+
+	return &sshJumpDialer{
+		user:           user,
+		addr:           addr,
+		agentSocket:    agentSocket,
+		knownHostsFile: knownHostsFile,
+	}, nil
+}
+
+func parseSSHJump(jump string) (string, string, error) {
+	var user string
+	host := jump
+	if i := strings.LastIndex(jump, "@"); i >= 0 {
+		user, host = jump[:i], jump[i+1:]
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("invalid SSH jump host %q, expected [user@]host[:port]", jump)
+	}
+
+	if user == "" {
+		current, err := osuser.Current()
+		if err != nil {
+			return "", "", fmt.Errorf("error looking up the current user for the SSH jump host: %w", err)
+		}
+		user = current.Username
+	}
+
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "22")
+	}
+	return user, host, nil
+}
+
+// DialContext dials addr from the jump host. It has the signature of the
+// DialContext of http.Transport.
+func (d *sshJumpDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := d.sshClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.Dial(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("error dialing %s through SSH jump host %s: %w", addr, d.addr, err)
+	}
+	return conn, nil
+}
+
+func (d *sshJumpDialer) sshClient(ctx context.Context) (*ssh.Client, error) {
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.client != nil {
+		return d.client, nil
+	}
+
+	hostKeyCallback, err := knownhosts.New(d.knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading known hosts to verify SSH jump host %s: %w", d.addr, err)
+	}
+
+	agentConn, err := net.Dial("unix", d.agentSocket)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the SSH agent: %w", err)
+	}
+
+	config := &ssh.ClientConfig{
+		User:            d.user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	}
+
+	conn, err := (&net.Dialer{Timeout: config.Timeout}).DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		agentConn.Close()
+		return nil, fmt.Errorf("error connecting to SSH jump host %s: %w", d.addr, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, d.addr, config)
+	if err != nil {
+		agentConn.Close()
+		conn.Close()
+		return nil, fmt.Errorf("error connecting to SSH jump host %s: %w", d.addr, err)
+	}
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+	d.client = client
+	go func() {
+		// Reconnect on the next dial if the jump host goes away
+		client.Wait()
+		agentConn.Close()
+		d.l.Lock()
+		if d.client == client {
+			d.client = nil
+		}
+		d.l.Unlock()
+	}()
+	return client, nil
+}
+
+// defaultKnownHostsFile returns the known hosts file of the current user.
+func defaultKnownHostsFile() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
@@ -0,0 +1,223 @@
+package command
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestParseSSHJump(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		jump string
+		user string
+		addr string
+	}{
+		{"alice@bastion", "alice", "bastion:22"},
+		{"alice@bastion:2222", "alice", "bastion:2222"},
+		{"alice@[::1]", "alice", "[::1]:22"},
+		{"alice@[::1]:2222", "alice", "[::1]:2222"},
+	}
+
+	for _, tc := range cases {
+		user, addr, err := parseSSHJump(tc.jump)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user != tc.user || addr != tc.addr {
+			t.Errorf("%s: expected %s and %s, got %s and %s", tc.jump, tc.user, tc.addr, user, addr)
+		}
+	}
+
+	if _, _, err := parseSSHJump("alice@"); err == nil {
+		t.Error("expected an error without a host")
+	}
+}
+
+// testSSHJumpHost starts an SSH server which lets alice, authenticated with
+// the key of the returned agent, open direct-tcpip channels. It returns the
+// address of the server, the socket of the agent and a known hosts file with
+// the key of the server.
+func testSSHJumpHost(tb testing.TB) (string, string, string) {
+	tb.Helper()
+
+	dir := tb.TempDir()
+
+	_, userKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: userKey}); err != nil {
+		tb.Fatal(err)
+	}
+	agentSocket := filepath.Join(dir, "agent.sock")
+	agentListener, err := net.Listen("unix", agentSocket)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { agentListener.Close() })
+	go func() {
+		for {
+			conn, err := agentListener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	userPublicKey, err := ssh.NewPublicKey(userKey.Public())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "alice" && bytes.Equal(key.Marshal(), userPublicKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unauthorized")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go testServeSSHJump(conn, config)
+		}
+	}()
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(listener.Addr().String())}, hostSigner.PublicKey())
+	if err := ioutil.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+
+	return listener.Addr().String(), agentSocket, knownHostsFile
+}
+
+func testServeSSHJump(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		var payload struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelReqs, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelReqs)
+		go func() {
+			io.Copy(channel, target)
+			channel.Close()
+		}()
+		go func() {
+			io.Copy(target, channel)
+			target.Close()
+		}()
+	}
+}
+
+func TestSSHJumpDialer(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("through the jump host"))
+	}))
+	defer srv.Close()
+
+	jumpAddr, agentSocket, knownHostsFile := testSSHJumpHost(t)
+
+	get := func(jump, knownHostsFile string) (string, error) {
+		dialer, err := newSSHJumpDialer(jump, agentSocket, knownHostsFile)
+		if err != nil {
+			return "", err
+		}
+		client := &http.Client{Transport: &http.Transport{DialContext: dialer.DialContext}}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	body, err := get("alice@"+jumpAddr, knownHostsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "through the jump host" {
+		t.Fatalf("unexpected response %q", body)
+	}
+
+	if _, err := get("bob@"+jumpAddr, knownHostsFile); err == nil || !strings.Contains(err.Error(), "unable to authenticate") {
+		t.Errorf("expected an authentication error, got %v", err)
+	}
+
+	unknown := filepath.Join(t.TempDir(), "known_hosts")
+	if err := ioutil.WriteFile(unknown, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get("alice@"+jumpAddr, unknown); err == nil || !strings.Contains(err.Error(), "key is unknown") {
+		t.Errorf("expected an unknown host key error, got %v", err)
+	}
+
+	if _, err := newSSHJumpDialer("alice@"+jumpAddr, "", knownHostsFile); err == nil {
+		t.Error("expected an error without an SSH agent")
+	}
+}
@@ -1,9 +1,21 @@
 package command
 
 import (
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/config"
+	"github.com/hashicorp/vault/command/token"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/mitchellh/cli"
 )
 
 func getDefaultCliHeaders(t *testing.T) http.Header {
@@ -34,6 +46,10 @@ func TestClient_FlagHeader(t *testing.T) {
 			map[string]string{"X-Vault-foo": "bar", "header2": "value2"},
 			false,
 		},
+		{
+			map[string]string{"x-vault-foo": "bar"},
+			false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -67,3 +83,267 @@ func TestClient_FlagHeader(t *testing.T) {
 		}
 	}
 }
+
+func TestClient_FlagRetries(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		retries  int
+		attempts int32
+		success  bool
+	}{
+		{"no_retries", http.StatusServiceUnavailable, 0, 1, false},
+		{"retry_5xx", http.StatusServiceUnavailable, 2, 3, true},
+		{"retry_429", http.StatusTooManyRequests, 2, 3, true},
+		{"too_few_retries", http.StatusInternalServerError, 1, 2, false},
+		{"no_retry_4xx", http.StatusForbidden, 2, 1, false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= 2 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"data":{"foo":"bar"}}`))
+			}))
+			defer srv.Close()
+
+			bc := &BaseCommand{
+				flagAddress:      srv.URL,
+				flagRetries:      tc.retries,
+				flagRetryMinWait: time.Millisecond,
+				flagRetryMaxWait: 5 * time.Millisecond,
+			}
+			client, err := bc.Client()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.Logical().Read("secret/foo")
+			if tc.success && err != nil {
+				t.Errorf("expected success, got %s", err)
+			}
+			if !tc.success && err == nil {
+				t.Error("expected an error")
+			}
+			if got := atomic.LoadInt32(&attempts); got != tc.attempts {
+				t.Errorf("expected %d attempts, got %d", tc.attempts, got)
+			}
+		})
+	}
+}
+
+func TestClient_FlagRateLimit(t *testing.T) {
+	bc := &BaseCommand{flagRateLimit: "5:2"}
+	client, err := bc.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := client.Limiter()
+	if limiter == nil {
+		t.Fatal("expected a limiter")
+	}
+	if limiter.Limit() != 5 || limiter.Burst() != 2 {
+		t.Errorf("expected 5:2, got %v:%d", limiter.Limit(), limiter.Burst())
+	}
+
+	bc = &BaseCommand{flagRateLimit: "fast"}
+	if _, err := bc.Client(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestClient_FlagTransport(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data":{"proto":%q}}`, r.Proto)
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	cases := []struct {
+		version string
+		proto   string
+	}{
+		{"", "HTTP/2.0"},
+		{"1.1", "HTTP/1.1"},
+		{"2", "HTTP/2.0"},
+	}
+
+	for _, tc := range cases {
+		bc := &BaseCommand{
+			flagAddress:       srv.URL,
+			flagTLSSkipVerify: true,
+			flagHTTPVersion:   tc.version,
+		}
+		client, err := bc.Client()
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.Logical().Read("secret/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proto := secret.Data["proto"]; proto != tc.proto {
+			t.Errorf("expected %s with -http-version=%q, got %v", tc.proto, tc.version, proto)
+		}
+	}
+
+	bc := &BaseCommand{
+		flagMaxIdleConns:    3,
+		flagIdleConnTimeout: 5 * time.Second,
+		flagTCPKeepAlive:    -1,
+	}
+	transport := api.DefaultConfig().HttpClient.Transport.(*http.Transport)
+	if err := bc.configureTransport(transport); err != nil {
+		t.Fatal(err)
+	}
+	if transport.MaxIdleConns != 3 || transport.MaxIdleConnsPerHost != 3 || transport.IdleConnTimeout != 5*time.Second {
+		t.Errorf("unexpected transport settings: %d, %d, %s", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+
+	for _, bc := range []*BaseCommand{
+		{flagHTTPVersion: "3"},
+		{flagMaxIdleConns: -1},
+		{flagIdleConnTimeout: -time.Second},
+	} {
+		if _, err := bc.Client(); err == nil {
+			t.Errorf("expected an error with %#v", bc)
+		}
+	}
+}
+
+func TestClient_FlagProfile(t *testing.T) {
+	f, err := ioutil.TempFile("", "vault-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(`
+profile "prod" {
+  address   = "https://vault.prod.example.com:8200"
+  namespace = "team-a"
+}
+`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	t.Setenv(config.ConfigPathEnv, f.Name())
+
+	newCommand := func(args ...string) *BaseCommand {
+		bc := &BaseCommand{UI: cli.NewMockUi(), tokenHelper: token.NewTestingTokenHelper()}
+		if err := bc.flagSet(FlagSetHTTP).Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return bc
+	}
+
+	client, err := newCommand("-profile=prod").Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "https://vault.prod.example.com:8200", client.Address(); exp != act {
+		t.Errorf("expected %q to be %q", act, exp)
+	}
+	if exp, act := "team-a/", client.Headers().Get(consts.NamespaceHeaderName); exp != act {
+		t.Errorf("expected %q to be %q", act, exp)
+	}
+
+	client, err = newCommand("-profile=prod", "-address=https://other:8200").Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "https://other:8200", client.Address(); exp != act {
+		t.Errorf("expected %q to be %q", act, exp)
+	}
+
+	if _, err := newCommand("-profile=staging").Client(); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestBaseCommand_OutputOptions(t *testing.T) {
+	f, err := ioutil.TempFile("", "vault-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(`
+redact = true
+
+profile "prod" {
+  format = "json"
+}
+`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	t.Setenv(config.ConfigPathEnv, f.Name())
+	t.Setenv(EnvVaultRedact, "")
+	os.Unsetenv(EnvVaultRedact)
+
+	parse := func(args ...string) (*VaultUI, []string) {
+		ui := &VaultUI{Ui: cli.NewMockUi(), format: "table"}
+		bc := &BaseCommand{UI: ui}
+		set := bc.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+		if err := set.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return ui, set.Args()
+	}
+
+	ui, _ := parse()
+	if !ui.redact || ui.format != "table" {
+		t.Errorf("expected the configuration to redact table output, got %#v", ui)
+	}
+
+	if ui, _ := parse("-reveal"); ui.redact {
+		t.Error("expected -reveal to take precedence over the configuration")
+	}
+	if ui, _ := parse("-redact=false"); ui.redact {
+		t.Error("expected -redact=false to take precedence over the configuration")
+	}
+
+	// Arguments after the flags are not parsed as flags
+	ui, args := parse("-columns=a,b", "--", "-redact=false", "-columns=c")
+	if !ui.redact || !reflect.DeepEqual(ui.tableColumns, []string{"a", "b"}) || len(args) != 2 {
+		t.Errorf("expected the arguments after -- to be ignored, got %#v, %q", ui, args)
+	}
+
+	ui, _ = parse("-template={{ .Data }}", "-env-prefix=APP_", "-env-uppercase",
+		"-sort-by=b", "-field=key", "-output-file=key.pem", "-decode-base64",
+		"-output-request=Python")
+	exp := &VaultUI{
+		Ui:                 ui.Ui,
+		format:             "table",
+		template:           "{{ .Data }}",
+		envPrefix:          "APP_",
+		envUppercase:       true,
+		outputFile:         "key.pem",
+		outputDecodeBase64: true,
+		tableSortBy:        "b",
+		redact:             true,
+		outputRequest:      "python",
+	}
+	if !reflect.DeepEqual(ui, exp) {
+		t.Errorf("expected %#v to be %#v", ui, exp)
+	}
+
+	if ui, _ := parse("-profile=prod"); ui.format != "json" {
+		t.Errorf("expected the format of the profile, got %q", ui.format)
+	}
+	if ui, _ := parse("-profile=prod", "-format=yaml"); ui.format != "table" {
+		t.Errorf("expected -format to take precedence over the profile, got %q", ui.format)
+	}
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*CacheCommand)(nil)
+
+type CacheCommand struct {
+	*BaseCommand
+}
+
+func (c *CacheCommand) Synopsis() string {
+	return "Manage the read cache of the CLI"
+}
+
+func (c *CacheCommand) Help() string {
+	helpText := `
+Usage: vault cache <subcommand> [options] [args]
+
+  This command groups subcommands for managing the cache of the responses of
+  "vault read" and "vault kv get", which is enabled with -cache-ttl or the
+  cache_ttl setting of the CLI configuration.
+
+  Remove all the cached responses:
+
+      $ vault cache purge
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *CacheCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*CachePurgeCommand)(nil)
+	_ cli.CommandAutocomplete = (*CachePurgeCommand)(nil)
+)
+
+type CachePurgeCommand struct {
+	*BaseCommand
+
+	// dirs are the directories of the caches, for tests
+	dirs []string
+}
+
+func (c *CachePurgeCommand) Synopsis() string {
+	return "Remove all the cached responses"
+}
+
+func (c *CachePurgeCommand) Help() string {
+	helpText := `
+Usage: vault cache purge [options]
+
+  Removes all the responses cached by "vault read" and "vault kv get" with
+  -cache-ttl, whichever token, server and namespace they were read with, and
+  the responses cached for shell completion. No request is made to Vault.
+
+      $ vault cache purge
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *CachePurgeCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetNone)
+}
+
+func (c *CachePurgeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CachePurgeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *CachePurgeCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if args = f.Args(); len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	dirs := c.dirs
+	if dirs == nil {
+		dir, err := homedir.Expand(defaultReadCacheDir)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error finding the cache directory: %s", err), err)
+			return 1
+		}
+		dirs = append(dirs, dir)
+
+		// There is no completion cache when the user has no cache directory
+		if dir, err := predictCacheDir(); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	removed := 0
+	for _, dir := range dirs {
+		n, err := purgeReadCache(dir)
+		removed += n
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error purging the cache: %s", err), err)
+			return 1
+		}
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Removed %d cached responses", removed))
+	return 0
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+func testCachePurgeCommand(tb testing.TB, dirs ...string) (*cli.MockUi, *CachePurgeCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &CachePurgeCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		dirs: dirs,
+	}
+}
+
+func TestCachePurgeCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("too_many_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testCachePurgeCommand(t, t.TempDir())
+		if code := cmd.Run([]string{"foo"}); code != 1 {
+			t.Errorf("expected 1 to be %d", code)
+		}
+		if out := ui.ErrorWriter.String(); !strings.Contains(out, "Too many arguments") {
+			t.Errorf("expected %q to contain %q", out, "Too many arguments")
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		t.Parallel()
+
+		dir, predictDir := t.TempDir(), t.TempDir()
+		for _, token := range []string{"s.alice", "s.bob"} {
+			cache, err := newReadCache(dir, time.Minute, token, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cache.put("read|secret/foo?", map[string]string{"foo": "bar"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Mkdir(filepath.Join(dir, "nested"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		cache, err := newReadCache(predictDir, time.Minute, "s.alice", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cache.put("mounts", []string{"secret/"}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testCachePurgeCommand(t, dir, predictDir)
+		if code := cmd.Run(nil); code != 0 {
+			t.Fatalf("expected 0 to be %d: %s", code, ui.ErrorWriter.String())
+		}
+		if out := ui.OutputWriter.String(); !strings.Contains(out, "Removed 3 cached responses") {
+			t.Errorf("unexpected output %q", out)
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 || files[0].Name() != "nested" {
+			t.Errorf("expected only the nested directory to be left, got %v", files)
+		}
+		if files, _ := ioutil.ReadDir(predictDir); len(files) != 0 {
+			t.Errorf("expected the completion cache to be empty, got %v", files)
+		}
+	})
+
+	t.Run("no_cache", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testCachePurgeCommand(t, filepath.Join(t.TempDir(), "missing"))
+		if code := cmd.Run(nil); code != 0 {
+			t.Fatalf("expected 0 to be %d: %s", code, ui.ErrorWriter.String())
+		}
+		if out := ui.OutputWriter.String(); !strings.Contains(out, "Removed 0 cached responses") {
+			t.Errorf("unexpected output %q", out)
+		}
+	})
+}
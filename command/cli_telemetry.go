@@ -0,0 +1,320 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+	"github.com/hashicorp/vault/sdk/version"
+)
+
+const (
+	// cliTelemetryTimeout bounds the time spent sending the metrics of an
+	// invocation, so that an unavailable collector doesn't slow commands down.
+	cliTelemetryTimeout = 2 * time.Second
+
+	// cliTelemetryOTLPPath is the path metrics are sent to on OTLP endpoints
+	// given without a path.
+	cliTelemetryOTLPPath = "/v1/metrics"
+)
+
+// cliTelemetryDurationBounds are the bounds, in milliseconds, of the buckets of
+// the duration histogram sent to OTLP endpoints.
+var cliTelemetryDurationBounds = []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// cliTelemetry records the metrics of a single invocation of the CLI: the
+// command, the path prefix of the last request, its duration, the number of
+// retries and the status code of the last response. A nil *cliTelemetry
+// records nothing, so that callers don't have to check whether telemetry is
+// enabled.
+type cliTelemetry struct {
+	address string
+	start   time.Time
+
+	l          sync.Mutex
+	retries    int
+	statusCode int
+	pathPrefix string
+}
+
+// newCLITelemetry returns the telemetry of the invocation if an endpoint is set
+// with VAULT_CLI_TELEMETRY or in the CLI configuration, and nil otherwise.
+func newCLITelemetry(loader *cliConfigLoader) *cliTelemetry {
+	address := os.Getenv(EnvVaultCLITelemetry)
+	if address == "" {
+		conf, err := loader.load()
+		if err != nil {
+			return nil
+		}
+		address = conf.Telemetry
+	}
+	if address == "" {
+		return nil
+	}
+	return &cliTelemetry{
+		address: address,
+		start:   time.Now(),
+	}
+}
+
+// countRetries wraps the backoff of the client, which is only called before a
+// request is retried.
+func (t *cliTelemetry) countRetries(backoff retryablehttp.Backoff) retryablehttp.Backoff {
+	if t == nil {
+		return backoff
+	}
+	return func(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+		t.l.Lock()
+		t.retries++
+		t.l.Unlock()
+		return backoff(min, max, attemptNum, resp)
+	}
+}
+
+// recordResponses wraps the retry policy of the client, which is called after
+// each attempt, to record the path and the status code of the requests.
+func (t *cliTelemetry) recordResponses(checkRetry retryablehttp.CheckRetry) retryablehttp.CheckRetry {
+	if t == nil {
+		return checkRetry
+	}
+	return func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		var u *url.URL
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+			if resp.Request != nil {
+				u = resp.Request.URL
+			}
+		} else if urlErr, ok := err.(*url.Error); ok {
+			u, _ = url.Parse(urlErr.URL)
+		}
+
+		t.l.Lock()
+		t.statusCode = statusCode
+		if u != nil {
+			t.pathPrefix = cliTelemetryPathPrefix(u.Path)
+		}
+		t.l.Unlock()
+		return checkRetry(ctx, resp, err)
+	}
+}
+
+// cliTelemetryPathPrefix returns the part of the path of a request which is
+// recorded: its first segment, which is usually the mount, or its first two
+// segments for the sys/, auth/ and identity/ paths. The rest of the path may
+// contain the names of secrets and is never recorded.
+func cliTelemetryPathPrefix(path string) string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "/"), "v1/")
+	segments := strings.SplitN(path, "/", 3)
+	switch {
+	case len(segments) >= 2 && (segments[0] == "sys" || segments[0] == "auth" || segments[0] == "identity"):
+		return segments[0] + "/" + segments[1]
+	default:
+		return segments[0]
+	}
+}
+
+// send sends the metrics of the invocation of the given command. Errors are
+// ignored: telemetry must never change the outcome of a command.
+func (t *cliTelemetry) send(command string, exitCode int) {
+	if t == nil {
+		return
+	}
+	switch command {
+	case "", "server", "agent":
+		// Not an invocation, or a long-running process
+		return
+	}
+
+	t.l.Lock()
+	inv := &cliInvocation{
+		Command:    command,
+		PathPrefix: t.pathPrefix,
+		Duration:   time.Since(t.start),
+		Retries:    t.retries,
+		StatusCode: t.statusCode,
+		ExitCode:   exitCode,
+		Time:       time.Now(),
+	}
+	t.l.Unlock()
+
+	u, err := url.Parse(t.address)
+	if err != nil {
+		return
+	}
+	switch u.Scheme {
+	case "statsd", "udp":
+		sendStatsd(u.Host, inv)
+	case "http", "https":
+		if u.Path == "" || u.Path == "/" {
+			u.Path = cliTelemetryOTLPPath
+		}
+		sendOTLP(u.String(), inv)
+	}
+}
+
+// cliInvocation are the metrics of an invocation of the CLI.
+type cliInvocation struct {
+	Command    string
+	PathPrefix string
+	Duration   time.Duration
+	Retries    int
+	StatusCode int
+	ExitCode   int
+	Time       time.Time
+}
+
+// sendStatsd sends the metrics of an invocation in a single UDP packet, with
+// DogStatsD tags.
+func sendStatsd(address string, inv *cliInvocation) error {
+	conn, err := net.DialTimeout("udp", address, cliTelemetryTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(statsdPacket(inv))
+	return err
+}
+
+func statsdPacket(inv *cliInvocation) []byte {
+	tags := strings.Join([]string{
+		"command:" + statsdTagValue(inv.Command),
+		"path_prefix:" + statsdTagValue(inv.PathPrefix),
+		"status_code:" + strconv.Itoa(inv.StatusCode),
+		"exit_code:" + strconv.Itoa(inv.ExitCode),
+	}, ",")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "vault.cli.invocations:1|c|#%s\n", tags)
+	fmt.Fprintf(&b, "vault.cli.duration:%s|ms|#%s\n", strconv.FormatFloat(float64(inv.Duration.Microseconds())/1000, 'f', -1, 64), tags)
+	fmt.Fprintf(&b, "vault.cli.retries:%d|c|#%s\n", inv.Retries, tags)
+	return b.Bytes()
+}
+
+// statsdTagValue replaces the characters which have a meaning in the statsd
+// protocol, and the spaces of subcommands, with underscores.
+func statsdTagValue(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', ',', '|', ':', '#', '\n':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// sendOTLP sends the metrics of an invocation to an OTLP/HTTP endpoint, in the
+// JSON encoding.
+func sendOTLP(endpoint string, inv *cliInvocation) error {
+	body, err := json.Marshal(otlpRequest(inv))
+	if err != nil {
+		return err
+	}
+
+	client := cleanhttp.DefaultClient()
+	client.Timeout = cliTelemetryTimeout
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// otlpRequest returns an OTLP ExportMetricsServiceRequest with the metrics of
+// an invocation as delta sums and histogram.
+func otlpRequest(inv *cliInvocation) map[string]interface{} {
+	str := func(key, value string) map[string]interface{} {
+		return map[string]interface{}{"key": key, "value": map[string]interface{}{"stringValue": value}}
+	}
+	integer := func(key string, value int) map[string]interface{} {
+		return map[string]interface{}{"key": key, "value": map[string]interface{}{"intValue": strconv.Itoa(value)}}
+	}
+	attributes := []interface{}{
+		str("command", inv.Command),
+		str("path_prefix", inv.PathPrefix),
+		integer("status_code", inv.StatusCode),
+		integer("exit_code", inv.ExitCode),
+	}
+	start := strconv.FormatInt(inv.Time.Add(-inv.Duration).UnixNano(), 10)
+	end := strconv.FormatInt(inv.Time.UnixNano(), 10)
+
+	sum := func(name string, value int) map[string]interface{} {
+		return map[string]interface{}{
+			"name": name,
+			"unit": "1",
+			"sum": map[string]interface{}{
+				"aggregationTemporality": 1, // delta
+				"isMonotonic":            true,
+				"dataPoints": []interface{}{map[string]interface{}{
+					"attributes":        attributes,
+					"startTimeUnixNano": start,
+					"timeUnixNano":      end,
+					"asInt":             strconv.Itoa(value),
+				}},
+			},
+		}
+	}
+
+	ms := float64(inv.Duration.Microseconds()) / 1000
+	buckets := make([]string, len(cliTelemetryDurationBounds)+1)
+	bucket := len(cliTelemetryDurationBounds)
+	for i, bound := range cliTelemetryDurationBounds {
+		if ms <= bound {
+			bucket = i
+			break
+		}
+	}
+	for i := range buckets {
+		buckets[i] = "0"
+	}
+	buckets[bucket] = "1"
+
+	return map[string]interface{}{
+		"resourceMetrics": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []interface{}{str("service.name", "vault-cli")},
+			},
+			"scopeMetrics": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{
+					"name":    "vault-cli",
+					"version": version.GetVersion().VersionNumber(),
+				},
+				"metrics": []interface{}{
+					sum("vault.cli.invocations", 1),
+					sum("vault.cli.retries", inv.Retries),
+					map[string]interface{}{
+						"name": "vault.cli.duration",
+						"unit": "ms",
+						"histogram": map[string]interface{}{
+							"aggregationTemporality": 1, // delta
+							"dataPoints": []interface{}{map[string]interface{}{
+								"attributes":        attributes,
+								"startTimeUnixNano": start,
+								"timeUnixNano":      end,
+								"count":             "1",
+								"sum":               ms,
+								"bucketCounts":      buckets,
+								"explicitBounds":    cliTelemetryDurationBounds,
+							}},
+						},
+					},
+				},
+			}},
+		}},
+	}
+}
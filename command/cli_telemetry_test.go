@@ -0,0 +1,178 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCLITelemetryRequests records a request to secret/data/foo which was
+// retried once.
+func testCLITelemetryRequests(t *testing.T, tel *cliTelemetry) {
+	t.Helper()
+
+	checkRetry := tel.recordResponses(cliRetryPolicy)
+	backoff := tel.countRetries(exponentialJitterBackoff)
+	u, _ := url.Parse("https://127.0.0.1:8200/v1/secret/data/foo")
+
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Request: &http.Request{URL: u}}
+	if retry, err := checkRetry(context.Background(), resp, nil); !retry || err != nil {
+		t.Fatalf("expected a retry, got %t, %v", retry, err)
+	}
+	backoff(time.Millisecond, time.Second, 0, resp)
+
+	resp = &http.Response{StatusCode: http.StatusOK, Request: &http.Request{URL: u}}
+	if retry, err := checkRetry(context.Background(), resp, nil); retry || err != nil {
+		t.Fatalf("expected no retry, got %t, %v", retry, err)
+	}
+}
+
+func TestCLITelemetry(t *testing.T) {
+	t.Run("path_prefix", func(t *testing.T) {
+		t.Parallel()
+
+		cases := map[string]string{
+			"/v1/secret/data/foo/bar":        "secret",
+			"/v1/sys/mounts/secret/tune":     "sys/mounts",
+			"/v1/auth/userpass/login/alice":  "auth/userpass",
+			"/v1/identity/entity/name/alice": "identity/entity",
+			"/v1/sys":                        "sys",
+			"/v1/transit/encrypt/my-key":     "transit",
+		}
+		for path, exp := range cases {
+			if got := cliTelemetryPathPrefix(path); got != exp {
+				t.Errorf("%s: expected %q, got %q", path, exp, got)
+			}
+		}
+	})
+
+	t.Run("statsd", func(t *testing.T) {
+		t.Parallel()
+
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		tel := &cliTelemetry{address: "statsd://" + conn.LocalAddr().String(), start: time.Now()}
+		testCLITelemetryRequests(t, tel)
+		tel.send("kv get", 0)
+
+		buf := make([]byte, 4096)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(buf[:n])), "\n")
+		tags := "|#command:kv_get,path_prefix:secret,status_code:200,exit_code:0"
+		if len(lines) != 3 {
+			t.Fatalf("expected 3 metrics, got %q", lines)
+		}
+		if exp := "vault.cli.invocations:1|c" + tags; lines[0] != exp {
+			t.Errorf("expected %q, got %q", exp, lines[0])
+		}
+		if !strings.HasPrefix(lines[1], "vault.cli.duration:") || !strings.HasSuffix(lines[1], "|ms"+tags) {
+			t.Errorf("bad duration: %q", lines[1])
+		}
+		if exp := "vault.cli.retries:1|c" + tags; lines[2] != exp {
+			t.Errorf("expected %q, got %q", exp, lines[2])
+		}
+	})
+
+	t.Run("otlp", func(t *testing.T) {
+		t.Parallel()
+
+		ch := make(chan map[string]interface{}, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("bad request: %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			ch <- body
+		}))
+		defer srv.Close()
+
+		tel := &cliTelemetry{address: srv.URL, start: time.Now()}
+		testCLITelemetryRequests(t, tel)
+		tel.send("kv get", 2)
+
+		body := <-ch
+		scope := body["resourceMetrics"].([]interface{})[0].(map[string]interface{})["scopeMetrics"].([]interface{})[0].(map[string]interface{})
+		metrics := make(map[string]map[string]interface{})
+		for _, raw := range scope["metrics"].([]interface{}) {
+			m := raw.(map[string]interface{})
+			metrics[m["name"].(string)] = m
+		}
+
+		point := func(name, kind string) map[string]interface{} {
+			m, ok := metrics[name]
+			if !ok {
+				t.Fatalf("metric %s not sent", name)
+			}
+			return m[kind].(map[string]interface{})["dataPoints"].([]interface{})[0].(map[string]interface{})
+		}
+		if v := point("vault.cli.invocations", "sum")["asInt"]; v != "1" {
+			t.Errorf("expected 1 invocation, got %v", v)
+		}
+		if v := point("vault.cli.retries", "sum")["asInt"]; v != "1" {
+			t.Errorf("expected 1 retry, got %v", v)
+		}
+		duration := point("vault.cli.duration", "histogram")
+		if duration["count"] != "1" || len(duration["bucketCounts"].([]interface{})) != len(cliTelemetryDurationBounds)+1 {
+			t.Errorf("bad histogram: %#v", duration)
+		}
+
+		attributes := make(map[string]interface{})
+		for _, raw := range duration["attributes"].([]interface{}) {
+			a := raw.(map[string]interface{})
+			for _, v := range a["value"].(map[string]interface{}) {
+				attributes[a["key"].(string)] = v
+			}
+		}
+		exp := map[string]interface{}{
+			"command":     "kv get",
+			"path_prefix": "secret",
+			"status_code": "200",
+			"exit_code":   "2",
+		}
+		for k, v := range exp {
+			if attributes[k] != v {
+				t.Errorf("expected %s to be %v, got %v", k, v, attributes[k])
+			}
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		// A nil telemetry records and sends nothing
+		var tel *cliTelemetry
+		testCLITelemetryRequests(t, tel)
+		tel.send("kv get", 0)
+
+		// Long-running commands are not recorded
+		ch := make(chan struct{}, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ch <- struct{}{}
+		}))
+		defer srv.Close()
+		tel = &cliTelemetry{address: srv.URL, start: time.Now()}
+		tel.send("server", 0)
+		select {
+		case <-ch:
+			t.Fatal("expected no metrics for the server command")
+		default:
+		}
+	})
+}
@@ -20,6 +20,8 @@ import (
 	_ "github.com/hashicorp/vault/helper/builtinplugins"
 
 	auditFile "github.com/hashicorp/vault/builtin/audit/file"
+	auditKafka "github.com/hashicorp/vault/builtin/audit/kafka"
+	auditOTLP "github.com/hashicorp/vault/builtin/audit/otlp"
 	auditSocket "github.com/hashicorp/vault/builtin/audit/socket"
 	auditSyslog "github.com/hashicorp/vault/builtin/audit/syslog"
 
@@ -27,8 +29,6 @@ import (
 	credCentrify "github.com/hashicorp/vault-plugin-auth-centrify"
 	credCF "github.com/hashicorp/vault-plugin-auth-cf"
 	credGcp "github.com/hashicorp/vault-plugin-auth-gcp/plugin"
-	credOIDC "github.com/hashicorp/vault-plugin-auth-jwt"
-	credKerb "github.com/hashicorp/vault-plugin-auth-kerberos"
 	credOCI "github.com/hashicorp/vault-plugin-auth-oci"
 	credAws "github.com/hashicorp/vault/builtin/credential/aws"
 	credCert "github.com/hashicorp/vault/builtin/credential/cert"
@@ -37,6 +37,7 @@ import (
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
 	credToken "github.com/hashicorp/vault/builtin/credential/token"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	credWebAuthn "github.com/hashicorp/vault/builtin/credential/webauthn"
 
 	logicalKv "github.com/hashicorp/vault-plugin-secrets-kv"
 	logicalDb "github.com/hashicorp/vault/builtin/logical/database"
@@ -75,6 +76,33 @@ const (
 	EnvVaultCLINoColor = `VAULT_CLI_NO_COLOR`
 	// EnvVaultFormat is the output format
 	EnvVaultFormat = `VAULT_FORMAT`
+	// EnvVaultFormatTemplate is the Go template used with the "template"
+	// output format
+	EnvVaultFormatTemplate = `VAULT_FORMAT_TEMPLATE`
+	// EnvVaultRetryMinWait is the minimum time to wait between retries
+	EnvVaultRetryMinWait = `VAULT_RETRY_MIN_WAIT`
+	// EnvVaultRetryMaxWait is the maximum time to wait between retries
+	EnvVaultRetryMaxWait = `VAULT_RETRY_MAX_WAIT`
+	// EnvVaultHTTPVersion forces the HTTP version used by the CLI
+	EnvVaultHTTPVersion = `VAULT_HTTP_VERSION`
+	// EnvVaultMaxIdleConns is the maximum number of idle connections the CLI
+	// keeps open
+	EnvVaultMaxIdleConns = `VAULT_MAX_IDLE_CONNS`
+	// EnvVaultIdleConnTimeout is how long the CLI keeps idle connections open
+	EnvVaultIdleConnTimeout = `VAULT_IDLE_CONN_TIMEOUT`
+	// EnvVaultTCPKeepAlive is the interval of the TCP keep-alive probes of
+	// the connections of the CLI
+	EnvVaultTCPKeepAlive = `VAULT_TCP_KEEPALIVE`
+	// EnvVaultSSHJump is the SSH jump host through which the CLI connects to
+	// Vault
+	EnvVaultSSHJump = `VAULT_SSH_JUMP`
+	// EnvVaultCacheTTL is how long the CLI caches the responses of reads
+	EnvVaultCacheTTL = `VAULT_CACHE_TTL`
+	// EnvVaultRedact masks the secret values in table output
+	EnvVaultRedact = `VAULT_REDACT`
+	// EnvVaultCLITelemetry is the statsd or OTLP endpoint the CLI sends the
+	// metrics of each invocation to
+	EnvVaultCLITelemetry = `VAULT_CLI_TELEMETRY`
 	// EnvVaultLicense is an env var used in Vault Enterprise to provide a license blob
 	EnvVaultLicense = "VAULT_LICENSE"
 	// EnvVaultLicensePath is an env var used in Vault Enterprise to provide a
@@ -127,6 +155,8 @@ const (
 var (
 	auditBackends = map[string]audit.Factory{
 		"file":   auditFile.Factory,
+		"kafka":  auditKafka.Factory,
+		"otlp":   auditOTLP.Factory,
 		"socket": auditSocket.Factory,
 		"syslog": auditSyslog.Factory,
 	}
@@ -194,10 +224,10 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 		"cf":       &credCF.CLIHandler{},
 		"gcp":      &credGcp.CLIHandler{},
 		"github":   &credGitHub.CLIHandler{},
-		"kerberos": &credKerb.CLIHandler{},
+		"kerberos": &kerberosLoginHandler{},
 		"ldap":     &credLdap.CLIHandler{},
 		"oci":      &credOCI.CLIHandler{},
-		"oidc":     &credOIDC.CLIHandler{},
+		"oidc":     &oidcLoginHandler{},
 		"okta":     &credOkta.CLIHandler{},
 		"pcf":      &credCF.CLIHandler{}, // Deprecated.
 		"radius": &credUserpass.CLIHandler{
@@ -207,14 +237,20 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 		"userpass": &credUserpass.CLIHandler{
 			DefaultMount: "userpass",
 		},
+		"webauthn": &credWebAuthn.CLIHandler{
+			DefaultMount: "webauthn",
+		},
 	}
 
 	getBaseCommand := func() *BaseCommand {
 		return &BaseCommand{
-			UI:          ui,
-			tokenHelper: runOpts.TokenHelper,
-			flagAddress: runOpts.Address,
-			client:      runOpts.Client,
+			UI:              ui,
+			tokenHelper:     runOpts.TokenHelper,
+			loginHandlers:   loginHandlers,
+			flagAddress:     runOpts.Address,
+			telemetry:       runOpts.telemetry,
+			cliConfigLoader: runOpts.config,
+			client:          runOpts.Client,
 		}
 	}
 
@@ -232,6 +268,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"audit decode": func() (cli.Command, error) {
+			return &AuditDecodeCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"audit disable": func() (cli.Command, error) {
 			return &AuditDisableCommand{
 				BaseCommand: getBaseCommand(),
@@ -247,6 +288,17 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"audit tail": func() (cli.Command, error) {
+			return &AuditTailCommand{
+				BaseCommand: getBaseCommand(),
+				ShutdownCh:  MakeShutdownCh(),
+			}, nil
+		},
+		"audit test-filter": func() (cli.Command, error) {
+			return &AuditTestFilterCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"auth tune": func() (cli.Command, error) {
 			return &AuthTuneCommand{
 				BaseCommand: getBaseCommand(),
@@ -283,6 +335,48 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"cache": func() (cli.Command, error) {
+			return &CacheCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"cache purge": func() (cli.Command, error) {
+			return &CachePurgeCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"control-group": func() (cli.Command, error) {
+			return &ControlGroupCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"control-group authorize": func() (cli.Command, error) {
+			return &ControlGroupAuthorizeCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"control-group request": func() (cli.Command, error) {
+			return &ControlGroupRequestCommand{
+				BaseCommand: getBaseCommand(),
+				ShutdownCh:  MakeShutdownCh(),
+			}, nil
+		},
+		"control-group status": func() (cli.Command, error) {
+			return &ControlGroupStatusCommand{
+				BaseCommand: getBaseCommand(),
+				ShutdownCh:  MakeShutdownCh(),
+			}, nil
+		},
+		"database": func() (cli.Command, error) {
+			return &DatabaseCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"database rotate-root": func() (cli.Command, error) {
+			return &DatabaseRotateRootCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"debug": func() (cli.Command, error) {
 			return &DebugCommand{
 				BaseCommand: getBaseCommand(),
@@ -294,6 +388,36 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"identity": func() (cli.Command, error) {
+			return &IdentityCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"identity group": func() (cli.Command, error) {
+			return &IdentityGroupCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"identity group tree": func() (cli.Command, error) {
+			return &IdentityGroupTreeCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"identity import": func() (cli.Command, error) {
+			return &IdentityImportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"kubernetes": func() (cli.Command, error) {
+			return &KubernetesCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"kubernetes kubeconfig": func() (cli.Command, error) {
+			return &KubernetesKubeconfigCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"lease": func() (cli.Command, error) {
 			return &LeaseCommand{
 				BaseCommand: getBaseCommand(),
@@ -302,6 +426,12 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 		"lease renew": func() (cli.Command, error) {
 			return &LeaseRenewCommand{
 				BaseCommand: getBaseCommand(),
+				ShutdownCh:  MakeShutdownCh(),
+			}, nil
+		},
+		"lease list": func() (cli.Command, error) {
+			return &LeaseListCommand{
+				BaseCommand: getBaseCommand(),
 			}, nil
 		},
 		"lease lookup": func() (cli.Command, error) {
@@ -360,6 +490,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"namespace tree": func() (cli.Command, error) {
+			return &NamespaceTreeCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator": func() (cli.Command, error) {
 			return &OperatorCommand{
 				BaseCommand: getBaseCommand(),
@@ -392,6 +527,21 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				ShutdownCh:       MakeShutdownCh(),
 			}, nil
 		},
+		"operator namespace": func() (cli.Command, error) {
+			return &OperatorNamespaceCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"operator namespace export": func() (cli.Command, error) {
+			return &OperatorNamespaceExportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"operator namespace import": func() (cli.Command, error) {
+			return &OperatorNamespaceImportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator raft": func() (cli.Command, error) {
 			return &OperatorRaftCommand{
 				BaseCommand: getBaseCommand(),
@@ -457,6 +607,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator seal-migration": func() (cli.Command, error) {
+			return &OperatorSealMigrationCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"operator seal-migration status": func() (cli.Command, error) {
+			return &OperatorSealMigrationStatusCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator step-down": func() (cli.Command, error) {
 			return &OperatorStepDownCommand{
 				BaseCommand: getBaseCommand(),
@@ -482,6 +642,31 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"patch": func() (cli.Command, error) {
+			return &PatchCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"pki": func() (cli.Command, error) {
+			return &PKICommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"pki health-check": func() (cli.Command, error) {
+			return &PKIHealthCheckCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"pki issue": func() (cli.Command, error) {
+			return &PKIIssueCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"pki renew": func() (cli.Command, error) {
+			return &PKIRenewCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"plugin": func() (cli.Command, error) {
 			return &PluginCommand{
 				BaseCommand: getBaseCommand(),
@@ -492,6 +677,17 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"plugin dev": func() (cli.Command, error) {
+			return &PluginDevCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"plugin dev run": func() (cli.Command, error) {
+			return &PluginDevRunCommand{
+				BaseCommand: getBaseCommand(),
+				ShutdownCh:  MakeShutdownCh(),
+			}, nil
+		},
 		"plugin info": func() (cli.Command, error) {
 			return &PluginInfoCommand{
 				BaseCommand: getBaseCommand(),
@@ -517,6 +713,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"plugin scaffold": func() (cli.Command, error) {
+			return &PluginScaffoldCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"policy": func() (cli.Command, error) {
 			return &PolicyCommand{
 				BaseCommand: getBaseCommand(),
@@ -532,6 +733,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"policy generate-from-audit": func() (cli.Command, error) {
+			return &PolicyGenerateFromAuditCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"policy lint": func() (cli.Command, error) {
+			return &PolicyLintCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"policy list": func() (cli.Command, error) {
 			return &PolicyListCommand{
 				BaseCommand: getBaseCommand(),
@@ -542,6 +753,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"policy render": func() (cli.Command, error) {
+			return &PolicyRenderCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"policy test": func() (cli.Command, error) {
+			return &PolicyTestCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"policy write": func() (cli.Command, error) {
 			return &PolicyWriteCommand{
 				BaseCommand: getBaseCommand(),
@@ -611,11 +832,21 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				SigUSR2Ch:  MakeSigUSR2Ch(),
 			}, nil
 		},
+		"shell": func() (cli.Command, error) {
+			return &ShellCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"ssh": func() (cli.Command, error) {
 			return &SSHCommand{
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"ssh agent-load": func() (cli.Command, error) {
+			return &SSHAgentLoadCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"status": func() (cli.Command, error) {
 			return &StatusCommand{
 				BaseCommand: getBaseCommand(),
@@ -651,6 +882,51 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"transform": func() (cli.Command, error) {
+			return &TransformCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transform decode": func() (cli.Command, error) {
+			return &TransformDecodeCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transform encode": func() (cli.Command, error) {
+			return &TransformEncodeCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit": func() (cli.Command, error) {
+			return &TransitCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit datakey": func() (cli.Command, error) {
+			return &TransitDatakeyCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit decrypt-file": func() (cli.Command, error) {
+			return &TransitDecryptFileCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit encrypt-file": func() (cli.Command, error) {
+			return &TransitEncryptFileCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit sign-file": func() (cli.Command, error) {
+			return &TransitSignFileCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"transit verify-file": func() (cli.Command, error) {
+			return &TransitVerifyFileCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"unwrap": func() (cli.Command, error) {
 			return &UnwrapCommand{
 				BaseCommand: getBaseCommand(),
@@ -682,6 +958,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"kv mv": func() (cli.Command, error) {
+			return &KVMoveCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"kv patch": func() (cli.Command, error) {
 			return &KVPatchCommand{
 				BaseCommand: getBaseCommand(),
@@ -692,16 +973,46 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"kv cp": func() (cli.Command, error) {
+			return &KVCopyCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"kv diff": func() (cli.Command, error) {
+			return &KVDiffCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"kv export": func() (cli.Command, error) {
+			return &KVExportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"kv get": func() (cli.Command, error) {
 			return &KVGetCommand{
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"kv edit": func() (cli.Command, error) {
+			return &KVEditCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"kv history": func() (cli.Command, error) {
+			return &KVHistoryCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"kv delete": func() (cli.Command, error) {
 			return &KVDeleteCommand{
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"kv import": func() (cli.Command, error) {
+			return &KVImportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"kv list": func() (cli.Command, error) {
 			return &KVListCommand{
 				BaseCommand: getBaseCommand(),
@@ -747,6 +1058,16 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) {
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"kv metadata delete-custom": func() (cli.Command, error) {
+			return &KVMetadataDeleteCustomCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
+		"kv metadata set-custom": func() (cli.Command, error) {
+			return &KVMetadataSetCustomCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"monitor": func() (cli.Command, error) {
 			return &MonitorCommand{
 				BaseCommand: getBaseCommand(),
@@ -0,0 +1,13 @@
+//go:build cgo
+
+package command
+
+import (
+	physSQLite "github.com/hashicorp/vault/physical/sqlite"
+)
+
+// The SQLite storage backend is only available in binaries built with cgo,
+// which its driver requires.
+func init() {
+	physicalBackends["sqlite"] = physSQLite.NewSQLiteBackend
+}
@@ -1,6 +1,8 @@
 package command
 
 import (
+	"time"
+
 	"github.com/hashicorp/vault/command/config"
 )
 
@@ -21,6 +23,43 @@ type DefaultConfig struct {
 	// is not specified, then vault's internal token store will be used, which
 	// stores the token on disk unencrypted.
 	TokenHelper string `hcl:"token_helper"`
+
+	// TokenHelperProtocol is the version of the protocol spoken by the token
+	// helper. Version 2 helpers receive the Vault address and namespace with
+	// each request, so they can store a token for each of them.
+	TokenHelperProtocol int `hcl:"token_helper_protocol"`
+
+	// TokenStore selects where vault's internal token store keeps the token
+	// when no token helper is specified: "file" (the default) stores it in
+	// ~/.vault-token, and "keychain" stores it in the credential store of the
+	// OS.
+	TokenStore string `hcl:"token_store"`
+
+	// MFATOTP configures the TOTP seeds of MFA methods, so that the CLI can
+	// generate passcodes for them itself rather than prompting for one.
+	MFATOTP []*config.MFATOTP `hcl:"mfa_totp"`
+
+	// Profiles are named sets of connection settings, one of which can be
+	// selected with -profile or VAULT_PROFILE.
+	Profiles []*config.Profile `hcl:"profile"`
+
+	// CacheTTL is how long the responses of reads are cached on disk, as with
+	// -cache-ttl. Caching is disabled if it is not set.
+	CacheTTL    time.Duration `hcl:"-"`
+	CacheTTLRaw interface{}   `hcl:"cache_ttl"`
+
+	// Reauth configures the auth method used to log in again when a command
+	// fails because the token expired.
+	Reauth *config.Reauth `hcl:"reauth"`
+
+	// Redact masks the secret values in table output by default, as with
+	// -redact. It is overridden with -reveal.
+	Redact bool `hcl:"redact"`
+
+	// Telemetry is the statsd or OTLP endpoint the metrics of each invocation
+	// are sent to, as with VAULT_CLI_TELEMETRY, e.g. "statsd://127.0.0.1:8125"
+	// or "http://127.0.0.1:4318". Metrics are not sent if it is not set.
+	Telemetry string `hcl:"telemetry"`
 }
 
 // Config loads the configuration and returns it. If the configuration
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/hashicorp/vault/sdk/helper/hclutil"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
 	homedir "github.com/mitchellh/go-homedir"
 )
 
@@ -18,6 +20,10 @@ const (
 	// ConfigPathEnv is the environment variable that can be used to
 	// override where the Vault configuration is.
 	ConfigPathEnv = "VAULT_CONFIG_PATH"
+
+	// ProfileEnv is the environment variable that can be used to select a
+	// profile from the configuration.
+	ProfileEnv = "VAULT_PROFILE"
 )
 
 // Config is the CLI configuration for Vault that can be specified via
@@ -28,6 +34,132 @@ type DefaultConfig struct {
 	// is not specified, then vault's internal token store will be used, which
 	// stores the token on disk unencrypted.
 	TokenHelper string `hcl:"token_helper"`
+
+	// TokenHelperProtocol is the version of the protocol spoken by the token
+	// helper. Version 2 helpers receive the Vault address and namespace with
+	// each request, so they can store a token for each of them.
+	TokenHelperProtocol int `hcl:"token_helper_protocol"`
+
+	// TokenStore selects where vault's internal token store keeps the token
+	// when no token helper is specified: "file" (the default) stores it in
+	// ~/.vault-token, and "keychain" stores it in the credential store of the
+	// OS.
+	TokenStore string `hcl:"token_store"`
+
+	// MFATOTP configures the TOTP seeds of MFA methods, so that the CLI can
+	// generate passcodes for them itself rather than prompting for one.
+	MFATOTP []*MFATOTP `hcl:"mfa_totp"`
+
+	// Profiles are named sets of connection settings, one of which can be
+	// selected with -profile or VAULT_PROFILE.
+	Profiles []*Profile `hcl:"profile"`
+
+	// CacheTTL is how long the responses of reads are cached on disk, as with
+	// -cache-ttl. Caching is disabled if it is not set.
+	CacheTTL    time.Duration `hcl:"-"`
+	CacheTTLRaw interface{}   `hcl:"cache_ttl"`
+
+	// Reauth configures the auth method used to log in again when a command
+	// fails because the token expired.
+	Reauth *Reauth `hcl:"reauth"`
+
+	// Redact masks the secret values in table output by default, as with
+	// -redact. It is overridden with -reveal.
+	Redact bool `hcl:"redact"`
+
+	// Telemetry is the statsd or OTLP endpoint the metrics of each invocation
+	// are sent to, as with VAULT_CLI_TELEMETRY, e.g. "statsd://127.0.0.1:8125"
+	// or "http://127.0.0.1:4318". Metrics are not sent if it is not set.
+	Telemetry string `hcl:"telemetry"`
+}
+
+// Profile is a named set of connection settings, given as a labeled block,
+// e.g.:
+//
+//	profile "prod" {
+//	  address   = "https://vault.prod.example.com:8200"
+//	  namespace = "team-a"
+//	}
+//
+// Settings given with flags or environment variables take precedence over the
+// ones of the selected profile.
+type Profile struct {
+	// Name is the name used to select the profile.
+	Name string `hcl:",key"`
+
+	Address       string `hcl:"address"`
+	Namespace     string `hcl:"namespace"`
+	CACert        string `hcl:"ca_cert"`
+	CAPath        string `hcl:"ca_path"`
+	ClientCert    string `hcl:"client_cert"`
+	ClientKey     string `hcl:"client_key"`
+	TLSServerName string `hcl:"tls_server_name"`
+	TLSSkipVerify bool   `hcl:"tls_skip_verify"`
+
+	// TokenHelper overrides the top-level token helper for this profile.
+	TokenHelper string `hcl:"token_helper"`
+
+	// Format is the default output format, e.g. "json".
+	Format string `hcl:"format"`
+}
+
+// Profile returns the profile with the given name.
+func (c *DefaultConfig) Profile(name string) (*Profile, error) {
+	for _, profile := range c.Profiles {
+		if profile != nil && profile.Name == name {
+			return profile, nil
+		}
+	}
+	return nil, fmt.Errorf("profile %q is not defined in the CLI configuration", name)
+}
+
+// MFATOTP is the configuration of the TOTP seed of a single MFA method, given
+// as a labeled block, e.g.:
+//
+//	mfa_totp "<method ID>" {
+//	  seed_command = "security find-generic-password -s vault-totp -w"
+//	}
+type MFATOTP struct {
+	// MethodID is the ID of the TOTP MFA method.
+	MethodID string `hcl:",key"`
+
+	// SeedCommand is executed within a shell and must write the
+	// base32-encoded TOTP seed to stdout. This allows the seed to be kept in
+	// the system keychain or in an encrypted file rather than in plain text.
+	SeedCommand string `hcl:"seed_command"`
+
+	// Digits, Period and Algorithm must match the MFA method's settings. If
+	// not set, they default to 6 digits, 30 seconds and SHA1.
+	Digits    int    `hcl:"digits"`
+	Period    int    `hcl:"period"`
+	Algorithm string `hcl:"algorithm"`
+}
+
+// Reauth is the auth method used to log in again when a command fails with a
+// 403 because the token expired, given as a block, e.g.:
+//
+//	reauth {
+//	  method = "approle"
+//	  params = {
+//	    role_id   = "..."
+//	    secret_id = "@/etc/vault/secret-id"
+//	  }
+//	}
+//
+// The command is then retried once with the new token, which is also stored
+// with the token helper.
+type Reauth struct {
+	// Method is the type of the auth method, e.g. "oidc" or "cert". Methods
+	// without a CLI handler, such as "approle", are logged in to by writing
+	// the params to their login endpoint.
+	Method string `hcl:"method"`
+
+	// Path is where the auth method is mounted. It defaults to the method.
+	Path string `hcl:"path"`
+
+	// Params are the parameters of the login, as given to vault login. Values
+	// starting with @ are read from a file.
+	Params map[string]string `hcl:"params"`
 }
 
 // Config loads the configuration and returns it. If the configuration
@@ -87,6 +219,14 @@ func ParseConfig(contents string) (*DefaultConfig, error) {
 
 	valid := []string{
 		"token_helper",
+		"token_helper_protocol",
+		"token_store",
+		"mfa_totp",
+		"profile",
+		"cache_ttl",
+		"reauth",
+		"redact",
+		"telemetry",
 	}
 	if err := hclutil.CheckHCLKeys(list, valid); err != nil {
 		return nil, err
@@ -96,5 +236,16 @@ func ParseConfig(contents string) (*DefaultConfig, error) {
 	if err := hcl.DecodeObject(&c, list); err != nil {
 		return nil, err
 	}
+
+	if c.CacheTTLRaw != nil {
+		if c.CacheTTL, err = parseutil.ParseDurationSecond(c.CacheTTLRaw); err != nil {
+			return nil, fmt.Errorf("invalid cache_ttl: %w", err)
+		}
+		c.CacheTTLRaw = nil
+	}
+
+	if c.Reauth != nil && c.Reauth.Method == "" {
+		return nil, fmt.Errorf("reauth: method is required")
+	}
 	return &c, nil
 }
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 const FixturePath = "../test-fixtures"
@@ -47,3 +48,175 @@ nope = "true"
 		t.Errorf("bad error: %s", err.Error())
 	}
 }
+
+func TestParseConfig_mfaTOTP(t *testing.T) {
+	config, err := ParseConfig(`
+token_helper = "/token"
+
+mfa_totp "method-id" {
+  seed_command = "security find-generic-password -s vault-totp -w"
+  digits       = 8
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &DefaultConfig{
+		TokenHelper: "/token",
+		MFATOTP: []*MFATOTP{
+			{
+				MethodID:    "method-id",
+				SeedCommand: "security find-generic-password -s vault-totp -w",
+				Digits:      8,
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestParseConfig_profiles(t *testing.T) {
+	config, err := ParseConfig(`
+profile "prod" {
+  address         = "https://vault.prod.example.com:8200"
+  namespace       = "team-a"
+  ca_cert         = "/etc/vault/ca.pem"
+  tls_skip_verify = true
+  token_helper    = "/prod-token"
+  format          = "json"
+}
+
+profile "dev" {
+  address = "http://127.0.0.1:8200"
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &DefaultConfig{
+		Profiles: []*Profile{
+			{
+				Name:          "prod",
+				Address:       "https://vault.prod.example.com:8200",
+				Namespace:     "team-a",
+				CACert:        "/etc/vault/ca.pem",
+				TLSSkipVerify: true,
+				TokenHelper:   "/prod-token",
+				Format:        "json",
+			},
+			{
+				Name:    "dev",
+				Address: "http://127.0.0.1:8200",
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	profile, err := config.Profile("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile != config.Profiles[1] {
+		t.Fatalf("bad: %#v", profile)
+	}
+	if _, err := config.Profile("staging"); err == nil {
+		t.Fatal("expected an error for an unknown profile")
+	}
+}
+
+func TestParseConfig_tokenHelper(t *testing.T) {
+	config, err := ParseConfig(`
+token_store           = "keychain"
+token_helper_protocol = 2
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &DefaultConfig{
+		TokenStore:          "keychain",
+		TokenHelperProtocol: 2,
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestParseConfig_cacheTTL(t *testing.T) {
+	config, err := ParseConfig(`cache_ttl = "5m"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CacheTTL != 5*time.Minute || config.CacheTTLRaw != nil {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	config, err = ParseConfig(`cache_ttl = 30`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CacheTTL != 30*time.Second {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	if _, err := ParseConfig(`cache_ttl = "soon"`); err == nil || !strings.Contains(err.Error(), "invalid cache_ttl") {
+		t.Fatalf("expected an error, got %v", err)
+	}
+}
+
+func TestParseConfig_redact(t *testing.T) {
+	config, err := ParseConfig(`redact = true`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Redact {
+		t.Fatal("expected redact to be set")
+	}
+}
+
+func TestParseConfig_telemetry(t *testing.T) {
+	config, err := ParseConfig(`telemetry = "statsd://127.0.0.1:8125"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Telemetry != "statsd://127.0.0.1:8125" {
+		t.Fatalf("bad telemetry: %q", config.Telemetry)
+	}
+}
+
+func TestParseConfig_reauth(t *testing.T) {
+	config, err := ParseConfig(`
+reauth {
+  method = "approle"
+  path   = "ci"
+  params = {
+    role_id   = "my-role"
+    secret_id = "@/etc/vault/secret-id"
+  }
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Reauth{
+		Method: "approle",
+		Path:   "ci",
+		Params: map[string]string{
+			"role_id":   "my-role",
+			"secret_id": "@/etc/vault/secret-id",
+		},
+	}
+	if !reflect.DeepEqual(config.Reauth, expected) {
+		t.Fatalf("bad: %#v", config.Reauth)
+	}
+
+	if _, err := ParseConfig(`reauth { path = "ci" }`); err == nil || !strings.Contains(err.Error(), "method is required") {
+		t.Fatalf("expected an error, got %v", err)
+	}
+}
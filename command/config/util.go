@@ -1,24 +1,54 @@
 package config
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/vault/command/token"
 )
 
 // DefaultTokenHelper returns the token helper that is configured for Vault.
 func DefaultTokenHelper() (token.TokenHelper, error) {
+	return ProfileTokenHelper("")
+}
+
+// ProfileTokenHelper returns the token helper that is configured for the given
+// profile. If the profile is empty or does not set a token helper, the
+// top-level one is used.
+func ProfileTokenHelper(profile string) (token.TokenHelper, error) {
 	config, err := LoadConfig("")
 	if err != nil {
 		return nil, err
 	}
 
 	path := config.TokenHelper
+	if profile != "" {
+		p, err := config.Profile(profile)
+		if err != nil {
+			return nil, err
+		}
+		if p.TokenHelper != "" {
+			path = p.TokenHelper
+		}
+	}
 	if path == "" {
-		return token.NewInternalTokenHelper()
+		switch config.TokenStore {
+		case "", "file":
+			return token.NewInternalTokenHelper()
+		case "keychain":
+			return token.NewKeychainTokenHelper(), nil
+		default:
+			return nil, fmt.Errorf("invalid token_store %q; must be \"file\" or \"keychain\"", config.TokenStore)
+		}
 	}
 
 	path, err = token.ExternalTokenHelperPath(path)
 	if err != nil {
 		return nil, err
 	}
-	return &token.ExternalTokenHelper{BinaryPath: path}, nil
+	switch config.TokenHelperProtocol {
+	case 0, 1, 2:
+	default:
+		return nil, fmt.Errorf("invalid token_helper_protocol %d; must be 1 or 2", config.TokenHelperProtocol)
+	}
+	return &token.ExternalTokenHelper{BinaryPath: path, Protocol: config.TokenHelperProtocol}, nil
 }
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+// defaultControlGroupWaitInterval is how often the status of a control group
+// request is read while waiting for it to be authorized.
+const defaultControlGroupWaitInterval = 5 * time.Second
+
+var _ cli.Command = (*ControlGroupCommand)(nil)
+
+type ControlGroupCommand struct {
+	*BaseCommand
+}
+
+func (c *ControlGroupCommand) Synopsis() string {
+	return "Request and authorize access through control groups"
+}
+
+func (c *ControlGroupCommand) Help() string {
+	helpText := `
+Usage: vault control-group <subcommand> [options] [args]
+
+  This command groups subcommands for working with control groups, which
+  require the authorization of other users before a request's response is
+  returned. Vault returns such responses wrapped, and unwraps them only once
+  the request is authorized.
+
+  Request a secret at a path which is protected by a control group:
+
+      $ vault control-group request secret/foo
+
+  Check whether the request with the given accessor is authorized yet:
+
+      $ vault control-group status 0ad21b78-e9bb-64fa-88b8-1e38db217bde
+
+  Authorize the request with the given accessor, as an approver:
+
+      $ vault control-group authorize 0ad21b78-e9bb-64fa-88b8-1e38db217bde
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ControlGroupCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// readControlGroupRequest reads the status of the control group request with
+// the given accessor.
+func readControlGroupRequest(client *api.Client, accessor string) (*api.Secret, error) {
+	secret, err := client.Logical().Write("sys/control-group/request", map[string]interface{}{
+		"accessor": accessor,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no control group request found for accessor %s", accessor)
+	}
+	return secret, nil
+}
+
+// waitForControlGroup reads the status of the control group request with the
+// given accessor every interval until it is authorized, and returns the last
+// status read. It returns nil if stopCh is closed first.
+func waitForControlGroup(client *api.Client, accessor string, interval time.Duration, stopCh <-chan struct{}) (*api.Secret, error) {
+	for {
+		secret, err := readControlGroupRequest(client, accessor)
+		if err != nil {
+			return nil, err
+		}
+		if approved, _ := secret.Data["approved"].(bool); approved {
+			return secret, nil
+		}
+
+		select {
+		case <-stopCh:
+			return nil, nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*ControlGroupAuthorizeCommand)(nil)
+	_ cli.CommandAutocomplete = (*ControlGroupAuthorizeCommand)(nil)
+)
+
+type ControlGroupAuthorizeCommand struct {
+	*BaseCommand
+}
+
+func (c *ControlGroupAuthorizeCommand) Synopsis() string {
+	return "Authorizes control group requests"
+}
+
+func (c *ControlGroupAuthorizeCommand) Help() string {
+	helpText := `
+Usage: vault control-group authorize [options] ACCESSOR...
+
+  Authorizes the control group requests with the given wrapping token
+  accessors, as the entity of the current token. A request is approved once
+  enough approvers authorized it, after which the requester can unwrap its
+  response.
+
+  Review a request before authorizing it:
+
+      $ vault control-group status 0ad21b78-e9bb-64fa-88b8-1e38db217bde
+
+  Authorize it:
+
+      $ vault control-group authorize 0ad21b78-e9bb-64fa-88b8-1e38db217bde
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ControlGroupAuthorizeCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+}
+
+func (c *ControlGroupAuthorizeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *ControlGroupAuthorizeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ControlGroupAuthorizeCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected at least 1, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	type result struct {
+		Accessor string `json:"accessor"`
+		Approved bool   `json:"approved"`
+	}
+	results := make([]result, 0, len(args))
+	for _, accessor := range args {
+		accessor = strings.TrimSpace(accessor)
+		secret, err := client.Logical().Write("sys/control-group/authorize", map[string]interface{}{
+			"accessor": accessor,
+		})
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error authorizing request %s: %s", accessor, err), err)
+			return 2
+		}
+
+		r := result{Accessor: accessor}
+		if secret != nil && secret.Data != nil {
+			r.Approved, _ = secret.Data["approved"].(bool)
+		}
+		results = append(results, r)
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, results)
+	}
+
+	table := []string{"Accessor | Approved"}
+	for _, r := range results {
+		table = append(table, fmt.Sprintf("%s | %t", r.Accessor, r.Approved))
+	}
+	c.UI.Output(tableOutput(table, nil))
+	return 0
+}
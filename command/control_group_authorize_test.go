@@ -0,0 +1,77 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testControlGroupAuthorizeCommand(tb testing.TB) (*cli.MockUi, *ControlGroupAuthorizeCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &ControlGroupAuthorizeCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestControlGroupAuthorizeCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not_enough_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testControlGroupAuthorizeCommand(t)
+
+		code := cmd.Run(nil)
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Not enough arguments"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("authorize", func(t *testing.T) {
+		t.Parallel()
+
+		server := testControlGroupServer(t, 0)
+		client := testClient(t, server.URL, "root")
+
+		ui, cmd := testControlGroupAuthorizeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"wrap-accessor"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		output := ui.OutputWriter.String()
+		if expected := "wrap-accessor true"; !strings.Contains(strings.Join(strings.Fields(output), " "), expected) {
+			t.Errorf("expected %q to contain %q", output, expected)
+		}
+
+		// The requester sees the approval.
+		statusUI, status := testControlGroupStatusCommand(t)
+		status.client = client
+		if code := status.Run([]string{"wrap-accessor"}); code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, statusUI.ErrorWriter.String())
+		}
+		if expected := "bob e2"; !strings.Contains(strings.Join(strings.Fields(statusUI.OutputWriter.String()), " "), expected) {
+			t.Errorf("expected %q to contain %q", statusUI.OutputWriter.String(), expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testControlGroupAuthorizeCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
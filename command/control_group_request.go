@@ -0,0 +1,181 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*ControlGroupRequestCommand)(nil)
+	_ cli.CommandAutocomplete = (*ControlGroupRequestCommand)(nil)
+)
+
+type ControlGroupRequestCommand struct {
+	*BaseCommand
+
+	flagWait         bool
+	flagWaitInterval time.Duration
+
+	// ShutdownCh is used to capture interrupt signal and stop waiting
+	ShutdownCh chan struct{}
+
+	testStdin io.Reader // for tests
+}
+
+func (c *ControlGroupRequestCommand) Synopsis() string {
+	return "Requests data protected by a control group"
+}
+
+func (c *ControlGroupRequestCommand) Help() string {
+	helpText := `
+Usage: vault control-group request [options] PATH [DATA K=V...]
+
+  Reads the data at PATH, which is protected by a control group. Vault
+  responds with a wrapping token, which can only be unwrapped once enough
+  approvers authorized the request with "vault control-group authorize". The
+  accessor of the wrapping token identifies the request; share it with the
+  approvers.
+
+  If data is given, it is sent with the request like by "vault read".
+
+  Request the secret at secret/foo:
+
+      $ vault control-group request secret/foo
+
+  Request the secret, wait until the request is authorized and print the
+  secret:
+
+      $ vault control-group request -wait secret/foo
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ControlGroupRequestCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "wait",
+		Target:  &c.flagWait,
+		Default: false,
+		Usage: "Wait until the request is authorized, then unwrap the response " +
+			"and print it instead of the wrapping token.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "wait-interval",
+		Target:     &c.flagWaitInterval,
+		Default:    defaultControlGroupWaitInterval,
+		Completion: complete.PredictAnything,
+		Usage:      "How often to check whether the request is authorized when -wait is set.",
+	})
+
+	return set
+}
+
+func (c *ControlGroupRequestCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultFiles()
+}
+
+func (c *ControlGroupRequestCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ControlGroupRequestCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	stdin := (io.Reader)(os.Stdin)
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+
+	path := sanitizePath(args[0])
+	data, err := parseArgsDataStringLists(stdin, args[1:])
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	secret, err := client.Logical().ReadWithData(path, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading %s: %s", path, err), err)
+		return 2
+	}
+	if secret == nil {
+		c.UI.Error(fmt.Sprintf("No value found at %s", path))
+		return 2
+	}
+
+	if secret.WrapInfo == nil {
+		c.UI.Warn(fmt.Sprintf("WARNING! No control group protects %s, so the response "+
+			"was returned directly.", path))
+		return OutputSecret(c.UI, secret)
+	}
+
+	accessor := secret.WrapInfo.Accessor
+	if !c.flagWait {
+		if code := OutputSecret(c.UI, secret); code != 0 {
+			return code
+		}
+		if Format(c.UI) == "table" {
+			c.UI.Output(fmt.Sprintf("\nThe request needs to be authorized. Approvers "+
+				"can authorize it with:\n\n"+
+				"    $ vault control-group authorize %s\n\n"+
+				"Once \"vault control-group status %s\" shows that it is approved, "+
+				"unwrap the response with \"vault unwrap\".", accessor, accessor))
+		}
+		return 0
+	}
+
+	if Format(c.UI) == "table" {
+		c.UI.Output(fmt.Sprintf("Waiting for the request to be authorized (accessor %s)...", accessor))
+	}
+	status, err := waitForControlGroup(client, accessor, c.flagWaitInterval, c.ShutdownCh)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading the status of the request: %s", err), err)
+		return 2
+	}
+	if status == nil {
+		c.UI.Error(fmt.Sprintf("Interrupted before the request was authorized. "+
+			"The wrapping token is %s", secret.WrapInfo.Token))
+		return 2
+	}
+
+	unwrapped, err := unwrapResponse(client, secret, "")
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error unwrapping the response: %s", err), err)
+		return 2
+	}
+	if unwrapped == nil {
+		if Format(c.UI) == "table" {
+			c.UI.Info("Successfully unwrapped. There was no data in the wrapped secret.")
+		}
+		return 0
+	}
+	return OutputSecret(c.UI, unwrapped)
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testControlGroupRequestCommand(tb testing.TB) (*cli.MockUi, *ControlGroupRequestCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &ControlGroupRequestCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+}
+
+// testControlGroupServer returns a server which protects secret/foo with a
+// control group. The request is approved once it was authorized, or after its
+// status was read approveAfter times if approveAfter is positive.
+func testControlGroupServer(tb testing.TB, approveAfter int32) *httptest.Server {
+	tb.Helper()
+
+	var reads, authorized int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/foo":
+			w.Write([]byte(`{"wrap_info": {"token": "wrap-token", "accessor": "wrap-accessor", "ttl": 86400, "creation_path": "secret/foo"}}`))
+		case "/v1/sys/control-group/request":
+			n := atomic.AddInt32(&reads, 1)
+			approved := atomic.LoadInt32(&authorized) > 0 || (approveAfter > 0 && n >= approveAfter)
+			authorizations := `[]`
+			if approved {
+				authorizations = `[{"entity_id": "e2", "entity_name": "bob"}]`
+			}
+			w.Write([]byte(`{"data": {"approved": ` + strconv.FormatBool(approved) +
+				`, "request_path": "secret/foo", "request_entity": {"id": "e1", "name": "alice"}, "authorizations": ` +
+				authorizations + `}}`))
+		case "/v1/sys/control-group/authorize":
+			atomic.AddInt32(&authorized, 1)
+			w.Write([]byte(`{"data": {"approved": true}}`))
+		case "/v1/sys/wrapping/unwrap":
+			w.Write([]byte(`{"data": {"password": "hunter2"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+func TestControlGroupRequestCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"wrapped",
+			[]string{"secret/foo"},
+			"vault control-group authorize wrap-accessor",
+			0,
+		},
+		{
+			"wait",
+			[]string{"-wait", "-wait-interval", "10ms", "secret/foo"},
+			"hunter2",
+			0,
+		},
+		{
+			"not_found",
+			[]string{"secret/bar"},
+			"No value found at secret/bar",
+			2,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := testControlGroupServer(t, 3)
+
+			ui, cmd := testControlGroupRequestCommand(t)
+			cmd.client = testClient(t, server.URL, "root")
+
+			code := cmd.Run(tc.args)
+			if code != tc.code {
+				t.Errorf("expected %d to be %d: %s", code, tc.code, ui.ErrorWriter.String())
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testControlGroupRequestCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,171 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*ControlGroupStatusCommand)(nil)
+	_ cli.CommandAutocomplete = (*ControlGroupStatusCommand)(nil)
+)
+
+type ControlGroupStatusCommand struct {
+	*BaseCommand
+
+	flagWait         bool
+	flagWaitInterval time.Duration
+
+	// ShutdownCh is used to capture interrupt signal and stop waiting
+	ShutdownCh chan struct{}
+}
+
+func (c *ControlGroupStatusCommand) Synopsis() string {
+	return "Shows the status of a control group request"
+}
+
+func (c *ControlGroupStatusCommand) Help() string {
+	helpText := `
+Usage: vault control-group status [options] ACCESSOR
+
+  Shows whether the control group request with the given wrapping token
+  accessor is approved, who made it, and who authorized it so far. The
+  requester and the approvers of the request can read its status.
+
+  Show the status of a request:
+
+      $ vault control-group status 0ad21b78-e9bb-64fa-88b8-1e38db217bde
+
+  Wait until the request is approved:
+
+      $ vault control-group status -wait 0ad21b78-e9bb-64fa-88b8-1e38db217bde
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ControlGroupStatusCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "wait",
+		Target:  &c.flagWait,
+		Default: false,
+		Usage:   "Wait until the request is approved before printing its status.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "wait-interval",
+		Target:     &c.flagWaitInterval,
+		Default:    defaultControlGroupWaitInterval,
+		Completion: complete.PredictAnything,
+		Usage:      "How often to check whether the request is approved when -wait is set.",
+	})
+
+	return set
+}
+
+func (c *ControlGroupStatusCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *ControlGroupStatusCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ControlGroupStatusCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+	accessor := strings.TrimSpace(args[0])
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	var secret *api.Secret
+	if c.flagWait {
+		secret, err = waitForControlGroup(client, accessor, c.flagWaitInterval, c.ShutdownCh)
+		if err == nil && secret == nil {
+			c.UI.Error("Interrupted before the request was approved")
+			return 2
+		}
+	} else {
+		secret, err = readControlGroupRequest(client, accessor)
+	}
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading control group request: %s", err), err)
+		return 2
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputSecret(c.UI, secret)
+	}
+
+	approved, _ := secret.Data["approved"].(bool)
+	requester := "n/a"
+	if entity, ok := secret.Data["request_entity"].(map[string]interface{}); ok {
+		requester = controlGroupEntity(entity["name"], entity["id"])
+	}
+
+	c.UI.Output(tableOutput([]string{
+		"Key | Value",
+		fmt.Sprintf("Approved | %t", approved),
+		fmt.Sprintf("Request Path | %v", secret.Data["request_path"]),
+		fmt.Sprintf("Requested By | %s", requester),
+	}, nil))
+
+	authorizations, _ := secret.Data["authorizations"].([]interface{})
+	if len(authorizations) == 0 {
+		c.UI.Output("\nNo approver has authorized the request yet.")
+		return 0
+	}
+
+	table := []string{"Authorized By | Entity ID"}
+	for _, raw := range authorizations {
+		authz, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		table = append(table, fmt.Sprintf("%s | %s",
+			identityImportString(authz["entity_name"]), identityImportString(authz["entity_id"])))
+	}
+	c.UI.Output("\n" + tableOutput(table, nil))
+	return 0
+}
+
+// controlGroupEntity describes the entity with the given name and ID from a
+// control group response.
+func controlGroupEntity(name, id interface{}) string {
+	n, i := identityImportString(name), identityImportString(id)
+	switch {
+	case n == "":
+		return i
+	case i == "":
+		return n
+	default:
+		return fmt.Sprintf("%s (%s)", n, i)
+	}
+}
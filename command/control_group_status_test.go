@@ -0,0 +1,101 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testControlGroupStatusCommand(tb testing.TB) (*cli.MockUi, *ControlGroupStatusCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &ControlGroupStatusCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+}
+
+func TestControlGroupStatusCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  []string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			[]string{"Not enough arguments"},
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			[]string{"Too many arguments"},
+			1,
+		},
+		{
+			"pending",
+			[]string{"wrap-accessor"},
+			[]string{
+				"Approved false",
+				"Request Path secret/foo",
+				"Requested By alice (e1)",
+				"No approver has authorized the request yet.",
+			},
+			0,
+		},
+		{
+			"wait",
+			[]string{"-wait", "-wait-interval", "10ms", "wrap-accessor"},
+			[]string{
+				"Approved true",
+				"Authorized By Entity ID",
+				"bob e2",
+			},
+			0,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := testControlGroupServer(t, 3)
+
+			ui, cmd := testControlGroupStatusCommand(t)
+			cmd.client = testClient(t, server.URL, "root")
+
+			code := cmd.Run(tc.args)
+			if code != tc.code {
+				t.Errorf("expected %d to be %d: %s", code, tc.code, ui.ErrorWriter.String())
+			}
+
+			var lines []string
+			for _, line := range strings.Split(ui.OutputWriter.String()+ui.ErrorWriter.String(), "\n") {
+				lines = append(lines, strings.Join(strings.Fields(line), " "))
+			}
+			combined := strings.Join(lines, "\n")
+			for _, expected := range tc.out {
+				if !strings.Contains(combined, expected) {
+					t.Errorf("expected %q to contain %q", combined, expected)
+				}
+			}
+		})
+	}
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testControlGroupStatusCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
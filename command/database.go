@@ -0,0 +1,42 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*DatabaseCommand)(nil)
+
+type DatabaseCommand struct {
+	*BaseCommand
+}
+
+func (c *DatabaseCommand) Synopsis() string {
+	return "Interact with Vault's database secrets engines"
+}
+
+func (c *DatabaseCommand) Help() string {
+	helpText := `
+Usage: vault database <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with Vault's database
+  secrets engines. Here are a few examples of the database commands:
+
+  Rotate the root credentials of the "my-postgres" connection:
+
+      $ vault database rotate-root database/my-postgres
+
+  Rotate the root credentials of every connection in every namespace:
+
+      $ vault database rotate-root -all -namespace=""
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DatabaseCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
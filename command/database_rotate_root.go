@@ -0,0 +1,366 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/mattn/go-isatty"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*DatabaseRotateRootCommand)(nil)
+	_ cli.CommandAutocomplete = (*DatabaseRotateRootCommand)(nil)
+)
+
+// defaultDatabaseRotateConcurrency is the default number of connections whose
+// root credentials are rotated at once.
+const defaultDatabaseRotateConcurrency = 4
+
+type DatabaseRotateRootCommand struct {
+	*BaseCommand
+
+	flagAll         bool
+	flagConcurrency int
+	flagDryRun      bool
+	flagForce       bool
+
+	testInteractive bool // for tests
+}
+
+func (c *DatabaseRotateRootCommand) Synopsis() string {
+	return "Rotate the root credentials of database connections"
+}
+
+func (c *DatabaseRotateRootCommand) Help() string {
+	helpText := `
+Usage: vault database rotate-root [options] [MOUNT/CONNECTION ...]
+
+  Rotates the root credentials of connections of the database secrets engine.
+  Once rotated, the root credentials are only known to Vault. Connections are
+  given as the path of the database secrets engine followed by the name of
+  the connection.
+
+  With -all, the connections of every database secrets engine in the current
+  namespace and in all namespaces below it are rotated. The connections are
+  listed first and, unless -force is set, the user is asked to confirm. The
+  result of each rotation is reported, and a failed rotation doesn't stop the
+  others.
+
+  Rotate the root credentials of the "my-postgres" connection:
+
+      $ vault database rotate-root database/my-postgres
+
+  List the connections of every namespace, without rotating them:
+
+      $ vault database rotate-root -all -dry-run -namespace=""
+
+  Rotate the root credentials of every connection of the "team-a" namespace
+  and the namespaces below it, 8 at a time:
+
+      $ vault database rotate-root -all -namespace=team-a -concurrency=8 -force
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DatabaseRotateRootCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "all",
+		Target:  &c.flagAll,
+		Default: false,
+		Usage: "Rotate the root credentials of every connection of every " +
+			"database secrets engine in the current namespace and in all " +
+			"namespaces below it.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "concurrency",
+		Target:  &c.flagConcurrency,
+		Default: defaultDatabaseRotateConcurrency,
+		Usage:   "Number of connections to rotate at once.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  &c.flagDryRun,
+		Default: false,
+		Usage:   "List the connections which would be rotated, without rotating them.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "force",
+		Aliases: []string{"f"},
+		Target:  &c.flagForce,
+		Default: false,
+		Usage:   "Rotate without asking for confirmation.",
+	})
+
+	return set
+}
+
+func (c *DatabaseRotateRootCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultFolders()
+}
+
+func (c *DatabaseRotateRootCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *DatabaseRotateRootCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case c.flagAll && len(args) > 0:
+		c.UI.Error("Connections cannot be given together with -all")
+		return 1
+	case !c.flagAll && len(args) == 0:
+		c.UI.Error("Not enough arguments (expected at least 1 connection or -all)")
+		return 1
+	}
+
+	var targets []*databaseRotateTarget
+	for _, arg := range args {
+		target, err := parseDatabaseRotateTarget(arg)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		targets = append(targets, target)
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	base := namespace.Canonicalize(client.Headers().Get(consts.NamespaceHeaderName))
+	for _, target := range targets {
+		target.Namespace = base
+	}
+
+	code := 0
+	if c.flagAll {
+		var listErr error
+		targets, listErr = c.listTargets(client, base)
+		if listErr != nil {
+			// Rotate what could be listed, but still report the failure
+			c.UI.Warn(listErr.Error())
+			code = 2
+		}
+		if len(targets) == 0 {
+			c.UI.Error("No database connections found")
+			return 2
+		}
+	}
+
+	if Format(c.UI) == "table" {
+		c.UI.Output(fmt.Sprintf("The root credentials of the following %d connections will be rotated:\n", len(targets)))
+		for _, target := range targets {
+			c.UI.Output(fmt.Sprintf("  %s", target.path()))
+		}
+		c.UI.Output("")
+	}
+
+	if c.flagDryRun {
+		if Format(c.UI) != "table" {
+			return OutputData(c.UI, targets)
+		}
+		c.UI.Info("No changes made (dry run)")
+		return code
+	}
+
+	if !c.flagForce && c.interactive() {
+		ok, err := confirm(c.UI, fmt.Sprintf("Rotate the root credentials of %d connections?", len(targets)))
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading confirmation: %s", err), err)
+			return 1
+		}
+		if !ok {
+			c.UI.Info("Aborted")
+			return 1
+		}
+	}
+
+	rotateErr := c.rotate(client, targets)
+	if rotateErr != nil {
+		code = 2
+	}
+
+	if Format(c.UI) != "table" {
+		if ret := OutputData(c.UI, targets); ret != 0 {
+			return ret
+		}
+		return code
+	}
+
+	rows := []string{"Namespace | Mount | Connection | Status"}
+	rotated := 0
+	for _, target := range targets {
+		ns := target.Namespace
+		if ns == "" {
+			ns = "root"
+		}
+		if target.Status == databaseRotateStatusRotated {
+			rotated++
+		}
+		rows = append(rows, fmt.Sprintf("%s | %s | %s | %s", ns, target.Mount, target.Connection, target.Status))
+	}
+	c.UI.Output(tableOutput(rows, nil))
+	c.UI.Output("")
+
+	if rotateErr != nil {
+		c.UI.Error(rotateErr.Error())
+		c.UI.Error(fmt.Sprintf("Rotated the root credentials of %d of %d connections", rotated, len(targets)))
+		return 2
+	}
+	c.UI.Info(fmt.Sprintf("Success! Rotated the root credentials of %d connections", rotated))
+	return code
+}
+
+// listTargets returns the connections of the database secrets engines of the
+// namespace base of client and of all namespaces below it. Namespaces and
+// mounts which cannot be listed are skipped, and their errors returned
+// together.
+func (c *DatabaseRotateRootCommand) listTargets(client *api.Client, base string) ([]*databaseRotateTarget, error) {
+	var errs error
+	children, err := listNamespacesRecursive(client)
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	namespaces := append([]string{""}, children...)
+
+	var (
+		lock    sync.Mutex
+		targets []*databaseRotateTarget
+	)
+	err = runConcurrently(namespaces, c.flagConcurrency, func(rel string) error {
+		nsClient, err := clientForNamespace(client, base+rel)
+		if err != nil {
+			return err
+		}
+		mounts, err := nsClient.Sys().ListMounts()
+		if err != nil {
+			return fmt.Errorf("Error listing secrets engines of namespace %q: %s", base+rel, err)
+		}
+
+		var errs error
+		for path, mount := range mounts {
+			if mount.Type != "database" {
+				continue
+			}
+			connections, err := listEntries(nsClient, path+"config")
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+
+			lock.Lock()
+			for _, connection := range connections {
+				targets = append(targets, &databaseRotateTarget{
+					Namespace:  base + rel,
+					Mount:      path,
+					Connection: connection,
+					Status:     databaseRotateStatusPending,
+				})
+			}
+			lock.Unlock()
+		}
+		return errs
+	})
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].path() < targets[j].path()
+	})
+	return targets, errs
+}
+
+// rotate rotates the root credentials of the given connections, recording the
+// result of each rotation in its target.
+func (c *DatabaseRotateRootCommand) rotate(client *api.Client, targets []*databaseRotateTarget) error {
+	byPath := make(map[string]*databaseRotateTarget, len(targets))
+	paths := make([]string, 0, len(targets))
+	for _, target := range targets {
+		byPath[target.path()] = target
+		paths = append(paths, target.path())
+	}
+
+	return runConcurrently(paths, c.flagConcurrency, func(p string) error {
+		target := byPath[p]
+
+		nsClient, err := clientForNamespace(client, target.Namespace)
+		if err == nil {
+			_, err = nsClient.Logical().Write(target.Mount+"rotate-root/"+target.Connection, nil)
+		}
+		if err != nil {
+			target.Status = databaseRotateStatusFailed
+			target.Error = err.Error()
+			return fmt.Errorf("Error rotating the root credentials of %s: %s", p, err)
+		}
+		target.Status = databaseRotateStatusRotated
+		return nil
+	})
+}
+
+// interactive returns true if the user can be asked to confirm the rotation.
+func (c *DatabaseRotateRootCommand) interactive() bool {
+	return c.testInteractive || isatty.IsTerminal(os.Stdin.Fd())
+}
+
+const (
+	databaseRotateStatusPending = "pending"
+	databaseRotateStatusRotated = "rotated"
+	databaseRotateStatusFailed  = "failed"
+)
+
+// databaseRotateTarget is a connection of a database secrets engine whose root
+// credentials are rotated.
+type databaseRotateTarget struct {
+	Namespace  string `json:"namespace"`
+	Mount      string `json:"mount"`
+	Connection string `json:"connection"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// path returns the full path of the connection, including its namespace.
+func (t *databaseRotateTarget) path() string {
+	return t.Namespace + t.Mount + t.Connection
+}
+
+// parseDatabaseRotateTarget parses a connection given as the path of its
+// database secrets engine followed by its name, e.g. "database/my-postgres".
+func parseDatabaseRotateTarget(arg string) (*databaseRotateTarget, error) {
+	arg = strings.Trim(arg, "/")
+	i := strings.LastIndex(arg, "/")
+	if i <= 0 {
+		return nil, fmt.Errorf("Invalid connection %q: expected MOUNT/CONNECTION", arg)
+	}
+	return &databaseRotateTarget{
+		Mount:      arg[:i+1],
+		Connection: arg[i+1:],
+		Status:     databaseRotateStatusPending,
+	}, nil
+}
@@ -0,0 +1,339 @@
+package command
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testDatabaseRotateRootCommand(tb testing.TB) (*cli.MockUi, *DatabaseRotateRootCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &DatabaseRotateRootCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testDatabaseServer starts a fake Vault server with database secrets engines
+// in the root namespace and in the "team-a" namespace, whose "broken"
+// connection fails to rotate. It returns a client for the server and a
+// function returning the paths of the rotated connections.
+func testDatabaseServer(tb testing.TB) (*api.Client, func() []string) {
+	tb.Helper()
+
+	type namespace struct {
+		children    []interface{}
+		mounts      map[string]interface{}
+		connections map[string][]interface{}
+	}
+	namespaces := map[string]namespace{
+		"": {
+			children: []interface{}{"team-a/"},
+			mounts: map[string]interface{}{
+				"database/": map[string]interface{}{"type": "database"},
+				"secret/":   map[string]interface{}{"type": "kv"},
+			},
+			connections: map[string][]interface{}{
+				"database/": {"mysql", "postgres"},
+			},
+		},
+		"team-a/": {
+			mounts: map[string]interface{}{
+				"db/": map[string]interface{}{"type": "database"},
+			},
+			connections: map[string][]interface{}{
+				"db/": {"broken"},
+			},
+		},
+	}
+
+	var (
+		lock    sync.Mutex
+		rotated []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ns, ok := namespaces[r.Header.Get("X-Vault-Namespace")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+
+		switch {
+		case path == "sys/namespaces" && len(ns.children) > 0:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"keys": ns.children},
+			})
+		case path == "sys/mounts":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": ns.mounts})
+		case strings.HasSuffix(path, "/config") && ns.connections[strings.TrimSuffix(path, "config")] != nil:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"keys": ns.connections[strings.TrimSuffix(path, "config")]},
+			})
+		case strings.Contains(path, "/rotate-root/") && r.Method == http.MethodPut:
+			if strings.HasSuffix(path, "/broken") {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"connection refused"}})
+				return
+			}
+			lock.Lock()
+			rotated = append(rotated, r.Header.Get("X-Vault-Namespace")+path)
+			lock.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	tb.Cleanup(server.Close)
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return client, func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+
+		paths := append([]string(nil), rotated...)
+		sort.Strings(paths)
+		return paths
+	}
+}
+
+func TestDatabaseRotateRootCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"args_and_all",
+			[]string{"-all", "database/postgres"},
+			"cannot be given together with -all",
+			1,
+		},
+		{
+			"invalid_connection",
+			[]string{"postgres"},
+			"expected MOUNT/CONNECTION",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, _ := testDatabaseServer(t)
+
+				ui, cmd := testDatabaseRotateRootCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("connections", func(t *testing.T) {
+		t.Parallel()
+
+		client, rotated := testDatabaseServer(t)
+
+		ui, cmd := testDatabaseRotateRootCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"database/postgres", "/database/mysql/"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := []string{"database/rotate-root/mysql", "database/rotate-root/postgres"}
+		if paths := rotated(); strings.Join(paths, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected %q to be %q", paths, expected)
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, "Success! Rotated the root credentials of 2 connections") {
+			t.Errorf("expected %q to report the rotations", combined)
+		}
+	})
+
+	t.Run("all_dry_run", func(t *testing.T) {
+		t.Parallel()
+
+		client, rotated := testDatabaseServer(t)
+
+		ui, cmd := testDatabaseRotateRootCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-all", "-dry-run"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if paths := rotated(); len(paths) != 0 {
+			t.Errorf("expected no rotations, got %q", paths)
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		for _, expected := range []string{
+			"following 3 connections",
+			"  database/mysql\n",
+			"  database/postgres\n",
+			"  team-a/db/broken\n",
+			"No changes made (dry run)",
+		} {
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+	})
+
+	t.Run("all", func(t *testing.T) {
+		t.Parallel()
+
+		client, rotated := testDatabaseServer(t)
+
+		ui, cmd := testDatabaseRotateRootCommand(t)
+		cmd.client = client
+
+		// The failed rotation doesn't stop the others
+		code := cmd.Run([]string{"-all", "-force"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := []string{"database/rotate-root/mysql", "database/rotate-root/postgres"}
+		if paths := rotated(); strings.Join(paths, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected %q to be %q", paths, expected)
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		for _, expected := range []string{
+			"root database/ postgres rotated",
+			"team-a/ db/ broken failed",
+			"Error rotating the root credentials of team-a/db/broken: ",
+			"connection refused",
+			"Rotated the root credentials of 2 of 3 connections",
+		} {
+			if !strings.Contains(strings.Join(strings.Fields(combined), " "), expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := testDatabaseServer(t)
+
+		ui, cmd := testDatabaseRotateRootCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-all", "-force", "-format", "json"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		var targets []databaseRotateTarget
+		if err := json.Unmarshal(ui.OutputWriter.Bytes(), &targets); err != nil {
+			t.Fatal(err)
+		}
+		statuses := map[string]string{}
+		for _, target := range targets {
+			statuses[target.path()] = target.Status
+		}
+		expected := map[string]string{
+			"database/mysql":    "rotated",
+			"database/postgres": "rotated",
+			"team-a/db/broken":  "failed",
+		}
+		for path, status := range expected {
+			if statuses[path] != status {
+				t.Errorf("expected %s to be %q, got %q", path, status, statuses[path])
+			}
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		t.Parallel()
+
+		client, rotated := testDatabaseServer(t)
+
+		ui, cmd := testDatabaseRotateRootCommand(t)
+		cmd.client = client
+		cmd.testInteractive = true
+		ui.InputReader = strings.NewReader("n\n")
+
+		code := cmd.Run([]string{"-all"})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+		if paths := rotated(); len(paths) != 0 {
+			t.Errorf("expected no rotations, got %q", paths)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testDatabaseRotateRootCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"database/postgres"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		for _, expected := range []string{
+			"Error rotating the root credentials of database/postgres: ",
+			"Rotated the root credentials of 0 of 1 connections",
+		} {
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testDatabaseRotateRootCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -85,6 +85,7 @@ type DebugCommand struct {
 
 	flagCompress        bool
 	flagDuration        time.Duration
+	flagFlamegraph      bool
 	flagInterval        time.Duration
 	flagMetricsInterval time.Duration
 	flagOutput          string
@@ -145,6 +146,14 @@ func (c *DebugCommand) Flags() *FlagSets {
 		Usage:      "Duration to run the command.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "flamegraph",
+		Target:  &c.flagFlamegraph,
+		Default: false,
+		Usage: "Toggles whether to draw flame graphs of the CPU and heap profiles " +
+			"captured at each interval as SVG images in the output package.",
+	})
+
 	f.DurationVar(&DurationVar{
 		Name:       "interval",
 		Target:     &c.flagInterval,
@@ -208,6 +217,13 @@ Usage: vault debug [options]
 
   $ vault debug -target=host -target=metrics
 
+  The pprof profiles of each interval are compared with those of the previous
+  interval, listing the functions whose CPU, heap, allocation and goroutine
+  usage changed the most in a delta.txt file. To also draw flame graphs of the
+  CPU and heap profiles:
+
+  $ vault debug -target=pprof -flamegraph
+
 ` + c.Flags().Help()
 
 	return helpText
@@ -235,7 +251,7 @@ func (c *DebugCommand) Run(args []string) int {
 
 	dstOutputFile, err := c.preflight(args)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error during validation: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error during validation: %s", err), err)
 		return 1
 	}
 
@@ -258,7 +274,7 @@ func (c *DebugCommand) Run(args []string) int {
 	// Capture static information
 	c.UI.Info("==> Capturing static information...")
 	if err := c.captureStaticTargets(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error capturing static information: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error capturing static information: %s", err), err)
 		return 2
 	}
 
@@ -267,7 +283,7 @@ func (c *DebugCommand) Run(args []string) int {
 	// Capture polling information
 	c.UI.Info("==> Capturing dynamic information...")
 	if err := c.capturePollingTargets(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error capturing dynamic information: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error capturing dynamic information: %s", err), err)
 		return 2
 	}
 
@@ -275,13 +291,13 @@ func (c *DebugCommand) Run(args []string) int {
 
 	// Generate index file
 	if err := c.generateIndex(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error generating index: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error generating index: %s", err), err)
 		return 1
 	}
 
 	if c.flagCompress {
 		if err := c.compress(dstOutputFile); err != nil {
-			c.UI.Error(fmt.Sprintf("Error encountered during bundle compression: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error encountered during bundle compression: %s", err), err)
 			// We want to inform that data collection was captured and stored in
 			// a directory even if compression fails
 			c.UI.Info(fmt.Sprintf("Data written to: %s", c.flagOutput))
@@ -505,7 +521,7 @@ func (c *DebugCommand) captureStaticTargets() error {
 				},
 			}
 			if err := c.persistCollection(collection, "config.json"); err != nil {
-				c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "config.json", err))
+				outputError(c.UI, fmt.Sprintf("Error writing data to %s: %v", "config.json", err), err)
 			}
 		}
 	}
@@ -613,19 +629,19 @@ func (c *DebugCommand) capturePollingTargets() error {
 
 	// Write collected data to their corresponding files
 	if err := c.persistCollection(c.metricsCollection, "metrics.json"); err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "metrics.json", err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %v", "metrics.json", err), err)
 	}
 	if err := c.persistCollection(c.serverStatusCollection, "server_status.json"); err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "server_status.json", err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %v", "server_status.json", err), err)
 	}
 	if err := c.persistCollection(c.replicationStatusCollection, "replication_status.json"); err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "replication_status.json", err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %v", "replication_status.json", err), err)
 	}
 	if err := c.persistCollection(c.hostInfoCollection, "host_info.json"); err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "host_info.json", err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %v", "host_info.json", err), err)
 	}
 	if err := c.persistCollection(c.inFlightReqStatusCollection, "requests.json"); err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "requests.json", err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %v", "requests.json", err), err)
 	}
 	return nil
 }
@@ -725,6 +741,10 @@ func (c *DebugCommand) collectPprof(ctx context.Context) {
 	startTime := time.Now()
 	intervalTicker := time.Tick(c.flagInterval)
 
+	// previous holds the profiles of the previous interval, which those of
+	// the current interval are compared with
+	var previous *pprofInterval
+
 	for {
 		if idxCount > 0 {
 			select {
@@ -742,7 +762,7 @@ func (c *DebugCommand) collectPprof(ctx context.Context) {
 		currentDir := currentTimestamp.Format(fileFriendlyTimeFormat)
 		dirName := filepath.Join(c.flagOutput, currentDir)
 		if err := os.MkdirAll(dirName, 0o755); err != nil {
-			c.UI.Error(fmt.Sprintf("Error creating sub-directory for time interval: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error creating sub-directory for time interval: %s", err), err)
 			continue
 		}
 
@@ -787,6 +807,7 @@ func (c *DebugCommand) collectPprof(ctx context.Context) {
 		runDuration := currentTimestamp.Sub(startTime)
 		if (c.flagDuration+debugDurationGrace)-runDuration < c.flagInterval {
 			wg.Wait()
+			previous = c.analyzePprof(dirName, previous)
 			continue
 		}
 
@@ -823,6 +844,7 @@ func (c *DebugCommand) collectPprof(ctx context.Context) {
 		}()
 
 		wg.Wait()
+		previous = c.analyzePprof(dirName, previous)
 	}
 }
 
@@ -0,0 +1,556 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// pprofDeltaTop is the number of functions listed for each profile in the
+	// delta comparisons.
+	pprofDeltaTop = 20
+
+	// pprofDeltaFile is the name of the file holding the comparison of the
+	// profiles of an interval with those of the previous interval.
+	pprofDeltaFile = "delta.txt"
+
+	flamegraphWidth       = 1200
+	flamegraphFrameHeight = 16
+)
+
+// pprofDeltaTargets are the profiles compared between intervals, along with
+// the sample type compared and whether a flame graph is drawn for them.
+var pprofDeltaTargets = []struct {
+	name       string
+	sampleType string
+	flamegraph bool
+}{
+	{"profile", "cpu", true},
+	{"heap", "inuse_space", true},
+	{"allocs", "alloc_space", false},
+	{"goroutine", "goroutine", false},
+}
+
+// pprofInterval holds the profiles captured during an interval.
+type pprofInterval struct {
+	dir      string
+	profiles map[string]*pprofProfileData
+}
+
+// analyzePprof parses the profiles captured in dir, compares them with the
+// profiles of the previous interval, if any, and draws their flame graphs if
+// requested. It returns the profiles to compare with the next interval.
+func (c *DebugCommand) analyzePprof(dir string, previous *pprofInterval) *pprofInterval {
+	current := &pprofInterval{
+		dir:      dir,
+		profiles: make(map[string]*pprofProfileData),
+	}
+
+	for _, target := range pprofDeltaTargets {
+		data, err := ioutil.ReadFile(filepath.Join(dir, target.name+".prof"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil {
+			current.profiles[target.name], err = parsePprofProfile(data)
+		}
+		if err != nil {
+			c.captureError("pprof."+target.name, fmt.Errorf("error parsing profile: %w", err))
+			continue
+		}
+
+		if c.flagFlamegraph && target.flamegraph {
+			p := current.profiles[target.name]
+			svg := pprofFlamegraph(p, p.valueIndex(target.sampleType), fmt.Sprintf("%s (%s)", target.name, filepath.Base(dir)))
+			if err := ioutil.WriteFile(filepath.Join(dir, target.name+".svg"), svg, 0o644); err != nil {
+				c.captureError("pprof."+target.name, err)
+			}
+		}
+	}
+
+	if previous == nil {
+		return current
+	}
+
+	var sections []string
+	for _, target := range pprofDeltaTargets {
+		base, ok := previous.profiles[target.name]
+		if !ok {
+			continue
+		}
+		p, ok := current.profiles[target.name]
+		if !ok {
+			continue
+		}
+		sections = append(sections, pprofDelta(target.name, target.sampleType, filepath.Base(previous.dir), base, p))
+	}
+	if len(sections) == 0 {
+		return current
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, pprofDeltaFile), []byte(strings.Join(sections, "\n\n")+"\n"), 0o644); err != nil {
+		c.captureError("pprof.delta", err)
+	}
+	return current
+}
+
+// pprofProfileData is the part of a pprof profile used to compare profiles
+// and draw flame graphs: the functions of the stack of each sample along
+// with its values.
+type pprofProfileData struct {
+	sampleTypes []string
+	units       []string
+	samples     []pprofSample
+}
+
+type pprofSample struct {
+	// stack lists the functions of the sample, from the leaf to the root
+	stack  []string
+	values []int64
+}
+
+// valueIndex returns the index of the values of the given sample type, or of
+// the last sample type, which is the default one, if it is not found.
+func (p *pprofProfileData) valueIndex(sampleType string) int {
+	for i, t := range p.sampleTypes {
+		if t == sampleType {
+			return i
+		}
+	}
+	return len(p.sampleTypes) - 1
+}
+
+// unit returns the unit of the values at index i.
+func (p *pprofProfileData) unit(i int) string {
+	if i < 0 || i >= len(p.units) {
+		return ""
+	}
+	return p.units[i]
+}
+
+// parsePprofProfile decodes the samples of a profile in the protobuf format
+// served by the pprof endpoints, which may be gzipped.
+func parsePprofProfile(data []byte) (*pprofProfileData, error) {
+	if len(data) > 1 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = ioutil.ReadAll(gz); err != nil {
+			return nil, err
+		}
+	}
+
+	type location struct {
+		address   uint64
+		functions []uint64
+	}
+	type sample struct {
+		locations []uint64
+		values    []int64
+	}
+	var valueTypes [][2]uint64
+	var samples []sample
+	locations := make(map[uint64]location)
+	functions := make(map[uint64]uint64)
+	var strs []string
+
+	err := pprofFields(data, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case 1: // sample_type
+			var vt [2]uint64
+			err := pprofFields(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				if num == 1 || num == 2 {
+					n, err := pprofVarint(v)
+					vt[num-1] = n
+					return err
+				}
+				return nil
+			})
+			valueTypes = append(valueTypes, vt)
+			return err
+		case 2: // sample
+			var s sample
+			err := pprofFields(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				switch num {
+				case 1:
+					ids, err := pprofVarints(typ, v)
+					s.locations = append(s.locations, ids...)
+					return err
+				case 2:
+					values, err := pprofVarints(typ, v)
+					for _, value := range values {
+						s.values = append(s.values, int64(value))
+					}
+					return err
+				}
+				return nil
+			})
+			samples = append(samples, s)
+			return err
+		case 4: // location
+			var id uint64
+			var loc location
+			err := pprofFields(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				var err error
+				switch num {
+				case 1:
+					id, err = pprofVarint(v)
+				case 3:
+					loc.address, err = pprofVarint(v)
+				case 4:
+					// Lines are listed from the inlined callee to the caller
+					err = pprofFields(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+						if num != 1 {
+							return nil
+						}
+						functionID, err := pprofVarint(v)
+						loc.functions = append(loc.functions, functionID)
+						return err
+					})
+				}
+				return err
+			})
+			locations[id] = loc
+			return err
+		case 5: // function
+			var id, name uint64
+			err := pprofFields(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				var err error
+				switch num {
+				case 1:
+					id, err = pprofVarint(v)
+				case 2:
+					name, err = pprofVarint(v)
+				}
+				return err
+			})
+			functions[id] = name
+			return err
+		case 6: // string_table
+			strs = append(strs, string(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	str := func(i uint64) string {
+		if i < uint64(len(strs)) {
+			return strs[i]
+		}
+		return ""
+	}
+
+	p := &pprofProfileData{}
+	for _, vt := range valueTypes {
+		p.sampleTypes = append(p.sampleTypes, str(vt[0]))
+		p.units = append(p.units, str(vt[1]))
+	}
+	for _, s := range samples {
+		var stack []string
+		for _, id := range s.locations {
+			loc := locations[id]
+			if len(loc.functions) == 0 {
+				stack = append(stack, fmt.Sprintf("0x%x", loc.address))
+				continue
+			}
+			for _, functionID := range loc.functions {
+				stack = append(stack, str(functions[functionID]))
+			}
+		}
+		p.samples = append(p.samples, pprofSample{stack: stack, values: s.values})
+	}
+	return p, nil
+}
+
+// pprofFields calls f with the number, type and content of each field of a
+// protobuf message. The content of varints is their encoding.
+func pprofFields(b []byte, f func(protowire.Number, protowire.Type, []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var v []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n >= 0 {
+				v = b[:n]
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := f(num, typ, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pprofVarint(b []byte) (uint64, error) {
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return v, nil
+}
+
+// pprofVarints decodes a repeated varint field, which may be packed.
+func pprofVarints(typ protowire.Type, b []byte) ([]uint64, error) {
+	if typ != protowire.BytesType {
+		v, err := pprofVarint(b)
+		return []uint64{v}, err
+	}
+
+	var values []uint64
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		values = append(values, v)
+		b = b[n:]
+	}
+	return values, nil
+}
+
+// pprofFunctionValues returns the flat and cumulative values of each function
+// of the profile, and the total value.
+func pprofFunctionValues(p *pprofProfileData, index int) (map[string]int64, map[string]int64, int64) {
+	flat := make(map[string]int64)
+	cum := make(map[string]int64)
+	var total int64
+	for _, s := range p.samples {
+		if index < 0 || index >= len(s.values) || len(s.stack) == 0 {
+			continue
+		}
+		value := s.values[index]
+		total += value
+		flat[s.stack[0]] += value
+
+		// Recursive functions only count once in the cumulative values
+		seen := make(map[string]bool, len(s.stack))
+		for _, function := range s.stack {
+			if !seen[function] {
+				seen[function] = true
+				cum[function] += value
+			}
+		}
+	}
+	return flat, cum, total
+}
+
+// pprofDelta describes the changes between the base profile and the profile
+// p, listing the functions whose values changed the most.
+func pprofDelta(name, sampleType, baseName string, base, p *pprofProfileData) string {
+	index := p.valueIndex(sampleType)
+	unit := p.unit(index)
+	baseFlat, baseCum, baseTotal := pprofFunctionValues(base, base.valueIndex(sampleType))
+	flat, cum, total := pprofFunctionValues(p, index)
+
+	type row struct {
+		function            string
+		flat, cum           int64
+		flatDelta, cumDelta int64
+	}
+	var rows []row
+	for function := range cum {
+		rows = append(rows, row{
+			function:  function,
+			flat:      flat[function],
+			cum:       cum[function],
+			flatDelta: flat[function] - baseFlat[function],
+			cumDelta:  cum[function] - baseCum[function],
+		})
+	}
+	for function := range baseCum {
+		if _, ok := cum[function]; !ok {
+			rows = append(rows, row{
+				function:  function,
+				flatDelta: -baseFlat[function],
+				cumDelta:  -baseCum[function],
+			})
+		}
+	}
+
+	abs := func(v int64) int64 {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if abs(rows[i].flatDelta) != abs(rows[j].flatDelta) {
+			return abs(rows[i].flatDelta) > abs(rows[j].flatDelta)
+		}
+		if abs(rows[i].cumDelta) != abs(rows[j].cumDelta) {
+			return abs(rows[i].cumDelta) > abs(rows[j].cumDelta)
+		}
+		return rows[i].function < rows[j].function
+	})
+
+	out := []string{"Flat Delta | Flat | Cum Delta | Cum | Function"}
+	for i, r := range rows {
+		if i == pprofDeltaTop || (r.flatDelta == 0 && r.cumDelta == 0) {
+			break
+		}
+		out = append(out, fmt.Sprintf("%s | %s | %s | %s | %s",
+			pprofFormatValue(r.flatDelta, unit, true), pprofFormatValue(r.flat, unit, false),
+			pprofFormatValue(r.cumDelta, unit, true), pprofFormatValue(r.cum, unit, false),
+			r.function))
+	}
+
+	header := fmt.Sprintf("%s: %s compared to %s\nTotal: %s (%s)", name, p.sampleTypes[index], baseName,
+		pprofFormatValue(total, unit, false), pprofFormatValue(total-baseTotal, unit, true))
+	if len(out) == 1 {
+		return header + "\n\nNo change."
+	}
+	return header + "\n\n" + tableOutput(out, nil)
+}
+
+// pprofFormatValue formats a value of the given unit, with its sign if it is
+// a delta.
+func pprofFormatValue(v int64, unit string, delta bool) string {
+	sign := ""
+	if delta && v > 0 {
+		sign = "+"
+	}
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	switch unit {
+	case "bytes":
+		return sign + humanize.IBytes(uint64(v))
+	case "nanoseconds":
+		return sign + time.Duration(v).Round(time.Millisecond).String()
+	default:
+		return sign + humanize.Comma(v)
+	}
+}
+
+// flamegraphNode is a frame of a flame graph, with the total value of the
+// samples going through it.
+type flamegraphNode struct {
+	name     string
+	value    int64
+	children map[string]*flamegraphNode
+}
+
+func (n *flamegraphNode) sortedChildren() []*flamegraphNode {
+	children := make([]*flamegraphNode, 0, len(n.children))
+	for _, child := range n.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].name < children[j].name })
+	return children
+}
+
+// pprofFlamegraph draws the flame graph of the values at index of the
+// samples of p as an SVG image. The root of the stacks is at the bottom and
+// the width of each frame is proportional to its value.
+func pprofFlamegraph(p *pprofProfileData, index int, title string) []byte {
+	root := &flamegraphNode{name: "all", children: make(map[string]*flamegraphNode)}
+	depth := 0
+	for _, s := range p.samples {
+		if index < 0 || index >= len(s.values) || s.values[index] <= 0 {
+			continue
+		}
+		value := s.values[index]
+		root.value += value
+		node := root
+		for i := len(s.stack) - 1; i >= 0; i-- {
+			child, ok := node.children[s.stack[i]]
+			if !ok {
+				child = &flamegraphNode{name: s.stack[i], children: make(map[string]*flamegraphNode)}
+				node.children[s.stack[i]] = child
+			}
+			child.value += value
+			node = child
+		}
+		if len(s.stack) > depth {
+			depth = len(s.stack)
+		}
+	}
+
+	unit := p.unit(index)
+	titleHeight := 2 * flamegraphFrameHeight
+	height := titleHeight + (depth+1)*flamegraphFrameHeight
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="1.0" standalone="no"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg version="1.1" width="%d" height="%d" xmlns="http://www.w3.org/2000/svg" font-family="Verdana, sans-serif" font-size="12">`+"\n", flamegraphWidth, height)
+	fmt.Fprintf(&buf, `<rect x="0" y="0" width="%d" height="%d" fill="#ffffff"/>`+"\n", flamegraphWidth, height)
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle" font-size="16">%s</text>`+"\n", flamegraphWidth/2, flamegraphFrameHeight+4, html.EscapeString(title))
+
+	if root.value == 0 {
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle">No samples</text>`+"\n", flamegraphWidth/2, titleHeight+flamegraphFrameHeight)
+		buf.WriteString("</svg>\n")
+		return buf.Bytes()
+	}
+
+	scale := float64(flamegraphWidth) / float64(root.value)
+	var draw func(node *flamegraphNode, x float64, level int)
+	draw = func(node *flamegraphNode, x float64, level int) {
+		width := float64(node.value) * scale
+		if width < 0.5 {
+			return
+		}
+
+		y := height - (level+1)*flamegraphFrameHeight
+		fmt.Fprintf(&buf, `<g><title>%s (%s, %.2f%%)</title>`, html.EscapeString(node.name),
+			pprofFormatValue(node.value, unit, false), float64(node.value)*100/float64(root.value))
+		fmt.Fprintf(&buf, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s" rx="2" ry="2"/>`,
+			x, y, math.Max(width-0.5, 0.5), flamegraphFrameHeight-1, flamegraphColor(node.name))
+		if chars := int(width / 7); chars > 2 {
+			label := node.name
+			if len(label) > chars {
+				label = label[:chars-2] + ".."
+			}
+			fmt.Fprintf(&buf, `<text x="%.1f" y="%d">%s</text>`, x+3, y+flamegraphFrameHeight-4, html.EscapeString(label))
+		}
+		buf.WriteString("</g>\n")
+
+		for _, child := range node.sortedChildren() {
+			draw(child, x, level+1)
+			x += float64(child.value) * scale
+		}
+	}
+	draw(root, 0, 0)
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// flamegraphColor returns a warm color that is stable for a function name, so
+// that the same function has the same color in every flame graph.
+func flamegraphColor(name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	v := h.Sum32()
+	return fmt.Sprintf("rgb(%d,%d,%d)", 205+v%50, 80+(v>>8)%150, 40+(v>>16)%40)
+}
@@ -0,0 +1,234 @@
+package command
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// testPprofProfile encodes a heap profile in the pprof protobuf format. Each
+// stack lists the functions of a sample from the leaf to the root, and is
+// given the value of the same index.
+func testPprofProfile(tb testing.TB, stacks [][]string, values []int64) []byte {
+	tb.Helper()
+
+	strs := []string{"", "inuse_space", "bytes"}
+	str := func(s string) uint64 {
+		for i, existing := range strs {
+			if existing == s {
+				return uint64(i)
+			}
+		}
+		strs = append(strs, s)
+		return uint64(len(strs) - 1)
+	}
+	message := func(fields ...uint64) []byte {
+		var b []byte
+		for i := 0; i < len(fields); i += 2 {
+			b = protowire.AppendTag(b, protowire.Number(fields[i]), protowire.VarintType)
+			b = protowire.AppendVarint(b, fields[i+1])
+		}
+		return b
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, message(1, 1, 2, 2))
+
+	functions := make(map[string]uint64)
+	for i, stack := range stacks {
+		var ids []byte
+		for _, function := range stack {
+			if _, ok := functions[function]; !ok {
+				functions[function] = uint64(len(functions) + 1)
+			}
+			ids = protowire.AppendVarint(ids, functions[function])
+		}
+
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.BytesType)
+		sample = protowire.AppendBytes(sample, ids)
+		sample = append(sample, message(2, uint64(values[i]))...)
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, sample)
+	}
+
+	// Locations and functions share their IDs
+	for function, id := range functions {
+		line := message(1, id)
+		location := message(1, id, 3, 0x1000+id)
+		location = protowire.AppendTag(location, 4, protowire.BytesType)
+		location = protowire.AppendBytes(location, line)
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, location)
+
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendBytes(b, message(1, id, 2, str(function)))
+	}
+
+	for _, s := range strs {
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(b); err != nil {
+		tb.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParsePprofProfile(t *testing.T) {
+	t.Parallel()
+
+	data := testPprofProfile(t, [][]string{
+		{"alloc", "handle", "main"},
+		{"handle", "main"},
+	}, []int64{1024, 512})
+
+	p, err := parsePprofProfile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index := p.valueIndex("inuse_space"); index != 0 || p.unit(index) != "bytes" {
+		t.Fatalf("unexpected sample types: %v %v", p.sampleTypes, p.units)
+	}
+	if len(p.samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(p.samples))
+	}
+	if stack := strings.Join(p.samples[0].stack, ","); stack != "alloc,handle,main" {
+		t.Fatalf("unexpected stack %q", stack)
+	}
+
+	flat, cum, total := pprofFunctionValues(p, 0)
+	if total != 1536 || flat["handle"] != 512 || cum["handle"] != 1536 || flat["main"] != 0 {
+		t.Fatalf("unexpected values: flat %v, cum %v, total %d", flat, cum, total)
+	}
+
+	if _, err := parsePprofProfile([]byte{0x0a, 0xff}); err == nil {
+		t.Fatal("expected an error parsing a truncated profile")
+	}
+}
+
+func TestPprofDelta(t *testing.T) {
+	t.Parallel()
+
+	base, err := parsePprofProfile(testPprofProfile(t, [][]string{
+		{"alloc", "handle", "main"},
+		{"cache", "main"},
+	}, []int64{1024, 2048}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parsePprofProfile(testPprofProfile(t, [][]string{
+		{"alloc", "handle", "main"},
+		{"leak", "handle", "main"},
+	}, []int64{1024, 4096}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delta := pprofDelta("heap", "inuse_space", "2019-10-15T21-44-49Z", base, p)
+	lines := strings.Split(delta, "\n")
+	if lines[0] != "heap: inuse_space compared to 2019-10-15T21-44-49Z" || lines[1] != "Total: 5.0 KiB (+2.0 KiB)" {
+		t.Fatalf("unexpected header:\n%s", delta)
+	}
+
+	// The functions are sorted by how much their flat, then cumulative, value
+	// changed, and unchanged functions are left out
+	var functions []string
+	for _, line := range lines[5:] {
+		fields := strings.Fields(line)
+		functions = append(functions, fields[len(fields)-1])
+	}
+	if got := strings.Join(functions, ","); got != "leak,cache,handle,main" {
+		t.Fatalf("unexpected functions %q in:\n%s", got, delta)
+	}
+	if !strings.Contains(lines[5], "+4.0 KiB") || !strings.Contains(lines[6], "-2.0 KiB") {
+		t.Fatalf("unexpected deltas:\n%s", delta)
+	}
+
+	if delta := pprofDelta("heap", "inuse_space", "2019-10-15T21-44-49Z", p, p); !strings.HasSuffix(delta, "No change.") {
+		t.Fatalf("expected no change, got:\n%s", delta)
+	}
+}
+
+func TestPprofFlamegraph(t *testing.T) {
+	t.Parallel()
+
+	p, err := parsePprofProfile(testPprofProfile(t, [][]string{
+		{"alloc", "handle", "main"},
+		{"(*Store).<Put>", "main"},
+	}, []int64{1024, 3072}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svg := pprofFlamegraph(p, 0, "heap & more")
+	if err := xml.Unmarshal(svg, new(struct{})); err != nil {
+		t.Fatalf("expected a valid SVG document: %v\n%s", err, svg)
+	}
+	for _, expected := range []string{
+		"<title>main (4.0 KiB, 100.00%)</title>",
+		"<title>(*Store).&lt;Put&gt; (3.0 KiB, 75.00%)</title>",
+		"heap &amp; more",
+	} {
+		if !bytes.Contains(svg, []byte(expected)) {
+			t.Errorf("expected the flame graph to contain %q", expected)
+		}
+	}
+}
+
+func TestDebugCommand_analyzePprof(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "2019-10-15T21-44-49Z")
+	second := filepath.Join(dir, "2019-10-15T21-45-19Z")
+	for i, d := range []string{first, second} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		data := testPprofProfile(t, [][]string{{"alloc", "main"}}, []int64{int64(i+1) * 1024})
+		if err := ioutil.WriteFile(filepath.Join(d, "heap.prof"), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, cmd := testDebugCommand(t)
+	cmd.flagFlamegraph = true
+	cmd.debugIndex = &debugIndex{Errors: []*captureError{}}
+
+	previous := cmd.analyzePprof(first, nil)
+	if _, err := os.Stat(filepath.Join(first, pprofDeltaFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected no delta for the first interval, got %v", err)
+	}
+	cmd.analyzePprof(second, previous)
+
+	if len(cmd.debugIndex.Errors) != 0 {
+		t.Fatalf("unexpected errors: %v", cmd.debugIndex.Errors)
+	}
+	delta, err := ioutil.ReadFile(filepath.Join(second, pprofDeltaFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(delta), "Total: 2.0 KiB (+1.0 KiB)") {
+		t.Fatalf("unexpected delta:\n%s", delta)
+	}
+	for _, d := range []string{first, second} {
+		if _, err := os.Stat(filepath.Join(d, "heap.svg")); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
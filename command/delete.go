@@ -18,6 +18,8 @@ var (
 type DeleteCommand struct {
 	*BaseCommand
 
+	flagDryRun bool
+
 	testStdin io.Reader // for tests
 }
 
@@ -53,7 +55,20 @@ Usage: vault delete [options] PATH
 }
 
 func (c *DeleteCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:       "dry-run",
+		Target:     &c.flagDryRun,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Print the resolved path, parsed payload, and request headers " +
+			"without sending the request to Vault.",
+	})
+
+	return set
 }
 
 func (c *DeleteCommand) AutocompleteArgs() complete.Predictor {
@@ -95,13 +110,17 @@ func (c *DeleteCommand) Run(args []string) int {
 
 	data, err := parseArgsDataStringLists(stdin, args[1:])
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse string list data: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse string list data: %s", err), err)
 		return 1
 	}
 
+	if c.flagDryRun {
+		return outputDryRun(c.UI, client, "DELETE", path, data)
+	}
+
 	secret, err := client.Logical().DeleteWithData(path, data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error deleting %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error deleting %s: %s", path, err), err)
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
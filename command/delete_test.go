@@ -109,6 +109,43 @@ func TestDeleteCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("dry_run", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if _, err := client.Logical().Write("secret/delete/dry", map[string]interface{}{
+			"foo": "bar",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testDeleteCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-dry-run", "secret/delete/dry",
+		})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "no request was sent"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		secret, err := client.Logical().Read("secret/delete/dry")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret == nil {
+			t.Error("expected secret to still exist")
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/posener/complete"
+)
+
+// editFormats are the formats secrets can be edited in with -edit-format.
+var editFormats = []string{"json", "yaml"}
+
+// errEditUnchanged is returned by editData when the data was not changed in
+// the editor.
+var errEditUnchanged = errors.New("no changes made")
+
+// editorFunc opens the file in an editor and returns once it was closed.
+type editorFunc func(file string) error
+
+// addEditFormatFlag adds the -edit-format flag to the flags of a command
+// editing secrets.
+func addEditFormatFlag(f *FlagSet, target *string) {
+	f.StringVar(&StringVar{
+		Name:       "edit-format",
+		Target:     target,
+		Default:    "json",
+		Completion: complete.PredictSet(editFormats...),
+		Usage: "The format the data is edited in. Valid formats are \"json\" " +
+			"and \"yaml\".",
+	})
+}
+
+// runEditor opens file in the editor set with VISUAL or EDITOR, in the
+// terminal the command runs in. The editor may be given with arguments, e.g.
+// "code --wait".
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], file)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running editor %q: %w", editor, err)
+	}
+	return nil
+}
+
+// editData writes data to a temporary file in the given format, opens it with
+// editor and returns the data it contains once the editor was closed. The file
+// is only readable by the current user, and is removed before returning.
+// errEditUnchanged is returned if the file was not changed.
+func editData(data map[string]interface{}, format string, editor editorFunc) (map[string]interface{}, error) {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	var original []byte
+	var err error
+	switch format {
+	case "json":
+		original, err = json.MarshalIndent(data, "", "  ")
+		original = append(original, '\n')
+	case "yaml":
+		original, err = yaml.Marshal(data)
+	default:
+		return nil, fmt.Errorf("invalid edit format %q, must be one of %s", format, strings.Join(editFormats, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error encoding data: %w", err)
+	}
+
+	// TempFile creates the file with mode 0600
+	tmp, err := ioutil.TempFile("", "vault-edit-*."+format)
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(original); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("error writing temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("error writing temporary file: %w", err)
+	}
+
+	if err := editor(tmp.Name()); err != nil {
+		return nil, err
+	}
+
+	edited, err := ioutil.ReadFile(tmp.Name())
+	if err != nil {
+		return nil, fmt.Errorf("error reading temporary file: %w", err)
+	}
+	// Don't leave the secret behind in the file system if the removal fails
+	ioutil.WriteFile(tmp.Name(), nil, 0o600)
+
+	if len(bytes.TrimSpace(edited)) == 0 {
+		return nil, errors.New("the file is empty, nothing was written")
+	}
+	result, err := parseEditedData(edited, format)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing edited data: %w", err)
+	}
+
+	// Compare the decoded data, so that only reformatting the file is not a
+	// change
+	if before, err := parseEditedData(original, format); err == nil && reflect.DeepEqual(before, result) {
+		return nil, errEditUnchanged
+	}
+	return result, nil
+}
+
+// parseEditedData decodes the content of a file edited in the given format,
+// keeping numbers as json.Number.
+func parseEditedData(b []byte, format string) (map[string]interface{}, error) {
+	if format == "yaml" {
+		var err error
+		if b, err = yaml.YAMLToJSON(b); err != nil {
+			return nil, err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var result map[string]interface{}
+	if err := dec.Decode(&result); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	return result, nil
+}
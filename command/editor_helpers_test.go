@@ -0,0 +1,106 @@
+package command
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditData(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]interface{}{
+		"foo":   "bar",
+		"count": json.Number("3"),
+	}
+
+	cases := []struct {
+		name   string
+		format string
+		edited string
+		exp    map[string]interface{}
+		err    string
+	}{
+		{
+			"json",
+			"json",
+			`{"foo": "baz", "count": 4}`,
+			map[string]interface{}{"foo": "baz", "count": json.Number("4")},
+			"",
+		},
+		{
+			"yaml",
+			"yaml",
+			"foo: baz\ncount: 4\n",
+			map[string]interface{}{"foo": "baz", "count": json.Number("4")},
+			"",
+		},
+		{
+			"reformatted",
+			"json",
+			`{"count":3,"foo":"bar"}`,
+			nil,
+			errEditUnchanged.Error(),
+		},
+		{
+			"empty",
+			"json",
+			"\n",
+			nil,
+			"the file is empty",
+		},
+		{
+			"invalid",
+			"json",
+			`{"foo": `,
+			nil,
+			"error parsing edited data",
+		},
+		{
+			"unsupported_format",
+			"toml",
+			"",
+			nil,
+			"invalid edit format",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var file string
+			result, err := editData(data, tc.format, func(f string) error {
+				file = f
+				info, err := os.Stat(f)
+				if err != nil {
+					return err
+				}
+				if info.Mode().Perm() != 0o600 {
+					t.Errorf("expected the file to only be readable by the user, got %v", info.Mode())
+				}
+				return ioutil.WriteFile(f, []byte(tc.edited), 0o600)
+			})
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tc.exp) {
+				t.Errorf("expected %#v to be %#v", result, tc.exp)
+			}
+			if file != "" {
+				if _, err := os.Stat(file); !os.IsNotExist(err) {
+					t.Errorf("expected %s to be removed", file)
+				}
+			}
+		})
+	}
+}
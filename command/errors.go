@@ -0,0 +1,110 @@
+package command
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+// Stable error codes reported in structured CLI errors.
+const (
+	errCodeCLI                  = "cli_error"
+	errCodeInvalidRequest       = "invalid_request"
+	errCodePermissionDenied     = "permission_denied"
+	errCodeNotFound             = "not_found"
+	errCodeUnsupportedOperation = "unsupported_operation"
+	errCodePreconditionFailed   = "precondition_failed"
+	errCodeRateLimited          = "rate_limited"
+	errCodeInternal             = "internal_error"
+	errCodeUnavailable          = "unavailable"
+	errCodeRequestFailed        = "request_failed"
+)
+
+// cliError is the machine-readable representation of an error printed by the
+// CLI when the JSON output format is in use.
+type cliError struct {
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	StatusCode int      `json:"status_code,omitempty"`
+	Method     string   `json:"method,omitempty"`
+	Path       string   `json:"path,omitempty"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// newCLIError builds a cliError from an error message, and the error it
+// reports if any. The details of the API response error wrapped by err are
+// included.
+func newCLIError(msg string, err error) *cliError {
+	msg = strings.TrimSpace(msg)
+
+	var respErr *api.ResponseError
+	if !errors.As(err, &respErr) {
+		return &cliError{
+			Code:    errCodeCLI,
+			Message: msg,
+		}
+	}
+
+	e := &cliError{
+		Code:       errorCodeForStatus(respErr.StatusCode),
+		Message:    msg,
+		StatusCode: respErr.StatusCode,
+		Method:     respErr.HTTPMethod,
+		Namespace:  respErr.NamespacePath,
+		Errors:     respErr.Errors,
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(respErr.StatusCode)
+	}
+	if u, err := url.Parse(respErr.URL); err == nil {
+		e.Path = strings.TrimPrefix(u.Path, "/v1/")
+	}
+	return e
+}
+
+// errorReporter is implemented by the UIs which report the error behind an
+// error message, like VaultUI does in structured errors.
+type errorReporter interface {
+	ReportError(msg string, err error)
+}
+
+// outputError prints msg, the error message reporting err. The UIs printing
+// structured errors include the details of the API response error wrapped by
+// err.
+func outputError(ui cli.Ui, msg string, err error) {
+	if r, ok := ui.(errorReporter); ok {
+		r.ReportError(msg, err)
+		return
+	}
+	ui.Error(msg)
+}
+
+// errorCodeForStatus maps an HTTP status code returned by Vault to a stable
+// error code.
+func errorCodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return errCodeInvalidRequest
+	case http.StatusForbidden:
+		return errCodePermissionDenied
+	case http.StatusNotFound:
+		return errCodeNotFound
+	case http.StatusMethodNotAllowed:
+		return errCodeUnsupportedOperation
+	case http.StatusPreconditionFailed:
+		return errCodePreconditionFailed
+	case http.StatusTooManyRequests:
+		return errCodeRateLimited
+	case http.StatusInternalServerError:
+		return errCodeInternal
+	case http.StatusServiceUnavailable:
+		return errCodeUnavailable
+	default:
+		return errCodeRequestFailed
+	}
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestNewCLIError(t *testing.T) {
+	t.Parallel()
+
+	denied := &api.ResponseError{
+		HTTPMethod: "PUT",
+		URL:        "http://127.0.0.1:8200/v1/secret/foo",
+		StatusCode: 403,
+		Errors:     []string{"1 error occurred:\n\t* permission denied\n\n"},
+	}
+	sealed := &api.ResponseError{
+		HTTPMethod:    "GET",
+		URL:           "http://127.0.0.1:8200/v1/secret/foo",
+		StatusCode:    503,
+		RawError:      true,
+		Errors:        []string{"Vault is sealed"},
+		NamespacePath: "ns1/",
+	}
+
+	cases := []struct {
+		name     string
+		msg      string
+		err      error
+		expected *cliError
+	}{
+		{
+			"local",
+			"Not enough arguments (expected 1, got 0)",
+			nil,
+			&cliError{
+				Code:    errCodeCLI,
+				Message: "Not enough arguments (expected 1, got 0)",
+			},
+		},
+		{
+			"response",
+			"Error writing data to secret/foo: permission denied",
+			denied,
+			&cliError{
+				Code:       errCodePermissionDenied,
+				Message:    "Error writing data to secret/foo: permission denied",
+				StatusCode: 403,
+				Method:     "PUT",
+				Path:       "secret/foo",
+				Errors:     []string{"1 error occurred:\n\t* permission denied\n\n"},
+			},
+		},
+		{
+			"namespace",
+			"Error reading ns1/secret/foo: the secret could not be read",
+			fmt.Errorf("read failed: %w", sealed),
+			&cliError{
+				Code:       errCodeUnavailable,
+				Message:    "Error reading ns1/secret/foo: the secret could not be read",
+				StatusCode: 503,
+				Method:     "GET",
+				Path:       "secret/foo",
+				Namespace:  "ns1/",
+				Errors:     []string{"Vault is sealed"},
+			},
+		},
+		{
+			"no_message",
+			"",
+			denied,
+			&cliError{
+				Code:       errCodePermissionDenied,
+				Message:    "Forbidden",
+				StatusCode: 403,
+				Method:     "PUT",
+				Path:       "secret/foo",
+				Errors:     []string{"1 error occurred:\n\t* permission denied\n\n"},
+			},
+		},
+		{
+			"not_response",
+			"Error reading secret/foo: connection refused",
+			errors.New("connection refused"),
+			&cliError{
+				Code:    errCodeCLI,
+				Message: "Error reading secret/foo: connection refused",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := newCLIError(tc.msg, tc.err)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %#v to be %#v", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestOutputError(t *testing.T) {
+	t.Parallel()
+
+	denied := &api.ResponseError{
+		HTTPMethod: "PUT",
+		URL:        "http://127.0.0.1:8200/v1/secret/foo",
+		StatusCode: 403,
+		Errors:     []string{"permission denied"},
+	}
+	msg := fmt.Sprintf("Error writing data to secret/foo: %s", denied)
+
+	// The error is reported explicitly, whatever the message says
+	mockUI := cli.NewMockUi()
+	outputError(&VaultUI{Ui: mockUI, format: "json"}, "Error writing data", denied)
+	var actual cliError
+	if err := json.Unmarshal(mockUI.ErrorWriter.Bytes(), &actual); err != nil {
+		t.Fatal(err)
+	}
+	if actual.Code != errCodePermissionDenied || actual.StatusCode != 403 || actual.Message != "Error writing data" {
+		t.Errorf("unexpected structured error %#v", actual)
+	}
+
+	// Plain error messages carry no response details
+	mockUI = cli.NewMockUi()
+	(&VaultUI{Ui: mockUI, format: "json"}).Error(msg)
+	actual = cliError{}
+	if err := json.Unmarshal(mockUI.ErrorWriter.Bytes(), &actual); err != nil {
+		t.Fatal(err)
+	}
+	if actual.Code != errCodeCLI || actual.StatusCode != 0 {
+		t.Errorf("unexpected structured error %#v", actual)
+	}
+
+	// Other UIs print the message
+	mockUI = cli.NewMockUi()
+	outputError(mockUI, msg, denied)
+	if out := mockUI.ErrorWriter.String(); out != msg+"\n" {
+		t.Errorf("expected %q to be %q", out, msg+"\n")
+	}
+}
@@ -2,13 +2,17 @@ package command
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ghodss/yaml"
 	"github.com/hashicorp/vault/api"
@@ -65,11 +69,14 @@ type Formatter interface {
 }
 
 var Formatters = map[string]Formatter{
-	"json":   JsonFormatter{},
-	"table":  TableFormatter{},
-	"yaml":   YamlFormatter{},
-	"yml":    YamlFormatter{},
-	"pretty": PrettyFormatter{},
+	"json":     JsonFormatter{},
+	"table":    TableFormatter{},
+	"yaml":     YamlFormatter{},
+	"yml":      YamlFormatter{},
+	"pretty":   PrettyFormatter{},
+	"template": TemplateFormatter{},
+	"env":      EnvFormatter{},
+	"jsonl":    JsonLinesFormatter{},
 }
 
 func Format(ui cli.Ui) string {
@@ -102,6 +109,47 @@ func (j JsonFormatter) Output(ui cli.Ui, secret *api.Secret, data interface{}) e
 	return nil
 }
 
+// An output formatter for JSON Lines output, which prints each element of a
+// list, or any other object, as a single line of compact JSON
+type JsonLinesFormatter struct{}
+
+func (j JsonLinesFormatter) Format(data interface{}) ([]byte, error) {
+	var items []interface{}
+	switch data := data.(type) {
+	case []interface{}:
+		items = data
+	case []string:
+		items = make([]interface{}, len(data))
+		for i, v := range data {
+			items[i] = v
+		}
+	default:
+		items = []interface{}{data}
+	}
+
+	var buf bytes.Buffer
+	for _, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (j JsonLinesFormatter) Output(ui cli.Ui, secret *api.Secret, data interface{}) error {
+	b, err := j.Format(data)
+	if err != nil {
+		return err
+	}
+	if len(b) > 0 {
+		ui.Output(strings.TrimSuffix(string(b), "\n"))
+	}
+	return nil
+}
+
 // An output formatter for yaml output format of an object
 type YamlFormatter struct{}
 
@@ -117,6 +165,166 @@ func (y YamlFormatter) Output(ui cli.Ui, secret *api.Secret, data interface{}) e
 	return err
 }
 
+// FormatTemplate returns the Go template to use with the "template" output
+// format.
+func FormatTemplate(ui cli.Ui) string {
+	switch ui := ui.(type) {
+	case *VaultUI:
+		if ui.template != "" {
+			return ui.template
+		}
+	}
+
+	return os.Getenv(EnvVaultFormatTemplate)
+}
+
+// templateFuncs are the additional functions available to output templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"base64encode": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"base64decode": func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		return string(b), err
+	},
+	"join": strings.Join,
+}
+
+// An output formatter which renders the output through a Go template
+type TemplateFormatter struct {
+	Template string
+}
+
+func (t TemplateFormatter) Format(data interface{}) ([]byte, error) {
+	if t.Template == "" {
+		return nil, errors.New("a template must be provided with -template when using the template format")
+	}
+
+	raw, err := parseFlagFile(t.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("error executing template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (t TemplateFormatter) Output(ui cli.Ui, secret *api.Secret, data interface{}) error {
+	if t.Template == "" {
+		t.Template = FormatTemplate(ui)
+	}
+
+	b, err := t.Format(data)
+	if err != nil {
+		return err
+	}
+	ui.Output(strings.TrimSuffix(string(b), "\n"))
+	return nil
+}
+
+// reEnvInvalidChars matches the characters which are not allowed in shell
+// variable names.
+var reEnvInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// An output formatter which prints data as shell "export" statements
+type EnvFormatter struct {
+	Prefix    string
+	Uppercase bool
+}
+
+func (e EnvFormatter) Format(data interface{}) ([]byte, error) {
+	var values map[string]interface{}
+	switch data := data.(type) {
+	case *api.Secret:
+		if data == nil {
+			return nil, nil
+		}
+		values = data.Data
+
+		// Unwrap the data of KV version 2 secrets
+		if inner, ok := values["data"].(map[string]interface{}); ok {
+			if _, ok := values["metadata"]; ok {
+				values = inner
+			}
+		}
+	case map[string]interface{}:
+		values = data
+	default:
+		return nil, errors.New("cannot use the env formatter for this type")
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		var value string
+		switch v := values[k].(type) {
+		case string:
+			value = v
+		case nil:
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			value = string(b)
+		}
+
+		fmt.Fprintf(&buf, "export %s=%s\n", e.envName(k), shellQuote(value))
+	}
+	return buf.Bytes(), nil
+}
+
+func (e EnvFormatter) Output(ui cli.Ui, secret *api.Secret, data interface{}) error {
+	if vui, ok := ui.(*VaultUI); ok {
+		e.Prefix = vui.envPrefix
+		e.Uppercase = vui.envUppercase
+	}
+
+	b, err := e.Format(data)
+	if err != nil {
+		return err
+	}
+	if len(b) > 0 {
+		ui.Output(strings.TrimSuffix(string(b), "\n"))
+	}
+	return nil
+}
+
+// envName converts the given key into a valid shell variable name.
+func (e EnvFormatter) envName(k string) string {
+	name := reEnvInvalidChars.ReplaceAllString(e.Prefix+k, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	if e.Uppercase {
+		name = strings.ToUpper(name)
+	}
+	return name
+}
+
+// shellQuote quotes the given string so it is safe to use as a single
+// argument in a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 type PrettyFormatter struct{}
 
 func (p PrettyFormatter) Format(data interface{}) ([]byte, error) {
@@ -208,6 +416,169 @@ func (p PrettyFormatter) OutputAutopilotState(ui cli.Ui, data interface{}) {
 	ui.Output(buffer.String())
 }
 
+// TableOptions are the column selection and ordering requested for table
+// output.
+type TableOptions struct {
+	Columns []string
+	SortBy  string
+	Redact  bool
+}
+
+// tableOptions returns the table options configured on the given UI.
+func tableOptions(ui cli.Ui) TableOptions {
+	if vui, ok := ui.(*VaultUI); ok {
+		return TableOptions{
+			Columns: vui.tableColumns,
+			SortBy:  vui.tableSortBy,
+			Redact:  vui.redact,
+		}
+	}
+	return TableOptions{}
+}
+
+// unredactedUI returns ui with redaction disabled, for output which holds no
+// secret values, such as the metadata of a key.
+func unredactedUI(ui cli.Ui) cli.Ui {
+	if vui, ok := ui.(*VaultUI); ok && vui.redact {
+		u := *vui
+		u.redact = false
+		return &u
+	}
+	return ui
+}
+
+// redactValue masks a secret value in table output. Strings are replaced with
+// their length, and other values with a placeholder, except for booleans and
+// empty values which reveal nothing.
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool:
+		return v
+	case string:
+		if v == "" {
+			return v
+		}
+		return fmt.Sprintf("<redacted, %d characters>", utf8.RuneCountInString(v))
+	default:
+		return "<redacted>"
+	}
+}
+
+// OutputTable prints the list of items as a table, where the first row is
+// the list of headers, after applying any requested column selection and
+// sorting.
+func OutputTable(ui cli.Ui, list []string, c *columnize.Config) int {
+	delim := "|"
+	if c != nil && c.Delim != "" {
+		delim = c.Delim
+	}
+
+	list, err := applyTableOptions(list, delim, tableOptions(ui))
+	if err != nil {
+		ui.Error(err.Error())
+		return 1
+	}
+
+	ui.Output(tableOutput(list, c))
+	return 0
+}
+
+// applyTableOptions selects and sorts the columns of the given table rows,
+// where the first row is the list of headers. Column names are matched
+// case-insensitively.
+func applyTableOptions(list []string, delim string, opts TableOptions) ([]string, error) {
+	if len(list) == 0 || (len(opts.Columns) == 0 && opts.SortBy == "") {
+		return list, nil
+	}
+
+	rows := make([][]string, len(list))
+	for i, line := range list {
+		cells := strings.Split(line, delim)
+		for j := range cells {
+			cells[j] = strings.TrimSpace(cells[j])
+		}
+		rows[i] = cells
+	}
+
+	headers := rows[0]
+	columnIndex := func(name string) (int, error) {
+		for i, h := range headers {
+			if strings.EqualFold(h, strings.TrimSpace(name)) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown column %q; valid columns are: %s",
+			name, strings.Join(headers, ", "))
+	}
+
+	if opts.SortBy != "" {
+		idx, err := columnIndex(opts.SortBy)
+		if err != nil {
+			return nil, err
+		}
+		body := rows[1:]
+		sort.SliceStable(body, func(i, j int) bool {
+			return cell(body[i], idx) < cell(body[j], idx)
+		})
+	}
+
+	indexes := make([]int, 0, len(headers))
+	if len(opts.Columns) > 0 {
+		for _, name := range opts.Columns {
+			idx, err := columnIndex(name)
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, idx)
+		}
+	} else {
+		for i := range headers {
+			indexes = append(indexes, i)
+		}
+	}
+
+	result := make([]string, len(rows))
+	for i, row := range rows {
+		cells := make([]string, len(indexes))
+		for j, idx := range indexes {
+			cells[j] = cell(row, idx)
+		}
+		result[i] = strings.Join(cells, " "+delim+" ")
+	}
+	return result, nil
+}
+
+// cell returns the cell at the given index of the row, or the empty string if
+// the row is too short.
+func cell(row []string, idx int) string {
+	if idx < len(row) {
+		return row[idx]
+	}
+	return ""
+}
+
+// filterKeyValueRows keeps only the key/value rows whose key is one of the
+// given keys, in the order the keys were requested.
+func filterKeyValueRows(out []string, keys []string) []string {
+	if len(keys) == 0 {
+		return out
+	}
+
+	byKey := make(map[string]string, len(out))
+	for _, row := range out {
+		k := strings.TrimSpace(strings.SplitN(row, hopeDelim, 2)[0])
+		byKey[strings.ToLower(k)] = row
+	}
+
+	filtered := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if row, ok := byKey[strings.ToLower(strings.TrimSpace(k))]; ok {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
 // An output formatter for table output of an object
 type TableFormatter struct{}
 
@@ -370,6 +741,7 @@ func (t TableFormatter) OutputSecret(ui cli.Ui, secret *api.Secret) error {
 
 	t.printWarnings(ui, secret)
 
+	opts := tableOptions(ui)
 	out := make([]string, 0, 8)
 	if secret.LeaseDuration > 0 {
 		if secret.LeaseID != "" {
@@ -384,7 +756,11 @@ func (t TableFormatter) OutputSecret(ui cli.Ui, secret *api.Secret) error {
 	}
 
 	if secret.Auth != nil {
-		out = append(out, fmt.Sprintf("token %s %s", hopeDelim, secret.Auth.ClientToken))
+		token := interface{}(secret.Auth.ClientToken)
+		if opts.Redact {
+			token = redactValue(token)
+		}
+		out = append(out, fmt.Sprintf("token %s %v", hopeDelim, token))
 		out = append(out, fmt.Sprintf("token_accessor %s %s", hopeDelim, secret.Auth.Accessor))
 		// If the lease duration is 0, it's likely a root token, so output the
 		// duration as "infinity" to clear things up.
@@ -403,7 +779,11 @@ func (t TableFormatter) OutputSecret(ui cli.Ui, secret *api.Secret) error {
 	}
 
 	if secret.WrapInfo != nil {
-		out = append(out, fmt.Sprintf("wrapping_token: %s %s", hopeDelim, secret.WrapInfo.Token))
+		token := interface{}(secret.WrapInfo.Token)
+		if opts.Redact {
+			token = redactValue(token)
+		}
+		out = append(out, fmt.Sprintf("wrapping_token: %s %v", hopeDelim, token))
 		out = append(out, fmt.Sprintf("wrapping_accessor: %s %s", hopeDelim, secret.WrapInfo.Accessor))
 		out = append(out, fmt.Sprintf("wrapping_token_ttl: %s %v", hopeDelim, humanDurationInt(secret.WrapInfo.TTL)))
 		out = append(out, fmt.Sprintf("wrapping_token_creation_time: %s %s", hopeDelim, secret.WrapInfo.CreationTime.String()))
@@ -424,14 +804,19 @@ func (t TableFormatter) OutputSecret(ui cli.Ui, secret *api.Secret) error {
 			v := secret.Data[k]
 
 			// If the field "looks" like a TTL, print it as a time duration instead.
-			if looksLikeDuration(k) {
+			switch {
+			case looksLikeDuration(k):
 				v = humanDurationInt(v)
+			case opts.Redact:
+				v = redactValue(v)
 			}
 
 			out = append(out, fmt.Sprintf("%s %s %v", k, hopeDelim, v))
 		}
 	}
 
+	out = filterKeyValueRows(out, opts.Columns)
+
 	// If we got this far and still don't have any data, there's nothing to print,
 	// sorry.
 	if len(out) == 0 {
@@ -441,6 +826,11 @@ func (t TableFormatter) OutputSecret(ui cli.Ui, secret *api.Secret) error {
 	// Prepend the header
 	out = append([]string{"Key" + hopeDelim + "Value"}, out...)
 
+	out, err := applyTableOptions(out, hopeDelim, TableOptions{SortBy: opts.SortBy})
+	if err != nil {
+		return err
+	}
+
 	ui.Output(tableOutput(out, &columnize.Config{
 		Delim: hopeDelim,
 	}))
@@ -448,6 +838,7 @@ func (t TableFormatter) OutputSecret(ui cli.Ui, secret *api.Secret) error {
 }
 
 func (t TableFormatter) OutputMap(ui cli.Ui, data map[string]interface{}) error {
+	opts := tableOptions(ui)
 	out := make([]string, 0, len(data)+1)
 	if len(data) > 0 {
 		keys := make([]string, 0, len(data))
@@ -460,14 +851,19 @@ func (t TableFormatter) OutputMap(ui cli.Ui, data map[string]interface{}) error
 			v := data[k]
 
 			// If the field "looks" like a TTL, print it as a time duration instead.
-			if looksLikeDuration(k) {
+			switch {
+			case looksLikeDuration(k):
 				v = humanDurationInt(v)
+			case opts.Redact:
+				v = redactValue(v)
 			}
 
 			out = append(out, fmt.Sprintf("%s %s %v", k, hopeDelim, v))
 		}
 	}
 
+	out = filterKeyValueRows(out, opts.Columns)
+
 	// If we got this far and still don't have any data, there's nothing to print,
 	// sorry.
 	if len(out) == 0 {
@@ -477,6 +873,11 @@ func (t TableFormatter) OutputMap(ui cli.Ui, data map[string]interface{}) error
 	// Prepend the header
 	out = append([]string{"Key" + hopeDelim + "Value"}, out...)
 
+	out, err := applyTableOptions(out, hopeDelim, TableOptions{SortBy: opts.SortBy})
+	if err != nil {
+		return err
+	}
+
 	ui.Output(tableOutput(out, &columnize.Config{
 		Delim: hopeDelim,
 	}))
@@ -2,8 +2,10 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -69,6 +71,168 @@ func TestYamlFormatter(t *testing.T) {
 	}
 }
 
+func TestYamlFormatter_SecretAndList(t *testing.T) {
+	os.Setenv(EnvVaultFormat, "yaml")
+	ui := mockUi{t: t}
+
+	s := &api.Secret{Data: map[string]interface{}{"k": "something"}}
+	if err := OutputSecret(ui, s); err != 0 {
+		t.Fatal(err)
+	}
+	var secret api.Secret
+	if err := yaml.Unmarshal([]byte(output), &secret); err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["k"] != "something" {
+		t.Fatalf("expected secret data to round-trip, got %#v", secret.Data)
+	}
+
+	l := &api.Secret{Data: map[string]interface{}{"keys": []interface{}{"a", "b/"}}}
+	if err := OutputList(ui, l); err != 0 {
+		t.Fatal(err)
+	}
+	var keys []string
+	if err := yaml.Unmarshal([]byte(output), &keys); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b/" {
+		t.Fatalf("expected list keys to round-trip, got %#v", keys)
+	}
+}
+
+func TestTemplateFormatter(t *testing.T) {
+	defer os.Setenv(EnvVaultFormatTemplate, "")
+	os.Setenv(EnvVaultFormat, "template")
+	os.Setenv(EnvVaultFormatTemplate, `{{ .Data.user }}:{{ .Data.pass | base64encode }}`)
+	ui := mockUi{t: t}
+
+	s := &api.Secret{Data: map[string]interface{}{"user": "foo", "pass": "bar"}}
+	if err := OutputSecret(ui, s); err != 0 {
+		t.Fatal(err)
+	}
+	if exp := "foo:YmFy"; output != exp {
+		t.Fatalf("expected %q to be %q", output, exp)
+	}
+
+	os.Setenv(EnvVaultFormatTemplate, "")
+	if err := OutputSecret(ui, s); err == 0 {
+		t.Fatal("expected an error without a template")
+	}
+}
+
+func TestEnvFormatter(t *testing.T) {
+	f := EnvFormatter{Prefix: "app-", Uppercase: true}
+
+	s := &api.Secret{Data: map[string]interface{}{
+		"data": map[string]interface{}{
+			"password": "it's a secret",
+			"port":     json.Number("5432"),
+			"hosts":    []interface{}{"a", "b"},
+		},
+		"metadata": map[string]interface{}{},
+	}}
+
+	b, err := f.Format(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `export APP_HOSTS='["a","b"]'
+export APP_PASSWORD='it'\''s a secret'
+export APP_PORT='5432'
+`
+	if string(b) != expected {
+		t.Fatalf("expected %q to be %q", string(b), expected)
+	}
+
+	if _, err := f.Format([]string{"foo"}); err == nil {
+		t.Fatal("expected an error formatting a list")
+	}
+}
+
+func TestJsonLinesFormatter(t *testing.T) {
+	os.Setenv(EnvVaultFormat, "jsonl")
+	ui := mockUi{t: t}
+
+	l := &api.Secret{Data: map[string]interface{}{"keys": []interface{}{"a", "b/"}}}
+	if err := OutputList(ui, l); err != 0 {
+		t.Fatal(err)
+	}
+	if exp := "\"a\"\n\"b/\""; output != exp {
+		t.Fatalf("expected %q to be %q", output, exp)
+	}
+
+	if err := OutputData(ui, map[string]interface{}{"foo": "bar"}); err != 0 {
+		t.Fatal(err)
+	}
+	if exp := `{"foo":"bar"}`; output != exp {
+		t.Fatalf("expected %q to be %q", output, exp)
+	}
+}
+
+func TestApplyTableOptions(t *testing.T) {
+	list := []string{
+		"Path | Type | Description",
+		"secret/ | kv | key/value secret storage",
+		"cubbyhole/ | cubbyhole | per-token private secret storage",
+		"identity/ | identity | identity store",
+	}
+
+	cases := []struct {
+		name     string
+		opts     TableOptions
+		expected []string
+		err      bool
+	}{
+		{
+			"none",
+			TableOptions{},
+			list,
+			false,
+		},
+		{
+			"columns",
+			TableOptions{Columns: []string{"type", "Path"}},
+			[]string{
+				"Type | Path",
+				"kv | secret/",
+				"cubbyhole | cubbyhole/",
+				"identity | identity/",
+			},
+			false,
+		},
+		{
+			"sort_by",
+			TableOptions{Columns: []string{"Path"}, SortBy: "path"},
+			[]string{
+				"Path",
+				"cubbyhole/",
+				"identity/",
+				"secret/",
+			},
+			false,
+		},
+		{
+			"unknown_column",
+			TableOptions{Columns: []string{"Accessor"}},
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := applyTableOptions(list, "|", tc.opts)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %t, got %v", tc.err, err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %q to be %q", actual, tc.expected)
+			}
+		})
+	}
+}
+
 func TestTableFormatter(t *testing.T) {
 	os.Setenv(EnvVaultFormat, "table")
 	ui := mockUi{t: t}
@@ -83,6 +247,45 @@ func TestTableFormatter(t *testing.T) {
 	}
 }
 
+func TestTableFormatter_redact(t *testing.T) {
+	ui := &VaultUI{Ui: mockUi{t: t}, format: "table", redact: true}
+
+	s := &api.Secret{
+		Data: map[string]interface{}{
+			"password": "hunter2",
+			"empty":    "",
+			"enabled":  true,
+			"nested":   map[string]interface{}{"k": "v"},
+			"ttl":      json.Number("60"),
+		},
+		Auth: &api.SecretAuth{ClientToken: "s.abcd", Accessor: "accessor"},
+	}
+	if err := OutputSecret(ui, s); err != 0 {
+		t.Fatal(err)
+	}
+	for _, exp := range []string{"<redacted, 7 characters>", "<redacted, 6 characters>", "<redacted>", "true", "1m", "accessor"} {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected %q to contain %q", output, exp)
+		}
+	}
+	for _, secret := range []string{"hunter2", "s.abcd", "map["} {
+		if strings.Contains(output, secret) {
+			t.Errorf("expected %q to be redacted in %q", secret, output)
+		}
+	}
+
+	// Output without secrets is shown as is
+	if err := OutputData(unredactedUI(ui), s.Data); err != 0 {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "hunter2") {
+		t.Errorf("expected %q to contain the value", output)
+	}
+	if !ui.redact {
+		t.Error("expected the original UI to be unchanged")
+	}
+}
+
 // TestStatusFormat tests to verify that the embedded struct
 // SealStatusOutput ignores omitEmpty fields and prints out
 // fields in the embedded struct explicitly. It also checks the spacing,
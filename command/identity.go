@@ -0,0 +1,43 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*IdentityCommand)(nil)
+
+type IdentityCommand struct {
+	*BaseCommand
+}
+
+func (c *IdentityCommand) Synopsis() string {
+	return "Interact with identity entities and groups"
+}
+
+func (c *IdentityCommand) Help() string {
+	helpText := `
+Usage: vault identity <subcommand> [options] [args]
+
+  This command groups subcommands for managing the entities, aliases and
+  groups of Vault's identity store in bulk. Single objects are managed by
+  reading and writing the paths of the identity secrets engine.
+
+  Import the entities and groups described in a file:
+
+      $ vault identity import identities.json
+
+  Show how groups are nested, and which policies flow from where:
+
+      $ vault identity group tree
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *IdentityCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*IdentityGroupCommand)(nil)
+
+type IdentityGroupCommand struct {
+	*BaseCommand
+}
+
+func (c *IdentityGroupCommand) Synopsis() string {
+	return "Interact with identity groups"
+}
+
+func (c *IdentityGroupCommand) Help() string {
+	helpText := `
+Usage: vault identity group <subcommand> [options] [args]
+
+  This command groups subcommands for inspecting identity groups.
+
+  Show the groups nested in the group "engineering", and the policies they
+  inherit:
+
+      $ vault identity group tree engineering
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *IdentityGroupCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
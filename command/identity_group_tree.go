@@ -0,0 +1,487 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*IdentityGroupTreeCommand)(nil)
+	_ cli.CommandAutocomplete = (*IdentityGroupTreeCommand)(nil)
+)
+
+type IdentityGroupTreeCommand struct {
+	*BaseCommand
+
+	flagDot      bool
+	flagEntities bool
+}
+
+func (c *IdentityGroupTreeCommand) Synopsis() string {
+	return "Shows how identity groups are nested"
+}
+
+func (c *IdentityGroupTreeCommand) Help() string {
+	helpText := `
+Usage: vault identity group tree [options] [NAME]
+
+  Shows the identity groups and their member groups and entities as a tree,
+  together with the policies each of them is given. Members of a group also
+  get the policies of the group and of all groups above it; these inherited
+  policies are shown with the group they come from.
+
+  If NAME is given, only the group of that name and its members are shown.
+  Otherwise all groups which are not themselves members of a group are shown.
+
+  Show all groups:
+
+      $ vault identity group tree
+
+  Show the group "engineering" as a graph, rendered by Graphviz:
+
+      $ vault identity group tree -dot engineering | dot -Tsvg > groups.svg
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *IdentityGroupTreeCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "dot",
+		Target:  &c.flagDot,
+		Default: false,
+		Usage:   "Print the tree as a graph in the DOT language of Graphviz.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "entities",
+		Target:  &c.flagEntities,
+		Default: true,
+		Usage: "Show the entities which are members of the groups. Reading " +
+			"them takes a request per entity.",
+	})
+
+	return set
+}
+
+func (c *IdentityGroupTreeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *IdentityGroupTreeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *IdentityGroupTreeCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 1 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0 or 1, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	groups, err := readIdentityGroupTreeGroups(client)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading groups: %s", err), err)
+		return 2
+	}
+	if len(groups) == 0 {
+		c.UI.Error("No groups found")
+		return 2
+	}
+
+	b := &identityGroupTreeBuilder{
+		client:      client,
+		groups:      groups,
+		entities:    c.flagEntities,
+		entityCache: map[string]*identityGroupTreeEntity{},
+	}
+
+	var roots []*identityGroupTreeNode
+	if len(args) == 1 {
+		var root *identityGroupTreeGroup
+		for _, group := range groups {
+			if group.Name == args[0] {
+				root = group
+			}
+		}
+		if root == nil {
+			c.UI.Error(fmt.Sprintf("No group named %q", args[0]))
+			return 2
+		}
+		node, err := b.node(root, b.ancestorPolicies(root), nil)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading members: %s", err), err)
+			return 2
+		}
+		roots = append(roots, node)
+	} else {
+		for _, group := range sortedIdentityGroupTreeGroups(groups) {
+			if len(group.ParentGroupIDs) > 0 {
+				continue
+			}
+			node, err := b.node(group, nil, nil)
+			if err != nil {
+				outputError(c.UI, fmt.Sprintf("Error reading members: %s", err), err)
+				return 2
+			}
+			roots = append(roots, node)
+		}
+	}
+
+	switch {
+	case c.flagDot:
+		c.UI.Output(identityGroupTreeDot(roots))
+		return 0
+	case Format(c.UI) == "table":
+		var lines []string
+		for _, root := range roots {
+			lines = append(lines, root.label())
+			lines = append(lines, root.treeLines("")...)
+		}
+		c.UI.Output(strings.Join(lines, "\n"))
+		return 0
+	default:
+		return OutputData(c.UI, roots)
+	}
+}
+
+type identityGroupTreeGroup struct {
+	ID              string
+	Name            string
+	Type            string
+	Policies        []string
+	Alias           string
+	MemberGroupIDs  []string
+	MemberEntityIDs []string
+	ParentGroupIDs  []string
+}
+
+type identityGroupTreeEntity struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Policies []string `json:"policies"`
+}
+
+// identityGroupTreePolicy is a policy a group or entity gets from a group it
+// is a member of, directly or through other groups.
+type identityGroupTreePolicy struct {
+	Policy string `json:"policy"`
+	From   string `json:"from"`
+}
+
+type identityGroupTreeNode struct {
+	ID                string                    `json:"id"`
+	Name              string                    `json:"name"`
+	Kind              string                    `json:"kind"`
+	Type              string                    `json:"type,omitempty"`
+	Alias             string                    `json:"alias,omitempty"`
+	Policies          []string                  `json:"policies"`
+	InheritedPolicies []identityGroupTreePolicy `json:"inherited_policies"`
+	Members           []*identityGroupTreeNode  `json:"members,omitempty"`
+
+	// Cycle is set if the group is a member of itself through the groups
+	// above it, in which case its members are not shown again.
+	Cycle bool `json:"cycle,omitempty"`
+}
+
+func (n *identityGroupTreeNode) label() string {
+	var b strings.Builder
+	b.WriteString(n.Name)
+
+	switch n.Kind {
+	case "entity":
+		b.WriteString(" (entity)")
+	default:
+		b.WriteString(" (" + n.Type + " group")
+		if n.Alias != "" {
+			b.WriteString(", alias " + n.Alias)
+		}
+		b.WriteString(")")
+	}
+
+	var parts []string
+	if len(n.Policies) > 0 {
+		parts = append(parts, "policies: "+strings.Join(n.Policies, ", "))
+	}
+	if len(n.InheritedPolicies) > 0 {
+		inherited := make([]string, 0, len(n.InheritedPolicies))
+		for _, p := range n.InheritedPolicies {
+			inherited = append(inherited, fmt.Sprintf("%s (from %s)", p.Policy, p.From))
+		}
+		parts = append(parts, "inherited: "+strings.Join(inherited, ", "))
+	}
+	if len(parts) > 0 {
+		b.WriteString(" " + strings.Join(parts, "; "))
+	}
+	if n.Cycle {
+		b.WriteString(" [cycle]")
+	}
+	return b.String()
+}
+
+// treeLines returns the lines of the members of n, each prefixed with indent
+// and the glyphs connecting it to n.
+func (n *identityGroupTreeNode) treeLines(indent string) []string {
+	var lines []string
+	for i, member := range n.Members {
+		branch, next := "├── ", "│   "
+		if i == len(n.Members)-1 {
+			branch, next = "└── ", "    "
+		}
+		lines = append(lines, indent+branch+member.label())
+		lines = append(lines, member.treeLines(indent+next)...)
+	}
+	return lines
+}
+
+type identityGroupTreeBuilder struct {
+	client      *api.Client
+	groups      map[string]*identityGroupTreeGroup
+	entities    bool
+	entityCache map[string]*identityGroupTreeEntity
+}
+
+// node returns the tree below group, which gets inherited from the groups
+// above it. path holds the IDs of the groups above it, to detect cycles.
+func (b *identityGroupTreeBuilder) node(group *identityGroupTreeGroup, inherited []identityGroupTreePolicy, path []string) (*identityGroupTreeNode, error) {
+	node := &identityGroupTreeNode{
+		ID:                group.ID,
+		Name:              group.Name,
+		Kind:              "group",
+		Type:              group.Type,
+		Alias:             group.Alias,
+		Policies:          group.Policies,
+		InheritedPolicies: inherited,
+	}
+	if strutil.StrListContains(path, group.ID) {
+		node.Cycle = true
+		return node, nil
+	}
+	path = append(path[:len(path):len(path)], group.ID)
+
+	memberInherited := inherited[:len(inherited):len(inherited)]
+	for _, policy := range group.Policies {
+		memberInherited = appendIdentityGroupTreePolicy(memberInherited, identityGroupTreePolicy{Policy: policy, From: group.Name})
+	}
+
+	var members []*identityGroupTreeGroup
+	for _, id := range group.MemberGroupIDs {
+		if member, ok := b.groups[id]; ok {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	for _, member := range members {
+		child, err := b.node(member, memberInherited, path)
+		if err != nil {
+			return nil, err
+		}
+		node.Members = append(node.Members, child)
+	}
+
+	if !b.entities {
+		return node, nil
+	}
+
+	var entities []*identityGroupTreeEntity
+	for _, id := range group.MemberEntityIDs {
+		entity, err := b.entity(id)
+		if err != nil {
+			return nil, err
+		}
+		if entity != nil {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].Name < entities[j].Name })
+	for _, entity := range entities {
+		node.Members = append(node.Members, &identityGroupTreeNode{
+			ID:                entity.ID,
+			Name:              entity.Name,
+			Kind:              "entity",
+			Policies:          entity.Policies,
+			InheritedPolicies: memberInherited,
+		})
+	}
+
+	return node, nil
+}
+
+// ancestorPolicies returns the policies group inherits from the groups it is
+// a member of, directly or through other groups.
+func (b *identityGroupTreeBuilder) ancestorPolicies(group *identityGroupTreeGroup) []identityGroupTreePolicy {
+	var inherited []identityGroupTreePolicy
+	seen := map[string]bool{group.ID: true}
+	queue := append([]string(nil), group.ParentGroupIDs...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		parent, ok := b.groups[id]
+		if !ok {
+			continue
+		}
+		for _, policy := range parent.Policies {
+			inherited = appendIdentityGroupTreePolicy(inherited, identityGroupTreePolicy{Policy: policy, From: parent.Name})
+		}
+		queue = append(queue, parent.ParentGroupIDs...)
+	}
+	return inherited
+}
+
+func (b *identityGroupTreeBuilder) entity(id string) (*identityGroupTreeEntity, error) {
+	if entity, ok := b.entityCache[id]; ok {
+		return entity, nil
+	}
+
+	secret, err := b.client.Logical().Read("identity/entity/id/" + id)
+	if err != nil {
+		return nil, fmt.Errorf("error reading entity %s: %w", id, err)
+	}
+	var entity *identityGroupTreeEntity
+	if secret != nil && secret.Data != nil {
+		entity = &identityGroupTreeEntity{
+			ID:       id,
+			Name:     identityImportString(secret.Data["name"]),
+			Policies: identityGroupTreeStrings(secret.Data["policies"]),
+		}
+	}
+	b.entityCache[id] = entity
+	return entity, nil
+}
+
+func readIdentityGroupTreeGroups(client *api.Client) (map[string]*identityGroupTreeGroup, error) {
+	list, err := client.Logical().List("identity/group/id")
+	if err != nil {
+		return nil, err
+	}
+	groups := map[string]*identityGroupTreeGroup{}
+	if list == nil || list.Data == nil {
+		return groups, nil
+	}
+
+	for _, id := range identityGroupTreeStrings(list.Data["keys"]) {
+		secret, err := client.Logical().Read("identity/group/id/" + id)
+		if err != nil {
+			return nil, fmt.Errorf("error reading group %s: %w", id, err)
+		}
+		if secret == nil || secret.Data == nil {
+			continue
+		}
+
+		group := &identityGroupTreeGroup{
+			ID:              id,
+			Name:            identityImportString(secret.Data["name"]),
+			Type:            identityImportString(secret.Data["type"]),
+			Policies:        identityGroupTreeStrings(secret.Data["policies"]),
+			MemberGroupIDs:  identityGroupTreeStrings(secret.Data["member_group_ids"]),
+			MemberEntityIDs: identityGroupTreeStrings(secret.Data["member_entity_ids"]),
+			ParentGroupIDs:  identityGroupTreeStrings(secret.Data["parent_group_ids"]),
+		}
+		if alias, ok := secret.Data["alias"].(map[string]interface{}); ok && alias["name"] != nil {
+			group.Alias = fmt.Sprintf("%q on %s", identityImportString(alias["name"]), identityImportString(alias["mount_path"]))
+		}
+		groups[id] = group
+	}
+	return groups, nil
+}
+
+func sortedIdentityGroupTreeGroups(groups map[string]*identityGroupTreeGroup) []*identityGroupTreeGroup {
+	sorted := make([]*identityGroupTreeGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+func appendIdentityGroupTreePolicy(policies []identityGroupTreePolicy, policy identityGroupTreePolicy) []identityGroupTreePolicy {
+	for _, p := range policies {
+		if p == policy {
+			return policies
+		}
+	}
+	return append(policies, policy)
+}
+
+// identityGroupTreeStrings returns the strings in raw if it is a list from an
+// API response.
+func identityGroupTreeStrings(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	strs := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// identityGroupTreeDot returns the trees below roots as a directed graph in
+// the DOT language, with an edge from each group to each of its members.
+func identityGroupTreeDot(roots []*identityGroupTreeNode) string {
+	lines := []string{"digraph \"identity groups\" {"}
+	nodes := map[string]bool{}
+	edges := map[string]bool{}
+
+	var walk func(n *identityGroupTreeNode)
+	walk = func(n *identityGroupTreeNode) {
+		id := n.Kind + ":" + n.ID
+		if !nodes[id] {
+			nodes[id] = true
+
+			label := n.Name
+			shape := "ellipse"
+			if n.Kind == "group" {
+				label += "\n(" + n.Type + " group)"
+				shape = "box"
+			}
+			if len(n.Policies) > 0 {
+				label += "\n" + strings.Join(n.Policies, ", ")
+			}
+			lines = append(lines, fmt.Sprintf("  %q [label=%q, shape=%s];", id, label, shape))
+		}
+
+		for _, member := range n.Members {
+			edge := fmt.Sprintf("  %q -> %q;", id, member.Kind+":"+member.ID)
+			if !edges[edge] {
+				edges[edge] = true
+				lines = append(lines, edge)
+			}
+			walk(member)
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
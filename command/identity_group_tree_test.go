@@ -0,0 +1,134 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testIdentityGroupTreeCommand(tb testing.TB) (*cli.MockUi, *IdentityGroupTreeCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &IdentityGroupTreeCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestIdentityGroupTreeCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("too_many_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testIdentityGroupTreeCommand(t)
+
+		code := cmd.Run([]string{"foo", "bar"})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Too many arguments"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		write := func(path string, data map[string]interface{}) string {
+			t.Helper()
+
+			secret, err := client.Logical().Write(path, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return secret.Data["id"].(string)
+		}
+
+		alice := write("identity/entity", map[string]interface{}{
+			"name":     "alice",
+			"policies": []string{"dev"},
+		})
+		web := write("identity/group", map[string]interface{}{
+			"name":              "web",
+			"policies":          []string{"web"},
+			"member_entity_ids": []string{alice},
+		})
+		write("identity/group", map[string]interface{}{
+			"name":             "all",
+			"policies":         []string{"base"},
+			"member_group_ids": []string{web},
+		})
+
+		run := func(args ...string) string {
+			t.Helper()
+
+			ui, cmd := testIdentityGroupTreeCommand(t)
+			cmd.client = client
+
+			code := cmd.Run(args)
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+			return ui.OutputWriter.String()
+		}
+
+		output := run()
+		expected := `all (internal group) policies: base
+└── web (internal group) policies: web; inherited: base (from all)
+    └── alice (entity) policies: dev; inherited: base (from all), web (from web)
+`
+		if output != expected {
+			t.Errorf("expected %q to be %q", output, expected)
+		}
+
+		output = run("web")
+		expected = `web (internal group) policies: web; inherited: base (from all)
+└── alice (entity) policies: dev; inherited: base (from all), web (from web)
+`
+		if output != expected {
+			t.Errorf("expected %q to be %q", output, expected)
+		}
+
+		output = run("-entities=false", "all")
+		if strings.Contains(output, "alice") {
+			t.Errorf("expected %q not to contain entities", output)
+		}
+
+		output = run("-dot")
+		for _, expected := range []string{
+			`digraph "identity groups" {`,
+			`"group:` + web + `" [label="web\n(internal group)\nweb", shape=box];`,
+			`"group:` + web + `" -> "entity:` + alice + `";`,
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("expected %q to contain %q", output, expected)
+			}
+		}
+
+		ui, cmd := testIdentityGroupTreeCommand(t)
+		cmd.client = client
+		if code := cmd.Run([]string{"nope"}); code != 2 {
+			t.Errorf("expected %d to be %d", code, 2)
+		}
+		if expected := `No group named "nope"`; !strings.Contains(ui.ErrorWriter.String(), expected) {
+			t.Errorf("expected %q to contain %q", ui.ErrorWriter.String(), expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testIdentityGroupTreeCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,839 @@
+package command
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/parseutil"
+	"github.com/hashicorp/vault/sdk/helper/strutil"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/mitchellh/mapstructure"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*IdentityImportCommand)(nil)
+	_ cli.CommandAutocomplete = (*IdentityImportCommand)(nil)
+)
+
+type IdentityImportCommand struct {
+	*BaseCommand
+
+	flagDryRun bool
+
+	testStdin io.Reader // for tests
+}
+
+func (c *IdentityImportCommand) Synopsis() string {
+	return "Creates or updates entities, aliases and groups from a file"
+}
+
+func (c *IdentityImportCommand) Help() string {
+	helpText := `
+Usage: vault identity import [options] PATH
+
+  Reads entities, entity aliases and groups from the file at PATH and creates
+  or updates them in Vault, so that they match the file. Objects are found by
+  name, so importing the same file again changes nothing. The changes are
+  printed before they are applied. If PATH is "-", the file is read from
+  stdin.
+
+  Show what importing a file would change, without changing anything:
+
+      $ vault identity import -dry-run identities.json
+
+  Files ending in ".csv" are read as CSV, all others as JSON of the form:
+
+      {
+        "entities": [{
+          "name": "alice",
+          "policies": ["dev"],
+          "metadata": {"team": "web"},
+          "aliases": [{"name": "alice", "mount": "userpass/"}],
+          "groups": ["web"]
+        }],
+        "groups": [{
+          "name": "ops",
+          "type": "external",
+          "policies": ["ops"],
+          "alias": {"name": "cn=ops,ou=groups", "mount": "ldap/"}
+        }]
+      }
+
+  CSV files have a header row and one row per entity alias, with the columns
+  "entity", "policies", "groups", "disabled", "alias_name", "alias_mount",
+  "metadata.<key>" and "alias_custom_metadata.<key>". Lists are separated by
+  ";". Rows of the same entity are merged.
+
+  Fields which are not given are left as they are in Vault. Group members are
+  only ever added, never removed.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *IdentityImportCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  &c.flagDryRun,
+		Default: false,
+		Usage:   "Only print the changes the import would make.",
+	})
+
+	return set
+}
+
+func (c *IdentityImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictOr(complete.PredictFiles("*.json"), complete.PredictFiles("*.csv"))
+}
+
+func (c *IdentityImportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *IdentityImportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	path := strings.TrimSpace(args[0])
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+		if c.testStdin != nil {
+			r = c.testStdin
+		}
+	} else {
+		expanded, err := homedir.Expand(path)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+			return 1
+		}
+		file, err := os.Open(expanded)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error opening file: %s", err), err)
+			return 1
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var input *identityImportFile
+	var err error
+	if strings.HasSuffix(strings.ToLower(path), ".csv") {
+		input, err = parseIdentityImportCSV(r)
+	} else {
+		input, err = parseIdentityImportJSON(r)
+	}
+	if err == nil {
+		err = input.validate()
+	}
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing %s: %s", path, err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	plan := &identityImportPlan{
+		client:    client,
+		entityIDs: map[string]string{},
+		groupIDs:  map[string]string{},
+	}
+	if err := plan.build(input); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error planning import: %s", err), err)
+		return 2
+	}
+
+	if len(plan.changes) == 0 {
+		c.UI.Output("No changes")
+		return 0
+	}
+
+	for _, change := range plan.changes {
+		c.UI.Output(change.summary)
+		for _, detail := range change.details {
+			c.UI.Output("    " + detail)
+		}
+	}
+
+	if c.flagDryRun {
+		c.UI.Output(fmt.Sprintf("\nDry run: %d change(s) would be applied", len(plan.changes)))
+		return 0
+	}
+
+	for i, change := range plan.changes {
+		if err := change.apply(); err != nil {
+			outputError(c.UI, fmt.Sprintf("Error applying %q: %s", change.summary, err), err)
+			c.UI.Error(fmt.Sprintf("%d of %d change(s) were applied", i, len(plan.changes)))
+			return 2
+		}
+	}
+
+	c.UI.Output(fmt.Sprintf("\nSuccess! Applied %d change(s)", len(plan.changes)))
+	return 0
+}
+
+// identityImportFile is the content of a file imported by vault identity
+// import. Nil fields are left as they are in Vault.
+type identityImportFile struct {
+	Entities []*identityImportEntity `json:"entities"`
+	Groups   []*identityImportGroup  `json:"groups"`
+}
+
+type identityImportEntity struct {
+	Name     string                 `json:"name"`
+	Policies []string               `json:"policies"`
+	Metadata map[string]string      `json:"metadata"`
+	Disabled *bool                  `json:"disabled"`
+	Aliases  []*identityImportAlias `json:"aliases"`
+	Groups   []string               `json:"groups"`
+}
+
+type identityImportAlias struct {
+	Name           string            `json:"name"`
+	Mount          string            `json:"mount"`
+	CustomMetadata map[string]string `json:"custom_metadata"`
+}
+
+type identityImportGroup struct {
+	Name           string               `json:"name"`
+	Type           string               `json:"type"`
+	Policies       []string             `json:"policies"`
+	Metadata       map[string]string    `json:"metadata"`
+	MemberEntities []string             `json:"member_entities"`
+	MemberGroups   []string             `json:"member_groups"`
+	Alias          *identityImportAlias `json:"alias"`
+}
+
+func parseIdentityImportJSON(r io.Reader) (*identityImportFile, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var input identityImportFile
+	if err := dec.Decode(&input); err != nil {
+		return nil, err
+	}
+	return &input, nil
+}
+
+func parseIdentityImportCSV(r io.Reader) (*identityImportFile, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+
+	header := records[0]
+	var hasEntity bool
+	for _, column := range header {
+		switch {
+		case column == "entity":
+			hasEntity = true
+		case column == "policies", column == "groups", column == "disabled",
+			column == "alias_name", column == "alias_mount",
+			strings.HasPrefix(column, "metadata."), strings.HasPrefix(column, "alias_custom_metadata."):
+		default:
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+	}
+	if !hasEntity {
+		return nil, fmt.Errorf(`missing column "entity"`)
+	}
+
+	splitList := func(s string) []string {
+		var list []string
+		for _, item := range strings.Split(s, ";") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list
+	}
+
+	input := &identityImportFile{}
+	entities := map[string]*identityImportEntity{}
+	for i, record := range records[1:] {
+		row := map[string]string{}
+		for j, column := range header {
+			row[column] = strings.TrimSpace(record[j])
+		}
+
+		name := row["entity"]
+		if name == "" {
+			return nil, fmt.Errorf("row %d: missing entity name", i+2)
+		}
+		entity, ok := entities[name]
+		if !ok {
+			entity = &identityImportEntity{Name: name}
+			entities[name] = entity
+			input.Entities = append(input.Entities, entity)
+		}
+
+		alias := &identityImportAlias{
+			Name:  row["alias_name"],
+			Mount: row["alias_mount"],
+		}
+		for column, value := range row {
+			switch {
+			case value == "":
+			case column == "policies":
+				entity.Policies = splitList(value)
+			case column == "groups":
+				entity.Groups = strutil.RemoveDuplicates(append(entity.Groups, splitList(value)...), false)
+			case column == "disabled":
+				disabled, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: invalid value for disabled: %w", i+2, err)
+				}
+				entity.Disabled = &disabled
+			case strings.HasPrefix(column, "metadata."):
+				if entity.Metadata == nil {
+					entity.Metadata = map[string]string{}
+				}
+				entity.Metadata[strings.TrimPrefix(column, "metadata.")] = value
+			case strings.HasPrefix(column, "alias_custom_metadata."):
+				if alias.CustomMetadata == nil {
+					alias.CustomMetadata = map[string]string{}
+				}
+				alias.CustomMetadata[strings.TrimPrefix(column, "alias_custom_metadata.")] = value
+			}
+		}
+		if alias.Name != "" || alias.Mount != "" {
+			entity.Aliases = append(entity.Aliases, alias)
+		}
+	}
+
+	return input, nil
+}
+
+func (f *identityImportFile) validate() error {
+	entities := map[string]bool{}
+	for _, entity := range f.Entities {
+		if entity.Name == "" {
+			return fmt.Errorf("entity without a name")
+		}
+		if entities[entity.Name] {
+			return fmt.Errorf("entity %q is defined more than once", entity.Name)
+		}
+		entities[entity.Name] = true
+		mounts := map[string]bool{}
+		for _, alias := range entity.Aliases {
+			if alias.Name == "" || alias.Mount == "" {
+				return fmt.Errorf("alias of entity %q needs a name and a mount", entity.Name)
+			}
+			mount := ensureTrailingSlash(sanitizePath(alias.Mount))
+			if mounts[mount] {
+				return fmt.Errorf("entity %q has more than one alias on %s", entity.Name, mount)
+			}
+			mounts[mount] = true
+		}
+	}
+
+	groups := map[string]bool{}
+	for _, group := range f.Groups {
+		if group.Name == "" {
+			return fmt.Errorf("group without a name")
+		}
+		if groups[group.Name] {
+			return fmt.Errorf("group %q is defined more than once", group.Name)
+		}
+		groups[group.Name] = true
+		switch group.Type {
+		case "", "internal":
+			if group.Alias != nil {
+				return fmt.Errorf("group %q: only external groups can have an alias", group.Name)
+			}
+		case "external":
+			if len(group.MemberEntities) > 0 || len(group.MemberGroups) > 0 {
+				return fmt.Errorf("group %q: external groups cannot have members", group.Name)
+			}
+			if group.Alias != nil && (group.Alias.Name == "" || group.Alias.Mount == "") {
+				return fmt.Errorf("alias of group %q needs a name and a mount", group.Name)
+			}
+		default:
+			return fmt.Errorf("group %q: invalid type %q", group.Name, group.Type)
+		}
+	}
+
+	return nil
+}
+
+// identityImportChange is a single change to Vault made by vault identity
+// import.
+type identityImportChange struct {
+	summary string
+	details []string
+	apply   func() error
+}
+
+// identityImportPlan computes the changes needed to make Vault match an
+// imported file.
+type identityImportPlan struct {
+	client    *api.Client
+	accessors map[string]string
+
+	// entityIDs and groupIDs map names to IDs. The IDs of the objects which
+	// the plan creates are added when the change is applied.
+	entityIDs map[string]string
+	groupIDs  map[string]string
+
+	changes []*identityImportChange
+}
+
+func (p *identityImportPlan) add(summary string, details []string, apply func() error) {
+	p.changes = append(p.changes, &identityImportChange{
+		summary: summary,
+		details: details,
+		apply:   apply,
+	})
+}
+
+func (p *identityImportPlan) read(path string) (map[string]interface{}, error) {
+	secret, err := p.client.Logical().Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+	return secret.Data, nil
+}
+
+// accessor returns the accessor of the auth method mounted at mount.
+func (p *identityImportPlan) accessor(mount string) (string, error) {
+	if p.accessors == nil {
+		auths, err := p.client.Sys().ListAuth()
+		if err != nil {
+			return "", fmt.Errorf("error listing auth methods: %w", err)
+		}
+		p.accessors = make(map[string]string, len(auths))
+		for path, auth := range auths {
+			p.accessors[path] = auth.Accessor
+		}
+	}
+
+	accessor, ok := p.accessors[ensureTrailingSlash(sanitizePath(mount))]
+	if !ok {
+		return "", fmt.Errorf("no auth method is mounted at %q", mount)
+	}
+	return accessor, nil
+}
+
+// entityID returns the ID of the entity named name, which must either exist or
+// be created by the plan.
+func (p *identityImportPlan) entityID(name string) (string, error) {
+	if id, ok := p.entityIDs[name]; ok {
+		return id, nil
+	}
+	existing, err := p.read("identity/entity/name/" + name)
+	if err != nil {
+		return "", fmt.Errorf("error reading entity %q: %w", name, err)
+	}
+	if existing == nil {
+		return "", fmt.Errorf("entity %q does not exist", name)
+	}
+	p.entityIDs[name] = identityImportString(existing["id"])
+	return p.entityIDs[name], nil
+}
+
+// groupID returns the ID of the group named name, which must either exist or be
+// created by the plan.
+func (p *identityImportPlan) groupID(name string) (string, error) {
+	if id, ok := p.groupIDs[name]; ok {
+		return id, nil
+	}
+	existing, err := p.read("identity/group/name/" + name)
+	if err != nil {
+		return "", fmt.Errorf("error reading group %q: %w", name, err)
+	}
+	if existing == nil {
+		return "", fmt.Errorf("group %q does not exist", name)
+	}
+	p.groupIDs[name] = identityImportString(existing["id"])
+	return p.groupIDs[name], nil
+}
+
+func (p *identityImportPlan) build(input *identityImportFile) error {
+	// Entities can name the groups they belong to, which are created if they
+	// are not in the file.
+	groups := map[string]*identityImportGroup{}
+	for _, group := range input.Groups {
+		groups[group.Name] = group
+	}
+	for _, entity := range input.Entities {
+		for _, name := range entity.Groups {
+			group, ok := groups[name]
+			if !ok {
+				group = &identityImportGroup{Name: name}
+				groups[name] = group
+				input.Groups = append(input.Groups, group)
+			}
+			if group.Type == "external" {
+				return fmt.Errorf("entity %q cannot be a member of external group %q", entity.Name, name)
+			}
+			group.MemberEntities = strutil.RemoveDuplicates(append(group.MemberEntities, entity.Name), false)
+		}
+	}
+
+	for _, entity := range input.Entities {
+		if err := p.planEntity(entity); err != nil {
+			return err
+		}
+	}
+
+	// Groups are created before their member groups are set, so that groups
+	// can have members which are defined after them.
+	existingGroups := make(map[string]map[string]interface{}, len(input.Groups))
+	for _, group := range input.Groups {
+		existing, err := p.planGroup(group)
+		if err != nil {
+			return err
+		}
+		existingGroups[group.Name] = existing
+	}
+	for _, group := range input.Groups {
+		if err := p.planMemberGroups(group, existingGroups[group.Name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *identityImportPlan) planEntity(entity *identityImportEntity) error {
+	existing, err := p.read("identity/entity/name/" + entity.Name)
+	if err != nil {
+		return fmt.Errorf("error reading entity %q: %w", entity.Name, err)
+	}
+
+	data := map[string]interface{}{}
+	var details []string
+
+	// Entities which are created by the plan have an empty ID until then.
+	p.entityIDs[entity.Name] = ""
+	if existing != nil {
+		p.entityIDs[entity.Name] = identityImportString(existing["id"])
+	}
+	if entity.Policies != nil {
+		current, _ := parseutil.ParseCommaStringSlice(existing["policies"])
+		if existing == nil || !strutil.EquivalentSlices(current, entity.Policies) {
+			data["policies"] = entity.Policies
+			details = append(details, fmt.Sprintf("policies: %s => %s", identityImportList(current), identityImportList(entity.Policies)))
+		}
+	}
+	if entity.Metadata != nil {
+		current := identityImportStringMap(existing["metadata"])
+		if existing == nil || !identityImportEqualMaps(current, entity.Metadata) {
+			data["metadata"] = entity.Metadata
+			details = append(details, fmt.Sprintf("metadata: %s => %s", identityImportMap(current), identityImportMap(entity.Metadata)))
+		}
+	}
+	if entity.Disabled != nil {
+		current, _ := existing["disabled"].(bool)
+		if existing == nil || current != *entity.Disabled {
+			data["disabled"] = *entity.Disabled
+			details = append(details, fmt.Sprintf("disabled: %t => %t", current, *entity.Disabled))
+		}
+	}
+
+	switch {
+	case existing == nil:
+		p.add(fmt.Sprintf("+ entity %q", entity.Name), details, func() error {
+			secret, err := p.client.Logical().Write("identity/entity/name/"+entity.Name, data)
+			if err != nil {
+				return err
+			}
+			if secret == nil || secret.Data == nil {
+				return fmt.Errorf("no entity ID returned")
+			}
+			p.entityIDs[entity.Name] = identityImportString(secret.Data["id"])
+			return nil
+		})
+	case len(data) > 0:
+		p.add(fmt.Sprintf("~ entity %q", entity.Name), details, func() error {
+			_, err := p.client.Logical().Write("identity/entity/name/"+entity.Name, data)
+			return err
+		})
+	}
+
+	var existingAliases []interface{}
+	if existing != nil {
+		existingAliases, _ = existing["aliases"].([]interface{})
+	}
+	for _, alias := range entity.Aliases {
+		accessor, err := p.accessor(alias.Mount)
+		if err != nil {
+			return fmt.Errorf("alias %q of entity %q: %w", alias.Name, entity.Name, err)
+		}
+
+		// An entity has at most one alias per auth method.
+		var current map[string]interface{}
+		for _, raw := range existingAliases {
+			a, _ := raw.(map[string]interface{})
+			if identityImportString(a["mount_accessor"]) == accessor {
+				current = a
+			}
+		}
+
+		summary := fmt.Sprintf("entity alias %q on %s (entity %q)", alias.Name, alias.Mount, entity.Name)
+		aliasData := map[string]interface{}{
+			"name":           alias.Name,
+			"mount_accessor": accessor,
+		}
+		if alias.CustomMetadata != nil {
+			aliasData["custom_metadata"] = alias.CustomMetadata
+		}
+		switch {
+		case current == nil:
+			var details []string
+			if alias.CustomMetadata != nil {
+				details = append(details, fmt.Sprintf("custom_metadata: %s", identityImportMap(alias.CustomMetadata)))
+			}
+			p.add("+ "+summary, details, func() error {
+				aliasData["canonical_id"] = p.entityIDs[entity.Name]
+				_, err := p.client.Logical().Write("identity/entity-alias", aliasData)
+				return err
+			})
+
+		default:
+			var details []string
+			if name := identityImportString(current["name"]); name != alias.Name {
+				details = append(details, fmt.Sprintf("name: %s => %s", name, alias.Name))
+			}
+			if currentMetadata := identityImportStringMap(current["custom_metadata"]); alias.CustomMetadata != nil && !identityImportEqualMaps(currentMetadata, alias.CustomMetadata) {
+				details = append(details, fmt.Sprintf("custom_metadata: %s => %s", identityImportMap(currentMetadata), identityImportMap(alias.CustomMetadata)))
+			}
+			if len(details) == 0 {
+				continue
+			}
+			id, canonicalID := identityImportString(current["id"]), identityImportString(current["canonical_id"])
+			p.add("~ "+summary, details, func() error {
+				aliasData["canonical_id"] = canonicalID
+				_, err := p.client.Logical().Write("identity/entity-alias/id/"+id, aliasData)
+				return err
+			})
+		}
+	}
+
+	return nil
+}
+
+// planGroup plans the changes to group, except for its member groups, and
+// returns the group as it currently is in Vault, if it exists.
+func (p *identityImportPlan) planGroup(group *identityImportGroup) (map[string]interface{}, error) {
+	existing, err := p.read("identity/group/name/" + group.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading group %q: %w", group.Name, err)
+	}
+
+	data := map[string]interface{}{}
+	var details []string
+
+	// Groups which are created by the plan have an empty ID until then.
+	p.groupIDs[group.Name] = ""
+	if existing != nil {
+		p.groupIDs[group.Name] = identityImportString(existing["id"])
+		if current := identityImportString(existing["type"]); group.Type != "" && group.Type != current {
+			return nil, fmt.Errorf("group %q is of type %q, which cannot be changed to %q", group.Name, current, group.Type)
+		}
+	} else if group.Type != "" {
+		data["type"] = group.Type
+	}
+	if group.Policies != nil {
+		current, _ := parseutil.ParseCommaStringSlice(existing["policies"])
+		if existing == nil || !strutil.EquivalentSlices(current, group.Policies) {
+			data["policies"] = group.Policies
+			details = append(details, fmt.Sprintf("policies: %s => %s", identityImportList(current), identityImportList(group.Policies)))
+		}
+	}
+	if group.Metadata != nil {
+		current := identityImportStringMap(existing["metadata"])
+		if existing == nil || !identityImportEqualMaps(current, group.Metadata) {
+			data["metadata"] = group.Metadata
+			details = append(details, fmt.Sprintf("metadata: %s => %s", identityImportMap(current), identityImportMap(group.Metadata)))
+		}
+	}
+
+	// Members are only added, so the IDs of existing members are kept.
+	currentMembers, _ := parseutil.ParseCommaStringSlice(existing["member_entity_ids"])
+	var added []string
+	for _, name := range group.MemberEntities {
+		id, ok := p.entityIDs[name]
+		if !ok {
+			if id, err = p.entityID(name); err != nil {
+				return nil, fmt.Errorf("member of group %q: %w", group.Name, err)
+			}
+		}
+		if id == "" || !strutil.StrListContains(currentMembers, id) {
+			added = append(added, name)
+		}
+	}
+	if len(added) > 0 {
+		details = append(details, fmt.Sprintf("member_entities: + %s", strings.Join(added, ", ")))
+	}
+
+	apply := func() error {
+		if len(added) > 0 {
+			members := append([]string{}, currentMembers...)
+			for _, name := range added {
+				members = append(members, p.entityIDs[name])
+			}
+			data["member_entity_ids"] = members
+		}
+		secret, err := p.client.Logical().Write("identity/group/name/"+group.Name, data)
+		if err != nil {
+			return err
+		}
+		if secret != nil && secret.Data != nil && secret.Data["id"] != nil {
+			p.groupIDs[group.Name] = identityImportString(secret.Data["id"])
+		}
+		return nil
+	}
+	switch {
+	case existing == nil:
+		p.add(fmt.Sprintf("+ group %q", group.Name), details, apply)
+	case len(data) > 0 || len(added) > 0:
+		p.add(fmt.Sprintf("~ group %q", group.Name), details, apply)
+	}
+
+	if group.Alias == nil {
+		return existing, nil
+	}
+
+	accessor, err := p.accessor(group.Alias.Mount)
+	if err != nil {
+		return nil, fmt.Errorf("alias %q of group %q: %w", group.Alias.Name, group.Name, err)
+	}
+	var current map[string]interface{}
+	if existing != nil {
+		current, _ = existing["alias"].(map[string]interface{})
+	}
+	summary := fmt.Sprintf("group alias %q on %s (group %q)", group.Alias.Name, group.Alias.Mount, group.Name)
+	aliasData := map[string]interface{}{
+		"name":           group.Alias.Name,
+		"mount_accessor": accessor,
+	}
+	switch {
+	case len(current) == 0 || identityImportString(current["id"]) == "":
+		p.add("+ "+summary, nil, func() error {
+			aliasData["canonical_id"] = p.groupIDs[group.Name]
+			_, err := p.client.Logical().Write("identity/group-alias", aliasData)
+			return err
+		})
+	case identityImportString(current["name"]) != group.Alias.Name || identityImportString(current["mount_accessor"]) != accessor:
+		details := []string{fmt.Sprintf("name: %s => %s", identityImportString(current["name"]), group.Alias.Name)}
+		id := identityImportString(current["id"])
+		p.add("~ "+summary, details, func() error {
+			aliasData["canonical_id"] = p.groupIDs[group.Name]
+			_, err := p.client.Logical().Write("identity/group-alias/id/"+id, aliasData)
+			return err
+		})
+	}
+
+	return existing, nil
+}
+
+func (p *identityImportPlan) planMemberGroups(group *identityImportGroup, existing map[string]interface{}) error {
+	if len(group.MemberGroups) == 0 {
+		return nil
+	}
+
+	currentMembers, _ := parseutil.ParseCommaStringSlice(existing["member_group_ids"])
+	var added []string
+	for _, name := range group.MemberGroups {
+		id, ok := p.groupIDs[name]
+		if !ok {
+			var err error
+			if id, err = p.groupID(name); err != nil {
+				return fmt.Errorf("member of group %q: %w", group.Name, err)
+			}
+		}
+		if id == "" || !strutil.StrListContains(currentMembers, id) {
+			added = append(added, name)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	details := []string{fmt.Sprintf("member_groups: + %s", strings.Join(added, ", "))}
+	p.add(fmt.Sprintf("~ group %q", group.Name), details, func() error {
+		members := append([]string{}, currentMembers...)
+		for _, name := range added {
+			members = append(members, p.groupIDs[name])
+		}
+		_, err := p.client.Logical().Write("identity/group/name/"+group.Name, map[string]interface{}{
+			"member_group_ids": members,
+		})
+		return err
+	})
+	return nil
+}
+
+// identityImportString returns raw if it is a string from an API response, and
+// an empty string otherwise.
+func identityImportString(raw interface{}) string {
+	s, _ := raw.(string)
+	return s
+}
+
+func identityImportStringMap(raw interface{}) map[string]string {
+	var m map[string]string
+	if err := mapstructure.WeakDecode(raw, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+func identityImportEqualMaps(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func identityImportList(list []string) string {
+	return "[" + strings.Join(list, ", ") + "]"
+}
+
+func identityImportMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
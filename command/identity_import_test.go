@@ -0,0 +1,267 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testIdentityImportCommand(tb testing.TB) (*cli.MockUi, *IdentityImportCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &IdentityImportCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestIdentityImportCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		args  []string
+		stdin string
+		out   string
+		code  int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"",
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"",
+			"Too many arguments",
+			1,
+		},
+		{
+			"unknown_field",
+			[]string{"-"},
+			`{"entities": [{"name": "alice", "polices": ["dev"]}]}`,
+			`unknown field "polices"`,
+			1,
+		},
+		{
+			"duplicate_entity",
+			[]string{"-"},
+			`{"entities": [{"name": "alice"}, {"name": "alice"}]}`,
+			`entity "alice" is defined more than once`,
+			1,
+		},
+		{
+			"duplicate_alias_mount",
+			[]string{"-"},
+			`{"entities": [{"name": "alice", "aliases": [{"name": "a", "mount": "userpass"}, {"name": "b", "mount": "userpass/"}]}]}`,
+			`entity "alice" has more than one alias on userpass/`,
+			1,
+		},
+		{
+			"external_group_members",
+			[]string{"-"},
+			`{"groups": [{"name": "ops", "type": "external", "member_entities": ["alice"]}]}`,
+			`group "ops": external groups cannot have members`,
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testIdentityImportCommand(t)
+				cmd.testStdin = strings.NewReader(tc.stdin)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{
+			Type: "userpass",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		input := `{
+  "entities": [{
+    "name": "alice",
+    "policies": ["dev"],
+    "metadata": {"team": "web"},
+    "aliases": [{"name": "alice", "mount": "userpass"}],
+    "groups": ["web"]
+  }],
+  "groups": [{
+    "name": "all",
+    "policies": ["base"],
+    "member_groups": ["web"]
+  }, {
+    "name": "ops",
+    "type": "external",
+    "alias": {"name": "ops", "mount": "userpass/"}
+  }]
+}`
+
+		run := func(args ...string) string {
+			t.Helper()
+
+			ui, cmd := testIdentityImportCommand(t)
+			cmd.client = client
+			cmd.testStdin = strings.NewReader(input)
+
+			code := cmd.Run(append(args, "-"))
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+			return ui.OutputWriter.String()
+		}
+
+		output := run("-dry-run")
+		for _, expected := range []string{
+			`+ entity "alice"`,
+			"    policies: [] => [dev]",
+			"    metadata: {} => {team=web}",
+			`+ entity alias "alice" on userpass (entity "alice")`,
+			`+ group "web"`,
+			"    member_entities: + alice",
+			`+ group alias "ops" on userpass/ (group "ops")`,
+			`~ group "all"`,
+			"    member_groups: + web",
+			"Dry run: 7 change(s) would be applied",
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("expected %q to contain %q", output, expected)
+			}
+		}
+		if secret, err := client.Logical().Read("identity/entity/name/alice"); err != nil || secret != nil {
+			t.Fatalf("expected dry run not to create entity, got %v, %v", secret, err)
+		}
+
+		output = run()
+		if expected := "Success! Applied 7 change(s)"; !strings.Contains(output, expected) {
+			t.Errorf("expected %q to contain %q", output, expected)
+		}
+
+		entity, err := client.Logical().Read("identity/entity/name/alice")
+		if err != nil || entity == nil {
+			t.Fatalf("expected entity, got %v, %v", entity, err)
+		}
+		if aliases := entity.Data["aliases"].([]interface{}); len(aliases) != 1 {
+			t.Errorf("expected 1 alias, got %v", aliases)
+		}
+		web, err := client.Logical().Read("identity/group/name/web")
+		if err != nil || web == nil {
+			t.Fatalf("expected group, got %v, %v", web, err)
+		}
+		if members := web.Data["member_entity_ids"].([]interface{}); len(members) != 1 || members[0] != entity.Data["id"] {
+			t.Errorf("expected group web to have alice as member, got %v", members)
+		}
+		all, err := client.Logical().Read("identity/group/name/all")
+		if err != nil || all == nil {
+			t.Fatalf("expected group, got %v, %v", all, err)
+		}
+		if members := all.Data["member_group_ids"].([]interface{}); len(members) != 1 || members[0] != web.Data["id"] {
+			t.Errorf("expected group all to have web as member, got %v", members)
+		}
+
+		if output := run(); output != "No changes\n" {
+			t.Errorf("expected a second import to change nothing, got %q", output)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{
+			Type: "userpass",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Sys().EnableAuthWithOptions("ldap", &api.EnableAuthOptions{
+			Type: "ldap",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("identity/entity/name/bob", map[string]interface{}{
+			"metadata": map[string]string{"team": "web"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		dir, err := ioutil.TempDir("", "vault-identity-import")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "identities.csv")
+		if err := ioutil.WriteFile(path, []byte(`entity,policies,metadata.team,alias_name,alias_mount,alias_custom_metadata.email
+bob,dev;ops,ops,bob,userpass,bob@example.com
+bob,,,robert,ldap,
+`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testIdentityImportCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{path})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		output := ui.OutputWriter.String()
+		for _, expected := range []string{
+			`~ entity "bob"`,
+			"    policies: [] => [dev, ops]",
+			"    metadata: {team=web} => {team=ops}",
+			`+ entity alias "bob" on userpass (entity "bob")`,
+			"    custom_metadata: {email=bob@example.com}",
+			`+ entity alias "robert" on ldap (entity "bob")`,
+			"Success! Applied 3 change(s)",
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("expected %q to contain %q", output, expected)
+			}
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testIdentityImportCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*KubernetesCommand)(nil)
+
+type KubernetesCommand struct {
+	*BaseCommand
+}
+
+func (c *KubernetesCommand) Synopsis() string {
+	return "Interact with Vault's Kubernetes secrets engines"
+}
+
+func (c *KubernetesCommand) Help() string {
+	helpText := `
+Usage: vault kubernetes <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with Vault's Kubernetes
+  secrets engines. Here are a few examples of the Kubernetes commands:
+
+  Write a kubeconfig for a token of the "my-role" role:
+
+      $ vault kubernetes kubeconfig -kubernetes-namespace=default my-role
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KubernetesCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,323 @@
+package command
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KubernetesKubeconfigCommand)(nil)
+	_ cli.CommandAutocomplete = (*KubernetesKubeconfigCommand)(nil)
+)
+
+type KubernetesKubeconfigCommand struct {
+	*BaseCommand
+
+	flagMount               string
+	flagKubernetesNamespace string
+	flagClusterRoleBinding  bool
+	flagTTL                 time.Duration
+	flagServer              string
+	flagOutput              string
+}
+
+func (c *KubernetesKubeconfigCommand) Synopsis() string {
+	return "Generate a kubeconfig for Kubernetes secrets engine credentials"
+}
+
+func (c *KubernetesKubeconfigCommand) Help() string {
+	helpText := `
+Usage: vault kubernetes kubeconfig [options] ROLE
+
+  Generates a service account token with the given role of the Kubernetes
+  secrets engine, and writes a kubeconfig which uses it to connect to the
+  cluster configured on the secrets engine. The kubeconfig is written to
+  stdout, or to the file given with -output.
+
+  The kubeconfig holds a single cluster, user and context, and its current
+  context is set to this context. The token is not renewed: once its lease
+  expires, run this command again.
+
+  Connect to the cluster with a token of the "my-role" role:
+
+      $ vault kubernetes kubeconfig -kubernetes-namespace=default \
+          -output=my-role.kubeconfig my-role
+      $ kubectl --kubeconfig=my-role.kubeconfig get pods
+
+  Use a token valid for 1 hour from a secrets engine mounted at "k8s-prod":
+
+      $ vault kubernetes kubeconfig -mount=k8s-prod -kubernetes-namespace=default \
+          -ttl=1h my-role > ~/.kube/config
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KubernetesKubeconfigCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "kubernetes",
+		Completion: c.PredictVaultFolders(),
+		Usage:      "Path where the Kubernetes secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "kubernetes-namespace",
+		Target:     &c.flagKubernetesNamespace,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Kubernetes namespace to generate the service account token in. " +
+			"It is also the namespace of the context of the kubeconfig. This " +
+			"is required.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "cluster-role-binding",
+		Target:  &c.flagClusterRoleBinding,
+		Default: false,
+		Usage: "Bind the generated service account with a cluster role binding " +
+			"instead of a role binding.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "ttl",
+		Target:     &c.flagTTL,
+		Default:    0,
+		Completion: complete.PredictAnything,
+		Usage:      "Requested TTL of the token. Defaults to the TTL of the role.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "server",
+		Target:     &c.flagServer,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Address of the Kubernetes API server. Defaults to the " +
+			"kubernetes_host configured on the secrets engine.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "output",
+		Target:     &c.flagOutput,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path of the file to write the kubeconfig to, instead of stdout.",
+	})
+
+	return set
+}
+
+func (c *KubernetesKubeconfigCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *KubernetesKubeconfigCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KubernetesKubeconfigCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	case c.flagKubernetesNamespace == "":
+		c.UI.Error("-kubernetes-namespace is required")
+		return 1
+	}
+	role := args[0]
+	mount := ensureTrailingSlash(sanitizePath(c.flagMount))
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	server, caCert, err := c.readCluster(client, mount)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	data := map[string]interface{}{
+		"kubernetes_namespace": c.flagKubernetesNamespace,
+		"cluster_role_binding": c.flagClusterRoleBinding,
+	}
+	if c.flagTTL > 0 {
+		data["ttl"] = c.flagTTL.String()
+	}
+	secret, err := client.Logical().Write(mount+"creds/"+role, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error generating credentials: %s", err), err)
+		return 2
+	}
+	if secret == nil || secret.Data == nil {
+		c.UI.Error("Error generating credentials: no credentials returned")
+		return 2
+	}
+	token, _ := secret.Data["service_account_token"].(string)
+	if token == "" {
+		c.UI.Error("Error generating credentials: no service account token returned")
+		return 2
+	}
+	user, _ := secret.Data["service_account_name"].(string)
+	if user == "" {
+		user = role
+	}
+	namespace, _ := secret.Data["service_account_namespace"].(string)
+	if namespace == "" {
+		namespace = c.flagKubernetesNamespace
+	}
+
+	cluster := strings.ReplaceAll(strings.TrimSuffix(mount, "/"), "/", "-")
+	b, err := yaml.Marshal(newKubeconfig(cluster, server, caCert, user, token, namespace))
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error encoding kubeconfig: %s", err), err)
+		return 2
+	}
+
+	if c.flagOutput == "" {
+		c.UI.Output(strings.TrimSuffix(string(b), "\n"))
+		return 0
+	}
+
+	path, err := homedir.Expand(c.flagOutput)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+		return 1
+	}
+	// The kubeconfig holds the token, so it replaces any existing file with
+	// one only readable by its owner
+	if err := writeOutputFile(path, b, 0o600); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing kubeconfig: %s", err), err)
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Success! Wrote a kubeconfig for service account %q to %s", user, c.flagOutput))
+	if secret.LeaseID != "" {
+		c.UI.Info(fmt.Sprintf("The token expires in %s, with lease %s",
+			time.Duration(secret.LeaseDuration)*time.Second, secret.LeaseID))
+	}
+	return 0
+}
+
+// readCluster returns the address and the CA certificate of the Kubernetes
+// API server configured on the secrets engine at mount. The address can be
+// overridden with -server.
+func (c *KubernetesKubeconfigCommand) readCluster(client *api.Client, mount string) (string, string, error) {
+	config, err := client.Logical().Read(mount + "config")
+	if err != nil {
+		return "", "", fmt.Errorf("Error reading the configuration of %s: %w", mount, err)
+	}
+
+	var server, caCert string
+	if config != nil && config.Data != nil {
+		server, _ = config.Data["kubernetes_host"].(string)
+		caCert, _ = config.Data["kubernetes_ca_cert"].(string)
+	}
+	if c.flagServer != "" {
+		server = c.flagServer
+	}
+	if server == "" {
+		return "", "", fmt.Errorf("No Kubernetes API server configured on %s, please set -server", mount)
+	}
+	return server, caCert, nil
+}
+
+// kubeconfig is the subset of the kubeconfig format used by vault kubernetes
+// kubeconfig.
+type kubeconfig struct {
+	APIVersion     string                   `json:"apiVersion"`
+	Kind           string                   `json:"kind"`
+	Clusters       []kubeconfigNamedCluster `json:"clusters"`
+	Users          []kubeconfigNamedUser    `json:"users"`
+	Contexts       []kubeconfigNamedContext `json:"contexts"`
+	CurrentContext string                   `json:"current-context"`
+}
+
+type kubeconfigNamedCluster struct {
+	Name    string            `json:"name"`
+	Cluster kubeconfigCluster `json:"cluster"`
+}
+
+type kubeconfigCluster struct {
+	Server                   string `json:"server"`
+	CertificateAuthorityData string `json:"certificate-authority-data,omitempty"`
+}
+
+type kubeconfigNamedUser struct {
+	Name string         `json:"name"`
+	User kubeconfigUser `json:"user"`
+}
+
+type kubeconfigUser struct {
+	Token string `json:"token"`
+}
+
+type kubeconfigNamedContext struct {
+	Name    string            `json:"name"`
+	Context kubeconfigContext `json:"context"`
+}
+
+type kubeconfigContext struct {
+	Cluster   string `json:"cluster"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// newKubeconfig returns a kubeconfig with a single cluster, user and context,
+// whose current context is this context. caCert is PEM encoded, and is
+// omitted if empty.
+func newKubeconfig(cluster, server, caCert, user, token, namespace string) *kubeconfig {
+	context := user + "@" + cluster
+
+	config := &kubeconfig{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []kubeconfigNamedCluster{{
+			Name:    cluster,
+			Cluster: kubeconfigCluster{Server: server},
+		}},
+		Users: []kubeconfigNamedUser{{
+			Name: user,
+			User: kubeconfigUser{Token: token},
+		}},
+		Contexts: []kubeconfigNamedContext{{
+			Name: context,
+			Context: kubeconfigContext{
+				Cluster:   cluster,
+				User:      user,
+				Namespace: namespace,
+			},
+		}},
+		CurrentContext: context,
+	}
+	if caCert != "" {
+		config.Clusters[0].Cluster.CertificateAuthorityData = base64.StdEncoding.EncodeToString([]byte(caCert))
+	}
+	return config
+}
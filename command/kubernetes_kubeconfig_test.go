@@ -0,0 +1,245 @@
+package command
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testKubernetesKubeconfigCommand(tb testing.TB) (*cli.MockUi, *KubernetesKubeconfigCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KubernetesKubeconfigCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testKubernetesServer starts a fake Vault server with a Kubernetes secrets
+// engine mounted at "kubernetes/", configured with the given host, and
+// returns a client for it. The requests to generate credentials are sent to
+// requests.
+func testKubernetesServer(tb testing.TB, host string, requests chan<- map[string]interface{}) *api.Client {
+	tb.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/kubernetes/config" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"kubernetes_host":    host,
+					"kubernetes_ca_cert": "-----BEGIN CERTIFICATE-----\n...\n-----END CERTIFICATE-----",
+				},
+			})
+		case r.URL.Path == "/v1/kubernetes/creds/my-role" && r.Method == http.MethodPut:
+			var data map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&data)
+			requests <- data
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       "kubernetes/creds/my-role/abcd",
+				"lease_duration": 3600,
+				"data": map[string]interface{}{
+					"service_account_name":      "v-token-my-role-1234",
+					"service_account_namespace": data["kubernetes_namespace"],
+					"service_account_token":     "eyJhbGciOi...",
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	tb.Cleanup(server.Close)
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return client
+}
+
+func TestKubernetesKubeconfigCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"-kubernetes-namespace=default"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"-kubernetes-namespace=default", "foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"no_kubernetes_namespace",
+			[]string{"my-role"},
+			"-kubernetes-namespace is required",
+			1,
+		},
+		{
+			"no_server",
+			[]string{"-kubernetes-namespace=default", "my-role"},
+			"No Kubernetes API server configured",
+			2,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client := testKubernetesServer(t, "", make(chan map[string]interface{}, 1))
+
+				ui, cmd := testKubernetesKubeconfigCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		t.Parallel()
+
+		requests := make(chan map[string]interface{}, 1)
+		client := testKubernetesServer(t, "https://10.0.0.1:6443", requests)
+
+		ui, cmd := testKubernetesKubeconfigCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-kubernetes-namespace=apps", "-ttl=1h", "my-role"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		data := <-requests
+		if data["kubernetes_namespace"] != "apps" || data["ttl"] != "1h0m0s" {
+			t.Errorf("unexpected request %v", data)
+		}
+
+		var config kubeconfig
+		if err := yaml.Unmarshal(ui.OutputWriter.Bytes(), &config); err != nil {
+			t.Fatal(err)
+		}
+		expected := newKubeconfig("kubernetes", "https://10.0.0.1:6443",
+			"-----BEGIN CERTIFICATE-----\n...\n-----END CERTIFICATE-----",
+			"v-token-my-role-1234", "eyJhbGciOi...", "apps")
+		if config.CurrentContext != expected.CurrentContext ||
+			config.Clusters[0] != expected.Clusters[0] ||
+			config.Users[0] != expected.Users[0] ||
+			config.Contexts[0] != expected.Contexts[0] {
+			t.Errorf("expected %+v to be %+v", config, expected)
+		}
+
+		ca, err := base64.StdEncoding.DecodeString(config.Clusters[0].Cluster.CertificateAuthorityData)
+		if err != nil || !strings.HasPrefix(string(ca), "-----BEGIN CERTIFICATE-----") {
+			t.Errorf("unexpected certificate-authority-data %q: %v", ca, err)
+		}
+	})
+
+	t.Run("output", func(t *testing.T) {
+		t.Parallel()
+
+		client := testKubernetesServer(t, "", make(chan map[string]interface{}, 1))
+
+		ui, cmd := testKubernetesKubeconfigCommand(t)
+		cmd.client = client
+
+		path := filepath.Join(t.TempDir(), "kubeconfig")
+		code := cmd.Run([]string{
+			"-kubernetes-namespace=default",
+			"-server=https://k8s.example.com",
+			"-output=" + path,
+			"my-role",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("expected mode %o to be %o", perm, 0o600)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), "server: https://k8s.example.com") {
+			t.Errorf("expected %q to use the server given with -server", b)
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		for _, expected := range []string{
+			`Success! Wrote a kubeconfig for service account "v-token-my-role-1234"`,
+			"The token expires in 1h0m0s, with lease kubernetes/creds/my-role/abcd",
+		} {
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testKubernetesKubeconfigCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-kubernetes-namespace=default", "my-role"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error reading the configuration of kubernetes/: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testKubernetesKubeconfigCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,260 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVCopyCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVCopyCommand)(nil)
+)
+
+// kvCopyOptions are the options shared by "kv cp" and "kv mv".
+type kvCopyOptions struct {
+	Recursive     bool
+	AllVersions   bool
+	FromNamespace string
+	ToNamespace   string
+	Concurrency   int
+}
+
+type KVCopyCommand struct {
+	*BaseCommand
+
+	flagCopyOpts kvCopyOptions
+}
+
+func (c *KVCopyCommand) Synopsis() string {
+	return "Copies secrets in the KV store"
+}
+
+func (c *KVCopyCommand) Help() string {
+	helpText := `
+Usage: vault kv cp [options] SOURCE DESTINATION
+
+  Copies the secret at SOURCE to DESTINATION, which may be in another mount or
+  namespace. The custom metadata and settings of KV v2 secrets are copied
+  along with the data.
+
+  Copy a secret to a new path:
+
+      $ vault kv cp secret/foo secret/bar
+
+  Copy every secret under "secret/app" into another mount, including all of
+  their versions:
+
+      $ vault kv cp -r -all-versions secret/app other-secret/app
+
+  Copy a secret into another namespace:
+
+      $ vault kv cp -to-namespace=ns1 secret/foo secret/foo
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVCopyCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+	addKVCopyFlags(f, &c.flagCopyOpts)
+
+	return set
+}
+
+func (c *KVCopyCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVCopyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVCopyCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) != 2 {
+		c.UI.Error(fmt.Sprintf("Invalid number of arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	return kvCopy(c.UI, client, c.flagCopyOpts, args[0], args[1], false)
+}
+
+// addKVCopyFlags adds the flags which set opts to f.
+func addKVCopyFlags(f *FlagSet, opts *kvCopyOptions) {
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Aliases: []string{"r"},
+		Target:  &opts.Recursive,
+		Default: false,
+		Usage:   "Treat SOURCE as a prefix and transfer every key under it, including those in sub-folders.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "all-versions",
+		Target:  &opts.AllVersions,
+		Default: false,
+		Usage: "Transfer every version of each secret which has not been " +
+			"deleted or destroyed, not just the current version. Both mounts " +
+			"must be KV v2.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "from-namespace",
+		Target:  &opts.FromNamespace,
+		Default: "",
+		Usage:   "The namespace to read SOURCE from, relative to -namespace.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "to-namespace",
+		Target:  &opts.ToNamespace,
+		Default: "",
+		Usage:   "The namespace to write DESTINATION to, relative to -namespace.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "concurrency",
+		Target:  &opts.Concurrency,
+		Default: defaultListConcurrency,
+		Usage:   "Number of secrets to transfer at once when -recursive is set.",
+	})
+}
+
+// kvCopy copies the secret at src, or every key under it if opts.Recursive is
+// set, to dst. If move is set, each source key is permanently deleted once it
+// has been copied.
+func kvCopy(ui cli.Ui, client *api.Client, opts kvCopyOptions, src, dst string, move bool) int {
+	src = sanitizePath(src)
+	dst = sanitizePath(dst)
+	if src == dst && opts.FromNamespace == opts.ToNamespace {
+		ui.Error("Source and destination must be different")
+		return 1
+	}
+
+	srcClient, err := clientForNamespace(client, opts.FromNamespace)
+	if err != nil {
+		ui.Error(err.Error())
+		return 2
+	}
+	dstClient, err := clientForNamespace(client, opts.ToNamespace)
+	if err != nil {
+		ui.Error(err.Error())
+		return 2
+	}
+
+	srcMount, srcV2, err := isKVv2(src, srcClient)
+	if err != nil {
+		ui.Error(err.Error())
+		return 2
+	}
+	dstMount, dstV2, err := isKVv2(dst, dstClient)
+	if err != nil {
+		ui.Error(err.Error())
+		return 2
+	}
+	if opts.AllVersions && (!srcV2 || !dstV2) {
+		ui.Error("K/V engine mounts must be version 2 to transfer all versions")
+		return 1
+	}
+
+	targets := map[string]string{src: dst}
+	if opts.Recursive {
+		src = ensureTrailingSlash(src)
+		dst = ensureTrailingSlash(dst)
+
+		keys, err := kvListRecursive(ui, srcClient, srcMount, srcV2, src, opts.Concurrency)
+		if err != nil {
+			ui.Error(err.Error())
+			return 2
+		}
+		if len(keys) == 0 {
+			ui.Error(fmt.Sprintf("No keys found under %s", src))
+			return 2
+		}
+
+		targets = make(map[string]string, len(keys))
+		for _, key := range keys {
+			targets[key] = dst + strings.TrimPrefix(key, src)
+		}
+	}
+
+	keys := make([]string, 0, len(targets))
+	for key := range targets {
+		keys = append(keys, key)
+	}
+
+	action := "copied"
+	if move {
+		action = "moved"
+	}
+
+	var keysProgress *progress
+	if opts.Recursive {
+		keysProgress = newProgress(ui, fmt.Sprintf("Keys %s", action), len(keys))
+	}
+	err = runConcurrently(keys, opts.Concurrency, func(key string) error {
+		defer keysProgress.Add(1)
+
+		secret, err := kvExportKey(srcClient, srcMount, srcV2, key, srcV2, opts.AllVersions)
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil && len(secret.Versions) == 0 {
+			return fmt.Errorf("No value found at %s", key)
+		}
+		if !dstV2 {
+			secret.Metadata = nil
+		}
+
+		if err := kvImportKey(dstClient, dstMount, dstV2, targets[key], secret); err != nil {
+			return err
+		}
+
+		if !move {
+			return nil
+		}
+
+		deletePath := key
+		if srcV2 {
+			deletePath = addPrefixToKVPath(key, srcMount, "metadata")
+		}
+		if _, err := srcClient.Logical().Delete(deletePath); err != nil {
+			return fmt.Errorf("Error deleting %s: %s", deletePath, err)
+		}
+		return nil
+	})
+	keysProgress.Stop()
+	if err != nil {
+		ui.Error(err.Error())
+		return 2
+	}
+
+	if !opts.Recursive {
+		ui.Info(fmt.Sprintf("Success! Data %s from %s to: %s", action, src, dst))
+	} else {
+		ui.Info(fmt.Sprintf("Success! %d keys %s from %s to: %s", len(keys), action, src, dst))
+	}
+	return 0
+}
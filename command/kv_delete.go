@@ -17,7 +17,8 @@ var (
 type KVDeleteCommand struct {
 	*BaseCommand
 
-	flagVersions []string
+	flagVersions      []string
+	flagRecursiveOpts kvRecursiveOptions
 }
 
 func (c *KVDeleteCommand) Synopsis() string {
@@ -40,6 +41,11 @@ Usage: vault kv delete [options] PATH
 
       $ vault kv delete -versions=3 secret/foo
 
+  To delete the latest version of every key under "secret/old-app", without
+  asking for confirmation:
+
+      $ vault kv delete -recursive -force secret/old-app
+
   To delete all versions and metadata, see the "vault kv metadata" subcommand.
 
   Additional flags and more advanced use cases are detailed below.
@@ -58,9 +64,11 @@ func (c *KVDeleteCommand) Flags() *FlagSets {
 		Name:    "versions",
 		Target:  &c.flagVersions,
 		Default: nil,
-		Usage:   `Specifies the version numbers to delete.`,
+		Usage:   `Specifies the version numbers to delete. Ranges such as "3-9" are also accepted.`,
 	})
 
+	addKVRecursiveFlags(f, &c.flagRecursiveOpts, "delete")
+
 	return set
 }
 
@@ -90,6 +98,12 @@ func (c *KVDeleteCommand) Run(args []string) int {
 		return 1
 	}
 
+	versions, err := kvParseVersionsFlags(c.flagVersions)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing versions: %s", err), err)
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -103,15 +117,30 @@ func (c *KVDeleteCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagRecursiveOpts.Recursive {
+		return kvRunRecursive(c.UI, client, mountPath, v2, ensureTrailingSlash(path), "deleted", c.flagRecursiveOpts, func(key string) error {
+			var err error
+			if v2 {
+				_, err = c.deleteV2(key, mountPath, versions, client)
+			} else {
+				_, err = client.Logical().Delete(key)
+			}
+			if err != nil {
+				return fmt.Errorf("Error deleting %s: %s", key, err)
+			}
+			return nil
+		})
+	}
+
 	var secret *api.Secret
 	if v2 {
-		secret, err = c.deleteV2(path, mountPath, client)
+		secret, err = c.deleteV2(path, mountPath, versions, client)
 	} else {
 		secret, err = client.Logical().Delete(path)
 	}
 
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error deleting %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error deleting %s: %s", path, err), err)
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
@@ -133,18 +162,18 @@ func (c *KVDeleteCommand) Run(args []string) int {
 	return OutputSecret(c.UI, secret)
 }
 
-func (c *KVDeleteCommand) deleteV2(path, mountPath string, client *api.Client) (*api.Secret, error) {
+func (c *KVDeleteCommand) deleteV2(path, mountPath string, versions []string, client *api.Client) (*api.Secret, error) {
 	var err error
 	var secret *api.Secret
 	switch {
-	case len(c.flagVersions) > 0:
+	case len(versions) > 0:
 		path = addPrefixToKVPath(path, mountPath, "delete")
 		if err != nil {
 			return nil, err
 		}
 
 		data := map[string]interface{}{
-			"versions": kvParseVersionsFlags(c.flagVersions),
+			"versions": versions,
 		}
 
 		secret, err = client.Logical().Write(path, data)
@@ -16,7 +16,9 @@ var (
 type KVDestroyCommand struct {
 	*BaseCommand
 
-	flagVersions []string
+	flagVersions      []string
+	flagAll           bool
+	flagRecursiveOpts kvRecursiveOptions
 }
 
 func (c *KVDestroyCommand) Synopsis() string {
@@ -34,6 +36,15 @@ Usage: vault kv destroy [options] KEY
 
       $ vault kv destroy -versions=3 secret/foo
 
+  To destroy versions 3 through 9 of key foo:
+
+      $ vault kv destroy -versions=3-9 secret/foo
+
+  To destroy every version of every key under "secret/old-app", without
+  asking for confirmation:
+
+      $ vault kv destroy -all -recursive -force secret/old-app
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -50,9 +61,18 @@ func (c *KVDestroyCommand) Flags() *FlagSets {
 		Name:    "versions",
 		Target:  &c.flagVersions,
 		Default: nil,
-		Usage:   `Specifies the version numbers to destroy.`,
+		Usage:   `Specifies the version numbers to destroy. Ranges such as "3-9" are also accepted.`,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "all",
+		Target:  &c.flagAll,
+		Default: false,
+		Usage:   "Destroy every version of the secret.",
 	})
 
+	addKVRecursiveFlags(f, &c.flagRecursiveOpts, "destroy")
+
 	return set
 }
 
@@ -82,11 +102,20 @@ func (c *KVDestroyCommand) Run(args []string) int {
 		return 1
 	}
 
-	if len(c.flagVersions) == 0 {
-		c.UI.Error("No versions provided, use the \"-versions\" flag to specify the version to destroy.")
+	switch {
+	case len(c.flagVersions) == 0 && !c.flagAll:
+		c.UI.Error("No versions provided, use the \"-versions\" or \"-all\" flag to specify the versions to destroy.")
+		return 1
+	case len(c.flagVersions) > 0 && c.flagAll:
+		c.UI.Error("Only one of the \"-versions\" and \"-all\" flags can be specified")
+		return 1
+	}
+
+	versions, err := kvParseVersionsFlags(c.flagVersions)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing versions: %s", err), err)
 		return 1
 	}
-	var err error
 	path := sanitizePath(args[0])
 
 	client, err := c.Client()
@@ -104,19 +133,20 @@ func (c *KVDestroyCommand) Run(args []string) int {
 		c.UI.Error("Destroy not supported on KV Version 1")
 		return 1
 	}
-	path = addPrefixToKVPath(path, mountPath, "destroy")
-	if err != nil {
-		c.UI.Error(err.Error())
-		return 2
-	}
 
-	data := map[string]interface{}{
-		"versions": kvParseVersionsFlags(c.flagVersions),
+	if c.flagRecursiveOpts.Recursive {
+		return kvRunRecursive(c.UI, client, mountPath, v2, ensureTrailingSlash(path), "destroyed", c.flagRecursiveOpts, func(key string) error {
+			if _, err := kvWriteVersions(client, mountPath, key, "destroy", versions, c.flagAll); err != nil {
+				return fmt.Errorf("Error destroying %s: %s", key, err)
+			}
+			return nil
+		})
 	}
 
-	secret, err := client.Logical().Write(path, data)
+	secret, err := kvWriteVersions(client, mountPath, path, "destroy", versions, c.flagAll)
+	path = addPrefixToKVPath(path, mountPath, "destroy")
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
@@ -0,0 +1,275 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVDiffCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVDiffCommand)(nil)
+)
+
+type KVDiffCommand struct {
+	*BaseCommand
+
+	flagFromVersion   int
+	flagToVersion     int
+	flagFromNamespace string
+	flagToNamespace   string
+	flagMaskValues    bool
+}
+
+// kvDiffSide describes one of the two secrets being compared.
+type kvDiffSide struct {
+	Path      string `json:"path"`
+	Namespace string `json:"namespace,omitempty"`
+	Version   int    `json:"version,omitempty"`
+
+	data map[string]interface{}
+}
+
+func (s *kvDiffSide) String() string {
+	out := s.Path
+	if s.Namespace != "" {
+		out = fmt.Sprintf("%s (namespace %s)", out, s.Namespace)
+	}
+	if s.Version > 0 {
+		out = fmt.Sprintf("%s (version %d)", out, s.Version)
+	}
+	return out
+}
+
+func (c *KVDiffCommand) Synopsis() string {
+	return "Compares two versions or paths in the KV store"
+}
+
+func (c *KVDiffCommand) Help() string {
+	helpText := `
+Usage: vault kv diff [options] KEY [OTHER_KEY]
+
+  Compares the data of two secrets in Vault's key-value store and prints the
+  keys which were added, removed, or changed.
+
+  With a single key, two versions of that key are compared. By default the
+  current version is compared with the version before it:
+
+      $ vault kv diff secret/foo
+
+  Compare specific versions of the key:
+
+      $ vault kv diff -from-version=2 -to-version=5 secret/foo
+
+  Compare the current versions of keys in two mounts, without printing the
+  secret values:
+
+      $ vault kv diff -mask-values secret/foo other-secret/foo
+
+  Compare the same key in two namespaces:
+
+      $ vault kv diff -from-namespace=ns1 -to-namespace=ns2 secret/foo
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVDiffCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	f.IntVar(&IntVar{
+		Name:   "from-version",
+		Target: &c.flagFromVersion,
+		Usage:  `The version of the first key to compare. When comparing a single key, this defaults to the version before -to-version. Otherwise the current version is used.`,
+	})
+
+	f.IntVar(&IntVar{
+		Name:   "to-version",
+		Target: &c.flagToVersion,
+		Usage:  `The version of the second key to compare. Defaults to the current version.`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "from-namespace",
+		Target:     &c.flagFromNamespace,
+		Default:    "",
+		Completion: c.PredictVaultNamespaces(),
+		Usage:      `The namespace to read the first key from, relative to -namespace.`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "to-namespace",
+		Target:     &c.flagToNamespace,
+		Default:    "",
+		Completion: c.PredictVaultNamespaces(),
+		Usage:      `The namespace to read the second key from, relative to -namespace.`,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "mask-values",
+		Target:  &c.flagMaskValues,
+		Default: false,
+		Usage:   `Print only the names of the keys which differ, not their values.`,
+	})
+
+	return set
+}
+
+func (c *KVDiffCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVDiffCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVDiffCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1 or 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1 or 2, got %d)", len(args)))
+		return 1
+	case c.flagFromVersion < 0 || c.flagToVersion < 0:
+		c.UI.Error("Versions must be positive")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	from := &kvDiffSide{
+		Path:      sanitizePath(args[0]),
+		Namespace: c.flagFromNamespace,
+		Version:   c.flagFromVersion,
+	}
+	to := &kvDiffSide{
+		Path:      from.Path,
+		Namespace: c.flagToNamespace,
+		Version:   c.flagToVersion,
+	}
+	if len(args) == 2 {
+		to.Path = sanitizePath(args[1])
+	}
+	sameKey := from.Path == to.Path && from.Namespace == to.Namespace
+
+	if err := c.read(client, to); err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	// When comparing versions of a single key, default to the version before
+	// the one being compared against.
+	if sameKey && from.Version == 0 {
+		if to.Version == 0 {
+			c.UI.Error(fmt.Sprintf("%s is not a KV v2 secret; specify a second key to compare", to.Path))
+			return 1
+		}
+		if to.Version == 1 {
+			c.UI.Error(fmt.Sprintf("No version before version 1 of %s to compare with", to.Path))
+			return 1
+		}
+		from.Version = to.Version - 1
+	}
+
+	if err := c.read(client, from); err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	changes := diffData(from.data, to.data)
+	if c.flagMaskValues {
+		for i := range changes {
+			changes[i].From = nil
+			changes[i].To = nil
+		}
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, map[string]interface{}{
+			"from":    from,
+			"to":      to,
+			"changes": changes,
+		})
+	}
+
+	c.UI.Output(fmt.Sprintf("--- %s", from))
+	c.UI.Output(fmt.Sprintf("+++ %s", to))
+	if len(changes) == 0 {
+		c.UI.Output("No differences")
+		return 0
+	}
+
+	outputDataDiff(c.UI, from.data, to.data, c.flagMaskValues)
+	return 0
+}
+
+// read reads the data of the given side of the comparison, setting its
+// version to the version that was read if the key is in a KV v2 mount.
+func (c *KVDiffCommand) read(client *api.Client, side *kvDiffSide) error {
+	client, err := clientForNamespace(client, side.Namespace)
+	if err != nil {
+		return err
+	}
+
+	mountPath, v2, err := isKVv2(side.Path, client)
+	if err != nil {
+		return err
+	}
+
+	path := side.Path
+	var versionParam map[string]string
+	if v2 {
+		path = addPrefixToKVPath(path, mountPath, "data")
+		if side.Version > 0 {
+			versionParam = map[string]string{
+				"version": fmt.Sprintf("%d", side.Version),
+			}
+		}
+	} else if side.Version > 0 {
+		return fmt.Errorf("K/V engine mount must be version 2 to compare versions of %s", side.Path)
+	}
+
+	secret, err := kvReadRequest(client, path, versionParam)
+	if err != nil {
+		return fmt.Errorf("Error reading %s: %s", side, err)
+	}
+	if secret == nil {
+		return fmt.Errorf("No value found at %s", side)
+	}
+
+	side.data = kvSecretData(secret, v2)
+	if v2 && side.Version == 0 {
+		if meta, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+			if v, ok := meta["version"].(json.Number); ok {
+				version, err := v.Int64()
+				if err == nil {
+					side.Version = int(version)
+				}
+			}
+		}
+	}
+
+	return nil
+}
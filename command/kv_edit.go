@@ -0,0 +1,194 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVEditCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVEditCommand)(nil)
+)
+
+type KVEditCommand struct {
+	*BaseCommand
+
+	flagEditFormat string
+
+	testEditor editorFunc // for tests
+}
+
+func (c *KVEditCommand) Synopsis() string {
+	return "Edits data in the KV store with an editor"
+}
+
+func (c *KVEditCommand) Help() string {
+	helpText := `
+Usage: vault kv edit [options] KEY
+
+  Opens the current data of the key in the editor set with the VISUAL or EDITOR
+  environment variable, and writes the data back once the editor is closed. If
+  the key does not exist, it is created. Nothing is written if the data was not
+  changed.
+
+      $ vault kv edit secret/foo
+
+  Edit the data as YAML:
+
+      $ vault kv edit -edit-format=yaml secret/foo
+
+  The data is edited in a temporary file only readable by the current user,
+  which is removed once the editor is closed. For KV v2 keys, the data is
+  written with the version that was edited as check-and-set parameter, so the
+  write fails if the key was changed in the meantime. For KV v1 keys, the
+  key is read again before writing and the write is cancelled if the data
+  changed.
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVEditCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	addEditFormatFlag(f, &c.flagEditFormat)
+
+	return set
+}
+
+func (c *KVEditCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVEditCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVEditCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	editor := c.testEditor
+	if editor == nil {
+		editor = runEditor
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	path := sanitizePath(args[0])
+	mountPath, v2, err := isKVv2(path, client)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	var current map[string]interface{}
+	var version int
+	if v2 {
+		path = addPrefixToKVPath(path, mountPath, "data")
+		current, version, err = kvReadCurrent(client, path)
+	} else {
+		current, err = c.readV1(client, path)
+	}
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading %s: %s", path, err), err)
+		return 2
+	}
+
+	data, err := editData(current, c.flagEditFormat, editor)
+	switch {
+	case err == errEditUnchanged:
+		c.UI.Info(fmt.Sprintf("No changes made to %s", path))
+		return 0
+	case err != nil:
+		outputError(c.UI, fmt.Sprintf("Error editing %s: %s", path, err), err)
+		return 1
+	}
+
+	var secret *api.Secret
+	if v2 {
+		secret, err = client.Logical().Write(path, map[string]interface{}{
+			"data": data,
+			"options": map[string]interface{}{
+				"cas": version,
+			},
+		})
+		if isKVCASMismatch(err) {
+			c.UI.Error(fmt.Sprintf("%s was changed while it was being edited, nothing was written. Run the command again to edit the new version.", path))
+			return 2
+		}
+	} else {
+		// KV v1 has no check-and-set, so check that the data did not change
+		// right before writing
+		latest, readErr := c.readV1(client, path)
+		switch {
+		case readErr != nil:
+			err = readErr
+		case !reflect.DeepEqual(latest, current):
+			c.UI.Error(fmt.Sprintf("%s was changed while it was being edited, nothing was written. Run the command again to edit the new data.", path))
+			return 2
+		default:
+			secret, err = client.Logical().Write(path, data)
+		}
+	}
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
+		if secret != nil {
+			OutputSecret(c.UI, secret)
+		}
+		return 2
+	}
+	if secret == nil {
+		// Don't output anything unless using the "table" format
+		if Format(c.UI) == "table" {
+			c.UI.Info(fmt.Sprintf("Success! Data written to: %s", path))
+		}
+		return 0
+	}
+
+	if c.flagField != "" {
+		return PrintRawField(c.UI, secret, c.flagField)
+	}
+
+	return OutputSecret(c.UI, secret)
+}
+
+// readV1 reads the data of a KV v1 key, which is nil if it doesn't exist.
+func (c *KVEditCommand) readV1(client *api.Client, path string) (map[string]interface{}, error) {
+	secret, err := kvReadRequest(client, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, nil
+	}
+	return secret.Data, nil
+}
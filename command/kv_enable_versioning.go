@@ -82,7 +82,7 @@ func (c *KVEnableVersioningCommand) Run(args []string) int {
 			"version": "2",
 		},
 	}); err != nil {
-		c.UI.Error(fmt.Sprintf("Error tuning secrets engine %s: %s", mountPath, err))
+		outputError(c.UI, fmt.Sprintf("Error tuning secrets engine %s: %s", mountPath, err), err)
 		return 2
 	}
 
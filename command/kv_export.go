@@ -0,0 +1,310 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVExportCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVExportCommand)(nil)
+)
+
+// kvExportMetadataFields are the metadata fields which are exported and can
+// be written back when importing.
+var kvExportMetadataFields = []string{
+	"max_versions",
+	"cas_required",
+	"delete_version_after",
+	"custom_metadata",
+}
+
+// kvExportDocument is the document produced by "kv export" and read by
+// "kv import". Secrets are keyed by their path relative to the exported
+// prefix, so that they can be imported under a different prefix.
+type kvExportDocument struct {
+	Prefix  string                     `json:"prefix"`
+	Secrets map[string]*kvExportSecret `json:"secrets"`
+}
+
+type kvExportSecret struct {
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Versions []*kvExportVersion     `json:"versions,omitempty"`
+}
+
+type kvExportVersion struct {
+	Version   int                    `json:"version"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Deleted   bool                   `json:"deleted,omitempty"`
+	Destroyed bool                   `json:"destroyed,omitempty"`
+}
+
+type KVExportCommand struct {
+	*BaseCommand
+
+	flagMetadata    bool
+	flagVersions    bool
+	flagConcurrency int
+}
+
+func (c *KVExportCommand) Synopsis() string {
+	return "Exports all secrets under a prefix"
+}
+
+func (c *KVExportCommand) Help() string {
+	helpText := `
+Usage: vault kv export [options] PREFIX
+
+  Reads every secret under the given prefix of a key-value store, including
+  those in sub-folders, and prints them as a single document which can be
+  restored with "vault kv import".
+
+  Export all secrets under "secret/app" to a file:
+
+      $ vault kv export secret/app > app.json
+
+  Export the secrets as YAML together with their metadata and all of their
+  versions:
+
+      $ vault kv export -format=yaml -metadata -versions secret/app
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVExportCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "metadata",
+		Target:  &c.flagMetadata,
+		Default: false,
+		Usage:   `Include the settings and custom metadata of each secret. This is only supported for KV v2 engine mounts.`,
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "versions",
+		Target:  &c.flagVersions,
+		Default: false,
+		Usage:   `Include the data of every version of each secret rather than just the current version. This is only supported for KV v2 engine mounts.`,
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "concurrency",
+		Target:  &c.flagConcurrency,
+		Default: defaultListConcurrency,
+		Usage:   "Number of folders to list and secrets to read at once.",
+	})
+
+	return set
+}
+
+func (c *KVExportCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVExportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVExportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	prefix := ensureTrailingSlash(sanitizePath(args[0]))
+	mountPath, v2, err := isKVv2(prefix, client)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	if !v2 && (c.flagMetadata || c.flagVersions) {
+		c.UI.Error("K/V engine mount must be version 2 to export metadata or versions")
+		return 1
+	}
+
+	keys, err := kvListRecursive(c.UI, client, mountPath, v2, prefix, c.flagConcurrency)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	if len(keys) == 0 {
+		c.UI.Error(fmt.Sprintf("No secrets found under %s", prefix))
+		return 2
+	}
+
+	doc := &kvExportDocument{
+		Prefix:  prefix,
+		Secrets: make(map[string]*kvExportSecret, len(keys)),
+	}
+	var lock sync.Mutex
+	progress := newProgress(c.UI, "Exporting keys", len(keys))
+	err = runConcurrently(keys, c.flagConcurrency, func(key string) error {
+		defer progress.Add(1)
+
+		secret, err := kvExportKey(client, mountPath, v2, key, c.flagMetadata, c.flagVersions)
+		if err != nil {
+			return err
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		doc.Secrets[strings.TrimPrefix(key, prefix)] = secret
+		return nil
+	})
+	progress.Stop()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	// A table does not make sense for a document meant to be imported again.
+	format := Format(c.UI)
+	if format == "table" {
+		format = "json"
+	}
+	formatter, ok := Formatters[format]
+	if !ok {
+		c.UI.Error(fmt.Sprintf("Invalid output format: %s", format))
+		return 1
+	}
+	out, err := formatter.Format(doc)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error formatting output: %s", err), err)
+		return 1
+	}
+	c.UI.Output(strings.TrimSpace(string(out)))
+
+	return 0
+}
+
+// kvExportKey reads the secret at the given key, optionally including its
+// metadata and the data of all of its versions.
+func kvExportKey(client *api.Client, mountPath string, v2 bool, key string, withMetadata, withVersions bool) (*kvExportSecret, error) {
+	if !v2 {
+		secret, err := client.Logical().Read(key)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading %s: %s", key, err)
+		}
+		return &kvExportSecret{
+			Data: kvSecretData(secret, false),
+		}, nil
+	}
+
+	dataPath := addPrefixToKVPath(key, mountPath, "data")
+	secret, err := kvReadRequest(client, dataPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", dataPath, err)
+	}
+	out := &kvExportSecret{
+		Data: kvSecretData(secret, true),
+	}
+
+	if !withMetadata && !withVersions {
+		return out, nil
+	}
+
+	metadataPath := addPrefixToKVPath(key, mountPath, "metadata")
+	metadata, err := client.Logical().Read(metadataPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", metadataPath, err)
+	}
+	if metadata == nil {
+		return out, nil
+	}
+
+	if withMetadata {
+		out.Metadata = make(map[string]interface{}, len(kvExportMetadataFields))
+		for _, field := range kvExportMetadataFields {
+			if v, ok := metadata.Data[field]; ok && v != nil {
+				out.Metadata[field] = v
+			}
+		}
+	}
+
+	if withVersions {
+		versions, _ := metadata.Data["versions"].(map[string]interface{})
+		for raw, info := range versions {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				continue
+			}
+			version := &kvExportVersion{Version: n}
+			if info, ok := info.(map[string]interface{}); ok {
+				version.Destroyed, _ = info["destroyed"].(bool)
+				deletionTime, _ := info["deletion_time"].(string)
+				version.Deleted = deletionTime != ""
+			}
+
+			if !version.Deleted && !version.Destroyed {
+				secret, err := kvReadRequest(client, dataPath, map[string]string{
+					"version": raw,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("Error reading version %d of %s: %s", n, dataPath, err)
+				}
+				version.Data = kvSecretData(secret, true)
+			}
+			out.Versions = append(out.Versions, version)
+		}
+		sort.Slice(out.Versions, func(i, j int) bool {
+			return out.Versions[i].Version < out.Versions[j].Version
+		})
+	}
+
+	return out, nil
+}
+
+// parseKVExportDocument parses a document produced by "kv export". YAML is
+// accepted as well as JSON.
+func parseKVExportDocument(b []byte) (*kvExportDocument, error) {
+	b, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc kvExportDocument
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
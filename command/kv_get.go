@@ -3,7 +3,9 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -16,7 +18,12 @@ var (
 type KVGetCommand struct {
 	*BaseCommand
 
-	flagVersion int
+	flagVersion       int
+	flagWatch         bool
+	flagWatchInterval time.Duration
+	flagWatchDiff     bool
+
+	testStopCh chan struct{} // for tests
 }
 
 func (c *KVGetCommand) Synopsis() string {
@@ -38,6 +45,11 @@ Usage: vault kv get [options] KEY
 
       $ vault kv get -version=1 secret/foo
 
+  To print the key name again each time a new version is written, specify the
+  "-watch" flag:
+
+      $ vault kv get -watch -watch-diff secret/foo
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -57,6 +69,32 @@ func (c *KVGetCommand) Flags() *FlagSets {
 		Usage:   `If passed, the value at the version number will be returned.`,
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "watch",
+		Target:  &c.flagWatch,
+		Default: false,
+		Usage: "Keep reading the key on an interval and print it each time " +
+			"its data changes, until interrupted.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "watch-interval",
+		Target:     &c.flagWatchInterval,
+		Default:    defaultWatchInterval,
+		Completion: complete.PredictAnything,
+		Usage:      "How often to read the key when -watch is set.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "watch-diff",
+		Target:  &c.flagWatchDiff,
+		Default: false,
+		Usage: "When used with -watch, print the keys which were added, " +
+			"removed, or changed instead of the whole secret.",
+	})
+
+	c.addCacheTTLFlag(f)
+
 	return set
 }
 
@@ -93,6 +131,25 @@ func (c *KVGetCommand) Run(args []string) int {
 	}
 
 	path := sanitizePath(args[0])
+
+	// Watching always reads from Vault
+	var cache *readCache
+	if !c.flagWatch {
+		cache, err = c.readCache(client)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error setting up the read cache: %s", err), err)
+			return 2
+		}
+	}
+
+	// The version of the mount is cached with the secret, so that a cached
+	// read sends no request at all
+	cacheID := fmt.Sprintf("kv get|%s|%d", path, c.flagVersion)
+	var cached kvGetCacheEntry
+	if cache != nil && cache.get(cacheID, &cached) {
+		return c.output(cached.Secret, cached.Path, cached.V2)
+	}
+
 	mountPath, v2, err := isKVv2(path, client)
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -111,9 +168,37 @@ func (c *KVGetCommand) Run(args []string) int {
 		}
 	}
 
+	if c.flagWatch {
+		stopCh := c.testStopCh
+		if stopCh == nil {
+			stopCh = MakeShutdownCh()
+		}
+
+		w := &secretWatcher{
+			UI:       c.UI,
+			Interval: c.flagWatchInterval,
+			Diff:     c.flagWatchDiff,
+			Fetch: func() (*api.Secret, error) {
+				secret, err := kvReadRequest(client, path, versionParam)
+				if err != nil {
+					return nil, fmt.Errorf("Error reading %s: %s", path, err)
+				}
+				return secret, nil
+			},
+			Data: func(secret *api.Secret) map[string]interface{} {
+				return kvSecretData(secret, v2)
+			},
+			Output: func(secret *api.Secret) int {
+				return c.output(secret, path, v2)
+			},
+			StopCh: stopCh,
+		}
+		return w.Run()
+	}
+
 	secret, err := kvReadRequest(client, path, versionParam)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error reading %s: %s", path, err), err)
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
@@ -124,6 +209,23 @@ func (c *KVGetCommand) Run(args []string) int {
 		return 2
 	}
 
+	if cache != nil && readCacheable(secret) {
+		if err := cache.put(cacheID, &kvGetCacheEntry{Path: path, V2: v2, Secret: secret}); err != nil {
+			c.UI.Warn(fmt.Sprintf("Failed to cache the response: %s", err))
+		}
+	}
+
+	return c.output(secret, path, v2)
+}
+
+// kvGetCacheEntry is a secret read by kv get, as it is cached.
+type kvGetCacheEntry struct {
+	Path   string      `json:"path"`
+	V2     bool        `json:"v2"`
+	Secret *api.Secret `json:"secret"`
+}
+
+func (c *KVGetCommand) output(secret *api.Secret, path string, v2 bool) int {
 	if c.flagField != "" {
 		if v2 {
 			// This is a v2, pass in the data field
@@ -160,19 +262,12 @@ func (c *KVGetCommand) Run(args []string) int {
 
 	if metadata, ok := secret.Data["metadata"]; ok && metadata != nil {
 		c.UI.Info(getHeaderForMap("Metadata", metadata.(map[string]interface{})))
-		OutputData(c.UI, metadata)
+		// The metadata holds no secrets, so it is not redacted
+		OutputData(unredactedUI(c.UI), metadata)
 		c.UI.Info("")
 	}
 
-	data := secret.Data
-	if v2 && data != nil {
-		data = nil
-		dataRaw := secret.Data["data"]
-		if dataRaw != nil {
-			data = dataRaw.(map[string]interface{})
-		}
-	}
-
+	data := kvSecretData(secret, v2)
 	if data != nil {
 		c.UI.Info(getHeaderForMap("Data", data))
 		OutputData(c.UI, data)
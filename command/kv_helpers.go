@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
 )
 
 func kvReadRequest(client *api.Client, path string, params map[string]string) (*api.Secret, error) {
@@ -157,11 +160,352 @@ func getHeaderForMap(header string, data map[string]interface{}) string {
 	return fmt.Sprintf("%s %s %s", strings.Repeat("=", equalSigns/2), header, strings.Repeat("=", equalSigns/2))
 }
 
-func kvParseVersionsFlags(versions []string) []string {
+// kvParseVersionsFlags flattens the values of a -versions flag, which may be
+// comma-separated, and expands ranges such as "3-9" into each version number
+// in the range.
+func kvParseVersionsFlags(versions []string) ([]string, error) {
 	versionsOut := make([]string, 0, len(versions))
 	for _, v := range versions {
-		versionsOut = append(versionsOut, strutil.ParseStringSlice(v, ",")...)
+		for _, version := range strutil.ParseStringSlice(v, ",") {
+			bounds := strings.SplitN(version, "-", 2)
+			if len(bounds) != 2 {
+				versionsOut = append(versionsOut, version)
+				continue
+			}
+
+			start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid version range %q", version)
+			}
+			end, err := strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid version range %q", version)
+			}
+			if start < 1 || end < start {
+				return nil, fmt.Errorf("invalid version range %q, must be of the form START-END with 0 < START <= END", version)
+			}
+			for n := start; n <= end; n++ {
+				versionsOut = append(versionsOut, strconv.Itoa(n))
+			}
+		}
+	}
+
+	return versionsOut, nil
+}
+
+// kvAllVersions returns every version number of the secret at the given path
+// of a KV v2 mount which is still known to its metadata, in ascending order.
+func kvAllVersions(client *api.Client, mountPath, path string) ([]string, error) {
+	metadataPath := addPrefixToKVPath(path, mountPath, "metadata")
+	secret, err := client.Logical().Read(metadataPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", metadataPath, err)
+	}
+	if secret == nil {
+		return nil, nil
+	}
+
+	raw, _ := secret.Data["versions"].(map[string]interface{})
+	numbers := make([]int, 0, len(raw))
+	for version := range raw {
+		n, err := strconv.Atoi(version)
+		if err != nil {
+			continue
+		}
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	versions := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		versions = append(versions, strconv.Itoa(n))
+	}
+	return versions, nil
+}
+
+// kvWriteVersions writes the given versions of the secret at path to the
+// apiPrefix endpoint of a KV v2 mount, e.g. "undelete" or "destroy". If all is
+// set, every version of the secret is used instead.
+func kvWriteVersions(client *api.Client, mountPath, path, apiPrefix string, versions []string, all bool) (*api.Secret, error) {
+	if all {
+		var err error
+		versions, err = kvAllVersions(client, mountPath, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, nil
+		}
+	}
+
+	return client.Logical().Write(addPrefixToKVPath(path, mountPath, apiPrefix), map[string]interface{}{
+		"versions": versions,
+	})
+}
+
+// kvSecretData returns the key/value data of a secret read from a KV mount.
+// For KV v2 this is the "data" field of the response, which is nil if the
+// version has been deleted.
+func kvSecretData(secret *api.Secret, v2 bool) map[string]interface{} {
+	if secret == nil {
+		return nil
+	}
+	if !v2 {
+		return secret.Data
+	}
+
+	data, _ := secret.Data["data"].(map[string]interface{})
+	return data
+}
+
+// kvListFolder returns a function which lists a folder of a KV mount, for
+// use with listRecursive.
+func kvListFolder(client *api.Client, mountPath string, v2 bool) func(folder string) ([]string, error) {
+	return func(folder string) ([]string, error) {
+		listPath := folder
+		if v2 {
+			listPath = addPrefixToKVPath(folder, mountPath, "metadata")
+		}
+		return listEntries(client, listPath)
+	}
+}
+
+// kvListRecursive returns the keys under the given prefix of a KV mount and
+// all of its sub-folders, sorted. The returned keys include the prefix. Up to
+// concurrency folders are listed at a time. The number of keys found so far is
+// shown on ui's terminal, if ui is set.
+func kvListRecursive(ui cli.Ui, client *api.Client, mountPath string, v2 bool, prefix string, concurrency int) ([]string, error) {
+	list := kvListFolder(client, mountPath, v2)
+	if ui != nil {
+		progress := newProgress(ui, "Listing keys", 0)
+		defer progress.Stop()
+		list = progress.countKeys(list)
+	}
+	return listRecursive(prefix, concurrency, list)
+}
+
+// kvRecursiveOptions are the options shared by commands which operate on every
+// key under a prefix.
+type kvRecursiveOptions struct {
+	Recursive   bool
+	Force       bool
+	DryRun      bool
+	Concurrency int
+}
+
+// addKVRecursiveFlags adds the flags which set opts to f. action describes
+// what the command does to each key, e.g. "delete".
+func addKVRecursiveFlags(f *FlagSet, opts *kvRecursiveOptions, action string) {
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Aliases: []string{"r"},
+		Target:  &opts.Recursive,
+		Default: false,
+		Usage: fmt.Sprintf("Treat the path as a prefix and %s every key under it, "+
+			"including those in sub-folders. The keys are listed and must be "+
+			"confirmed unless -force is set.", action),
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "force",
+		Aliases: []string{"f"},
+		Target:  &opts.Force,
+		Default: false,
+		Usage:   "Do not ask for confirmation when -recursive is set.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  &opts.DryRun,
+		Default: false,
+		Usage: "When -recursive is set, list the keys which would be " +
+			"affected without making any changes.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "concurrency",
+		Target:  &opts.Concurrency,
+		Default: defaultListConcurrency,
+		Usage:   "Number of requests to make at once when -recursive is set.",
+	})
+}
+
+// kvRunRecursive calls f for every key under prefix. The affected keys are
+// printed first and, unless opts.Force is set, the user is asked to confirm.
+// With opts.DryRun, nothing is changed. action describes what f does, e.g.
+// "deleted", and is used in the output.
+func kvRunRecursive(ui cli.Ui, client *api.Client, mountPath string, v2 bool, prefix, action string, opts kvRecursiveOptions, f func(key string) error) int {
+	keys, err := kvListRecursive(ui, client, mountPath, v2, prefix, opts.Concurrency)
+	if err != nil {
+		ui.Error(err.Error())
+		return 2
+	}
+	if len(keys) == 0 {
+		ui.Error(fmt.Sprintf("No keys found under %s", prefix))
+		return 2
+	}
+
+	ui.Output(fmt.Sprintf("The following %d keys will be %s:\n", len(keys), action))
+	for _, key := range keys {
+		ui.Output(fmt.Sprintf("  %s", key))
+	}
+	ui.Output("")
+
+	if opts.DryRun {
+		ui.Info("No changes made (dry run)")
+		return 0
 	}
 
-	return versionsOut
+	if !opts.Force {
+		ok, err := confirm(ui, "Do you want to continue?")
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error reading confirmation: %s", err))
+			return 1
+		}
+		if !ok {
+			ui.Info("Aborted")
+			return 1
+		}
+	}
+
+	progress := newProgress(ui, fmt.Sprintf("Keys %s", action), len(keys))
+	err = runConcurrently(keys, opts.Concurrency, func(key string) error {
+		defer progress.Add(1)
+		return f(key)
+	})
+	progress.Stop()
+	if err != nil {
+		ui.Error(err.Error())
+		return 2
+	}
+
+	ui.Info(fmt.Sprintf("Success! %d keys %s under: %s", len(keys), action, prefix))
+	return 0
+}
+
+// kvCASAuto is the value of the -cas flag which makes "kv put" and "kv patch"
+// read the current version of a secret and use it for check-and-set.
+const kvCASAuto = "auto"
+
+// defaultKVCASRetries is the default number of times a write with -cas=auto
+// is retried after a check-and-set conflict.
+const defaultKVCASRetries = 3
+
+// parseKVCASFlag parses the value of the -cas flag. An empty value returns
+// def.
+func parseKVCASFlag(value string, def int) (cas int, auto bool, err error) {
+	switch value {
+	case "":
+		return def, false, nil
+	case kvCASAuto:
+		return 0, true, nil
+	}
+
+	cas, err = strconv.Atoi(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("Invalid value %q for the cas flag, must be a version number or %q", value, kvCASAuto)
+	}
+	return cas, false, nil
+}
+
+// isKVCASMismatch returns true if err is the error returned by a KV v2 mount
+// when the cas parameter does not match the current version of a secret.
+func isKVCASMismatch(err error) bool {
+	re, ok := err.(*api.ResponseError)
+	if !ok || re.StatusCode != 400 {
+		return false
+	}
+	for _, e := range re.Errors {
+		if strings.Contains(e, "check-and-set parameter did not match") {
+			return true
+		}
+	}
+	return false
+}
+
+// kvReadCurrent reads the current data and version of the secret at the given
+// KV v2 data path. The version is 0 if the secret does not exist, and the
+// data is nil if the current version has been deleted.
+func kvReadCurrent(client *api.Client, dataPath string) (map[string]interface{}, int, error) {
+	// Note that we don't want to see curl output for the read request.
+	curOutputCurl := client.OutputCurlString()
+	client.SetOutputCurlString(false)
+	secret, err := kvReadRequest(client, dataPath, nil)
+	client.SetOutputCurlString(curOutputCurl)
+	if err != nil {
+		return nil, 0, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, 0, nil
+	}
+
+	var version int
+	if meta, ok := secret.Data["metadata"].(map[string]interface{}); ok && meta["version"] != nil {
+		version, err = strconv.Atoi(fmt.Sprint(meta["version"]))
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid version in metadata at %s: %w", dataPath, err)
+		}
+	}
+	return kvSecretData(secret, true), version, nil
+}
+
+// kvWriteCASAuto writes to the given KV v2 data path using the current version
+// of the secret as the cas parameter. update is called with the current data,
+// which is nil if there is none, and returns the data to write. If another
+// client writes the secret in the meantime, the read, update and write are
+// retried up to retries times.
+func kvWriteCASAuto(client *api.Client, dataPath string, retries int, update func(current map[string]interface{}) (map[string]interface{}, error)) (*api.Secret, error) {
+	for attempt := 0; ; attempt++ {
+		current, version, err := kvReadCurrent(client, dataPath)
+		if err != nil {
+			return nil, fmt.Errorf("Error doing pre-read at %s: %w", dataPath, err)
+		}
+
+		data, err := update(current)
+		if err != nil {
+			return nil, err
+		}
+
+		secret, err := client.Logical().Write(dataPath, map[string]interface{}{
+			"data": data,
+			"options": map[string]interface{}{
+				"cas": version,
+			},
+		})
+		if err == nil {
+			return secret, nil
+		}
+		if !isKVCASMismatch(err) || attempt >= retries {
+			return secret, fmt.Errorf("Error writing data to %s: %w", dataPath, err)
+		}
+	}
+}
+
+// kvUpdateCustomMetadata reads the custom metadata of the key at the given
+// KV v2 metadata path, calls update to modify it, and writes back only the
+// custom metadata, so that the other settings of the key are left unchanged.
+func kvUpdateCustomMetadata(client *api.Client, metadataPath string, update func(custom map[string]interface{})) (*api.Secret, error) {
+	secret, err := client.Logical().Read(metadataPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", metadataPath, err)
+	}
+
+	custom := make(map[string]interface{})
+	if secret != nil {
+		if existing, ok := secret.Data["custom_metadata"].(map[string]interface{}); ok {
+			for k, v := range existing {
+				custom[k] = v
+			}
+		}
+	}
+
+	update(custom)
+
+	secret, err = client.Logical().Write(metadataPath, map[string]interface{}{
+		"custom_metadata": custom,
+	})
+	if err != nil {
+		return secret, fmt.Errorf("Error writing data to %s: %s", metadataPath, err)
+	}
+	return secret, nil
 }
@@ -0,0 +1,328 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+	"github.com/ryanuber/columnize"
+)
+
+var (
+	_ cli.Command             = (*KVHistoryCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVHistoryCommand)(nil)
+)
+
+type KVHistoryCommand struct {
+	*BaseCommand
+
+	flagAuditLogs []string
+}
+
+// kvHistoryVersion is a version of a key, as listed by vault kv history.
+type kvHistoryVersion struct {
+	Version      int             `json:"version"`
+	CreatedTime  string          `json:"created_time"`
+	DeletionTime string          `json:"deletion_time"`
+	Destroyed    bool            `json:"destroyed"`
+	State        string          `json:"state"`
+	CreatedBy    *kvHistoryActor `json:"created_by,omitempty"`
+}
+
+// kvHistoryActor is who wrote a version, as found in the audit log.
+type kvHistoryActor struct {
+	DisplayName string `json:"display_name,omitempty"`
+	EntityID    string `json:"entity_id,omitempty"`
+}
+
+func (a *kvHistoryActor) String() string {
+	switch {
+	case a == nil:
+		return "n/a"
+	case a.DisplayName == "":
+		return a.EntityID
+	case a.EntityID == "":
+		return a.DisplayName
+	default:
+		return fmt.Sprintf("%s (%s)", a.DisplayName, a.EntityID)
+	}
+}
+
+func (c *KVHistoryCommand) Synopsis() string {
+	return "Lists the versions of a key in the KV store"
+}
+
+func (c *KVHistoryCommand) Help() string {
+	helpText := `
+Usage: vault kv history [options] KEY
+
+  *NOTE*: This is only supported for KV v2 engine mounts.
+
+  Lists all the versions of a key with their creation time, deletion time and
+  state, followed by the custom metadata of the key.
+
+      $ vault kv history secret/foo
+
+  Vault does not record who wrote each version. To show it, pass the audit logs
+  covering the writes; the version numbers of the responses are matched with
+  the versions of the key:
+
+      $ vault kv history -audit-log=/var/log/vault/audit.log secret/foo
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVHistoryCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "audit-log",
+		Target:     &c.flagAuditLogs,
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to a JSON audit log to look up who wrote each version in. " +
+			"This can be specified multiple times.",
+	})
+
+	return set
+}
+
+func (c *KVHistoryCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVHistoryCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVHistoryCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	path := sanitizePath(args[0])
+	mountPath, v2, err := isKVv2(path, client)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	if !v2 {
+		c.UI.Error("History not supported on KV Version 1")
+		return 1
+	}
+
+	metadataPath := addPrefixToKVPath(path, mountPath, "metadata")
+	secret, err := client.Logical().Read(metadataPath)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading %s: %s", metadataPath, err), err)
+		return 2
+	}
+	if secret == nil || secret.Data == nil {
+		c.UI.Error(fmt.Sprintf("No value found at %s", metadataPath))
+		return 2
+	}
+
+	versionsRaw, _ := secret.Data["versions"].(map[string]interface{})
+	versions := make([]*kvHistoryVersion, 0, len(versionsRaw))
+	now := time.Now()
+	for k, raw := range versionsRaw {
+		i, err := strconv.Atoi(k)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error parsing version %s", k))
+			return 2
+		}
+		meta, _ := raw.(map[string]interface{})
+
+		v := &kvHistoryVersion{Version: i}
+		v.CreatedTime, _ = meta["created_time"].(string)
+		v.DeletionTime, _ = meta["deletion_time"].(string)
+		v.Destroyed, _ = meta["destroyed"].(bool)
+
+		// Versions can be deleted in the future with delete_version_after
+		deleted := false
+		if v.DeletionTime != "" {
+			if t, err := time.Parse(time.RFC3339Nano, v.DeletionTime); err == nil {
+				deleted = !t.After(now)
+			}
+		}
+		switch {
+		case v.Destroyed:
+			v.State = "destroyed"
+		case deleted:
+			v.State = "deleted"
+		default:
+			v.State = "active"
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+
+	if len(c.flagAuditLogs) > 0 {
+		ns := namespace.Canonicalize(client.Headers().Get(consts.NamespaceHeaderName))
+		actors, err := kvHistoryActors(c.flagAuditLogs, ns, addPrefixToKVPath(path, mountPath, "data"))
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		for _, v := range versions {
+			v.CreatedBy = actors[v.Version]
+		}
+	}
+
+	custom, _ := secret.Data["custom_metadata"].(map[string]interface{})
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, map[string]interface{}{
+			"current_version": secret.Data["current_version"],
+			"custom_metadata": custom,
+			"versions":        versions,
+		})
+	}
+
+	if len(versions) == 0 {
+		c.UI.Error(fmt.Sprintf("No versions found at %s", metadataPath))
+		return 2
+	}
+
+	currentVersion := fmt.Sprintf("%v", secret.Data["current_version"])
+	header := "Version | Created Time | Deletion Time | State"
+	if len(c.flagAuditLogs) > 0 {
+		header += " | Created By"
+	}
+	table := []string{header}
+	for _, v := range versions {
+		version := strconv.Itoa(v.Version)
+		if version == currentVersion {
+			version += " (current)"
+		}
+		deletionTime := v.DeletionTime
+		if deletionTime == "" {
+			deletionTime = "n/a"
+		}
+		row := fmt.Sprintf("%s | %s | %s | %s", version, v.CreatedTime, deletionTime, v.State)
+		if len(c.flagAuditLogs) > 0 {
+			row += " | " + v.CreatedBy.String()
+		}
+		table = append(table, row)
+	}
+	c.UI.Output(tableOutput(table, columnize.DefaultConfig()))
+
+	if len(custom) > 0 {
+		c.UI.Info("\n" + getHeaderForMap("Custom Metadata", custom))
+		OutputData(unredactedUI(c.UI), custom)
+	}
+
+	return 0
+}
+
+// kvHistoryActors returns who wrote each version of the key at dataPath in
+// namespace ns, according to the responses to writes in the given audit logs.
+// The version numbers of the responses are not hashed by audit devices, so
+// they can be matched with the versions of the key.
+func kvHistoryActors(files []string, ns, dataPath string) (map[int]*kvHistoryActor, error) {
+	actors := map[int]*kvHistoryActor{}
+	for _, file := range files {
+		path, err := homedir.Expand(strings.TrimSpace(file))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to expand path: %s", err)
+		}
+		if err := kvHistoryReadAuditLog(path, ns, dataPath, actors); err != nil {
+			return nil, err
+		}
+	}
+	return actors, nil
+}
+
+func kvHistoryReadAuditLog(path, ns, dataPath string, actors map[int]*kvHistoryActor) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Error opening audit log: %s", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		// Skip anything before the JSON object, such as the prefix the audit
+		// device may be configured with.
+		line := scanner.Bytes()
+		i := bytes.IndexByte(line, '{')
+		if i < 0 {
+			continue
+		}
+
+		var entry audit.AuditResponseEntry
+		if err := json.Unmarshal(line[i:], &entry); err != nil {
+			continue
+		}
+		if entry.Type != "response" || entry.Request == nil || entry.Response == nil || entry.Error != "" {
+			continue
+		}
+		switch entry.Request.Operation {
+		case logical.CreateOperation, logical.UpdateOperation, logical.PatchOperation:
+		default:
+			continue
+		}
+		entryNS := ""
+		if entry.Request.Namespace != nil {
+			entryNS = entry.Request.Namespace.Path
+		}
+		if entryNS != ns || entry.Request.Path != dataPath {
+			continue
+		}
+
+		version, ok := entry.Response.Data["version"].(float64)
+		if !ok {
+			continue
+		}
+		actor := &kvHistoryActor{}
+		if entry.Auth != nil {
+			actor.DisplayName = entry.Auth.DisplayName
+			actor.EntityID = entry.Auth.EntityID
+		}
+		actors[int(version)] = actor
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Error reading audit log: %s", err)
+	}
+	return nil
+}
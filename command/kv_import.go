@@ -0,0 +1,227 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVImportCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVImportCommand)(nil)
+)
+
+type KVImportCommand struct {
+	*BaseCommand
+
+	flagConcurrency int
+
+	testStdin io.Reader // for tests
+}
+
+func (c *KVImportCommand) Synopsis() string {
+	return "Imports secrets exported with \"kv export\""
+}
+
+func (c *KVImportCommand) Help() string {
+	helpText := `
+Usage: vault kv import [options] PREFIX [FILE]
+
+  Writes the secrets in a document produced by "vault kv export" under the
+  given prefix. The document is read from FILE, or from stdin if FILE is
+  omitted or "-". The prefix may be in a different mount, namespace, or
+  cluster than the one the secrets were exported from.
+
+  Restore secrets exported to a file under "secret/app":
+
+      $ vault kv import secret/app app.json
+
+  Copy all secrets under "secret/app" to another namespace:
+
+      $ vault kv export secret/app | vault kv import -namespace=ns1 secret/app
+
+  If the document includes metadata, it is written before the data of each
+  secret. If it includes versions, each version which was not deleted or
+  destroyed is written in order, so the restored secret has the same history
+  but possibly different version numbers.
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVImportCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	f.IntVar(&IntVar{
+		Name:    "concurrency",
+		Target:  &c.flagConcurrency,
+		Default: defaultListConcurrency,
+		Usage: "Number of secrets to write at once. The versions of each " +
+			"secret are always written in order.",
+	})
+
+	return set
+}
+
+func (c *KVImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *KVImportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVImportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1-2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1-2, got %d)", len(args)))
+		return 1
+	}
+
+	var r io.Reader = os.Stdin
+	if c.testStdin != nil {
+		r = c.testStdin
+	}
+	if len(args) == 2 && args[1] != "-" {
+		file, err := os.Open(args[1])
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error opening file: %s", err), err)
+			return 1
+		}
+		defer file.Close()
+		r = file
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading document: %s", err), err)
+		return 1
+	}
+	doc, err := parseKVExportDocument(b)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing document: %s", err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	prefix := ensureTrailingSlash(sanitizePath(args[0]))
+	mountPath, v2, err := isKVv2(prefix, client)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	keys := make([]string, 0, len(doc.Secrets))
+	for key := range doc.Secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// The errors are printed once the progress is no longer shown
+	var errs []error
+	var lock sync.Mutex
+	progress := newProgress(c.UI, "Importing keys", len(keys))
+	runConcurrently(keys, c.flagConcurrency, func(key string) error {
+		defer progress.Add(1)
+
+		path := prefix + ensureNoLeadingSlash(key)
+		if err := kvImportKey(client, mountPath, v2, path, doc.Secrets[key]); err != nil {
+			lock.Lock()
+			errs = append(errs, err)
+			lock.Unlock()
+		}
+		return nil
+	})
+	progress.Stop()
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Error() < errs[j].Error()
+		})
+		for _, err := range errs {
+			c.UI.Error(err.Error())
+		}
+		c.UI.Error(fmt.Sprintf("Failed to import %d of %d secrets", len(errs), len(keys)))
+		return 2
+	}
+
+	c.UI.Info(fmt.Sprintf("Success! Imported %d secrets under: %s", len(keys), prefix))
+	return 0
+}
+
+// kvImportKey writes a single exported secret to path.
+func kvImportKey(client *api.Client, mountPath string, v2 bool, path string, secret *kvExportSecret) error {
+	if secret == nil {
+		return nil
+	}
+
+	if !v2 {
+		if len(secret.Metadata) > 0 || len(secret.Versions) > 0 {
+			return fmt.Errorf("K/V engine mount must be version 2 to import metadata or versions of %s", path)
+		}
+		if secret.Data == nil {
+			return nil
+		}
+		if _, err := client.Logical().Write(path, secret.Data); err != nil {
+			return fmt.Errorf("Error writing data to %s: %s", path, err)
+		}
+		return nil
+	}
+
+	if len(secret.Metadata) > 0 {
+		metadataPath := addPrefixToKVPath(path, mountPath, "metadata")
+		if _, err := client.Logical().Write(metadataPath, secret.Metadata); err != nil {
+			return fmt.Errorf("Error writing metadata to %s: %s", metadataPath, err)
+		}
+	}
+
+	var versions []map[string]interface{}
+	for _, version := range secret.Versions {
+		if version != nil && version.Data != nil {
+			versions = append(versions, version.Data)
+		}
+	}
+	if len(versions) == 0 && secret.Data != nil {
+		versions = append(versions, secret.Data)
+	}
+
+	dataPath := addPrefixToKVPath(path, mountPath, "data")
+	for _, data := range versions {
+		if _, err := client.Logical().Write(dataPath, map[string]interface{}{
+			"data": data,
+		}); err != nil {
+			return fmt.Errorf("Error writing data to %s: %s", dataPath, err)
+		}
+	}
+
+	return nil
+}
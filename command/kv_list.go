@@ -15,6 +15,11 @@ var (
 
 type KVListCommand struct {
 	*BaseCommand
+
+	flagRecursive   bool
+	flagFilter      string
+	flagFilterRegex string
+	flagConcurrency int
 }
 
 func (c *KVListCommand) Synopsis() string {
@@ -32,6 +37,11 @@ Usage: vault kv list [options] PATH
 
       $ vault kv list secret/my-app/
 
+  List the full path of every key below the "my-app" folder, including those
+  in sub-folders:
+
+      $ vault kv list -r secret/my-app/
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -40,7 +50,49 @@ Usage: vault kv list [options] PATH
 }
 
 func (c *KVListCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	f.BoolVar(&BoolVar{
+		Name:       "recursive",
+		Aliases:    []string{"r"},
+		Target:     &c.flagRecursive,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage: "List the path and all of its sub-folders, printing the full " +
+			"path of every entry which is not a folder.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "filter",
+		Target:     &c.flagFilter,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "When listing recursively, only print paths matching this glob " +
+			"pattern. The pattern is matched against both the full path and " +
+			"its last segment.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "filter-regex",
+		Target:     &c.flagFilterRegex,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "When listing recursively, only print paths matching this " +
+			"regular expression.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:       "concurrency",
+		Target:     &c.flagConcurrency,
+		Default:    defaultListConcurrency,
+		Completion: complete.PredictAnything,
+		Usage:      "Number of folders to list at once when listing recursively.",
+	})
+
+	return set
 }
 
 func (c *KVListCommand) AutocompleteArgs() complete.Predictor {
@@ -89,6 +141,10 @@ func (c *KVListCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagRecursive {
+		return outputListRecursive(c.UI, path, c.flagConcurrency, c.flagFilter, c.flagFilterRegex, kvListFolder(client, mountPath, v2))
+	}
+
 	if v2 {
 		path = addPrefixToKVPath(path, mountPath, "metadata")
 		if err != nil {
@@ -99,7 +155,7 @@ func (c *KVListCommand) Run(args []string) int {
 
 	secret, err := client.Logical().List(path)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error listing %s: %s", path, err), err)
 		return 2
 	}
 
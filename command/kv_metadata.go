@@ -33,6 +33,11 @@ Usage: vault kv metadata <subcommand> [options] [args]
 
       $ vault kv metadata get secret/foo
 
+  Set or remove individual custom metadata keys, leaving the rest unchanged:
+
+      $ vault kv metadata set-custom secret/foo owner=alice
+      $ vault kv metadata delete-custom secret/foo owner
+
   Delete a key and all existing versions:
 
       $ vault kv metadata delete secret/foo
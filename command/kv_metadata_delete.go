@@ -15,6 +15,8 @@ var (
 
 type KVMetadataDeleteCommand struct {
 	*BaseCommand
+
+	flagRecursiveOpts kvRecursiveOptions
 }
 
 func (c *KVMetadataDeleteCommand) Synopsis() string {
@@ -29,6 +31,10 @@ Usage: vault kv metadata delete [options] PATH
 
       $ vault kv metadata delete secret/foo
 
+  To see which keys would be permanently deleted under a prefix:
+
+      $ vault kv metadata delete -recursive -dry-run secret/old-app
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -37,7 +43,14 @@ Usage: vault kv metadata delete [options] PATH
 }
 
 func (c *KVMetadataDeleteCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP)
+	set := c.flagSet(FlagSetHTTP)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+
+	addKVRecursiveFlags(f, &c.flagRecursiveOpts, "permanently delete")
+
+	return set
 }
 
 func (c *KVMetadataDeleteCommand) AutocompleteArgs() complete.Predictor {
@@ -83,9 +96,19 @@ func (c *KVMetadataDeleteCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagRecursiveOpts.Recursive {
+		return kvRunRecursive(c.UI, client, mountPath, v2, ensureTrailingSlash(path), "permanently deleted", c.flagRecursiveOpts, func(key string) error {
+			metadataPath := addPrefixToKVPath(key, mountPath, "metadata")
+			if _, err := client.Logical().Delete(metadataPath); err != nil {
+				return fmt.Errorf("Error deleting %s: %s", metadataPath, err)
+			}
+			return nil
+		})
+	}
+
 	path = addPrefixToKVPath(path, mountPath, "metadata")
 	if secret, err := client.Logical().Delete(path); err != nil {
-		c.UI.Error(fmt.Sprintf("Error deleting %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error deleting %s: %s", path, err), err)
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
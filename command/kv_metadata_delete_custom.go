@@ -0,0 +1,111 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVMetadataDeleteCustomCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVMetadataDeleteCustomCommand)(nil)
+)
+
+type KVMetadataDeleteCustomCommand struct {
+	*BaseCommand
+}
+
+func (c *KVMetadataDeleteCustomCommand) Synopsis() string {
+	return "Deletes individual custom metadata keys in the KV store"
+}
+
+func (c *KVMetadataDeleteCustomCommand) Help() string {
+	helpText := `
+Usage: vault kv metadata delete-custom [options] KEY CUSTOM_KEY...
+
+  Removes the given custom metadata keys from a key in the key-value store.
+  Other custom metadata keys and the rest of the key's settings are left
+  unchanged. Custom metadata keys which are not set are ignored.
+
+  Remove the team custom metadata key:
+
+      $ vault kv metadata delete-custom secret/foo team
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVMetadataDeleteCustomCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+}
+
+func (c *KVMetadataDeleteCustomCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVMetadataDeleteCustomCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVMetadataDeleteCustomCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected >1, got %d)", len(args)))
+		return 1
+	case len(args) == 1:
+		c.UI.Error("Must supply custom metadata keys to delete")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	path := sanitizePath(args[0])
+	mountPath, v2, err := isKVv2(path, client)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	if !v2 {
+		c.UI.Error("Metadata not supported on KV Version 1")
+		return 1
+	}
+
+	path = addPrefixToKVPath(path, mountPath, "metadata")
+	secret, err := kvUpdateCustomMetadata(client, path, func(custom map[string]interface{}) {
+		for _, k := range args[1:] {
+			delete(custom, k)
+		}
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		if secret != nil {
+			OutputSecret(c.UI, secret)
+		}
+		return 2
+	}
+	if secret == nil {
+		// Don't output anything unless using the "table" format
+		if Format(c.UI) == "table" {
+			c.UI.Info(fmt.Sprintf("Success! Data written to: %s", path))
+		}
+		return 0
+	}
+
+	return OutputSecret(c.UI, secret)
+}
@@ -90,7 +90,7 @@ func (c *KVMetadataGetCommand) Run(args []string) int {
 	path = addPrefixToKVPath(path, mountPath, "metadata")
 	secret, err := client.Logical().Read(path)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error reading %s: %s", path, err), err)
 		return 2
 	}
 	if secret == nil {
@@ -117,8 +117,23 @@ func (c *KVMetadataGetCommand) Run(args []string) int {
 
 	delete(secret.Data, "versions")
 
+	// Custom metadata is shown in its own table rather than as a single map
+	// value in the metadata table.
+	custom, _ := secret.Data["custom_metadata"].(map[string]interface{})
+	if len(custom) > 0 {
+		delete(secret.Data, "custom_metadata")
+	}
+
+	// The metadata holds no secrets, so it is not redacted
+	ui := unredactedUI(c.UI)
+
 	c.UI.Info(getHeaderForMap("Metadata", secret.Data))
-	OutputSecret(c.UI, secret)
+	OutputSecret(ui, secret)
+
+	if len(custom) > 0 {
+		c.UI.Info("\n" + getHeaderForMap("Custom Metadata", custom))
+		OutputData(ui, custom)
+	}
 
 	versionKeys := []int{}
 	for k := range versions {
@@ -135,7 +150,7 @@ func (c *KVMetadataGetCommand) Run(args []string) int {
 
 	for _, v := range versionKeys {
 		c.UI.Info("\n" + getHeaderForMap(fmt.Sprintf("Version %d", v), versions[strconv.Itoa(v)].(map[string]interface{})))
-		OutputData(c.UI, versions[strconv.Itoa(v)])
+		OutputData(ui, versions[strconv.Itoa(v)])
 	}
 
 	return 0
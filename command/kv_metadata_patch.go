@@ -173,7 +173,7 @@ func (c *KVMetadataPatchCommand) Run(args []string) int {
 
 	secret, err := client.Logical().JSONMergePatch(context.Background(), path, data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
 
 		if secret != nil {
 			OutputSecret(c.UI, secret)
@@ -170,7 +170,7 @@ func (c *KVMetadataPutCommand) Run(args []string) int {
 
 	secret, err := client.Logical().Write(path, data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
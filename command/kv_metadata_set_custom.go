@@ -0,0 +1,136 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVMetadataSetCustomCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVMetadataSetCustomCommand)(nil)
+)
+
+type KVMetadataSetCustomCommand struct {
+	*BaseCommand
+
+	testStdin io.Reader // for tests
+}
+
+func (c *KVMetadataSetCustomCommand) Synopsis() string {
+	return "Sets individual custom metadata keys in the KV store"
+}
+
+func (c *KVMetadataSetCustomCommand) Help() string {
+	helpText := `
+Usage: vault kv metadata set-custom [options] KEY K=V...
+
+  Sets the given custom metadata keys on a key in the key-value store. Other
+  custom metadata keys and the rest of the key's settings are left unchanged,
+  unlike "vault kv metadata put -custom-metadata", which replaces all of the
+  custom metadata.
+
+  Set the owner and team of a key:
+
+      $ vault kv metadata set-custom secret/foo owner=alice team=payments
+
+  To remove custom metadata keys, see "vault kv metadata delete-custom".
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVMetadataSetCustomCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+}
+
+func (c *KVMetadataSetCustomCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVMetadataSetCustomCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVMetadataSetCustomCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected >1, got %d)", len(args)))
+		return 1
+	case len(args) == 1:
+		c.UI.Error("Must supply custom metadata")
+		return 1
+	}
+
+	// Pull our fake stdin if needed
+	stdin := (io.Reader)(os.Stdin)
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+
+	data, err := parseArgsData(stdin, args[1:])
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
+		return 1
+	}
+	for k, v := range data {
+		if _, ok := v.(string); !ok {
+			c.UI.Error(fmt.Sprintf("Custom metadata value for %q must be a string", k))
+			return 1
+		}
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	path := sanitizePath(args[0])
+	mountPath, v2, err := isKVv2(path, client)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	if !v2 {
+		c.UI.Error("Metadata not supported on KV Version 1")
+		return 1
+	}
+
+	path = addPrefixToKVPath(path, mountPath, "metadata")
+	secret, err := kvUpdateCustomMetadata(client, path, func(custom map[string]interface{}) {
+		for k, v := range data {
+			custom[k] = v
+		}
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		if secret != nil {
+			OutputSecret(c.UI, secret)
+		}
+		return 2
+	}
+	if secret == nil {
+		// Don't output anything unless using the "table" format
+		if Format(c.UI) == "table" {
+			c.UI.Info(fmt.Sprintf("Success! Data written to: %s", path))
+		}
+		return 0
+	}
+
+	return OutputSecret(c.UI, secret)
+}
@@ -0,0 +1,164 @@
+package command
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testKVMetadataSetCustomCommand(tb testing.TB) (*cli.MockUi, *KVMetadataSetCustomCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVMetadataSetCustomCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testKVMetadataDeleteCustomCommand(tb testing.TB) (*cli.MockUi, *KVMetadataDeleteCustomCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVMetadataDeleteCustomCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestKVMetadataSetCustomCommand(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	basePath := t.Name() + "/"
+	if err := client.Sys().Mount(basePath, &api.MountInput{
+		Type: "kv-v2",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	metaFullPath := basePath + "metadata/secret/my-secret"
+	if _, err := client.Logical().Write(metaFullPath, map[string]interface{}{
+		"max_versions": 5,
+		"custom_metadata": map[string]interface{}{
+			"owner": "alice",
+			"team":  "payments",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	readMetadata := func(t *testing.T) *api.Secret {
+		t.Helper()
+
+		secret, err := client.Logical().Read(metaFullPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return secret
+	}
+
+	ui, cmd := testKVMetadataSetCustomCommand(t)
+	cmd.client = client
+
+	code := cmd.Run([]string{basePath + "secret/my-secret", "owner=bob", "env=prod"})
+	if code != 0 {
+		t.Fatalf("expected %d but received %d: %s", 0, code, ui.ErrorWriter.String())
+	}
+
+	secret := readMetadata(t)
+	exp := map[string]interface{}{"owner": "bob", "team": "payments", "env": "prod"}
+	if custom := secret.Data["custom_metadata"]; !reflect.DeepEqual(custom, exp) {
+		t.Errorf("expected %#v to be %#v", custom, exp)
+	}
+	if maxVersions := secret.Data["max_versions"]; maxVersions.(json.Number).String() != "5" {
+		t.Errorf("expected max_versions to be unchanged, got %v", maxVersions)
+	}
+
+	deleteUI, deleteCmd := testKVMetadataDeleteCustomCommand(t)
+	deleteCmd.client = client
+
+	code = deleteCmd.Run([]string{basePath + "secret/my-secret", "team", "missing"})
+	if code != 0 {
+		t.Fatalf("expected %d but received %d: %s", 0, code, deleteUI.ErrorWriter.String())
+	}
+
+	exp = map[string]interface{}{"owner": "bob", "env": "prod"}
+	if custom := readMetadata(t).Data["custom_metadata"]; !reflect.DeepEqual(custom, exp) {
+		t.Errorf("expected %#v to be %#v", custom, exp)
+	}
+
+	getUI, getCmd := testKVMetadataGetCommand(t)
+	getCmd.client = client
+
+	code = getCmd.Run([]string{basePath + "secret/my-secret"})
+	if code != 0 {
+		t.Fatalf("expected %d but received %d: %s", 0, code, getUI.ErrorWriter.String())
+	}
+	out := getUI.OutputWriter.String()
+	if !strings.Contains(out, "Custom Metadata") || strings.Contains(out, "map[") {
+		t.Errorf("expected %q to show custom metadata in its own table", out)
+	}
+}
+
+func TestKVMetadataSetCustomCommand_Validation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		cmd  func() (*cli.MockUi, cli.Command)
+		args []string
+		out  string
+	}{
+		{
+			"set_not_enough_args",
+			func() (*cli.MockUi, cli.Command) { return testKVMetadataSetCustomCommand(t) },
+			[]string{"secret/foo"},
+			"Must supply custom metadata",
+		},
+		{
+			"set_invalid_kv",
+			func() (*cli.MockUi, cli.Command) { return testKVMetadataSetCustomCommand(t) },
+			[]string{"secret/foo", "owner"},
+			"Failed to parse K=V data",
+		},
+		{
+			"delete_not_enough_args",
+			func() (*cli.MockUi, cli.Command) { return testKVMetadataDeleteCustomCommand(t) },
+			[]string{"secret/foo"},
+			"Must supply custom metadata keys",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := tc.cmd()
+			if code := cmd.Run(tc.args); code != 1 {
+				t.Errorf("expected %d to be %d", code, 1)
+			}
+			if combined := ui.ErrorWriter.String(); !strings.Contains(combined, tc.out) {
+				t.Errorf("expected %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, setCmd := testKVMetadataSetCustomCommand(t)
+		assertNoTabs(t, setCmd)
+
+		_, deleteCmd := testKVMetadataDeleteCustomCommand(t)
+		assertNoTabs(t, deleteCmd)
+	})
+}
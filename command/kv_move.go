@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*KVMoveCommand)(nil)
+	_ cli.CommandAutocomplete = (*KVMoveCommand)(nil)
+)
+
+type KVMoveCommand struct {
+	*BaseCommand
+
+	flagCopyOpts kvCopyOptions
+}
+
+func (c *KVMoveCommand) Synopsis() string {
+	return "Moves secrets in the KV store"
+}
+
+func (c *KVMoveCommand) Help() string {
+	helpText := `
+Usage: vault kv mv [options] SOURCE DESTINATION
+
+  Copies the secret at SOURCE to DESTINATION, which may be in another mount or
+  namespace, and then permanently deletes SOURCE. For KV v2 secrets this
+  deletes all versions and metadata of SOURCE, so use -all-versions to keep
+  the history of the secret.
+
+  Rename a secret, keeping all of its versions:
+
+      $ vault kv mv -all-versions secret/foo secret/bar
+
+  Move every secret under "secret/app" into another mount:
+
+      $ vault kv mv -r secret/app other-secret/app
+
+  Additional flags and more advanced use cases are detailed below.
+
+` + c.Flags().Help()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVMoveCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	// Common Options
+	f := set.NewFlagSet("Common Options")
+	addKVCopyFlags(f, &c.flagCopyOpts)
+
+	return set
+}
+
+func (c *KVMoveCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *KVMoveCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *KVMoveCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) != 2 {
+		c.UI.Error(fmt.Sprintf("Invalid number of arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	return kvCopy(c.UI, client, c.flagCopyOpts, args[0], args[1], true)
+}
@@ -20,9 +20,10 @@ var (
 type KVPatchCommand struct {
 	*BaseCommand
 
-	flagCAS    int
-	flagMethod string
-	testStdin  io.Reader // for tests
+	flagCAS        string
+	flagCASRetries int
+	flagMethod     string
+	testStdin      io.Reader // for tests
 }
 
 func (c *KVPatchCommand) Synopsis() string {
@@ -49,12 +50,26 @@ Usage: vault kv patch [options] KEY [DATA]
 
       $ echo "abcd1234" | vault kv patch secret/foo bar=-
 
+  A file containing a single JSON object is applied as a JSON merge patch
+  (RFC 7386): keys set to null are removed from the secret and nested objects
+  are merged rather than replaced. For example, if patch.json contains
+  {"old-key": null, "nested": {"field": "value"}}:
+
+      $ vault kv patch secret/foo @patch.json
+
   To perform a Check-And-Set operation, specify the -cas flag with the
   appropriate version number corresponding to the key you want to perform
   the CAS operation on:
 
       $ vault kv patch -cas=1 secret/foo bar=baz
 
+  To read the current data and version, apply the patch locally and write the
+  result with check-and-set, specify -cas=auto. If another client writes the
+  key before this write completes, the patch is retried up to -cas-retries
+  times:
+
+      $ vault kv patch -cas=auto secret/foo bar=baz
+
   By default, this operation will attempt an HTTP PATCH operation. If your
   policy does not allow that, it will fall back to a read/local update/write approach.
   If you wish to specify which method this command should use, you may do so
@@ -80,14 +95,23 @@ func (c *KVPatchCommand) Flags() *FlagSets {
 	// Patch specific options
 	f := set.NewFlagSet("Common Options")
 
-	f.IntVar(&IntVar{
+	f.StringVar(&StringVar{
 		Name:    "cas",
 		Target:  &c.flagCAS,
-		Default: 0,
+		Default: "",
 		Usage: `Specifies to use a Check-And-Set operation. If set to 0 or not
 		set, the patch will be allowed. If the index is non-zero the patch will
 		only be allowed if the key’s current version matches the version
-		specified in the cas parameter.`,
+		specified in the cas parameter. If set to "auto", the current version
+		is read and the patch is applied with a read/local update/write.`,
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "cas-retries",
+		Target:  &c.flagCASRetries,
+		Default: defaultKVCASRetries,
+		Usage: `Number of times to retry the patch when -cas=auto is set and
+		the key is changed by another client between the read and the write.`,
 	})
 
 	f.StringVar(&StringVar{
@@ -133,7 +157,16 @@ func (c *KVPatchCommand) Run(args []string) int {
 		return 1
 	}
 
-	var err error
+	cas, casAuto, err := parseKVCASFlag(c.flagCAS, 0)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	if casAuto && c.flagMethod == "patch" {
+		c.UI.Error("-cas=auto cannot be used with -method=patch")
+		return 1
+	}
+
 	path := sanitizePath(args[0])
 
 	client, err := c.Client()
@@ -144,7 +177,7 @@ func (c *KVPatchCommand) Run(args []string) int {
 
 	newData, err := parseArgsData(stdin, args[1:])
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse K=V data: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
 		return 1
 	}
 
@@ -169,13 +202,15 @@ func (c *KVPatchCommand) Run(args []string) int {
 	var secret *api.Secret
 	var code int
 
-	switch c.flagMethod {
-	case "rw":
+	switch {
+	case casAuto && (c.flagMethod == "" || c.flagMethod == "rw"):
+		secret, code = c.casAutoWrite(client, path, newData)
+	case c.flagMethod == "rw":
 		secret, code = c.readThenWrite(client, path, newData)
-	case "patch":
-		secret, code = c.mergePatch(client, path, newData, false)
-	case "":
-		secret, code = c.mergePatch(client, path, newData, true)
+	case c.flagMethod == "patch":
+		secret, code = c.mergePatch(client, path, newData, cas, false)
+	case c.flagMethod == "":
+		secret, code = c.mergePatch(client, path, newData, cas, true)
 	default:
 		c.UI.Error(fmt.Sprintf("Unsupported method provided to -method flag: %s", c.flagMethod))
 		return 2
@@ -196,7 +231,7 @@ func (c *KVPatchCommand) readThenWrite(client *api.Client, path string, newData
 	secret, err := kvReadRequest(client, path, nil)
 	client.SetOutputCurlString(curOutputCurl)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error doing pre-read at %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error doing pre-read at %s: %s", path, err), err)
 		return nil, 2
 	}
 
@@ -238,10 +273,9 @@ func (c *KVPatchCommand) readThenWrite(client *api.Client, path string, newData
 		return nil, 2
 	}
 
-	// Copy new data over
-	for k, v := range newData {
-		data[k] = v
-	}
+	// Apply the new data as a JSON merge patch, which is how the server
+	// handles an HTTP PATCH request
+	data = jsonMergePatch(data, newData)
 
 	secret, err = client.Logical().Write(path, map[string]interface{}{
 		"data": data,
@@ -250,7 +284,7 @@ func (c *KVPatchCommand) readThenWrite(client *api.Client, path string, newData
 		},
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
 		return nil, 2
 	}
 
@@ -269,14 +303,14 @@ func (c *KVPatchCommand) readThenWrite(client *api.Client, path string, newData
 	return secret, 0
 }
 
-func (c *KVPatchCommand) mergePatch(client *api.Client, path string, newData map[string]interface{}, rwFallback bool) (*api.Secret, int) {
+func (c *KVPatchCommand) mergePatch(client *api.Client, path string, newData map[string]interface{}, cas int, rwFallback bool) (*api.Secret, int) {
 	data := map[string]interface{}{
 		"data":    newData,
 		"options": map[string]interface{}{},
 	}
 
-	if c.flagCAS > 0 {
-		data["options"].(map[string]interface{})["cas"] = c.flagCAS
+	if cas > 0 {
+		data["options"].(map[string]interface{})["cas"] = cas
 	}
 
 	secret, err := client.Logical().JSONMergePatch(context.Background(), path, data)
@@ -295,7 +329,34 @@ func (c *KVPatchCommand) mergePatch(client *api.Client, path string, newData map
 			return c.readThenWrite(client, path, newData)
 		}
 
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
+		return nil, 2
+	}
+
+	if secret == nil {
+		// Don't output anything unless using the "table" format
+		if Format(c.UI) == "table" {
+			c.UI.Info(fmt.Sprintf("Success! Data written to: %s", path))
+		}
+		return nil, 0
+	}
+
+	if c.flagField != "" {
+		return nil, PrintRawField(c.UI, secret, c.flagField)
+	}
+
+	return secret, 0
+}
+
+func (c *KVPatchCommand) casAutoWrite(client *api.Client, path string, newData map[string]interface{}) (*api.Secret, int) {
+	secret, err := kvWriteCASAuto(client, path, c.flagCASRetries, func(current map[string]interface{}) (map[string]interface{}, error) {
+		if current == nil {
+			return nil, fmt.Errorf("No data found at %s; patch only works on existing data", path)
+		}
+		return jsonMergePatch(current, newData), nil
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
 		return nil, 2
 	}
 
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -18,8 +19,9 @@ var (
 type KVPutCommand struct {
 	*BaseCommand
 
-	flagCAS   int
-	testStdin io.Reader // for tests
+	flagCAS        string
+	flagCASRetries int
+	testStdin      io.Reader // for tests
 }
 
 func (c *KVPutCommand) Synopsis() string {
@@ -50,6 +52,12 @@ Usage: vault kv put [options] KEY [DATA]
 
       $ vault kv put -cas=1 secret/foo bar=baz
 
+  To have the current version looked up automatically, specify -cas=auto. If
+  another client writes the key before this write completes, the write is
+  retried up to -cas-retries times:
+
+      $ vault kv put -cas=auto secret/foo bar=baz
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -62,15 +70,24 @@ func (c *KVPutCommand) Flags() *FlagSets {
 	// Common Options
 	f := set.NewFlagSet("Common Options")
 
-	f.IntVar(&IntVar{
+	f.StringVar(&StringVar{
 		Name:    "cas",
 		Target:  &c.flagCAS,
-		Default: -1,
+		Default: "",
 		Usage: `Specifies to use a Check-And-Set operation. If not set the write
 		will be allowed. If set to 0 a write will only be allowed if the key
 		doesn’t exist. If the index is non-zero the write will only be allowed
 		if the key’s current version matches the version specified in the cas
-		parameter.`,
+		parameter. If set to "auto", the current version is read and used as
+		the cas parameter.`,
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "cas-retries",
+		Target:  &c.flagCASRetries,
+		Default: defaultKVCASRetries,
+		Usage: `Number of times to retry the write when -cas=auto is set and
+		the key is changed by another client between the read and the write.`,
 	})
 
 	return set
@@ -108,7 +125,12 @@ func (c *KVPutCommand) Run(args []string) int {
 		return 1
 	}
 
-	var err error
+	cas, casAuto, err := parseKVCASFlag(c.flagCAS, -1)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
 	path := sanitizePath(args[0])
 
 	client, err := c.Client()
@@ -119,7 +141,7 @@ func (c *KVPutCommand) Run(args []string) int {
 
 	data, err := parseArgsData(stdin, args[1:])
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse K=V data: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
 		return 1
 	}
 
@@ -129,21 +151,38 @@ func (c *KVPutCommand) Run(args []string) int {
 		return 2
 	}
 
-	if v2 {
+	if casAuto && !v2 {
+		c.UI.Error("K/V engine mount must be version 2 for check-and-set support")
+		return 2
+	}
+
+	var secret *api.Secret
+	switch {
+	case casAuto:
+		path = addPrefixToKVPath(path, mountPath, "data")
+		secret, err = kvWriteCASAuto(client, path, c.flagCASRetries, func(map[string]interface{}) (map[string]interface{}, error) {
+			return data, nil
+		})
+	case v2:
 		path = addPrefixToKVPath(path, mountPath, "data")
 		data = map[string]interface{}{
 			"data":    data,
 			"options": map[string]interface{}{},
 		}
 
-		if c.flagCAS > -1 {
-			data["options"].(map[string]interface{})["cas"] = c.flagCAS
+		if cas > -1 {
+			data["options"].(map[string]interface{})["cas"] = cas
 		}
+		secret, err = client.Logical().Write(path, data)
+	default:
+		secret, err = client.Logical().Write(path, data)
 	}
-
-	secret, err := client.Logical().Write(path, data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		// Errors from kvWriteCASAuto already say what failed
+		if !casAuto {
+			err = fmt.Errorf("Error writing data to %s: %w", path, err)
+		}
+		c.UI.Error(err.Error())
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
@@ -3,8 +3,11 @@ package command
 import (
 	"flag"
 	"fmt"
+	"os"
+	"reflect"
 	"strings"
 
+	"github.com/mattn/go-isatty"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -18,6 +21,10 @@ type KVRollbackCommand struct {
 	*BaseCommand
 
 	flagVersion int
+	flagDryRun  bool
+	flagForce   bool
+
+	testInteractive bool // for tests
 }
 
 func (c *KVRollbackCommand) Synopsis() string {
@@ -37,6 +44,12 @@ Usage: vault kv rollback [options] KEY
 
       $ vault kv rollback -version=2 secret/foo
 
+  When run from a terminal, the differences between the current version and
+  the rollback version are shown and must be confirmed before anything is
+  written, unless -force is set. To only show the differences:
+
+      $ vault kv rollback -dry-run -version=2 secret/foo
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -55,6 +68,22 @@ func (c *KVRollbackCommand) Flags() *FlagSets {
 		Usage:  `Specifies the version number that should be made current again.`,
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  &c.flagDryRun,
+		Default: false,
+		Usage: "Show the differences between the current version and the " +
+			"rollback version without writing anything.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "force",
+		Aliases: []string{"f"},
+		Target:  &c.flagForce,
+		Default: false,
+		Usage:   "Do not ask for confirmation before rolling back.",
+	})
+
 	return set
 }
 
@@ -122,11 +151,11 @@ func (c *KVRollbackCommand) Run(args []string) int {
 	}
 
 	// First, do a read to get the current version for check-and-set
-	var meta map[string]interface{}
+	var meta, current map[string]interface{}
 	{
 		secret, err := kvReadRequest(client, path, nil)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error doing pre-read at %s: %s", path, err))
+			outputError(c.UI, fmt.Sprintf("Error doing pre-read at %s: %s", path, err), err)
 			return 2
 		}
 
@@ -151,6 +180,8 @@ func (c *KVRollbackCommand) Run(args []string) int {
 			c.UI.Error(fmt.Sprintf("No metadata found at %s; rollback only works on existing data", path))
 			return 2
 		}
+
+		current = kvSecretData(secret, true)
 	}
 
 	casVersion := meta["version"]
@@ -165,7 +196,7 @@ func (c *KVRollbackCommand) Run(args []string) int {
 	{
 		secret, err := kvReadRequest(client, path, versionParam)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error doing pre-read at %s: %s", path, err))
+			outputError(c.UI, fmt.Sprintf("Error doing pre-read at %s: %s", path, err), err)
 			return 2
 		}
 
@@ -219,6 +250,42 @@ func (c *KVRollbackCommand) Run(args []string) int {
 		}
 	}
 
+	if c.flagDryRun && Format(c.UI) != "table" {
+		return OutputData(c.UI, map[string]interface{}{
+			"current_version": casVersion,
+			"version":         c.flagVersion,
+			"changes":         diffData(current, data),
+		})
+	}
+
+	// Show what will change before a rollback is written, so that it can be
+	// reviewed and confirmed
+	if c.flagDryRun || (c.interactive() && !c.flagForce) {
+		c.UI.Output(fmt.Sprintf("--- %s (current version %v)", path, casVersion))
+		c.UI.Output(fmt.Sprintf("+++ %s (version %d)", path, c.flagVersion))
+		if reflect.DeepEqual(current, data) {
+			c.UI.Output("No differences")
+		} else {
+			outputDataDiff(c.UI, current, data, false)
+		}
+		c.UI.Output("")
+
+		if c.flagDryRun {
+			c.UI.Info("No changes made (dry run)")
+			return 0
+		}
+
+		ok, err := confirm(c.UI, fmt.Sprintf("Roll back %s to version %d?", path, c.flagVersion))
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading confirmation: %s", err), err)
+			return 1
+		}
+		if !ok {
+			c.UI.Info("Aborted")
+			return 1
+		}
+	}
+
 	secret, err := client.Logical().Write(path, map[string]interface{}{
 		"data": data,
 		"options": map[string]interface{}{
@@ -226,7 +293,7 @@ func (c *KVRollbackCommand) Run(args []string) int {
 		},
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
 		return 2
 	}
 	if secret == nil {
@@ -243,3 +310,8 @@ func (c *KVRollbackCommand) Run(args []string) int {
 
 	return OutputSecret(c.UI, secret)
 }
+
+// interactive returns true if the user can be asked to confirm the rollback.
+func (c *KVRollbackCommand) interactive() bool {
+	return c.testInteractive || isatty.IsTerminal(os.Stdin.Fd())
+}
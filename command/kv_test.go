@@ -3,6 +3,9 @@ package command
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -220,6 +223,56 @@ func TestKVPutCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("v2_cas_auto", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Only have to potentially retry the first time.
+		code, combined := kvPutWithRetry(t, client, []string{
+			"-cas", "auto", "kv/write/cas", "bar=baz",
+		})
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d: %s", code, combined)
+		}
+
+		ui, cmd := testKVPutCommand(t)
+		cmd.client = client
+		code = cmd.Run([]string{
+			"-cas", "auto", "kv/write/cas", "bar=qux",
+		})
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d: %s", code, ui.ErrorWriter.String())
+		}
+
+		secret, err := kvReadRequest(client, "kv/data/write/cas", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := "qux", kvSecretData(secret, true)["bar"]; exp != act {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+
+		ui, cmd = testKVPutCommand(t)
+		cmd.client = client
+		code = cmd.Run([]string{
+			"-cas", "bogus", "kv/write/cas", "bar=baz",
+		})
+		if code != 1 {
+			t.Fatalf("expected 1 to be %d", code)
+		}
+		if combined := ui.ErrorWriter.String(); !strings.Contains(combined, "Invalid value") {
+			t.Errorf("expected %q to contain %q", combined, "Invalid value")
+		}
+	})
+
 	t.Run("v1_data", func(t *testing.T) {
 		t.Parallel()
 
@@ -504,37 +557,20 @@ func TestKVGetCommand(t *testing.T) {
 	})
 }
 
-func testKVMetadataGetCommand(tb testing.TB) (*cli.MockUi, *KVMetadataGetCommand) {
+func testKVDiffCommand(tb testing.TB) (*cli.MockUi, *KVDiffCommand) {
 	tb.Helper()
 
 	ui := cli.NewMockUi()
-	return ui, &KVMetadataGetCommand{
+	return ui, &KVDiffCommand{
 		BaseCommand: &BaseCommand{
 			UI: ui,
 		},
 	}
 }
 
-func TestKVMetadataGetCommand(t *testing.T) {
+func TestKVDiffCommand(t *testing.T) {
 	t.Parallel()
 
-	expectedTopLevelFields := []string{
-		"cas_required",
-		"created_time",
-		"current_version",
-		"custom_metadata",
-		"delete_version_after",
-		"max_versions",
-		"oldest_version",
-		"updated_time",
-	}
-
-	expectedVersionFields := []string{
-		"created_time", // field is redundant
-		"deletion_time",
-		"destroyed",
-	}
-
 	cases := []struct {
 		name       string
 		args       []string
@@ -542,24 +578,70 @@ func TestKVMetadataGetCommand(t *testing.T) {
 		code       int
 	}{
 		{
-			"v1",
-			[]string{"secret/foo"},
-			[]string{"Metadata not supported on KV Version 1"},
+			"not_enough_args",
+			[]string{},
+			[]string{"Not enough arguments"},
 			1,
 		},
 		{
-			"metadata_exists",
-			[]string{"kv/foo"},
-			expectedTopLevelFields,
+			"too_many_args",
+			[]string{"foo", "bar", "baz"},
+			[]string{"Too many arguments"},
+			1,
+		},
+		{
+			"v2_previous_version",
+			[]string{"kv/diff/foo"},
+			[]string{
+				"--- kv/diff/foo (version 1)",
+				"+++ kv/diff/foo (version 2)",
+				"~ foo: bar -> baz",
+				"+ zip: zap",
+			},
 			0,
 		},
-		// ensure that all top-level and version-level fields are output along with version num
 		{
-			"versions_exist",
-			[]string{"kv/foo"},
-			append(expectedTopLevelFields, expectedVersionFields[:]...),
+			"v2_versions",
+			[]string{"-from-version", "2", "-to-version", "1", "kv/diff/foo"},
+			[]string{"~ foo: baz -> bar", "- zip: zap"},
+			0,
+		},
+		{
+			"v2_same_version",
+			[]string{"-from-version", "1", "-to-version", "1", "kv/diff/foo"},
+			[]string{"No differences"},
+			0,
+		},
+		{
+			"mask_values",
+			[]string{"-mask-values", "kv/diff/foo"},
+			[]string{"~ foo: (hidden) -> (hidden)", "+ zip: (hidden)"},
+			0,
+		},
+		{
+			"two_paths",
+			[]string{"secret/diff/foo", "kv/diff/foo"},
+			[]string{"--- secret/diff/foo", "~ foo: bar -> baz", "+ zip: zap"},
 			0,
 		},
+		{
+			"v1_single_key",
+			[]string{"secret/diff/foo"},
+			[]string{"not a KV v2 secret"},
+			1,
+		},
+		{
+			"v2_first_version",
+			[]string{"-to-version", "1", "kv/diff/foo"},
+			[]string{"No version before version 1"},
+			1,
+		},
+		{
+			"not_found",
+			[]string{"secret/diff/foo", "secret/nope"},
+			[]string{"No value found at secret/nope"},
+			2,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -582,15 +664,24 @@ func TestKVMetadataGetCommand(t *testing.T) {
 				// Give time for the upgrade code to run/finish
 				time.Sleep(time.Second)
 
-				if _, err := client.Logical().Write("kv/data/foo", map[string]interface{}{
-					"data": map[string]interface{}{
-						"foo": "bar",
-					},
+				if _, err := client.Logical().Write("secret/diff/foo", map[string]interface{}{
+					"foo": "bar",
 				}); err != nil {
 					t.Fatal(err)
 				}
 
-				ui, cmd := testKVMetadataGetCommand(t)
+				for _, data := range []map[string]interface{}{
+					{"foo": "bar"},
+					{"foo": "baz", "zip": "zap"},
+				} {
+					if _, err := client.Logical().Write("kv/data/diff/foo", map[string]interface{}{
+						"data": data,
+					}); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				ui, cmd := testKVDiffCommand(t)
 				cmd.client = client
 
 				code := cmd.Run(tc.args)
@@ -599,6 +690,7 @@ func TestKVMetadataGetCommand(t *testing.T) {
 				}
 
 				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+
 				for _, str := range tc.outStrings {
 					if !strings.Contains(combined, str) {
 						t.Errorf("expected %q to contain %q", combined, str)
@@ -611,380 +703,1648 @@ func TestKVMetadataGetCommand(t *testing.T) {
 	t.Run("no_tabs", func(t *testing.T) {
 		t.Parallel()
 
-		_, cmd := testKVMetadataGetCommand(t)
+		_, cmd := testKVDiffCommand(t)
 		assertNoTabs(t, cmd)
 	})
 }
 
-func testKVPatchCommand(tb testing.TB) (*cli.MockUi, *KVPatchCommand) {
+func testKVHistoryCommand(tb testing.TB) (*cli.MockUi, *KVHistoryCommand) {
 	tb.Helper()
 
 	ui := cli.NewMockUi()
-	return ui, &KVPatchCommand{
+	return ui, &KVHistoryCommand{
 		BaseCommand: &BaseCommand{
 			UI: ui,
 		},
 	}
 }
 
-func TestKVPatchCommand_ArgValidation(t *testing.T) {
+func TestKVHistoryCommand(t *testing.T) {
 	t.Parallel()
 
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	if err := ioutil.WriteFile(auditLog, []byte(strings.Join([]string{
+		`{"type":"request","auth":{"display_name":"userpass-alice"},"request":{"operation":"update","path":"kv/data/history/foo"}}`,
+		`{"type":"response","auth":{"display_name":"userpass-alice","entity_id":"e1"},"request":{"operation":"update","path":"kv/data/history/foo"},"response":{"data":{"version":3}}}`,
+		`{"type":"response","auth":{"display_name":"userpass-bob"},"request":{"operation":"update","path":"kv/data/history/bar"},"response":{"data":{"version":1}}}`,
+	}, "\n")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
 	cases := []struct {
-		name string
-		args []string
-		out  string
-		code int
+		name       string
+		args       []string
+		outStrings []string
+		code       int
 	}{
 		{
 			"not_enough_args",
 			[]string{},
-			"Not enough arguments",
+			[]string{"Not enough arguments"},
 			1,
 		},
 		{
-			"empty_kvs",
-			[]string{"kv/patch/foo"},
-			"Must supply data",
+			"too_many_args",
+			[]string{"foo", "bar"},
+			[]string{"Too many arguments"},
 			1,
 		},
 		{
-			"kvs_no_value",
-			[]string{"kv/patch/foo", "foo"},
-			"Failed to parse K=V data",
+			"v1",
+			[]string{"secret/history/foo"},
+			[]string{"History not supported on KV Version 1"},
+			1,
+		},
+		{
+			"versions",
+			[]string{"kv/history/foo"},
+			[]string{
+				"Version",
+				"Deletion Time",
+				"destroyed",
+				"deleted",
+				"3 (current)",
+				"active",
+				"== Custom Metadata ==",
+				"team",
+			},
+			0,
+		},
+		{
+			"audit_log",
+			[]string{"-audit-log", auditLog, "kv/history/foo"},
+			[]string{"Created By", "userpass-alice (e1)", "n/a"},
+			0,
+		},
+		{
+			"json",
+			[]string{"-format", "json", "kv/history/foo"},
+			[]string{`"current_version": 3`, `"state": "destroyed"`, `"team": "payments"`},
+			0,
+		},
+		{
+			"audit_log_missing",
+			[]string{"-audit-log", filepath.Join(t.TempDir(), "nope.log"), "kv/history/foo"},
+			[]string{"Error opening audit log"},
 			1,
 		},
 	}
 
-	for _, tc := range cases {
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
 
-			client, closer := testVaultServer(t)
-			defer closer()
+		for _, tc := range cases {
+			tc := tc
 
-			if err := client.Sys().Mount("kv/", &api.MountInput{
-				Type: "kv-v2",
-			}); err != nil {
-				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-			}
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
 
-			code, combined := kvPatchWithRetry(t, client, tc.args, nil)
+				client, closer := testVaultServer(t)
+				defer closer()
+				if err := client.Sys().Mount("kv/", &api.MountInput{
+					Type: "kv-v2",
+				}); err != nil {
+					t.Fatal(err)
+				}
 
-			if code != tc.code {
-				t.Fatalf("expected code to be %d but was %d for patch cmd with args %#v\n", tc.code, code, tc.args)
-			}
+				// Give time for the upgrade code to run/finish
+				time.Sleep(time.Second)
 
-			if !strings.Contains(combined, tc.out) {
-				t.Fatalf("expected output to be %q but was %q for patch cmd with args %#v\n", tc.out, combined, tc.args)
-			}
-		})
-	}
-}
+				for i := 0; i < 3; i++ {
+					if _, err := client.Logical().Write("kv/data/history/foo", map[string]interface{}{
+						"data": map[string]interface{}{
+							"foo": i,
+						},
+					}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if _, err := client.Logical().Write("kv/destroy/history/foo", map[string]interface{}{
+					"versions": []int{1},
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := client.Logical().Write("kv/delete/history/foo", map[string]interface{}{
+					"versions": []int{2},
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := client.Logical().Write("kv/metadata/history/foo", map[string]interface{}{
+					"custom_metadata": map[string]interface{}{
+						"team": "payments",
+					},
+				}); err != nil {
+					t.Fatal(err)
+				}
 
-// expectedPatchFields produces a deterministic slice of
-// expected fields for patch command output since const
-// slices are not supported
-func expectedPatchFields() []string {
-	return []string{
-		"created_time",
-		"custom_metadata",
-		"deletion_time",
-		"destroyed",
-		"version",
-	}
-}
+				ui, cmd := testKVHistoryCommand(t)
+				cmd.client = client
 
-func TestKvPatchCommand_StdinFull(t *testing.T) {
-	client, closer := testVaultServer(t)
-	defer closer()
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
 
-	if err := client.Sys().Mount("kv/", &api.MountInput{
-		Type: "kv-v2",
-	}); err != nil {
-		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-	}
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				for _, str := range tc.outStrings {
+					if !strings.Contains(combined, str) {
+						t.Errorf("expected %q to contain %q", combined, str)
+					}
+				}
+			})
+		}
+	})
 
-	if _, err := client.Logical().Write("kv/data/patch/foo", map[string]interface{}{
-		"data": map[string]interface{}{
-			"foo": "a",
-		},
-	}); err != nil {
-		t.Fatalf("write failed, err: %#v\n", err)
-	}
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
 
-	stdinR, stdinW := io.Pipe()
-	go func() {
-		stdinW.Write([]byte(`{"foo":"bar"}`))
-		stdinW.Close()
-	}()
+		_, cmd := testKVHistoryCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
 
-	args := []string{"kv/patch/foo", "-"}
-	code, combined := kvPatchWithRetry(t, client, args, stdinR)
+func testKVEditCommand(tb testing.TB) (*cli.MockUi, *KVEditCommand) {
+	tb.Helper()
 
-	for _, str := range expectedPatchFields() {
-		if !strings.Contains(combined, str) {
-			t.Errorf("expected %q to contain %q", combined, str)
-		}
+	ui := cli.NewMockUi()
+	return ui, &KVEditCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
 	}
+}
 
-	if code != 0 {
-		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v\n", code, args)
-	}
+func TestKVEditCommand(t *testing.T) {
+	t.Parallel()
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, args := range [][]string{{}, {"foo", "bar"}} {
+			ui, cmd := testKVEditCommand(t)
+			if code := cmd.Run(args); code != 1 {
+				t.Errorf("%v: expected 1 to be %d", args, code)
+			}
+			if exp := "arguments"; !strings.Contains(ui.ErrorWriter.String(), exp) {
+				t.Errorf("expected %q to contain %q", ui.ErrorWriter.String(), exp)
+			}
+		}
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Give time for the upgrade code to run/finish
+		time.Sleep(time.Second)
+
+		edit := func(editor editorFunc) (*cli.MockUi, int) {
+			ui, cmd := testKVEditCommand(t)
+			cmd.client = client
+			cmd.testEditor = editor
+			return ui, cmd.Run([]string{"kv/edit/foo"})
+		}
+
+		// A new key is created
+		ui, code := edit(func(file string) error {
+			return ioutil.WriteFile(file, []byte(`{"foo": "bar"}`), 0o600)
+		})
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d: %s", code, ui.ErrorWriter.String())
+		}
+
+		ui, code = edit(func(file string) error {
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(string(b), `"foo": "bar"`) {
+				t.Errorf("expected the current data, got %q", b)
+			}
+			return ioutil.WriteFile(file, []byte(`{"foo": "baz"}`), 0o600)
+		})
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d: %s", code, ui.ErrorWriter.String())
+		}
+		secret, err := client.Logical().Read("kv/data/edit/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data := secret.Data["data"].(map[string]interface{}); data["foo"] != "baz" {
+			t.Errorf("expected the edited data to be written, got %v", data)
+		}
+
+		ui, code = edit(func(file string) error { return nil })
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d", code)
+		}
+		if exp := "No changes made"; !strings.Contains(ui.OutputWriter.String(), exp) {
+			t.Errorf("expected %q to contain %q", ui.OutputWriter.String(), exp)
+		}
+
+		// The version being edited is used as check-and-set parameter
+		ui, code = edit(func(file string) error {
+			if _, err := client.Logical().Write("kv/data/edit/foo", map[string]interface{}{
+				"data": map[string]interface{}{"foo": "other"},
+			}); err != nil {
+				return err
+			}
+			return ioutil.WriteFile(file, []byte(`{"foo": "mine"}`), 0o600)
+		})
+		if code != 2 {
+			t.Fatalf("expected 2 to be %d", code)
+		}
+		if exp := "was changed while it was being edited"; !strings.Contains(ui.ErrorWriter.String(), exp) {
+			t.Errorf("expected %q to contain %q", ui.ErrorWriter.String(), exp)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testKVEditCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func testKVExportCommand(tb testing.TB) (*cli.MockUi, *KVExportCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVExportCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testKVImportCommand(tb testing.TB) (*cli.MockUi, *KVImportCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVImportCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestKVExportImportCommand(t *testing.T) {
+	t.Parallel()
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name string
+			cmd  func() (*cli.MockUi, cli.Command)
+			args []string
+			out  string
+			code int
+		}{
+			{
+				"export_not_enough_args",
+				func() (*cli.MockUi, cli.Command) { return testKVExportCommand(t) },
+				[]string{},
+				"Not enough arguments",
+				1,
+			},
+			{
+				"export_too_many_args",
+				func() (*cli.MockUi, cli.Command) { return testKVExportCommand(t) },
+				[]string{"foo", "bar"},
+				"Too many arguments",
+				1,
+			},
+			{
+				"import_not_enough_args",
+				func() (*cli.MockUi, cli.Command) { return testKVImportCommand(t) },
+				[]string{},
+				"Not enough arguments",
+				1,
+			},
+			{
+				"import_too_many_args",
+				func() (*cli.MockUi, cli.Command) { return testKVImportCommand(t) },
+				[]string{"foo", "bar", "baz"},
+				"Too many arguments",
+				1,
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := tc.cmd()
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("round_trip", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Sys().Mount("kv2/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Give time for the upgrade code to run/finish
+		time.Sleep(time.Second)
+
+		for _, data := range []map[string]interface{}{
+			{"foo": "bar"},
+			{"foo": "baz"},
+		} {
+			if _, err := client.Logical().Write("kv/data/app/foo", map[string]interface{}{
+				"data": data,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := client.Logical().Write("kv/data/app/nested/bar", map[string]interface{}{
+			"data": map[string]interface{}{"zip": "zap"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("kv/metadata/app/foo", map[string]interface{}{
+			"custom_metadata": map[string]interface{}{"owner": "ops"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, exportCmd := testKVExportCommand(t)
+		exportCmd.client = client
+
+		code := exportCmd.Run([]string{"-metadata", "-versions", "kv/app"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+		exported := ui.OutputWriter.String()
+
+		doc, err := parseKVExportDocument([]byte(exported))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(doc.Secrets) != 2 {
+			t.Fatalf("expected 2 secrets, got %d: %s", len(doc.Secrets), exported)
+		}
+		if v := len(doc.Secrets["foo"].Versions); v != 2 {
+			t.Errorf("expected 2 versions of foo, got %d", v)
+		}
+
+		ui, importCmd := testKVImportCommand(t)
+		importCmd.client = client
+		importCmd.testStdin = strings.NewReader(exported)
+
+		code = importCmd.Run([]string{"-concurrency=2", "kv2/restored"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+
+		secret, err := client.Logical().Read("kv2/data/restored/nested/bar")
+		if err != nil || secret == nil {
+			t.Fatalf("expected secret, got %v: %v", secret, err)
+		}
+		if v := secret.Data["data"].(map[string]interface{})["zip"]; v != "zap" {
+			t.Errorf("expected %q to be %q", v, "zap")
+		}
+
+		metadata, err := client.Logical().Read("kv2/metadata/restored/foo")
+		if err != nil || metadata == nil {
+			t.Fatalf("expected metadata, got %v: %v", metadata, err)
+		}
+		if v := fmt.Sprintf("%v", metadata.Data["current_version"]); v != "2" {
+			t.Errorf("expected current version %q to be %q", v, "2")
+		}
+		custom, _ := metadata.Data["custom_metadata"].(map[string]interface{})
+		if v := custom["owner"]; v != "ops" {
+			t.Errorf("expected %q to be %q", v, "ops")
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, exportCmd := testKVExportCommand(t)
+		assertNoTabs(t, exportCmd)
+
+		_, importCmd := testKVImportCommand(t)
+		assertNoTabs(t, importCmd)
+	})
+}
+
+func testKVMetadataGetCommand(tb testing.TB) (*cli.MockUi, *KVMetadataGetCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVMetadataGetCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestKVMetadataGetCommand(t *testing.T) {
+	t.Parallel()
+
+	expectedTopLevelFields := []string{
+		"cas_required",
+		"created_time",
+		"current_version",
+		"custom_metadata",
+		"delete_version_after",
+		"max_versions",
+		"oldest_version",
+		"updated_time",
+	}
+
+	expectedVersionFields := []string{
+		"created_time", // field is redundant
+		"deletion_time",
+		"destroyed",
+	}
+
+	cases := []struct {
+		name       string
+		args       []string
+		outStrings []string
+		code       int
+	}{
+		{
+			"v1",
+			[]string{"secret/foo"},
+			[]string{"Metadata not supported on KV Version 1"},
+			1,
+		},
+		{
+			"metadata_exists",
+			[]string{"kv/foo"},
+			expectedTopLevelFields,
+			0,
+		},
+		// ensure that all top-level and version-level fields are output along with version num
+		{
+			"versions_exist",
+			[]string{"kv/foo"},
+			append(expectedTopLevelFields, expectedVersionFields[:]...),
+			0,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+				if err := client.Sys().Mount("kv/", &api.MountInput{
+					Type: "kv-v2",
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				// Give time for the upgrade code to run/finish
+				time.Sleep(time.Second)
+
+				if _, err := client.Logical().Write("kv/data/foo", map[string]interface{}{
+					"data": map[string]interface{}{
+						"foo": "bar",
+					},
+				}); err != nil {
+					t.Fatal(err)
+				}
+
+				ui, cmd := testKVMetadataGetCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				for _, str := range tc.outStrings {
+					if !strings.Contains(combined, str) {
+						t.Errorf("expected %q to contain %q", combined, str)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testKVMetadataGetCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func testKVPatchCommand(tb testing.TB) (*cli.MockUi, *KVPatchCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVPatchCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestKVPatchCommand_ArgValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"empty_kvs",
+			[]string{"kv/patch/foo"},
+			"Must supply data",
+			1,
+		},
+		{
+			"kvs_no_value",
+			[]string{"kv/patch/foo", "foo"},
+			"Failed to parse K=V data",
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := testVaultServer(t)
+			defer closer()
+
+			if err := client.Sys().Mount("kv/", &api.MountInput{
+				Type: "kv-v2",
+			}); err != nil {
+				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+			}
+
+			code, combined := kvPatchWithRetry(t, client, tc.args, nil)
+
+			if code != tc.code {
+				t.Fatalf("expected code to be %d but was %d for patch cmd with args %#v\n", tc.code, code, tc.args)
+			}
+
+			if !strings.Contains(combined, tc.out) {
+				t.Fatalf("expected output to be %q but was %q for patch cmd with args %#v\n", tc.out, combined, tc.args)
+			}
+		})
+	}
+}
+
+// expectedPatchFields produces a deterministic slice of
+// expected fields for patch command output since const
+// slices are not supported
+func expectedPatchFields() []string {
+	return []string{
+		"created_time",
+		"custom_metadata",
+		"deletion_time",
+		"destroyed",
+		"version",
+	}
+}
+
+func TestKvPatchCommand_StdinFull(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("kv/", &api.MountInput{
+		Type: "kv-v2",
+	}); err != nil {
+		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+	}
+
+	if _, err := client.Logical().Write("kv/data/patch/foo", map[string]interface{}{
+		"data": map[string]interface{}{
+			"foo": "a",
+		},
+	}); err != nil {
+		t.Fatalf("write failed, err: %#v\n", err)
+	}
+
+	stdinR, stdinW := io.Pipe()
+	go func() {
+		stdinW.Write([]byte(`{"foo":"bar"}`))
+		stdinW.Close()
+	}()
+
+	args := []string{"kv/patch/foo", "-"}
+	code, combined := kvPatchWithRetry(t, client, args, stdinR)
+
+	for _, str := range expectedPatchFields() {
+		if !strings.Contains(combined, str) {
+			t.Errorf("expected %q to contain %q", combined, str)
+		}
+	}
+
+	if code != 0 {
+		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v\n", code, args)
+	}
+
+	secret, err := client.Logical().Read("kv/data/patch/foo")
+	if err != nil {
+		t.Fatalf("read failed, err: %#v\n", err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		t.Fatal("expected secret to have data")
+	}
+
+	secretDataRaw, ok := secret.Data["data"]
+
+	if !ok {
+		t.Fatalf("expected secret to have nested data key, data: %#v", secret.Data)
+	}
+
+	secretData := secretDataRaw.(map[string]interface{})
+	foo, ok := secretData["foo"].(string)
+	if !ok {
+		t.Fatal("expected foo to be a string but it wasn't")
+	}
+
+	if exp, act := "bar", foo; exp != act {
+		t.Fatalf("expected %q to be %q, data: %#v\n", act, exp, secret.Data)
+	}
+}
+
+func TestKvPatchCommand_StdinValue(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("kv/", &api.MountInput{
+		Type: "kv-v2",
+	}); err != nil {
+		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+	}
+
+	if _, err := client.Logical().Write("kv/data/patch/foo", map[string]interface{}{
+		"data": map[string]interface{}{
+			"foo": "a",
+		},
+	}); err != nil {
+		t.Fatalf("write failed, err: %#v\n", err)
+	}
+
+	stdinR, stdinW := io.Pipe()
+	go func() {
+		stdinW.Write([]byte("bar"))
+		stdinW.Close()
+	}()
+
+	args := []string{"kv/patch/foo", "foo=-"}
+	code, combined := kvPatchWithRetry(t, client, args, stdinR)
+	if code != 0 {
+		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v\n", code, args)
+	}
+
+	for _, str := range expectedPatchFields() {
+		if !strings.Contains(combined, str) {
+			t.Errorf("expected %q to contain %q", combined, str)
+		}
+	}
+
+	secret, err := client.Logical().Read("kv/data/patch/foo")
+	if err != nil {
+		t.Fatalf("read failed, err: %#v\n", err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		t.Fatal("expected secret to have data")
+	}
+
+	secretDataRaw, ok := secret.Data["data"]
+
+	if !ok {
+		t.Fatalf("expected secret to have nested data key, data: %#v\n", secret.Data)
+	}
+
+	secretData := secretDataRaw.(map[string]interface{})
+
+	if exp, act := "bar", secretData["foo"].(string); exp != act {
+		t.Fatalf("expected %q to be %q, data: %#v\n", act, exp, secret.Data)
+	}
+}
+
+func TestKVPatchCommand_RWMethodNotExists(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("kv/", &api.MountInput{
+		Type: "kv-v2",
+	}); err != nil {
+		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+	}
+
+	args := []string{"-method", "rw", "kv/patch/foo", "foo=a"}
+	code, combined := kvPatchWithRetry(t, client, args, nil)
+
+	if code != 2 {
+		t.Fatalf("expected code to be 2 but was %d for patch cmd with args %#v\n", code, args)
+	}
+
+	expectedOutputSubstr := "No value found"
+	if !strings.Contains(combined, expectedOutputSubstr) {
+		t.Fatalf("expected output %q to contain %q for patch cmd with args %#v\n", combined, expectedOutputSubstr, args)
+	}
+}
+
+func TestKVPatchCommand_RWMethodSucceeds(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("kv/", &api.MountInput{
+		Type: "kv-v2",
+	}); err != nil {
+		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+	}
+
+	if _, err := client.Logical().Write("kv/data/patch/foo", map[string]interface{}{
+		"data": map[string]interface{}{
+			"foo": "a",
+			"bar": "b",
+		},
+	}); err != nil {
+		t.Fatalf("write failed, err: %#v\n", err)
+	}
+
+	// Test single value
+	args := []string{"-method", "rw", "kv/patch/foo", "foo=aa"}
+	code, combined := kvPatchWithRetry(t, client, args, nil)
+
+	if code != 0 {
+		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v\n", code, args)
+	}
+
+	for _, str := range expectedPatchFields() {
+		if !strings.Contains(combined, str) {
+			t.Errorf("expected %q to contain %q", combined, str)
+		}
+	}
+
+	// Test multi value
+	args = []string{"-method", "rw", "kv/patch/foo", "foo=aaa", "bar=bbb"}
+	code, combined = kvPatchWithRetry(t, client, args, nil)
+
+	if code != 0 {
+		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v\n", code, args)
+	}
+
+	for _, str := range expectedPatchFields() {
+		if !strings.Contains(combined, str) {
+			t.Errorf("expected %q to contain %q", combined, str)
+		}
+	}
+}
+
+func TestKVPatchCommand_CAS(t *testing.T) {
+	cases := []struct {
+		name       string
+		args       []string
+		expected   string
+		outStrings []string
+		code       int
+	}{
+		{
+			"right version",
+			[]string{"-cas", "1", "kv/foo", "bar=quux"},
+			"quux",
+			expectedPatchFields(),
+			0,
+		},
+		{
+			"wrong version",
+			[]string{"-cas", "2", "kv/foo", "bar=wibble"},
+			"baz",
+			[]string{"check-and-set parameter did not match the current version"},
+			2,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := testVaultServer(t)
+			defer closer()
+
+			if err := client.Sys().Mount("kv/", &api.MountInput{
+				Type: "kv-v2",
+			}); err != nil {
+				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+			}
+
+			// create a policy with patch capability
+			policy := `path "kv/*" { capabilities = ["create", "update", "read", "patch"] }`
+			secretAuth, err := createTokenForPolicy(t, client, policy)
+			if err != nil {
+				t.Fatalf("policy/token creation failed for policy %s, err: %#v\n", policy, err)
+			}
+
+			kvClient, err := client.Clone()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			kvClient.SetToken(secretAuth.ClientToken)
+
+			_, err = kvClient.Logical().Write("kv/data/foo", map[string]interface{}{"data": map[string]interface{}{"bar": "baz"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code, combined := kvPatchWithRetry(t, kvClient, tc.args, nil)
+
+			if code != tc.code {
+				t.Fatalf("expected code to be %d but was %d", tc.code, code)
+			}
+
+			for _, str := range tc.outStrings {
+				if !strings.Contains(combined, str) {
+					t.Errorf("expected %q to contain %q", combined, str)
+				}
+			}
+
+			secret, err := kvClient.Logical().Read("kv/data/foo")
+			bar := secret.Data["data"].(map[string]interface{})["bar"]
+			if bar != tc.expected {
+				t.Fatalf("expected bar to be %q but it was %q", tc.expected, bar)
+			}
+		})
+	}
+}
+
+func TestKVPatchCommand_CASAuto(t *testing.T) {
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("kv/", &api.MountInput{
+		Type: "kv-v2",
+	}); err != nil {
+		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+	}
+
+	if _, err := client.Logical().Write("kv/data/patch/foo", map[string]interface{}{
+		"data": map[string]interface{}{
+			"foo": "a",
+			"bar": "b",
+		},
+	}); err != nil {
+		t.Fatalf("write failed, err: %#v\n", err)
+	}
+
+	args := []string{"-cas", "auto", "kv/patch/foo", "foo=aa"}
+	code, combined := kvPatchWithRetry(t, client, args, nil)
+	if code != 0 {
+		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v: %s\n", code, args, combined)
+	}
+
+	secret, err := kvReadRequest(client, "kv/data/patch/foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{"foo": "aa", "bar": "b"}
+	if data := kvSecretData(secret, true); !reflect.DeepEqual(data, exp) {
+		t.Errorf("expected %#v to be %#v", data, exp)
+	}
+
+	args = []string{"-cas", "auto", "-method", "patch", "kv/patch/foo", "foo=aa"}
+	code, combined = kvPatchWithRetry(t, client, args, nil)
+	if code != 1 {
+		t.Fatalf("expected code to be 1 but was %d for patch cmd with args %#v\n", code, args)
+	}
+	if !strings.Contains(combined, "cannot be used with -method=patch") {
+		t.Errorf("expected %q to reject -method=patch", combined)
+	}
+}
+
+func TestKVPatchCommand_Methods(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		expected string
+		code     int
+	}{
+		{
+			"rw",
+			[]string{"-method", "rw", "kv/foo", "bar=quux"},
+			"quux",
+			0,
+		},
+		{
+			"patch",
+			[]string{"-method", "patch", "kv/foo", "bar=wibble"},
+			"wibble",
+			0,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, closer := testVaultServer(t)
+			defer closer()
+
+			if err := client.Sys().Mount("kv/", &api.MountInput{
+				Type: "kv-v2",
+			}); err != nil {
+				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+			}
+
+			// create a policy with patch capability
+			policy := `path "kv/*" { capabilities = ["create", "update", "read", "patch"] }`
+			secretAuth, err := createTokenForPolicy(t, client, policy)
+			if err != nil {
+				t.Fatalf("policy/token creation failed for policy %s, err: %#v\n", policy, err)
+			}
+
+			kvClient, err := client.Clone()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			kvClient.SetToken(secretAuth.ClientToken)
+
+			_, err = kvClient.Logical().Write("kv/data/foo", map[string]interface{}{"data": map[string]interface{}{"bar": "baz"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code, _ := kvPatchWithRetry(t, kvClient, tc.args, nil)
+
+			if code != tc.code {
+				t.Fatalf("expected code to be %d but was %d", tc.code, code)
+			}
+
+			secret, err := kvClient.Logical().Read("kv/data/foo")
+			bar := secret.Data["data"].(map[string]interface{})["bar"]
+			if bar != tc.expected {
+				t.Fatalf("expected bar to be %q but it was %q", tc.expected, bar)
+			}
+		})
+	}
+}
+
+func TestKVPatchCommand_403Fallback(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		expected string
+		code     int
+	}{
+		// if no -method is specified, and patch fails, it should fall back to rw and succeed
+		{
+			"unspecified",
+			[]string{"kv/foo", "bar=quux"},
+			`add the "patch" capability to your ACL policy`,
+			0,
+		},
+		// if -method=patch is specified, and patch fails, it should not fall back, and just error
+		{
+			"specifying patch",
+			[]string{"-method", "patch", "kv/foo", "bar=quux"},
+			"permission denied",
+			2,
+		},
+	}
 
-	secret, err := client.Logical().Read("kv/data/patch/foo")
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			client, closer := testVaultServer(t)
+			defer closer()
+
+			if err := client.Sys().Mount("kv/", &api.MountInput{
+				Type: "kv-v2",
+			}); err != nil {
+				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+			}
+
+			// create a policy without patch capability
+			policy := `path "kv/*" { capabilities = ["create", "update", "read"] }`
+			secretAuth, err := createTokenForPolicy(t, client, policy)
+			if err != nil {
+				t.Fatalf("policy/token creation failed for policy %s, err: %#v\n", policy, err)
+			}
+
+			kvClient, err := client.Clone()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			kvClient.SetToken(secretAuth.ClientToken)
+
+			// Write a value then attempt to patch it
+			_, err = kvClient.Logical().Write("kv/data/foo", map[string]interface{}{"data": map[string]interface{}{"bar": "baz"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code, combined := kvPatchWithRetry(t, kvClient, tc.args, nil)
+
+			if code != tc.code {
+				t.Fatalf("expected code to be %d but was %d", tc.code, code)
+			}
+
+			if !strings.Contains(combined, tc.expected) {
+				t.Errorf("expected %q to contain %q", combined, tc.expected)
+			}
+		})
+	}
+}
+
+func createTokenForPolicy(t *testing.T, client *api.Client, policy string) (*api.SecretAuth, error) {
+	t.Helper()
+
+	if err := client.Sys().PutPolicy("policy", policy); err != nil {
+		return nil, err
+	}
+
+	secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+		Policies: []string{"policy"},
+		TTL:      "30m",
+	})
 	if err != nil {
-		t.Fatalf("read failed, err: %#v\n", err)
+		return nil, err
 	}
 
-	if secret == nil || secret.Data == nil {
-		t.Fatal("expected secret to have data")
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("missing auth data: %#v", secret)
 	}
 
-	secretDataRaw, ok := secret.Data["data"]
+	return secret.Auth, err
+}
 
-	if !ok {
-		t.Fatalf("expected secret to have nested data key, data: %#v", secret.Data)
+func TestKVPatchCommand_RWMethodPolicyVariations(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		policy   string
+		expected string
+		code     int
+	}{
+		// if the policy doesn't have read capability and -method=rw is specified, it fails
+		{
+			"no read",
+			[]string{"-method", "rw", "kv/foo", "bar=quux"},
+			`path "kv/*" { capabilities = ["create", "update"] }`,
+			"permission denied",
+			2,
+		},
+		// if the policy doesn't have update capability and -method=rw is specified, it fails
+		{
+			"no update",
+			[]string{"-method", "rw", "kv/foo", "bar=quux"},
+			`path "kv/*" { capabilities = ["create", "read"] }`,
+			"permission denied",
+			2,
+		},
+		// if the policy has both read and update and -method=rw is specified, it succeeds
+		{
+			"read and update",
+			[]string{"-method", "rw", "kv/foo", "bar=quux"},
+			`path "kv/*" { capabilities = ["create", "read", "update"] }`,
+			"",
+			0,
+		},
 	}
 
-	secretData := secretDataRaw.(map[string]interface{})
-	foo, ok := secretData["foo"].(string)
-	if !ok {
-		t.Fatal("expected foo to be a string but it wasn't")
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			client, closer := testVaultServer(t)
+			defer closer()
+
+			if err := client.Sys().Mount("kv/", &api.MountInput{
+				Type: "kv-v2",
+			}); err != nil {
+				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
+			}
+
+			secretAuth, err := createTokenForPolicy(t, client, tc.policy)
+			if err != nil {
+				t.Fatalf("policy/token creation failed for policy %s, err: %#v\n", tc.policy, err)
+			}
+
+			client.SetToken(secretAuth.ClientToken)
+
+			if _, err := client.Logical().Write("kv/data/foo", map[string]interface{}{
+				"data": map[string]interface{}{
+					"foo": "bar",
+					"bar": "baz",
+				},
+			}); err != nil {
+				t.Fatalf("write failed, err: %#v\n", err)
+			}
+
+			code, combined := kvPatchWithRetry(t, client, tc.args, nil)
+			if code != tc.code {
+				t.Fatalf("expected code to be %d but was %d for patch cmd with args %#v\n", tc.code, code, tc.args)
+			}
+
+			if code != 0 {
+				if !strings.Contains(combined, tc.expected) {
+					t.Fatalf("expected output %q to contain %q for patch cmd with args %#v\n", combined, tc.expected, tc.args)
+				}
+			}
+		})
 	}
+}
 
-	if exp, act := "bar", foo; exp != act {
-		t.Fatalf("expected %q to be %q, data: %#v\n", act, exp, secret.Data)
+func testKVDeleteCommand(tb testing.TB) (*cli.MockUi, *KVDeleteCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVDeleteCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testKVMetadataDeleteCommand(tb testing.TB) (*cli.MockUi, *KVMetadataDeleteCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVMetadataDeleteCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestKVDeleteCommand_Recursive(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) *api.Client {
+		t.Helper()
+
+		client, closer := testVaultServer(t)
+		t.Cleanup(closer)
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Give time for the upgrade code to run/finish
+		time.Sleep(time.Second)
+
+		for _, key := range []string{"app/foo", "app/nested/bar", "other/baz"} {
+			if _, err := client.Logical().Write("kv/data/"+key, map[string]interface{}{
+				"data": map[string]interface{}{"foo": "bar"},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return client
+	}
+
+	readData := func(t *testing.T, client *api.Client, key string) map[string]interface{} {
+		t.Helper()
+
+		secret, err := kvReadRequest(client, "kv/data/"+key, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kvSecretData(secret, true)
+	}
+
+	t.Run("dry_run", func(t *testing.T) {
+		t.Parallel()
+
+		client := setup(t)
+		ui, cmd := testKVDeleteCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-recursive", "-dry-run", "kv/app"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+
+		out := ui.OutputWriter.String()
+		for _, exp := range []string{"kv/app/foo", "kv/app/nested/bar", "dry run"} {
+			if !strings.Contains(out, exp) {
+				t.Errorf("expected %q to contain %q", out, exp)
+			}
+		}
+		if data := readData(t, client, "app/foo"); data == nil {
+			t.Error("expected kv/app/foo to still exist")
+		}
+	})
+
+	t.Run("not_confirmed", func(t *testing.T) {
+		t.Parallel()
+
+		client := setup(t)
+		ui, cmd := testKVDeleteCommand(t)
+		cmd.client = client
+		ui.InputReader = strings.NewReader("n\n")
+
+		code := cmd.Run([]string{"-recursive", "kv/app"})
+		if code != 1 {
+			t.Errorf("expected %d to be %d", code, 1)
+		}
+		if data := readData(t, client, "app/foo"); data == nil {
+			t.Error("expected kv/app/foo to still exist")
+		}
+	})
+
+	t.Run("confirmed", func(t *testing.T) {
+		t.Parallel()
+
+		client := setup(t)
+		ui, cmd := testKVDeleteCommand(t)
+		cmd.client = client
+		ui.InputReader = strings.NewReader("y\n")
+
+		code := cmd.Run([]string{"-recursive", "kv/app"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+		for _, key := range []string{"app/foo", "app/nested/bar"} {
+			if data := readData(t, client, key); data != nil {
+				t.Errorf("expected kv/%s to be deleted, got %v", key, data)
+			}
+		}
+		if data := readData(t, client, "other/baz"); data == nil {
+			t.Error("expected kv/other/baz to still exist")
+		}
+	})
+
+	t.Run("metadata_force", func(t *testing.T) {
+		t.Parallel()
+
+		client := setup(t)
+		ui, cmd := testKVMetadataDeleteCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-recursive", "-force", "-concurrency", "1", "kv/app"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+
+		keys, err := kvListRecursive(nil, client, "kv/", true, "kv/", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := []string{"kv/other/baz"}; !reflect.DeepEqual(keys, exp) {
+			t.Errorf("expected %q to be %q", keys, exp)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testKVDeleteCommand(t)
+		assertNoTabs(t, cmd)
+
+		_, metadataCmd := testKVMetadataDeleteCommand(t)
+		assertNoTabs(t, metadataCmd)
+	})
+}
+
+func testKVCopyCommand(tb testing.TB) (*cli.MockUi, *KVCopyCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVCopyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
 	}
 }
 
-func TestKvPatchCommand_StdinValue(t *testing.T) {
-	client, closer := testVaultServer(t)
-	defer closer()
-
-	if err := client.Sys().Mount("kv/", &api.MountInput{
-		Type: "kv-v2",
-	}); err != nil {
-		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-	}
+func testKVMoveCommand(tb testing.TB) (*cli.MockUi, *KVMoveCommand) {
+	tb.Helper()
 
-	if _, err := client.Logical().Write("kv/data/patch/foo", map[string]interface{}{
-		"data": map[string]interface{}{
-			"foo": "a",
+	ui := cli.NewMockUi()
+	return ui, &KVMoveCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
 		},
-	}); err != nil {
-		t.Fatalf("write failed, err: %#v\n", err)
 	}
+}
 
-	stdinR, stdinW := io.Pipe()
-	go func() {
-		stdinW.Write([]byte("bar"))
-		stdinW.Close()
-	}()
+func TestKVCopyMoveCommand(t *testing.T) {
+	t.Parallel()
 
-	args := []string{"kv/patch/foo", "foo=-"}
-	code, combined := kvPatchWithRetry(t, client, args, stdinR)
-	if code != 0 {
-		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v\n", code, args)
-	}
+	setup := func(t *testing.T) *api.Client {
+		t.Helper()
 
-	for _, str := range expectedPatchFields() {
-		if !strings.Contains(combined, str) {
-			t.Errorf("expected %q to contain %q", combined, str)
+		client, closer := testVaultServer(t)
+		t.Cleanup(closer)
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
 		}
-	}
 
-	secret, err := client.Logical().Read("kv/data/patch/foo")
-	if err != nil {
-		t.Fatalf("read failed, err: %#v\n", err)
-	}
+		// Give time for the upgrade code to run/finish
+		time.Sleep(time.Second)
 
-	if secret == nil || secret.Data == nil {
-		t.Fatal("expected secret to have data")
+		for _, data := range []map[string]interface{}{
+			{"foo": "bar"},
+			{"foo": "baz"},
+		} {
+			if _, err := client.Logical().Write("kv/data/app/foo", map[string]interface{}{
+				"data": data,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := client.Logical().Write("kv/data/app/nested/bar", map[string]interface{}{
+			"data": map[string]interface{}{"zip": "zap"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("kv/metadata/app/foo", map[string]interface{}{
+			"custom_metadata": map[string]interface{}{"owner": "ops"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return client
 	}
 
-	secretDataRaw, ok := secret.Data["data"]
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
 
-	if !ok {
-		t.Fatalf("expected secret to have nested data key, data: %#v\n", secret.Data)
-	}
+		ui, cmd := testKVCopyCommand(t)
+		if code := cmd.Run([]string{"kv/app/foo"}); code != 1 {
+			t.Errorf("expected %d to be %d", code, 1)
+		}
+		if exp, act := "Invalid number of arguments", ui.ErrorWriter.String(); !strings.Contains(act, exp) {
+			t.Errorf("expected %q to contain %q", act, exp)
+		}
 
-	secretData := secretDataRaw.(map[string]interface{})
+		client := setup(t)
+		ui, cmd = testKVCopyCommand(t)
+		cmd.client = client
+		if code := cmd.Run([]string{"kv/app/foo", "kv/app/foo/"}); code != 1 {
+			t.Errorf("expected %d to be %d", code, 1)
+		}
+		if exp, act := "must be different", ui.ErrorWriter.String(); !strings.Contains(act, exp) {
+			t.Errorf("expected %q to contain %q", act, exp)
+		}
+	})
 
-	if exp, act := "bar", secretData["foo"].(string); exp != act {
-		t.Fatalf("expected %q to be %q, data: %#v\n", act, exp, secret.Data)
-	}
-}
+	t.Run("copy", func(t *testing.T) {
+		t.Parallel()
 
-func TestKVPatchCommand_RWMethodNotExists(t *testing.T) {
-	client, closer := testVaultServer(t)
-	defer closer()
+		client := setup(t)
+		ui, cmd := testKVCopyCommand(t)
+		cmd.client = client
 
-	if err := client.Sys().Mount("kv/", &api.MountInput{
-		Type: "kv-v2",
-	}); err != nil {
-		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-	}
+		code := cmd.Run([]string{"kv/app/foo", "secret/copied"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
 
-	args := []string{"-method", "rw", "kv/patch/foo", "foo=a"}
-	code, combined := kvPatchWithRetry(t, client, args, nil)
+		secret, err := client.Logical().Read("secret/copied")
+		if err != nil || secret == nil {
+			t.Fatalf("expected secret, got %v: %v", secret, err)
+		}
+		if v := secret.Data["foo"]; v != "baz" {
+			t.Errorf("expected %q to be %q", v, "baz")
+		}
+	})
 
-	if code != 2 {
-		t.Fatalf("expected code to be 2 but was %d for patch cmd with args %#v\n", code, args)
-	}
+	t.Run("move_recursive_all_versions", func(t *testing.T) {
+		t.Parallel()
 
-	expectedOutputSubstr := "No value found"
-	if !strings.Contains(combined, expectedOutputSubstr) {
-		t.Fatalf("expected output %q to contain %q for patch cmd with args %#v\n", combined, expectedOutputSubstr, args)
-	}
-}
+		client := setup(t)
+		ui, cmd := testKVMoveCommand(t)
+		cmd.client = client
 
-func TestKVPatchCommand_RWMethodSucceeds(t *testing.T) {
-	client, closer := testVaultServer(t)
-	defer closer()
+		code := cmd.Run([]string{"-r", "-all-versions", "kv/app", "kv/moved"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
 
-	if err := client.Sys().Mount("kv/", &api.MountInput{
-		Type: "kv-v2",
-	}); err != nil {
-		t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-	}
+		keys, err := kvListRecursive(nil, client, "kv/", true, "kv/", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := []string{"kv/moved/foo", "kv/moved/nested/bar"}; !reflect.DeepEqual(keys, exp) {
+			t.Errorf("expected %q to be %q", keys, exp)
+		}
 
-	if _, err := client.Logical().Write("kv/data/patch/foo", map[string]interface{}{
-		"data": map[string]interface{}{
-			"foo": "a",
-			"bar": "b",
+		metadata, err := client.Logical().Read("kv/metadata/moved/foo")
+		if err != nil || metadata == nil {
+			t.Fatalf("expected metadata, got %v: %v", metadata, err)
+		}
+		if v := fmt.Sprintf("%v", metadata.Data["current_version"]); v != "2" {
+			t.Errorf("expected current version %q to be %q", v, "2")
+		}
+		custom, _ := metadata.Data["custom_metadata"].(map[string]interface{})
+		if v := custom["owner"]; v != "ops" {
+			t.Errorf("expected %q to be %q", v, "ops")
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, copyCmd := testKVCopyCommand(t)
+		assertNoTabs(t, copyCmd)
+
+		_, moveCmd := testKVMoveCommand(t)
+		assertNoTabs(t, moveCmd)
+	})
+}
+
+func testKVRollbackCommand(tb testing.TB) (*cli.MockUi, *KVRollbackCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVRollbackCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
 		},
-	}); err != nil {
-		t.Fatalf("write failed, err: %#v\n", err)
 	}
+}
 
-	// Test single value
-	args := []string{"-method", "rw", "kv/patch/foo", "foo=aa"}
-	code, combined := kvPatchWithRetry(t, client, args, nil)
+func TestKVRollbackCommand(t *testing.T) {
+	t.Parallel()
 
-	if code != 0 {
-		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v\n", code, args)
-	}
+	setup := func(t *testing.T) *api.Client {
+		t.Helper()
 
-	for _, str := range expectedPatchFields() {
-		if !strings.Contains(combined, str) {
-			t.Errorf("expected %q to contain %q", combined, str)
+		client, closer := testVaultServer(t)
+		t.Cleanup(closer)
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
 		}
-	}
-
-	// Test multi value
-	args = []string{"-method", "rw", "kv/patch/foo", "foo=aaa", "bar=bbb"}
-	code, combined = kvPatchWithRetry(t, client, args, nil)
 
-	if code != 0 {
-		t.Fatalf("expected code to be 0 but was %d for patch cmd with args %#v\n", code, args)
-	}
+		// Give time for the upgrade code to run/finish
+		time.Sleep(time.Second)
 
-	for _, str := range expectedPatchFields() {
-		if !strings.Contains(combined, str) {
-			t.Errorf("expected %q to contain %q", combined, str)
+		for _, data := range []map[string]interface{}{
+			{"foo": "a", "bar": "b"},
+			{"foo": "c", "baz": "d"},
+		} {
+			if _, err := client.Logical().Write("kv/data/rollback", map[string]interface{}{
+				"data": data,
+			}); err != nil {
+				t.Fatal(err)
+			}
 		}
+		return client
 	}
-}
 
-func TestKVPatchCommand_CAS(t *testing.T) {
-	cases := []struct {
-		name       string
-		args       []string
-		expected   string
-		outStrings []string
-		code       int
-	}{
-		{
-			"right version",
-			[]string{"-cas", "1", "kv/foo", "bar=quux"},
-			"quux",
-			expectedPatchFields(),
-			0,
-		},
-		{
-			"wrong version",
-			[]string{"-cas", "2", "kv/foo", "bar=wibble"},
-			"baz",
-			[]string{"check-and-set parameter did not match the current version"},
-			2,
-		},
-	}
+	readData := func(t *testing.T, client *api.Client) map[string]interface{} {
+		t.Helper()
 
-	for _, tc := range cases {
-		tc := tc
+		secret, err := kvReadRequest(client, "kv/data/rollback", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kvSecretData(secret, true)
+	}
 
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
+	t.Run("dry_run", func(t *testing.T) {
+		t.Parallel()
 
-			client, closer := testVaultServer(t)
-			defer closer()
+		client := setup(t)
+		ui, cmd := testKVRollbackCommand(t)
+		cmd.client = client
 
-			if err := client.Sys().Mount("kv/", &api.MountInput{
-				Type: "kv-v2",
-			}); err != nil {
-				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-			}
+		code := cmd.Run([]string{"-dry-run", "-version=1", "kv/rollback"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
 
-			// create a policy with patch capability
-			policy := `path "kv/*" { capabilities = ["create", "update", "read", "patch"] }`
-			secretAuth, err := createTokenForPolicy(t, client, policy)
-			if err != nil {
-				t.Fatalf("policy/token creation failed for policy %s, err: %#v\n", policy, err)
+		out := ui.OutputWriter.String()
+		for _, exp := range []string{"+ bar: b", "- baz: d", "~ foo: c -> a", "dry run"} {
+			if !strings.Contains(out, exp) {
+				t.Errorf("expected %q to contain %q", out, exp)
 			}
+		}
+		if exp, act := "c", readData(t, client)["foo"]; exp != act {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+	})
 
-			kvClient, err := client.Clone()
-			if err != nil {
-				t.Fatal(err)
-			}
+	t.Run("not_confirmed", func(t *testing.T) {
+		t.Parallel()
 
-			kvClient.SetToken(secretAuth.ClientToken)
+		client := setup(t)
+		ui, cmd := testKVRollbackCommand(t)
+		cmd.client = client
+		cmd.testInteractive = true
+		ui.InputReader = strings.NewReader("n\n")
 
-			_, err = kvClient.Logical().Write("kv/data/foo", map[string]interface{}{"data": map[string]interface{}{"bar": "baz"}})
-			if err != nil {
-				t.Fatal(err)
-			}
+		code := cmd.Run([]string{"-version=1", "kv/rollback"})
+		if code != 1 {
+			t.Errorf("expected %d to be %d", code, 1)
+		}
+		if exp, act := "c", readData(t, client)["foo"]; exp != act {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+	})
 
-			code, combined := kvPatchWithRetry(t, kvClient, tc.args, nil)
+	t.Run("confirmed", func(t *testing.T) {
+		t.Parallel()
 
-			if code != tc.code {
-				t.Fatalf("expected code to be %d but was %d", tc.code, code)
-			}
+		client := setup(t)
+		ui, cmd := testKVRollbackCommand(t)
+		cmd.client = client
+		cmd.testInteractive = true
+		ui.InputReader = strings.NewReader("y\n")
 
-			for _, str := range tc.outStrings {
-				if !strings.Contains(combined, str) {
-					t.Errorf("expected %q to contain %q", combined, str)
-				}
-			}
+		code := cmd.Run([]string{"-version=1", "kv/rollback"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+		exp := map[string]interface{}{"foo": "a", "bar": "b"}
+		if data := readData(t, client); !reflect.DeepEqual(data, exp) {
+			t.Errorf("expected %#v to be %#v", data, exp)
+		}
+	})
 
-			secret, err := kvClient.Logical().Read("kv/data/foo")
-			bar := secret.Data["data"].(map[string]interface{})["bar"]
-			if bar != tc.expected {
-				t.Fatalf("expected bar to be %q but it was %q", tc.expected, bar)
-			}
-		})
-	}
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testKVRollbackCommand(t)
+		assertNoTabs(t, cmd)
+	})
 }
 
-func TestKVPatchCommand_Methods(t *testing.T) {
+func TestKVParseVersionsFlags(t *testing.T) {
+	t.Parallel()
+
 	cases := []struct {
 		name     string
-		args     []string
-		expected string
-		code     int
+		versions []string
+		exp      []string
+		err      bool
 	}{
-		{
-			"rw",
-			[]string{"-method", "rw", "kv/foo", "bar=quux"},
-			"quux",
-			0,
-		},
-		{
-			"patch",
-			[]string{"-method", "patch", "kv/foo", "bar=wibble"},
-			"wibble",
-			0,
-		},
+		{"empty", nil, []string{}, false},
+		{"single", []string{"3"}, []string{"3"}, false},
+		{"comma_separated", []string{"1,3", "5"}, []string{"1", "3", "5"}, false},
+		{"range", []string{"3-6"}, []string{"3", "4", "5", "6"}, false},
+		{"range_and_single", []string{"1,3-4"}, []string{"1", "3", "4"}, false},
+		{"single_version_range", []string{"2-2"}, []string{"2"}, false},
+		{"reversed_range", []string{"6-3"}, nil, true},
+		{"zero_start", []string{"0-3"}, nil, true},
+		{"invalid_range", []string{"a-3"}, nil, true},
 	}
 
 	for _, tc := range cases {
@@ -993,215 +2353,171 @@ func TestKVPatchCommand_Methods(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			client, closer := testVaultServer(t)
-			defer closer()
-
-			if err := client.Sys().Mount("kv/", &api.MountInput{
-				Type: "kv-v2",
-			}); err != nil {
-				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-			}
-
-			// create a policy with patch capability
-			policy := `path "kv/*" { capabilities = ["create", "update", "read", "patch"] }`
-			secretAuth, err := createTokenForPolicy(t, client, policy)
-			if err != nil {
-				t.Fatalf("policy/token creation failed for policy %s, err: %#v\n", policy, err)
-			}
-
-			kvClient, err := client.Clone()
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			kvClient.SetToken(secretAuth.ClientToken)
-
-			_, err = kvClient.Logical().Write("kv/data/foo", map[string]interface{}{"data": map[string]interface{}{"bar": "baz"}})
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			code, _ := kvPatchWithRetry(t, kvClient, tc.args, nil)
-
-			if code != tc.code {
-				t.Fatalf("expected code to be %d but was %d", tc.code, code)
+			versions, err := kvParseVersionsFlags(tc.versions)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error to be %t: %v", tc.err, err)
 			}
-
-			secret, err := kvClient.Logical().Read("kv/data/foo")
-			bar := secret.Data["data"].(map[string]interface{})["bar"]
-			if bar != tc.expected {
-				t.Fatalf("expected bar to be %q but it was %q", tc.expected, bar)
+			if !tc.err && !reflect.DeepEqual(versions, tc.exp) {
+				t.Errorf("expected %q to be %q", versions, tc.exp)
 			}
 		})
 	}
 }
 
-func TestKVPatchCommand_403Fallback(t *testing.T) {
-	cases := []struct {
-		name     string
-		args     []string
-		expected string
-		code     int
-	}{
-		// if no -method is specified, and patch fails, it should fall back to rw and succeed
-		{
-			"unspecified",
-			[]string{"kv/foo", "bar=quux"},
-			`add the "patch" capability to your ACL policy`,
-			0,
-		},
-		// if -method=patch is specified, and patch fails, it should not fall back, and just error
-		{
-			"specifying patch",
-			[]string{"-method", "patch", "kv/foo", "bar=quux"},
-			"permission denied",
-			2,
+func testKVUndeleteCommand(tb testing.TB) (*cli.MockUi, *KVUndeleteCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &KVUndeleteCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
 		},
 	}
+}
 
-	for _, tc := range cases {
-		tc := tc
+func testKVDestroyCommand(tb testing.TB) (*cli.MockUi, *KVDestroyCommand) {
+	tb.Helper()
 
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-			client, closer := testVaultServer(t)
-			defer closer()
+	ui := cli.NewMockUi()
+	return ui, &KVDestroyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
 
-			if err := client.Sys().Mount("kv/", &api.MountInput{
-				Type: "kv-v2",
-			}); err != nil {
-				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-			}
+func TestKVUndeleteDestroyCommand_Versions(t *testing.T) {
+	t.Parallel()
 
-			// create a policy without patch capability
-			policy := `path "kv/*" { capabilities = ["create", "update", "read"] }`
-			secretAuth, err := createTokenForPolicy(t, client, policy)
-			if err != nil {
-				t.Fatalf("policy/token creation failed for policy %s, err: %#v\n", policy, err)
-			}
+	setup := func(t *testing.T) *api.Client {
+		t.Helper()
 
-			kvClient, err := client.Clone()
-			if err != nil {
-				t.Fatal(err)
-			}
+		client, closer := testVaultServer(t)
+		t.Cleanup(closer)
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
 
-			kvClient.SetToken(secretAuth.ClientToken)
+		// Give time for the upgrade code to run/finish
+		time.Sleep(time.Second)
 
-			// Write a value then attempt to patch it
-			_, err = kvClient.Logical().Write("kv/data/foo", map[string]interface{}{"data": map[string]interface{}{"bar": "baz"}})
-			if err != nil {
+		for _, key := range []string{"app/foo", "app/nested/bar"} {
+			for i := 0; i < 3; i++ {
+				if _, err := client.Logical().Write("kv/data/"+key, map[string]interface{}{
+					"data": map[string]interface{}{"version": i + 1},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := client.Logical().Write("kv/delete/"+key, map[string]interface{}{
+				"versions": []string{"1", "2", "3"},
+			}); err != nil {
 				t.Fatal(err)
 			}
+		}
+		return client
+	}
 
-			code, combined := kvPatchWithRetry(t, kvClient, tc.args, nil)
-
-			if code != tc.code {
-				t.Fatalf("expected code to be %d but was %d", tc.code, code)
-			}
+	versionState := func(t *testing.T, client *api.Client, key string) map[string]string {
+		t.Helper()
 
-			if !strings.Contains(combined, tc.expected) {
-				t.Errorf("expected %q to contain %q", combined, tc.expected)
+		secret, err := client.Logical().Read("kv/metadata/" + key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state := make(map[string]string)
+		for version, raw := range secret.Data["versions"].(map[string]interface{}) {
+			info := raw.(map[string]interface{})
+			switch {
+			case info["destroyed"].(bool):
+				state[version] = "destroyed"
+			case info["deletion_time"].(string) != "":
+				state[version] = "deleted"
+			default:
+				state[version] = "live"
 			}
-		})
+		}
+		return state
 	}
-}
 
-func createTokenForPolicy(t *testing.T, client *api.Client, policy string) (*api.SecretAuth, error) {
-	t.Helper()
+	t.Run("undelete_range", func(t *testing.T) {
+		t.Parallel()
 
-	if err := client.Sys().PutPolicy("policy", policy); err != nil {
-		return nil, err
-	}
+		client := setup(t)
+		ui, cmd := testKVUndeleteCommand(t)
+		cmd.client = client
 
-	secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
-		Policies: []string{"policy"},
-		TTL:      "30m",
+		code := cmd.Run([]string{"-versions=2-3", "kv/app/foo"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
+
+		exp := map[string]string{"1": "deleted", "2": "live", "3": "live"}
+		if state := versionState(t, client, "app/foo"); !reflect.DeepEqual(state, exp) {
+			t.Errorf("expected %v to be %v", state, exp)
+		}
 	})
-	if err != nil {
-		return nil, err
-	}
 
-	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
-		return nil, fmt.Errorf("missing auth data: %#v", secret)
-	}
+	t.Run("undelete_all_recursive", func(t *testing.T) {
+		t.Parallel()
 
-	return secret.Auth, err
-}
+		client := setup(t)
+		ui, cmd := testKVUndeleteCommand(t)
+		cmd.client = client
 
-func TestKVPatchCommand_RWMethodPolicyVariations(t *testing.T) {
-	cases := []struct {
-		name     string
-		args     []string
-		policy   string
-		expected string
-		code     int
-	}{
-		// if the policy doesn't have read capability and -method=rw is specified, it fails
-		{
-			"no read",
-			[]string{"-method", "rw", "kv/foo", "bar=quux"},
-			`path "kv/*" { capabilities = ["create", "update"] }`,
-			"permission denied",
-			2,
-		},
-		// if the policy doesn't have update capability and -method=rw is specified, it fails
-		{
-			"no update",
-			[]string{"-method", "rw", "kv/foo", "bar=quux"},
-			`path "kv/*" { capabilities = ["create", "read"] }`,
-			"permission denied",
-			2,
-		},
-		// if the policy has both read and update and -method=rw is specified, it succeeds
-		{
-			"read and update",
-			[]string{"-method", "rw", "kv/foo", "bar=quux"},
-			`path "kv/*" { capabilities = ["create", "read", "update"] }`,
-			"",
-			0,
-		},
-	}
+		code := cmd.Run([]string{"-all", "-recursive", "-force", "kv/app"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
 
-	for _, tc := range cases {
-		tc := tc
+		exp := map[string]string{"1": "live", "2": "live", "3": "live"}
+		for _, key := range []string{"app/foo", "app/nested/bar"} {
+			if state := versionState(t, client, key); !reflect.DeepEqual(state, exp) {
+				t.Errorf("%s: expected %v to be %v", key, state, exp)
+			}
+		}
+	})
 
-		t.Run(tc.name, func(t *testing.T) {
-			t.Parallel()
-			client, closer := testVaultServer(t)
-			defer closer()
+	t.Run("destroy_all", func(t *testing.T) {
+		t.Parallel()
 
-			if err := client.Sys().Mount("kv/", &api.MountInput{
-				Type: "kv-v2",
-			}); err != nil {
-				t.Fatalf("kv-v2 mount attempt failed - err: %#v\n", err)
-			}
+		client := setup(t)
+		ui, cmd := testKVDestroyCommand(t)
+		cmd.client = client
 
-			secretAuth, err := createTokenForPolicy(t, client, tc.policy)
-			if err != nil {
-				t.Fatalf("policy/token creation failed for policy %s, err: %#v\n", tc.policy, err)
-			}
+		code := cmd.Run([]string{"-all", "kv/app/foo"})
+		if code != 0 {
+			t.Fatalf("expected %d to be %d: %s", code, 0, ui.ErrorWriter.String())
+		}
 
-			client.SetToken(secretAuth.ClientToken)
+		exp := map[string]string{"1": "destroyed", "2": "destroyed", "3": "destroyed"}
+		if state := versionState(t, client, "app/foo"); !reflect.DeepEqual(state, exp) {
+			t.Errorf("expected %v to be %v", state, exp)
+		}
+	})
 
-			if _, err := client.Logical().Write("kv/data/foo", map[string]interface{}{
-				"data": map[string]interface{}{
-					"foo": "bar",
-					"bar": "baz",
-				},
-			}); err != nil {
-				t.Fatalf("write failed, err: %#v\n", err)
-			}
+	t.Run("versions_and_all", func(t *testing.T) {
+		t.Parallel()
 
-			code, combined := kvPatchWithRetry(t, client, tc.args, nil)
-			if code != tc.code {
-				t.Fatalf("expected code to be %d but was %d for patch cmd with args %#v\n", tc.code, code, tc.args)
-			}
+		ui, cmd := testKVDestroyCommand(t)
+		code := cmd.Run([]string{"-all", "-versions=1", "kv/app/foo"})
+		if code != 1 {
+			t.Errorf("expected %d to be %d", code, 1)
+		}
+		if combined := ui.ErrorWriter.String(); !strings.Contains(combined, "Only one of") {
+			t.Errorf("expected %q to contain %q", combined, "Only one of")
+		}
+	})
 
-			if code != 0 {
-				if !strings.Contains(combined, tc.expected) {
-					t.Fatalf("expected output %q to contain %q for patch cmd with args %#v\n", combined, tc.expected, tc.args)
-				}
-			}
-		})
-	}
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, undeleteCmd := testKVUndeleteCommand(t)
+		assertNoTabs(t, undeleteCmd)
+
+		_, destroyCmd := testKVDestroyCommand(t)
+		assertNoTabs(t, destroyCmd)
+	})
 }
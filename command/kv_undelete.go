@@ -16,7 +16,9 @@ var (
 type KVUndeleteCommand struct {
 	*BaseCommand
 
-	flagVersions []string
+	flagVersions      []string
+	flagAll           bool
+	flagRecursiveOpts kvRecursiveOptions
 }
 
 func (c *KVUndeleteCommand) Synopsis() string {
@@ -34,6 +36,15 @@ Usage: vault kv undelete [options] KEY
   
       $ vault kv undelete -versions=3 secret/foo
 
+  To undelete versions 3 through 9 of key "foo":
+
+      $ vault kv undelete -versions=3-9 secret/foo
+
+  To undelete every version of every key under "secret/app", without asking
+  for confirmation:
+
+      $ vault kv undelete -all -recursive -force secret/app
+
   Additional flags and more advanced use cases are detailed below.
 
 ` + c.Flags().Help()
@@ -50,9 +61,18 @@ func (c *KVUndeleteCommand) Flags() *FlagSets {
 		Name:    "versions",
 		Target:  &c.flagVersions,
 		Default: nil,
-		Usage:   `Specifies the version numbers to undelete.`,
+		Usage:   `Specifies the version numbers to undelete. Ranges such as "3-9" are also accepted.`,
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "all",
+		Target:  &c.flagAll,
+		Default: false,
+		Usage:   "Undelete every version of the secret.",
+	})
+
+	addKVRecursiveFlags(f, &c.flagRecursiveOpts, "undelete")
+
 	return set
 }
 
@@ -82,8 +102,18 @@ func (c *KVUndeleteCommand) Run(args []string) int {
 		return 1
 	}
 
-	if len(c.flagVersions) == 0 {
-		c.UI.Error("No versions provided, use the \"-versions\" flag to specify the version to undelete.")
+	switch {
+	case len(c.flagVersions) == 0 && !c.flagAll:
+		c.UI.Error("No versions provided, use the \"-versions\" or \"-all\" flag to specify the versions to undelete.")
+		return 1
+	case len(c.flagVersions) > 0 && c.flagAll:
+		c.UI.Error("Only one of the \"-versions\" and \"-all\" flags can be specified")
+		return 1
+	}
+
+	versions, err := kvParseVersionsFlags(c.flagVersions)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing versions: %s", err), err)
 		return 1
 	}
 
@@ -104,14 +134,19 @@ func (c *KVUndeleteCommand) Run(args []string) int {
 		return 1
 	}
 
-	path = addPrefixToKVPath(path, mountPath, "undelete")
-	data := map[string]interface{}{
-		"versions": kvParseVersionsFlags(c.flagVersions),
+	if c.flagRecursiveOpts.Recursive {
+		return kvRunRecursive(c.UI, client, mountPath, v2, ensureTrailingSlash(path), "undeleted", c.flagRecursiveOpts, func(key string) error {
+			if _, err := kvWriteVersions(client, mountPath, key, "undelete", versions, c.flagAll); err != nil {
+				return fmt.Errorf("Error undeleting %s: %s", key, err)
+			}
+			return nil
+		})
 	}
 
-	secret, err := client.Logical().Write(path, data)
+	secret, err := kvWriteVersions(client, mountPath, path, "undelete", versions, c.flagAll)
+	path = addPrefixToKVPath(path, mountPath, "undelete")
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
@@ -20,8 +20,12 @@ func (c *LeaseCommand) Help() string {
 	helpText := `
 Usage: vault lease <subcommand> [options] [args]
 
-  This command groups subcommands for interacting with leases. Users can revoke
-  or renew leases.
+  This command groups subcommands for interacting with leases. Users can list,
+  revoke or renew leases.
+
+  List the leases of a mount:
+
+      $ vault lease list database/
 
   Renew a lease:
 
@@ -0,0 +1,194 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+	"github.com/ryanuber/columnize"
+)
+
+var (
+	_ cli.Command             = (*LeaseListCommand)(nil)
+	_ cli.CommandAutocomplete = (*LeaseListCommand)(nil)
+)
+
+type LeaseListCommand struct {
+	*BaseCommand
+
+	flagExpiresWithin time.Duration
+	flagIssuedAfter   string
+	flagIssuedBefore  string
+	flagLimit         string
+}
+
+func (c *LeaseListCommand) Synopsis() string {
+	return "Lists leases and their metadata"
+}
+
+func (c *LeaseListCommand) Help() string {
+	helpText := `
+Usage: vault lease list [options] [PREFIX]
+
+  Lists the leases whose ID starts with PREFIX, such as the path of a mount,
+  together with their issue time, expire time, TTL and whether they are
+  renewable. Leases are sorted by expire time, soonest first. This requires
+  sudo capability on sys/leases/metadata.
+
+  List all database leases that expire within the next hour:
+
+      $ vault lease list -expires-within=1h database/
+
+  List the leases issued since the start of March 2022:
+
+      $ vault lease list -issued-after=2022-03-01T00:00:00Z
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LeaseListCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.DurationVar(&DurationVar{
+		Name:       "expires-within",
+		Target:     &c.flagExpiresWithin,
+		Default:    0,
+		Completion: complete.PredictAnything,
+		Usage:      "Only list leases which expire within this duration.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "issued-after",
+		Target:     &c.flagIssuedAfter,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage:      "Only list leases issued at or after this RFC3339 time.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "issued-before",
+		Target:     &c.flagIssuedBefore,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage:      "Only list leases issued before this RFC3339 time.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "limit",
+		Target:     &c.flagLimit,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Maximum number of leases to list, or \"none\" to list all of " +
+			"them. Vault lists at most 10,000 leases by default.",
+	})
+
+	return set
+}
+
+func (c *LeaseListCommand) AutocompleteArgs() complete.Predictor {
+	return c.PredictVaultFiles()
+}
+
+func (c *LeaseListCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *LeaseListCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	prefix := ""
+	args = f.Args()
+	switch len(args) {
+	case 0:
+	case 1:
+		prefix = strings.TrimSpace(args[0])
+	default:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0-1, got %d)", len(args)))
+		return 1
+	}
+
+	data := map[string][]string{}
+	if prefix != "" {
+		data["prefix"] = []string{prefix}
+	}
+	if c.flagExpiresWithin > 0 {
+		data["expires_within"] = []string{strconv.Itoa(truncateToSeconds(c.flagExpiresWithin))}
+	}
+	for name, value := range map[string]string{
+		"issued_after":  c.flagIssuedAfter,
+		"issued_before": c.flagIssuedBefore,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			outputError(c.UI, fmt.Sprintf("Invalid time for -%s: %s", strings.Replace(name, "_", "-", 1), err), err)
+			return 1
+		}
+		data[name] = []string{value}
+	}
+	if c.flagLimit != "" {
+		data["limit"] = []string{c.flagLimit}
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	secret, err := client.Logical().ReadWithData("sys/leases/metadata", data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error listing leases: %s", err), err)
+		return 2
+	}
+	if secret == nil || secret.Data == nil {
+		c.UI.Error("Invalid response returned from Vault")
+		return 2
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputSecret(c.UI, secret)
+	}
+
+	for _, warning := range secret.Warnings {
+		c.UI.Warn(fmt.Sprintf("WARNING! %s", warning))
+	}
+
+	leases, _ := secret.Data["leases"].([]interface{})
+	if len(leases) == 0 {
+		c.UI.Error("No leases found")
+		return 2
+	}
+
+	table := []string{"Lease ID | Issue Time | Expire Time | TTL | Renewable"}
+	for _, leaseRaw := range leases {
+		lease, ok := leaseRaw.(map[string]interface{})
+		if !ok {
+			c.UI.Error("Expected leases in response to be maps")
+			return 2
+		}
+
+		expireTime, ttl := "n/a", "n/a"
+		if lease["expire_time"] != nil {
+			expireTime = fmt.Sprintf("%v", lease["expire_time"])
+			ttl = fmt.Sprintf("%v", humanDurationInt(lease["ttl"]))
+		}
+		table = append(table, fmt.Sprintf("%v | %v | %s | %s | %v",
+			lease["lease_id"], lease["issue_time"], expireTime, ttl, lease["renewable"]))
+	}
+
+	c.UI.Output(tableOutput(table, columnize.DefaultConfig()))
+	return 0
+}
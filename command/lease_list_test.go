@@ -0,0 +1,132 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testLeaseListCommand(tb testing.TB) (*cli.MockUi, *LeaseListCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &LeaseListCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestLeaseListCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"invalid_time",
+			[]string{"-issued-after=yesterday"},
+			"Invalid time for -issued-after",
+			1,
+		},
+		{
+			"prefix",
+			[]string{"testing/"},
+			"testing/foo/",
+			0,
+		},
+		{
+			"expires_within",
+			[]string{"-expires-within=10m", "testing/"},
+			"testing/foo/",
+			0,
+		},
+		{
+			"expires_later",
+			[]string{"-expires-within=1m", "testing/"},
+			"No leases found",
+			2,
+		},
+		{
+			"issued_after",
+			[]string{"-issued-after=2100-01-01T00:00:00Z", "testing/"},
+			"No leases found",
+			2,
+		},
+		{
+			"other_prefix",
+			[]string{"database/"},
+			"No leases found",
+			2,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		leaseID := testLeaseRenewCommandMountAndLease(t, client)
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				ui, cmd := testLeaseListCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+				if tc.code == 0 && !strings.Contains(combined, leaseID) {
+					t.Errorf("expected %q to contain %q", combined, leaseID)
+				}
+			})
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testLeaseListCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error listing leases: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testLeaseListCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -83,7 +83,7 @@ func (c *LeaseLookupCommand) Run(args []string) int {
 
 	secret, err := client.Sys().Lookup(leaseID)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("error looking up lease id %s: %s", leaseID, err))
+		outputError(c.UI, fmt.Sprintf("error looking up lease id %s: %s", leaseID, err), err)
 		return 2
 	}
 
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -18,8 +19,16 @@ type LeaseRenewCommand struct {
 	*BaseCommand
 
 	flagIncrement time.Duration
+	flagFollow    bool
+
+	// ShutdownCh is used to capture interrupt signal and stop following
+	ShutdownCh chan struct{}
 }
 
+// leaseFollowRenewFraction is the fraction of a lease's TTL after which it
+// is renewed again when following it.
+const leaseFollowRenewFraction = 2.0 / 3.0
+
 func (c *LeaseRenewCommand) Synopsis() string {
 	return "Renews the lease of a secret"
 }
@@ -40,8 +49,15 @@ Usage: vault lease renew [options] ID
 
       $ vault lease renew database/creds/readonly/2f6a614c...
 
+  Keep renewing a lease in the foreground until interrupted, for example while a
+  long-running job uses the secret:
+
+      $ vault lease renew -follow database/creds/readonly/2f6a614c...
+
   Lease renewal will fail if the secret is not renewable, the secret has already
-  been revoked, or if the secret has already reached its maximum TTL.
+  been revoked, or if the secret has already reached its maximum TTL. When
+  following a lease, the command exits with an error as soon as the lease can
+  no longer be extended.
 
   For a full list of examples, please see the documentation.
 
@@ -64,6 +80,16 @@ func (c *LeaseRenewCommand) Flags() *FlagSets {
 			"to honor this request.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "follow",
+		Target:  &c.flagFollow,
+		Default: false,
+		Usage: "Keep renewing the lease in the foreground, each time two thirds " +
+			"of its TTL have passed, and print a line for every renewal. The " +
+			"command exits with an error once the lease cannot be renewed or " +
+			"extended any further.",
+	})
+
 	return set
 }
 
@@ -104,11 +130,54 @@ func (c *LeaseRenewCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagFollow {
+		return c.follow(client, leaseID, truncateToSeconds(increment))
+	}
+
 	secret, err := client.Sys().Renew(leaseID, truncateToSeconds(increment))
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error renewing %s: %s", leaseID, err))
+		outputError(c.UI, fmt.Sprintf("Error renewing %s: %s", leaseID, err), err)
 		return 2
 	}
 
 	return OutputSecret(c.UI, secret)
 }
+
+// follow renews the lease until it cannot be extended any more, or the
+// command is interrupted.
+func (c *LeaseRenewCommand) follow(client *api.Client, leaseID string, increment int) int {
+	var expiry time.Time
+	for {
+		secret, err := client.Sys().Renew(leaseID, increment)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error renewing %s: %s", leaseID, err), err)
+			return 2
+		}
+		if secret == nil || !secret.Renewable || secret.LeaseDuration <= 0 {
+			c.UI.Error(fmt.Sprintf("Lease %s is not renewable", leaseID))
+			return 2
+		}
+
+		now := time.Now()
+		ttl := time.Duration(secret.LeaseDuration) * time.Second
+
+		// TTLs are whole seconds, so anything less than a second later than
+		// the previous expiry is the same: the lease hit its max TTL.
+		previous := expiry
+		expiry = now.Add(ttl)
+		if !previous.IsZero() && expiry.Before(previous.Add(time.Second)) {
+			c.UI.Error(fmt.Sprintf("Lease %s can no longer be extended; it expires at %s",
+				leaseID, expiry.Format(time.RFC3339)))
+			return 2
+		}
+
+		c.UI.Info(fmt.Sprintf("%s: renewed lease %s, expires in %s",
+			now.Format(time.RFC3339), leaseID, ttl))
+
+		select {
+		case <-c.ShutdownCh:
+			return 0
+		case <-time.After(time.Duration(float64(ttl) * leaseFollowRenewFraction)):
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package command
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/hashicorp/vault/api"
@@ -16,6 +19,7 @@ func testLeaseRenewCommand(tb testing.TB) (*cli.MockUi, *LeaseRenewCommand) {
 		BaseCommand: &BaseCommand{
 			UI: ui,
 		},
+		ShutdownCh: make(chan struct{}),
 	}
 }
 
@@ -149,6 +153,97 @@ func TestLeaseRenewCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("follow", func(t *testing.T) {
+		t.Parallel()
+
+		// Every renewal returns the next response, until the lease cannot be
+		// extended any further or is not renewable.
+		cases := []struct {
+			name      string
+			responses []string
+			renewals  int
+			out       string
+		}{
+			{
+				"max_ttl",
+				[]string{
+					`{"lease_id":"foo/bar","renewable":true,"lease_duration":2}`,
+					`{"lease_id":"foo/bar","renewable":true,"lease_duration":2}`,
+					`{"lease_id":"foo/bar","renewable":true,"lease_duration":1}`,
+				},
+				2,
+				"Lease foo/bar can no longer be extended",
+			},
+			{
+				"not_renewable",
+				[]string{
+					`{"lease_id":"foo/bar","renewable":false,"lease_duration":0}`,
+				},
+				0,
+				"Lease foo/bar is not renewable",
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				var requests int32
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					i := atomic.AddInt32(&requests, 1) - 1
+					if r.URL.Path != "/v1/sys/leases/renew" || int(i) >= len(tc.responses) {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					w.Write([]byte(tc.responses[i]))
+				}))
+				defer server.Close()
+
+				ui, cmd := testLeaseRenewCommand(t)
+				cmd.client = testClient(t, server.URL, "root")
+
+				code := cmd.Run([]string{"-follow", "foo/bar"})
+				if exp := 2; code != exp {
+					t.Errorf("expected %d to be %d", code, exp)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+				if renewals := strings.Count(combined, "renewed lease foo/bar"); renewals != tc.renewals {
+					t.Errorf("expected %d renewals to be logged, got %d: %q", tc.renewals, renewals, combined)
+				}
+			})
+		}
+	})
+
+	t.Run("follow_interrupt", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		leaseID := testLeaseRenewCommandMountAndLease(t, client)
+
+		ui, cmd := testLeaseRenewCommand(t)
+		cmd.client = client
+		close(cmd.ShutdownCh)
+
+		code := cmd.Run([]string{"-follow", leaseID})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "renewed lease " + leaseID + ", expires in 5m0s"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
 	t.Run("no_tabs", func(t *testing.T) {
 		t.Parallel()
 
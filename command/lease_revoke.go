@@ -1,8 +1,12 @@
 package command
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
@@ -17,11 +21,17 @@ var (
 type LeaseRevokeCommand struct {
 	*BaseCommand
 
-	flagForce  bool
-	flagPrefix bool
-	flagSync   bool
+	flagForce       bool
+	flagPrefix      bool
+	flagSync        bool
+	flagDryRun      bool
+	flagParallelism int
 }
 
+// leaseRevokeProgressInterval is how often the progress of a prefix
+// revocation is printed.
+var leaseRevokeProgressInterval = 5 * time.Second
+
 func (c *LeaseRevokeCommand) Synopsis() string {
 	return "Revokes leases and secrets"
 }
@@ -51,6 +61,14 @@ Usage: vault lease revoke [options] ID
 
       $ vault lease revoke -force -prefix consul/creds
 
+  List the leases that would be revoked, without revoking them:
+
+      $ vault lease revoke -prefix -dry-run aws/creds/deploy
+
+  Without -force, the leases matching a prefix are looked up first and then
+  revoked one by one, printing the number of revoked, failed and remaining
+  leases as it goes. Leases created after the lookup are not revoked.
+
   For a full list of examples and paths, please see the documentation that
   corresponds to the secret engine in use.
 
@@ -90,6 +108,23 @@ func (c *LeaseRevokeCommand) Flags() *FlagSets {
 			"to retry.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  &c.flagDryRun,
+		Default: false,
+		Usage: "List the leases matching the prefix instead of revoking them. " +
+			"This requires -prefix.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:       "parallelism",
+		Target:     &c.flagParallelism,
+		Default:    8,
+		Completion: complete.PredictAnything,
+		Usage: "Number of leases to revoke concurrently when revoking by " +
+			"prefix without -force.",
+	})
+
 	return set
 }
 
@@ -124,6 +159,16 @@ func (c *LeaseRevokeCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagDryRun && !c.flagPrefix {
+		c.UI.Error("Specifying -dry-run requires also specifying -prefix")
+		return 1
+	}
+
+	if c.flagParallelism < 1 {
+		c.UI.Error("Parallelism must be at least 1")
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -132,6 +177,29 @@ func (c *LeaseRevokeCommand) Run(args []string) int {
 
 	leaseID := strings.TrimSpace(args[0])
 
+	if c.flagPrefix && (c.flagDryRun || !c.flagForce) {
+		leaseIDs, err := c.prefixLeases(client, leaseID)
+		switch {
+		case err != nil:
+			outputError(c.UI, fmt.Sprintf("Error looking up leases with prefix %s: %s", leaseID, err), err)
+			return 2
+		case leaseIDs != nil && c.flagDryRun:
+			for _, id := range leaseIDs {
+				c.UI.Output(id)
+			}
+			c.UI.Output(fmt.Sprintf("\n%d lease(s) would be revoked", len(leaseIDs)))
+			return 0
+		case leaseIDs != nil:
+			return c.revokeLeases(client, leaseID, leaseIDs)
+		case c.flagDryRun:
+			c.UI.Error("Listing the leases of a prefix is not supported by this Vault server")
+			return 2
+		}
+
+		// The server cannot list lease metadata, so revoke the prefix in a
+		// single request without progress.
+	}
+
 	revokeOpts := &api.RevokeOptions{
 		LeaseID: leaseID,
 		Force:   c.flagForce,
@@ -148,13 +216,13 @@ func (c *LeaseRevokeCommand) Run(args []string) int {
 	if err != nil {
 		switch {
 		case c.flagForce:
-			c.UI.Error(fmt.Sprintf("Error force revoking leases with prefix %s: %s", leaseID, err))
+			outputError(c.UI, fmt.Sprintf("Error force revoking leases with prefix %s: %s", leaseID, err), err)
 			return 2
 		case c.flagPrefix:
-			c.UI.Error(fmt.Sprintf("Error revoking leases with prefix %s: %s", leaseID, err))
+			outputError(c.UI, fmt.Sprintf("Error revoking leases with prefix %s: %s", leaseID, err), err)
 			return 2
 		default:
-			c.UI.Error(fmt.Sprintf("Error revoking lease %s: %s", leaseID, err))
+			outputError(c.UI, fmt.Sprintf("Error revoking lease %s: %s", leaseID, err), err)
 			return 2
 		}
 	}
@@ -176,3 +244,120 @@ func (c *LeaseRevokeCommand) Run(args []string) int {
 	c.UI.Output("All revocation operations queued successfully!")
 	return 0
 }
+
+// prefixLeases returns the IDs of the leases below prefix, or nil if the
+// server does not support listing lease metadata.
+func (c *LeaseRevokeCommand) prefixLeases(client *api.Client, prefix string) ([]string, error) {
+	secret, err := client.Logical().ReadWithData("sys/leases/metadata", map[string][]string{
+		"prefix": {prefix},
+		"limit":  {"none"},
+	})
+	if re, ok := err.(*api.ResponseError); ok && re.StatusCode == http.StatusMethodNotAllowed {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	leases, ok := secret.Data["leases"].([]interface{})
+	if !ok && secret.Data["leases"] != nil {
+		return nil, errors.New("expected leases in response to be an array")
+	}
+
+	// Like revoke-prefix, a prefix that is not a directory only matches the
+	// lease with that ID and the leases below it.
+	dir := strings.TrimSuffix(prefix, "/") + "/"
+	leaseIDs := make([]string, 0, len(leases))
+	for _, leaseRaw := range leases {
+		lease, _ := leaseRaw.(map[string]interface{})
+		id, _ := lease["lease_id"].(string)
+		if id == prefix || strings.HasPrefix(id, dir) {
+			leaseIDs = append(leaseIDs, id)
+		}
+	}
+	return leaseIDs, nil
+}
+
+// revokeLeases revokes the leases with the given IDs, printing the progress
+// while doing so and a summary at the end.
+func (c *LeaseRevokeCommand) revokeLeases(client *api.Client, prefix string, leaseIDs []string) int {
+	total := len(leaseIDs)
+
+	idCh := make(chan string)
+	type result struct {
+		leaseID string
+		err     error
+	}
+	resultCh := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.flagParallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range idCh {
+				err := client.Sys().RevokeWithOptions(&api.RevokeOptions{
+					LeaseID: id,
+					Sync:    c.flagSync,
+				})
+				resultCh <- result{leaseID: id, err: err}
+			}
+		}()
+	}
+	go func() {
+		for _, id := range leaseIDs {
+			idCh <- id
+		}
+		close(idCh)
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	ticker := time.NewTicker(leaseRevokeProgressInterval)
+	defer ticker.Stop()
+
+	verb := "Queued"
+	if c.flagSync {
+		verb = "Revoked"
+	}
+	progress := func(revoked, failed int) string {
+		return fmt.Sprintf("%s: %d, Failed: %d, Remaining: %d", verb, revoked, failed, total-revoked-failed)
+	}
+
+	var revoked int
+	var failures []result
+	for done := false; !done; {
+		select {
+		case r, ok := <-resultCh:
+			switch {
+			case !ok:
+				done = true
+			case r.err != nil:
+				failures = append(failures, r)
+			default:
+				revoked++
+			}
+		case <-ticker.C:
+			c.UI.Info(progress(revoked, len(failures)))
+		}
+	}
+
+	for _, f := range failures {
+		c.UI.Error(fmt.Sprintf("Error revoking lease %s: %s", f.leaseID, f.err))
+	}
+	c.UI.Info(progress(revoked, len(failures)))
+
+	if len(failures) > 0 {
+		c.UI.Error(fmt.Sprintf("Failed to revoke %d of %d lease(s) with prefix %s", len(failures), total, prefix))
+		return 2
+	}
+	if c.flagSync {
+		c.UI.Output(fmt.Sprintf("Success! Revoked any leases with prefix: %s", prefix))
+		return 0
+	}
+	c.UI.Output("All revocation operations queued successfully!")
+	return 0
+}
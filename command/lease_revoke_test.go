@@ -34,6 +34,24 @@ func TestLeaseRevokeCommand_Run(t *testing.T) {
 			"requires also specifying -prefix",
 			1,
 		},
+		{
+			"dry_run_without_prefix",
+			[]string{"-dry-run"},
+			"requires also specifying -prefix",
+			1,
+		},
+		{
+			"zero_parallelism",
+			[]string{"-prefix", "-parallelism=0"},
+			"Parallelism must be at least 1",
+			1,
+		},
+		{
+			"dry_run",
+			[]string{"-prefix", "-dry-run"},
+			"1 lease(s) would be revoked",
+			0,
+		},
 		{
 			"single",
 			nil,
@@ -114,6 +132,57 @@ func TestLeaseRevokeCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("prefix_progress", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("secret-leased", &api.MountInput{
+			Type: "generic-leased",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Leases below the prefix, and one of a sibling path which shares
+		// the prefix as a string.
+		var leaseIDs []string
+		for _, path := range []string{"multi/a", "multi/b", "multi/c", "multi2/d"} {
+			if _, err := client.Logical().Write("secret-leased/"+path, map[string]interface{}{
+				"key":   "value",
+				"lease": "1h",
+			}); err != nil {
+				t.Fatal(err)
+			}
+			secret, err := client.Logical().Read("secret-leased/" + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaseIDs = append(leaseIDs, secret.LeaseID)
+		}
+
+		ui, cmd := testLeaseRevokeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-prefix", "-sync", "-parallelism=2", "secret-leased/multi"})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Revoked: 3, Failed: 0, Remaining: 0"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		for i, leaseID := range leaseIDs {
+			_, err := client.Sys().Lookup(leaseID)
+			if revoked := err != nil; revoked != (i < 3) {
+				t.Errorf("unexpected state of lease %s: %v", leaseID, err)
+			}
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
@@ -15,6 +15,11 @@ var (
 
 type ListCommand struct {
 	*BaseCommand
+
+	flagRecursive   bool
+	flagFilter      string
+	flagFilterRegex string
+	flagConcurrency int
 }
 
 func (c *ListCommand) Synopsis() string {
@@ -33,6 +38,10 @@ Usage: vault list [options] PATH
 
       $ vault list secret/my-app/
 
+  List every path below the "my-app" folder whose name ends in "-key":
+
+      $ vault list -recursive -filter='*-key' secret/my-app/
+
   For a full list of examples and paths, please see the documentation that
   corresponds to the secret engine in use. Not all engines support listing.
 
@@ -42,7 +51,47 @@ Usage: vault list [options] PATH
 }
 
 func (c *ListCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:       "recursive",
+		Aliases:    []string{"r"},
+		Target:     &c.flagRecursive,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage: "List the path and all of its sub-folders, printing the full " +
+			"path of every entry which is not a folder.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "filter",
+		Target:     &c.flagFilter,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "When listing recursively, only print paths matching this glob " +
+			"pattern. The pattern is matched against both the full path and " +
+			"its last segment.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "filter-regex",
+		Target:     &c.flagFilterRegex,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "When listing recursively, only print paths matching this " +
+			"regular expression.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:       "concurrency",
+		Target:     &c.flagConcurrency,
+		Default:    defaultListConcurrency,
+		Completion: complete.PredictAnything,
+		Usage:      "Number of folders to list at once when listing recursively.",
+	})
+
+	return set
 }
 
 func (c *ListCommand) AutocompleteArgs() complete.Predictor {
@@ -84,9 +133,15 @@ func (c *ListCommand) Run(args []string) int {
 	}
 
 	path = sanitizePath(path)
+	if c.flagRecursive {
+		return outputListRecursive(c.UI, path, c.flagConcurrency, c.flagFilter, c.flagFilterRegex, func(folder string) ([]string, error) {
+			return listEntries(client, folder)
+		})
+	}
+
 	secret, err := client.Logical().List(path)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error listing %s: %s", path, err), err)
 		return 2
 	}
 
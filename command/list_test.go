@@ -57,6 +57,36 @@ func TestListCommand_Run(t *testing.T) {
 			"bar\nbaz\nfoo",
 			0,
 		},
+		{
+			"recursive",
+			[]string{"-recursive", "secret/list"},
+			"secret/list/bar\nsecret/list/baz\nsecret/list/foo\nsecret/list/nested/qux-key",
+			0,
+		},
+		{
+			"recursive_filter",
+			[]string{"-r", "-filter", "*-key", "secret/list"},
+			"secret/list/nested/qux-key",
+			0,
+		},
+		{
+			"recursive_filter_regex",
+			[]string{"-r", "-filter-regex", "ba[rz]$", "-concurrency", "1", "secret/list"},
+			"secret/list/bar\nsecret/list/baz\n",
+			0,
+		},
+		{
+			"recursive_no_match",
+			[]string{"-r", "-filter", "nope", "secret/list"},
+			"No entries found under secret/list",
+			2,
+		},
+		{
+			"recursive_invalid_regex",
+			[]string{"-r", "-filter-regex", "(", "secret/list"},
+			"invalid filter regex",
+			1,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -75,6 +105,7 @@ func TestListCommand_Run(t *testing.T) {
 					"secret/list/foo",
 					"secret/list/bar",
 					"secret/list/baz",
+					"secret/list/nested/qux-key",
 				}
 				for _, k := range keys {
 					if _, err := client.Logical().Write(k, map[string]interface{}{
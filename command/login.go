@@ -199,7 +199,7 @@ func (c *LoginCommand) Run(args []string) int {
 
 	config, err := parseArgsDataString(stdin, args)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error parsing configuration: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error parsing configuration: %s", err), err)
 		return 1
 	}
 
@@ -224,7 +224,7 @@ func (c *LoginCommand) Run(args []string) int {
 	// Authenticate delegation to the auth handler
 	secret, err := authHandler.Auth(client, config)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error authenticating: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error authenticating: %s", err), err)
 		return 2
 	}
 
@@ -236,7 +236,7 @@ func (c *LoginCommand) Run(args []string) int {
 	unwrap := !c.flagTokenOnly && !c.flagNoStore
 	secret, isWrapped, err := c.extractToken(client, secret, unwrap)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error extracting token: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error extracting token: %s", err), err)
 		return 2
 	}
 	if secret == nil {
@@ -280,7 +280,7 @@ func (c *LoginCommand) Run(args []string) int {
 
 		// Store the token in the local client
 		if err := tokenHelper.Store(token); err != nil {
-			c.UI.Error(fmt.Sprintf("Error storing token: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error storing token: %s", err), err)
 			c.UI.Error(wrapAtLength(
 				"Authentication was successful, but the token was not persisted. The "+
 					"resulting token is shown below for your records.") + "\n")
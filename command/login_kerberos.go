@@ -0,0 +1,210 @@
+package command
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	credKerb "github.com/hashicorp/vault-plugin-auth-kerberos"
+	"github.com/hashicorp/vault/api"
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+var _ LoginHandler = (*kerberosLoginHandler)(nil)
+
+// kerberosLoginHandler is the login handler of the Kerberos auth method. With
+// keytab_path it logs in with the keytab like the plugin does; otherwise it
+// uses the tickets in the local credential cache, as obtained by kinit.
+type kerberosLoginHandler struct {
+	credKerb.CLIHandler
+}
+
+func (h *kerberosLoginHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, error) {
+	if m["keytab_path"] != "" {
+		return h.CLIHandler.Auth(c, m)
+	}
+
+	mount := m["mount"]
+	if mount == "" {
+		mount = "kerberos"
+	}
+
+	service := m["service"]
+	if service == "" {
+		u, err := url.Parse(c.Address())
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Vault address: %w", err)
+		}
+		host := u.Hostname()
+		if ip := net.ParseIP(host); ip != nil {
+			return nil, errors.New(`"service" is required when the Vault address is an IP address`)
+		}
+		service = "HTTP/" + host
+	}
+
+	disableFAST := false
+	if v := m["disable_fast_negotiation"]; v != "" {
+		setting, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid value "%s" for disable_fast_negotiation, must be "true" or "false"`, v)
+		}
+		disableFAST = setting
+	}
+
+	authHeaderVal, err := kerberosCCacheAuthHeader(m["ccache_path"], m["krb5conf_path"], service, disableFAST)
+	if err != nil {
+		return nil, err
+	}
+
+	client := c.WithRequestCallbacks(func(r *api.Request) {
+		r.Headers.Set(spnego.HTTPHeaderAuthRequest, authHeaderVal)
+	})
+	secret, err := client.Logical().Write(fmt.Sprintf("auth/%s/login", mount), nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, errors.New("empty response from credential provider")
+	}
+	return secret, nil
+}
+
+// kerberosCCacheAuthHeader returns the SPNEGO "Authorization" header value for
+// service, using the tickets in the given or default credential cache.
+func kerberosCCacheAuthHeader(ccachePath, krb5ConfPath, service string, disableFAST bool) (string, error) {
+	if ccachePath == "" {
+		var err error
+		ccachePath, err = defaultKerberosCCachePath()
+		if err != nil {
+			return "", err
+		}
+	}
+	if krb5ConfPath == "" {
+		krb5ConfPath = os.Getenv("KRB5_CONFIG")
+	}
+	if krb5ConfPath == "" {
+		krb5ConfPath = "/etc/krb5.conf"
+	}
+
+	ccache, err := credentials.LoadCCache(ccachePath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't load credential cache %s, run kinit first: %w", ccachePath, err)
+	}
+	krb5Conf, err := config.Load(krb5ConfPath)
+	if err != nil {
+		return "", fmt.Errorf("couldn't parse krb5conf: %w", err)
+	}
+
+	var settings []func(*client.Settings)
+	if disableFAST {
+		settings = append(settings, client.DisablePAFXFAST(true))
+	}
+	cl, err := client.NewFromCCache(ccache, krb5Conf, settings...)
+	if err != nil {
+		return "", fmt.Errorf("couldn't use credential cache %s: %w", ccachePath, err)
+	}
+	defer cl.Destroy()
+
+	spnegoClient := spnego.SPNEGOClient(cl, service)
+	if err := spnegoClient.AcquireCred(); err != nil {
+		return "", fmt.Errorf("couldn't acquire client credential: %w", err)
+	}
+	spnegoToken, err := spnegoClient.InitSecContext()
+	if err != nil {
+		return "", fmt.Errorf("couldn't initialize context: %w", err)
+	}
+	marshalledToken, err := spnegoToken.Marshal()
+	if err != nil {
+		return "", fmt.Errorf("couldn't marshal SPNEGO: %w", err)
+	}
+	return "Negotiate " + base64.StdEncoding.EncodeToString(marshalledToken), nil
+}
+
+// defaultKerberosCCachePath returns the path of the credential cache kinit
+// uses, from KRB5CCNAME or the MIT default. Only file caches can be read.
+func defaultKerberosCCachePath() (string, error) {
+	name := os.Getenv("KRB5CCNAME")
+	if name == "" {
+		uid := os.Getuid()
+		if uid < 0 {
+			return "", errors.New(`"ccache_path" or KRB5CCNAME is required on this platform`)
+		}
+		return fmt.Sprintf("/tmp/krb5cc_%d", uid), nil
+	}
+
+	if i := strings.Index(name, ":"); i > 1 {
+		typ := strings.ToUpper(name[:i])
+		if typ != "FILE" {
+			return "", fmt.Errorf("credential cache type %s is not supported, set KRB5CCNAME to a FILE: cache", typ)
+		}
+		name = name[i+1:]
+	}
+	return name, nil
+}
+
+func (h *kerberosLoginHandler) Help() string {
+	help := `
+Usage: vault login -method=kerberos [CONFIG K=V...]
+
+  The Kerberos auth method allows users to authenticate using Kerberos
+  combined with LDAP.
+
+  Without a keytab, the CLI uses the tickets in the local credential cache,
+  so a user who has run kinit, or whose cache was populated at login, can
+  authenticate with SPNEGO directly. Only file credential caches are read;
+  set KRB5CCNAME to a FILE: cache if the default is a keyring or KCM cache.
+
+      $ kinit grace@MATRIX.LAN
+      $ vault login -method=kerberos
+
+  Authenticate with a keytab instead:
+
+      $ vault login -method=kerberos \
+            username=grace \
+            service="HTTP/ab10dfy3be7v.matrix.lan:8200" \
+            realm=MATRIX.LAN \
+            keytab_path=/etc/krb5/krb5.keytab \
+            krb5conf_path=/etc/krb5.conf
+
+Configuration:
+
+  ccache_path=<string>
+      The path to the credential cache to use when no keytab is given.
+      Defaults to KRB5CCNAME, or /tmp/krb5cc_<uid>.
+
+  disable_fast_negotiation=<bool>
+      Disable FAST pre-authentication negotiation, which some Kerberos
+      implementations do not support.
+
+  krb5conf_path=<string>
+      The path to a valid krb5.conf file describing how to communicate with
+      the Kerberos environment. Defaults to KRB5_CONFIG, or /etc/krb5.conf,
+      when no keytab is given.
+
+  keytab_path=<string>
+      The path to the keytab in which the entry lives for the entity
+      authenticating to Vault. Requires username and realm.
+
+  realm=<string>
+      The name of the Kerberos realm. Only used with a keytab.
+
+  service=<string>
+      The service principal name to use in obtaining a service ticket for
+      gaining a SPNEGO token. Defaults to "HTTP/<host of the Vault address>"
+      when no keytab is given.
+
+  username=<string>
+      The username for the entry within the keytab to use for logging into
+      Kerberos. Only used with a keytab.
+`
+
+	return strings.TrimSpace(help)
+}
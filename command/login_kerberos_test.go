@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestDefaultKerberosCCachePath(t *testing.T) {
+	cases := []struct {
+		name string
+		env  string
+		exp  string
+		err  string
+	}{
+		{"default", "", fmt.Sprintf("/tmp/krb5cc_%d", os.Getuid()), ""},
+		{"path", "/tmp/my-cache", "/tmp/my-cache", ""},
+		{"file", "FILE:/tmp/my-cache", "/tmp/my-cache", ""},
+		{"keyring", "KEYRING:persistent:1000", "", "KEYRING is not supported"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("KRB5CCNAME", tc.env)
+
+			path, err := defaultKerberosCCachePath()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected %q to contain %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != tc.exp {
+				t.Errorf("expected %q to be %q", path, tc.exp)
+			}
+		})
+	}
+}
+
+func TestKerberosLoginHandler_ccache(t *testing.T) {
+	t.Parallel()
+
+	config := api.DefaultConfig()
+	config.Address = "https://127.0.0.1:8200"
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &kerberosLoginHandler{}
+
+	t.Run("ip_address", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := h.Auth(client, map[string]string{})
+		if err == nil || !strings.Contains(err.Error(), `"service" is required`) {
+			t.Fatalf("expected an error about the service, got %v", err)
+		}
+	})
+
+	t.Run("missing_ccache", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := h.Auth(client, map[string]string{
+			"service":     "HTTP/vault.example.com",
+			"ccache_path": filepath.Join(t.TempDir(), "krb5cc"),
+		})
+		if err == nil || !strings.Contains(err.Error(), "run kinit first") {
+			t.Fatalf("expected an error about the credential cache, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,283 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	credOIDC "github.com/hashicorp/vault-plugin-auth-jwt"
+	"github.com/hashicorp/vault/api"
+)
+
+var _ LoginHandler = (*oidcLoginHandler)(nil)
+
+const (
+	// oidcDeviceGrantType is the grant type of the OAuth 2.0 device
+	// authorization grant (RFC 8628).
+	oidcDeviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+	// defaultOIDCDeviceInterval is how often the token endpoint is polled if
+	// the provider does not say otherwise.
+	defaultOIDCDeviceInterval = 5 * time.Second
+)
+
+// oidcLoginHandler is the login handler of the OIDC auth method. It uses the
+// browser-based flow of the plugin, unless device=true is given, in which
+// case the user completes the login on another device with a short code.
+type oidcLoginHandler struct {
+	credOIDC.CLIHandler
+
+	// output receives the instructions for the user; defaults to stderr.
+	output io.Writer
+}
+
+func (h *oidcLoginHandler) Auth(c *api.Client, m map[string]string) (*api.Secret, error) {
+	if device, _ := strconv.ParseBool(m["device"]); !device {
+		return h.CLIHandler.Auth(c, m)
+	}
+
+	mount := m["mount"]
+	if mount == "" {
+		mount = "oidc"
+	}
+	issuer := m["issuer"]
+	if issuer == "" {
+		return nil, errors.New("'issuer' must be set to the OIDC discovery URL of the provider when using device=true")
+	}
+	clientID := m["client_id"]
+	if clientID == "" {
+		return nil, errors.New("'client_id' must be set when using device=true")
+	}
+	scopes := []string{"openid"}
+	if s := m["scopes"]; s != "" {
+		scopes = append(scopes, strings.Split(s, ",")...)
+	}
+
+	output := h.output
+	if output == nil {
+		output = os.Stderr
+	}
+
+	flow := &oidcDeviceFlow{
+		client:       cleanhttp.DefaultClient(),
+		clientID:     clientID,
+		clientSecret: m["client_secret"],
+	}
+	if err := flow.discover(issuer); err != nil {
+		return nil, err
+	}
+
+	auth, err := flow.authorize(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationURI := auth.VerificationURIComplete
+	if verificationURI == "" {
+		verificationURI = auth.VerificationURI
+	}
+	fmt.Fprintf(output, "Complete the login on another device by visiting:\n\n    %s\n\n", verificationURI)
+	fmt.Fprintf(output, "and entering the code: %s\n\nWaiting for OIDC authentication to complete...\n", auth.UserCode)
+
+	idToken, err := flow.poll(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"jwt": idToken,
+	}
+	if role := m["role"]; role != "" {
+		data["role"] = role
+	}
+	return c.Logical().Write(fmt.Sprintf("auth/%s/login", mount), data)
+}
+
+func (h *oidcLoginHandler) Help() string {
+	help := strings.TrimSpace(h.CLIHandler.Help()) + `
+
+Device Authorization
+
+  On machines without a browser, such as over SSH, set device=true to use the
+  OAuth 2.0 device authorization grant instead. The CLI prints a URL and a
+  short code to enter on any other device, and then exchanges the resulting ID
+  token for a Vault token at the login endpoint of the mount. The role must
+  have role_type "jwt" and accept the ID token of the given client.
+
+      $ vault login -method=oidc device=true role=dev \
+          issuer=https://idp.example.com client_id=vault-cli
+
+Device Authorization Configuration:
+
+  device=<bool>
+      Use the device authorization grant instead of the browser-based flow.
+
+  issuer=<string>
+      OIDC discovery URL of the provider. Required with device=true.
+
+  client_id=<string>
+      OAuth client ID registered for the device flow. Required with
+      device=true.
+
+  client_secret=<string>
+      OAuth client secret, if the provider requires one for the client.
+
+  scopes=<string>
+      Comma-separated list of scopes to request in addition to "openid".
+`
+	return help
+}
+
+// oidcDeviceFlow runs the OAuth 2.0 device authorization grant (RFC 8628)
+// against an OIDC provider.
+type oidcDeviceFlow struct {
+	client       *http.Client
+	clientID     string
+	clientSecret string
+
+	deviceEndpoint string
+	tokenEndpoint  string
+}
+
+type oidcDeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type oidcTokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// discover reads the endpoints of the provider from its discovery document.
+func (f *oidcDeviceFlow) discover(issuer string) error {
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	resp, err := f.client.Get(wellKnown)
+	if err != nil {
+		return fmt.Errorf("error reading OIDC discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error reading OIDC discovery document: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		DeviceEndpoint string `json:"device_authorization_endpoint"`
+		TokenEndpoint  string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("error parsing OIDC discovery document: %w", err)
+	}
+	if doc.DeviceEndpoint == "" {
+		return errors.New("the OIDC provider does not support the device authorization grant")
+	}
+
+	f.deviceEndpoint = doc.DeviceEndpoint
+	f.tokenEndpoint = doc.TokenEndpoint
+	return nil
+}
+
+// authorize requests a device and user code.
+func (f *oidcDeviceFlow) authorize(scopes []string) (*oidcDeviceAuthorization, error) {
+	form := url.Values{
+		"client_id": {f.clientID},
+		"scope":     {strings.Join(scopes, " ")},
+	}
+	if f.clientSecret != "" {
+		form.Set("client_secret", f.clientSecret)
+	}
+
+	resp, err := f.client.PostForm(f.deviceEndpoint, form)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting device code: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var tokenErr oidcTokenResponse
+		json.NewDecoder(resp.Body).Decode(&tokenErr)
+		return nil, fmt.Errorf("error requesting device code: unexpected status %d %s", resp.StatusCode, tokenErr.ErrorDescription)
+	}
+
+	var auth oidcDeviceAuthorization
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return nil, fmt.Errorf("error parsing device code response: %w", err)
+	}
+	if auth.DeviceCode == "" || auth.UserCode == "" {
+		return nil, errors.New("device code response is missing the device or user code")
+	}
+	return &auth, nil
+}
+
+// poll polls the token endpoint until the user has completed the login, and
+// returns the ID token.
+func (f *oidcDeviceFlow) poll(auth *oidcDeviceAuthorization) (string, error) {
+	interval := defaultOIDCDeviceInterval
+	if auth.Interval > 0 {
+		interval = time.Duration(auth.Interval) * time.Second
+	}
+	ctx := context.Background()
+	if auth.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(auth.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	form := url.Values{
+		"grant_type":  {oidcDeviceGrantType},
+		"device_code": {auth.DeviceCode},
+		"client_id":   {f.clientID},
+	}
+	if f.clientSecret != "" {
+		form.Set("client_secret", f.clientSecret)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", errors.New("the device code expired before the login was completed")
+		case <-time.After(interval):
+		}
+
+		resp, err := f.client.PostForm(f.tokenEndpoint, form)
+		if err != nil {
+			return "", fmt.Errorf("error polling for the ID token: %w", err)
+		}
+		var token oidcTokenResponse
+		err = json.NewDecoder(resp.Body).Decode(&token)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("error parsing token response: %w", err)
+		}
+
+		switch token.Error {
+		case "":
+			if token.IDToken == "" {
+				return "", errors.New("token response does not include an ID token")
+			}
+			return token.IDToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return "", errors.New("the login was denied")
+		case "expired_token":
+			return "", errors.New("the device code expired before the login was completed")
+		default:
+			return "", fmt.Errorf("error polling for the ID token: %s %s", token.Error, token.ErrorDescription)
+		}
+	}
+}
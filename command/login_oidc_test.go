@@ -0,0 +1,133 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestOIDCLoginHandler_device(t *testing.T) {
+	t.Parallel()
+
+	var polls int32
+	var idp *httptest.Server
+	idp = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"device_authorization_endpoint": idp.URL + "/device",
+				"token_endpoint":                idp.URL + "/token",
+			})
+		case "/device":
+			if r.FormValue("client_id") != "vault-cli" || r.FormValue("scope") != "openid email" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"device_code":      "device-123",
+				"user_code":        "ABCD-EFGH",
+				"verification_uri": idp.URL + "/activate",
+				"expires_in":       60,
+				"interval":         1,
+			})
+		case "/token":
+			if r.FormValue("grant_type") != oidcDeviceGrantType || r.FormValue("device_code") != "device-123" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if atomic.AddInt32(&polls, 1) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"id_token": "id-token"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer idp.Close()
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/v1/auth/my-oidc/login" || body["jwt"] != "id-token" || body["role"] != "dev" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "s.device"},
+		})
+	}))
+	defer vault.Close()
+
+	config := api.DefaultConfig()
+	config.Address = vault.URL
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	h := &oidcLoginHandler{output: &out}
+	secret, err := h.Auth(client, map[string]string{
+		"device":    "true",
+		"mount":     "my-oidc",
+		"role":      "dev",
+		"issuer":    idp.URL,
+		"client_id": "vault-cli",
+		"scopes":    "email",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken != "s.device" {
+		t.Fatalf("bad secret: %#v", secret)
+	}
+	if !strings.Contains(out.String(), "ABCD-EFGH") || !strings.Contains(out.String(), idp.URL+"/activate") {
+		t.Errorf("expected the user code and URL in the output, got %q", out.String())
+	}
+	if polls != 2 {
+		t.Errorf("expected 2 polls, got %d", polls)
+	}
+}
+
+func TestOIDCLoginHandler_deviceValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		data map[string]string
+		err  string
+	}{
+		{
+			"no_issuer",
+			map[string]string{"device": "true", "client_id": "vault-cli"},
+			"'issuer' must be set",
+		},
+		{
+			"no_client_id",
+			map[string]string{"device": "true", "issuer": "https://idp.example.com"},
+			"'client_id' must be set",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := &oidcLoginHandler{}
+			_, err := h.Auth(nil, tc.data)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected %q to contain %q", err, tc.err)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package command
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -20,7 +21,48 @@ import (
 
 type VaultUI struct {
 	cli.Ui
-	format string
+	format   string
+	template string
+
+	envPrefix    string
+	envUppercase bool
+
+	outputFile         string
+	outputDecodeBase64 bool
+
+	tableColumns []string
+	tableSortBy  string
+
+	// redact masks the secret values in table output
+	redact bool
+
+	// outputRequest is the language of the code printed instead of making the
+	// request, set with -output-request
+	outputRequest string
+}
+
+// Error prints the given error message. When the JSON output format is in
+// use, the error is printed as a structured JSON object instead so that it can
+// be consumed by automation.
+func (u *VaultUI) Error(msg string) {
+	u.ReportError(msg, nil)
+}
+
+// ReportError prints the given error message, which reports err. When the
+// JSON output format is in use, the structured JSON object includes the
+// details of the API response error wrapped by err.
+func (u *VaultUI) ReportError(msg string, err error) {
+	if u.format != "json" {
+		u.Ui.Error(msg)
+		return
+	}
+
+	b, jsonErr := json.Marshal(newCLIError(msg, err))
+	if jsonErr != nil {
+		u.Ui.Error(msg)
+		return
+	}
+	u.Ui.Error(string(b))
 }
 
 // setupEnv parses args and may replace them and sets some env vars to known
@@ -49,6 +91,13 @@ func setupEnv(args []string) (retArgs []string, format string, outputCurlString
 			continue
 		}
 
+		// The language is read from the parsed flag by the command
+		if arg == "-output-request" || arg == "--output-request" ||
+			strings.HasPrefix(arg, "-output-request=") || strings.HasPrefix(arg, "--output-request=") {
+			outputCurlString = true
+			continue
+		}
+
 		// Parse a given flag here, which overrides the env var
 		if strings.HasPrefix(arg, "--format=") {
 			format = strings.TrimPrefix(arg, "--format=")
@@ -82,6 +131,12 @@ type RunOptions struct {
 	Stderr      io.Writer
 	Address     string
 	Client      *api.Client
+
+	// telemetry records the metrics of the invocation
+	telemetry *cliTelemetry
+
+	// config loads the CLI configuration once for the invocation
+	config *cliConfigLoader
 }
 
 func Run(args []string) int {
@@ -99,6 +154,12 @@ func RunCustom(args []string, runOpts *RunOptions) int {
 	var outputCurlString bool
 	args, format, outputCurlString = setupEnv(args)
 
+	runOpts.config = &cliConfigLoader{}
+	// No requests are made when generating them
+	if !outputCurlString {
+		runOpts.telemetry = newCLITelemetry(runOpts.config)
+	}
+
 	// Don't use color if disabled
 	useColor := true
 	if os.Getenv(EnvVaultCLINoColor) != "" || color.NoColor {
@@ -178,6 +239,7 @@ func RunCustom(args []string, runOpts *RunOptions) int {
 	}
 
 	exitCode, err := cli.Run()
+	runOpts.telemetry.send(cli.Subcommand(), exitCode)
 	if outputCurlString {
 		if exitCode == 0 {
 			fmt.Fprint(runOpts.Stderr, "Could not generate cURL command")
@@ -195,6 +257,15 @@ func RunCustom(args []string, runOpts *RunOptions) int {
 				runOpts.Stdout.Write([]byte(fmt.Sprintf("Error creating request string: %s\n", api.LastOutputStringError.Error())))
 				return 1
 			}
+			if ui.outputRequest != "" {
+				request, err := outputRequestString(ui.outputRequest, api.LastOutputStringError)
+				if err != nil {
+					runOpts.Stdout.Write([]byte(fmt.Sprintf("Error creating request string: %s\n", err)))
+					return 1
+				}
+				runOpts.Stdout.Write([]byte(fmt.Sprintf("%s\n", request)))
+				return 0
+			}
 			runOpts.Stdout.Write([]byte(fmt.Sprintf("%s\n", api.LastOutputStringError.CurlString())))
 			return 0
 		}
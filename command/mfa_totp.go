@@ -0,0 +1,123 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/command/config"
+	"github.com/hashicorp/vault/command/token"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// mfaTOTPSeeds returns the TOTP seed configuration of each MFA method in the
+// given CLI configuration, keyed by method ID.
+func mfaTOTPSeeds(conf []*config.MFATOTP) map[string]*config.MFATOTP {
+	seeds := make(map[string]*config.MFATOTP, len(conf))
+	for _, seed := range conf {
+		if seed != nil && seed.MethodID != "" {
+			seeds[seed.MethodID] = seed
+		}
+	}
+	return seeds
+}
+
+// mfaTOTPPasscode runs the seed command of the given configuration and
+// returns the TOTP passcode for the given time.
+func mfaTOTPPasscode(conf *config.MFATOTP, now time.Time) (string, error) {
+	if conf.SeedCommand == "" {
+		return "", fmt.Errorf("no seed_command configured for MFA method %q", conf.MethodID)
+	}
+
+	cmd, err := token.ExecScript(conf.SeedCommand)
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running seed_command for MFA method %q: %q: %w", conf.MethodID, stderr.String(), err)
+	}
+
+	seed := strings.ToUpper(strings.Join(strings.Fields(stdout.String()), ""))
+	if seed == "" {
+		return "", fmt.Errorf("seed_command for MFA method %q returned no seed", conf.MethodID)
+	}
+
+	opts := totp.ValidateOpts{
+		Period:    30,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+	if conf.Period > 0 {
+		opts.Period = uint(conf.Period)
+	}
+	switch conf.Digits {
+	case 0, 6:
+	case 8:
+		opts.Digits = otp.DigitsEight
+	default:
+		return "", fmt.Errorf("invalid digits %d for MFA method %q, must be 6 or 8", conf.Digits, conf.MethodID)
+	}
+	switch strings.ToUpper(conf.Algorithm) {
+	case "", "SHA1":
+	case "SHA256":
+		opts.Algorithm = otp.AlgorithmSHA256
+	case "SHA512":
+		opts.Algorithm = otp.AlgorithmSHA512
+	default:
+		return "", fmt.Errorf("invalid algorithm %q for MFA method %q", conf.Algorithm, conf.MethodID)
+	}
+
+	passcode, err := totp.GenerateCodeCustom(seed, now, opts)
+	if err != nil {
+		return "", fmt.Errorf("error generating passcode for MFA method %q: %w", conf.MethodID, err)
+	}
+	return passcode, nil
+}
+
+// selectLocalTOTPMethods returns a method with a configured TOTP seed for each
+// of the given constraints, sorted by constraint name. If any constraint
+// cannot be satisfied by such a method, nil is returned.
+func selectLocalTOTPMethods(mfaConstraintAny map[string]*logical.MFAConstraintAny, seeds map[string]*config.MFATOTP) []MFAMethodInfo {
+	if len(seeds) == 0 || len(mfaConstraintAny) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(mfaConstraintAny))
+	for name := range mfaConstraintAny {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	methods := make([]MFAMethodInfo, 0, len(names))
+	for _, name := range names {
+		constraint := mfaConstraintAny[name]
+		if constraint == nil {
+			continue
+		}
+
+		var found bool
+		for _, method := range constraint.Any {
+			if _, ok := seeds[method.ID]; ok && method.UsesPasscode {
+				methods = append(methods, MFAMethodInfo{
+					methodType:  method.Type,
+					methodID:    method.ID,
+					usePasscode: true,
+				})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	return methods
+}
@@ -0,0 +1,119 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/command/config"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/pquerna/otp/totp"
+)
+
+func TestMFATOTPPasscode(t *testing.T) {
+	t.Parallel()
+
+	const seed = "JBSWY3DPEHPK3PXP"
+	now := time.Unix(1600000000, 0)
+
+	exp, err := totp.GenerateCode(seed, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		conf *config.MFATOTP
+		exp  string
+		err  bool
+	}{
+		{
+			"defaults",
+			&config.MFATOTP{MethodID: "id", SeedCommand: "echo " + seed},
+			exp,
+			false,
+		},
+		{
+			"lowercase_seed",
+			&config.MFATOTP{MethodID: "id", SeedCommand: "echo jbswy3dpehpk3pxp"},
+			exp,
+			false,
+		},
+		{
+			"no_seed_command",
+			&config.MFATOTP{MethodID: "id"},
+			"",
+			true,
+		},
+		{
+			"failing_seed_command",
+			&config.MFATOTP{MethodID: "id", SeedCommand: "exit 1"},
+			"",
+			true,
+		},
+		{
+			"empty_seed",
+			&config.MFATOTP{MethodID: "id", SeedCommand: "true"},
+			"",
+			true,
+		},
+		{
+			"invalid_digits",
+			&config.MFATOTP{MethodID: "id", SeedCommand: "echo " + seed, Digits: 7},
+			"",
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			passcode, err := mfaTOTPPasscode(tc.conf, now)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error to be %t: %v", tc.err, err)
+			}
+			if passcode != tc.exp {
+				t.Errorf("expected %q to be %q", passcode, tc.exp)
+			}
+		})
+	}
+}
+
+func TestSelectLocalTOTPMethods(t *testing.T) {
+	t.Parallel()
+
+	seeds := mfaTOTPSeeds([]*config.MFATOTP{
+		{MethodID: "totp-id", SeedCommand: "echo seed"},
+	})
+
+	constraints := map[string]*logical.MFAConstraintAny{
+		"login": {
+			Any: []*logical.MFAMethodID{
+				{Type: "duo", ID: "duo-id", UsesPasscode: false},
+				{Type: "totp", ID: "totp-id", UsesPasscode: true},
+			},
+		},
+	}
+	exp := []MFAMethodInfo{
+		{methodType: "totp", methodID: "totp-id", usePasscode: true},
+	}
+	if methods := selectLocalTOTPMethods(constraints, seeds); !reflect.DeepEqual(methods, exp) {
+		t.Errorf("expected %#v to be %#v", methods, exp)
+	}
+
+	constraints["other"] = &logical.MFAConstraintAny{
+		Any: []*logical.MFAMethodID{
+			{Type: "okta", ID: "okta-id", UsesPasscode: false},
+		},
+	}
+	if methods := selectLocalTOTPMethods(constraints, seeds); methods != nil {
+		t.Errorf("expected no methods when a constraint has no local seed, got %#v", methods)
+	}
+
+	if methods := selectLocalTOTPMethods(constraints, nil); methods != nil {
+		t.Errorf("expected no methods without seeds, got %#v", methods)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/monitor"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -19,6 +20,7 @@ type MonitorCommand struct {
 	*BaseCommand
 
 	logLevel string
+	filter   string
 
 	// ShutdownCh is used to capture interrupt signal and end streaming
 	ShutdownCh chan struct{}
@@ -35,7 +37,15 @@ Usage: vault monitor [options]
 	Stream log messages of a Vault server. The monitor command lets you listen
 	for log levels that may be filtered out of the server logs. For example,
 	the server may be logging at the INFO level, but with the monitor command
-	you can set -log-level=DEBUG.
+	you can set -log-level=DEBUG. With -format=jsonl, every log message is
+	printed as a JSON object on its own line as soon as it arrives.
+
+	The -filter flag selects the log messages to stream by their subsystem,
+	level, message, namespace or any other field. Messages are filtered by the
+	server before they are sent:
+
+	    $ vault monitor -log-level=debug \
+	        -filter='subsystem == core.expiration and level >= warn'
 
 ` + c.Flags().Help()
 
@@ -43,7 +53,7 @@ Usage: vault monitor [options]
 }
 
 func (c *MonitorCommand) Flags() *FlagSets {
-	set := c.flagSet(FlagSetHTTP)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
 
 	f := set.NewFlagSet("Monitor Options")
 	f.StringVar(&StringVar{
@@ -56,6 +66,18 @@ func (c *MonitorCommand) Flags() *FlagSets {
 			" and \"error\". These are not case sensitive.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "filter",
+		Target:     &c.filter,
+		Completion: complete.PredictAnything,
+		Usage: "Filter expression selecting the log messages to stream. Matchers " +
+			"of the form <field> <operator> <value> are joined with \"and\". " +
+			"The fields are \"subsystem\", \"level\", \"message\", \"namespace\" " +
+			"or the key of any other field of the messages, and the operators " +
+			"are ==, !=, =~ and !~ for regular expressions, as well as >=, <=, > " +
+			"and < for the level.",
+	})
+
 	return set
 }
 
@@ -88,6 +110,11 @@ func (c *MonitorCommand) Run(args []string) int {
 		return 1
 	}
 
+	if _, err := monitor.ParseFilter(c.filter); err != nil {
+		outputError(c.UI, fmt.Sprintf("Invalid filter: %s", err), err)
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
@@ -100,9 +127,9 @@ func (c *MonitorCommand) Run(args []string) int {
 	var logCh chan string
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	logCh, err = client.Sys().Monitor(ctx, c.logLevel)
+	logCh, err = client.Sys().MonitorWithFilter(ctx, c.logLevel, c.filter)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error starting monitor: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error starting monitor: %s", err), err)
 		return 1
 	}
 
@@ -112,6 +139,12 @@ func (c *MonitorCommand) Run(args []string) int {
 			if !ok {
 				return 0
 			}
+			if Format(c.UI) == "jsonl" {
+				OutputData(c.UI, map[string]interface{}{
+					"message": strings.TrimSuffix(log, "\n"),
+				})
+				continue
+			}
 			c.UI.Info(log)
 		case <-c.ShutdownCh:
 			return 0
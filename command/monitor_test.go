@@ -55,6 +55,23 @@ func TestMonitorCommand_Run(t *testing.T) {
 			"haha is an unknown log level",
 			1,
 		},
+		{
+			"filter",
+			[]string{
+				"-log-level=debug",
+				"-filter=subsystem == core and level == debug",
+			},
+			"",
+			0,
+		},
+		{
+			"invalid_filter",
+			[]string{
+				"-filter=path >= secret/",
+			},
+			"Invalid filter",
+			1,
+		},
 	}
 
 	for _, tc := range cases {
@@ -48,6 +48,10 @@ Usage: vault namespace <subcommand> [options] [args]
 
       $ vault namespace unlock
 
+  Show the namespaces below the current namespace and their mounts:
+
+      $ vault namespace tree
+
   Please see the individual subcommand help for detailed usage information.
 `
 
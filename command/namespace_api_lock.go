@@ -79,7 +79,7 @@ func (c *NamespaceAPILockCommand) Run(args []string) int {
 
 	resp, err := client.Logical().Write(fmt.Sprintf("sys/namespaces/api-lock/lock%s", optionalChildNSPath), nil)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error locking namespace: %v", err))
+		outputError(c.UI, fmt.Sprintf("Error locking namespace: %v", err), err)
 		return 2
 	}
 
@@ -85,7 +85,7 @@ func (c *NamespaceAPIUnlockCommand) Run(args []string) int {
 		"unlock_key": c.flagUnlockKey,
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error unlocking namespace: %v", err))
+		outputError(c.UI, fmt.Sprintf("Error unlocking namespace: %v", err), err)
 		return 2
 	}
 
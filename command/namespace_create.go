@@ -82,7 +82,7 @@ func (c *NamespaceCreateCommand) Run(args []string) int {
 
 	secret, err := client.Logical().Write("sys/namespaces/"+namespacePath, nil)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error creating namespace: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error creating namespace: %s", err), err)
 		return 2
 	}
 
@@ -82,7 +82,7 @@ func (c *NamespaceDeleteCommand) Run(args []string) int {
 
 	secret, err := client.Logical().Delete("sys/namespaces/" + namespacePath)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error deleting namespace: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error deleting namespace: %s", err), err)
 		return 2
 	}
 
@@ -2,8 +2,12 @@ package command
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -15,6 +19,8 @@ var (
 
 type NamespaceListCommand struct {
 	*BaseCommand
+
+	flagRecursive bool
 }
 
 func (c *NamespaceListCommand) Synopsis() string {
@@ -31,13 +37,28 @@ Usage: vault namespace list [options]
 
       $ vault namespace list
 
+  List all namespaces below the current namespace, at any depth:
+
+      $ vault namespace list -recursive
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *NamespaceListCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Target:  &c.flagRecursive,
+		Default: false,
+		Usage: "List the namespaces below the child namespaces as well, " +
+			"at any depth. Paths are relative to the current namespace.",
+	})
+
+	return set
 }
 
 func (c *NamespaceListCommand) AutocompleteArgs() complete.Predictor {
@@ -68,9 +89,26 @@ func (c *NamespaceListCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagRecursive {
+		paths, err := listNamespacesRecursive(client)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error listing namespaces: %s", err), err)
+			return 2
+		}
+		if len(paths) == 0 {
+			if Format(c.UI) != "table" {
+				OutputData(c.UI, []string{})
+			} else {
+				c.UI.Error("No namespaces found")
+			}
+			return 2
+		}
+		return OutputList(c.UI, paths)
+	}
+
 	secret, err := client.Logical().List("sys/namespaces")
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing namespaces: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error listing namespaces: %s", err), err)
 		return 2
 	}
 
@@ -103,3 +141,54 @@ func (c *NamespaceListCommand) Run(args []string) int {
 
 	return OutputList(c.UI, secret)
 }
+
+// listChildNamespaces returns the paths of the namespaces directly below the
+// namespace of client, relative to it, sorted.
+func listChildNamespaces(client *api.Client) ([]string, error) {
+	secret, err := client.Logical().List("sys/namespaces")
+	if err != nil {
+		return nil, err
+	}
+
+	keys, _ := extractListData(secret)
+	paths := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if path, ok := key.(string); ok {
+			paths = append(paths, namespace.Canonicalize(path))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// listNamespacesRecursive returns the paths of all namespaces below the
+// namespace of client, relative to it. Each namespace comes right before the
+// namespaces below it.
+func listNamespacesRecursive(client *api.Client) ([]string, error) {
+	base := namespace.Canonicalize(client.Headers().Get(consts.NamespaceHeaderName))
+
+	var paths []string
+	var walk func(prefix string) error
+	walk = func(prefix string) error {
+		nsClient, err := clientForNamespace(client, base+prefix)
+		if err != nil {
+			return err
+		}
+		children, err := listChildNamespaces(nsClient)
+		if err != nil {
+			return fmt.Errorf("error listing namespaces in %q: %w", base+prefix, err)
+		}
+		for _, child := range children {
+			paths = append(paths, prefix+child)
+			if err := walk(prefix + child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
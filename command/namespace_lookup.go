@@ -78,7 +78,7 @@ func (c *NamespaceLookupCommand) Run(args []string) int {
 
 	secret, err := client.Logical().Read("sys/namespaces/" + namespacePath)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error looking up namespace: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error looking up namespace: %s", err), err)
 		return 2
 	}
 	if secret == nil {
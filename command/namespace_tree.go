@@ -0,0 +1,236 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*NamespaceTreeCommand)(nil)
+	_ cli.CommandAutocomplete = (*NamespaceTreeCommand)(nil)
+)
+
+type NamespaceTreeCommand struct {
+	*BaseCommand
+
+	flagMounts bool
+}
+
+func (c *NamespaceTreeCommand) Synopsis() string {
+	return "Show the namespace hierarchy and its mounts"
+}
+
+func (c *NamespaceTreeCommand) Help() string {
+	helpText := `
+Usage: vault namespace tree [options]
+
+  Shows the current namespace and all namespaces below it as a tree, together
+  with the secrets engines and auth methods enabled in each namespace. The
+  mounts Vault creates in every namespace, such as "sys/" and "identity/",
+  are not shown.
+
+  Show the whole namespace hierarchy:
+
+      $ vault namespace tree -namespace=""
+
+  Show only the namespaces below "ns1/":
+
+      $ vault namespace tree -namespace=ns1 -mounts=false
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceTreeCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "mounts",
+		Target:  &c.flagMounts,
+		Default: true,
+		Usage: "Show the secrets engines and auth methods of each namespace. " +
+			"Reading them takes two requests per namespace.",
+	})
+
+	return set
+}
+
+func (c *NamespaceTreeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *NamespaceTreeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *NamespaceTreeCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	paths, err := listNamespacesRecursive(client)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error listing namespaces: %s", err), err)
+		return 2
+	}
+
+	base := namespace.Canonicalize(client.Headers().Get(consts.NamespaceHeaderName))
+	root := &namespaceTreeNode{Path: base}
+	if root.Path == "" {
+		root.Path = "root"
+	}
+	nodes := map[string]*namespaceTreeNode{"": root}
+	for _, path := range paths {
+		node := &namespaceTreeNode{Path: path}
+		nodes[path] = node
+
+		parent := nodes[namespaceTreeParent(path)]
+		parent.Namespaces = append(parent.Namespaces, node)
+	}
+
+	if c.flagMounts {
+		for path, node := range nodes {
+			nsClient, err := clientForNamespace(client, base+path)
+			if err != nil {
+				c.UI.Error(err.Error())
+				return 2
+			}
+			if err := node.readMounts(nsClient); err != nil {
+				outputError(c.UI, fmt.Sprintf("Error reading mounts of namespace %q: %s", base+path, err), err)
+				return 2
+			}
+		}
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, root)
+	}
+
+	lines := append([]string{root.Path}, root.treeLines("")...)
+	c.UI.Output(strings.Join(lines, "\n"))
+	return 0
+}
+
+type namespaceTreeMount struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+type namespaceTreeNode struct {
+	Path           string               `json:"path"`
+	SecretsEngines []namespaceTreeMount `json:"secrets_engines,omitempty"`
+	AuthMethods    []namespaceTreeMount `json:"auth_methods,omitempty"`
+	Namespaces     []*namespaceTreeNode `json:"namespaces,omitempty"`
+}
+
+// readMounts reads the secrets engines and auth methods of the namespace of
+// client into n, leaving out the ones every namespace has.
+func (n *namespaceTreeNode) readMounts(client *api.Client) error {
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		return err
+	}
+	for path, mount := range mounts {
+		if namespaceBuiltinMount(mount.Type) {
+			continue
+		}
+		n.SecretsEngines = append(n.SecretsEngines, namespaceTreeMount{Path: path, Type: mount.Type})
+	}
+
+	auths, err := client.Sys().ListAuth()
+	if err != nil {
+		return err
+	}
+	for path, auth := range auths {
+		if namespaceBuiltinMount(auth.Type) {
+			continue
+		}
+		n.AuthMethods = append(n.AuthMethods, namespaceTreeMount{Path: path, Type: auth.Type})
+	}
+
+	sort.Slice(n.SecretsEngines, func(i, j int) bool { return n.SecretsEngines[i].Path < n.SecretsEngines[j].Path })
+	sort.Slice(n.AuthMethods, func(i, j int) bool { return n.AuthMethods[i].Path < n.AuthMethods[j].Path })
+	return nil
+}
+
+// namespaceBuiltinMount returns true for the types of the secrets engines and
+// auth methods Vault mounts in every namespace.
+func namespaceBuiltinMount(mountType string) bool {
+	switch strings.TrimPrefix(mountType, "ns_") {
+	case "system", "identity", "cubbyhole", "token":
+		return true
+	}
+	return false
+}
+
+// treeLines returns the lines of the mounts and namespaces below n, each
+// prefixed with indent and the glyphs connecting it to n.
+func (n *namespaceTreeNode) treeLines(indent string) []string {
+	type entry struct {
+		label string
+		node  *namespaceTreeNode
+	}
+	var entries []entry
+	for _, mount := range n.SecretsEngines {
+		entries = append(entries, entry{label: fmt.Sprintf("%s (%s)", mount.Path, mount.Type)})
+	}
+	for _, mount := range n.AuthMethods {
+		entries = append(entries, entry{label: fmt.Sprintf("auth/%s (%s)", mount.Path, mount.Type)})
+	}
+	for _, child := range n.Namespaces {
+		entries = append(entries, entry{label: "[namespace] " + namespaceTreeName(child.Path), node: child})
+	}
+
+	var lines []string
+	for i, e := range entries {
+		branch, next := "├── ", "│   "
+		if i == len(entries)-1 {
+			branch, next = "└── ", "    "
+		}
+		lines = append(lines, indent+branch+e.label)
+		if e.node != nil {
+			lines = append(lines, e.node.treeLines(indent+next)...)
+		}
+	}
+	return lines
+}
+
+// namespaceTreeParent returns the path of the namespace path is directly
+// below, or an empty string for a child of the current namespace.
+func namespaceTreeParent(path string) string {
+	trimmed := strings.TrimSuffix(path, "/")
+	i := strings.LastIndex(trimmed, "/")
+	if i < 0 {
+		return ""
+	}
+	return trimmed[:i+1]
+}
+
+// namespaceTreeName returns the last segment of the namespace path.
+func namespaceTreeName(path string) string {
+	return path[len(namespaceTreeParent(path)):]
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testNamespaceTreeCommand(tb testing.TB) (*cli.MockUi, *NamespaceTreeCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &NamespaceTreeCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestNamespaceTreeCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("too_many_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testNamespaceTreeCommand(t)
+
+		code := cmd.Run([]string{"foo"})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Too many arguments"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("kv/", &api.MountInput{
+			Type: "kv",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{
+			Type: "userpass",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testNamespaceTreeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run(nil)
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		// The test server has no namespaces, so only its mounts are shown.
+		expected := `root
+├── kv/ (kv)
+├── secret/ (kv)
+└── auth/userpass/ (userpass)
+`
+		if output := ui.OutputWriter.String(); output != expected {
+			t.Errorf("expected %q to be %q", output, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testNamespaceTreeCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestNamespaceTreeParent(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		path   string
+		parent string
+		name   string
+	}{
+		{"ns1/", "", "ns1/"},
+		{"ns1/ns2/", "ns1/", "ns2/"},
+		{"ns1/ns2/ns3/", "ns1/ns2/", "ns3/"},
+	}
+
+	for _, tc := range cases {
+		if parent := namespaceTreeParent(tc.path); parent != tc.parent {
+			t.Errorf("expected parent of %q to be %q, got %q", tc.path, tc.parent, parent)
+		}
+		if name := namespaceTreeName(tc.path); name != tc.name {
+			t.Errorf("expected name of %q to be %q, got %q", tc.path, tc.name, name)
+		}
+	}
+}
@@ -41,6 +41,10 @@ const OperatorDiagnoseEnableEnv = "VAULT_DIAGNOSE"
 
 const CoreConfigUninitializedErr = "Diagnose cannot attempt this step because core config could not be set."
 
+// diagnoseBenchmarkSamples is the number of times each benchmarked operation
+// is repeated to compute its latency percentiles.
+const diagnoseBenchmarkSamples = 20
+
 var (
 	_ cli.Command             = (*OperatorDiagnoseCommand)(nil)
 	_ cli.CommandAutocomplete = (*OperatorDiagnoseCommand)(nil)
@@ -109,7 +113,8 @@ func (c *OperatorDiagnoseCommand) Flags() *FlagSets {
 	f.StringSliceVar(&StringSliceVar{
 		Name:   "skip",
 		Target: &c.flagSkips,
-		Usage:  "Skip the health checks named as arguments. May be 'listener', 'storage', or 'autounseal'.",
+		Usage: "Skip the health checks named as arguments. May be 'listener', 'storage', " +
+			"'autounseal', or the name of a benchmark such as 'benchmark storage latency'.",
 	})
 
 	f.BoolVar(&BoolVar{
@@ -388,6 +393,46 @@ func (c *OperatorDiagnoseCommand) offlineDiagnostics(ctx context.Context) error
 				}
 				return nil
 			}))
+
+			// Benchmark each kind of storage operation over several samples,
+			// as a single slow request says little about the backend.
+			diagnose.Test(ctx, "Benchmark Storage Latency", diagnose.WithTimeout(time.Minute, func(ctx context.Context) error {
+				id, err := uuid.GenerateUUID()
+				if err != nil {
+					return err
+				}
+				stats, err := diagnose.StorageLatencyBenchmark(ctx, *backend, id, diagnoseBenchmarkSamples)
+				if err != nil {
+					return err
+				}
+				for _, op := range []string{"write", "read", "list", "delete"} {
+					diagnose.LatencyResult(ctx, "Storage "+strings.Title(op)+" Latency", stats[op], diagnose.StorageLatencyThreshold,
+						"Vault performs storage operations on every request. Check the network path and the load of the storage backend.")
+				}
+				return nil
+			}))
+		}
+
+		// Raft writes its log and its database synchronously, so slow disks
+		// directly slow down every write to Vault.
+		if !c.skipEndEnd && config.Storage.Type == storageTypeRaft {
+			diagnose.Test(ctx, "Benchmark Raft Fsync Latency", diagnose.WithTimeout(time.Minute, func(ctx context.Context) error {
+				path := os.Getenv(raft.EnvVaultRaftPath)
+				if path == "" {
+					path = config.Storage.Config["path"]
+				}
+				if path == "" {
+					diagnose.Skipped(ctx, "Skipping fsync benchmark, storage folder path is not set.")
+					return nil
+				}
+				stats, err := diagnose.FsyncLatencyBenchmark(ctx, path, diagnoseBenchmarkSamples)
+				if err != nil {
+					return err
+				}
+				diagnose.LatencyResult(ctx, "Raft Fsync Latency", stats, diagnose.FsyncLatencyThreshold,
+					"Raft syncs every write to disk. Use local SSD storage for the raft data directory.")
+				return nil
+			}))
 		}
 		return nil
 	})
@@ -698,6 +743,24 @@ SEALFAIL:
 		return nil
 	}))
 
+	// Auto-unseal devices are contacted on unseal and, for seal wrapped
+	// values, during normal operation, so their latency is worth measuring.
+	if !c.skipEndEnd {
+		diagnose.Test(ctx, "Benchmark Seal Latency", diagnose.WithTimeout(time.Minute, func(ctx context.Context) error {
+			if barrierSeal == nil || barrierSeal.BarrierType() == wrapping.Shamir {
+				diagnose.Skipped(ctx, "Skipping seal latency benchmark. Only supported for auto-unseal.")
+				return nil
+			}
+			stats, err := diagnose.SealLatencyBenchmark(ctx, barrierWrapper, diagnoseBenchmarkSamples)
+			if err != nil {
+				return err
+			}
+			diagnose.LatencyResult(ctx, "Seal Round Trip Latency", stats, diagnose.SealLatencyThreshold,
+				"Check the network path to the seal device or KMS and any rate limits it applies.")
+			return nil
+		}))
+	}
+
 	// The following block contains static checks that are run during the
 	// startHttpServers portion of server run. In other words, they are static
 	// checks during resource creation. Currently there is nothing important in this
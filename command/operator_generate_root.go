@@ -282,7 +282,7 @@ func (c *OperatorGenerateRootCommand) generateOTP(client *api.Client, kind gener
 
 	status, err := f()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error getting root generation status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error getting root generation status: %s", err), err)
 		return "", 2
 	}
 
@@ -317,7 +317,7 @@ func (c *OperatorGenerateRootCommand) decode(client *api.Client, encoded, otp st
 
 		var buf bytes.Buffer
 		if _, err := io.Copy(&buf, stdin); err != nil {
-			c.UI.Error(fmt.Sprintf("Failed to read from stdin: %s", err))
+			outputError(c.UI, fmt.Sprintf("Failed to read from stdin: %s", err), err)
 			return 1
 		}
 
@@ -339,13 +339,13 @@ func (c *OperatorGenerateRootCommand) decode(client *api.Client, encoded, otp st
 
 	status, err := f()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error getting root generation status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error getting root generation status: %s", err), err)
 		return 2
 	}
 
 	token, err := roottoken.DecodeToken(encoded, otp, status.OTPLength)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error decoding root token: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error decoding root token: %s", err), err)
 		return 1
 	}
 
@@ -378,7 +378,7 @@ func (c *OperatorGenerateRootCommand) init(client *api.Client, otp, pgpKey strin
 	}
 	status, err := f(otp, pgpKey)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error initializing root generation: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error initializing root generation: %s", err), err)
 		return 2
 	}
 
@@ -402,7 +402,7 @@ func (c *OperatorGenerateRootCommand) provide(client *api.Client, key string, ki
 	}
 	status, err := f()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error getting root generation status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error getting root generation status: %s", err), err)
 		return 2
 	}
 
@@ -433,7 +433,7 @@ func (c *OperatorGenerateRootCommand) provide(client *api.Client, key string, ki
 
 		var buf bytes.Buffer
 		if _, err := io.Copy(&buf, stdin); err != nil {
-			c.UI.Error(fmt.Sprintf("Failed to read from stdin: %s", err))
+			outputError(c.UI, fmt.Sprintf("Failed to read from stdin: %s", err), err)
 			return 1
 		}
 
@@ -485,7 +485,7 @@ func (c *OperatorGenerateRootCommand) provide(client *api.Client, key string, ki
 	}
 	status, err = fUpd(key, nonce)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error posting unseal key: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error posting unseal key: %s", err), err)
 		return 2
 	}
 	switch Format(c.UI) {
@@ -506,7 +506,7 @@ func (c *OperatorGenerateRootCommand) cancel(client *api.Client, kind generateRo
 		f = client.Sys().GenerateRecoveryOperationTokenCancel
 	}
 	if err := f(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error canceling root token generation: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error canceling root token generation: %s", err), err)
 		return 2
 	}
 	c.UI.Output("Success! Root token generation canceled (if it was started)")
@@ -525,7 +525,7 @@ func (c *OperatorGenerateRootCommand) status(client *api.Client, kind generateRo
 
 	status, err := f()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error getting root generation status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error getting root generation status: %s", err), err)
 		return 2
 	}
 	switch Format(c.UI) {
@@ -272,7 +272,7 @@ func (c *OperatorInitCommand) consulAuto(client *api.Client, req *api.InitReques
 	// Create a client to communicate with Consul
 	consulClient, err := consulapi.NewClient(consulapi.DefaultConfig())
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to create Consul client:%v", err))
+		outputError(c.UI, fmt.Sprintf("Failed to create Consul client:%v", err), err)
 		return 1
 	}
 
@@ -281,7 +281,7 @@ func (c *OperatorInitCommand) consulAuto(client *api.Client, req *api.InitReques
 	addr := client.Address()
 	clientURL, err := url.Parse(addr)
 	if err != nil || clientURL == nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse Vault address %s: %s", addr, err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse Vault address %s: %s", addr, err), err)
 		return 1
 	}
 
@@ -304,7 +304,7 @@ func (c *OperatorInitCommand) consulAuto(client *api.Client, req *api.InitReques
 			// Check the initialization status of the discovered node
 			inited, err := client.Sys().InitStatus()
 			if err != nil {
-				c.UI.Error(fmt.Sprintf("Error checking init status of %q: %s", vaultAddr, err))
+				outputError(c.UI, fmt.Sprintf("Error checking init status of %q: %s", vaultAddr, err), err)
 			}
 			if inited {
 				initedVault = vaultAddr
@@ -328,7 +328,7 @@ func (c *OperatorInitCommand) consulAuto(client *api.Client, req *api.InitReques
 	if initedVault != "" {
 		vaultURL, err := url.Parse(initedVault)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Failed to parse Vault address %q: %s", initedVault, err))
+			outputError(c.UI, fmt.Sprintf("Failed to parse Vault address %q: %s", initedVault, err), err)
 			return 2
 		}
 		vaultAddr := vaultURL.String()
@@ -353,7 +353,7 @@ func (c *OperatorInitCommand) consulAuto(client *api.Client, req *api.InitReques
 		// uninitialized.
 		vaultURL, err := url.Parse(uninitedVaults[0])
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Failed to parse Vault address %q: %s", initedVault, err))
+			outputError(c.UI, fmt.Sprintf("Failed to parse Vault address %q: %s", initedVault, err), err)
 			return 2
 		}
 		vaultAddr := vaultURL.String()
@@ -390,7 +390,7 @@ func (c *OperatorInitCommand) consulAuto(client *api.Client, req *api.InitReques
 		for _, node := range uninitedVaults {
 			vaultURL, err := url.Parse(node)
 			if err != nil {
-				c.UI.Error(fmt.Sprintf("Failed to parse Vault address %q: %s", initedVault, err))
+				outputError(c.UI, fmt.Sprintf("Failed to parse Vault address %q: %s", initedVault, err), err)
 				return 2
 			}
 			vaultAddr := vaultURL.String()
@@ -406,7 +406,7 @@ func (c *OperatorInitCommand) consulAuto(client *api.Client, req *api.InitReques
 func (c *OperatorInitCommand) init(client *api.Client, req *api.InitRequest) int {
 	resp, err := client.Sys().Init(req)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error initializing: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error initializing: %s", err), err)
 		return 2
 	}
 
@@ -487,7 +487,7 @@ func (c *OperatorInitCommand) init(client *api.Client, req *api.InitRequest) int
 func (c *OperatorInitCommand) status(client *api.Client) int {
 	inited, err := client.Sys().InitStatus()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error checking init status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error checking init status: %s", err), err)
 		return 1 // Normally we'd return 2, but 2 means something special here
 	}
 
@@ -67,7 +67,7 @@ func (c *OperatorKeyStatusCommand) Run(args []string) int {
 
 	status, err := client.Sys().KeyStatus()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading key status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error reading key status: %s", err), err)
 		return 2
 	}
 
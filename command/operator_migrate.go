@@ -2,12 +2,14 @@ package command
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/hashicorp/go-hclog"
@@ -30,6 +32,16 @@ var (
 
 var errAbort = errors.New("Migration aborted")
 
+const (
+	// defaultMigrateParallel is the number of keys copied concurrently when
+	// -max-parallel is not set.
+	defaultMigrateParallel = 10
+
+	// migrateProgressInterval is how often the progress of a migration is
+	// reported and checkpointed.
+	migrateProgressInterval = 10 * time.Second
+)
+
 type OperatorMigrateCommand struct {
 	*BaseCommand
 
@@ -37,8 +49,13 @@ type OperatorMigrateCommand struct {
 	flagConfig       string
 	flagStart        string
 	flagReset        bool
+	flagMaxParallel  int
+	flagCheckpoint   string
 	logger           log.Logger
 	ShutdownCh       chan struct{}
+
+	// progressInterval overrides migrateProgressInterval in tests
+	progressInterval time.Duration
 }
 
 type migratorConfig struct {
@@ -96,6 +113,23 @@ func (c *OperatorMigrateCommand) Flags() *FlagSets {
 		Usage:  "Reset the migration lock. No migration will occur.",
 	})
 
+	f.IntVar(&IntVar{
+		Name:    "max-parallel",
+		Target:  &c.flagMaxParallel,
+		Default: defaultMigrateParallel,
+		Usage:   "Maximum number of keys copied concurrently.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "checkpoint",
+		Target:     &c.flagCheckpoint,
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to a file in which the progress of the migration is " +
+			"recorded. If the file exists, the migration resumes after the " +
+			"last key it records. The file is removed once all the keys " +
+			"have been migrated.",
+	})
+
 	return set
 }
 
@@ -121,9 +155,14 @@ func (c *OperatorMigrateCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagMaxParallel < 1 {
+		c.UI.Error("-max-parallel must be at least 1")
+		return 1
+	}
+
 	config, err := c.loadMigratorConfig(c.flagConfig)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfig, err))
+		outputError(c.UI, fmt.Sprintf("Error loading configuration from %s: %s", c.flagConfig, err), err)
 		return 1
 	}
 
@@ -131,7 +170,7 @@ func (c *OperatorMigrateCommand) Run(args []string) int {
 		if err == errAbort {
 			return 0
 		}
-		c.UI.Error(fmt.Sprintf("Error migrating: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error migrating: %s", err), err)
 		return 2
 	}
 
@@ -205,28 +244,317 @@ func (c *OperatorMigrateCommand) migrate(config *migratorConfig) error {
 	}
 }
 
-// migrateAll copies all keys in lexicographic order.
+// migrateAll copies all keys, with up to -max-parallel keys copied at a time.
+// Keys are read from the source in lexicographic order, and the progress of
+// the migration is checkpointed as the key up to which all the keys have been
+// copied.
 func (c *OperatorMigrateCommand) migrateAll(ctx context.Context, from physical.Backend, to physical.Backend) error {
-	return dfsScan(ctx, from, func(ctx context.Context, path string) error {
-		if path < c.flagStart || path == storageMigrationLock || path == vault.CoreLockPath {
+	checkpoint, err := c.loadCheckpoint()
+	if err != nil {
+		return fmt.Errorf("error loading checkpoint: %w", err)
+	}
+	if checkpoint.LastKey != "" {
+		c.logger.Info("resuming migration", "after", checkpoint.LastKey, "copied", checkpoint.Copied)
+	}
+
+	skip := func(path string) bool {
+		if checkpoint.LastKey != "" && path <= checkpoint.LastKey {
+			return true
+		}
+		return path < c.flagStart || path == storageMigrationLock || path == vault.CoreLockPath
+	}
+
+	parallel := c.flagMaxParallel
+	if parallel < 1 {
+		parallel = defaultMigrateParallel
+	}
+
+	copyCtx, cancelFunc := context.WithCancel(ctx)
+	defer cancelFunc()
+
+	progress := newMigrationProgress(checkpoint)
+	wg := &sync.WaitGroup{}
+
+	// Count the keys to copy, to estimate when the migration completes
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		var total uint64
+		err := dfsScan(copyCtx, from, func(ctx context.Context, path string) error {
+			if !skip(path) {
+				total++
+			}
 			return nil
+		})
+		if err == nil && copyCtx.Err() == nil {
+			progress.setTotal(total)
 		}
+	}()
 
-		entry, err := from.Get(ctx, path)
-		if err != nil {
-			return fmt.Errorf("error reading entry: %w", err)
+	// Report the progress until the keys are copied
+	reportDoneCh := make(chan struct{})
+	reportStoppedCh := make(chan struct{})
+	go func() {
+		defer close(reportStoppedCh)
+
+		interval := c.progressInterval
+		if interval == 0 {
+			interval = migrateProgressInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.reportProgress(progress)
+			case <-reportDoneCh:
+				return
+			}
 		}
+	}()
+
+	var copyErr error
+	var copyErrOnce sync.Once
+	keys := make(chan migrationKey, parallel)
+	workers := &sync.WaitGroup{}
+	for i := 0; i < parallel; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			for key := range keys {
+				if copyCtx.Err() != nil {
+					continue
+				}
+				if err := c.migrateKey(copyCtx, from, to, key.path); err != nil {
+					copyErrOnce.Do(func() {
+						copyErr = err
+						cancelFunc()
+					})
+					continue
+				}
+				progress.copied(key.seq)
+			}
+		}()
+	}
 
-		if entry == nil {
+	var seq uint64
+	scanErr := dfsScan(copyCtx, from, func(ctx context.Context, path string) error {
+		if skip(path) {
 			return nil
 		}
 
-		if err := to.Put(ctx, entry); err != nil {
-			return fmt.Errorf("error writing entry: %w", err)
+		progress.started(seq, path)
+		select {
+		case keys <- migrationKey{seq: seq, path: path}:
+			seq++
+		case <-ctx.Done():
 		}
-		c.logger.Info("copied key", "path", path)
 		return nil
 	})
+	close(keys)
+	workers.Wait()
+
+	close(reportDoneCh)
+	<-reportStoppedCh
+	cancelFunc()
+	wg.Wait()
+
+	if copyErr == nil {
+		copyErr = scanErr
+	}
+	if copyErr == nil && ctx.Err() == nil {
+		c.reportProgress(progress)
+		if c.flagCheckpoint != "" {
+			if err := os.Remove(c.flagCheckpoint); err != nil && !os.IsNotExist(err) {
+				c.logger.Warn("error removing checkpoint", "path", c.flagCheckpoint, "error", err)
+			}
+		}
+		return nil
+	}
+
+	// Record how far the migration went so that it can be resumed
+	if err := c.saveCheckpoint(progress.checkpoint()); err != nil {
+		c.logger.Error("error saving checkpoint", "path", c.flagCheckpoint, "error", err)
+	}
+	return copyErr
+}
+
+// migrateKey copies a key from the source to the destination.
+func (c *OperatorMigrateCommand) migrateKey(ctx context.Context, from physical.Backend, to physical.Backend, path string) error {
+	entry, err := from.Get(ctx, path)
+	if err != nil {
+		return fmt.Errorf("error reading entry: %w", err)
+	}
+
+	if entry == nil {
+		return nil
+	}
+
+	if err := to.Put(ctx, entry); err != nil {
+		return fmt.Errorf("error writing entry: %w", err)
+	}
+	c.logger.Info("copied key", "path", path)
+	return nil
+}
+
+// reportProgress logs the throughput of the migration and its estimated
+// remaining time, and saves its checkpoint.
+func (c *OperatorMigrateCommand) reportProgress(progress *migrationProgress) {
+	copied, total, elapsed := progress.stats()
+
+	rate := float64(copied) / elapsed.Seconds()
+	eta := "unknown"
+	percent := "unknown"
+	if total >= copied && rate > 0 {
+		remaining := time.Duration(float64(total-copied) / rate * float64(time.Second))
+		eta = remaining.Round(time.Second).String()
+	}
+	if total > 0 && total >= copied {
+		percent = fmt.Sprintf("%.1f%%", float64(copied)*100/float64(total))
+	}
+	c.logger.Info("migration progress", "copied", copied, "total", total, "percent", percent, "keys_per_second", fmt.Sprintf("%.1f", rate), "eta", eta)
+
+	if err := c.saveCheckpoint(progress.checkpoint()); err != nil {
+		c.logger.Error("error saving checkpoint", "path", c.flagCheckpoint, "error", err)
+	}
+}
+
+// migrationCheckpoint records the progress of a migration, so that an
+// interrupted migration can be resumed.
+type migrationCheckpoint struct {
+	// LastKey is the key up to which all the keys have been copied.
+	LastKey string `json:"last_key"`
+
+	// Copied is the number of keys copied, including by the runs of the
+	// migration which were interrupted.
+	Copied uint64 `json:"copied"`
+}
+
+// loadCheckpoint loads the checkpoint of an interrupted migration from the
+// -checkpoint file, if it exists.
+func (c *OperatorMigrateCommand) loadCheckpoint() (migrationCheckpoint, error) {
+	var checkpoint migrationCheckpoint
+	if c.flagCheckpoint == "" {
+		return checkpoint, nil
+	}
+
+	d, err := ioutil.ReadFile(c.flagCheckpoint)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return checkpoint, err
+	}
+
+	if err := json.Unmarshal(d, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("error parsing %s: %w", c.flagCheckpoint, err)
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint writes the checkpoint to the -checkpoint file, if it is set.
+func (c *OperatorMigrateCommand) saveCheckpoint(checkpoint migrationCheckpoint) error {
+	if c.flagCheckpoint == "" {
+		return nil
+	}
+
+	d, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	// Replace the file at once so that an interruption does not corrupt it
+	tmpPath := c.flagCheckpoint + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, d, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.flagCheckpoint)
+}
+
+// migrationKey is a key to copy, numbered in the order in which it was read
+// from the source.
+type migrationKey struct {
+	seq  uint64
+	path string
+}
+
+// migrationProgress tracks the keys copied by a migration. Keys are copied
+// out of order, so the checkpoint only moves past a key once all the keys
+// before it have been copied.
+type migrationProgress struct {
+	lock sync.Mutex
+
+	start time.Time
+	last  migrationCheckpoint
+
+	// copiedKeys is the number of keys copied since the migration started,
+	// and totalKeys the number of keys to copy, if known
+	copiedKeys uint64
+	totalKeys  uint64
+
+	// next is the number of the first key which has not been copied yet
+	next     uint64
+	pending  map[uint64]string
+	finished map[uint64]bool
+}
+
+func newMigrationProgress(checkpoint migrationCheckpoint) *migrationProgress {
+	return &migrationProgress{
+		start:    time.Now(),
+		last:     checkpoint,
+		pending:  make(map[uint64]string),
+		finished: make(map[uint64]bool),
+	}
+}
+
+// started records that a key is about to be copied.
+func (p *migrationProgress) started(seq uint64, path string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pending[seq] = path
+}
+
+// copied records that a key has been copied.
+func (p *migrationProgress) copied(seq uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.copiedKeys++
+	p.last.Copied++
+	p.finished[seq] = true
+	for p.finished[p.next] {
+		p.last.LastKey = p.pending[p.next]
+		delete(p.finished, p.next)
+		delete(p.pending, p.next)
+		p.next++
+	}
+}
+
+func (p *migrationProgress) setTotal(total uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.totalKeys = total
+}
+
+// stats returns the number of keys copied since the migration started, the
+// number of keys to copy, or 0 if it is not known yet, and the time elapsed.
+func (p *migrationProgress) stats() (uint64, uint64, time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.copiedKeys, p.totalKeys, time.Since(p.start)
+}
+
+func (p *migrationProgress) checkpoint() migrationCheckpoint {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.last
 }
 
 func (c *OperatorMigrateCommand) newBackend(kind string, conf map[string]string) (physical.Backend, error) {
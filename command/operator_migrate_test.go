@@ -3,6 +3,7 @@ package command
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -11,6 +12,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -108,6 +110,92 @@ func TestMigration(t *testing.T) {
 		}
 	})
 
+	t.Run("Parallel", func(t *testing.T) {
+		data := generateData()
+
+		from, err := physicalBackends["inmem"](map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storeData(from, data); err != nil {
+			t.Fatal(err)
+		}
+
+		to, err := physicalBackends["inmem"](map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := OperatorMigrateCommand{
+			logger:           log.NewNullLogger(),
+			flagMaxParallel:  50,
+			progressInterval: time.Millisecond,
+		}
+		if err := cmd.migrateAll(context.Background(), from, to); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := compareStoredData(to, data, ""); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Checkpoint", func(t *testing.T) {
+		data := generateData()
+
+		from, err := physicalBackends["inmem"](map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := storeData(from, data); err != nil {
+			t.Fatal(err)
+		}
+
+		to, err := physicalBackends["inmem"](map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkpointPath := filepath.Join(t.TempDir(), "checkpoint.json")
+		cmd := OperatorMigrateCommand{
+			logger:          log.NewNullLogger(),
+			flagMaxParallel: 4,
+			flagCheckpoint:  checkpointPath,
+		}
+
+		// Interrupt the migration with a write error
+		failing := &recordingBackend{Backend: to, failAfter: 100}
+		if err := cmd.migrateAll(context.Background(), from, failing); err == nil {
+			t.Fatal("expected an error")
+		}
+
+		checkpoint, err := cmd.loadCheckpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if checkpoint.LastKey == "" || checkpoint.Copied == 0 || checkpoint.Copied > 100 {
+			t.Fatalf("bad checkpoint: %#v", checkpoint)
+		}
+
+		// Resume the migration
+		recording := &recordingBackend{Backend: to}
+		if err := cmd.migrateAll(context.Background(), from, recording); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range recording.keys {
+			if key <= checkpoint.LastKey {
+				t.Fatalf("key %q copied again, the checkpoint is at %q", key, checkpoint.LastKey)
+			}
+		}
+
+		if err := compareStoredData(to, data, ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(checkpointPath); !os.IsNotExist(err) {
+			t.Fatalf("expected the checkpoint to be removed, got %v", err)
+		}
+	})
+
 	t.Run("Config parsing", func(t *testing.T) {
 		cmd := new(OperatorMigrateCommand)
 
@@ -253,6 +341,28 @@ func (l randomLister) Delete(ctx context.Context, path string) error {
 	return l.b.Delete(ctx, path)
 }
 
+// recordingBackend wraps a physical backend, recording the keys written to it.
+// Writes fail once failAfter keys have been written, if it is set.
+type recordingBackend struct {
+	physical.Backend
+
+	lock      sync.Mutex
+	keys      []string
+	failAfter int
+}
+
+func (b *recordingBackend) Put(ctx context.Context, entry *physical.Entry) error {
+	b.lock.Lock()
+	if b.failAfter > 0 && len(b.keys) >= b.failAfter {
+		b.lock.Unlock()
+		return errors.New("write failed")
+	}
+	b.keys = append(b.keys, entry.Key)
+	b.lock.Unlock()
+
+	return b.Backend.Put(ctx, entry)
+}
+
 // generateData creates a map of 500 random keys and values
 func generateData() map[string][]byte {
 	result := make(map[string][]byte)
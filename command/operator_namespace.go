@@ -0,0 +1,45 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*OperatorNamespaceCommand)(nil)
+
+type OperatorNamespaceCommand struct {
+	*BaseCommand
+}
+
+func (c *OperatorNamespaceCommand) Synopsis() string {
+	return "Export and import the configuration of a namespace"
+}
+
+func (c *OperatorNamespaceCommand) Help() string {
+	helpText := `
+Usage: vault operator namespace <subcommand> [options] [args]
+
+  This command groups subcommands for copying the configuration of a namespace
+  to another namespace or cluster. The secrets engines, auth methods, policies
+  and identity objects of a namespace are exported into a bundle, which can be
+  imported elsewhere. Secret data is not part of the bundle.
+
+  Export the namespace "ns1/" into a bundle:
+
+      $ vault operator namespace export -namespace=ns1 ns1.json
+
+  Recreate it as the namespace "ns2/", on another cluster:
+
+      $ vault operator namespace import -address=https://dr.example.com:8200 \
+          -namespace=ns2 ns1.json
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorNamespaceCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,337 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorNamespaceExportCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorNamespaceExportCommand)(nil)
+)
+
+// namespaceBundleVersion is the version of the bundle format written by vault
+// operator namespace export.
+const namespaceBundleVersion = 1
+
+// namespaceBundle is the configuration of a namespace, as exported by vault
+// operator namespace export. The identity objects use the format of vault
+// identity import.
+type namespaceBundle struct {
+	Version        int                        `json:"version"`
+	Namespace      string                     `json:"namespace"`
+	SecretsEngines map[string]*api.MountInput `json:"secrets_engines"`
+	AuthMethods    map[string]*api.MountInput `json:"auth_methods"`
+	Policies       map[string]string          `json:"policies"`
+	Identity       *identityImportFile        `json:"identity"`
+}
+
+type OperatorNamespaceExportCommand struct {
+	*BaseCommand
+}
+
+func (c *OperatorNamespaceExportCommand) Synopsis() string {
+	return "Exports the configuration of a namespace into a bundle"
+}
+
+func (c *OperatorNamespaceExportCommand) Help() string {
+	helpText := `
+Usage: vault operator namespace export [options] [PATH]
+
+  Exports the configuration of the current namespace into a JSON bundle, which
+  "vault operator namespace import" recreates in another namespace or cluster.
+  The bundle is written to PATH, or to stdout if PATH is not given.
+
+  The bundle holds:
+
+    - the secrets engines and auth methods, with their mount configuration
+    - the policies, except for "root"
+    - the entities, entity aliases, groups and group aliases
+
+  Secret data, and the configuration and roles stored inside of secrets
+  engines and auth methods, are not exported. The built-in mounts which exist
+  in every namespace are not exported either.
+
+  Export the namespace "ns1/":
+
+      $ vault operator namespace export -namespace=ns1 ns1.json
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorNamespaceExportCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetHTTP)
+}
+
+func (c *OperatorNamespaceExportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*.json")
+}
+
+func (c *OperatorNamespaceExportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorNamespaceExportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 1 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0 or 1, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	bundle, warnings, err := exportNamespaceBundle(client)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error exporting namespace: %s", err), err)
+		return 2
+	}
+	for _, warning := range warnings {
+		c.UI.Warn(fmt.Sprintf("WARNING! %s", warning))
+	}
+
+	b, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error encoding bundle: %s", err), err)
+		return 2
+	}
+
+	if len(args) == 0 {
+		c.UI.Output(string(b))
+		return 0
+	}
+
+	path, err := homedir.Expand(args[0])
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+		return 1
+	}
+	if err := writeOutputFile(path, append(b, '\n'), 0o600); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing bundle: %s", err), err)
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Exported %d secrets engine(s), %d auth method(s), "+
+		"%d policies, %d entities and %d group(s) to %s", len(bundle.SecretsEngines),
+		len(bundle.AuthMethods), len(bundle.Policies), len(bundle.Identity.Entities),
+		len(bundle.Identity.Groups), args[0]))
+	return 0
+}
+
+// exportNamespaceBundle reads the configuration of the namespace of client. It
+// also returns warnings about objects which cannot be exported.
+func exportNamespaceBundle(client *api.Client) (*namespaceBundle, []string, error) {
+	bundle := &namespaceBundle{
+		Version:        namespaceBundleVersion,
+		Namespace:      namespace.Canonicalize(client.Headers().Get(consts.NamespaceHeaderName)),
+		SecretsEngines: map[string]*api.MountInput{},
+		AuthMethods:    map[string]*api.MountInput{},
+		Policies:       map[string]string{},
+		Identity:       &identityImportFile{},
+	}
+	var warnings []string
+
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing secrets engines: %w", err)
+	}
+	for path, mount := range mounts {
+		if !namespaceBuiltinMount(mount.Type) {
+			bundle.SecretsEngines[path] = namespaceBundleMount(mount)
+		}
+	}
+
+	auths, err := client.Sys().ListAuth()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing auth methods: %w", err)
+	}
+	mountPaths := make(map[string]string, len(auths))
+	for path, auth := range auths {
+		mountPaths[auth.Accessor] = path
+		if !namespaceBuiltinMount(auth.Type) {
+			bundle.AuthMethods[path] = namespaceBundleMount(auth)
+		}
+	}
+
+	policies, err := client.Sys().ListPolicies()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing policies: %w", err)
+	}
+	for _, name := range policies {
+		if name == "root" {
+			continue
+		}
+		rules, err := client.Sys().GetPolicy(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading policy %q: %w", name, err)
+		}
+		bundle.Policies[name] = rules
+	}
+
+	entities, err := namespaceBundleReadAll(client, "identity/entity/id")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading entities: %w", err)
+	}
+	entityNames := make(map[string]string, len(entities))
+	for id, data := range entities {
+		disabled, _ := data["disabled"].(bool)
+		entity := &identityImportEntity{
+			Name:     identityImportString(data["name"]),
+			Policies: identityGroupTreeStrings(data["policies"]),
+			Metadata: identityImportStringMap(data["metadata"]),
+			Disabled: &disabled,
+		}
+		entityNames[id] = entity.Name
+
+		aliases, _ := data["aliases"].([]interface{})
+		for _, raw := range aliases {
+			alias, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			mount, ok := mountPaths[identityImportString(alias["mount_accessor"])]
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("Alias %q of entity %q is on an auth method which "+
+					"no longer exists and was not exported", identityImportString(alias["name"]), entity.Name))
+				continue
+			}
+			entity.Aliases = append(entity.Aliases, &identityImportAlias{
+				Name:           identityImportString(alias["name"]),
+				Mount:          mount,
+				CustomMetadata: identityImportStringMap(alias["custom_metadata"]),
+			})
+		}
+		sort.Slice(entity.Aliases, func(i, j int) bool { return entity.Aliases[i].Mount < entity.Aliases[j].Mount })
+
+		bundle.Identity.Entities = append(bundle.Identity.Entities, entity)
+	}
+	sort.Slice(bundle.Identity.Entities, func(i, j int) bool {
+		return bundle.Identity.Entities[i].Name < bundle.Identity.Entities[j].Name
+	})
+
+	groups, err := namespaceBundleReadAll(client, "identity/group/id")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading groups: %w", err)
+	}
+	groupNames := make(map[string]string, len(groups))
+	for id, data := range groups {
+		groupNames[id] = identityImportString(data["name"])
+	}
+	for _, data := range groups {
+		group := &identityImportGroup{
+			Name:     identityImportString(data["name"]),
+			Type:     identityImportString(data["type"]),
+			Policies: identityGroupTreeStrings(data["policies"]),
+			Metadata: identityImportStringMap(data["metadata"]),
+		}
+		for _, id := range identityGroupTreeStrings(data["member_entity_ids"]) {
+			if name, ok := entityNames[id]; ok {
+				group.MemberEntities = append(group.MemberEntities, name)
+			}
+		}
+		for _, id := range identityGroupTreeStrings(data["member_group_ids"]) {
+			if name, ok := groupNames[id]; ok {
+				group.MemberGroups = append(group.MemberGroups, name)
+			}
+		}
+		sort.Strings(group.MemberEntities)
+		sort.Strings(group.MemberGroups)
+
+		if alias, ok := data["alias"].(map[string]interface{}); ok && alias["name"] != nil {
+			mount, ok := mountPaths[identityImportString(alias["mount_accessor"])]
+			if ok {
+				group.Alias = &identityImportAlias{
+					Name:  identityImportString(alias["name"]),
+					Mount: mount,
+				}
+			} else {
+				warnings = append(warnings, fmt.Sprintf("Alias %q of group %q is on an auth method which "+
+					"no longer exists and was not exported", identityImportString(alias["name"]), group.Name))
+			}
+		}
+
+		bundle.Identity.Groups = append(bundle.Identity.Groups, group)
+	}
+	sort.Slice(bundle.Identity.Groups, func(i, j int) bool {
+		return bundle.Identity.Groups[i].Name < bundle.Identity.Groups[j].Name
+	})
+
+	return bundle, warnings, nil
+}
+
+// namespaceBundleMount returns the input which mounts a secrets engine or auth
+// method like mount.
+func namespaceBundleMount(mount *api.MountOutput) *api.MountInput {
+	input := &api.MountInput{
+		Type:                  mount.Type,
+		Description:           mount.Description,
+		Local:                 mount.Local,
+		SealWrap:              mount.SealWrap,
+		ExternalEntropyAccess: mount.ExternalEntropyAccess,
+		Options:               mount.Options,
+		Config: api.MountConfigInput{
+			ForceNoCache:              mount.Config.ForceNoCache,
+			AuditNonHMACRequestKeys:   mount.Config.AuditNonHMACRequestKeys,
+			AuditNonHMACResponseKeys:  mount.Config.AuditNonHMACResponseKeys,
+			ListingVisibility:         mount.Config.ListingVisibility,
+			PassthroughRequestHeaders: mount.Config.PassthroughRequestHeaders,
+			AllowedResponseHeaders:    mount.Config.AllowedResponseHeaders,
+			TokenType:                 mount.Config.TokenType,
+			AllowedManagedKeys:        mount.Config.AllowedManagedKeys,
+		},
+	}
+	if mount.Config.DefaultLeaseTTL != 0 {
+		input.Config.DefaultLeaseTTL = fmt.Sprintf("%ds", mount.Config.DefaultLeaseTTL)
+	}
+	if mount.Config.MaxLeaseTTL != 0 {
+		input.Config.MaxLeaseTTL = fmt.Sprintf("%ds", mount.Config.MaxLeaseTTL)
+	}
+	return input
+}
+
+// namespaceBundleReadAll lists path and reads each of the listed keys below
+// it, returning their data by key.
+func namespaceBundleReadAll(client *api.Client, path string) (map[string]map[string]interface{}, error) {
+	list, err := client.Logical().List(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, _ := extractListData(list)
+
+	all := make(map[string]map[string]interface{}, len(keys))
+	for _, raw := range keys {
+		key, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		secret, err := client.Logical().Read(path + "/" + key)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s/%s: %w", path, key, err)
+		}
+		if secret != nil && secret.Data != nil {
+			all[key] = secret.Data
+		}
+	}
+	return all, nil
+}
@@ -0,0 +1,361 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorNamespaceImportCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorNamespaceImportCommand)(nil)
+)
+
+type OperatorNamespaceImportCommand struct {
+	*BaseCommand
+
+	flagDryRun bool
+
+	testStdin io.Reader // for tests
+}
+
+func (c *OperatorNamespaceImportCommand) Synopsis() string {
+	return "Recreates the configuration of a namespace from a bundle"
+}
+
+func (c *OperatorNamespaceImportCommand) Help() string {
+	helpText := `
+Usage: vault operator namespace import [options] PATH
+
+  Recreates the configuration exported by "vault operator namespace export"
+  in the current namespace. The bundle is read from the file at PATH, or from
+  stdin if PATH is "-". The changes are printed before they are applied.
+
+  Secrets engines and auth methods which are missing are mounted, and
+  policies are written if they differ. Mounts which already exist are left
+  as they are. Entities, aliases and groups are imported like by "vault
+  identity import", so importing the same bundle again changes nothing.
+
+  Show what importing a bundle into the namespace "ns2/" would change:
+
+      $ vault operator namespace import -namespace=ns2 -dry-run ns1.json
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorNamespaceImportCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  &c.flagDryRun,
+		Default: false,
+		Usage:   "Only print the changes the import would make.",
+	})
+
+	return set
+}
+
+func (c *OperatorNamespaceImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*.json")
+}
+
+func (c *OperatorNamespaceImportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorNamespaceImportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	path := strings.TrimSpace(args[0])
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+		if c.testStdin != nil {
+			r = c.testStdin
+		}
+	} else {
+		expanded, err := homedir.Expand(path)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+			return 1
+		}
+		file, err := os.Open(expanded)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error opening file: %s", err), err)
+			return 1
+		}
+		defer file.Close()
+		r = file
+	}
+
+	bundle, err := parseNamespaceBundle(r)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing %s: %s", path, err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	// Mounts and policies are applied before the identity objects are
+	// planned, since aliases refer to auth methods by their accessor.
+	mountPlan, err := planNamespaceBundleMounts(client, bundle)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error planning import: %s", err), err)
+		return 2
+	}
+
+	identityPlan := &identityImportPlan{
+		client:    client,
+		entityIDs: map[string]string{},
+		groupIDs:  map[string]string{},
+	}
+	if c.flagDryRun {
+		// The auth methods the import would mount have no accessor yet.
+		auths, err := client.Sys().ListAuth()
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error listing auth methods: %s", err), err)
+			return 2
+		}
+		identityPlan.accessors = make(map[string]string, len(auths)+len(bundle.AuthMethods))
+		for path := range bundle.AuthMethods {
+			identityPlan.accessors[ensureTrailingSlash(sanitizePath(path))] = ""
+		}
+		for path, auth := range auths {
+			identityPlan.accessors[path] = auth.Accessor
+		}
+
+		if err := identityPlan.build(bundle.Identity); err != nil {
+			outputError(c.UI, fmt.Sprintf("Error planning import: %s", err), err)
+			return 2
+		}
+
+		changes := append(mountPlan, identityPlan.changes...)
+		if len(changes) == 0 {
+			c.UI.Output("No changes")
+			return 0
+		}
+		c.printChanges(changes)
+		c.UI.Output(fmt.Sprintf("\nDry run: %d change(s) would be applied", len(changes)))
+		return 0
+	}
+
+	c.printChanges(mountPlan)
+	if code := c.applyChanges(mountPlan, 0); code != 0 {
+		return code
+	}
+
+	if err := identityPlan.build(bundle.Identity); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error planning import: %s", err), err)
+		c.UI.Error(fmt.Sprintf("%d change(s) were applied", len(mountPlan)))
+		return 2
+	}
+	c.printChanges(identityPlan.changes)
+	if code := c.applyChanges(identityPlan.changes, len(mountPlan)); code != 0 {
+		return code
+	}
+
+	total := len(mountPlan) + len(identityPlan.changes)
+	if total == 0 {
+		c.UI.Output("No changes")
+		return 0
+	}
+	c.UI.Output(fmt.Sprintf("\nSuccess! Applied %d change(s)", total))
+	return 0
+}
+
+func (c *OperatorNamespaceImportCommand) printChanges(changes []*identityImportChange) {
+	for _, change := range changes {
+		c.UI.Output(change.summary)
+		for _, detail := range change.details {
+			c.UI.Output("    " + detail)
+		}
+	}
+}
+
+// applyChanges applies changes, after applied changes have been applied
+// before.
+func (c *OperatorNamespaceImportCommand) applyChanges(changes []*identityImportChange, applied int) int {
+	for i, change := range changes {
+		if err := change.apply(); err != nil {
+			outputError(c.UI, fmt.Sprintf("Error applying %q: %s", change.summary, err), err)
+			c.UI.Error(fmt.Sprintf("%d change(s) were applied", applied+i))
+			return 2
+		}
+	}
+	return 0
+}
+
+func parseNamespaceBundle(r io.Reader) (*namespaceBundle, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var bundle namespaceBundle
+	if err := dec.Decode(&bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Version != namespaceBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	if bundle.Identity == nil {
+		bundle.Identity = &identityImportFile{}
+	}
+	if err := bundle.Identity.validate(); err != nil {
+		return nil, err
+	}
+	for path, mount := range bundle.SecretsEngines {
+		if mount == nil || mount.Type == "" {
+			return nil, fmt.Errorf("secrets engine %q has no type", path)
+		}
+	}
+	for path, mount := range bundle.AuthMethods {
+		if mount == nil || mount.Type == "" {
+			return nil, fmt.Errorf("auth method %q has no type", path)
+		}
+	}
+	return &bundle, nil
+}
+
+// planNamespaceBundleMounts returns the changes which mount the secrets
+// engines and auth methods of bundle and write its policies.
+func planNamespaceBundleMounts(client *api.Client, bundle *namespaceBundle) ([]*identityImportChange, error) {
+	var changes []*identityImportChange
+
+	mounts, err := client.Sys().ListMounts()
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets engines: %w", err)
+	}
+	for _, path := range namespaceBundleSortedPaths(bundle.SecretsEngines) {
+		mount := bundle.SecretsEngines[path]
+		if existing, ok := mounts[ensureTrailingSlash(sanitizePath(path))]; ok {
+			if existing.Type != mount.Type {
+				return nil, fmt.Errorf("secrets engine at %q is of type %q, not %q", path, existing.Type, mount.Type)
+			}
+			continue
+		}
+
+		path := path
+		changes = append(changes, &identityImportChange{
+			summary: fmt.Sprintf("+ secrets engine %s (%s)", path, mount.Type),
+			details: namespaceBundleMountDetails(mount),
+			apply: func() error {
+				return client.Sys().Mount(path, mount)
+			},
+		})
+	}
+
+	auths, err := client.Sys().ListAuth()
+	if err != nil {
+		return nil, fmt.Errorf("error listing auth methods: %w", err)
+	}
+	for _, path := range namespaceBundleSortedPaths(bundle.AuthMethods) {
+		mount := bundle.AuthMethods[path]
+		if existing, ok := auths[ensureTrailingSlash(sanitizePath(path))]; ok {
+			if existing.Type != mount.Type {
+				return nil, fmt.Errorf("auth method at %q is of type %q, not %q", path, existing.Type, mount.Type)
+			}
+			continue
+		}
+
+		path := path
+		changes = append(changes, &identityImportChange{
+			summary: fmt.Sprintf("+ auth method %s (%s)", path, mount.Type),
+			details: namespaceBundleMountDetails(mount),
+			apply: func() error {
+				return client.Sys().EnableAuthWithOptions(path, mount)
+			},
+		})
+	}
+
+	names := make([]string, 0, len(bundle.Policies))
+	for name := range bundle.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rules := bundle.Policies[name]
+		existing, err := client.Sys().GetPolicy(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading policy %q: %w", name, err)
+		}
+		if existing == rules {
+			continue
+		}
+
+		summary := fmt.Sprintf("~ policy %q", name)
+		if existing == "" {
+			summary = fmt.Sprintf("+ policy %q", name)
+		}
+		name := name
+		changes = append(changes, &identityImportChange{
+			summary: summary,
+			apply: func() error {
+				return client.Sys().PutPolicy(name, rules)
+			},
+		})
+	}
+
+	return changes, nil
+}
+
+func namespaceBundleMountDetails(mount *api.MountInput) []string {
+	var details []string
+	if mount.Description != "" {
+		details = append(details, "description: "+mount.Description)
+	}
+	if len(mount.Options) > 0 {
+		details = append(details, "options: "+identityImportMap(mount.Options))
+	}
+	if mount.Config.DefaultLeaseTTL != "" {
+		details = append(details, "default_lease_ttl: "+mount.Config.DefaultLeaseTTL)
+	}
+	if mount.Config.MaxLeaseTTL != "" {
+		details = append(details, "max_lease_ttl: "+mount.Config.MaxLeaseTTL)
+	}
+	if mount.Local {
+		details = append(details, "local: true")
+	}
+	if mount.SealWrap {
+		details = append(details, "seal_wrap: true")
+	}
+	return details
+}
+
+func namespaceBundleSortedPaths(mounts map[string]*api.MountInput) []string {
+	paths := make([]string, 0, len(mounts))
+	for path := range mounts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
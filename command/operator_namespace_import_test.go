@@ -0,0 +1,240 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorNamespaceImportCommand(tb testing.TB) (*cli.MockUi, *OperatorNamespaceImportCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorNamespaceImportCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func testOperatorNamespaceExportCommand(tb testing.TB) (*cli.MockUi, *OperatorNamespaceExportCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorNamespaceExportCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorNamespaceImportCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		args  []string
+		stdin string
+		out   string
+		code  int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"",
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"",
+			"Too many arguments",
+			1,
+		},
+		{
+			"unsupported_version",
+			[]string{"-"},
+			`{"version": 2}`,
+			"unsupported bundle version 2",
+			1,
+		},
+		{
+			"missing_type",
+			[]string{"-"},
+			`{"version": 1, "auth_methods": {"userpass/": {}}}`,
+			`auth method "userpass/" has no type`,
+			1,
+		},
+		{
+			"invalid_identity",
+			[]string{"-"},
+			`{"version": 1, "identity": {"entities": [{"name": "alice"}, {"name": "alice"}]}}`,
+			`entity "alice" is defined more than once`,
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testOperatorNamespaceImportCommand(t)
+				cmd.testStdin = strings.NewReader(tc.stdin)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		source, closeSource := testVaultServer(t)
+		defer closeSource()
+		target, closeTarget := testVaultServer(t)
+		defer closeTarget()
+
+		if err := source.Sys().Mount("kv/", &api.MountInput{
+			Type:        "kv",
+			Description: "team secrets",
+			Options:     map[string]string{"version": "2"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := source.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{
+			Type: "userpass",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := source.Sys().PutPolicy("dev", `path "kv/*" { capabilities = ["read"] }`); err != nil {
+			t.Fatal(err)
+		}
+		_, cmd := testIdentityImportCommand(t)
+		cmd.client = source
+		cmd.testStdin = strings.NewReader(`{
+  "entities": [{
+    "name": "alice",
+    "policies": ["dev"],
+    "aliases": [{"name": "alice", "mount": "userpass"}],
+    "groups": ["web"]
+  }],
+  "groups": [{
+    "name": "ops",
+    "type": "external",
+    "alias": {"name": "ops", "mount": "userpass/"}
+  }]
+}`)
+		if code := cmd.Run([]string{"-"}); code != 0 {
+			t.Fatalf("expected %d to be %d", code, 0)
+		}
+
+		dir, err := ioutil.TempDir("", "vault-namespace-bundle")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "bundle.json")
+
+		export := func(client *api.Client) *namespaceBundle {
+			t.Helper()
+
+			ui, cmd := testOperatorNamespaceExportCommand(t)
+			cmd.client = client
+
+			code := cmd.Run([]string{path})
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			bundle, err := parseNamespaceBundle(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return bundle
+		}
+
+		run := func(args ...string) string {
+			t.Helper()
+
+			ui, cmd := testOperatorNamespaceImportCommand(t)
+			cmd.client = target
+
+			code := cmd.Run(append(args, path))
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+			return ui.OutputWriter.String()
+		}
+
+		exported := export(source)
+
+		output := run("-dry-run")
+		for _, expected := range []string{
+			"+ secrets engine kv/ (kv)",
+			"    description: team secrets",
+			"    options: {version=2}",
+			"+ auth method userpass/ (userpass)",
+			`+ policy "dev"`,
+			`+ entity "alice"`,
+			`+ entity alias "alice" on userpass/ (entity "alice")`,
+			`+ group "web"`,
+			`+ group alias "ops" on userpass/ (group "ops")`,
+			"Dry run: 8 change(s) would be applied",
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("expected %q to contain %q", output, expected)
+			}
+		}
+		if mounts, err := target.Sys().ListMounts(); err != nil || mounts["kv/"] != nil {
+			t.Fatalf("expected dry run not to mount kv/, got %v, %v", mounts["kv/"], err)
+		}
+
+		output = run()
+		if expected := "Success! Applied 8 change(s)"; !strings.Contains(output, expected) {
+			t.Errorf("expected %q to contain %q", output, expected)
+		}
+
+		if imported := export(target); !reflect.DeepEqual(imported, exported) {
+			t.Errorf("expected the imported namespace to match the exported one\nexported: %#v\nimported: %#v", exported, imported)
+		}
+
+		if output := run(); output != "No changes\n" {
+			t.Errorf("expected a second import to change nothing, got %q", output)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorNamespaceImportCommand(t)
+		assertNoTabs(t, cmd)
+
+		_, export := testOperatorNamespaceExportCommand(t)
+		assertNoTabs(t, export)
+	})
+}
@@ -1,14 +1,25 @@
 package command
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/fatih/color"
+	"github.com/hashicorp/vault/api"
+	"github.com/mattn/go-isatty"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
 
+// defaultAutopilotStateWatchInterval is how often the autopilot state is
+// refreshed with -watch if no interval is given.
+const defaultAutopilotStateWatchInterval = 2 * time.Second
+
 var (
 	_ cli.Command             = (*OperatorRaftAutopilotStateCommand)(nil)
 	_ cli.CommandAutocomplete = (*OperatorRaftAutopilotStateCommand)(nil)
@@ -16,6 +27,11 @@ var (
 
 type OperatorRaftAutopilotStateCommand struct {
 	*BaseCommand
+
+	flagWatch         bool
+	flagWatchInterval time.Duration
+
+	testStopCh chan struct{} // for tests
 }
 
 func (c *OperatorRaftAutopilotStateCommand) Synopsis() string {
@@ -24,9 +40,19 @@ func (c *OperatorRaftAutopilotStateCommand) Synopsis() string {
 
 func (c *OperatorRaftAutopilotStateCommand) Help() string {
 	helpText := `
-Usage: vault operator raft autopilot state
+Usage: vault operator raft autopilot state [options]
 
   Displays the state of the raft cluster under integrated storage as seen by autopilot.
+
+  Keep watching the state, with a table of the health, last contact and log
+  lag of each node refreshed in place:
+
+      $ vault operator raft autopilot state -watch
+
+  Stream the state as one line of JSON per refresh:
+
+      $ vault operator raft autopilot state -watch -format=json
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -47,6 +73,29 @@ func (c *OperatorRaftAutopilotStateCommand) Flags() *FlagSets {
 	if ok && ui.format == "table" {
 		ui.format = "pretty"
 	}
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:       "watch",
+		Target:     &c.flagWatch,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Keep refreshing the state until interrupted. With the default " +
+			"format, a table of the nodes is redrawn in place. With -format=json, " +
+			"the state is printed as one line of JSON per refresh.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "watch-interval",
+		Target:     &c.flagWatchInterval,
+		Default:    defaultAutopilotStateWatchInterval,
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage:      "How often to refresh the state when -watch is set.",
+	})
+
 	return set
 }
 
@@ -74,15 +123,24 @@ func (c *OperatorRaftAutopilotStateCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagWatch && c.flagWatchInterval <= 0 {
+		c.UI.Error("-watch-interval must be positive")
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
+	if c.flagWatch {
+		return c.watch(client)
+	}
+
 	state, err := client.Sys().RaftAutopilotState()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error checking autopilot state: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error checking autopilot state: %s", err), err)
 		return 2
 	}
 
@@ -92,3 +150,136 @@ func (c *OperatorRaftAutopilotStateCommand) Run(args []string) int {
 
 	return OutputData(c.UI, state)
 }
+
+// watch refreshes the autopilot state on an interval until interrupted.
+// Errors are reported but do not stop the watch, so that the command keeps
+// running through the incidents it is meant to follow.
+func (c *OperatorRaftAutopilotStateCommand) watch(client *api.Client) int {
+	stopCh := c.testStopCh
+	if stopCh == nil {
+		stopCh = MakeShutdownCh()
+	}
+
+	useColor := !color.NoColor && os.Getenv(EnvVaultCLINoColor) == ""
+	inPlace := useColor && isatty.IsTerminal(os.Stdout.Fd())
+
+	ticker := time.NewTicker(c.flagWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		state, err := client.Sys().RaftAutopilotState()
+		switch {
+		case err != nil:
+			outputError(c.UI, fmt.Sprintf("%s: Error checking autopilot state: %s", time.Now().Format(time.RFC3339), err), err)
+		case state == nil:
+		default:
+			switch Format(c.UI) {
+			case "json", "jsonl":
+				b, err := json.Marshal(state)
+				if err != nil {
+					outputError(c.UI, fmt.Sprintf("Error encoding autopilot state: %s", err), err)
+					return 2
+				}
+				c.UI.Output(string(b))
+			case "table", "pretty":
+				out := autopilotStateTable(state, time.Now(), useColor)
+				if inPlace {
+					// Move the cursor home and clear the screen so the table
+					// is redrawn in place
+					out = "\033[H\033[2J" + out
+				} else {
+					out = out + "\n"
+				}
+				c.UI.Output(out)
+			default:
+				if code := OutputData(c.UI, state); code != 0 {
+					return code
+				}
+			}
+		}
+
+		select {
+		case <-stopCh:
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// autopilotStateTable renders the state as a summary line followed by a table
+// of the nodes, the leader first. The log lag of a node is how far its last
+// index is behind the leader's. When useColor is set, healthy nodes are
+// printed in green and unhealthy ones in red.
+func autopilotStateTable(state *api.AutopilotState, now time.Time, useColor bool) string {
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+	paint := func(healthy bool, s string) string {
+		switch {
+		case !useColor:
+			return s
+		case healthy:
+			return green.Sprint(s)
+		default:
+			return red.Sprint(s)
+		}
+	}
+
+	voters := make(map[string]bool, len(state.Voters))
+	for _, id := range state.Voters {
+		voters[id] = true
+	}
+
+	servers := make([]*api.AutopilotServer, 0, len(state.Servers))
+	for _, server := range state.Servers {
+		servers = append(servers, server)
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		if (servers[i].ID == state.Leader) != (servers[j].ID == state.Leader) {
+			return servers[i].ID == state.Leader
+		}
+		if voters[servers[i].ID] != voters[servers[j].ID] {
+			return voters[servers[i].ID]
+		}
+		return servers[i].ID < servers[j].ID
+	})
+
+	leaderName := state.Leader
+	var leaderIndex uint64
+	if leader, ok := state.Servers[state.Leader]; ok {
+		leaderName = leader.Name
+		leaderIndex = leader.LastIndex
+	}
+
+	out := []string{"Node | Address | Status | Node Status | Healthy | Last Contact | Last Index | Log Lag"}
+	for _, server := range servers {
+		lag := "n/a"
+		if leaderIndex > 0 {
+			var behind uint64
+			if server.LastIndex < leaderIndex {
+				behind = leaderIndex - server.LastIndex
+			}
+			lag = fmt.Sprintf("%d", behind)
+		}
+		lastContact := server.LastContact
+		if server.ID == state.Leader || lastContact == "" {
+			lastContact = "-"
+		}
+		out = append(out, fmt.Sprintf("%s | %s | %s | %s | %t | %s | %d | %s",
+			server.Name, server.Address, server.Status, server.NodeStatus,
+			server.Healthy, lastContact, server.LastIndex, lag))
+	}
+
+	lines := strings.Split(tableOutput(out, nil), "\n")
+	for i := range lines {
+		// Skip the header and its underline
+		if i < 2 || i-2 >= len(servers) {
+			continue
+		}
+		lines[i] = paint(servers[i-2].Healthy, lines[i])
+	}
+
+	summary := fmt.Sprintf("Healthy: %s    Failure Tolerance: %d    Leader: %s    Updated: %s",
+		paint(state.Healthy, fmt.Sprintf("%t", state.Healthy)), state.FailureTolerance, leaderName, now.Format("15:04:05"))
+
+	return summary + "\n\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,198 @@
+package command
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorRaftAutopilotStateCommand(tb testing.TB) (*cli.MockUi, *OperatorRaftAutopilotStateCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorRaftAutopilotStateCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+const testAutopilotStateResponse = `{
+  "data": {
+    "healthy": false,
+    "failure_tolerance": 0,
+    "leader": "node1",
+    "voters": ["node1", "node2", "node3"],
+    "servers": {
+      "node1": {"id": "node1", "name": "node1", "address": "127.0.0.1:8201", "node_status": "alive", "status": "leader", "healthy": true, "last_index": 120},
+      "node2": {"id": "node2", "name": "node2", "address": "127.0.0.2:8201", "node_status": "alive", "status": "voter", "healthy": true, "last_contact": "1.2ms", "last_index": 118},
+      "node3": {"id": "node3", "name": "node3", "address": "127.0.0.3:8201", "node_status": "left", "status": "voter", "healthy": false, "last_contact": "45s", "last_index": 20}
+    }
+  }
+}`
+
+func testAutopilotStateServer(tb testing.TB) *api.Client {
+	tb.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testAutopilotStateResponse))
+	}))
+	tb.Cleanup(server.Close)
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return client
+}
+
+func TestOperatorRaftAutopilotStateCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"too_many_args",
+			[]string{"foo"},
+			"Incorrect arguments",
+			1,
+		},
+		{
+			"invalid_watch_interval",
+			[]string{"-watch", "-watch-interval=0s"},
+			"-watch-interval must be positive",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testOperatorRaftAutopilotStateCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testOperatorRaftAutopilotStateCommand(t)
+		cmd.client = testAutopilotStateServer(t)
+		stopCh := make(chan struct{})
+		cmd.testStopCh = stopCh
+
+		codeCh := make(chan int, 1)
+		go func() {
+			codeCh <- cmd.Run([]string{"-watch", "-watch-interval=50ms"})
+		}()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for strings.Count(ui.OutputWriter.String(), "Failure Tolerance") < 2 {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the state to be refreshed, got %q", ui.OutputWriter.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(stopCh)
+		if code := <-codeCh; code != 0 {
+			t.Errorf("expected %d to be %d", code, 0)
+		}
+
+		for _, expected := range []string{
+			"Healthy: false",
+			"Leader: node1",
+			"Log Lag",
+			"node3    127.0.0.3:8201    voter     left",
+		} {
+			if !strings.Contains(ui.OutputWriter.String(), expected) {
+				t.Errorf("expected %q to contain %q", ui.OutputWriter.String(), expected)
+			}
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testOperatorRaftAutopilotStateCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error checking autopilot state: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorRaftAutopilotStateCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestAutopilotStateTable(t *testing.T) {
+	t.Parallel()
+
+	client := testAutopilotStateServer(t)
+	state, err := client.Sys().RaftAutopilotState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := autopilotStateTable(state, time.Date(2022, 3, 1, 10, 4, 5, 0, time.UTC), false)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected 7 lines, got %q", out)
+	}
+	if exp := "Healthy: false    Failure Tolerance: 0    Leader: node1    Updated: 10:04:05"; lines[0] != exp {
+		t.Errorf("expected %q to be %q", lines[0], exp)
+	}
+
+	// The leader comes first and the lag is relative to its last index
+	for i, expected := range []string{"node1", "node2", "node3"} {
+		fields := strings.Fields(lines[i+4])
+		if fields[0] != expected {
+			t.Errorf("expected row %d to be %s, got %q", i, expected, lines[i+4])
+		}
+	}
+	for row, lag := range map[int]string{4: "0", 5: "2", 6: "100"} {
+		fields := strings.Fields(lines[row])
+		if fields[len(fields)-1] != lag {
+			t.Errorf("expected the lag of %q to be %s", lines[row], lag)
+		}
+	}
+}
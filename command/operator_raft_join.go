@@ -152,19 +152,19 @@ func (c *OperatorRaftJoinCommand) Run(args []string) int {
 
 	leaderCACert, err := parseFlagFile(c.flagLeaderCACert)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse leader CA certificate: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse leader CA certificate: %s", err), err)
 		return 1
 	}
 
 	leaderClientCert, err := parseFlagFile(c.flagLeaderClientCert)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse leader client certificate: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse leader client certificate: %s", err), err)
 		return 1
 	}
 
 	leaderClientKey, err := parseFlagFile(c.flagLeaderClientKey)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse leader client key: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse leader client key: %s", err), err)
 		return 1
 	}
 
@@ -197,7 +197,7 @@ func (c *OperatorRaftJoinCommand) Run(args []string) int {
 
 	resp, err := client.Sys().RaftJoin(joinReq)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error joining the node to the Raft cluster: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error joining the node to the Raft cluster: %s", err), err)
 		return 2
 	}
 
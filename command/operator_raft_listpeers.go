@@ -90,7 +90,7 @@ func (c *OperatorRaftListPeersCommand) Run(args []string) int {
 		secret, err = client.Logical().Read("sys/storage/raft/configuration")
 	}
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading the raft cluster configuration: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error reading the raft cluster configuration: %s", err), err)
 		return 2
 	}
 	if secret == nil {
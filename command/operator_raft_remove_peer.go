@@ -95,7 +95,7 @@ func (c *OperatorRaftRemovePeerCommand) Run(args []string) int {
 		"dr_operation_token": c.flagDRToken,
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error removing the peer from raft cluster: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error removing the peer from raft cluster: %s", err), err)
 		return 2
 	}
 
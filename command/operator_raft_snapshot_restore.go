@@ -1,10 +1,17 @@
 package command
 
 import (
+	"archive/tar"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/dustin/go-humanize"
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -14,9 +21,17 @@ var (
 	_ cli.CommandAutocomplete = (*OperatorRaftSnapshotRestoreCommand)(nil)
 )
 
+// snapshotRestoreProgressInterval is how often the progress of the upload and
+// of the restore of a snapshot is reported.
+const snapshotRestoreProgressInterval = 5 * time.Second
+
 type OperatorRaftSnapshotRestoreCommand struct {
-	flagForce bool
+	flagForce    bool
+	flagProgress bool
 	*BaseCommand
+
+	// progressInterval overrides snapshotRestoreProgressInterval in tests
+	progressInterval time.Duration
 }
 
 func (c *OperatorRaftSnapshotRestoreCommand) Synopsis() string {
@@ -25,12 +40,22 @@ func (c *OperatorRaftSnapshotRestoreCommand) Synopsis() string {
 
 func (c *OperatorRaftSnapshotRestoreCommand) Help() string {
 	helpText := `
-Usage: vault operator raft snapshot restore <snapshot_file>
+Usage: vault operator raft snapshot restore <snapshot_file> [<delta_file>...]
 
   Installs the provided snapshot, returning the cluster to the state defined in it.
 
 	  $ vault operator raft snapshot restore raft.snap
 
+  Delta snapshots taken with "vault operator raft snapshot save -incremental"
+  are given after the full snapshot they build on, in order. The cluster is
+  returned to the state defined by the last one:
+
+	  $ vault operator raft snapshot restore raft.snap raft-1.delta raft-2.delta
+
+  The progress of the upload is reported, then the command waits for the
+  active node to apply the snapshot, reporting its progress, unless
+  -progress=false is given.
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -48,6 +73,15 @@ func (c *OperatorRaftSnapshotRestoreCommand) Flags() *FlagSets {
 		Usage:   "This bypasses checks ensuring the Autounseal or shamir keys are consistent with the snapshot data.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "progress",
+		Target:  &c.flagProgress,
+		Default: true,
+		Usage: "Report the progress of the upload of the snapshot, then wait for " +
+			"the active node to apply it and report the progress of the restore. " +
+			"When false, the command returns once the snapshot is uploaded.",
+	})
+
 	return set
 }
 
@@ -67,40 +101,250 @@ func (c *OperatorRaftSnapshotRestoreCommand) Run(args []string) int {
 		return 1
 	}
 
-	snapFile := ""
-
 	args = f.Args()
-	switch len(args) {
-	case 1:
-		snapFile = strings.TrimSpace(args[0])
-	default:
-		c.UI.Error(fmt.Sprintf("Incorrect arguments (expected 1, got %d)", len(args)))
+	if len(args) == 0 {
+		c.UI.Error("Incorrect arguments (expected at least 1, got 0)")
 		return 1
 	}
 
+	snapFile := strings.TrimSpace(args[0])
 	if len(snapFile) == 0 {
 		c.UI.Error("Snapshot file name is required")
 		return 1
 	}
 
+	if len(args) > 1 {
+		return c.restoreChain(args)
+	}
+
 	snapReader, err := os.Open(snapFile)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error opening policy file: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error opening policy file: %s", err), err)
 		return 2
 	}
 	defer snapReader.Close()
 
+	info, err := snapReader.Stat()
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error opening snapshot file: %s", err), err)
+		return 2
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
-	err = client.Sys().RaftSnapshotRestore(snapReader, c.flagForce)
+	upload := newSnapshotFileUploadReader(snapReader, info.Size())
+	err = c.upload(client, upload.size, &upload.read, func() error {
+		return client.Sys().RaftSnapshotRestore(upload, c.flagForce)
+	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error installing the snapshot: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error installing the snapshot: %s", err), err)
 		return 2
 	}
 
 	return 0
 }
+
+// upload runs restore, which uploads a snapshot of size bytes, reporting how
+// many bytes have been read so far. It then waits for the restore to be
+// applied.
+func (c *OperatorRaftSnapshotRestoreCommand) upload(client *api.Client, size int64, read *int64, restore func() error) error {
+	if !c.flagProgress {
+		return restore()
+	}
+
+	interval := c.progressInterval
+	if interval == 0 {
+		interval = snapshotRestoreProgressInterval
+	}
+
+	// The restore is applied by the active node, whose status must be polled
+	// directly as the status is not forwarded
+	statusClient := client
+	if leader, err := client.Sys().Leader(); err == nil && leader.LeaderAddress != "" && !leader.IsSelf {
+		if clone, err := client.Clone(); err == nil && clone.SetAddress(leader.LeaderAddress) == nil {
+			statusClient = clone
+		}
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				uploaded := atomic.LoadInt64(read)
+				var remaining time.Duration
+				if uploaded > 0 && size > uploaded {
+					remaining = time.Duration(float64(size-uploaded) / float64(uploaded) * float64(time.Since(start)))
+				}
+				c.UI.Info(snapshotProgress("Uploaded", uploaded, size, remaining))
+			}
+		}
+	}()
+	err := restore()
+	close(done)
+	<-stopped
+	if err != nil {
+		return err
+	}
+	c.UI.Info(fmt.Sprintf("Uploaded %s in %s", humanize.IBytes(uint64(size)), time.Since(start).Round(time.Second)))
+
+	for {
+		status, err := statusClient.Sys().RaftSnapshotRestoreStatus()
+		if err != nil {
+			return fmt.Errorf("error reading the restore status: %w", err)
+		}
+		if status == nil {
+			// The server does not report the progress of restores
+			return nil
+		}
+
+		switch status.State {
+		case "completed":
+			c.UI.Info(fmt.Sprintf("Snapshot applied in %s", status.EndTime.Sub(status.PhaseStartTime).Round(time.Second)))
+			return nil
+		case "failed":
+			return fmt.Errorf("the restore failed: %s", status.Error)
+		case "applying":
+			c.UI.Info(snapshotProgress("Applied", status.BytesProcessed, status.BytesTotal, time.Duration(status.ETASeconds)*time.Second))
+		default:
+			c.UI.Info("Waiting for the restore to start")
+		}
+		time.Sleep(interval)
+	}
+}
+
+// restoreChain installs a full snapshot followed by delta snapshots. The files
+// are streamed to the server as a tar archive, in order.
+func (c *OperatorRaftSnapshotRestoreCommand) restoreChain(paths []string) int {
+	var files []*os.File
+	for _, path := range paths {
+		f, err := os.Open(strings.TrimSpace(path))
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error opening snapshot file: %s", err), err)
+			return 2
+		}
+		defer f.Close()
+		files = append(files, f)
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	var size int64
+	for _, f := range files {
+		info, err := f.Stat()
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error opening snapshot file: %s", err), err)
+			return 2
+		}
+		size += info.Size()
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(writeSnapshotChain(w, files))
+	}()
+	defer r.Close()
+
+	chain := &snapshotUploadReader{r: r}
+	err = c.upload(client, size, &chain.read, func() error {
+		return client.Sys().RaftSnapshotChainRestore(chain, c.flagForce)
+	})
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error installing the snapshot: %s", err), err)
+		return 2
+	}
+
+	return 0
+}
+
+// writeSnapshotChain writes the snapshot files to a tar stream, in order.
+func writeSnapshotChain(w io.Writer, files []*os.File) error {
+	chain := tar.NewWriter(w)
+	for _, f := range files {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if err := chain.WriteHeader(&tar.Header{
+			Name:    filepath.Base(f.Name()),
+			Mode:    0o600,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(chain, f, info.Size()); err != nil {
+			return err
+		}
+	}
+	return chain.Close()
+}
+
+// snapshotProgress describes how many of the total bytes of a snapshot have
+// been processed, and how long the rest should take if it is known.
+func snapshotProgress(verb string, done, total int64, remaining time.Duration) string {
+	progress := fmt.Sprintf("%s %s", verb, humanize.IBytes(uint64(done)))
+	if total > 0 {
+		progress += fmt.Sprintf(" of %s (%d%%)", humanize.IBytes(uint64(total)), done*100/total)
+	}
+	if remaining > 0 {
+		progress += fmt.Sprintf(", %s remaining", remaining.Round(time.Second))
+	}
+	return progress
+}
+
+// snapshotUploadReader counts the bytes read from a snapshot being uploaded.
+type snapshotUploadReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (u *snapshotUploadReader) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	atomic.AddInt64(&u.read, int64(n))
+	return n, err
+}
+
+// snapshotFileUploadReader counts the bytes read from a snapshot file being
+// uploaded. It can be rewound for retries and reports the size left to read,
+// so that the size of the upload is sent to the server.
+type snapshotFileUploadReader struct {
+	snapshotUploadReader
+	f    *os.File
+	size int64
+}
+
+func newSnapshotFileUploadReader(f *os.File, size int64) *snapshotFileUploadReader {
+	return &snapshotFileUploadReader{
+		snapshotUploadReader: snapshotUploadReader{r: f},
+		f:                    f,
+		size:                 size,
+	}
+}
+
+func (u *snapshotFileUploadReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := u.f.Seek(offset, whence)
+	if err == nil {
+		atomic.StoreInt64(&u.read, pos)
+	}
+	return pos, err
+}
+
+func (u *snapshotFileUploadReader) Len() int {
+	return int(u.size - atomic.LoadInt64(&u.read))
+}
@@ -0,0 +1,164 @@
+package command
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorRaftSnapshotRestoreCommand(tb testing.TB) (*cli.MockUi, *OperatorRaftSnapshotRestoreCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorRaftSnapshotRestoreCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		progressInterval: 10 * time.Millisecond,
+	}
+}
+
+// testSnapshotRestoreServer fakes a server reporting a restore as applying
+// for a few polls before completing it. It records the size of the uploaded
+// snapshot.
+func testSnapshotRestoreServer(tb testing.TB, restoreStatus bool) (*api.Client, func() int64) {
+	tb.Helper()
+
+	var l sync.Mutex
+	var uploaded int64
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/sys/leader":
+			w.Write([]byte(`{"ha_enabled": true, "is_self": true, "leader_address": "http://127.0.0.1:8200"}`))
+		case "/v1/sys/storage/raft/snapshot":
+			body, _ := ioutil.ReadAll(r.Body)
+			l.Lock()
+			uploaded = r.ContentLength
+			if int64(len(body)) != r.ContentLength {
+				uploaded = -1
+			}
+			l.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		case "/v1/sys/storage/raft/snapshot-restore-status":
+			if !restoreStatus {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			l.Lock()
+			polls++
+			done := polls > 2
+			l.Unlock()
+			if done {
+				w.Write([]byte(`{"state": "completed", "phase_start_time": "2022-03-15T17:04:00Z", "end_time": "2022-03-15T17:05:21Z", "bytes_total": 2048, "bytes_processed": 2048}`))
+				return
+			}
+			w.Write([]byte(`{"state": "applying", "bytes_total": 2048, "bytes_processed": 1024, "eta_seconds": 57}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	tb.Cleanup(server.Close)
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return client, func() int64 {
+		l.Lock()
+		defer l.Unlock()
+		return uploaded
+	}
+}
+
+func TestOperatorRaftSnapshotRestoreCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	snapPath := filepath.Join(t.TempDir(), "raft.snap")
+	if err := ioutil.WriteFile(snapPath, make([]byte, 2048), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("not_enough_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testOperatorRaftSnapshotRestoreCommand(t)
+
+		code := cmd.Run(nil)
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+		expected := "Incorrect arguments"
+		if combined := ui.OutputWriter.String() + ui.ErrorWriter.String(); !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		t.Parallel()
+
+		client, uploaded := testSnapshotRestoreServer(t, true)
+		ui, cmd := testOperatorRaftSnapshotRestoreCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{snapPath})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		if size := uploaded(); size != 2048 {
+			t.Errorf("expected the size of the snapshot to be sent, got %d", size)
+		}
+
+		combined := ui.OutputWriter.String()
+		for _, expected := range []string{
+			"Uploaded 2.0 KiB in",
+			"Applied 1.0 KiB of 2.0 KiB (50%), 57s remaining",
+			"Snapshot applied in 1m21s",
+		} {
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+	})
+
+	t.Run("no_restore_status", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := testSnapshotRestoreServer(t, false)
+		ui, cmd := testOperatorRaftSnapshotRestoreCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{snapPath})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if combined := ui.OutputWriter.String(); strings.Contains(combined, "Applied") {
+			t.Errorf("expected %q not to report the restore", combined)
+		}
+	})
+
+	t.Run("no_progress", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := testSnapshotRestoreServer(t, true)
+		ui, cmd := testOperatorRaftSnapshotRestoreCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-progress=false", snapPath})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if combined := ui.OutputWriter.String(); combined != "" {
+			t.Errorf("expected no output, got %q", combined)
+		}
+	})
+}
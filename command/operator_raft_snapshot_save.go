@@ -1,11 +1,14 @@
 package command
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/physical/raft"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -16,6 +19,7 @@ var (
 )
 
 type OperatorRaftSnapshotSaveCommand struct {
+	flagIncremental []string
 	*BaseCommand
 }
 
@@ -31,6 +35,17 @@ Usage: vault operator raft snapshot save <snapshot_file>
 
 	  $ vault operator raft snapshot save raft.snap
 
+  Save a delta snapshot holding only the changes made since a previous
+  snapshot. The -incremental flag is given the full snapshot the delta builds
+  on, followed by any deltas already taken after it, in order:
+
+	  $ vault operator raft snapshot save -incremental=raft.snap raft-1.delta
+	  $ vault operator raft snapshot save -incremental=raft.snap \
+	      -incremental=raft-1.delta raft-2.delta
+
+  Delta snapshots are restored along with the chain they build on with
+  "vault operator raft snapshot restore".
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -39,6 +54,18 @@ Usage: vault operator raft snapshot save <snapshot_file>
 func (c *OperatorRaftSnapshotSaveCommand) Flags() *FlagSets {
 	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
 
+	f := set.NewFlagSet("Command Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "incremental",
+		Target:     &c.flagIncremental,
+		Completion: complete.PredictFiles("*"),
+		Usage: "Save a delta snapshot holding the changes made since the given " +
+			"snapshot chain instead of a full snapshot. The first value is the " +
+			"full snapshot and the following ones the deltas taken after it, in " +
+			"order. This can be specified multiple times.",
+	})
+
 	return set
 }
 
@@ -87,21 +114,53 @@ func (c *OperatorRaftSnapshotSaveCommand) Run(args []string) int {
 		return 2
 	}
 
-	err = client.Sys().RaftSnapshot(w)
+	if len(c.flagIncremental) > 0 {
+		err = c.saveDelta(client, w)
+	} else {
+		err = client.Sys().RaftSnapshot(w)
+	}
 	if err != nil {
 		w.Close()
-		c.UI.Error(fmt.Sprintf("Error taking the snapshot: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error taking the snapshot: %s", err), err)
 		return 2
 	}
 
 	err = w.Close()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error taking the snapshot: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error taking the snapshot: %s", err), err)
 		return 2
 	}
 	return 0
 }
 
+// saveDelta takes a delta snapshot against the chain given with -incremental.
+// Only the manifest of the chain, the sums of its values, is sent to the
+// server.
+func (c *OperatorRaftSnapshotSaveCommand) saveDelta(client *api.Client, w io.Writer) error {
+	var files []io.Reader
+	for _, path := range c.flagIncremental {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		files = append(files, bufio.NewReader(f))
+	}
+
+	manifest, parent, err := raft.NewDeltaManifest(files[0], files[1:]...)
+	if err != nil {
+		return err
+	}
+
+	r, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(manifest.Encode(pw))
+	}()
+	defer r.Close()
+
+	return client.Sys().RaftSnapshotDelta(parent, r, w)
+}
+
 type lazyOpenWriter struct {
 	openFunc func() (io.WriteCloser, error)
 	writer   io.WriteCloser
@@ -287,7 +287,7 @@ func (c *OperatorRekeyCommand) init(client *api.Client) int {
 		RequireVerification: c.flagVerify,
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error initializing rekey: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error initializing rekey: %s", err), err)
 		return 2
 	}
 
@@ -345,7 +345,7 @@ func (c *OperatorRekeyCommand) cancel(client *api.Client) int {
 
 	// Make the request
 	if err := fn(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error canceling rekey: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error canceling rekey: %s", err), err)
 		return 2
 	}
 
@@ -397,7 +397,7 @@ func (c *OperatorRekeyCommand) provide(client *api.Client, key string) int {
 
 	status, err := statusFn()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error getting rekey status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error getting rekey status: %s", err), err)
 		return 2
 	}
 
@@ -439,7 +439,7 @@ func (c *OperatorRekeyCommand) provide(client *api.Client, key string) int {
 
 		var buf bytes.Buffer
 		if _, err := io.Copy(&buf, stdin); err != nil {
-			c.UI.Error(fmt.Sprintf("Failed to read from stdin: %s", err))
+			outputError(c.UI, fmt.Sprintf("Failed to read from stdin: %s", err), err)
 			return 1
 		}
 
@@ -482,7 +482,7 @@ func (c *OperatorRekeyCommand) provide(client *api.Client, key string) int {
 	// Provide the key, this may potentially complete the update
 	resp, err := updateFn(key, nonce)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error posting unseal key: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error posting unseal key: %s", err), err)
 		return 2
 	}
 
@@ -544,7 +544,7 @@ func (c *OperatorRekeyCommand) status(client *api.Client) int {
 	// Make the request
 	status, err := fn()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading rekey status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error reading rekey status: %s", err), err)
 		return 2
 	}
 
@@ -568,7 +568,7 @@ func (c *OperatorRekeyCommand) backupRetrieve(client *api.Client) int {
 	// Make the request
 	storedKeys, err := fn()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error retrieving rekey stored keys: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error retrieving rekey stored keys: %s", err), err)
 		return 2
 	}
 
@@ -595,7 +595,7 @@ func (c *OperatorRekeyCommand) backupDelete(client *api.Client) int {
 
 	// Make the request
 	if err := fn(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error deleting rekey stored keys: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error deleting rekey stored keys: %s", err), err)
 		return 2
 	}
 
@@ -77,7 +77,7 @@ func (c *OperatorSealCommand) Run(args []string) int {
 	}
 
 	if err := client.Sys().Seal(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error sealing: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error sealing: %s", err), err)
 		return 2
 	}
 
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*OperatorSealMigrationCommand)(nil)
+
+type OperatorSealMigrationCommand struct {
+	*BaseCommand
+}
+
+func (c *OperatorSealMigrationCommand) Synopsis() string {
+	return "Interact with the rewrap of storage entries after a seal migration"
+}
+
+func (c *OperatorSealMigrationCommand) Help() string {
+	helpText := `
+Usage: vault operator seal-migration <subcommand> [options] [args]
+
+  This command groups subcommands for operators following the rewrap of the
+  storage entries that happens in the background after a seal migration.
+
+  Report how many storage entries have been rewrapped:
+
+      $ vault operator seal-migration status
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSealMigrationCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorSealMigrationStatusCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorSealMigrationStatusCommand)(nil)
+)
+
+type OperatorSealMigrationStatusCommand struct {
+	*BaseCommand
+}
+
+func (c *OperatorSealMigrationStatusCommand) Synopsis() string {
+	return "Displays the progress of the rewrap of storage entries after a seal migration"
+}
+
+func (c *OperatorSealMigrationStatusCommand) Help() string {
+	helpText := `
+Usage: vault operator seal-migration status [options]
+
+  Displays the progress of the rewrap of the storage entries that the active
+  node runs after a seal migration: the number of entries processed and
+  rewrapped, the rate at which they are processed and the estimated time
+  remaining.
+
+      $ vault operator seal-migration status
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSealMigrationStatusCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+}
+
+func (c *OperatorSealMigrationStatusCommand) AutocompleteArgs() complete.Predictor {
+	return nil
+}
+
+func (c *OperatorSealMigrationStatusCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorSealMigrationStatusCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	status, err := client.Sys().SealMigrationStatus()
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading seal migration status: %s", err), err)
+		return 2
+	}
+	if status == nil {
+		return 0
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		c.UI.Output(printSealMigrationStatus(status))
+		return 0
+	default:
+		return OutputData(c.UI, status)
+	}
+}
+
+func printSealMigrationStatus(status *api.SealMigrationStatusResponse) string {
+	out := []string{
+		fmt.Sprintf("State | %s", status.State),
+	}
+	if !status.StartTime.IsZero() {
+		out = append(out, fmt.Sprintf("Start Time | %s", status.StartTime.UTC().Format(time.RFC822)))
+	}
+	if !status.EndTime.IsZero() {
+		out = append(out, fmt.Sprintf("End Time | %s", status.EndTime.UTC().Format(time.RFC822)))
+	}
+	out = append(out,
+		fmt.Sprintf("Entries Total | %d", status.Entries.Total),
+		fmt.Sprintf("Entries Processed | %d", status.Entries.Processed),
+		fmt.Sprintf("Entries Rewrapped | %d", status.Entries.Rewrapped),
+		fmt.Sprintf("Entries Failed | %d", status.Entries.Failed),
+		fmt.Sprintf("Entries Per Second | %.1f", status.EntriesPerSecond),
+		fmt.Sprintf("Percent Complete | %.1f%%", status.PercentComplete),
+	)
+	if status.ETASeconds > 0 {
+		out = append(out, fmt.Sprintf("Estimated Time Remaining | %s", time.Duration(status.ETASeconds)*time.Second))
+	}
+	if status.Error != "" {
+		out = append(out, fmt.Sprintf("Error | %s", status.Error))
+	}
+	return columnOutput(out, nil)
+}
@@ -73,7 +73,7 @@ func (c *OperatorStepDownCommand) Run(args []string) int {
 	}
 
 	if err := client.Sys().StepDown(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error stepping down: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error stepping down: %s", err), err)
 		return 2
 	}
 
@@ -119,7 +119,7 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 	if c.flagReset {
 		status, err := client.Sys().ResetUnsealProcess()
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error resetting unseal process: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error resetting unseal process: %s", err), err)
 			return 2
 		}
 		return OutputSealStatus(c.UI, client, status)
@@ -154,7 +154,7 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 		Migrate: c.flagMigrate,
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error unsealing: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error unsealing: %s", err), err)
 		return 2
 	}
 
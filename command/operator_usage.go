@@ -103,7 +103,7 @@ func (c *OperatorUsageCommand) Run(args []string) int {
 
 	resp, err := client.Logical().ReadWithData("sys/internal/counters/activity", data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error retrieving client counts: %v", err))
+		outputError(c.UI, fmt.Sprintf("Error retrieving client counts: %v", err), err)
 		return 2
 	}
 
@@ -146,7 +146,7 @@ func (c *OperatorUsageCommand) Run(args []string) int {
 // queries can be answered; if there's an error, just fall back to
 // reporting that the response is empty.
 func (c *OperatorUsageCommand) noReportAvailable(client *api.Client) bool {
-	if c.flagOutputCurlString {
+	if c.flagOutputCurlString || c.flagOutputRequest != "" {
 		// Don't mess up the original query string
 		return false
 	}
@@ -259,7 +259,7 @@ func (c *OperatorUsageCommand) namespacesOutput(data map[string]interface{}) []s
 	for _, rawVal := range byNs {
 		val, err := c.parseNamespaceCount(rawVal)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("malformed namespace in response: %v", err))
+			outputError(c.UI, fmt.Sprintf("malformed namespace in response: %v", err), err)
 			continue
 		}
 
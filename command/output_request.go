@@ -0,0 +1,325 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+// outputRequestLanguages are the languages supported by -output-request.
+var outputRequestLanguages = []string{"go", "powershell", "python"}
+
+// outputRequest is the request a command would have made, as captured with
+// -output-request.
+type outputRequest struct {
+	*api.OutputStringError
+
+	address   string
+	path      string
+	query     url.Values
+	namespace string
+	headers   [][2]string
+	body      []byte
+}
+
+// outputRequestString returns the code making the request of e in language,
+// using the Vault client library of that language when there is one. The
+// token is read from the VAULT_TOKEN environment variable.
+func outputRequestString(language string, e *api.OutputStringError) (string, error) {
+	body, err := e.Request.BodyBytes()
+	if err != nil {
+		return "", err
+	}
+
+	r := &outputRequest{
+		OutputStringError: e,
+		address:           (&url.URL{Scheme: e.Request.URL.Scheme, Host: e.Request.URL.Host}).String(),
+		path:              e.Request.URL.Path,
+		query:             e.Request.URL.Query(),
+		body:              body,
+	}
+
+	keys := make([]string, 0, len(e.Request.Header))
+	for k := range e.Request.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch http.CanonicalHeaderKey(k) {
+		case consts.AuthHeaderName:
+			// The token is read from the environment
+			continue
+		case consts.NamespaceHeaderName:
+			r.namespace = e.Request.Header.Get(k)
+			continue
+		}
+		for _, v := range e.Request.Header[k] {
+			r.headers = append(r.headers, [2]string{k, v})
+		}
+	}
+
+	switch language {
+	case "go":
+		return r.goString(), nil
+	case "powershell":
+		return r.powershellString(), nil
+	case "python":
+		return r.pythonString()
+	default:
+		return "", fmt.Errorf("unsupported language %q, must be one of %s", language, strings.Join(outputRequestLanguages, ", "))
+	}
+}
+
+// pathWithQuery returns the path of the request with its query string.
+func (r *outputRequest) pathWithQuery() string {
+	if len(r.query) == 0 {
+		return r.path
+	}
+	return r.path + "?" + r.query.Encode()
+}
+
+// goString returns a program making the request with the Go API client.
+func (r *outputRequest) goString() string {
+	var b strings.Builder
+	b.WriteString(`package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func main() {
+	config := vault.DefaultConfig()
+`)
+	fmt.Fprintf(&b, "\tconfig.Address = %s\n", strconv.Quote(r.address))
+
+	if r.TLSSkipVerify || r.ClientCACert != "" || r.ClientCAPath != "" || r.ClientCert != "" || r.ClientKey != "" {
+		b.WriteString("\tif err := config.ConfigureTLS(&vault.TLSConfig{\n")
+		for _, field := range [][2]string{
+			{"CACert", r.ClientCACert},
+			{"CAPath", r.ClientCAPath},
+			{"ClientCert", r.ClientCert},
+			{"ClientKey", r.ClientKey},
+		} {
+			if field[1] != "" {
+				fmt.Fprintf(&b, "\t\t%s: %s,\n", field[0], strconv.Quote(field[1]))
+			}
+		}
+		if r.TLSSkipVerify {
+			b.WriteString("\t\tInsecure: true,\n")
+		}
+		b.WriteString("\t}); err != nil {\n\t\tlog.Fatal(err)\n\t}\n")
+	}
+
+	b.WriteString(`
+	// The token is read from the VAULT_TOKEN environment variable
+	client, err := vault.NewClient(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+`)
+	if r.namespace != "" {
+		fmt.Fprintf(&b, "\tclient.SetNamespace(%s)\n", strconv.Quote(r.namespace))
+	}
+	for _, h := range r.headers {
+		if http.CanonicalHeaderKey(h[0]) == consts.RequestHeaderName {
+			// Set by the client
+			continue
+		}
+		fmt.Fprintf(&b, "\tclient.AddHeader(%s, %s)\n", strconv.Quote(h[0]), strconv.Quote(h[1]))
+	}
+
+	fmt.Fprintf(&b, "\n\treq := client.NewRequest(%s, %s)\n", strconv.Quote(r.Method), strconv.Quote(r.path))
+	keys := make([]string, 0, len(r.query))
+	for k := range r.query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range r.query[k] {
+			fmt.Fprintf(&b, "\treq.Params.Add(%s, %s)\n", strconv.Quote(k), strconv.Quote(v))
+		}
+	}
+	if len(r.body) > 0 {
+		body := strconv.Quote(string(r.body))
+		if !bytes.ContainsAny(r.body, "`\r") && strconv.CanBackquote(string(r.body)) {
+			body = "`" + string(r.body) + "`"
+		}
+		fmt.Fprintf(&b, "\treq.BodyBytes = []byte(%s)\n", body)
+	}
+
+	b.WriteString(`
+	resp, err := client.RawRequestWithContext(context.Background(), req)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(body))
+}`)
+
+	// Align the fields of the TLS configuration
+	if src, err := format.Source([]byte(b.String())); err == nil {
+		return string(src)
+	}
+	return b.String()
+}
+
+// powershellString returns a script making the request with
+// Invoke-RestMethod, as there is no Vault client module for PowerShell.
+func (r *outputRequest) powershellString() string {
+	method := r.Method
+	query := r.query
+	if method == "LIST" {
+		// Invoke-RestMethod only supports the standard methods
+		method = "GET"
+		query = url.Values{}
+		for k, v := range r.query {
+			query[k] = v
+		}
+		query.Set("list", "true")
+	}
+	uri := r.address + r.path
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+
+	var b strings.Builder
+	if r.ClientCACert != "" || r.ClientCAPath != "" {
+		b.WriteString("# The CA certificate of the server must be trusted by the system\n")
+	}
+	if r.ClientCert != "" {
+		fmt.Fprintf(&b, "# Pass the client certificate %s with -Certificate\n", r.ClientCert)
+	}
+
+	b.WriteString("$headers = @{\n")
+	fmt.Fprintf(&b, "    %s = $env:VAULT_TOKEN\n", powershellQuote(consts.AuthHeaderName))
+	if r.namespace != "" {
+		fmt.Fprintf(&b, "    %s = %s\n", powershellQuote(consts.NamespaceHeaderName), powershellQuote(r.namespace))
+	}
+	for _, h := range r.headers {
+		fmt.Fprintf(&b, "    %s = %s\n", powershellQuote(h[0]), powershellQuote(h[1]))
+	}
+	b.WriteString("}\n")
+
+	if len(r.body) > 0 {
+		fmt.Fprintf(&b, "$body = %s\n", powershellQuote(string(r.body)))
+	}
+
+	fmt.Fprintf(&b, "\nInvoke-RestMethod -Method %s -Uri %s -Headers $headers", method, powershellQuote(uri))
+	if len(r.body) > 0 {
+		b.WriteString(` -ContentType "application/json" -Body $body`)
+	}
+	if r.TLSSkipVerify {
+		b.WriteString(" -SkipCertificateCheck")
+	}
+	return b.String()
+}
+
+// powershellQuote quotes s as a verbatim PowerShell string.
+func powershellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// pythonString returns a script making the request with the hvac client.
+func (r *outputRequest) pythonString() (string, error) {
+	var b strings.Builder
+	b.WriteString("import os\n\nimport hvac\n\nclient = hvac.Client(\n")
+	fmt.Fprintf(&b, "    url=%s,\n", strconv.Quote(r.address))
+	b.WriteString("    token=os.environ[\"VAULT_TOKEN\"],\n")
+	switch {
+	case r.TLSSkipVerify:
+		b.WriteString("    verify=False,\n")
+	case r.ClientCACert != "":
+		fmt.Fprintf(&b, "    verify=%s,\n", strconv.Quote(r.ClientCACert))
+	case r.ClientCAPath != "":
+		fmt.Fprintf(&b, "    verify=%s,\n", strconv.Quote(r.ClientCAPath))
+	}
+	if r.ClientCert != "" {
+		fmt.Fprintf(&b, "    cert=(%s, %s),\n", strconv.Quote(r.ClientCert), strconv.Quote(r.ClientKey))
+	}
+	if r.namespace != "" {
+		fmt.Fprintf(&b, "    namespace=%s,\n", strconv.Quote(r.namespace))
+	}
+	b.WriteString(")\n\nresponse = client.adapter.request(\n")
+	fmt.Fprintf(&b, "    %s,\n    %s,\n", strconv.Quote(r.Method), strconv.Quote(r.pathWithQuery()))
+
+	var headers []string
+	for _, h := range r.headers {
+		if http.CanonicalHeaderKey(h[0]) == consts.RequestHeaderName {
+			// Set by the client
+			continue
+		}
+		headers = append(headers, fmt.Sprintf("%s: %s", strconv.Quote(h[0]), strconv.Quote(h[1])))
+	}
+	if len(headers) > 0 {
+		fmt.Fprintf(&b, "    headers={%s},\n", strings.Join(headers, ", "))
+	}
+
+	if len(r.body) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(r.body))
+		dec.UseNumber()
+		var body interface{}
+		if err := dec.Decode(&body); err != nil {
+			return "", fmt.Errorf("error decoding the body of the request: %w", err)
+		}
+		fmt.Fprintf(&b, "    json=%s,\n", pythonValue(body))
+	}
+	b.WriteString(")\nprint(response)")
+	return b.String(), nil
+}
+
+// pythonValue returns the Python literal of a decoded JSON value.
+func pythonValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case json.Number:
+		return v.String()
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, pythonValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]string, 0, len(v))
+		for _, k := range keys {
+			items = append(items, fmt.Sprintf("%s: %s", strconv.Quote(k), pythonValue(v[k])))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return strconv.Quote(fmt.Sprint(v))
+	}
+}
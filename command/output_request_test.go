@@ -0,0 +1,135 @@
+package command
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+	"github.com/hashicorp/vault/api"
+)
+
+func testOutputStringError(tb testing.TB, method, url, body string) *api.OutputStringError {
+	tb.Helper()
+
+	var reqBody interface{}
+	if body != "" {
+		reqBody = []byte(body)
+	}
+	req, err := retryablehttp.NewRequest(method, url, reqBody)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	req.Header.Set("X-Vault-Token", "s.secret")
+	req.Header.Set("X-Vault-Request", "true")
+	req.Header.Set("X-Vault-Namespace", "ns1/")
+	req.Header.Set("X-Vault-Wrap-TTL", "5m")
+
+	return &api.OutputStringError{
+		Request:       req,
+		TLSSkipVerify: true,
+		ClientCACert:  "/etc/vault/ca.pem",
+	}
+}
+
+func TestOutputRequestString(t *testing.T) {
+	t.Parallel()
+
+	e := testOutputStringError(t, "POST", "https://127.0.0.1:8200/v1/secret/data/foo?version=2",
+		`{"data":{"enabled":true,"password":"it's","ttl":30,"tags":["a",null]}}`)
+
+	cases := []struct {
+		language string
+		exp      []string
+	}{
+		{
+			"go",
+			[]string{
+				`config.Address = "https://127.0.0.1:8200"`,
+				`CACert:   "/etc/vault/ca.pem",`,
+				`Insecure: true,`,
+				`client.SetNamespace("ns1/")`,
+				`client.AddHeader("X-Vault-Wrap-Ttl", "5m")`,
+				`req := client.NewRequest("POST", "/v1/secret/data/foo")`,
+				`req.Params.Add("version", "2")`,
+				"req.BodyBytes = []byte(`{\"data\":{\"enabled\":true,\"password\":\"it's\",\"ttl\":30,\"tags\":[\"a\",null]}}`)",
+			},
+		},
+		{
+			"powershell",
+			[]string{
+				`'X-Vault-Token' = $env:VAULT_TOKEN`,
+				`'X-Vault-Namespace' = 'ns1/'`,
+				`'X-Vault-Request' = 'true'`,
+				`$body = '{"data":{"enabled":true,"password":"it''s","ttl":30,"tags":["a",null]}}'`,
+				`Invoke-RestMethod -Method POST -Uri 'https://127.0.0.1:8200/v1/secret/data/foo?version=2' -Headers $headers -ContentType "application/json" -Body $body -SkipCertificateCheck`,
+			},
+		},
+		{
+			"python",
+			[]string{
+				`url="https://127.0.0.1:8200",`,
+				`token=os.environ["VAULT_TOKEN"],`,
+				`verify=False,`,
+				`namespace="ns1/",`,
+				`"/v1/secret/data/foo?version=2",`,
+				`headers={"X-Vault-Wrap-Ttl": "5m"},`,
+				`json={"data": {"enabled": True, "password": "it's", "tags": ["a", None], "ttl": 30}},`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.language, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := outputRequestString(tc.language, e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, exp := range tc.exp {
+				if !strings.Contains(out, exp) {
+					t.Errorf("expected %q to contain %q", out, exp)
+				}
+			}
+			if strings.Contains(out, "s.secret") {
+				t.Errorf("expected the token to be left out of %q", out)
+			}
+		})
+	}
+
+	t.Run("go_valid", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := outputRequestString("go", e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), "main.go", out, 0); err != nil {
+			t.Fatalf("expected a valid Go program: %v\n%s", err, out)
+		}
+	})
+
+	t.Run("powershell_list", func(t *testing.T) {
+		t.Parallel()
+
+		out, err := outputRequestString("powershell", testOutputStringError(t, "LIST", "https://127.0.0.1:8200/v1/secret/metadata/", ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := `-Method GET -Uri 'https://127.0.0.1:8200/v1/secret/metadata/?list=true'`; !strings.Contains(out, exp) {
+			t.Errorf("expected %q to contain %q", out, exp)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := outputRequestString("ruby", e); err == nil || !strings.Contains(err.Error(), "unsupported language") {
+			t.Fatalf("expected an error, got %v", err)
+		}
+	})
+}
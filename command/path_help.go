@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/vault/api"
+	"github.com/kr/text"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -23,6 +25,9 @@ points of the secret engines are known.
 
 type PathHelpCommand struct {
 	*BaseCommand
+
+	flagOpenAPI bool
+	flagExample bool
 }
 
 func (c *PathHelpCommand) Synopsis() string {
@@ -49,13 +54,43 @@ Usage: vault path-help [options] PATH
 
   If -format is specified as JSON, the output will be in OpenAPI format.
 
+  List the endpoints of the thing mounted at database/ with their operations,
+  from its OpenAPI document:
+
+      $ vault path-help -openapi database/
+
+  Show the parameters of an endpoint, and example commands calling it:
+
+      $ vault path-help -openapi -example database/roles/my-role
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *PathHelpCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "openapi",
+		Target:  &c.flagOpenAPI,
+		Default: false,
+		Usage: "Render the OpenAPI document of the path rather than its help " +
+			"text. For a mount, its endpoints are listed with their " +
+			"operations. For an endpoint, its parameters are listed.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "example",
+		Target:  &c.flagExample,
+		Default: false,
+		Usage: "Print example commands calling each operation of the " +
+			"endpoint. This implies -openapi.",
+	})
+
+	return set
 }
 
 func (c *PathHelpCommand) AutocompleteArgs() complete.Predictor {
@@ -97,16 +132,20 @@ func (c *PathHelpCommand) Run(args []string) int {
 		if strings.Contains(err.Error(), "Vault is sealed") {
 			c.UI.Error(pathHelpVaultSealedMessage)
 		} else {
-			c.UI.Error(fmt.Sprintf("Error retrieving help: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error retrieving help: %s", err), err)
 		}
 		return 2
 	}
 
+	if c.flagOpenAPI || c.flagExample {
+		return c.outputOpenAPI(client, path, help.OpenAPI)
+	}
+
 	switch c.flagFormat {
 	case "json":
 		b, err := json.Marshal(help.OpenAPI)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error marshaling OpenAPI: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error marshaling OpenAPI: %s", err), err)
 			return 2
 		}
 		c.UI.Output(string(b))
@@ -116,3 +155,87 @@ func (c *PathHelpCommand) Run(args []string) int {
 
 	return 0
 }
+
+// outputOpenAPI renders the OpenAPI document returned by the help request for
+// path.
+func (c *PathHelpCommand) outputOpenAPI(client *api.Client, path string, doc map[string]interface{}) int {
+	// The paths of the document are relative to the mount. If the mount can't
+	// be looked up, they are shown as they are.
+	mountPath, _, err := kvPreflightVersionRequest(client, path)
+	if err != nil {
+		mountPath = ""
+	}
+
+	endpoints, err := openAPIEndpoints(doc, mountPath)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	if len(endpoints) == 0 {
+		c.UI.Error(fmt.Sprintf("No OpenAPI document available for %s", path))
+		return 2
+	}
+
+	isMount := mountPath != "" && ensureNoTrailingSlash(path) == ensureNoTrailingSlash(mountPath)
+	if isMount && c.flagExample {
+		c.UI.Error("-example requires the path of an endpoint rather than of a mount")
+		return 1
+	}
+
+	if Format(c.UI) != "table" {
+		if !isMount && c.flagExample {
+			data := make([]map[string]interface{}, 0, len(endpoints))
+			for _, e := range endpoints {
+				data = append(data, map[string]interface{}{
+					"endpoint": e,
+					"examples": openAPIExamples(e),
+				})
+			}
+			return OutputData(c.UI, data)
+		}
+		return OutputData(c.UI, endpoints)
+	}
+
+	if isMount {
+		c.UI.Output(tableOutput(openAPIEndpointsTable(endpoints), nil))
+		c.UI.Output("")
+		c.UI.Output(wrapAtLength("Run \"vault path-help -openapi\" with the path " +
+			"of an endpoint to list its parameters, replacing the parameters in " +
+			"braces with values."))
+		return 0
+	}
+
+	for i, e := range endpoints {
+		if i > 0 {
+			c.UI.Output("")
+		}
+
+		info := []string{
+			"Path | " + e.Path,
+			"Operations | " + strings.Join(e.Operations, ", "),
+			"Summary | " + openAPITableText(e.Summary),
+		}
+		if e.Sudo {
+			info = append(info, "Sudo | required")
+		}
+		if e.Unauthenticated {
+			info = append(info, "Unauthenticated | true")
+		}
+		c.UI.Output(columnOutput(info, nil))
+
+		if len(e.Parameters) > 0 {
+			c.UI.Output("")
+			c.UI.Output(tableOutput(openAPIParametersTable(e), nil))
+		}
+
+		if c.flagExample {
+			c.UI.Output("")
+			c.UI.Output("Examples:")
+			for _, example := range openAPIExamples(e) {
+				c.UI.Output("")
+				c.UI.Output(text.Indent("$ "+example, "    "))
+			}
+		}
+	}
+	return 0
+}
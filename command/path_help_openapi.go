@@ -0,0 +1,238 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+)
+
+// openAPIDescriptionLength is the length at which descriptions are truncated in
+// tables.
+const openAPIDescriptionLength = 80
+
+// openAPIEndpoint is a path of an OpenAPI document, as rendered by
+// "vault path-help -openapi".
+type openAPIEndpoint struct {
+	Path            string              `json:"path"`
+	Operations      []string            `json:"operations"`
+	Summary         string              `json:"summary,omitempty"`
+	Sudo            bool                `json:"sudo,omitempty"`
+	Unauthenticated bool                `json:"unauthenticated,omitempty"`
+	Parameters      []*openAPIParameter `json:"parameters,omitempty"`
+}
+
+// openAPIParameter is a parameter of an openAPIEndpoint. In is where the
+// parameter is given: "path", "query" or "body".
+type openAPIParameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Type        string      `json:"type"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// openAPIEndpoints returns the endpoints of the OpenAPI document returned by
+// a help request, sorted by path. The paths of the document are relative to
+// the mount, and mountPath is prepended to them.
+func openAPIEndpoints(raw map[string]interface{}, mountPath string) ([]*openAPIEndpoint, error) {
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var doc framework.OASDocument
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("error decoding OpenAPI document: %w", err)
+	}
+
+	endpoints := make([]*openAPIEndpoint, 0, len(doc.Paths))
+	for path, item := range doc.Paths {
+		if item == nil {
+			continue
+		}
+		e := &openAPIEndpoint{
+			Path:            ensureNoTrailingSlash(mountPath) + "/" + ensureNoLeadingSlash(path),
+			Summary:         item.Description,
+			Sudo:            item.Sudo,
+			Unauthenticated: item.Unauthenticated,
+		}
+		if mountPath == "" {
+			e.Path = ensureNoLeadingSlash(path)
+		}
+		for _, p := range item.Parameters {
+			e.Parameters = append(e.Parameters, newOpenAPIParameter(p.Name, p.In, p.Schema, p.Required, p.Description))
+		}
+
+		if op := item.Get; op != nil {
+			// Lists are GET requests with a "list" query parameter, which is
+			// required if the path can't be read
+			read, list := true, false
+			for _, p := range op.Parameters {
+				if p.Name == "list" && p.In == "query" {
+					read, list = !p.Required, true
+					continue
+				}
+				e.Parameters = append(e.Parameters, newOpenAPIParameter(p.Name, p.In, p.Schema, p.Required, p.Description))
+			}
+			if read {
+				e.Operations = append(e.Operations, "read")
+			}
+			if list {
+				e.Operations = append(e.Operations, "list")
+			}
+			e.setSummary(op)
+		}
+		if op := item.Post; op != nil {
+			e.Operations = append(e.Operations, "write")
+			if op.RequestBody != nil {
+				if media := op.RequestBody.Content["application/json"]; media != nil && media.Schema != nil {
+					required := make(map[string]bool, len(media.Schema.Required))
+					for _, name := range media.Schema.Required {
+						required[name] = true
+					}
+					for name, schema := range media.Schema.Properties {
+						e.Parameters = append(e.Parameters, newOpenAPIParameter(name, "body", schema, required[name], schema.Description))
+					}
+				}
+			}
+			e.setSummary(op)
+		}
+		if op := item.Delete; op != nil {
+			e.Operations = append(e.Operations, "delete")
+			e.setSummary(op)
+		}
+
+		// Path parameters first, then required parameters, then by name
+		sort.SliceStable(e.Parameters, func(i, j int) bool {
+			pi, pj := e.Parameters[i], e.Parameters[j]
+			if (pi.In == "path") != (pj.In == "path") {
+				return pi.In == "path"
+			}
+			if pi.Required != pj.Required {
+				return pi.Required
+			}
+			return pi.Name < pj.Name
+		})
+		endpoints = append(endpoints, e)
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].Path < endpoints[j].Path
+	})
+	return endpoints, nil
+}
+
+func newOpenAPIParameter(name, in string, schema *framework.OASSchema, required bool, description string) *openAPIParameter {
+	p := &openAPIParameter{
+		Name:        name,
+		In:          in,
+		Required:    required || in == "path",
+		Description: strings.TrimSpace(description),
+	}
+	if schema != nil {
+		p.Type = schema.Type
+		if schema.Type == "array" && schema.Items != nil && schema.Items.Type != "" {
+			p.Type = "array of " + schema.Items.Type
+		}
+		p.Default = schema.Default
+	}
+	return p
+}
+
+// setSummary uses the summary of op if the path has no description.
+func (e *openAPIEndpoint) setSummary(op *framework.OASOperation) {
+	if e.Summary == "" {
+		e.Summary = op.Summary
+	}
+}
+
+// openAPIEndpointsTable returns the rows of a table listing the endpoints.
+func openAPIEndpointsTable(endpoints []*openAPIEndpoint) []string {
+	out := []string{"Path | Operations | Summary"}
+	for _, e := range endpoints {
+		out = append(out, fmt.Sprintf("%s | %s | %s", e.Path, strings.Join(e.Operations, ", "), openAPITableText(e.Summary)))
+	}
+	return out
+}
+
+// openAPIParametersTable returns the rows of a table listing the parameters
+// of an endpoint.
+func openAPIParametersTable(e *openAPIEndpoint) []string {
+	out := []string{"Parameter | In | Type | Required | Description"}
+	for _, p := range e.Parameters {
+		required := "no"
+		if p.Required {
+			required = "yes"
+		}
+		out = append(out, fmt.Sprintf("%s | %s | %s | %s | %s", p.Name, p.In, p.Type, required, openAPITableText(p.Description)))
+	}
+	return out
+}
+
+// openAPITableText returns the first line of s, truncated to fit in a table
+// column.
+func openAPITableText(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	s = strings.ReplaceAll(s, "|", "/")
+	if len(s) > openAPIDescriptionLength {
+		s = strings.TrimSpace(s[:openAPIDescriptionLength-3]) + "..."
+	}
+	if s == "" {
+		return "n/a"
+	}
+	return s
+}
+
+// openAPIExamples returns example commands calling each operation of the
+// endpoint. Path parameters are replaced with placeholders, and body
+// parameters are set to their default value or to a placeholder of their type.
+func openAPIExamples(e *openAPIEndpoint) []string {
+	path := e.Path
+	var body []string
+	for _, p := range e.Parameters {
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", "<"+p.Name+">")
+		case "body":
+			value := fmt.Sprintf("<%s>", p.Type)
+			if p.Default != nil && p.Default != "" {
+				value = fmt.Sprintf("%v", p.Default)
+			}
+			body = append(body, openAPIExampleArg(p.Name+"="+value))
+		}
+	}
+	path = openAPIExampleArg(path)
+
+	examples := make([]string, 0, len(e.Operations))
+	for _, op := range e.Operations {
+		switch op {
+		case "write":
+			if len(body) == 0 {
+				examples = append(examples, fmt.Sprintf("vault write -force %s", path))
+				continue
+			}
+			examples = append(examples, fmt.Sprintf("vault write %s \\\n    %s", path, strings.Join(body, " \\\n    ")))
+		default:
+			examples = append(examples, fmt.Sprintf("vault %s %s", op, path))
+		}
+	}
+	return examples
+}
+
+// openAPIExampleArg quotes s for a POSIX shell if it contains characters
+// which the shell would interpret, such as the brackets of placeholders.
+func openAPIExampleArg(s string) string {
+	safe := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,@%+", r)
+	}
+	if s != "" && strings.IndexFunc(s, func(r rune) bool { return !safe(r) }) < 0 {
+		return s
+	}
+	return shellQuote(s)
+}
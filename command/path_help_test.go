@@ -1,6 +1,7 @@
 package command
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -57,6 +58,24 @@ func TestPathHelpCommand_Run(t *testing.T) {
 			"currently mounted backends",
 			0,
 		},
+		{
+			"openapi_mount",
+			[]string{"-openapi", "sys/"},
+			"sys/mounts/{path}",
+			0,
+		},
+		{
+			"openapi_example",
+			[]string{"-example", "sys/mounts/foo"},
+			"vault read 'sys/mounts/<path>'",
+			0,
+		},
+		{
+			"openapi_example_mount",
+			[]string{"-example", "sys/"},
+			"requires the path of an endpoint",
+			1,
+		},
 	}
 
 	for _, tc := range cases {
@@ -83,6 +102,81 @@ func TestPathHelpCommand_Run(t *testing.T) {
 		})
 	}
 
+	t.Run("openapi_endpoints", func(t *testing.T) {
+		t.Parallel()
+
+		doc := map[string]interface{}{
+			"openapi": "3.0.2",
+			"paths": map[string]interface{}{
+				"/roles/{name}": map[string]interface{}{
+					"description": "Manage roles.",
+					"parameters": []interface{}{
+						map[string]interface{}{"name": "name", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}},
+					},
+					"get": map[string]interface{}{"summary": "Read a role."},
+					"post": map[string]interface{}{
+						"requestBody": map[string]interface{}{
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type":     "object",
+										"required": []interface{}{"db_name"},
+										"properties": map[string]interface{}{
+											"ttl":     map[string]interface{}{"type": "integer", "default": 3600},
+											"db_name": map[string]interface{}{"type": "string", "description": "Name of the database."},
+										},
+									},
+								},
+							},
+						},
+					},
+					"delete": map[string]interface{}{},
+				},
+				"/roles": map[string]interface{}{
+					"get": map[string]interface{}{
+						"summary": "List roles.",
+						"parameters": []interface{}{
+							map[string]interface{}{"name": "list", "in": "query", "required": true},
+						},
+					},
+				},
+			},
+		}
+
+		endpoints, err := openAPIEndpoints(doc, "database/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(endpoints) != 2 {
+			t.Fatalf("expected 2 endpoints, got %d", len(endpoints))
+		}
+
+		list, role := endpoints[0], endpoints[1]
+		if list.Path != "database/roles" || strings.Join(list.Operations, ",") != "list" || list.Summary != "List roles." {
+			t.Errorf("unexpected endpoint %#v", list)
+		}
+		if role.Path != "database/roles/{name}" || strings.Join(role.Operations, ",") != "read,write,delete" {
+			t.Errorf("unexpected endpoint %#v", role)
+		}
+		var names []string
+		for _, p := range role.Parameters {
+			names = append(names, p.Name+":"+p.In)
+		}
+		if exp, act := "name:path,db_name:body,ttl:body", strings.Join(names, ","); act != exp {
+			t.Errorf("expected parameters %q, got %q", exp, act)
+		}
+
+		examples := openAPIExamples(role)
+		expected := []string{
+			"vault read 'database/roles/<name>'",
+			"vault write 'database/roles/<name>' \\\n    'db_name=<string>' \\\n    ttl=3600",
+			"vault delete 'database/roles/<name>'",
+		}
+		if !reflect.DeepEqual(examples, expected) {
+			t.Errorf("expected %q, got %q", expected, examples)
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*PKICommand)(nil)
+
+type PKICommand struct {
+	*BaseCommand
+}
+
+func (c *PKICommand) Synopsis() string {
+	return "Interact with Vault's PKI secrets engine"
+}
+
+func (c *PKICommand) Help() string {
+	helpText := `
+Usage: vault pki <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with Vault's PKI secrets
+  engine. Here are a few examples of the PKI commands:
+
+  Issue a certificate for the "web" role, and write it to files:
+
+      $ vault pki issue -out=web web common_name=www.example.com
+
+  Issue a certificate for a private key generated locally, which is never
+  sent to Vault:
+
+      $ vault pki issue -generate-key -out=web web common_name=www.example.com
+
+  Renew the certificate, with the same names, and replace its files:
+
+      $ vault pki renew -cert=web.crt web
+
+  Check the CA, CRL and OCSP of the PKI secrets engine:
+
+      $ vault pki health-check
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PKICommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,481 @@
+package command
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+	"golang.org/x/crypto/ocsp"
+)
+
+var (
+	_ cli.Command             = (*PKIHealthCheckCommand)(nil)
+	_ cli.CommandAutocomplete = (*PKIHealthCheckCommand)(nil)
+)
+
+// The statuses of the health checks, from the least to the most severe.
+const (
+	pkiHealthOK            = "ok"
+	pkiHealthInformational = "informational"
+	pkiHealthWarning       = "warning"
+	pkiHealthCritical      = "critical"
+)
+
+// The exit codes of the health check command, besides 0 when everything is
+// good and the usual 1 and 2 for usage and communication errors.
+const (
+	pkiHealthExitWarning  = 3
+	pkiHealthExitCritical = 4
+)
+
+// pkiHealthResult is the result of one health check.
+type pkiHealthResult struct {
+	Check   string `json:"check" mapstructure:"check"`
+	Status  string `json:"status" mapstructure:"status"`
+	Message string `json:"message" mapstructure:"message"`
+}
+
+type PKIHealthCheckCommand struct {
+	*BaseCommand
+
+	flagMount         string
+	flagExpiryWarning time.Duration
+	flagCRLWarning    time.Duration
+	flagOCSPSerial    string
+}
+
+func (c *PKIHealthCheckCommand) Synopsis() string {
+	return "Check the health of the CA, CRL and OCSP of a PKI secrets engine"
+}
+
+func (c *PKIHealthCheckCommand) Help() string {
+	helpText := `
+Usage: vault pki health-check [options]
+
+  Checks the health of a PKI secrets engine:
+
+    - The CA certificate is not expired nor about to expire.
+
+    - The CRL is signed by the CA, and its next update is not past nor near.
+
+    - The issuing certificate URLs of the AIA extension serve the CA
+      certificate, and the CRL distribution points serve a valid CRL.
+
+    - The OCSP servers are reachable, and their responses are signed for the
+      CA and not stale.
+
+  The exit code is 0 when all the checks pass, 3 when one of them has a
+  warning, and 4 when one of them is critical, so that the command can be
+  used for monitoring, with -format=json for the details.
+
+  Check the PKI secrets engine mounted at "pki":
+
+      $ vault pki health-check
+
+  Check the PKI secrets engine mounted at "pki_int", warning when the CA
+  expires within 90 days:
+
+      $ vault pki health-check -mount=pki_int -expiry-warning=2160h
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PKIHealthCheckCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "pki",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the PKI secrets engine is mounted.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "expiry-warning",
+		Target:     &c.flagExpiryWarning,
+		Default:    30 * 24 * time.Hour,
+		Completion: complete.PredictAnything,
+		Usage:      "Warn when the CA certificate expires within this duration.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "crl-warning",
+		Target:     &c.flagCRLWarning,
+		Default:    12 * time.Hour,
+		Completion: complete.PredictAnything,
+		Usage: "Warn when the next update of a CRL or an OCSP response is due " +
+			"within this duration.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "ocsp-serial",
+		Target:     &c.flagOCSPSerial,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Serial number of the certificate to query the OCSP servers for. " +
+			"Defaults to a certificate issued by the PKI secrets engine.",
+	})
+
+	return set
+}
+
+func (c *PKIHealthCheckCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *PKIHealthCheckCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PKIHealthCheckCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if args = f.Args(); len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	mount := sanitizePath(c.flagMount)
+	ca, err := readPKIMountCertificate(client, mount+"/cert/ca")
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading CA certificate: %s", err), err)
+		return 2
+	}
+
+	urls, err := client.Logical().Read(mount + "/config/urls")
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading URLs configuration: %s", err), err)
+		return 2
+	}
+	var urlsData map[string]interface{}
+	if urls != nil {
+		urlsData = urls.Data
+	}
+
+	h := &pkiHealthChecker{
+		client:        client,
+		httpClient:    client.CloneConfig().HttpClient,
+		mount:         mount,
+		ca:            ca,
+		now:           time.Now(),
+		expiryWarning: c.flagExpiryWarning,
+		crlWarning:    c.flagCRLWarning,
+	}
+	h.checkCAExpiry()
+	h.checkCRL()
+	h.checkIssuingCertificates(pkiStringList(urlsData["issuing_certificates"]))
+	h.checkCRLDistributionPoints(pkiStringList(urlsData["crl_distribution_points"]))
+	h.checkOCSPServers(pkiStringList(urlsData["ocsp_servers"]), c.flagOCSPSerial)
+
+	code := 0
+	for _, r := range h.results {
+		switch {
+		case r.Status == pkiHealthCritical:
+			code = pkiHealthExitCritical
+		case r.Status == pkiHealthWarning && code == 0:
+			code = pkiHealthExitWarning
+		}
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		out := []string{"Check | Status | Message"}
+		for _, r := range h.results {
+			out = append(out, fmt.Sprintf("%s | %s | %s", r.Check, r.Status, r.Message))
+		}
+		c.UI.Output(tableOutput(out, nil))
+	default:
+		if ret := OutputData(c.UI, h.results); ret != 0 {
+			return ret
+		}
+	}
+	return code
+}
+
+// pkiHealthChecker runs the health checks of a PKI secrets engine and
+// collects their results.
+type pkiHealthChecker struct {
+	client     *api.Client
+	httpClient *http.Client
+	mount      string
+	ca         *x509.Certificate
+	now        time.Time
+
+	expiryWarning time.Duration
+	crlWarning    time.Duration
+
+	results []*pkiHealthResult
+}
+
+func (h *pkiHealthChecker) add(check, status, format string, a ...interface{}) {
+	h.results = append(h.results, &pkiHealthResult{
+		Check:   check,
+		Status:  status,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (h *pkiHealthChecker) checkCAExpiry() {
+	const check = "ca_expiry"
+
+	notAfter := h.ca.NotAfter.UTC().Format(time.RFC3339)
+	switch left := h.ca.NotAfter.Sub(h.now); {
+	case left <= 0:
+		h.add(check, pkiHealthCritical, "CA certificate expired on %s", notAfter)
+	case left < h.expiryWarning:
+		h.add(check, pkiHealthWarning, "CA certificate expires on %s, in %s", notAfter, left.Round(time.Minute))
+	default:
+		h.add(check, pkiHealthOK, "CA certificate expires on %s", notAfter)
+	}
+}
+
+func (h *pkiHealthChecker) checkCRL() {
+	const check = "crl"
+
+	config, err := h.client.Logical().Read(h.mount + "/config/crl")
+	if err != nil {
+		h.add(check, pkiHealthCritical, "error reading CRL configuration: %s", err)
+		return
+	}
+	if config != nil {
+		if disable, _ := config.Data["disable"].(bool); disable {
+			h.add(check, pkiHealthInformational, "CRL generation is disabled")
+			return
+		}
+	}
+
+	secret, err := h.client.Logical().Read(h.mount + "/cert/crl")
+	if err != nil {
+		h.add(check, pkiHealthCritical, "error reading CRL: %s", err)
+		return
+	}
+	var crlPEM string
+	if secret != nil {
+		crlPEM, _ = secret.Data["certificate"].(string)
+	}
+	if crlPEM == "" {
+		h.add(check, pkiHealthCritical, "no CRL found")
+		return
+	}
+	h.checkCRLBytes(check, "CRL", []byte(crlPEM))
+}
+
+func (h *pkiHealthChecker) checkIssuingCertificates(urls []string) {
+	const check = "aia_issuing_certificates"
+
+	if len(urls) == 0 {
+		h.add(check, pkiHealthWarning, "no issuing certificate URLs configured")
+		return
+	}
+	for _, u := range urls {
+		b, err := h.fetch(http.MethodGet, u, "", nil)
+		if err != nil {
+			h.add(check, pkiHealthCritical, "%s: %s", u, err)
+			continue
+		}
+		cert, err := parsePKICertificateBytes(b)
+		switch {
+		case err != nil:
+			h.add(check, pkiHealthCritical, "%s: error parsing certificate: %s", u, err)
+		case !cert.Equal(h.ca):
+			h.add(check, pkiHealthCritical, "%s: serves a certificate other than the CA certificate", u)
+		default:
+			h.add(check, pkiHealthOK, "%s: serves the CA certificate", u)
+		}
+	}
+}
+
+func (h *pkiHealthChecker) checkCRLDistributionPoints(urls []string) {
+	const check = "aia_crl_distribution_points"
+
+	if len(urls) == 0 {
+		h.add(check, pkiHealthWarning, "no CRL distribution points configured")
+		return
+	}
+	for _, u := range urls {
+		b, err := h.fetch(http.MethodGet, u, "", nil)
+		if err != nil {
+			h.add(check, pkiHealthCritical, "%s: %s", u, err)
+			continue
+		}
+		h.checkCRLBytes(check, u+": CRL", b)
+	}
+}
+
+// checkCRLBytes checks that the PEM or DER encoded CRL is signed by the CA and
+// not stale.
+func (h *pkiHealthChecker) checkCRLBytes(check, name string, b []byte) {
+	crl, err := x509.ParseCRL(b)
+	if err != nil {
+		h.add(check, pkiHealthCritical, "%s: error parsing: %s", name, err)
+		return
+	}
+	if err := h.ca.CheckCRLSignature(crl); err != nil {
+		h.add(check, pkiHealthCritical, "%s not signed by the CA: %s", name, err)
+		return
+	}
+	h.checkNextUpdate(check, name, crl.TBSCertList.NextUpdate)
+}
+
+func (h *pkiHealthChecker) checkNextUpdate(check, name string, nextUpdate time.Time) {
+	if nextUpdate.IsZero() {
+		h.add(check, pkiHealthInformational, "%s has no next update", name)
+		return
+	}
+
+	formatted := nextUpdate.UTC().Format(time.RFC3339)
+	switch left := nextUpdate.Sub(h.now); {
+	case left <= 0:
+		h.add(check, pkiHealthCritical, "%s is stale, its next update was due on %s", name, formatted)
+	case left < h.crlWarning:
+		h.add(check, pkiHealthWarning, "%s next update is due on %s, in %s", name, formatted, left.Round(time.Minute))
+	default:
+		h.add(check, pkiHealthOK, "%s next update is due on %s", name, formatted)
+	}
+}
+
+func (h *pkiHealthChecker) checkOCSPServers(urls []string, serial string) {
+	const check = "ocsp"
+
+	if len(urls) == 0 {
+		h.add(check, pkiHealthInformational, "no OCSP servers configured")
+		return
+	}
+
+	cert, err := h.ocspCertificate(serial)
+	if err != nil {
+		h.add(check, pkiHealthWarning, "%s", err)
+		return
+	}
+	req, err := ocsp.CreateRequest(cert, h.ca, nil)
+	if err != nil {
+		h.add(check, pkiHealthCritical, "error creating OCSP request: %s", err)
+		return
+	}
+
+	for _, u := range urls {
+		b, err := h.fetch(http.MethodPost, u, "application/ocsp-request", req)
+		if err != nil {
+			h.add(check, pkiHealthCritical, "%s: %s", u, err)
+			continue
+		}
+		resp, err := ocsp.ParseResponseForCert(b, cert, h.ca)
+		if err != nil {
+			h.add(check, pkiHealthCritical, "%s: invalid OCSP response: %s", u, err)
+			continue
+		}
+		if resp.Status == ocsp.Unknown {
+			h.add(check, pkiHealthWarning, "%s: unknown status for certificate %s", u, pkiSerial(cert))
+			continue
+		}
+		h.checkNextUpdate(check, u+": OCSP response", resp.NextUpdate)
+	}
+}
+
+// ocspCertificate returns the certificate with the serial number, or the first
+// certificate listed by the PKI secrets engine other than its CA.
+func (h *pkiHealthChecker) ocspCertificate(serial string) (*x509.Certificate, error) {
+	if serial != "" {
+		return readPKIMountCertificate(h.client, h.mount+"/cert/"+serial)
+	}
+
+	secret, err := h.client.Logical().List(h.mount + "/certs")
+	if err != nil {
+		return nil, fmt.Errorf("error listing certificates: %w", err)
+	}
+	var serials []interface{}
+	if secret != nil {
+		serials, _ = secret.Data["keys"].([]interface{})
+	}
+	for _, s := range serials {
+		s, _ := s.(string)
+		if s == "" || strings.ReplaceAll(s, "-", ":") == pkiSerial(h.ca) {
+			continue
+		}
+		return readPKIMountCertificate(h.client, h.mount+"/cert/"+s)
+	}
+	return nil, errors.New("no certificate to query the OCSP servers for, use -ocsp-serial")
+}
+
+// fetch sends a request to a URL of the AIA extension, and returns the body
+// of the response.
+func (h *pkiHealthChecker) fetch(method, url, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// readPKIMountCertificate reads a certificate from a fetch endpoint of the PKI
+// secrets engine.
+func readPKIMountCertificate(client *api.Client, path string) (*x509.Certificate, error) {
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		return nil, err
+	}
+	var certPEM string
+	if secret != nil {
+		certPEM, _ = secret.Data["certificate"].(string)
+	}
+	if certPEM == "" {
+		return nil, fmt.Errorf("no certificate found at %s", path)
+	}
+	return parsePKICertificate([]byte(certPEM))
+}
+
+// parsePKICertificateBytes parses a PEM or DER encoded certificate.
+func parsePKICertificateBytes(b []byte) (*x509.Certificate, error) {
+	if cert, err := parsePKICertificate(b); err == nil {
+		return cert, nil
+	}
+	return x509.ParseCertificate(b)
+}
+
+// pkiStringList returns the strings of a list field of a response.
+func pkiStringList(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	values := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok && s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
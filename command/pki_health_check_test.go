@@ -0,0 +1,153 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testPKIHealthCheckCommand(tb testing.TB) (*cli.MockUi, *PKIHealthCheckCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PKIHealthCheckCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestPKIHealthCheckCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("too_many_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testPKIHealthCheckCommand(t)
+
+		code := cmd.Run([]string{"foo"})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Too many arguments"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("pki", &api.MountInput{
+			Type: "pki",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+			"common_name": "example.com",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("pki/config/urls", map[string]interface{}{
+			"issuing_certificates":    client.Address() + "/v1/pki/ca",
+			"crl_distribution_points": client.Address() + "/v1/pki/crl",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		run := func(t *testing.T, expCode int, args ...string) map[string]string {
+			t.Helper()
+
+			ui, cmd := testPKIHealthCheckCommand(t)
+			cmd.client = client
+
+			code := cmd.Run(append([]string{"-format=json"}, args...))
+			if code != expCode {
+				t.Fatalf("expected %d to be %d: %s%s", code, expCode, ui.OutputWriter.String(), ui.ErrorWriter.String())
+			}
+
+			var results []*pkiHealthResult
+			if err := json.Unmarshal(ui.OutputWriter.Bytes(), &results); err != nil {
+				t.Fatal(err)
+			}
+			statuses := make(map[string]string)
+			for _, r := range results {
+				statuses[r.Check] = r.Status
+			}
+			return statuses
+		}
+
+		statuses := run(t, 0)
+		for check, exp := range map[string]string{
+			"ca_expiry":                   pkiHealthOK,
+			"crl":                         pkiHealthOK,
+			"aia_issuing_certificates":    pkiHealthOK,
+			"aia_crl_distribution_points": pkiHealthOK,
+			"ocsp":                        pkiHealthInformational,
+		} {
+			if statuses[check] != exp {
+				t.Errorf("expected %s to be %q, got %q", check, exp, statuses[check])
+			}
+		}
+
+		statuses = run(t, pkiHealthExitWarning, "-expiry-warning=87600h")
+		if statuses["ca_expiry"] != pkiHealthWarning {
+			t.Errorf("expected ca_expiry to be %q, got %q", pkiHealthWarning, statuses["ca_expiry"])
+		}
+
+		// A CRL that is past its next update is critical
+		if _, err := client.Logical().Write("pki/config/crl", map[string]interface{}{
+			"expiry": "1s",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Read("pki/crl/rotate"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Second)
+
+		statuses = run(t, pkiHealthExitCritical)
+		for _, check := range []string{"crl", "aia_crl_distribution_points"} {
+			if statuses[check] != pkiHealthCritical {
+				t.Errorf("expected %s to be %q, got %q", check, pkiHealthCritical, statuses[check])
+			}
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testPKIHealthCheckCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error reading CA certificate: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPKIHealthCheckCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,448 @@
+package command
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*PKIIssueCommand)(nil)
+	_ cli.CommandAutocomplete = (*PKIIssueCommand)(nil)
+)
+
+type PKIIssueCommand struct {
+	*BaseCommand
+
+	flagMount       string
+	flagOut         string
+	flagGenerateKey bool
+	flagKeyType     string
+	flagKeyBits     int
+
+	testStdin io.Reader // for tests
+}
+
+func (c *PKIIssueCommand) Synopsis() string {
+	return "Issue a certificate and write it to files"
+}
+
+func (c *PKIIssueCommand) Help() string {
+	helpText := `
+Usage: vault pki issue [options] ROLE [K=V...]
+
+  Issues a certificate for the given role of a PKI secrets engine, and writes
+  the private key, the certificate and the CA chain to files named after the
+  -out prefix: PREFIX.key, PREFIX.crt and PREFIX.chain.crt. The private key
+  file is only readable by its owner.
+
+  The data is specified as "key=value" pairs, and is sent with the request.
+  See the documentation of the PKI secrets engine for the available fields.
+
+  Issue a certificate for the "web" role of the PKI secrets engine mounted at
+  "pki", with a private key generated by Vault:
+
+      $ vault pki issue -out=web web common_name=www.example.com
+
+  With -generate-key, the private key is generated locally, and only a CSR is
+  sent to Vault to be signed, so that the private key never leaves this
+  machine. The common name and the alt_names, ip_sans and uri_sans fields
+  are included in the CSR:
+
+      $ vault pki issue -generate-key -key-type=ec -out=web web \
+          common_name=www.example.com alt_names=example.com
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PKIIssueCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "pki",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the PKI secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "out",
+		Target:     &c.flagOut,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Prefix of the files to write the private key, the certificate " +
+			"and the CA chain to. This is required.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "generate-key",
+		Target:     &c.flagGenerateKey,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage: "Generate the private key locally and have Vault sign a CSR for " +
+			"it, instead of having Vault generate the private key.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "key-type",
+		Target:     &c.flagKeyType,
+		Default:    "rsa",
+		Completion: complete.PredictSet("rsa", "ec", "ed25519"),
+		Usage: "Type of the private key generated with -generate-key: \"rsa\", " +
+			"\"ec\" or \"ed25519\".",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "key-bits",
+		Target:  &c.flagKeyBits,
+		Default: 0,
+		Usage: "Number of bits of the private key generated with -generate-key. " +
+			"Defaults to 2048 for RSA keys and 256 for EC keys.",
+	})
+
+	return set
+}
+
+func (c *PKIIssueCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *PKIIssueCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PKIIssueCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected at least 1, got %d)", len(args)))
+		return 1
+	}
+	if c.flagOut == "" {
+		c.UI.Error("-out is required")
+		return 1
+	}
+
+	stdin := (io.Reader)(os.Stdin)
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+
+	data, err := parseArgsData(stdin, args[1:])
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
+		return 1
+	}
+
+	var key crypto.Signer
+	if c.flagGenerateKey {
+		key, err = generatePKIKey(c.flagKeyType, c.flagKeyBits)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error generating private key: %s", err), err)
+			return 1
+		}
+	}
+	path, err := preparePKIRequest(c.flagMount, args[0], data, key)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error creating CSR: %s", err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	cert, err := issuePKICertificate(client, path, data, key)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error issuing certificate: %s", err), err)
+		return 2
+	}
+
+	files := pkiFiles{
+		PrivateKey:  c.flagOut + ".key",
+		Certificate: c.flagOut + ".crt",
+		CAChain:     c.flagOut + ".chain.crt",
+	}
+	if err := files.write(cert); err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	return outputPKICertificate(c.UI, cert, files)
+}
+
+// pkiCertificate is a certificate issued by the PKI secrets engine, PEM
+// encoded.
+type pkiCertificate struct {
+	Certificate string
+	PrivateKey  string
+	CAChain     string
+}
+
+// pkiFiles are the paths of the files a certificate is written to.
+type pkiFiles struct {
+	PrivateKey  string `json:"private_key" mapstructure:"private_key"`
+	Certificate string `json:"certificate" mapstructure:"certificate"`
+	CAChain     string `json:"ca_chain" mapstructure:"ca_chain"`
+}
+
+// write replaces each of the files with the certificate. The private key file
+// is only readable by its owner.
+func (f pkiFiles) write(cert *pkiCertificate) error {
+	if err := writePKIFile(f.PrivateKey, cert.PrivateKey, 0o600); err != nil {
+		return fmt.Errorf("Error writing private key: %w", err)
+	}
+	if err := writePKIFile(f.Certificate, cert.Certificate, 0o644); err != nil {
+		return fmt.Errorf("Error writing certificate: %w", err)
+	}
+	if err := writePKIFile(f.CAChain, cert.CAChain, 0o644); err != nil {
+		return fmt.Errorf("Error writing CA chain: %w", err)
+	}
+	return nil
+}
+
+// preparePKIRequest returns the path to issue a certificate for the role of
+// the PKI secrets engine mounted at mount. If a private key is given, a CSR for
+// it is added to the data, to be signed instead of having Vault generate the
+// private key.
+func preparePKIRequest(mount, role string, data map[string]interface{}, key crypto.Signer) (string, error) {
+	mount = sanitizePath(mount)
+	role = sanitizePath(role)
+	data["format"] = "pem"
+
+	if key == nil {
+		return fmt.Sprintf("%s/issue/%s", mount, role), nil
+	}
+
+	csr, err := createPKICSR(key, data)
+	if err != nil {
+		return "", err
+	}
+	data["csr"] = csr
+	return fmt.Sprintf("%s/sign/%s", mount, role), nil
+}
+
+// issuePKICertificate issues a certificate by writing the data to path. The
+// private key is the one given, if any, or the one generated by Vault.
+func issuePKICertificate(client *api.Client, path string, data map[string]interface{}, key crypto.Signer) (*pkiCertificate, error) {
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("no certificate returned by %s", path)
+	}
+
+	cert := &pkiCertificate{
+		CAChain: pkiChain(secret),
+	}
+	cert.Certificate, _ = secret.Data["certificate"].(string)
+	if cert.Certificate == "" {
+		return nil, fmt.Errorf("no certificate returned by %s", path)
+	}
+
+	if key == nil {
+		cert.PrivateKey, _ = secret.Data["private_key"].(string)
+		if cert.PrivateKey == "" {
+			return nil, fmt.Errorf("no private key returned by %s", path)
+		}
+		return cert, nil
+	}
+
+	cert.PrivateKey, err = encodePKIPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// encodePKIPrivateKey PEM encodes a private key in the PKCS #8 format.
+func encodePKIPrivateKey(key crypto.Signer) (string, error) {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("error encoding private key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})), nil
+}
+
+func outputPKICertificate(ui cli.Ui, cert *pkiCertificate, files pkiFiles) int {
+	parsed, err := parsePKICertificate([]byte(cert.Certificate))
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error parsing certificate: %s", err))
+		return 2
+	}
+	serialNumber := pkiSerial(parsed)
+
+	switch Format(ui) {
+	case "table":
+		ui.Output(columnOutput([]string{
+			fmt.Sprintf("Serial Number | %s", serialNumber),
+			fmt.Sprintf("Private Key | %s", files.PrivateKey),
+			fmt.Sprintf("Certificate | %s", files.Certificate),
+			fmt.Sprintf("CA Chain | %s", files.CAChain),
+		}, nil))
+		return 0
+	default:
+		return OutputData(ui, map[string]interface{}{
+			"serial_number": serialNumber,
+			"expiration":    parsed.NotAfter.Unix(),
+			"files":         files,
+		})
+	}
+}
+
+// pkiSerial returns the serial number of a certificate in the format used by
+// the PKI secrets engine.
+func pkiSerial(cert *x509.Certificate) string {
+	return certutil.GetHexFormatted(cert.SerialNumber.Bytes(), ":")
+}
+
+// generatePKIKey generates a private key of the given type and size.
+func generatePKIKey(keyType string, keyBits int) (crypto.Signer, error) {
+	switch keyType {
+	case "rsa":
+		if keyBits == 0 {
+			keyBits = 2048
+		}
+		if keyBits < 2048 {
+			return nil, fmt.Errorf("RSA keys must have at least 2048 bits, got %d", keyBits)
+		}
+		return rsa.GenerateKey(rand.Reader, keyBits)
+
+	case "ec":
+		var curve elliptic.Curve
+		switch keyBits {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 224:
+			curve = elliptic.P224()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported bit length for EC keys: %d", keyBits)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
+
+// createPKICSR creates a PEM encoded CSR for the key, with the common name
+// and the subject alternative names of the request data.
+func createPKICSR(key crypto.Signer, data map[string]interface{}) (string, error) {
+	commonName, _ := data["common_name"].(string)
+	if commonName == "" {
+		return "", fmt.Errorf("common_name is required")
+	}
+
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}
+	for _, name := range pkiListField(data, "alt_names") {
+		if strings.Contains(name, "@") {
+			template.EmailAddresses = append(template.EmailAddresses, name)
+			continue
+		}
+		template.DNSNames = append(template.DNSNames, name)
+	}
+	for _, ipSAN := range pkiListField(data, "ip_sans") {
+		ip := net.ParseIP(ipSAN)
+		if ip == nil {
+			return "", fmt.Errorf("invalid IP SAN %q", ipSAN)
+		}
+		template.IPAddresses = append(template.IPAddresses, ip)
+	}
+	for _, uriSAN := range pkiListField(data, "uri_sans") {
+		uri, err := url.Parse(uriSAN)
+		if err != nil {
+			return "", fmt.Errorf("invalid URI SAN %q: %w", uriSAN, err)
+		}
+		template.URIs = append(template.URIs, uri)
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
+
+// pkiListField returns the comma separated values of a field of the request
+// data.
+func pkiListField(data map[string]interface{}, field string) []string {
+	raw, _ := data[field].(string)
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// pkiChain returns the CA chain of an issued certificate, or its issuing CA
+// when the chain isn't returned.
+func pkiChain(secret *api.Secret) string {
+	var chain []string
+	if certs, ok := secret.Data["ca_chain"].([]interface{}); ok {
+		for _, cert := range certs {
+			if s, ok := cert.(string); ok {
+				chain = append(chain, strings.TrimSpace(s))
+			}
+		}
+	}
+	if len(chain) == 0 {
+		if issuingCA, ok := secret.Data["issuing_ca"].(string); ok {
+			chain = append(chain, strings.TrimSpace(issuingCA))
+		}
+	}
+	if len(chain) == 0 {
+		return ""
+	}
+	return strings.Join(chain, "\n") + "\n"
+}
+
+// writePKIFile atomically replaces the file at path with the PEM contents,
+// with the given mode.
+func writePKIFile(path, contents string, mode os.FileMode) error {
+	if contents != "" && !strings.HasSuffix(contents, "\n") {
+		contents += "\n"
+	}
+	return writeOutputFile(path, []byte(contents), mode)
+}
@@ -0,0 +1,177 @@
+package command
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testPKIIssueCommand(tb testing.TB) (*cli.MockUi, *PKIIssueCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PKIIssueCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestPKIIssueCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"-out=foo"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"no_out",
+			[]string{"web", "common_name=www.example.com"},
+			"-out is required",
+			1,
+		},
+		{
+			"bad_key_type",
+			[]string{"-out=foo", "-generate-key", "-key-type=dsa", "web", "common_name=www.example.com"},
+			"unsupported key type",
+			1,
+		},
+		{
+			"no_common_name",
+			[]string{"-out=foo", "-generate-key", "web"},
+			"common_name is required",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testPKIIssueCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("pki", &api.MountInput{
+			Type: "pki",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+			"common_name": "example.com",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("pki/roles/web", map[string]interface{}{
+			"allowed_domains":  "example.com",
+			"allow_subdomains": true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, generateKey := range []bool{false, true} {
+			out := filepath.Join(t.TempDir(), "web")
+			args := []string{"-out=" + out}
+			if generateKey {
+				args = append(args, "-generate-key")
+			}
+
+			ui, cmd := testPKIIssueCommand(t)
+			cmd.client = client
+
+			code := cmd.Run(append(args, "web", "common_name=www.example.com"))
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+
+			// The private key matches the certificate and is only readable by
+			// its owner
+			if _, err := tls.LoadX509KeyPair(out+".crt", out+".key"); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(out + ".key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o600 {
+				t.Fatalf("expected the private key to have mode 0600, got %v", info.Mode().Perm())
+			}
+
+			chain, err := ioutil.ReadFile(out + ".chain.crt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(chain), "BEGIN CERTIFICATE") {
+				t.Fatalf("bad chain: %s", chain)
+			}
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testPKIIssueCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-out=" + filepath.Join(t.TempDir(), "web"), "web", "common_name=www.example.com"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error issuing certificate: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPKIIssueCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
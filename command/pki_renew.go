@@ -0,0 +1,357 @@
+package command
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*PKIRenewCommand)(nil)
+	_ cli.CommandAutocomplete = (*PKIRenewCommand)(nil)
+)
+
+type PKIRenewCommand struct {
+	*BaseCommand
+
+	flagMount       string
+	flagCert        string
+	flagKey         string
+	flagChain       string
+	flagGenerateKey bool
+	flagReuseKey    bool
+
+	testStdin io.Reader // for tests
+}
+
+func (c *PKIRenewCommand) Synopsis() string {
+	return "Renew a certificate and replace its files"
+}
+
+func (c *PKIRenewCommand) Help() string {
+	helpText := `
+Usage: vault pki renew [options] -cert=FILE ROLE [K=V...]
+
+  Renews an existing certificate with the given role of a PKI secrets engine.
+  The common name, the subject alternative names and the TTL of the existing
+  certificate are requested again, and the renewed certificate must have the
+  same key usages. The private key, the certificate and the CA chain files are
+  then atomically replaced.
+
+  The private key and CA chain files are next to the certificate file by
+  default: renewing web.crt replaces web.key and web.chain.crt.
+
+  Renew a certificate issued for the "web" role, with a new private key
+  generated by Vault:
+
+      $ vault pki renew -cert=web.crt web
+
+  Renew a certificate with a new private key of the same type, generated
+  locally:
+
+      $ vault pki renew -cert=web.crt -generate-key web
+
+  Renew a certificate for the same private key:
+
+      $ vault pki renew -cert=web.crt -reuse-key web
+
+  Additional data is specified as "key=value" pairs, and takes precedence over
+  the values of the existing certificate.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PKIRenewCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "pki",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the PKI secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "cert",
+		Target:     &c.flagCert,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path to the PEM encoded certificate to renew. This is required.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "key",
+		Target:     &c.flagKey,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to the private key file. Defaults to the path of the " +
+			"certificate with the \".key\" extension.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "chain",
+		Target:     &c.flagChain,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to the CA chain file. Defaults to the path of the " +
+			"certificate with the \".chain.crt\" extension.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "generate-key",
+		Target:     &c.flagGenerateKey,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage: "Generate a new private key of the same type and size locally, " +
+			"and have Vault sign a CSR for it.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "reuse-key",
+		Target:     &c.flagReuseKey,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage: "Keep the existing private key, read from the -key file, and " +
+			"have Vault sign a CSR for it.",
+	})
+
+	return set
+}
+
+func (c *PKIRenewCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *PKIRenewCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PKIRenewCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected at least 1, got %d)", len(args)))
+		return 1
+	case c.flagCert == "":
+		c.UI.Error("-cert is required")
+		return 1
+	case c.flagGenerateKey && c.flagReuseKey:
+		c.UI.Error("-generate-key and -reuse-key cannot be used together")
+		return 1
+	}
+
+	files := pkiFiles{
+		PrivateKey:  c.flagKey,
+		Certificate: c.flagCert,
+		CAChain:     c.flagChain,
+	}
+	prefix := strings.TrimSuffix(c.flagCert, ".crt")
+	if files.PrivateKey == "" {
+		files.PrivateKey = prefix + ".key"
+	}
+	if files.CAChain == "" {
+		files.CAChain = prefix + ".chain.crt"
+	}
+
+	existing, err := readPKICertificate(files.Certificate)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading certificate: %s", err), err)
+		return 1
+	}
+
+	stdin := (io.Reader)(os.Stdin)
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+
+	data, err := parseArgsData(stdin, args[1:])
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
+		return 1
+	}
+	for k, v := range pkiRenewalData(existing) {
+		if _, ok := data[k]; !ok {
+			data[k] = v
+		}
+	}
+
+	var key crypto.Signer
+	switch {
+	case c.flagGenerateKey:
+		keyType, keyBits, err := pkiKeyTypeAndBits(existing.PublicKey)
+		if err == nil {
+			key, err = generatePKIKey(keyType, keyBits)
+		}
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error generating private key: %s", err), err)
+			return 1
+		}
+	case c.flagReuseKey:
+		key, err = readPKIPrivateKey(files.PrivateKey)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading private key: %s", err), err)
+			return 1
+		}
+	}
+
+	path, err := preparePKIRequest(c.flagMount, args[0], data, key)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error creating CSR: %s", err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	cert, err := issuePKICertificate(client, path, data, key)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error renewing certificate: %s", err), err)
+		return 2
+	}
+
+	// Don't replace the existing certificate by one that can't be used the
+	// same way
+	renewed, err := parsePKICertificate([]byte(cert.Certificate))
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing renewed certificate: %s", err), err)
+		return 2
+	}
+	if renewed.KeyUsage != existing.KeyUsage || !reflect.DeepEqual(renewed.ExtKeyUsage, existing.ExtKeyUsage) {
+		c.UI.Error(fmt.Sprintf("The key usages of the renewed certificate differ from the existing certificate, check the key_usage and ext_key_usage of the role %q. The files were not replaced.", args[0]))
+		return 2
+	}
+
+	if err := files.write(cert); err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	return outputPKICertificate(c.UI, cert, files)
+}
+
+// pkiRenewalData returns the request data to issue a certificate with the
+// same names and TTL as the certificate. The TTL is truncated to the minute,
+// as Vault backdates the certificates it issues by a few seconds.
+func pkiRenewalData(cert *x509.Certificate) map[string]interface{} {
+	ttl := cert.NotAfter.Sub(cert.NotBefore).Truncate(time.Minute)
+	data := map[string]interface{}{
+		"common_name": cert.Subject.CommonName,
+		"ttl":         fmt.Sprintf("%ds", int64(ttl.Seconds())),
+	}
+
+	altNames := append(append([]string{}, cert.DNSNames...), cert.EmailAddresses...)
+	if len(altNames) > 0 {
+		data["alt_names"] = strings.Join(altNames, ",")
+	}
+	if len(cert.IPAddresses) > 0 {
+		ipSANs := make([]string, 0, len(cert.IPAddresses))
+		for _, ip := range cert.IPAddresses {
+			ipSANs = append(ipSANs, ip.String())
+		}
+		data["ip_sans"] = strings.Join(ipSANs, ",")
+	}
+	if len(cert.URIs) > 0 {
+		uriSANs := make([]string, 0, len(cert.URIs))
+		for _, uri := range cert.URIs {
+			uriSANs = append(uriSANs, uri.String())
+		}
+		data["uri_sans"] = strings.Join(uriSANs, ",")
+	}
+	return data
+}
+
+// pkiKeyTypeAndBits returns the type and size of a public key, as expected by
+// generatePKIKey.
+func pkiKeyTypeAndBits(publicKey interface{}) (string, int, error) {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return "rsa", key.N.BitLen(), nil
+	case *ecdsa.PublicKey:
+		return "ec", key.Curve.Params().BitSize, nil
+	case ed25519.PublicKey:
+		return "ed25519", 0, nil
+	default:
+		return "", 0, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+func readPKICertificate(path string) (*x509.Certificate, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePKICertificate(b)
+}
+
+// parsePKICertificate parses the first certificate of a PEM bundle.
+func parsePKICertificate(b []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			return nil, errors.New("no PEM encoded certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// readPKIPrivateKey reads a PEM encoded PKCS #8, PKCS #1 or EC private key.
+func readPKIPrivateKey(path string) (crypto.Signer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
@@ -0,0 +1,251 @@
+package command
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testPKIRenewCommand(tb testing.TB) (*cli.MockUi, *PKIRenewCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PKIRenewCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testPKIRenewCert writes a self-signed certificate to a temporary file and
+// returns its path.
+func testPKIRenewCert(tb testing.TB) string {
+	tb.Helper()
+
+	key, err := generatePKIKey("ec", 256)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	path := filepath.Join(tb.TempDir(), "web.crt")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestPKIRenewCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"-cert=foo.crt"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"no_cert",
+			[]string{"web"},
+			"-cert is required",
+			1,
+		},
+		{
+			"both_key_flags",
+			[]string{"-cert=foo.crt", "-generate-key", "-reuse-key", "web"},
+			"cannot be used together",
+			1,
+		},
+		{
+			"missing_cert",
+			[]string{"-cert=" + filepath.Join(os.TempDir(), "vault-pki-renew-missing.crt"), "web"},
+			"Error reading certificate",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testPKIRenewCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("pki", &api.MountInput{
+			Type: "pki",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+			"common_name": "example.com",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("pki/roles/web", map[string]interface{}{
+			"allowed_domains":  "example.com",
+			"allow_subdomains": true,
+			"max_ttl":          "1h",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, flag := range []string{"", "-generate-key", "-reuse-key"} {
+			out := filepath.Join(t.TempDir(), "web")
+
+			ui, issueCmd := testPKIIssueCommand(t)
+			issueCmd.client = client
+			code := issueCmd.Run([]string{
+				"-out=" + out, "-generate-key", "-key-type=ec", "web",
+				"common_name=www.example.com", "alt_names=api.example.com", "ip_sans=127.0.0.1", "ttl=30m",
+			})
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+			existing, err := readPKICertificate(out + ".crt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			existingKey, err := ioutil.ReadFile(out + ".key")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			args := []string{"-cert=" + out + ".crt"}
+			if flag != "" {
+				args = append(args, flag)
+			}
+
+			ui, cmd := testPKIRenewCommand(t)
+			cmd.client = client
+
+			code = cmd.Run(append(args, "web"))
+			if exp := 0; code != exp {
+				t.Fatalf("%s: expected %d to be %d: %s", flag, code, exp, ui.ErrorWriter.String())
+			}
+
+			renewed, err := readPKICertificate(out + ".crt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if renewed.SerialNumber.Cmp(existing.SerialNumber) == 0 {
+				t.Fatalf("%s: the certificate was not replaced", flag)
+			}
+			if renewed.Subject.CommonName != existing.Subject.CommonName ||
+				!reflect.DeepEqual(renewed.DNSNames, existing.DNSNames) ||
+				len(renewed.IPAddresses) != 1 || !renewed.IPAddresses[0].Equal(existing.IPAddresses[0]) {
+				t.Fatalf("%s: names not preserved: %q %q %v", flag, renewed.Subject.CommonName, renewed.DNSNames, renewed.IPAddresses)
+			}
+			if renewed.KeyUsage != existing.KeyUsage || !reflect.DeepEqual(renewed.ExtKeyUsage, existing.ExtKeyUsage) {
+				t.Fatalf("%s: key usages not preserved", flag)
+			}
+
+			// The private key matches the certificate and is only readable by
+			// its owner
+			if _, err := tls.LoadX509KeyPair(out+".crt", out+".key"); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(out + ".key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o600 {
+				t.Fatalf("%s: expected the private key to have mode 0600, got %v", flag, info.Mode().Perm())
+			}
+
+			renewedKey, err := ioutil.ReadFile(out + ".key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reused := string(renewedKey) == string(existingKey); reused != (flag == "-reuse-key") {
+				t.Fatalf("%s: unexpected private key reuse: %t", flag, reused)
+			}
+			if keyType, _, _ := pkiKeyTypeAndBits(renewed.PublicKey); flag != "" && keyType != "ec" {
+				t.Fatalf("%s: expected an EC private key, got %s", flag, keyType)
+			}
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testPKIRenewCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-cert=" + testPKIRenewCert(t), "web"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error renewing certificate: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPKIRenewCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -37,6 +37,14 @@ Usage: vault plugin <subcommand> [options] [args]
 
       $ vault plugin info auth my-custom-plugin
 
+  Generate the skeleton of a new secrets engine plugin:
+
+      $ vault plugin scaffold -type=secret my-custom-plugin
+
+  Run a plugin in a dev server, and reload it on changes:
+
+      $ vault plugin dev run ./cmd/my-custom-plugin
+
   Please see the individual subcommand help for detailed usage information.
 `
 
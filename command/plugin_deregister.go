@@ -96,7 +96,7 @@ func (c *PluginDeregisterCommand) Run(args []string) int {
 		Name: pluginName,
 		Type: pluginType,
 	}); err != nil {
-		c.UI.Error(fmt.Sprintf("Error deregistering plugin named %s: %s", pluginName, err))
+		outputError(c.UI, fmt.Sprintf("Error deregistering plugin named %s: %s", pluginName, err), err)
 		return 2
 	}
 
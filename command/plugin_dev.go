@@ -0,0 +1,39 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*PluginDevCommand)(nil)
+
+type PluginDevCommand struct {
+	*BaseCommand
+}
+
+func (c *PluginDevCommand) Synopsis() string {
+	return "Develop external plugins against a local Vault dev server"
+}
+
+func (c *PluginDevCommand) Help() string {
+	helpText := `
+Usage: vault plugin dev <subcommand> [options] [args]
+
+  This command groups subcommands for developing external plugins locally.
+  Here are a few examples of the plugin dev commands:
+
+  Build the plugin in ./cmd/my-plugin, run it in a dev server, and rebuild it
+  on changes:
+
+      $ vault plugin dev run ./cmd/my-plugin
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginDevCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
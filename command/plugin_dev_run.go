@@ -0,0 +1,558 @@
+package command
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*PluginDevRunCommand)(nil)
+	_ cli.CommandAutocomplete = (*PluginDevRunCommand)(nil)
+)
+
+// pluginDevServerTimeout is how long vault plugin dev run waits for the dev
+// server to start.
+const pluginDevServerTimeout = 30 * time.Second
+
+type PluginDevRunCommand struct {
+	*BaseCommand
+
+	flagType          string
+	flagName          string
+	flagPath          string
+	flagInterval      time.Duration
+	flagListenAddress string
+	flagLogLevel      string
+
+	ShutdownCh chan struct{}
+}
+
+func (c *PluginDevRunCommand) Synopsis() string {
+	return "Run a plugin in a dev server, and reload it on changes"
+}
+
+func (c *PluginDevRunCommand) Help() string {
+	helpText := `
+Usage: vault plugin dev run [options] PACKAGE
+
+  Builds the main Go package of an external plugin, starts a Vault dev server
+  with the plugin registered in its catalog, and mounts the plugin. The logs
+  of the dev server, which hold the logs of the plugin, are written inline.
+
+  The Go module of the package is then watched for changes. When a Go source
+  file, go.mod, or go.sum changes, the plugin is rebuilt, registered again
+  with its new SHA256, and reloaded. If the build fails, its errors are
+  written and the previous build keeps running.
+
+  Secrets engine and auth method plugins are mounted at -path, and reloaded
+  on all of their mounts. Database plugins are mounted by enabling the
+  database secrets engine at -path: configure a connection using the plugin
+  there, and the connections of the secrets engine are reset on changes.
+
+  The dev server is stopped, and the builds removed, on interrupt. As with
+  "vault server -dev", the dev server stores everything in-memory and must
+  never be used in production.
+
+  Run the secrets engine plugin in ./cmd/my-plugin, mounted at "my-plugin":
+
+      $ vault plugin dev run ./cmd/my-plugin
+
+  Run an auth method plugin mounted at "auth/example", with debug logs:
+
+      $ vault plugin dev run -type=auth -path=example -log-level=debug \
+          ./cmd/vault-plugin-auth-example
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginDevRunCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetNone)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "type",
+		Target:     &c.flagType,
+		Default:    "secret",
+		Completion: complete.PredictSet("auth", "database", "secret"),
+		Usage:      `Type of the plugin, one of "auth", "database", or "secret".`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "name",
+		Target:     &c.flagName,
+		Completion: complete.PredictAnything,
+		Usage: "Name of the plugin in the catalog. This defaults to the last " +
+			"element of PACKAGE.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "path",
+		Target:     &c.flagPath,
+		Completion: complete.PredictAnything,
+		Usage: "Path to mount the plugin at. This defaults to the name of the " +
+			"plugin, or to \"database\" for database plugins.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "interval",
+		Target:     &c.flagInterval,
+		Default:    time.Second,
+		Completion: complete.PredictAnything,
+		Usage:      "Interval to check the sources of the plugin for changes at.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "listen-address",
+		Target:     &c.flagListenAddress,
+		Default:    "127.0.0.1:8200",
+		Completion: complete.PredictAnything,
+		Usage:      "Address the dev server listens on.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "log-level",
+		Target:     &c.flagLogLevel,
+		Default:    "info",
+		Completion: complete.PredictSet("trace", "debug", "info", "warn", "error"),
+		Usage: "Log level of the dev server and the plugin. Supported values " +
+			"(in order of detail) are \"trace\", \"debug\", \"info\", \"warn\", " +
+			"and \"error\".",
+	})
+
+	return set
+}
+
+func (c *PluginDevRunCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictDirs("*")
+}
+
+func (c *PluginDevRunCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PluginDevRunCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	case c.flagInterval <= 0:
+		c.UI.Error("-interval must be positive")
+		return 1
+	}
+
+	var pluginType consts.PluginType
+	switch c.flagType {
+	case "auth":
+		pluginType = consts.PluginTypeCredential
+	case "database":
+		pluginType = consts.PluginTypeDatabase
+	case "secret":
+		pluginType = consts.PluginTypeSecrets
+	default:
+		c.UI.Error(fmt.Sprintf("Invalid plugin type %q: expected \"auth\", \"database\", "+
+			"or \"secret\"", c.flagType))
+		return 1
+	}
+
+	pkg := args[0]
+	name := c.flagName
+	if name == "" {
+		name = pluginDevName(pkg)
+	}
+	if !pluginScaffoldNameRegex.MatchString(name) {
+		c.UI.Error(fmt.Sprintf("Invalid plugin name %q: it may only contain letters, "+
+			"digits, '_', '.' and '-'", name))
+		return 1
+	}
+
+	mountPath := c.flagPath
+	if mountPath == "" {
+		mountPath = name
+		if pluginType == consts.PluginTypeDatabase {
+			mountPath = "database"
+		}
+	}
+	mountPath = ensureTrailingSlash(sanitizePath(mountPath))
+
+	moduleDir, err := pluginDevModuleDir(pkg)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error finding the module of %s: %s", pkg, err), err)
+		return 1
+	}
+	sources, err := pluginDevSourcesHash(moduleDir)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading the sources of %s: %s", pkg, err), err)
+		return 1
+	}
+
+	pluginDir, err := ioutil.TempDir("", "vault-plugin-dev")
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error creating the plugin directory: %s", err), err)
+		return 1
+	}
+	defer os.RemoveAll(pluginDir)
+
+	// The logs of the dev server are written concurrently with the output of
+	// this command
+	ui := &cli.ConcurrentUi{Ui: c.UI}
+
+	ui.Info(fmt.Sprintf("==> Building %s", pkg))
+	binary := filepath.Join(pluginDir, name)
+	if err := pluginDevBuild(pkg, binary); err != nil {
+		ui.Error(fmt.Sprintf("Error building %s: %s", pkg, err))
+		return 1
+	}
+
+	token, err := uuid.GenerateUUID()
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error generating the root token: %s", err))
+		return 1
+	}
+	server, exited, err := c.startServer(ui, pluginDir, token)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error starting the dev server: %s", err))
+		return 1
+	}
+	defer stopPluginDevServer(server, exited)
+
+	client, err := api.NewClient(&api.Config{Address: "http://" + c.flagListenAddress})
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error creating the client: %s", err))
+		return 1
+	}
+	client.SetToken(token)
+	client.ClearNamespace()
+
+	if err := waitForPluginDevServer(client, exited); err != nil {
+		ui.Error(fmt.Sprintf("Error starting the dev server: %s", err))
+		return 2
+	}
+
+	if err := registerPluginDevBinary(client, name, pluginType, binary); err != nil {
+		ui.Error(fmt.Sprintf("Error registering the plugin: %s", err))
+		return 2
+	}
+
+	switch pluginType {
+	case consts.PluginTypeCredential:
+		err = client.Sys().EnableAuthWithOptions(mountPath, &api.EnableAuthOptions{Type: name})
+	case consts.PluginTypeDatabase:
+		err = client.Sys().Mount(mountPath, &api.MountInput{Type: "database"})
+	default:
+		err = client.Sys().Mount(mountPath, &api.MountInput{Type: name})
+	}
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error mounting the plugin: %s", err))
+		return 2
+	}
+
+	mounted := mountPath
+	if pluginType == consts.PluginTypeCredential {
+		mounted = "auth/" + mountPath
+	}
+	ui.Info("")
+	ui.Info(fmt.Sprintf("==> Plugin %q is mounted at %s", name, mounted))
+	if pluginType == consts.PluginTypeDatabase {
+		ui.Info(wrapAtLength(fmt.Sprintf("Configure a connection using the plugin with "+
+			"\"vault write %sconfig/my-connection plugin_name=%s ...\".", mountPath, name)))
+	}
+	ui.Info("Use the dev server with:")
+	ui.Info("")
+	ui.Info(fmt.Sprintf("    $ export VAULT_ADDR=%s", client.Address()))
+	ui.Info(fmt.Sprintf("    $ export VAULT_TOKEN=%s", token))
+	ui.Info("")
+	ui.Info(fmt.Sprintf("==> Watching %s for changes", moduleDir))
+
+	ticker := time.NewTicker(c.flagInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ShutdownCh:
+			ui.Info("==> Stopping the dev server")
+			return 0
+		case err := <-exited:
+			ui.Error(fmt.Sprintf("The dev server exited: %v", err))
+			return 2
+		case <-ticker.C:
+		}
+
+		latest, err := pluginDevSourcesHash(moduleDir)
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error reading the sources of %s: %s", pkg, err))
+			continue
+		}
+		if latest == sources {
+			continue
+		}
+		sources = latest
+
+		ui.Info(fmt.Sprintf("==> Rebuilding %s", pkg))
+		if err := pluginDevBuild(pkg, binary); err != nil {
+			ui.Error(fmt.Sprintf("Error building %s, the previous build is kept: %s", pkg, err))
+			continue
+		}
+		if err := registerPluginDevBinary(client, name, pluginType, binary); err != nil {
+			ui.Error(fmt.Sprintf("Error registering the plugin: %s", err))
+			continue
+		}
+		if err := reloadPluginDev(client, name, pluginType, mountPath); err != nil {
+			ui.Error(fmt.Sprintf("Error reloading the plugin: %s", err))
+			continue
+		}
+		ui.Info(fmt.Sprintf("==> Reloaded plugin %q", name))
+	}
+}
+
+// startServer starts a dev server running the plugins in pluginDir, with the
+// given root token, and writes its logs to ui. The returned channel receives
+// the result of the server process once it exits.
+func (c *PluginDevRunCommand) startServer(ui cli.Ui, pluginDir, token string) (*exec.Cmd, <-chan error, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The plugin is registered with its type by this command, so the dev
+	// server doesn't register the plugins of pluginDir itself
+	cmd := exec.Command(executable, "server", "-dev",
+		"-dev-root-token-id="+token,
+		"-dev-listen-address="+c.flagListenAddress,
+		"-dev-plugin-dir="+pluginDir,
+		"-dev-plugin-init=false",
+		"-log-level="+c.flagLogLevel,
+	)
+	// The dev server must not be configured by the environment of this
+	// command for another Vault
+	cmd.Env = make([]string, 0, len(os.Environ()))
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "VAULT_") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	tail := func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			ui.Output(scanner.Text())
+		}
+	}
+	go tail(stdout)
+	go tail(stderr)
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	return cmd, exited, nil
+}
+
+// stopPluginDevServer interrupts the dev server, and kills it if it doesn't
+// exit in time.
+func stopPluginDevServer(cmd *exec.Cmd, exited <-chan error) {
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		cmd.Process.Kill()
+		return
+	}
+	select {
+	case <-exited:
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+	}
+}
+
+// waitForPluginDevServer waits until the dev server answers as unsealed, or
+// exits.
+func waitForPluginDevServer(client *api.Client, exited <-chan error) error {
+	deadline := time.Now().Add(pluginDevServerTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("the dev server exited: %v", err)
+		case <-time.After(250 * time.Millisecond):
+		}
+
+		if health, err := client.Sys().Health(); err == nil && health.Initialized && !health.Sealed {
+			return nil
+		}
+	}
+	return fmt.Errorf("the dev server did not start within %s", pluginDevServerTimeout)
+}
+
+// registerPluginDevBinary registers binary in the catalog as the plugin named
+// name, with its current SHA256.
+func registerPluginDevBinary(client *api.Client, name string, pluginType consts.PluginType, binary string) error {
+	f, err := os.Open(binary)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return err
+	}
+
+	return client.Sys().RegisterPlugin(&api.RegisterPluginInput{
+		Name:    name,
+		Type:    pluginType,
+		Command: filepath.Base(binary),
+		SHA256:  hex.EncodeToString(hasher.Sum(nil)),
+	})
+}
+
+// reloadPluginDev reloads the plugin named name on all of its mounts. Database
+// plugins are not mounted themselves, so the connections of the database
+// secrets engine at mountPath are reset instead, which restarts their
+// plugins.
+func reloadPluginDev(client *api.Client, name string, pluginType consts.PluginType, mountPath string) error {
+	if pluginType != consts.PluginTypeDatabase {
+		_, err := client.Sys().ReloadPlugin(&api.ReloadPluginInput{Plugin: name})
+		return err
+	}
+
+	secret, err := client.Logical().List(mountPath + "config")
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil
+	}
+	keys, _ := secret.Data["keys"].([]interface{})
+	for _, key := range keys {
+		connection, ok := key.(string)
+		if !ok {
+			continue
+		}
+		if _, err := client.Logical().Write(mountPath+"reset/"+connection, nil); err != nil {
+			return fmt.Errorf("error resetting connection %q: %w", connection, err)
+		}
+	}
+	return nil
+}
+
+// pluginDevName returns the default name of the plugin built from the Go
+// package pkg, which is the last element of its path.
+func pluginDevName(pkg string) string {
+	name := path.Base(filepath.ToSlash(filepath.Clean(pkg)))
+	if name == "." || name == ".." || name == "/" {
+		if abs, err := filepath.Abs(pkg); err == nil {
+			name = filepath.Base(abs)
+		}
+	}
+	return name
+}
+
+// pluginDevModuleDir returns the root directory of the Go module holding the
+// package pkg.
+func pluginDevModuleDir(pkg string) (string, error) {
+	out, err := exec.Command("go", "list", "-f", "{{with .Module}}{{.Dir}}{{end}}", pkg).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	dir := strings.TrimSpace(string(out))
+	if dir == "" {
+		return "", fmt.Errorf("%s is not in a Go module", pkg)
+	}
+	return dir, nil
+}
+
+// pluginDevBuild builds the Go package pkg into binary. The build is written
+// next to binary first, and then renamed to it, so that a running plugin
+// keeps its binary.
+func pluginDevBuild(pkg, binary string) error {
+	tmp := binary + ".build"
+	out, err := exec.Command("go", "build", "-o", tmp, pkg).CombinedOutput()
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return os.Rename(tmp, binary)
+}
+
+// pluginDevSourcesHash returns a hash of the paths, sizes and modification
+// times of the files of the Go module in dir which the build of a plugin
+// depends on: its non-test Go source files, go.mod, and go.sum. Hidden
+// directories, and testdata directories, are skipped.
+func pluginDevSourcesHash(dir string) (string, error) {
+	var sources []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case name == "go.mod", name == "go.sum":
+		case strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go"):
+		default:
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sources = append(sources, fmt.Sprintf("%s %d %d", p, info.Size(), info.ModTime().UnixNano()))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(sources)
+	hash := sha256.Sum256([]byte(strings.Join(sources, "\n")))
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,259 @@
+package command
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/mitchellh/cli"
+)
+
+func testPluginDevRunCommand(tb testing.TB) (*cli.MockUi, *PluginDevRunCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PluginDevRunCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+}
+
+func TestPluginDevRunCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"./foo", "./bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"invalid_interval",
+			[]string{"-interval=0s", "./foo"},
+			"-interval must be positive",
+			1,
+		},
+		{
+			"invalid_type",
+			[]string{"-type=audit", "./foo"},
+			"Invalid plugin type",
+			1,
+		},
+		{
+			"invalid_name",
+			[]string{"-name=my/plugin", "./foo"},
+			"Invalid plugin name",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testPluginDevRunCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPluginDevRunCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestPluginDevName(t *testing.T) {
+	t.Parallel()
+
+	for pkg, expected := range map[string]string{
+		"./cmd/my-plugin":                        "my-plugin",
+		"./cmd/my-plugin/":                       "my-plugin",
+		"github.com/example/vault-plugin-foo":    "vault-plugin-foo",
+		"github.com/example/foo/cmd/foo-plugin/": "foo-plugin",
+	} {
+		if name := pluginDevName(pkg); name != expected {
+			t.Errorf("expected name of %q to be %q, got %q", pkg, expected, name)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, expected := pluginDevName("."), filepath.Base(wd); name != expected {
+		t.Errorf("expected name of %q to be %q, got %q", ".", expected, name)
+	}
+}
+
+func TestPluginDevSourcesHash(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Make sure the modification time changes, whatever the resolution
+		// of the file system
+		modTime := time.Now().Add(time.Duration(len(content)) * time.Hour)
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() string {
+		t.Helper()
+
+		h, err := pluginDevSourcesHash(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	write("go.mod", "module example\n")
+	write("backend.go", "package example\n")
+	write("cmd/example/main.go", "package main\n")
+	initial := hash()
+
+	for _, name := range []string{
+		"backend_test.go",
+		"README.md",
+		".git/index.go",
+		"testdata/fixture.go",
+	} {
+		write(name, "ignored")
+		if h := hash(); h != initial {
+			t.Errorf("expected %s not to change the hash", name)
+		}
+	}
+
+	for _, name := range []string{
+		"backend.go",
+		"go.sum",
+		"cmd/example/main.go",
+		"path_config.go",
+	} {
+		previous := hash()
+		write(name, "package example // "+name+"\n")
+		if h := hash(); h == previous {
+			t.Errorf("expected %s to change the hash", name)
+		}
+	}
+
+	previous := hash()
+	if err := os.Remove(filepath.Join(dir, "path_config.go")); err != nil {
+		t.Fatal(err)
+	}
+	if h := hash(); h == previous {
+		t.Error("expected removing a file to change the hash")
+	}
+}
+
+func TestReloadPluginDev(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/v1/database/config" && r.Method == "LIST":
+			w.Write([]byte(`{"data": {"keys": ["one", "two"]}}`))
+		case r.URL.Path == "/v1/sys/plugins/reload/backend":
+			w.Write([]byte(`{"data": {"reload_id": "abcd"}}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		pluginType consts.PluginType
+		mountPath  string
+		expected   []string
+	}{
+		{
+			consts.PluginTypeSecrets,
+			"my-plugin/",
+			[]string{"PUT /v1/sys/plugins/reload/backend"},
+		},
+		{
+			consts.PluginTypeDatabase,
+			"database/",
+			[]string{
+				"LIST /v1/database/config",
+				"PUT /v1/database/reset/one",
+				"PUT /v1/database/reset/two",
+			},
+		},
+	} {
+		mu.Lock()
+		requests = nil
+		mu.Unlock()
+
+		if err := reloadPluginDev(client, "my-plugin", tc.pluginType, tc.mountPath); err != nil {
+			t.Fatalf("%s: %s", tc.pluginType, err)
+		}
+
+		mu.Lock()
+		sort.Strings(requests)
+		if strings.Join(requests, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: expected requests %v to be %v", tc.pluginType, requests, tc.expected)
+		}
+		mu.Unlock()
+	}
+}
@@ -97,7 +97,7 @@ func (c *PluginInfoCommand) Run(args []string) int {
 		Type: pluginType,
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading plugin named %s: %s", pluginName, err))
+		outputError(c.UI, fmt.Sprintf("Error reading plugin named %s: %s", pluginName, err), err)
 		return 2
 	}
 
@@ -113,6 +113,12 @@ func (c *PluginInfoCommand) Run(args []string) int {
 		"name":    resp.Name,
 		"sha256":  resp.SHA256,
 	}
+	if resp.Version != "" {
+		data["version"] = resp.Version
+	}
+	if resp.DeprecationStatus != "" {
+		data["deprecation_status"] = resp.DeprecationStatus
+	}
 
 	if c.flagField != "" {
 		return PrintRawField(c.UI, data, c.flagField)
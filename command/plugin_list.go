@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/mitchellh/cli"
@@ -16,8 +17,14 @@ var (
 	_ cli.CommandAutocomplete = (*PluginListCommand)(nil)
 )
 
+// pluginListFilters are the filters of vault plugin list.
+var pluginListFilters = []string{"builtin", "deprecated", "external", "mounted", "outdated", "unmounted"}
+
 type PluginListCommand struct {
 	*BaseCommand
+
+	flagDetailed bool
+	flagFilters  []string
 }
 
 func (c *PluginListCommand) Synopsis() string {
@@ -28,9 +35,14 @@ func (c *PluginListCommand) Help() string {
 	helpText := `
 Usage: vault plugin list [options] [TYPE]
 
-  Lists available plugins registered in the catalog. This does not list whether
-  plugins are in use, but rather just their availability. The last argument of
-  type takes "auth", "database", or "secret".
+  Lists available plugins registered in the catalog. The last argument of type
+  takes "auth", "database", or "secret".
+
+  With -detailed, the plugins are listed along with whether they are builtin
+  or external, their version, their deprecation status, the mounts of secrets
+  engines and auth methods using them, and the mounts which run an older
+  registration of them and need to be reloaded. Database plugins are used by
+  the connections of database secrets engines, which are not listed.
 
   List all available plugins in the catalog:
 
@@ -40,13 +52,40 @@ Usage: vault plugin list [options] [TYPE]
 
       $ vault plugin list database
 
+  List the details of the deprecated plugins which are still mounted:
+
+      $ vault plugin list -detailed -filter=deprecated -filter=mounted
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *PluginListCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "detailed",
+		Target:  &c.flagDetailed,
+		Default: false,
+		Usage: "Print the details of the plugins: whether they are builtin, " +
+			"their version and deprecation status, and the mounts using them.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "filter",
+		Target:     &c.flagFilters,
+		Completion: complete.PredictSet(pluginListFilters...),
+		Usage: "Only list the plugins matching the filter, one of " +
+			"\"builtin\", \"deprecated\", \"external\", \"mounted\", " +
+			"\"outdated\" (mounted with an older registration of the plugin), or " +
+			"\"unmounted\". This can be specified multiple times, and the plugins " +
+			"must match every filter.",
+	})
+
+	return set
 }
 
 func (c *PluginListCommand) AutocompleteArgs() complete.Predictor {
@@ -79,23 +118,35 @@ func (c *PluginListCommand) Run(args []string) int {
 			var err error
 			pluginType, err = consts.ParsePluginType(pluginTypeStr)
 			if err != nil {
-				c.UI.Error(fmt.Sprintf("Error parsing type: %s", err))
+				outputError(c.UI, fmt.Sprintf("Error parsing type: %s", err), err)
 				return 2
 			}
 		}
 	}
 
+	for _, filter := range c.flagFilters {
+		if !strutil.StrListContains(pluginListFilters, filter) {
+			c.UI.Error(fmt.Sprintf("Invalid filter %q: expected one of %s", filter,
+				strings.Join(pluginListFilters, ", ")))
+			return 1
+		}
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
+	if c.flagDetailed || len(c.flagFilters) > 0 {
+		return c.listDetails(client, pluginType)
+	}
+
 	resp, err := client.Sys().ListPlugins(&api.ListPluginsInput{
 		Type: pluginType,
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing available plugins: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error listing available plugins: %s", err), err)
 		return 2
 	}
 	if resp == nil {
@@ -127,3 +178,115 @@ func (c *PluginListCommand) Run(args []string) int {
 		return OutputData(c.UI, res)
 	}
 }
+
+// listDetails lists the details of the plugins of the given type, or of every
+// type, which match the filters.
+func (c *PluginListCommand) listDetails(client *api.Client, pluginType consts.PluginType) int {
+	resp, err := client.Sys().ListPlugins(&api.ListPluginsInput{
+		Detailed: true,
+	})
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error listing available plugins: %s", err), err)
+		return 2
+	}
+	if resp == nil || resp.Details == nil {
+		c.UI.Error("No plugin details returned by the server: it may not support -detailed or -filter")
+		return 2
+	}
+
+	var details []api.PluginDetails
+	for _, plugin := range resp.Details {
+		if pluginType != consts.PluginTypeUnknown && plugin.Type != pluginType.String() {
+			continue
+		}
+		if pluginListMatches(plugin, c.flagFilters) {
+			details = append(details, plugin)
+		}
+	}
+
+	if !c.flagDetailed {
+		names := make(map[string][]string)
+		for _, plugin := range details {
+			names[plugin.Type] = append(names[plugin.Type], plugin.Name)
+		}
+		if Format(c.UI) != "table" {
+			return OutputData(c.UI, names)
+		}
+
+		var flattenedNames []string
+		namesAdded := make(map[string]bool)
+		for _, plugin := range details {
+			if !namesAdded[plugin.Name] {
+				flattenedNames = append(flattenedNames, plugin.Name)
+				namesAdded[plugin.Name] = true
+			}
+		}
+		sort.Strings(flattenedNames)
+		c.UI.Output(tableOutput(append([]string{"Plugins"}, flattenedNames...), nil))
+		return 0
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, details)
+	}
+
+	out := []string{"Name | Type | Source | Version | Deprecation Status | Mounts | Outdated Mounts"}
+	for _, plugin := range details {
+		source := "external"
+		if plugin.Builtin {
+			source = "builtin"
+		}
+		deprecationStatus := plugin.DeprecationStatus
+		if deprecationStatus == "" {
+			deprecationStatus = "n/a"
+		}
+		version := plugin.Version
+		if version == "" {
+			version = "n/a"
+		}
+		out = append(out, fmt.Sprintf("%s | %s | %s | %s | %s | %s | %s",
+			plugin.Name,
+			plugin.Type,
+			source,
+			version,
+			deprecationStatus,
+			pluginListMounts(plugin.Mounts),
+			pluginListMounts(plugin.OutdatedMounts),
+		))
+	}
+	c.UI.Output(tableOutput(out, nil))
+	return 0
+}
+
+// pluginListMatches returns whether plugin matches every filter.
+func pluginListMatches(plugin api.PluginDetails, filters []string) bool {
+	for _, filter := range filters {
+		var matches bool
+		switch filter {
+		case "builtin":
+			matches = plugin.Builtin
+		case "deprecated":
+			matches = plugin.DeprecationStatus == "deprecated"
+		case "external":
+			matches = !plugin.Builtin
+		case "mounted":
+			matches = len(plugin.Mounts) > 0
+		case "outdated":
+			matches = len(plugin.OutdatedMounts) > 0
+		case "unmounted":
+			matches = len(plugin.Mounts) == 0
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+// pluginListMounts formats mounts for the table of vault plugin list.
+func pluginListMounts(mounts []string) string {
+	if len(mounts) == 0 {
+		return "n/a"
+	}
+	return strings.Join(mounts, ", ")
+}
@@ -33,6 +33,12 @@ func TestPluginListCommand_Run(t *testing.T) {
 			"Too many arguments",
 			1,
 		},
+		{
+			"invalid_filter",
+			[]string{"-filter=foo"},
+			"Invalid filter",
+			1,
+		},
 		{
 			"lists",
 			nil,
@@ -69,6 +75,59 @@ func TestPluginListCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("detailed", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testPluginListCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-detailed", "database"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		for _, expected := range []string{
+			"Deprecation Status",
+			"mysql-database-plugin",
+			"builtin",
+			"supported",
+		} {
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+		if strings.Contains(combined, "approle") {
+			t.Errorf("expected %q to only list database plugins", combined)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testPluginListCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-filter=deprecated", "-filter=builtin", "secret"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, "cassandra") {
+			t.Errorf("expected %q to contain %q", combined, "cassandra")
+		}
+		if strings.Contains(combined, "transit") {
+			t.Errorf("expected %q to only list deprecated plugins", combined)
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
@@ -21,6 +21,7 @@ type PluginRegisterCommand struct {
 	flagArgs    []string
 	flagCommand string
 	flagSHA256  string
+	flagVersion string
 }
 
 func (c *PluginRegisterCommand) Synopsis() string {
@@ -46,6 +47,11 @@ Usage: vault plugin register [options] TYPE NAME
           -args=--with-glibc,--with-cgo \
           auth my-custom-plugin
 
+  Register a plugin with its version:
+
+      $ vault plugin register -sha256=d3f0a8b... -version=v1.2.0 \
+          secret my-custom-plugin
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -79,6 +85,13 @@ func (c *PluginRegisterCommand) Flags() *FlagSets {
 		Usage:      "SHA256 of the plugin binary. This is required for all plugins.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "version",
+		Target:     &c.flagVersion,
+		Completion: complete.PredictAnything,
+		Usage:      "Semantic version of the plugin, such as \"v1.2.0\". This is optional.",
+	})
+
 	return set
 }
 
@@ -144,8 +157,9 @@ func (c *PluginRegisterCommand) Run(args []string) int {
 		Args:    c.flagArgs,
 		Command: command,
 		SHA256:  c.flagSHA256,
+		Version: c.flagVersion,
 	}); err != nil {
-		c.UI.Error(fmt.Sprintf("Error registering plugin %s: %s", pluginName, err))
+		outputError(c.UI, fmt.Sprintf("Error registering plugin %s: %s", pluginName, err), err)
 		return 2
 	}
 
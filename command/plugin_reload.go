@@ -110,7 +110,7 @@ func (c *PluginReloadCommand) Run(args []string) int {
 		Scope:  c.scope,
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reloading plugin/mounts: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error reloading plugin/mounts: %s", err), err)
 		return 2
 	}
 
@@ -77,7 +77,7 @@ func (c *PluginReloadStatusCommand) Run(args []string) int {
 		ReloadID: reloadId,
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error retrieving plugin reload status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error retrieving plugin reload status: %s", err), err)
 		return 2
 	}
 	out := []string{"Time | Participant | Success | Message "}
@@ -0,0 +1,332 @@
+package command
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*PluginScaffoldCommand)(nil)
+	_ cli.CommandAutocomplete = (*PluginScaffoldCommand)(nil)
+)
+
+// pluginScaffoldTemplates holds the templates of the files generated by vault
+// plugin scaffold. The templates of "common" are generated for every type of
+// plugin, and the templates of "cmd" are generated in "cmd/NAME". The
+// templates use "[[" and "]]" as delimiters, since the generated code can
+// hold Go templates itself.
+//
+//go:embed plugin_scaffold
+var pluginScaffoldTemplates embed.FS
+
+// pluginScaffoldModules are the modules required by the generated plugins.
+// They are required at the versions Vault was built with, so that the plugins
+// use the same SDK.
+var pluginScaffoldModules = []string{
+	"github.com/hashicorp/go-hclog",
+	"github.com/hashicorp/go-secure-stdlib/strutil",
+	"github.com/hashicorp/vault/api",
+	"github.com/hashicorp/vault/sdk",
+}
+
+var pluginScaffoldNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+type PluginScaffoldCommand struct {
+	*BaseCommand
+
+	flagType   string
+	flagModule string
+	flagOutput string
+
+	testModuleVersions map[string]string // for tests
+}
+
+// pluginScaffoldData is the data the templates of vault plugin scaffold are
+// executed with.
+type pluginScaffoldData struct {
+	Name     string
+	Type     string
+	Package  string
+	Module   string
+	Requires []pluginScaffoldRequire
+}
+
+type pluginScaffoldRequire struct {
+	Path    string
+	Version string
+}
+
+func (c *PluginScaffoldCommand) Synopsis() string {
+	return "Generates the skeleton of an external plugin"
+}
+
+func (c *PluginScaffoldCommand) Help() string {
+	helpText := `
+Usage: vault plugin scaffold [options] NAME
+
+  Generates the skeleton of an external plugin named NAME, which builds and
+  runs as is. The type of the plugin is given with -type, and takes "auth",
+  "database", or "secret".
+
+  The skeleton is a Go module which requires the version of the Vault SDK this
+  Vault binary was built with. It holds the backend of the plugin with an
+  example configuration endpoint, its tests, the main package in cmd/NAME, and
+  a Makefile to build the plugin and to run it in a Vault dev server. Database
+  plugins are served with multiplexing, so that a single plugin process serves
+  all of the connections using the plugin.
+
+  Generate a secrets engine plugin in ./vault-plugin-secrets-example:
+
+      $ vault plugin scaffold -type=secret vault-plugin-secrets-example
+
+  Generate an auth method plugin with a given module path:
+
+      $ vault plugin scaffold -type=auth \
+          -module=github.com/example/vault-plugin-auth-example \
+          vault-plugin-auth-example
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PluginScaffoldCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetNone)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "type",
+		Target:     &c.flagType,
+		Default:    "secret",
+		Completion: complete.PredictSet("auth", "database", "secret"),
+		Usage:      `Type of the plugin, one of "auth", "database", or "secret".`,
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "module",
+		Target:     &c.flagModule,
+		Completion: complete.PredictAnything,
+		Usage:      "Go module path of the plugin. This defaults to NAME.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "output",
+		Target:     &c.flagOutput,
+		Completion: complete.PredictDirs("*"),
+		Usage: "Directory to generate the plugin in. It must not exist, or be " +
+			"empty. This defaults to NAME in the current directory.",
+	})
+
+	return set
+}
+
+func (c *PluginScaffoldCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *PluginScaffoldCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PluginScaffoldCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	name := args[0]
+	if !pluginScaffoldNameRegex.MatchString(name) {
+		c.UI.Error(fmt.Sprintf("Invalid plugin name %q: it may only contain letters, "+
+			"digits, '_', '.' and '-'", name))
+		return 1
+	}
+	switch c.flagType {
+	case "auth", "database", "secret":
+	default:
+		c.UI.Error(fmt.Sprintf("Invalid plugin type %q: expected \"auth\", \"database\", "+
+			"or \"secret\"", c.flagType))
+		return 1
+	}
+
+	data := &pluginScaffoldData{
+		Name:    name,
+		Type:    c.flagType,
+		Package: pluginScaffoldPackage(name),
+		Module:  c.flagModule,
+	}
+	if data.Module == "" {
+		data.Module = name
+	}
+
+	versions := c.testModuleVersions
+	if versions == nil {
+		versions = pluginScaffoldModuleVersions()
+	}
+	for _, module := range pluginScaffoldModules {
+		if version := versions[module]; version != "" {
+			data.Requires = append(data.Requires, pluginScaffoldRequire{Path: module, Version: version})
+		}
+	}
+	if len(data.Requires) < len(pluginScaffoldModules) {
+		c.UI.Warn("WARNING! The versions of the modules this Vault binary was built " +
+			"with are unknown, so the latest versions are required instead.")
+	}
+
+	dir := c.flagOutput
+	if dir == "" {
+		dir = name
+	}
+	dir, err := homedir.Expand(dir)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+		return 1
+	}
+	if entries, err := ioutil.ReadDir(dir); err == nil && len(entries) > 0 {
+		c.UI.Error(fmt.Sprintf("Directory %s is not empty", dir))
+		return 1
+	}
+
+	files, err := generatePluginScaffold(data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error generating plugin: %s", err), err)
+		return 1
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		target := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			outputError(c.UI, fmt.Sprintf("Error creating directory: %s", err), err)
+			return 1
+		}
+		if err := ioutil.WriteFile(target, files[p], 0o644); err != nil {
+			outputError(c.UI, fmt.Sprintf("Error writing %s: %s", target, err), err)
+			return 1
+		}
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Generated %s plugin %q in %s:", c.flagType, name, dir))
+	c.UI.Output("")
+	for _, p := range paths {
+		c.UI.Output(fmt.Sprintf("  %s", p))
+	}
+	c.UI.Output("")
+	c.UI.Output(wrapAtLength(fmt.Sprintf("Run \"make build\" in %s to build the plugin "+
+		"into bin/, and \"make dev\" to run it in a Vault dev server.", dir)))
+	return 0
+}
+
+// generatePluginScaffold executes the templates of the type of plugin of data,
+// and returns the generated files by their slash-separated path. The generated
+// Go files are formatted.
+func generatePluginScaffold(data *pluginScaffoldData) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, dir := range []string{"common", data.Type} {
+		root := path.Join("plugin_scaffold", dir)
+		err := fs.WalkDir(pluginScaffoldTemplates, root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			b, err := pluginScaffoldTemplates.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			tmpl, err := template.New(p).Delims("[[", "]]").Parse(string(b))
+			if err != nil {
+				return err
+			}
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return err
+			}
+
+			target := strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".tmpl")
+			if strings.HasPrefix(target, "cmd/") {
+				target = path.Join("cmd", data.Name, strings.TrimPrefix(target, "cmd/"))
+			}
+			out := buf.Bytes()
+			if strings.HasSuffix(target, ".go") {
+				if out, err = format.Source(out); err != nil {
+					return fmt.Errorf("error formatting %s: %w", target, err)
+				}
+			}
+			files[target] = out
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// pluginScaffoldPackage returns the name of the Go package of the plugin
+// named name, dropping the usual "vault-plugin-<type>-" prefix.
+func pluginScaffoldPackage(name string) string {
+	pkg := strings.ToLower(name)
+	pkg = strings.TrimPrefix(pkg, "vault-plugin-")
+	for _, prefix := range []string{"secrets-", "auth-", "database-"} {
+		pkg = strings.TrimPrefix(pkg, prefix)
+	}
+	pkg = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, pkg)
+	if pkg == "" || pkg == "main" || token.IsKeyword(pkg) || (pkg[0] >= '0' && pkg[0] <= '9') {
+		pkg = "plugin" + pkg
+	}
+	return pkg
+}
+
+// pluginScaffoldModuleVersions returns the versions of the modules this binary
+// was built with, by their path.
+func pluginScaffoldModuleVersions() map[string]string {
+	versions := make(map[string]string)
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return versions
+	}
+	for _, dep := range info.Deps {
+		if dep.Version != "" && dep.Version != "(devel)" {
+			versions[dep.Path] = dep.Version
+		}
+	}
+	return versions
+}
@@ -0,0 +1,215 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testPluginScaffoldCommand(tb testing.TB) (*cli.MockUi, *PluginScaffoldCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PluginScaffoldCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+		testModuleVersions: map[string]string{
+			"github.com/hashicorp/go-hclog":                 "v1.1.0",
+			"github.com/hashicorp/go-secure-stdlib/strutil": "v0.1.2",
+			"github.com/hashicorp/vault/api":                "v1.4.1",
+			"github.com/hashicorp/vault/sdk":                "v0.4.1",
+		},
+	}
+}
+
+func TestPluginScaffoldCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"invalid_name",
+			[]string{"../foo"},
+			"Invalid plugin name",
+			1,
+		},
+		{
+			"invalid_type",
+			[]string{"-type=audit", "foo"},
+			"Invalid plugin type",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testPluginScaffoldCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	for _, tc := range []struct {
+		pluginType string
+		name       string
+		files      []string
+		main       string
+	}{
+		{
+			"secret",
+			"vault-plugin-secrets-example",
+			[]string{"backend.go", "backend_test.go", "path_config.go"},
+			"plugin.Serve(",
+		},
+		{
+			"auth",
+			"vault-plugin-auth-example",
+			[]string{"backend.go", "backend_test.go", "path_config.go", "path_login.go"},
+			"plugin.Serve(",
+		},
+		{
+			"database",
+			"vault-plugin-database-example",
+			[]string{"database.go", "database_test.go"},
+			"dbplugin.ServeMultiplex(example.New)",
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.pluginType, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := testPluginScaffoldCommand(t)
+
+			dir := filepath.Join(t.TempDir(), tc.name)
+			code := cmd.Run([]string{
+				"-type=" + tc.pluginType,
+				"-module=github.com/example/" + tc.name,
+				"-output=" + dir,
+				tc.name,
+			})
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+
+			files := append([]string{"Makefile", "README.md", "go.mod", "cmd/" + tc.name + "/main.go"}, tc.files...)
+			for _, file := range files {
+				if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+					t.Errorf("expected %s to be generated: %s", file, err)
+				}
+				if !strings.Contains(ui.OutputWriter.String(), "  "+file+"\n") {
+					t.Errorf("expected %s to be listed", file)
+				}
+			}
+
+			goMod, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range []string{
+				"module github.com/example/" + tc.name + "\n",
+				"\tgithub.com/hashicorp/vault/sdk v0.4.1\n",
+			} {
+				if !strings.Contains(string(goMod), expected) {
+					t.Errorf("expected %q to contain %q", goMod, expected)
+				}
+			}
+
+			mainGo, err := ioutil.ReadFile(filepath.Join(dir, "cmd", tc.name, "main.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range []string{
+				`example "github.com/example/` + tc.name + `"`,
+				tc.main,
+			} {
+				if !strings.Contains(string(mainGo), expected) {
+					t.Errorf("expected %q to contain %q", mainGo, expected)
+				}
+			}
+		})
+	}
+
+	t.Run("not_empty", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testPluginScaffoldCommand(t)
+
+		code := cmd.Run([]string{"-output=" + dir, "example"})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "is not empty"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPluginScaffoldCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestPluginScaffoldPackage(t *testing.T) {
+	t.Parallel()
+
+	for name, expected := range map[string]string{
+		"vault-plugin-secrets-my-engine": "myengine",
+		"vault-plugin-auth-Example":      "example",
+		"my_plugin.v2":                   "mypluginv2",
+		"vault-plugin-database-":         "plugin",
+		"2fa":                            "plugin2fa",
+		"func":                           "pluginfunc",
+	} {
+		if pkg := pluginScaffoldPackage(name); pkg != expected {
+			t.Errorf("expected package of %q to be %q, got %q", name, expected, pkg)
+		}
+	}
+}
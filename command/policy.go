@@ -1,9 +1,13 @@
 package command
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 
 	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
 )
 
 var _ cli.Command = (*PolicyCommand)(nil)
@@ -36,6 +40,10 @@ Usage: vault policy <subcommand> [options] [args]
 
       $ vault policy delete my-policy
 
+  Check a policy on local disk for problems before writing it:
+
+      $ vault policy lint ./my-policy.hcl
+
   Please see the individual subcommand help for detailed usage information.
 `
 
@@ -45,3 +53,41 @@ Usage: vault policy <subcommand> [options] [args]
 func (c *PolicyCommand) Run(args []string) int {
 	return cli.RunResultHelp
 }
+
+// readPolicySource returns the rules of the policy in the local file at source
+// or, if there is no such file, of the policy named source in Vault, together
+// with the name of the policy. It returns a non-zero exit code on failure.
+func readPolicySource(c *BaseCommand, source string) (string, string, int) {
+	path, err := homedir.Expand(source)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+		return "", "", 1
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading source file: %s", err), err)
+			return "", "", 1
+		}
+		return string(b), source, 0
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return "", "", 2
+	}
+
+	name := strings.ToLower(source)
+	rules, err := client.Sys().GetPolicy(name)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading policy named %s: %s", name, err), err)
+		return "", "", 2
+	}
+	if rules == "" {
+		c.UI.Error(fmt.Sprintf("No policy file or policy named: %s", source))
+		return "", "", 2
+	}
+	return rules, name, 0
+}
@@ -78,7 +78,7 @@ func (c *PolicyDeleteCommand) Run(args []string) int {
 
 	name := strings.TrimSpace(strings.ToLower(args[0]))
 	if err := client.Sys().DeletePolicy(name); err != nil {
-		c.UI.Error(fmt.Sprintf("Error deleting %s: %s", name, err))
+		outputError(c.UI, fmt.Sprintf("Error deleting %s: %s", name, err), err)
 		return 2
 	}
 
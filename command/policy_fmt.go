@@ -76,7 +76,7 @@ func (c *PolicyFmtCommand) Run(args []string) int {
 	// Get the filepath, accounting for ~ and stuff
 	path, err := homedir.Expand(strings.TrimSpace(args[0]))
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to expand path: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
 		return 1
 	}
 
@@ -84,7 +84,7 @@ func (c *PolicyFmtCommand) Run(args []string) int {
 	// a buffer, but hcl wants the full contents.
 	b, err := ioutil.ReadFile(path)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading source file: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error reading source file: %s", err), err)
 		return 1
 	}
 
@@ -98,13 +98,13 @@ func (c *PolicyFmtCommand) Run(args []string) int {
 	// Generate final contents
 	result, err := printer.Format(b)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error printing result: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error printing result: %s", err), err)
 		return 1
 	}
 
 	// Write them back out
 	if err := ioutil.WriteFile(path, result, 0o644); err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing result: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error writing result: %s", err), err)
 		return 1
 	}
 
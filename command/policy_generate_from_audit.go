@@ -0,0 +1,302 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/printer"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*PolicyGenerateFromAuditCommand)(nil)
+	_ cli.CommandAutocomplete = (*PolicyGenerateFromAuditCommand)(nil)
+)
+
+// policyAuditCapabilities maps the operations found in audit logs to the
+// capability they require. Operations which no policy can grant, such as help,
+// are not included.
+var policyAuditCapabilities = map[logical.Operation]string{
+	logical.CreateOperation:   vault.CreateCapability,
+	logical.ReadOperation:     vault.ReadCapability,
+	logical.UpdateOperation:   vault.UpdateCapability,
+	logical.PatchOperation:    vault.PatchCapability,
+	logical.DeleteOperation:   vault.DeleteCapability,
+	logical.ListOperation:     vault.ListCapability,
+	logical.RevokeOperation:   vault.UpdateCapability,
+	logical.RenewOperation:    vault.UpdateCapability,
+	logical.RollbackOperation: vault.UpdateCapability,
+}
+
+// policyCapabilityOrder is the order capabilities are written in generated
+// policies.
+var policyCapabilityOrder = []string{
+	vault.CreateCapability,
+	vault.ReadCapability,
+	vault.UpdateCapability,
+	vault.PatchCapability,
+	vault.DeleteCapability,
+	vault.ListCapability,
+}
+
+type PolicyGenerateFromAuditCommand struct {
+	*BaseCommand
+
+	flagEntityID      string
+	flagAccessor      string
+	flagAuditDevice   string
+	flagIncludeDenied bool
+
+	testStdin io.Reader // for tests
+}
+
+func (c *PolicyGenerateFromAuditCommand) Synopsis() string {
+	return "Generates a policy from the requests in audit logs"
+}
+
+func (c *PolicyGenerateFromAuditCommand) Help() string {
+	helpText := `
+Usage: vault policy generate-from-audit [options] PATH...
+
+  Reads the audit log files at PATH, which are written by the file audit
+  device, and prints a policy which grants exactly the capabilities needed by
+  the requests of an entity or a token. If PATH is "-", the audit log is read
+  from stdin.
+
+  Generate a policy from the requests of an entity:
+
+      $ vault policy generate-from-audit -entity-id=7d2e3179-... audit.log
+
+  Generate a policy from the requests of a token, whose accessor is hashed in
+  the audit log by the audit device enabled at "file/":
+
+      $ vault policy generate-from-audit -accessor=hQZ2... -audit-device=file \
+          audit.log
+
+  Every path is granted exactly as it was requested, and requests which were
+  denied are skipped. Review the policy before using it: it does not include
+  sudo, which audit logs do not record the need for, and paths with IDs in
+  them may have to be replaced by globs or templated parameters.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PolicyGenerateFromAuditCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "entity-id",
+		Target:     &c.flagEntityID,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage:      "Only use the requests made by this entity.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "accessor",
+		Target:     &c.flagAccessor,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Only use the requests made with the token with this accessor, " +
+			"either as it is or as it is hashed in the audit log.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "audit-device",
+		Target:     &c.flagAuditDevice,
+		Default:    "",
+		Completion: c.PredictVaultAudits(),
+		Usage: "Path of the audit device which wrote the audit log. If given, " +
+			"Vault is asked to hash the value of -accessor the way this device " +
+			"does, so it can be found in the audit log.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "include-denied",
+		Target:  &c.flagIncludeDenied,
+		Default: false,
+		Usage:   "Also grant the requests which were denied.",
+	})
+
+	return set
+}
+
+func (c *PolicyGenerateFromAuditCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *PolicyGenerateFromAuditCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PolicyGenerateFromAuditCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1 or more, got %d)", len(args)))
+		return 1
+	}
+
+	switch {
+	case c.flagEntityID == "" && c.flagAccessor == "":
+		c.UI.Error("One of -entity-id or -accessor must be given")
+		return 1
+	case c.flagEntityID != "" && c.flagAccessor != "":
+		c.UI.Error("Only one of -entity-id or -accessor can be given")
+		return 1
+	case c.flagAuditDevice != "" && c.flagAccessor == "":
+		c.UI.Error("-audit-device can only be given with -accessor")
+		return 1
+	}
+
+	accessors := map[string]bool{}
+	if c.flagAccessor != "" {
+		accessors[c.flagAccessor] = true
+	}
+	if c.flagAuditDevice != "" {
+		client, err := c.Client()
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+
+		hashed, err := client.Sys().AuditHash(ensureNoTrailingSlash(sanitizePath(c.flagAuditDevice)), c.flagAccessor)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error hashing accessor: %s", err), err)
+			return 2
+		}
+		accessors[hashed] = true
+	}
+
+	match := func(auth *audit.AuditAuth) bool {
+		if auth == nil {
+			return false
+		}
+		if c.flagEntityID != "" {
+			return auth.EntityID == c.flagEntityID
+		}
+		return accessors[auth.Accessor]
+	}
+
+	// capabilities maps paths to the capabilities requested on them.
+	capabilities := map[string]map[string]bool{}
+	var requests int
+	for _, arg := range args {
+		var r io.Reader
+		switch arg {
+		case "-":
+			r = os.Stdin
+			if c.testStdin != nil {
+				r = c.testStdin
+			}
+		default:
+			path, err := homedir.Expand(strings.TrimSpace(arg))
+			if err != nil {
+				outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+				return 1
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				outputError(c.UI, fmt.Sprintf("Error opening audit log: %s", err), err)
+				return 1
+			}
+			defer file.Close()
+			r = file
+		}
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			// Skip anything before the JSON object, such as the prefix the
+			// audit device may be configured with.
+			line := scanner.Bytes()
+			i := bytes.IndexByte(line, '{')
+			if i < 0 {
+				continue
+			}
+
+			var entry audit.AuditResponseEntry
+			if err := json.Unmarshal(line[i:], &entry); err != nil {
+				continue
+			}
+			if entry.Type != "response" || entry.Request == nil || !match(entry.Auth) {
+				continue
+			}
+			if !c.flagIncludeDenied && strings.Contains(entry.Error, logical.ErrPermissionDenied.Error()) {
+				continue
+			}
+
+			capability, ok := policyAuditCapabilities[entry.Request.Operation]
+			if !ok {
+				continue
+			}
+
+			path := entry.Request.Path
+			if entry.Request.Namespace != nil {
+				path = entry.Request.Namespace.Path + path
+			}
+			if capabilities[path] == nil {
+				capabilities[path] = map[string]bool{}
+			}
+			capabilities[path][capability] = true
+			requests++
+		}
+		if err := scanner.Err(); err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading audit log: %s", err), err)
+			return 1
+		}
+	}
+
+	if requests == 0 {
+		c.UI.Error("No matching requests found in the audit log")
+		return 2
+	}
+
+	paths := make([]string, 0, len(capabilities))
+	for path := range capabilities {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated from %d request(s) in the audit log.\n", requests)
+	for _, path := range paths {
+		granted := []string{}
+		for _, capability := range policyCapabilityOrder {
+			if capabilities[path][capability] {
+				granted = append(granted, fmt.Sprintf("%q", capability))
+			}
+		}
+		fmt.Fprintf(&b, "\npath %q {\n  capabilities = [%s]\n}\n", path, strings.Join(granted, ", "))
+	}
+
+	result, err := printer.Format([]byte(b.String()))
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error printing result: %s", err), err)
+		return 1
+	}
+
+	c.UI.Output(strings.TrimSpace(string(result)))
+	return 0
+}
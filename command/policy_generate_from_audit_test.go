@@ -0,0 +1,234 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testPolicyGenerateFromAuditCommand(tb testing.TB) (*cli.MockUi, *PolicyGenerateFromAuditCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PolicyGenerateFromAuditCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestPolicyGenerateFromAuditCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"-entity-id=foo"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"no_identity",
+			[]string{"audit.log"},
+			"One of -entity-id or -accessor must be given",
+			1,
+		},
+		{
+			"both_identities",
+			[]string{"-entity-id=foo", "-accessor=bar", "audit.log"},
+			"Only one of -entity-id or -accessor can be given",
+			1,
+		},
+		{
+			"audit_device_without_accessor",
+			[]string{"-entity-id=foo", "-audit-device=file", "audit.log"},
+			"-audit-device can only be given with -accessor",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testPolicyGenerateFromAuditCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("entity_stdin", func(t *testing.T) {
+		t.Parallel()
+
+		log := strings.Join([]string{
+			`{"type":"request","auth":{"entity_id":"e1"},"request":{"operation":"read","path":"secret/foo"}}`,
+			`{"type":"response","auth":{"entity_id":"e1"},"request":{"operation":"read","path":"secret/foo"}}`,
+			`{"type":"response","auth":{"entity_id":"e1"},"request":{"operation":"update","path":"secret/foo"}}`,
+			`{"type":"response","auth":{"entity_id":"e1"},"request":{"operation":"list","path":"secret/"}}`,
+			`{"type":"response","auth":{"entity_id":"e1"},"request":{"operation":"delete","path":"secret/bar"},"error":"1 error occurred:\n\t* permission denied\n\n"}`,
+			`{"type":"response","auth":{"entity_id":"e1"},"request":{"operation":"help","path":"secret/bar"}}`,
+			`{"type":"response","auth":{"entity_id":"e2"},"request":{"operation":"read","path":"secret/baz"}}`,
+			`prefix{"type":"response","auth":{"entity_id":"e1"},"request":{"operation":"read","path":"kv/app","namespace":{"id":"abc","path":"team/"}}}`,
+			`not json`,
+		}, "\n")
+
+		ui, cmd := testPolicyGenerateFromAuditCommand(t)
+		cmd.testStdin = strings.NewReader(log)
+
+		code := cmd.Run([]string{"-entity-id=e1", "-"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := `# Generated from 4 request(s) in the audit log.
+
+path "secret/" {
+  capabilities = ["list"]
+}
+
+path "secret/foo" {
+  capabilities = ["read", "update"]
+}
+
+path "team/kv/app" {
+  capabilities = ["read"]
+}
+`
+		if output := ui.OutputWriter.String(); output != expected {
+			t.Errorf("expected %q to be %q", output, expected)
+		}
+	})
+
+	t.Run("include_denied", func(t *testing.T) {
+		t.Parallel()
+
+		log := `{"type":"response","auth":{"entity_id":"e1"},"request":{"operation":"delete","path":"secret/bar"},"error":"permission denied"}`
+
+		ui, cmd := testPolicyGenerateFromAuditCommand(t)
+		cmd.testStdin = strings.NewReader(log)
+
+		code := cmd.Run([]string{"-entity-id=e1", "-include-denied", "-"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := `path "secret/bar" {
+  capabilities = ["delete"]
+}`
+		if output := ui.OutputWriter.String(); !strings.Contains(output, expected) {
+			t.Errorf("expected %q to contain %q", output, expected)
+		}
+	})
+
+	t.Run("no_requests", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testPolicyGenerateFromAuditCommand(t)
+		cmd.testStdin = strings.NewReader("")
+
+		code := cmd.Run([]string{"-entity-id=e1", "-"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "No matching requests found"
+		if combined := ui.OutputWriter.String() + ui.ErrorWriter.String(); !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("hashed_accessor", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		dir, err := ioutil.TempDir("", "vault-audit")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		auditLog := filepath.Join(dir, "audit.log")
+
+		if err := client.Sys().EnableAuditWithOptions("file", &api.EnableAuditOptions{
+			Type: "file",
+			Options: map[string]string{
+				"file_path": auditLog,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := client.Sys().PutPolicy("app", `path "secret/*" { capabilities = ["create", "read", "update", "list"] }`); err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+			Policies: []string{"app"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		appClient, err := client.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		appClient.SetToken(secret.Auth.ClientToken)
+		if _, err := appClient.Logical().Write("secret/app", map[string]interface{}{"foo": "bar"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := appClient.Logical().Read("secret/app"); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testPolicyGenerateFromAuditCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-accessor=" + secret.Auth.Accessor,
+			"-audit-device=file",
+			auditLog,
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := `path "secret/app" {
+  capabilities = ["create", "read"]
+}`
+		if output := ui.OutputWriter.String(); !strings.Contains(output, expected) {
+			t.Errorf("expected %q to contain %q", output, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPolicyGenerateFromAuditCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
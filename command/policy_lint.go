@@ -0,0 +1,334 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*PolicyLintCommand)(nil)
+	_ cli.CommandAutocomplete = (*PolicyLintCommand)(nil)
+)
+
+type PolicyLintCommand struct {
+	*BaseCommand
+}
+
+func (c *PolicyLintCommand) Synopsis() string {
+	return "Checks policies on disk for problems"
+}
+
+func (c *PolicyLintCommand) Help() string {
+	helpText := `
+Usage: vault policy lint [options] PATH...
+
+  Checks local policy files for problems, without contacting Vault. Besides
+  errors which would make Vault reject the policy, such as unknown
+  capabilities, this reports:
+
+    - rules which grant capabilities that are ignored because of "deny"
+    - rules which override the "deny" of a broader glob, since only the most
+      specific rule matching a path applies
+    - paths defined more than once, whose rules are merged
+    - "*" characters which are not at the end of a path, which are not a
+      wildcard there
+    - templated parameters which can never be resolved
+    - the deprecated "policy" field
+
+  Every problem is printed with the file and line it was found at. The command
+  exits with status 2 if any problem was found.
+
+  Check the local files "app.hcl" and "ops.hcl":
+
+      $ vault policy lint app.hcl ops.hcl
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PolicyLintCommand) Flags() *FlagSets {
+	return c.flagSet(FlagSetNone)
+}
+
+func (c *PolicyLintCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*.hcl")
+}
+
+func (c *PolicyLintCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PolicyLintCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1 or more, got %d)", len(args)))
+		return 1
+	}
+
+	var problems int
+	for _, arg := range args {
+		path, err := homedir.Expand(strings.TrimSpace(arg))
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Failed to expand path: %s", err), err)
+			return 1
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading source file: %s", err), err)
+			return 1
+		}
+
+		for _, p := range lintPolicy(string(b)) {
+			problems++
+			c.UI.Warn(fmt.Sprintf("%s:%d: %s: %s", path, p.Line, p.Severity, p.Message))
+		}
+	}
+
+	if problems > 0 {
+		c.UI.Error(fmt.Sprintf("Found %d problem(s)", problems))
+		return 2
+	}
+
+	c.UI.Output("Success! No problems found")
+	return 0
+}
+
+const (
+	policyLintError   = "error"
+	policyLintWarning = "warning"
+)
+
+// policyLintProblem is a problem found in a policy by lintPolicy.
+type policyLintProblem struct {
+	Line     int
+	Severity string
+	Message  string
+}
+
+// policyLintRule is a path rule of a policy, as far as lintPolicy is
+// concerned.
+type policyLintRule struct {
+	line         int
+	path         string
+	capabilities []string
+	policy       string
+}
+
+// lintPolicy returns the problems found in the given ACL policy, sorted by
+// line.
+func lintPolicy(rules string) []policyLintProblem {
+	root, err := hcl.Parse(rules)
+	if err != nil {
+		return []policyLintProblem{{Severity: policyLintError, Message: fmt.Sprintf("failed to parse policy: %s", err)}}
+	}
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return []policyLintProblem{{Severity: policyLintError, Message: "failed to parse policy: does not contain a root object"}}
+	}
+
+	var problems []policyLintProblem
+	report := func(line int, severity, format string, a ...interface{}) {
+		problems = append(problems, policyLintProblem{Line: line, Severity: severity, Message: fmt.Sprintf(format, a...)})
+	}
+
+	var pathRules []*policyLintRule
+	for _, item := range list.Filter("path").Items {
+		rule := &policyLintRule{line: item.Pos().Line}
+		if len(item.Keys) > 0 {
+			rule.line = item.Keys[0].Pos().Line
+			rule.path, _ = item.Keys[0].Token.Value().(string)
+		}
+		rule.path = strings.TrimPrefix(rule.path, "/")
+
+		var decoded struct {
+			Capabilities []string `hcl:"capabilities"`
+			Policy       string   `hcl:"policy"`
+		}
+		if err := hcl.DecodeObject(&decoded, item.Val); err != nil {
+			report(rule.line, policyLintError, "path %q: %s", rule.path, err)
+			continue
+		}
+		rule.capabilities, rule.policy = decoded.Capabilities, decoded.Policy
+		pathRules = append(pathRules, rule)
+	}
+
+	seen := make(map[string]*policyLintRule)
+	for _, rule := range pathRules {
+		if first, ok := seen[rule.path]; ok {
+			report(rule.line, policyLintWarning, "path %q is also defined on line %d; the rules are merged", rule.path, first.line)
+		} else {
+			seen[rule.path] = rule
+		}
+
+		if rule.policy != "" {
+			report(rule.line, policyLintWarning, "path %q: the \"policy\" field is deprecated, use \"capabilities\" instead", rule.path)
+		}
+		if rule.policy == "" && len(rule.capabilities) == 0 {
+			report(rule.line, policyLintWarning, "path %q grants no capabilities", rule.path)
+		}
+
+		var deny bool
+		for _, capability := range rule.capabilities {
+			switch capability {
+			case vault.DenyCapability:
+				deny = true
+			case vault.CreateCapability, vault.ReadCapability, vault.UpdateCapability, vault.DeleteCapability,
+				vault.ListCapability, vault.SudoCapability, vault.PatchCapability:
+			default:
+				report(rule.line, policyLintError, "path %q: unknown capability %q", rule.path, capability)
+			}
+		}
+		if deny && len(rule.capabilities) > 1 {
+			report(rule.line, policyLintWarning, "path %q: capabilities other than \"deny\" are ignored", rule.path)
+		}
+
+		if i := strings.Index(rule.path, "*"); i >= 0 && i != len(rule.path)-1 {
+			report(rule.line, policyLintWarning, "path %q: \"*\" is only a wildcard at the end of a path and is matched literally elsewhere", rule.path)
+		}
+
+		for _, directive := range policyTemplateDirectives(rule.path) {
+			if err := checkPolicyTemplateDirective(directive); err != nil {
+				report(rule.line, policyLintError, "path %q: template {{%s}} can never be resolved: %s", rule.path, directive, err)
+			}
+		}
+	}
+
+	// Only the most specific rule matching a request path applies, so a
+	// narrower rule grants its capabilities even below a denied glob.
+	for _, denied := range pathRules {
+		if !isPolicyLintDeny(denied) || !strings.HasSuffix(denied.path, "*") || strings.Contains(denied.path, "+") {
+			continue
+		}
+		prefix := strings.TrimSuffix(denied.path, "*")
+		for _, rule := range pathRules {
+			if rule == denied || isPolicyLintDeny(rule) || strings.Contains(rule.path, "+") {
+				continue
+			}
+			if strings.HasPrefix(rule.path, prefix) && len(strings.TrimSuffix(rule.path, "*")) > len(prefix) {
+				report(rule.line, policyLintWarning, "path %q overrides the deny of %q on line %d; only the most specific rule matching a path applies", rule.path, denied.path, denied.line)
+			}
+		}
+	}
+
+	// Report anything else Vault would reject.
+	if _, err := vault.ParseACLPolicy(namespace.RootNamespace, rules); err != nil {
+		var reported bool
+		for _, p := range problems {
+			reported = reported || p.Severity == policyLintError
+		}
+		if !reported {
+			report(0, policyLintError, "%s", err)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems
+}
+
+func isPolicyLintDeny(rule *policyLintRule) bool {
+	if rule.policy == vault.OldDenyPathPolicy {
+		return true
+	}
+	for _, capability := range rule.capabilities {
+		if capability == vault.DenyCapability {
+			return true
+		}
+	}
+	return false
+}
+
+// policyTemplateDirectives returns the directives between "{{" and "}}" in s.
+func policyTemplateDirectives(s string) []string {
+	var directives []string
+	for {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			return directives
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return directives
+		}
+		directives = append(directives, strings.TrimSpace(s[start+2:start+end]))
+		s = s[start+end+2:]
+	}
+}
+
+// checkPolicyTemplateDirective returns an error if the ACL template
+// directive can never be resolved to a string, whatever the entity.
+func checkPolicyTemplateDirective(directive string) error {
+	switch {
+	case strings.HasPrefix(directive, "identity.entity."):
+		selector := strings.TrimPrefix(directive, "identity.entity.")
+		switch {
+		case selector == "id", selector == "name":
+			return nil
+		case strings.HasPrefix(selector, "metadata.") && selector != "metadata.":
+			return nil
+		case selector == "metadata", selector == "groups.names", selector == "groups.ids":
+			return fmt.Errorf("%q is not a single value", selector)
+		case strings.HasPrefix(selector, "aliases."):
+			parts := strings.SplitN(strings.TrimPrefix(selector, "aliases."), ".", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return fmt.Errorf("expected aliases.<mount accessor>.<field>")
+			}
+			return checkPolicyTemplateField(parts[1], "id", "name", "metadata.", "custom_metadata.")
+		}
+		return fmt.Errorf("unknown entity selector %q", selector)
+
+	case strings.HasPrefix(directive, "identity.groups."):
+		parts := strings.SplitN(strings.TrimPrefix(directive, "identity.groups."), ".", 3)
+		if len(parts) != 3 || (parts[0] != "ids" && parts[0] != "names") || parts[1] == "" {
+			return fmt.Errorf("expected identity.groups.ids.<group id>.<field> or identity.groups.names.<group name>.<field>")
+		}
+		return checkPolicyTemplateField(parts[2], "id", "name", "metadata.")
+
+	case directive == "time.now":
+		return nil
+
+	case strings.HasPrefix(directive, "time.now."):
+		parts := strings.SplitN(strings.TrimPrefix(directive, "time.now."), ".", 2)
+		if len(parts) != 2 || (parts[0] != "plus" && parts[0] != "minus") {
+			return fmt.Errorf("expected time.now.plus.<duration> or time.now.minus.<duration>")
+		}
+		if _, err := time.ParseDuration(parts[1]); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown template parameter")
+}
+
+// checkPolicyTemplateField returns an error unless field is one of fields,
+// where a field ending in "." requires a non-empty key after it.
+func checkPolicyTemplateField(field string, fields ...string) error {
+	for _, f := range fields {
+		if field == f || (strings.HasSuffix(f, ".") && strings.HasPrefix(field, f) && len(field) > len(f)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown field %q", field)
+}
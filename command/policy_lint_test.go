@@ -0,0 +1,198 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testPolicyLintCommand(tb testing.TB) (*cli.MockUi, *PolicyLintCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PolicyLintCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestPolicyLintCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		policy string
+		out    []string
+		code   int
+	}{
+		{
+			"clean",
+			`
+path "secret/data/{{identity.entity.id}}/*" {
+  capabilities = ["create", "read", "update"]
+}
+
+path "sys/*" {
+  capabilities = ["deny"]
+}
+`,
+			[]string{"Success! No problems found"},
+			0,
+		},
+		{
+			"unknown_capability",
+			`
+path "secret/*" {
+  capabilities = ["read", "bogus"]
+}
+`,
+			[]string{`:2: error: path "secret/*": unknown capability "bogus"`},
+			2,
+		},
+		{
+			"deny_override",
+			`
+path "secret/*" {
+  capabilities = ["deny"]
+}
+
+path "secret/app/*" {
+  capabilities = ["read"]
+}
+`,
+			[]string{`:6: warning: path "secret/app/*" overrides the deny of "secret/*" on line 2`},
+			2,
+		},
+		{
+			"deny_with_capabilities",
+			`
+path "secret/*" {
+  capabilities = ["deny", "read"]
+}
+`,
+			[]string{`:2: warning: path "secret/*": capabilities other than "deny" are ignored`},
+			2,
+		},
+		{
+			"duplicate",
+			`
+path "secret/foo" {
+  capabilities = ["read"]
+}
+
+path "/secret/foo" {
+  capabilities = ["update"]
+}
+`,
+			[]string{`:6: warning: path "secret/foo" is also defined on line 2`},
+			2,
+		},
+		{
+			"inner_glob",
+			`
+path "secret/*/foo" {
+  capabilities = ["read"]
+}
+`,
+			[]string{`:2: warning: path "secret/*/foo": "*" is only a wildcard at the end`},
+			2,
+		},
+		{
+			"deprecated_policy",
+			`
+path "secret/foo" {
+  policy = "write"
+}
+`,
+			[]string{`:2: warning: path "secret/foo": the "policy" field is deprecated`},
+			2,
+		},
+		{
+			"unresolvable_templates",
+			`
+path "secret/{{identity.entity.nmae}}" {
+  capabilities = ["read"]
+}
+
+path "secret/{{identity.entity.groups.names}}" {
+  capabilities = ["read"]
+}
+
+path "secret/{{identity.groups.ids.abcd}}" {
+  capabilities = ["read"]
+}
+`,
+			[]string{
+				`:2: error: path "secret/{{identity.entity.nmae}}": template {{identity.entity.nmae}} can never be resolved: unknown entity selector "nmae"`,
+				`:6: error: path "secret/{{identity.entity.groups.names}}": template {{identity.entity.groups.names}} can never be resolved`,
+				`:10: error: path "secret/{{identity.groups.ids.abcd}}": template {{identity.groups.ids.abcd}} can never be resolved`,
+			},
+			2,
+		},
+		{
+			"invalid",
+			`banana "foo" {}`,
+			[]string{`:0: error: failed to parse policy`},
+			2,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := ioutil.TempFile("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			if _, err := f.Write([]byte(tc.policy)); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			ui, cmd := testPolicyLintCommand(t)
+
+			code := cmd.Run([]string{f.Name()})
+			if code != tc.code {
+				t.Errorf("expected %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			for _, out := range tc.out {
+				if !strings.Contains(combined, out) {
+					t.Errorf("expected %q to contain %q", combined, out)
+				}
+			}
+		})
+	}
+
+	t.Run("not_enough_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testPolicyLintCommand(t)
+
+		code := cmd.Run([]string{})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Not enough arguments"
+		if combined := ui.OutputWriter.String() + ui.ErrorWriter.String(); !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPolicyLintCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
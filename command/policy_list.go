@@ -67,7 +67,7 @@ func (c *PolicyListCommand) Run(args []string) int {
 
 	policies, err := client.Sys().ListPolicies()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing policies: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error listing policies: %s", err), err)
 		return 2
 	}
 
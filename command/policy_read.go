@@ -76,7 +76,7 @@ func (c *PolicyReadCommand) Run(args []string) int {
 	name := strings.ToLower(strings.TrimSpace(args[0]))
 	rules, err := client.Sys().GetPolicy(name)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading policy named %s: %s", name, err))
+		outputError(c.UI, fmt.Sprintf("Error reading policy named %s: %s", name, err), err)
 		return 2
 	}
 	if rules == "" {
@@ -0,0 +1,404 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/identitytpl"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+	"github.com/ryanuber/columnize"
+)
+
+var (
+	_ cli.Command             = (*PolicyRenderCommand)(nil)
+	_ cli.CommandAutocomplete = (*PolicyRenderCommand)(nil)
+)
+
+type PolicyRenderCommand struct {
+	*BaseCommand
+
+	flagEntityID   string
+	flagEntityName string
+	flagVars       map[string]string
+}
+
+func (c *PolicyRenderCommand) Synopsis() string {
+	return "Resolves the templated paths of a policy"
+}
+
+func (c *PolicyRenderCommand) Help() string {
+	helpText := `
+Usage: vault policy render [options] POLICY
+
+  Resolves the templated paths of the policy POLICY, which is a policy file on
+  local disk or the name of a policy in Vault, the way Vault does for a token
+  of an entity, and prints the paths the policy grants. Paths which cannot be
+  resolved are not granted by Vault; they are printed with the reason.
+
+  Render the policy "kv-user" for an entity stored in Vault:
+
+      $ vault policy render -entity-id=7d2e3179-f69b-450c-7179-ac8ee8bd8ca9 \
+          kv-user
+
+  Render a local policy file with explicit values for its parameters:
+
+      $ vault policy render \
+          -var=identity.entity.name=alice \
+          -var=identity.entity.aliases.auth_userpass_3e4b1b35.metadata.team=ops \
+          ./kv-user.hcl
+
+  Values given with -var are applied on top of the entity read from Vault,
+  if any. Groups are given as identity.groups.ids.<id>.<field> or
+  identity.groups.names.<name>.<field>.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PolicyRenderCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "entity-id",
+		Target:     &c.flagEntityID,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage:      "ID of the entity in Vault to render the policy for.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "entity-name",
+		Target:     &c.flagEntityName,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage:      "Name of the entity in Vault to render the policy for.",
+	})
+
+	f.StringMapVar(&StringMapVar{
+		Name:       "var",
+		Target:     &c.flagVars,
+		Completion: complete.PredictAnything,
+		Usage: "Value of a template parameter in the form of key=value, such " +
+			"as identity.entity.metadata.team=ops. This can be specified " +
+			"multiple times.",
+	})
+
+	return set
+}
+
+func (c *PolicyRenderCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictOr(c.PredictVaultPolicies(), complete.PredictFiles("*.hcl"))
+}
+
+func (c *PolicyRenderCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PolicyRenderCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+	if c.flagEntityID != "" && c.flagEntityName != "" {
+		c.UI.Error("Only one of -entity-id or -entity-name can be given")
+		return 1
+	}
+	if c.flagEntityID == "" && c.flagEntityName == "" && len(c.flagVars) == 0 {
+		c.UI.Error("One of -entity-id, -entity-name or -var must be given")
+		return 1
+	}
+
+	rules, name, code := readPolicySource(c.BaseCommand, strings.TrimSpace(args[0]))
+	if code != 0 {
+		return code
+	}
+
+	entity := &logical.Entity{}
+	var groups []*logical.Group
+	if c.flagEntityID != "" || c.flagEntityName != "" {
+		var err error
+		entity, groups, err = c.readEntity()
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading entity: %s", err), err)
+			return 2
+		}
+	}
+	for key, value := range c.flagVars {
+		var err error
+		groups, err = setPolicyTemplateVar(entity, groups, key, value)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Invalid -var %q: %s", key, err), err)
+			return 1
+		}
+	}
+
+	rendered, err := renderPolicyPaths(rules, entity, groups)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error rendering policy %s: %s", name, err), err)
+		return 1
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, rendered)
+	}
+
+	table := []string{"Rule | Resolved Path | Capabilities"}
+	for _, path := range rendered {
+		resolved := path["path"].(string)
+		if path["error"] != nil {
+			resolved = fmt.Sprintf("not granted: %s", path["error"])
+		}
+		table = append(table, fmt.Sprintf("%s | %s | %s",
+			path["rule"], resolved, strings.Join(path["capabilities"].([]string), ", ")))
+	}
+
+	c.UI.Output(tableOutput(table, columnize.DefaultConfig()))
+	return 0
+}
+
+// readEntity reads the entity given by -entity-id or -entity-name, and all the
+// groups it belongs to, from Vault.
+func (c *PolicyRenderCommand) readEntity() (*logical.Entity, []*logical.Group, error) {
+	client, err := c.Client()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := "identity/entity/id/" + c.flagEntityID
+	if c.flagEntityName != "" {
+		path = "identity/entity/name/" + c.flagEntityName
+	}
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil, fmt.Errorf("no entity found at %s", path)
+	}
+
+	entity := &logical.Entity{
+		ID:          policyRenderString(secret.Data["id"]),
+		Name:        policyRenderString(secret.Data["name"]),
+		Metadata:    policyRenderStringMap(secret.Data["metadata"]),
+		NamespaceID: policyRenderString(secret.Data["namespace_id"]),
+	}
+	aliases, _ := secret.Data["aliases"].([]interface{})
+	for _, aliasRaw := range aliases {
+		alias, ok := aliasRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entity.Aliases = append(entity.Aliases, &logical.Alias{
+			ID:             policyRenderString(alias["id"]),
+			Name:           policyRenderString(alias["name"]),
+			MountAccessor:  policyRenderString(alias["mount_accessor"]),
+			MountType:      policyRenderString(alias["mount_type"]),
+			Metadata:       policyRenderStringMap(alias["metadata"]),
+			CustomMetadata: policyRenderStringMap(alias["custom_metadata"]),
+		})
+	}
+
+	var groups []*logical.Group
+	groupIDs, _ := secret.Data["group_ids"].([]interface{})
+	for _, groupID := range groupIDs {
+		secret, err := client.Logical().Read(fmt.Sprintf("identity/group/id/%v", groupID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading group %v: %w", groupID, err)
+		}
+		if secret == nil || secret.Data == nil {
+			continue
+		}
+		groups = append(groups, &logical.Group{
+			ID:          policyRenderString(secret.Data["id"]),
+			Name:        policyRenderString(secret.Data["name"]),
+			Metadata:    policyRenderStringMap(secret.Data["metadata"]),
+			NamespaceID: policyRenderString(secret.Data["namespace_id"]),
+		})
+	}
+
+	return entity, groups, nil
+}
+
+// setPolicyTemplateVar sets the value of the template parameter key on entity
+// or on one of groups, which it returns with any group it had to add.
+func setPolicyTemplateVar(entity *logical.Entity, groups []*logical.Group, key, value string) ([]*logical.Group, error) {
+	setMap := func(m *map[string]string, k string) {
+		if *m == nil {
+			*m = map[string]string{}
+		}
+		(*m)[k] = value
+	}
+
+	switch {
+	case key == "identity.entity.id":
+		entity.ID = value
+	case key == "identity.entity.name":
+		entity.Name = value
+	case strings.HasPrefix(key, "identity.entity.metadata.") && key != "identity.entity.metadata.":
+		setMap(&entity.Metadata, strings.TrimPrefix(key, "identity.entity.metadata."))
+
+	case strings.HasPrefix(key, "identity.entity.aliases."):
+		parts := strings.SplitN(strings.TrimPrefix(key, "identity.entity.aliases."), ".", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("expected identity.entity.aliases.<mount accessor>.<field>")
+		}
+		var alias *logical.Alias
+		for _, a := range entity.Aliases {
+			if a.MountAccessor == parts[0] {
+				alias = a
+			}
+		}
+		if alias == nil {
+			alias = &logical.Alias{MountAccessor: parts[0]}
+			entity.Aliases = append(entity.Aliases, alias)
+		}
+		switch field := parts[1]; {
+		case field == "id":
+			alias.ID = value
+		case field == "name":
+			alias.Name = value
+		case strings.HasPrefix(field, "metadata.") && field != "metadata.":
+			setMap(&alias.Metadata, strings.TrimPrefix(field, "metadata."))
+		case strings.HasPrefix(field, "custom_metadata.") && field != "custom_metadata.":
+			setMap(&alias.CustomMetadata, strings.TrimPrefix(field, "custom_metadata."))
+		default:
+			return nil, fmt.Errorf("unknown alias field %q", field)
+		}
+
+	case strings.HasPrefix(key, "identity.groups.ids."), strings.HasPrefix(key, "identity.groups.names."):
+		parts := strings.SplitN(strings.TrimPrefix(key, "identity.groups."), ".", 3)
+		if len(parts) != 3 || parts[1] == "" {
+			return nil, fmt.Errorf("expected identity.groups.%s.<group>.<field>", parts[0])
+		}
+		var group *logical.Group
+		for _, g := range groups {
+			if (parts[0] == "ids" && g.ID == parts[1]) || (parts[0] == "names" && g.Name == parts[1]) {
+				group = g
+			}
+		}
+		if group == nil {
+			group = &logical.Group{}
+			if parts[0] == "ids" {
+				group.ID = parts[1]
+			} else {
+				group.Name = parts[1]
+			}
+			groups = append(groups, group)
+		}
+		switch field := parts[2]; {
+		case field == "id":
+			group.ID = value
+		case field == "name":
+			group.Name = value
+		case strings.HasPrefix(field, "metadata.") && field != "metadata.":
+			setMap(&group.Metadata, strings.TrimPrefix(field, "metadata."))
+		default:
+			return nil, fmt.Errorf("unknown group field %q", field)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown template parameter")
+	}
+
+	return groups, nil
+}
+
+// renderPolicyPaths resolves the templated paths of the ACL policy rules for
+// the given entity and groups, in the order they are defined. A path which
+// cannot be resolved has the reason set as "error".
+func renderPolicyPaths(rules string, entity *logical.Entity, groups []*logical.Group) ([]map[string]interface{}, error) {
+	// Parsing without an entity leaves the templated paths as they are, but
+	// maps the capabilities of every rule.
+	policy, err := vault.ParseACLPolicy(namespace.RootNamespace, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := hcl.Parse(rules)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("does not contain a root object")
+	}
+
+	items := list.Filter("path").Items
+	if len(items) != len(policy.Paths) {
+		return nil, fmt.Errorf("expected %d paths, parsed %d", len(items), len(policy.Paths))
+	}
+
+	rendered := []map[string]interface{}{}
+	for i, item := range items {
+		var rule string
+		if len(item.Keys) > 0 {
+			rule, _ = item.Keys[0].Token.Value().(string)
+		}
+		capabilities := policy.Paths[i].Capabilities
+		if capabilities == nil {
+			capabilities = []string{}
+		}
+
+		path := map[string]interface{}{
+			"rule":         rule,
+			"path":         rule,
+			"capabilities": capabilities,
+			"error":        nil,
+		}
+		_, resolved, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+			Mode:   identitytpl.ACLTemplating,
+			String: rule,
+			Entity: entity,
+			Groups: groups,
+		})
+		switch {
+		case err == identitytpl.ErrTemplateValueNotFound:
+			path["path"], path["error"] = "", "no value for a template parameter"
+		case err != nil:
+			path["path"], path["error"] = "", err.Error()
+		default:
+			path["path"] = resolved
+		}
+		rendered = append(rendered, path)
+	}
+
+	return rendered, nil
+}
+
+// policyRenderString returns raw if it is a string from an API response, and an
+// empty string otherwise.
+func policyRenderString(raw interface{}) string {
+	s, _ := raw.(string)
+	return s
+}
+
+// policyRenderStringMap converts a map from an API response to a map of
+// strings.
+func policyRenderStringMap(raw interface{}) map[string]string {
+	m, _ := raw.(map[string]interface{})
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = fmt.Sprintf("%v", v)
+	}
+	return result
+}
@@ -0,0 +1,205 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testPolicyRenderCommand(tb testing.TB) (*cli.MockUi, *PolicyRenderCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PolicyRenderCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// policyRenderRows returns the rows of a table printed by vault policy
+// render, with the whitespace between columns collapsed.
+func policyRenderRows(output string) map[string]bool {
+	rows := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		rows[strings.Join(strings.Fields(line), " ")] = true
+	}
+	return rows
+}
+
+func TestPolicyRenderCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	policy := `
+path "secret/{{identity.entity.name}}/*" {
+  capabilities = ["read", "update"]
+}
+
+path "secret/teams/{{identity.entity.metadata.team}}/*" {
+  policy = "read"
+}
+
+path "secret/groups/{{identity.groups.names.ops.id}}" {
+  capabilities = ["list"]
+}
+
+path "secret/shared" {
+  capabilities = ["read"]
+}
+`
+
+	f, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	if _, err := f.Write([]byte(policy)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"-var=identity.entity.name=alice"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"-var=identity.entity.name=alice", "foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"no_entity",
+			[]string{f.Name()},
+			"One of -entity-id, -entity-name or -var must be given",
+			1,
+		},
+		{
+			"both_entities",
+			[]string{"-entity-id=foo", "-entity-name=bar", f.Name()},
+			"Only one of -entity-id or -entity-name can be given",
+			1,
+		},
+		{
+			"unknown_var",
+			[]string{"-var=identity.entity.nmae=alice", f.Name()},
+			`Invalid -var "identity.entity.nmae": unknown template parameter`,
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testPolicyRenderCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("vars", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testPolicyRenderCommand(t)
+
+		code := cmd.Run([]string{
+			"-var=identity.entity.name=alice",
+			"-var=identity.groups.names.ops.id=g1",
+			f.Name(),
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		rows := policyRenderRows(ui.OutputWriter.String())
+		for _, expected := range []string{
+			"secret/{{identity.entity.name}}/* secret/alice/* read, update",
+			"secret/teams/{{identity.entity.metadata.team}}/* not granted: no value for a template parameter read, list",
+			"secret/groups/{{identity.groups.names.ops.id}} secret/groups/g1 list",
+			"secret/shared secret/shared read",
+		} {
+			if !rows[expected] {
+				t.Errorf("expected %q to contain row %q", ui.OutputWriter.String(), expected)
+			}
+		}
+	})
+
+	t.Run("entity", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().PutPolicy("kv-user", policy); err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.Logical().Write("identity/entity", map[string]interface{}{
+			"name":     "bob",
+			"metadata": map[string]string{"team": "web"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entityID := secret.Data["id"].(string)
+		secret, err = client.Logical().Write("identity/group", map[string]interface{}{
+			"name":              "ops",
+			"member_entity_ids": []string{entityID},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		groupID := secret.Data["id"].(string)
+
+		ui, cmd := testPolicyRenderCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-entity-id=" + entityID, "kv-user"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		rows := policyRenderRows(ui.OutputWriter.String())
+		for _, expected := range []string{
+			"secret/{{identity.entity.name}}/* secret/bob/* read, update",
+			"secret/teams/{{identity.entity.metadata.team}}/* secret/teams/web/* read, list",
+			"secret/groups/{{identity.groups.names.ops.id}} secret/groups/" + groupID + " list",
+		} {
+			if !rows[expected] {
+				t.Errorf("expected %q to contain row %q", ui.OutputWriter.String(), expected)
+			}
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPolicyRenderCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
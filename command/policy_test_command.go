@@ -0,0 +1,251 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+	"github.com/ryanuber/columnize"
+)
+
+var (
+	_ cli.Command             = (*PolicyTestCommand)(nil)
+	_ cli.CommandAutocomplete = (*PolicyTestCommand)(nil)
+)
+
+// policyTestOperations maps the operations which can be checked by vault
+// policy test to the capability they require.
+var policyTestOperations = map[string]struct {
+	operation  logical.Operation
+	capability uint32
+}{
+	"create": {logical.CreateOperation, vault.CreateCapabilityInt},
+	"read":   {logical.ReadOperation, vault.ReadCapabilityInt},
+	"update": {logical.UpdateOperation, vault.UpdateCapabilityInt},
+	"patch":  {logical.PatchOperation, vault.PatchCapabilityInt},
+	"delete": {logical.DeleteOperation, vault.DeleteCapabilityInt},
+	"list":   {logical.ListOperation, vault.ListCapabilityInt},
+}
+
+type PolicyTestCommand struct {
+	*BaseCommand
+
+	flagPolicies []string
+}
+
+func (c *PolicyTestCommand) Synopsis() string {
+	return "Checks which requests a set of policies allows"
+}
+
+func (c *PolicyTestCommand) Help() string {
+	helpText := `
+Usage: vault policy test [options] OPERATION:PATH...
+
+  Evaluates a set of policies against requests, given as OPERATION:PATH, and
+  reports whether each of them is allowed, together with the rule that decided
+  it and the policies that rule comes from. OPERATION is one of create, read,
+  update, patch, delete or list.
+
+  Policies are given with -policy, either as a policy file on local disk or as
+  the name of a policy in Vault. The evaluation happens locally, so policy
+  files can be tested before they are written to Vault.
+
+  Check whether the local policy "app.hcl" and the "default" policy allow
+  reading and updating a secret:
+
+      $ vault policy test -policy=./app.hcl -policy=default \
+          read:secret/data/app/config update:secret/data/app/config
+
+  Templated paths are not resolved, parameter constraints such as
+  required_parameters are not checked and Sentinel policies are not evaluated.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *PolicyTestCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "policy",
+		Target:     &c.flagPolicies,
+		Completion: c.PredictVaultPolicies(),
+		Usage: "Path of a local policy file or name of a policy in Vault to " +
+			"evaluate. This can be specified multiple times.",
+	})
+
+	return set
+}
+
+func (c *PolicyTestCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *PolicyTestCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *PolicyTestCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1 or more, got %d)", len(args)))
+		return 1
+	}
+	if len(c.flagPolicies) == 0 {
+		c.UI.Error("At least one policy must be given with -policy")
+		return 1
+	}
+
+	type check struct {
+		operation string
+		path      string
+	}
+	checks := make([]check, 0, len(args))
+	for _, arg := range args {
+		i := strings.Index(arg, ":")
+		if i < 0 {
+			c.UI.Error(fmt.Sprintf("Invalid request %q: expected OPERATION:PATH", arg))
+			return 1
+		}
+		operation := strings.ToLower(strings.TrimSpace(arg[:i]))
+		if _, ok := policyTestOperations[operation]; !ok {
+			c.UI.Error(fmt.Sprintf("Invalid operation %q in %q", arg[:i], arg))
+			return 1
+		}
+		checks = append(checks, check{
+			operation: operation,
+			path:      sanitizePath(arg[i+1:]),
+		})
+	}
+
+	policies := make([]*vault.Policy, 0, len(c.flagPolicies))
+	for _, source := range c.flagPolicies {
+		policy, code := c.loadPolicy(strings.TrimSpace(source))
+		if code != 0 {
+			return code
+		}
+		policies = append(policies, policy)
+	}
+
+	ctx := namespace.RootContext(context.Background())
+	acl, err := vault.NewACL(ctx, policies)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error evaluating policies: %s", err), err)
+		return 1
+	}
+
+	results := make([]map[string]interface{}, 0, len(checks))
+	for _, check := range checks {
+		op := policyTestOperations[check.operation]
+		res := acl.AllowOperation(ctx, &logical.Request{
+			Operation: op.operation,
+			Path:      check.path,
+		}, true)
+
+		// With capCheckOnly, the bitmap only contains the deny bit if the
+		// matching rule denies access.
+		allowed := res.IsRoot || res.CapabilitiesBitmap&op.capability > 0
+		sources := policyTestRuleSources(policies, res.MatchedPath)
+		if res.IsRoot {
+			sources = []string{"root"}
+		}
+
+		results = append(results, map[string]interface{}{
+			"operation":    check.operation,
+			"path":         check.path,
+			"allowed":      allowed,
+			"matched_rule": res.MatchedPath,
+			"policies":     sources,
+		})
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, results)
+	}
+
+	table := []string{"Operation | Path | Result | Matched Rule | Policies"}
+	for _, result := range results {
+		decision := "allow"
+		if !result["allowed"].(bool) {
+			decision = "deny"
+		}
+		rule := result["matched_rule"].(string)
+		if rule == "" {
+			rule = "n/a"
+		}
+		sources := strings.Join(result["policies"].([]string), ", ")
+		if sources == "" {
+			sources = "n/a"
+		}
+		table = append(table, fmt.Sprintf("%s | %s | %s | %s | %s",
+			result["operation"], result["path"], decision, rule, sources))
+	}
+
+	c.UI.Output(tableOutput(table, columnize.DefaultConfig()))
+	return 0
+}
+
+// loadPolicy parses the policy in the local file at source or, if there is no
+// such file, the policy named source in Vault. It returns a non-zero exit code
+// on failure.
+func (c *PolicyTestCommand) loadPolicy(source string) (*vault.Policy, int) {
+	if strings.ToLower(source) == "root" {
+		if _, err := os.Stat(source); err != nil {
+			return &vault.Policy{Name: "root", Type: vault.PolicyTypeACL}, 0
+		}
+	}
+
+	rules, name, code := readPolicySource(c.BaseCommand, source)
+	if code != 0 {
+		return nil, code
+	}
+
+	policy, err := vault.ParseACLPolicy(namespace.RootNamespace, rules)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing policy %s: %s", name, err), err)
+		return nil, 1
+	}
+	policy.Name = name
+	return policy, 0
+}
+
+// policyTestRuleSources returns the names of the policies defining a rule for
+// path, which is written as in the policy.
+func policyTestRuleSources(policies []*vault.Policy, path string) []string {
+	sources := []string{}
+	if path == "" {
+		return sources
+	}
+
+	for _, policy := range policies {
+		for _, pc := range policy.Paths {
+			rulePath := pc.Path
+			if pc.IsPrefix {
+				rulePath += "*"
+			}
+			if rulePath == path {
+				sources = append(sources, policy.Name)
+				break
+			}
+		}
+	}
+
+	sort.Strings(sources)
+	return sources
+}
@@ -0,0 +1,183 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testPolicyTestCommand(tb testing.TB) (*cli.MockUi, *PolicyTestCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &PolicyTestCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestPolicyTestCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"-policy=default"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"no_policy",
+			[]string{"read:secret/foo"},
+			"At least one policy must be given",
+			1,
+		},
+		{
+			"invalid_request",
+			[]string{"-policy=default", "secret/foo"},
+			"expected OPERATION:PATH",
+			1,
+		},
+		{
+			"invalid_operation",
+			[]string{"-policy=default", "sudo:secret/foo"},
+			"Invalid operation",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testPolicyTestCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().PutPolicy("ops", `path "secret/*" { capabilities = ["deny"] }`); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := ioutil.TempFile("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.Write([]byte(`
+path "secret/*" {
+  capabilities = ["read"]
+}
+
+path "secret/app/+/config" {
+  capabilities = ["read", "update"]
+}
+`)); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		ui, cmd := testPolicyTestCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-policy=" + f.Name(),
+			"-policy=ops",
+			"read:secret/foo",
+			"update:secret/app/web/config",
+			"delete:secret/app/web/config",
+			"read:cubbyhole/foo",
+		})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		rows := map[string]bool{}
+		for _, line := range strings.Split(ui.OutputWriter.String(), "\n") {
+			rows[strings.Join(strings.Fields(line), " ")] = true
+		}
+		for _, expected := range []string{
+			"read secret/foo deny secret/* " + f.Name() + ", ops",
+			"update secret/app/web/config allow secret/app/+/config " + f.Name(),
+			"delete secret/app/web/config deny secret/app/+/config " + f.Name(),
+			"read cubbyhole/foo deny n/a n/a",
+		} {
+			if !rows[expected] {
+				t.Errorf("expected %q to contain row %q", ui.OutputWriter.String(), expected)
+			}
+		}
+	})
+
+	t.Run("root", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testPolicyTestCommand(t)
+
+		code := cmd.Run([]string{"-policy=root", "delete:sys/mounts/secret"})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "allow"
+		if output := ui.OutputWriter.String(); !strings.Contains(output, expected) {
+			t.Errorf("expected %q to contain %q", output, expected)
+		}
+	})
+
+	t.Run("no_such_policy", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testPolicyTestCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-policy=nope", "read:secret/foo"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "No policy file or policy named: nope"
+		if combined := ui.OutputWriter.String() + ui.ErrorWriter.String(); !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testPolicyTestCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
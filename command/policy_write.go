@@ -104,7 +104,7 @@ func (c *PolicyWriteCommand) Run(args []string) int {
 	} else {
 		file, err := os.Open(path)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error opening policy file: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error opening policy file: %s", err), err)
 			return 2
 		}
 		defer file.Close()
@@ -114,13 +114,13 @@ func (c *PolicyWriteCommand) Run(args []string) int {
 	// Read the policy
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, reader); err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading policy: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error reading policy: %s", err), err)
 		return 2
 	}
 	rules := buf.String()
 
 	if err := client.Sys().PutPolicy(name, rules); err != nil {
-		c.UI.Error(fmt.Sprintf("Error uploading policy: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error uploading policy: %s", err), err)
 		return 2
 	}
 
@@ -0,0 +1,135 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	colorable "github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
+	"github.com/mitchellh/cli"
+)
+
+const (
+	// progressInterval is how often a progress indicator is redrawn.
+	progressInterval = 100 * time.Millisecond
+
+	// progressBarWidth is the number of characters of a progress bar.
+	progressBarWidth = 30
+)
+
+// progressSpinner are the frames of the spinner shown when the number of items
+// of an operation is not known.
+var progressSpinner = []string{"|", "/", "-", "\\"}
+
+// progress shows the progress of a long-running operation on a single line of
+// the terminal, as a bar with counts when the number of items is known and as
+// a spinner with a count otherwise. A nil *progress shows nothing, so that
+// callers don't have to check whether progress is enabled.
+type progress struct {
+	w     io.Writer
+	label string
+	total int64
+	count int64 // accessed atomically
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// newProgress starts showing the progress of an operation on total items, or
+// on an unknown number of items if total is 0. Progress is only shown on
+// stderr when it is a terminal and the table format is in use, so that it
+// never ends up in logs or in machine-readable output. The returned progress
+// must be stopped before anything else is printed.
+func newProgress(ui cli.Ui, label string, total int) *progress {
+	if Format(ui) != "table" || !isatty.IsTerminal(os.Stderr.Fd()) {
+		return nil
+	}
+	return startProgress(colorable.NewColorable(os.Stderr), label, total)
+}
+
+// startProgress starts redrawing the progress of an operation on w.
+func startProgress(w io.Writer, label string, total int) *progress {
+	p := &progress{
+		w:      w,
+		label:  label,
+		total:  int64(total),
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *progress) run() {
+	defer close(p.doneCh)
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		fmt.Fprintf(p.w, "\r\x1b[K%s", p.line(frame))
+		select {
+		case <-ticker.C:
+		case <-p.stopCh:
+			fmt.Fprint(p.w, "\r\x1b[K")
+			return
+		}
+	}
+}
+
+// line returns the progress indicator for the given frame of the spinner.
+func (p *progress) line(frame int) string {
+	count := atomic.LoadInt64(&p.count)
+	if p.total <= 0 {
+		return fmt.Sprintf("%s %s: %d", progressSpinner[frame%len(progressSpinner)], p.label, count)
+	}
+
+	if count > p.total {
+		count = p.total
+	}
+	filled := int(count * progressBarWidth / p.total)
+	return fmt.Sprintf("%s [%s%s] %d/%d", p.label,
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		count, p.total)
+}
+
+// Add records that n more items were processed. It is safe to call from
+// multiple goroutines.
+func (p *progress) Add(n int) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.count, int64(n))
+}
+
+// Stop stops showing the progress and clears its line.
+func (p *progress) Stop() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() { close(p.stopCh) })
+	<-p.doneCh
+}
+
+// countKeys wraps a function listing a folder for listRecursive, so that the
+// number of keys found so far is shown.
+func (p *progress) countKeys(list func(folder string) ([]string, error)) func(folder string) ([]string, error) {
+	if p == nil {
+		return list
+	}
+	return func(folder string) ([]string, error) {
+		entries, err := list(folder)
+		for _, entry := range entries {
+			if !strings.HasSuffix(entry, "/") {
+				p.Add(1)
+			}
+		}
+		return entries, err
+	}
+}
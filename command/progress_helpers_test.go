@@ -0,0 +1,69 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+
+	t.Run("bar", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		p := startProgress(&buf, "Keys deleted", 4)
+		p.Add(1)
+		p.Add(2)
+		if exp, got := "Keys deleted [======================        ] 3/4", p.line(0); got != exp {
+			t.Errorf("expected %q, got %q", exp, got)
+		}
+		p.Stop()
+		p.Stop()
+
+		if !strings.HasPrefix(buf.String(), "\r\x1b[KKeys deleted [") {
+			t.Errorf("expected the bar to be drawn, got %q", buf.String())
+		}
+		if !strings.HasSuffix(buf.String(), "\r\x1b[K") {
+			t.Errorf("expected the line to be cleared, got %q", buf.String())
+		}
+	})
+
+	t.Run("spinner", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		p := startProgress(&buf, "Listing keys", 0)
+		list := p.countKeys(func(folder string) ([]string, error) {
+			return []string{"foo", "bar/", "baz"}, nil
+		})
+		if _, err := list("secret/"); err != nil {
+			t.Fatal(err)
+		}
+		p.Stop()
+
+		if exp, got := "/ Listing keys: 2", p.line(1); got != exp {
+			t.Errorf("expected %q, got %q", exp, got)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		// Progress is not shown without a terminal, and a nil progress does
+		// nothing
+		p := newProgress(&VaultUI{format: "table"}, "Listing keys", 0)
+		if p != nil {
+			t.Fatal("expected no progress without a terminal")
+		}
+		p.Add(1)
+		p.Stop()
+		entries, err := p.countKeys(func(folder string) ([]string, error) {
+			return []string{"foo"}, nil
+		})("secret/")
+		if err != nil || len(entries) != 1 {
+			t.Errorf("expected the entries to be listed, got %v, %v", entries, err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package command
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+// qrQuietZone is the number of light modules around a QR code rendered in the
+// terminal, so that it can be scanned on dark terminal backgrounds.
+const qrQuietZone = 4
+
+// outputQRTerminal renders the otpauth URL of a TOTP key, as returned by the
+// TOTP secrets engine and by the generation of MFA method secrets, as a QR
+// code in the terminal.
+func outputQRTerminal(ui cli.Ui, secret *api.Secret) error {
+	var url string
+	if secret != nil {
+		url, _ = secret.Data["url"].(string)
+	}
+	if !strings.HasPrefix(url, "otpauth://") {
+		return errors.New("no otpauth URL in the response")
+	}
+
+	code, err := renderQRTerminal(url)
+	if err != nil {
+		return err
+	}
+	ui.Output("")
+	ui.Output(code)
+	return nil
+}
+
+// renderQRTerminal renders the given content as a QR code made of ANSI colored
+// half blocks, each character holding two rows of modules.
+func renderQRTerminal(content string) (string, error) {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := code.Bounds()
+	size := bounds.Dx()
+	dark := func(x, y int) bool {
+		x, y = x-qrQuietZone, y-qrQuietZone
+		if x < 0 || y < 0 || x >= size || y >= size {
+			return false
+		}
+		gray := color.GrayModel.Convert(code.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+		return gray.Y < 128
+	}
+	// The foreground color is the upper module, and the background color the
+	// lower module
+	block := map[[2]bool]string{
+		{false, false}: "\x1b[97;107m▀",
+		{false, true}:  "\x1b[97;40m▀",
+		{true, false}:  "\x1b[30;107m▀",
+		{true, true}:   "\x1b[30;40m▀",
+	}
+
+	total := size + 2*qrQuietZone
+	lines := make([]string, 0, (total+1)/2)
+	for y := 0; y < total; y += 2 {
+		var b strings.Builder
+		for x := 0; x < total; x++ {
+			b.WriteString(block[[2]bool{dark(x, y), dark(x, y+1)}])
+		}
+		b.WriteString("\x1b[0m")
+		lines = append(lines, b.String())
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boombuler/barcode/qr"
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestRenderQRTerminal(t *testing.T) {
+	t.Parallel()
+
+	content := "otpauth://totp/Vault:alice?issuer=Vault&secret=JBSWY3DPEHPK3PXP"
+	code, err := renderQRTerminal(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each line holds two rows of modules
+	size := encoded.Bounds().Dx() + 2*qrQuietZone
+	lines := strings.Split(code, "\n")
+	if exp, act := (size+1)/2, len(lines); exp != act {
+		t.Fatalf("expected %d lines, got %d", exp, act)
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, "\x1b[0m") {
+			t.Errorf("expected line %d to reset the colors: %q", i, line)
+		}
+		if exp, act := size, strings.Count(line, "▀"); exp != act {
+			t.Errorf("expected line %d to have %d blocks, got %d", i, exp, act)
+		}
+	}
+
+	// The quiet zone is light
+	if exp := strings.Repeat("\x1b[97;107m▀", size) + "\x1b[0m"; lines[0] != exp {
+		t.Errorf("expected the first line to be light: %q", lines[0])
+	}
+}
+
+func TestOutputQRTerminal(t *testing.T) {
+	t.Parallel()
+
+	ui := cli.NewMockUi()
+	err := outputQRTerminal(ui, &api.Secret{Data: map[string]interface{}{
+		"barcode": "iVBORw0KGgo=",
+	}})
+	if err == nil || !strings.Contains(err.Error(), "no otpauth URL") {
+		t.Fatalf("expected an error about the missing URL, got %v", err)
+	}
+	if ui.OutputWriter.Len() != 0 {
+		t.Errorf("unexpected output %q", ui.OutputWriter.String())
+	}
+}
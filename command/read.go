@@ -3,9 +3,12 @@ package command
 import (
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -18,7 +21,15 @@ var (
 type ReadCommand struct {
 	*BaseCommand
 
-	testStdin io.Reader // for tests
+	flagUnwrap           bool
+	flagUnwrapVerifyPath bool
+	flagWatch            bool
+	flagWatchInterval    time.Duration
+	flagWatchDiff        bool
+	flagQRTerminal       bool
+
+	testStdin  io.Reader     // for tests
+	testStopCh chan struct{} // for tests
 }
 
 func (c *ReadCommand) Synopsis() string {
@@ -36,6 +47,21 @@ Usage: vault read [options] PATH
 
       $ vault read secret/my-secret
 
+  Generate dynamic credentials wrapped in a token and unwrap them immediately:
+
+      $ vault read -wrap-ttl=5m -unwrap -unwrap-verify-path \
+          database/creds/my-role
+
+  Print the secret again every minute whenever it changes, showing only the
+  keys which changed:
+
+      $ vault read -watch -watch-interval=1m -watch-diff secret/my-secret
+
+  Generate the TOTP secret of a legacy MFA method, and scan its QR code from
+  the terminal:
+
+      $ vault read -qr-terminal sys/mfa/method/totp/my-method/generate
+
   For a full list of examples and paths, please see the documentation that
   corresponds to the secrets engine in use.
 
@@ -45,7 +71,71 @@ Usage: vault read [options] PATH
 }
 
 func (c *ReadCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:       "unwrap",
+		Target:     &c.flagUnwrap,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "If the response is wrapped, immediately unwrap it and output " +
+			"the inner secret instead of the wrapping token.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "unwrap-verify-path",
+		Target:     &c.flagUnwrapVerifyPath,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "When used with -unwrap, verify that the wrapping token was " +
+			"created for the requested path before unwrapping it.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "watch",
+		Target:     &c.flagWatch,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Keep reading the path on an interval and print the secret " +
+			"each time it changes, until interrupted.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "watch-interval",
+		Target:     &c.flagWatchInterval,
+		Default:    defaultWatchInterval,
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage:      "How often to read the path when -watch is set.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "watch-diff",
+		Target:     &c.flagWatchDiff,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "When used with -watch, print the keys which were added, " +
+			"removed, or changed instead of the whole secret.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "qr-terminal",
+		Target:     &c.flagQRTerminal,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Render the otpauth URL of the response, returned when generating " +
+			"an MFA method secret, as a QR code in the terminal.",
+	})
+
+	c.addCacheTTLFlag(f)
+
+	return set
 }
 
 func (c *ReadCommand) AutocompleteArgs() complete.Predictor {
@@ -69,6 +159,12 @@ func (c *ReadCommand) Run(args []string) int {
 	case len(args) < 1:
 		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
 		return 1
+	case c.flagQRTerminal && c.flagWatch:
+		c.UI.Error("-qr-terminal cannot be used with -watch")
+		return 1
+	case c.flagQRTerminal && (c.flagField != "" || Format(c.UI) != "table"):
+		c.UI.Error("-qr-terminal can only be used with the table output format")
+		return 1
 	}
 
 	client, err := c.Client()
@@ -87,20 +183,92 @@ func (c *ReadCommand) Run(args []string) int {
 
 	data, err := parseArgsDataStringLists(stdin, args[1:])
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse K=V data: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
 		return 1
 	}
 
+	if c.flagWatch {
+		stopCh := c.testStopCh
+		if stopCh == nil {
+			stopCh = MakeShutdownCh()
+		}
+
+		w := &secretWatcher{
+			UI:       c.UI,
+			Interval: c.flagWatchInterval,
+			Diff:     c.flagWatchDiff,
+			Fetch: func() (*api.Secret, error) {
+				return c.read(client, path, data)
+			},
+			Output: c.output,
+			StopCh: stopCh,
+		}
+		return w.Run()
+	}
+
+	// Watching and unwrapping always read from Vault
+	var cache *readCache
+	if !c.flagUnwrap {
+		cache, err = c.readCache(client)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error setting up the read cache: %s", err), err)
+			return 2
+		}
+	}
+
+	cacheID := "read|" + path + "?" + url.Values(data).Encode()
+	var secret *api.Secret
+	if cache == nil || !cache.get(cacheID, &secret) {
+		secret, err = c.read(client, path, data)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+		if cache != nil && readCacheable(secret) {
+			if err := cache.put(cacheID, secret); err != nil {
+				c.UI.Warn(fmt.Sprintf("Failed to cache the response: %s", err))
+			}
+		}
+	}
+	if secret == nil {
+		// Only possible when unwrapping a response which had no data
+		if Format(c.UI) == "table" {
+			c.UI.Info("Successfully unwrapped. There was no data in the wrapped secret.")
+		}
+		return 0
+	}
+
+	code := c.output(secret)
+	if code == 0 && c.flagQRTerminal {
+		if err := outputQRTerminal(c.UI, secret); err != nil {
+			c.UI.Warn(fmt.Sprintf("Cannot render QR code: %s", err))
+		}
+	}
+	return code
+}
+
+// read reads the secret at path, unwrapping it if requested.
+func (c *ReadCommand) read(client *api.Client, path string, data map[string][]string) (*api.Secret, error) {
 	secret, err := client.Logical().ReadWithData(path, data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading %s: %s", path, err))
-		return 2
+		return nil, fmt.Errorf("Error reading %s: %s", path, err)
 	}
 	if secret == nil {
-		c.UI.Error(fmt.Sprintf("No value found at %s", path))
-		return 2
+		return nil, fmt.Errorf("No value found at %s", path)
 	}
 
+	if c.flagUnwrap {
+		verifyPath := ""
+		if c.flagUnwrapVerifyPath {
+			verifyPath = path
+		}
+		return unwrapResponse(client, secret, verifyPath)
+	}
+
+	return secret, nil
+}
+
+func (c *ReadCommand) output(secret *api.Secret) int {
 	if c.flagField != "" {
 		return PrintRawField(c.UI, secret, c.flagField)
 	}
@@ -0,0 +1,250 @@
+package command
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete"
+	"golang.org/x/crypto/hkdf"
+)
+
+// defaultReadCacheDir is the directory of the read cache.
+const defaultReadCacheDir = "~/.vault-cache"
+
+// readCache caches the responses of reads on disk for a TTL, so that scripts
+// reading the same secrets in a loop don't send a request each time. The
+// entries are encrypted with a key derived from the token which read them,
+// and named after an HMAC of the request with another key derived from the
+// token, so that they are only found and decrypted with the same token. The
+// modification time of each entry is set to its expiration time, so that the
+// expired entries of any token are pruned when a new entry is written.
+type readCache struct {
+	dir    string
+	ttl    time.Duration
+	prefix string
+	aead   cipher.AEAD
+	macKey []byte
+}
+
+type readCacheEntry struct {
+	Expires time.Time       `json:"expires"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// newReadCache returns a cache of the reads made with token. The prefix is
+// part of the name of every entry, to separate the entries of different
+// servers and namespaces.
+func newReadCache(dir string, ttl time.Duration, token, prefix string) (*readCache, error) {
+	keys := make([]byte, 64)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(token), nil, []byte("vault-cli-read-cache")), keys); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(keys[:32])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &readCache{
+		dir:    dir,
+		ttl:    ttl,
+		prefix: prefix,
+		aead:   aead,
+		macKey: keys[32:],
+	}, nil
+}
+
+// addCacheTTLFlag adds the -cache-ttl flag to the flags of a command reading
+// secrets.
+func (c *BaseCommand) addCacheTTLFlag(f *FlagSet) {
+	f.DurationVar(&DurationVar{
+		Name:       "cache-ttl",
+		Target:     &c.flagCacheTTL,
+		Default:    0,
+		EnvVar:     EnvVaultCacheTTL,
+		Completion: complete.PredictAnything,
+		Usage: "Cache the response on disk for this long, and return the " +
+			"cached response if it is available. Responses with a lease, auth " +
+			"information or wrapping information are never cached. The cache " +
+			"entries are encrypted with a key derived from the token. Overrides " +
+			"the cache_ttl setting of the CLI configuration; 0 disables caching.",
+	})
+}
+
+// readCache returns the read cache to use with client, or nil if caching is
+// disabled. The TTL is read from -cache-ttl or VAULT_CACHE_TTL, or else from
+// the CLI configuration.
+func (c *BaseCommand) readCache(client *api.Client) (*readCache, error) {
+	ttl := c.flagCacheTTL
+	set := false
+	if c.flags != nil {
+		c.flags.Visit(func(f *flag.Flag) {
+			set = set || f.Name == "cache-ttl"
+		})
+	}
+	if _, ok := os.LookupEnv(EnvVaultCacheTTL); !set && !ok {
+		conf, err := c.cliConfig()
+		if err != nil {
+			return nil, err
+		}
+		ttl = conf.CacheTTL
+	}
+
+	// Nothing can be cached without a token to derive the keys from
+	token := client.Token()
+	if ttl <= 0 || token == "" {
+		return nil, nil
+	}
+
+	dir, err := homedir.Expand(defaultReadCacheDir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := client.Address() + "|" + client.Headers().Get(consts.NamespaceHeaderName)
+	return newReadCache(dir, ttl, token, prefix)
+}
+
+// readCacheable returns whether a secret can be cached. Leased secrets, tokens
+// and wrapped responses are never cached, as each read must return new ones.
+func readCacheable(secret *api.Secret) bool {
+	return secret != nil && secret.LeaseID == "" && secret.Auth == nil && secret.WrapInfo == nil
+}
+
+func (r *readCache) file(id string) string {
+	mac := hmac.New(sha256.New, r.macKey)
+	mac.Write([]byte(r.prefix))
+	mac.Write([]byte{0})
+	mac.Write([]byte(id))
+	return filepath.Join(r.dir, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// get decodes the cached value of id in v, and returns whether there was one.
+// Expired and corrupted entries are removed.
+func (r *readCache) get(id string, v interface{}) bool {
+	file := r.file(id)
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false
+	}
+
+	var entry readCacheEntry
+	nonceSize := r.aead.NonceSize()
+	if len(data) < nonceSize {
+		os.Remove(file)
+		return false
+	}
+	plaintext, err := r.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(filepath.Base(file)))
+	if err != nil || json.Unmarshal(plaintext, &entry) != nil || time.Now().After(entry.Expires) {
+		os.Remove(file)
+		return false
+	}
+	return json.Unmarshal(entry.Value, v) == nil
+}
+
+// put caches v as the value of id for the TTL of the cache, and removes the
+// expired entries.
+func (r *readCache) put(id string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	expires := time.Now().Add(r.ttl)
+	plaintext, err := json.Marshal(&readCacheEntry{
+		Expires: expires,
+		Value:   value,
+	})
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, r.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	file := r.file(id)
+	data := r.aead.Seal(nonce, nonce, plaintext, []byte(filepath.Base(file)))
+
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return err
+	}
+	pruneReadCache(r.dir, time.Now())
+
+	// Write to a temporary file first, so that concurrent reads of the entry
+	// never see a partial write
+	tmp, err := ioutil.TempFile(r.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), expires, expires); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// pruneReadCache removes the entries of the read cache in dir which expired
+// before now. Entries are removed on a best-effort basis, as they are also
+// removed when they are read.
+func pruneReadCache(dir string, now time.Time) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		// Temporary files are removed by the process writing them
+		if !f.Mode().IsRegular() || strings.HasPrefix(f.Name(), ".tmp-") || !f.ModTime().Before(now) {
+			continue
+		}
+		os.Remove(filepath.Join(dir, f.Name()))
+	}
+}
+
+// purgeReadCache removes all the entries of the read cache in dir, and
+// returns how many were removed.
+func purgeReadCache(dir string) (int, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	for _, f := range files {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name())); err != nil {
+			return removed, fmt.Errorf("error removing cache entry: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,129 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func TestReadCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cache, err := newReadCache(dir, time.Minute, "s.token", "https://127.0.0.1:8200|")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &api.Secret{Data: map[string]interface{}{"password": "hunter2"}}
+	if err := cache.put("read|secret/foo?", secret); err != nil {
+		t.Fatal(err)
+	}
+
+	var cached *api.Secret
+	if !cache.get("read|secret/foo?", &cached) || cached.Data["password"] != "hunter2" {
+		t.Fatalf("expected the cached secret, got %#v", cached)
+	}
+	if cache.get("read|secret/bar?", &cached) {
+		t.Fatal("expected no cached secret for another path")
+	}
+
+	// The secret is encrypted and can't be found with another token, server
+	// or namespace
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Mode().Perm() != 0o600 {
+		t.Fatalf("expected a single entry readable by the user, got %v", files)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Fatal("expected the entry to be encrypted")
+	}
+	for _, other := range []struct {
+		token, prefix string
+	}{
+		{"s.other", "https://127.0.0.1:8200|"},
+		{"s.token", "https://127.0.0.1:8200|ns1/"},
+	} {
+		otherCache, err := newReadCache(dir, time.Minute, other.token, other.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if otherCache.get("read|secret/foo?", &cached) {
+			t.Fatalf("expected no cached secret with %v", other)
+		}
+	}
+
+	// Corrupted entries are removed
+	if err := ioutil.WriteFile(filepath.Join(dir, files[0].Name()), data[:len(data)-1], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if cache.get("read|secret/foo?", &cached) {
+		t.Fatal("expected a corrupted entry to be ignored")
+	}
+	if _, err := os.Stat(filepath.Join(dir, files[0].Name())); !os.IsNotExist(err) {
+		t.Fatalf("expected the corrupted entry to be removed, got %v", err)
+	}
+
+	// Expired entries are removed
+	expired, err := newReadCache(dir, -time.Second, "s.token", "https://127.0.0.1:8200|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := expired.put("read|secret/foo?", secret); err != nil {
+		t.Fatal(err)
+	}
+	if cache.get("read|secret/foo?", &cached) {
+		t.Fatal("expected an expired entry to be ignored")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("expected the expired entry to be removed, got %v", files)
+	}
+
+	// Expired entries of any token are removed when an entry is written
+	if err := expired.put("read|secret/foo?", secret); err != nil {
+		t.Fatal(err)
+	}
+	other, err := newReadCache(dir, time.Minute, "s.other", "https://127.0.0.1:8200|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.put("read|secret/bar?", secret); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 || files[0].Name() != filepath.Base(other.file("read|secret/bar?")) {
+		t.Fatalf("expected only the new entry to be left, got %v", files)
+	}
+}
+
+func TestReadCacheable(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		secret    *api.Secret
+		cacheable bool
+	}{
+		{"static", &api.Secret{Data: map[string]interface{}{"foo": "bar"}}, true},
+		{"nil", nil, false},
+		{"leased", &api.Secret{LeaseID: "database/creds/app/abcd"}, false},
+		{"auth", &api.Secret{Auth: &api.SecretAuth{ClientToken: "s.token"}}, false},
+		{"wrapped", &api.Secret{WrapInfo: &api.SecretWrapInfo{Token: "s.wrapped"}}, false},
+	}
+
+	for _, tc := range cases {
+		if readCacheable(tc.secret) != tc.cacheable {
+			t.Errorf("%s: expected cacheable to be %t", tc.name, tc.cacheable)
+		}
+	}
+}
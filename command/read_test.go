@@ -3,6 +3,7 @@ package command
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mitchellh/cli"
 )
@@ -105,6 +106,92 @@ func TestReadCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("unwrap", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if _, err := client.Logical().Write("secret/read/unwrap", map[string]interface{}{
+			"foo": "bar",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testReadCommand(t)
+		cmd.client = client
+		client.SetWrappingLookupFunc(cmd.DefaultWrappingLookupFunc)
+
+		code := cmd.Run([]string{
+			"-wrap-ttl", "5m",
+			"-unwrap",
+			"-unwrap-verify-path",
+			"-field", "foo",
+			"secret/read/unwrap",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %q", code, exp, ui.ErrorWriter.String())
+		}
+
+		if exp, act := "bar", ui.OutputWriter.String(); !strings.Contains(act, exp) {
+			t.Errorf("expected %q to contain %q", act, exp)
+		}
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if _, err := client.Logical().Write("secret/read/watch", map[string]interface{}{
+			"foo": "bar",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testReadCommand(t)
+		cmd.client = client
+		stopCh := make(chan struct{})
+		cmd.testStopCh = stopCh
+
+		codeCh := make(chan int, 1)
+		go func() {
+			codeCh <- cmd.Run([]string{
+				"-watch",
+				"-watch-interval", "50ms",
+				"-watch-diff",
+				"secret/read/watch",
+			})
+		}()
+
+		waitForOutput := func(exp string) {
+			t.Helper()
+			deadline := time.Now().Add(5 * time.Second)
+			for !strings.Contains(ui.OutputWriter.String(), exp) {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %q to contain %q", ui.OutputWriter.String(), exp)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+
+		waitForOutput("bar")
+		if _, err := client.Logical().Write("secret/read/watch", map[string]interface{}{
+			"foo": "baz",
+			"zip": "zap",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		waitForOutput("~ foo: bar -> baz")
+		waitForOutput("+ zip: zap")
+
+		close(stopCh)
+		if code := <-codeCh; code != 0 {
+			t.Errorf("expected %d to be %d", code, 0)
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
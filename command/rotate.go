@@ -80,14 +80,14 @@ func (c *OperatorRotateCommand) Run(args []string) int {
 	// Rotate the key
 	err = client.Sys().Rotate()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error rotating key: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error rotating key: %s", err), err)
 		return 2
 	}
 
 	// Print the key status
 	status, err := client.Sys().KeyStatus()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading key status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error reading key status: %s", err), err)
 		return 2
 	}
 
@@ -77,7 +77,7 @@ func (c *SecretsDisableCommand) Run(args []string) int {
 	path := ensureTrailingSlash(sanitizePath(args[0]))
 
 	if err := client.Sys().Unmount(path); err != nil {
-		c.UI.Error(fmt.Sprintf("Error disabling secrets engine at %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error disabling secrets engine at %s: %s", path, err), err)
 		return 2
 	}
 
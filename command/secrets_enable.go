@@ -323,7 +323,7 @@ func (c *SecretsEnableCommand) Run(args []string) int {
 	})
 
 	if err := client.Sys().Mount(mountPath, mountInput); err != nil {
-		c.UI.Error(fmt.Sprintf("Error enabling: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error enabling: %s", err), err)
 		return 2
 	}
 
@@ -94,18 +94,16 @@ func (c *SecretsListCommand) Run(args []string) int {
 
 	mounts, err := client.Sys().ListMounts()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing secrets engines: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error listing secrets engines: %s", err), err)
 		return 2
 	}
 
 	switch Format(c.UI) {
 	case "table":
 		if c.flagDetailed {
-			c.UI.Output(tableOutput(c.detailedMounts(mounts), nil))
-			return 0
+			return OutputTable(c.UI, c.detailedMounts(mounts), nil)
 		}
-		c.UI.Output(tableOutput(c.simpleMounts(mounts), nil))
-		return 0
+		return OutputTable(c.UI, c.simpleMounts(mounts), nil)
 	default:
 		return OutputData(c.UI, mounts)
 	}
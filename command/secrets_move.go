@@ -98,7 +98,7 @@ func (c *SecretsMoveCommand) Run(args []string) int {
 
 	remountResp, err := client.Sys().StartRemount(source, destination)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error moving secrets engine %s to %s: %s", source, destination, err))
+		outputError(c.UI, fmt.Sprintf("Error moving secrets engine %s to %s: %s", source, destination, err), err)
 		return 2
 	}
 
@@ -109,7 +109,7 @@ func (c *SecretsMoveCommand) Run(args []string) int {
 	for {
 		remountStatusResp, err := client.Sys().RemountStatus(remountResp.MigrationID)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error checking migration status of secrets engine %s to %s: %s", source, destination, err))
+			outputError(c.UI, fmt.Sprintf("Error checking migration status of secrets engine %s to %s: %s", source, destination, err), err)
 			return 2
 		}
 		if remountStatusResp.MigrationInfo.MigrationStatus == MountMigrationStatusSuccess {
@@ -229,7 +229,7 @@ func (c *SecretsTuneCommand) Run(args []string) int {
 	})
 
 	if err := client.Sys().TuneMount(mountPath, mountConfigInput); err != nil {
-		c.UI.Error(fmt.Sprintf("Error tuning secrets engine %s: %s", mountPath, err))
+		outputError(c.UI, fmt.Sprintf("Error tuning secrets engine %s: %s", mountPath, err), err)
 		return 2
 	}
 
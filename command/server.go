@@ -102,12 +102,18 @@ type ServerCommand struct {
 
 	reloadFuncsLock   *sync.RWMutex
 	reloadFuncs       *map[string][]reloadutil.ReloadFunc
+	listenersLock     sync.Mutex
+	listeners         []*serverListener
 	startedCh         chan (struct{}) // for tests
 	reloadedCh        chan (struct{}) // for tests
 	licenseReloadedCh chan (error)    // for tests
 
 	allLoggers []hclog.Logger
 
+	// devBootstrap is loaded from -dev-config and applied once the dev server
+	// is initialized
+	devBootstrap *devBootstrap
+
 	// new stuff
 	flagConfigs            []string
 	flagLogLevel           string
@@ -115,6 +121,7 @@ type ServerCommand struct {
 	flagRecovery           bool
 	flagDev                bool
 	flagDevRootTokenID     string
+	flagDevConfig          string
 	flagDevListenAddr      string
 	flagDevNoStoreToken    bool
 	flagDevPluginDir       string
@@ -135,6 +142,7 @@ type ServerCommand struct {
 	flagDevConsul          bool
 	flagExitOnCoreShutdown bool
 	flagDiagnose           string
+	flagTestConfig         bool
 }
 
 func (c *ServerCommand) Synopsis() string {
@@ -155,10 +163,19 @@ Usage: vault server [options]
 
       $ vault server -config=/etc/vault/config.hcl
 
+  Validate a configuration file without starting the server:
+
+      $ vault server -config=/etc/vault/config.hcl -test-config
+
   Run in "dev" mode:
 
       $ vault server -dev -dev-root-token-id="root"
 
+  Run in "dev" mode, enabling the mounts, auth methods and policies and
+  writing the secrets of a bootstrap file:
+
+      $ vault server -dev -dev-config=@bootstrap.hcl
+
   For a full list of examples, please see the documentation.
 
 ` + c.Flags().Help()
@@ -212,6 +229,16 @@ func (c *ServerCommand) Flags() *FlagSets {
 		Usage:   "Exit the vault server if the vault core is shutdown.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "test-config",
+		Target:  &c.flagTestConfig,
+		Default: false,
+		Usage: "Parse and validate the configuration given with -config, " +
+			"including the schemas of its stanzas, the permissions of the files " +
+			"it refers to and its TLS material, then exit without starting the " +
+			"server. The exit code is 1 if the configuration is invalid.",
+	})
+
 	f.BoolVar(&BoolVar{
 		Name:   "recovery",
 		Target: &c.flagRecovery,
@@ -258,6 +285,15 @@ func (c *ServerCommand) Flags() *FlagSets {
 		EnvVar:  "VAULT_DEV_LISTEN_ADDRESS",
 		Usage:   "Address to bind to in \"dev\" mode.",
 	})
+	f.StringVar(&StringVar{
+		Name:       "dev-config",
+		Target:     &c.flagDevConfig,
+		Completion: complete.PredictFiles("*.hcl"),
+		Usage: "Bootstrap file, given as @<path>, enabling secrets engines, auth " +
+			"methods and policies and writing secrets once the server is " +
+			"initialized. This implies \"dev\" mode.",
+	})
+
 	f.BoolVar(&BoolVar{
 		Name:    "dev-no-store-token",
 		Target:  &c.flagDevNoStoreToken,
@@ -508,7 +544,7 @@ func (c *ServerCommand) runRecoveryMode() int {
 	namedStorageLogger := c.logger.Named("storage." + config.Storage.Type)
 	backend, err := factory(config.Storage.Config, namedStorageLogger)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error initializing storage of type %s: %s", config.Storage.Type, err))
+		outputError(c.UI, fmt.Sprintf("Error initializing storage of type %s: %s", config.Storage.Type, err), err)
 		return 1
 	}
 
@@ -570,7 +606,7 @@ func (c *ServerCommand) runRecoveryMode() int {
 	defer func() {
 		err = seal.Finalize(context.Background())
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error finalizing seals: %v", err))
+			outputError(c.UI, fmt.Sprintf("Error finalizing seals: %v", err), err)
 		}
 	}()
 
@@ -593,7 +629,7 @@ func (c *ServerCommand) runRecoveryMode() int {
 	}
 
 	if err := core.InitializeRecovery(context.Background()); err != nil {
-		c.UI.Error(fmt.Sprintf("Error initializing core in recovery mode: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error initializing core in recovery mode: %s", err), err)
 		return 1
 	}
 
@@ -611,7 +647,7 @@ func (c *ServerCommand) runRecoveryMode() int {
 	for _, lnConfig := range config.Listeners {
 		ln, _, _, err := server.NewListener(lnConfig, c.gatedWriter, c.UI)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error initializing listener of type %s: %s", lnConfig.Type, err))
+			outputError(c.UI, fmt.Sprintf("Error initializing listener of type %s: %s", lnConfig.Type, err), err)
 			return 1
 		}
 
@@ -689,7 +725,7 @@ func (c *ServerCommand) runRecoveryMode() int {
 	if sealConfigError != nil {
 		init, err := core.InitializedLocally(context.Background())
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error checking if core is initialized: %v", err))
+			outputError(c.UI, fmt.Sprintf("Error checking if core is initialized: %v", err), err)
 			return 1
 		}
 		if init {
@@ -719,7 +755,7 @@ func (c *ServerCommand) runRecoveryMode() int {
 			c.cleanupGuard.Do(listenerCloseFunc)
 
 			if err := core.Shutdown(); err != nil {
-				c.UI.Error(fmt.Sprintf("Error with core shutdown: %s", err))
+				outputError(c.UI, fmt.Sprintf("Error with core shutdown: %s", err), err)
 			}
 
 			return 0
@@ -931,9 +967,7 @@ func (c *ServerCommand) InitListeners(config *server.Config, disableClustering b
 		}
 
 		if reloadFunc != nil {
-			relSlice := (*c.reloadFuncs)["listener|"+lnConfig.Type]
-			relSlice = append(relSlice, reloadFunc)
-			(*c.reloadFuncs)["listener|"+lnConfig.Type] = relSlice
+			(*c.reloadFuncs)["listener|"+listenerKey(lnConfig)] = []reloadutil.ReloadFunc{reloadFunc}
 		}
 
 		if !disableClustering && lnConfig.Type == "tcp" {
@@ -1005,15 +1039,33 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagTestConfig {
+		return c.testConfig()
+	}
+
 	if c.flagRecovery {
 		return c.runRecoveryMode()
 	}
 
 	// Automatically enable dev mode if other dev flags are provided.
-	if c.flagDevConsul || c.flagDevHA || c.flagDevTransactional || c.flagDevLeasedKV || c.flagDevThreeNode || c.flagDevFourCluster || c.flagDevAutoSeal || c.flagDevKVV1 {
+	if c.flagDevConsul || c.flagDevHA || c.flagDevTransactional || c.flagDevLeasedKV || c.flagDevThreeNode || c.flagDevFourCluster || c.flagDevAutoSeal || c.flagDevKVV1 || c.flagDevConfig != "" {
 		c.flagDev = true
 	}
 
+	if c.flagDevConfig != "" {
+		if c.flagDevThreeNode || c.flagDevFourCluster || c.flagDevSkipInit {
+			c.UI.Error("The -dev-config flag cannot be used with -dev-three-node, -dev-four-cluster or -dev-skip-init")
+			return 1
+		}
+
+		bootstrap, err := loadDevBootstrap(c.flagDevConfig)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error loading the dev bootstrap file: %s", err), err)
+			return 1
+		}
+		c.devBootstrap = bootstrap
+	}
+
 	// Validation
 	if !c.flagDev {
 		switch {
@@ -1192,9 +1244,10 @@ func (c *ServerCommand) Run(args []string) int {
 		DisplayName: "Vault",
 		UserAgent:   useragent.String(),
 		ClusterName: config.ClusterName,
+		Logger:      c.logger.Named("telemetry"),
 	})
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error initializing telemetry: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error initializing telemetry: %s", err), err)
 		return 1
 	}
 	metricsHelper := metricsutil.NewMetricsHelper(inmemMetrics, prometheusEnabled)
@@ -1239,7 +1292,7 @@ func (c *ServerCommand) Run(args []string) int {
 			defer func(seal *vault.Seal) {
 				err = (*seal).Finalize(context.Background())
 				if err != nil {
-					c.UI.Error(fmt.Sprintf("Error finalizing seals: %v", err))
+					outputError(c.UI, fmt.Sprintf("Error finalizing seals: %v", err), err)
 				}
 			}(&seal)
 		}
@@ -1363,13 +1416,15 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Make sure we close all listeners from this point on
-	listenerCloseFunc := func() {
-		for _, ln := range lns {
-			ln.Listener.Close()
-		}
+	// Keep track of the listeners, which are added and removed when the
+	// configuration is reloaded
+	for _, ln := range lns {
+		c.listeners = append(c.listeners, &serverListener{Listener: ln})
 	}
 
+	// Make sure we close all listeners from this point on
+	listenerCloseFunc := c.closeListeners
+
 	defer c.cleanupGuard.Do(listenerCloseFunc)
 
 	infoKeys = append(infoKeys, "version")
@@ -1460,8 +1515,12 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
+	core.SetListenerReloader(func() ([]string, []string, error) {
+		return c.reloadListenerConfig(core)
+	})
+
 	// Initialize the HTTP servers
-	err = startHttpServers(c, core, config, lns)
+	err = startHttpServers(c, core, config, c.listeners)
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 1
@@ -1474,7 +1533,7 @@ func (c *ServerCommand) Run(args []string) int {
 	if sealConfigError != nil {
 		init, err := core.InitializedLocally(context.Background())
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error checking if core is initialized: %v", err))
+			outputError(c.UI, fmt.Sprintf("Error checking if core is initialized: %v", err), err)
 			return 1
 		}
 		if init {
@@ -1499,7 +1558,7 @@ func (c *ServerCommand) Run(args []string) int {
 
 	// Write out the PID to the file now that server has successfully started
 	if err := c.storePidFile(config.PidFile); err != nil {
-		c.UI.Error(fmt.Sprintf("Error storing PID: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error storing PID: %s", err), err)
 		return 1
 	}
 
@@ -1508,7 +1567,7 @@ func (c *ServerCommand) Run(args []string) int {
 
 	defer func() {
 		if err := c.removePidFile(config.PidFile); err != nil {
-			c.UI.Error(fmt.Sprintf("Error deleting the PID file: %s", err))
+			outputError(c.UI, fmt.Sprintf("Error deleting the PID file: %s", err), err)
 		}
 	}()
 
@@ -1539,23 +1598,9 @@ func (c *ServerCommand) Run(args []string) int {
 			// Check for new log level
 			var config *server.Config
 			var level hclog.Level
-			for _, path := range c.flagConfigs {
-				current, err := server.LoadConfig(path)
-				if err != nil {
-					c.logger.Error("could not reload config", "path", path, "error", err)
-					goto RUNRELOADFUNCS
-				}
-
-				if config == nil {
-					config = current
-				} else {
-					config = config.Merge(current)
-				}
-			}
-
-			// Ensure at least one config was found.
-			if config == nil {
-				c.logger.Error("no config found at reload time")
+			config, err = c.loadReloadConfig()
+			if err != nil {
+				c.logger.Error(err.Error())
 				goto RUNRELOADFUNCS
 			}
 
@@ -1570,6 +1615,10 @@ func (c *ServerCommand) Run(args []string) int {
 			// Setting log request with the new value in the config after reload
 			core.ReloadLogRequestsLevel()
 
+			if _, _, err := c.reloadListeners(core, config); err != nil {
+				c.logger.Error("error reloading listeners", "error", err)
+			}
+
 			if config.LogLevel != "" {
 				configLogLevel := strings.ToLower(strings.TrimSpace(config.LogLevel))
 				switch configLogLevel {
@@ -1592,7 +1641,7 @@ func (c *ServerCommand) Run(args []string) int {
 
 		RUNRELOADFUNCS:
 			if err := c.Reload(c.reloadFuncsLock, c.reloadFuncs, c.flagConfigs); err != nil {
-				c.UI.Error(fmt.Sprintf("Error(s) were encountered during reload: %s", err))
+				outputError(c.UI, fmt.Sprintf("Error(s) were encountered during reload: %s", err), err)
 			}
 
 			// Reload license file
@@ -1624,7 +1673,7 @@ func (c *ServerCommand) Run(args []string) int {
 	// request forwarding listeners will also be closed (and also
 	// waited for).
 	if err := core.Shutdown(); err != nil {
-		c.UI.Error(fmt.Sprintf("Error with core shutdown: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error with core shutdown: %s", err), err)
 	}
 
 	// Wait for dependent goroutines to complete
@@ -1873,7 +1922,7 @@ func (c *ServerCommand) enableThreeNodeDevCluster(base *vault.CoreConfig, info m
 		}
 		resp, err := testCluster.Cores[0].HandleRequest(ctx, req)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("failed to create root token with ID %s: %s", base.DevToken, err))
+			outputError(c.UI, fmt.Sprintf("failed to create root token with ID %s: %s", base.DevToken, err), err)
 			return 1
 		}
 		if resp == nil {
@@ -1900,16 +1949,16 @@ func (c *ServerCommand) enableThreeNodeDevCluster(base *vault.CoreConfig, info m
 	// Set the token
 	tokenHelper, err := c.TokenHelper()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error getting token helper: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error getting token helper: %s", err), err)
 		return 1
 	}
 	if err := tokenHelper.Store(testCluster.RootToken); err != nil {
-		c.UI.Error(fmt.Sprintf("Error storing in token helper: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error storing in token helper: %s", err), err)
 		return 1
 	}
 
 	if err := ioutil.WriteFile(filepath.Join(testCluster.TempDir, "root_token"), []byte(testCluster.RootToken), 0o755); err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing token to tempfile: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error writing token to tempfile: %s", err), err)
 		return 1
 	}
 
@@ -1968,7 +2017,7 @@ func (c *ServerCommand) enableThreeNodeDevCluster(base *vault.CoreConfig, info m
 			// waited for).
 			for _, core := range testCluster.Cores {
 				if err := core.Shutdown(); err != nil {
-					c.UI.Error(fmt.Sprintf("Error with core shutdown: %s", err))
+					outputError(c.UI, fmt.Sprintf("Error with core shutdown: %s", err), err)
 				}
 			}
 
@@ -1978,7 +2027,7 @@ func (c *ServerCommand) enableThreeNodeDevCluster(base *vault.CoreConfig, info m
 			c.UI.Output("==> Vault reload triggered")
 			for _, core := range testCluster.Cores {
 				if err := c.Reload(core.ReloadFuncsLock, core.ReloadFuncs, nil); err != nil {
-					c.UI.Error(fmt.Sprintf("Error(s) were encountered during reload: %s", err))
+					outputError(c.UI, fmt.Sprintf("Error(s) were encountered during reload: %s", err), err)
 				}
 			}
 		}
@@ -2245,7 +2294,54 @@ func setSeal(c *ServerCommand, config *server.Config, infoKeys []string, info ma
 		}
 	}
 	var createdSeals []vault.Seal = make([]vault.Seal, len(config.Seals))
+
+	// Several enabled seals are combined in a seal that fails over between
+	// them by priority
+	configSeals := config.Seals
+	var failoverSeals []*configutil.KMS
 	for _, configSeal := range config.Seals {
+		if !configSeal.Disabled {
+			failoverSeals = append(failoverSeals, configSeal)
+		}
+	}
+	if len(failoverSeals) > 1 {
+		sort.SliceStable(failoverSeals, func(i, j int) bool {
+			return failoverSeals[i].Priority < failoverSeals[j].Priority
+		})
+
+		sealLogger := c.logger.ResetNamed("seal")
+		c.allLoggers = append(c.allLoggers, sealLogger)
+		var sealInfoKeys []string
+		sealInfoMap := map[string]string{}
+		wrapper, err := configutil.ConfigureFailoverWrapper(failoverSeals, &sealInfoKeys, &sealInfoMap, sealLogger)
+		if err != nil {
+			return barrierSeal, barrierWrapper, unwrapSeal, createdSeals, err, fmt.Errorf(
+				"Error parsing Seal configuration: %s", err)
+		}
+
+		// The seal keeps the type of the seal with the highest priority, so
+		// that seals can be added to an existing seal without a migration
+		access := &vaultseal.Access{
+			Wrapper: wrapper,
+		}
+		access.SetType(failoverSeals[0].Type)
+		barrierSeal = vault.NewAutoSeal(access)
+		barrierWrapper = wrapper
+		for _, k := range sealInfoKeys {
+			infoKeys = append(infoKeys, k)
+			info[k] = sealInfoMap[k]
+		}
+		createdSeals = append(createdSeals, barrierSeal)
+
+		configSeals = nil
+		for _, configSeal := range config.Seals {
+			if configSeal.Disabled {
+				configSeals = append(configSeals, configSeal)
+			}
+		}
+	}
+
+	for _, configSeal := range configSeals {
 		sealType := wrapping.Shamir
 		if !configSeal.Disabled && os.Getenv("VAULT_SEAL_TYPE") != "" {
 			sealType = os.Getenv("VAULT_SEAL_TYPE")
@@ -2555,6 +2651,13 @@ func initDevCore(c *ServerCommand, coreConfig *vault.CoreConfig, config *server.
 			return fmt.Errorf("Error initializing Dev mode: %s", err)
 		}
 
+		if c.devBootstrap != nil {
+			if err := c.devBootstrap.apply(core, init.RootToken); err != nil {
+				return fmt.Errorf("Error applying the dev bootstrap file: %s", err)
+			}
+			c.logger.Info("applied dev bootstrap file", "path", strings.TrimPrefix(c.flagDevConfig, "@"), "contents", c.devBootstrap.summary())
+		}
+
 		var plugins, pluginsNotLoaded []string
 		if c.flagDevPluginDir != "" && c.flagDevPluginInit {
 
@@ -2663,60 +2766,71 @@ func initDevCore(c *ServerCommand, coreConfig *vault.CoreConfig, config *server.
 }
 
 // Initialize the HTTP servers
-func startHttpServers(c *ServerCommand, core *vault.Core, config *server.Config, lns []listenerutil.Listener) error {
+func startHttpServers(c *ServerCommand, core *vault.Core, config *server.Config, lns []*serverListener) error {
 	for _, ln := range lns {
-		if ln.Config == nil {
-			return fmt.Errorf("Found nil listener config after parsing")
-		}
-
-		if err := config2.IsValidListener(ln.Config); err != nil {
+		server, err := newHTTPServer(c, core, config, ln.Listener)
+		if err != nil {
 			return err
 		}
-
-		handler := vaulthttp.Handler(&vault.HandlerProperties{
-			Core:                  core,
-			ListenerConfig:        ln.Config,
-			DisablePrintableCheck: config.DisablePrintableCheck,
-			RecoveryMode:          c.flagRecovery,
-		})
-
-		if len(ln.Config.XForwardedForAuthorizedAddrs) > 0 {
-			handler = vaulthttp.WrapForwardedForHandler(handler, ln.Config)
-		}
-
-		// server defaults
-		server := &http.Server{
-			Handler:           handler,
-			ReadHeaderTimeout: 10 * time.Second,
-			ReadTimeout:       30 * time.Second,
-			IdleTimeout:       5 * time.Minute,
-			ErrorLog:          c.logger.StandardLogger(nil),
-		}
-
-		// override server defaults with config values for read/write/idle timeouts if configured
-		if ln.Config.HTTPReadHeaderTimeout > 0 {
-			server.ReadHeaderTimeout = ln.Config.HTTPReadHeaderTimeout
-		}
-		if ln.Config.HTTPReadTimeout > 0 {
-			server.ReadTimeout = ln.Config.HTTPReadTimeout
-		}
-		if ln.Config.HTTPWriteTimeout > 0 {
-			server.WriteTimeout = ln.Config.HTTPWriteTimeout
-		}
-		if ln.Config.HTTPIdleTimeout > 0 {
-			server.IdleTimeout = ln.Config.HTTPIdleTimeout
-		}
+		ln.server = server
 
 		// server config tests can exit now
 		if c.flagTestServerConfig {
 			continue
 		}
 
-		go server.Serve(ln.Listener)
+		go server.Serve(ln.Listener.Listener)
 	}
 	return nil
 }
 
+// newHTTPServer returns the HTTP server for a listener.
+func newHTTPServer(c *ServerCommand, core *vault.Core, config *server.Config, ln listenerutil.Listener) (*http.Server, error) {
+	if ln.Config == nil {
+		return nil, fmt.Errorf("Found nil listener config after parsing")
+	}
+
+	if err := config2.IsValidListener(ln.Config); err != nil {
+		return nil, err
+	}
+
+	handler := vaulthttp.Handler(&vault.HandlerProperties{
+		Core:                  core,
+		ListenerConfig:        ln.Config,
+		DisablePrintableCheck: config.DisablePrintableCheck,
+		RecoveryMode:          c.flagRecovery,
+	})
+
+	if len(ln.Config.XForwardedForAuthorizedAddrs) > 0 {
+		handler = vaulthttp.WrapForwardedForHandler(handler, ln.Config)
+	}
+
+	// server defaults
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       5 * time.Minute,
+		ErrorLog:          c.logger.StandardLogger(nil),
+	}
+
+	// override server defaults with config values for read/write/idle timeouts if configured
+	if ln.Config.HTTPReadHeaderTimeout > 0 {
+		server.ReadHeaderTimeout = ln.Config.HTTPReadHeaderTimeout
+	}
+	if ln.Config.HTTPReadTimeout > 0 {
+		server.ReadTimeout = ln.Config.HTTPReadTimeout
+	}
+	if ln.Config.HTTPWriteTimeout > 0 {
+		server.WriteTimeout = ln.Config.HTTPWriteTimeout
+	}
+	if ln.Config.HTTPIdleTimeout > 0 {
+		server.IdleTimeout = ln.Config.HTTPIdleTimeout
+	}
+
+	return server, nil
+}
+
 func SetStorageMigration(b physical.Backend, active bool) error {
 	if !active {
 		return b.Delete(context.Background(), storageMigrationLock)
@@ -364,11 +364,38 @@ func CheckConfig(c *Config, e error) (*Config, error) {
 		switch {
 		case c.Seals[0].Disabled && c.Seals[1].Disabled:
 			return nil, errors.New("seals: two seals provided but both are disabled")
-		case !c.Seals[0].Disabled && !c.Seals[1].Disabled:
+		case !c.Seals[0].Disabled && !c.Seals[1].Disabled && (c.Seals[0].Priority == 0 || c.Seals[1].Priority == 0):
 			return nil, errors.New("seals: two seals provided but neither is disabled")
 		}
 	}
 
+	// Several enabled seals are combined in a seal that fails over between
+	// them, which requires each of them to have a distinct priority
+	var enabled []*configutil.KMS
+	var disabled int
+	for _, seal := range c.Seals {
+		if seal.Disabled {
+			disabled++
+			continue
+		}
+		enabled = append(enabled, seal)
+	}
+	if disabled > 1 {
+		return nil, errors.New("seals: more than one seal is disabled")
+	}
+	if len(enabled) > 1 {
+		priorities := make(map[int]bool, len(enabled))
+		for _, seal := range enabled {
+			switch {
+			case seal.Priority == 0:
+				return nil, errors.New("seals: multiple seals provided but not all of them have a priority")
+			case priorities[seal.Priority]:
+				return nil, fmt.Errorf("seals: multiple seals provided with priority %d", seal.Priority)
+			}
+			priorities[seal.Priority] = true
+		}
+	}
+
 	return c, nil
 }
 
@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/internalshared/configutil"
 )
 
@@ -82,6 +83,7 @@ func testLoadConfigFile_topLevel(t *testing.T, entropy *configutil.Entropy) {
 				DogStatsDAddr:               "127.0.0.1:7254",
 				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
 				PrometheusRetentionTime:     30 * time.Second,
+				OTLPExportInterval:          metricsutil.OTLPDefaultExportInterval,
 				UsageGaugePeriod:            5 * time.Minute,
 				MaximumGaugeCardinality:     125,
 				LeaseMetricsEpsilon:         time.Hour,
@@ -211,6 +213,7 @@ func testLoadConfigFile_json2(t *testing.T, entropy *configutil.Entropy) {
 				CirconusBrokerID:                   "0",
 				CirconusBrokerSelectTag:            "dc:sfo",
 				PrometheusRetentionTime:            30 * time.Second,
+				OTLPExportInterval:                 metricsutil.OTLPDefaultExportInterval,
 				LeaseMetricsEpsilon:                time.Hour,
 				NumLeaseMetricsTimeBuckets:         168,
 				LeaseMetricsNameSpaceLabels:        false,
@@ -403,6 +406,7 @@ func testLoadConfigFile(t *testing.T) {
 				DogStatsDAddr:               "127.0.0.1:7254",
 				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
 				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
+				OTLPExportInterval:          metricsutil.OTLPDefaultExportInterval,
 				MetricsPrefix:               "myprefix",
 				LeaseMetricsEpsilon:         time.Hour,
 				NumLeaseMetricsTimeBuckets:  168,
@@ -561,6 +565,7 @@ func testLoadConfigFile_json(t *testing.T) {
 				CirconusBrokerID:                   "",
 				CirconusBrokerSelectTag:            "",
 				PrometheusRetentionTime:            configutil.PrometheusDefaultRetentionTime,
+				OTLPExportInterval:                 metricsutil.OTLPDefaultExportInterval,
 				LeaseMetricsEpsilon:                time.Hour,
 				NumLeaseMetricsTimeBuckets:         168,
 				LeaseMetricsNameSpaceLabels:        false,
@@ -634,6 +639,7 @@ func testLoadConfigDir(t *testing.T) {
 				UsageGaugePeriod:            5 * time.Minute,
 				MaximumGaugeCardinality:     100,
 				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
+				OTLPExportInterval:          metricsutil.OTLPDefaultExportInterval,
 				LeaseMetricsEpsilon:         time.Hour,
 				NumLeaseMetricsTimeBuckets:  168,
 				LeaseMetricsNameSpaceLabels: false,
@@ -724,6 +730,7 @@ func testConfig_Sanitized(t *testing.T) {
 		"seals": []interface{}{
 			map[string]interface{}{
 				"disabled": false,
+				"priority": 0,
 				"type":     "awskms",
 			},
 		},
@@ -761,6 +768,12 @@ func testConfig_Sanitized(t *testing.T) {
 			"stackdriver_namespace":                  "",
 			"stackdriver_project_id":                 "",
 			"stackdriver_debug_logs":                 false,
+			"otlp_endpoint":                          "",
+			"otlp_protocol":                          "",
+			"otlp_export_interval":                   time.Minute,
+			"otlp_headers":                           "",
+			"otlp_resource_attributes":               map[string]string(nil),
+			"otlp_tls_ca_file":                       "",
 			"statsd_address":                         "bar",
 			"statsite_address":                       "",
 			"lease_metrics_epsilon":                  time.Hour,
@@ -947,6 +960,41 @@ func testParseSeals(t *testing.T) {
 	require.Equal(t, config, expected)
 }
 
+func testParseSealsFailover(t *testing.T) {
+	config, err := CheckConfig(LoadConfigFile("./test-fixtures/config_seals_failover.hcl"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []*configutil.KMS{
+		{
+			Type:     "awskms",
+			Priority: 1,
+			Config: map[string]string{
+				"region":     "us-east-1",
+				"kms_key_id": "19ec80b0-dfdd-4d97-8164-c6examplekey",
+			},
+		},
+		{
+			Type:     "awskms",
+			Priority: 2,
+			Config: map[string]string{
+				"region":     "us-west-2",
+				"kms_key_id": "mrk-1234abcd12ab34cd56ef1234567890ab",
+			},
+		},
+	}
+	require.Equal(t, expected, config.Seals)
+
+	// Each of the enabled seals needs a distinct priority
+	for _, priorities := range [][]int{{1, 0}, {2, 2}} {
+		config.Seals[0].Priority, config.Seals[1].Priority = priorities[0], priorities[1]
+		if _, err := CheckConfig(config, nil); err == nil {
+			t.Fatalf("expected an error for priorities %v", priorities)
+		}
+	}
+}
+
 func testLoadConfigFileLeaseMetrics(t *testing.T) {
 	config, err := LoadConfigFile("./test-fixtures/config5.hcl")
 	if err != nil {
@@ -972,6 +1020,7 @@ func testLoadConfigFileLeaseMetrics(t *testing.T) {
 				DogStatsDAddr:               "127.0.0.1:7254",
 				DogStatsDTags:               []string{"tag_1:val_1", "tag_2:val_2"},
 				PrometheusRetentionTime:     configutil.PrometheusDefaultRetentionTime,
+				OTLPExportInterval:          metricsutil.OTLPDefaultExportInterval,
 				MetricsPrefix:               "myprefix",
 				LeaseMetricsEpsilon:         time.Hour,
 				NumLeaseMetricsTimeBuckets:  2,
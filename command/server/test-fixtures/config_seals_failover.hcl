@@ -0,0 +1,18 @@
+listener "tcp" {
+  address = "127.0.0.1:443"
+}
+
+backend "consul" {
+}
+
+seal "awskms" {
+  priority = 1
+  region = "us-east-1"
+  kms_key_id = "19ec80b0-dfdd-4d97-8164-c6examplekey"
+}
+
+seal "awskms" {
+  priority = "2"
+  region = "us-west-2"
+  kms_key_id = "mrk-1234abcd12ab34cd56ef1234567890ab"
+}
@@ -0,0 +1,254 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/internalshared/listenerutil"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/vault/diagnose"
+	"github.com/hashicorp/vault/vault/seal/tpm"
+)
+
+// serverConfigCheck collects the problems found in a server configuration by
+// -test-config.
+type serverConfigCheck struct {
+	errors   []string
+	warnings []string
+}
+
+func (s *serverConfigCheck) errorf(format string, args ...interface{}) {
+	s.errors = append(s.errors, fmt.Sprintf(format, args...))
+}
+
+func (s *serverConfigCheck) warnf(format string, args ...interface{}) {
+	s.warnings = append(s.warnings, fmt.Sprintf(format, args...))
+}
+
+// testConfig parses and validates the configuration files given with -config,
+// loading the files they refer to, and returns without starting the server.
+// Nothing is written and no connection is made, so storage and seals are only
+// checked against their schemas.
+func (c *ServerCommand) testConfig() int {
+	if len(c.flagConfigs) == 0 {
+		c.UI.Error("Must specify at least one config path using -config")
+		return 1
+	}
+
+	check := &serverConfigCheck{}
+	var config *server.Config
+	for _, path := range c.flagConfigs {
+		checkConfigFilePerms(check, path)
+
+		current, err := server.LoadConfig(path)
+		if err != nil {
+			check.errorf("Error loading configuration from %s: %s", path, err)
+			continue
+		}
+		for _, configErr := range current.Validate(path) {
+			check.errorf("%s", configErr.String())
+		}
+
+		if config == nil {
+			config = current
+		} else {
+			config = config.Merge(current)
+		}
+	}
+
+	if config != nil {
+		c.checkStorageConfig(check, config)
+		checkSealConfig(check, config)
+		c.checkListenerConfig(check, config)
+		checkAddrConfig(check, "api_addr", config.APIAddr)
+		checkAddrConfig(check, "cluster_addr", config.ClusterAddr)
+		checkPluginDirectoryConfig(check, config.PluginDirectory)
+	}
+
+	for _, warning := range check.warnings {
+		c.UI.Warn(fmt.Sprintf("Warning: %s", warning))
+	}
+	for _, err := range check.errors {
+		c.UI.Error(fmt.Sprintf("Error: %s", err))
+	}
+	if len(check.errors) > 0 {
+		c.UI.Error(fmt.Sprintf("Configuration is invalid: found %d errors and %d warnings", len(check.errors), len(check.warnings)))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("Configuration is valid, with %d warnings", len(check.warnings)))
+	return 0
+}
+
+// checkConfigFilePerms warns about configuration files that can be modified
+// by other users, as they could change the configuration of the server.
+func checkConfigFilePerms(check *serverConfigCheck, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		// The error is reported when the file is loaded
+		return
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, ext := range []string{"*.hcl", "*.json"} {
+			matches, _ := filepath.Glob(filepath.Join(path, ext))
+			files = append(files, matches...)
+		}
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && info.Mode().Perm()&0o002 != 0 {
+			check.warnf("configuration file %s is writable by any user", file)
+		}
+	}
+}
+
+func (c *ServerCommand) checkStorageConfig(check *serverConfigCheck, config *server.Config) {
+	if config.Storage == nil {
+		check.errorf("A storage backend must be specified")
+		return
+	}
+	if _, ok := c.PhysicalBackends[config.Storage.Type]; !ok {
+		check.errorf("Unknown storage type %s", config.Storage.Type)
+	}
+
+	if config.Storage.Type == storageTypeRaft {
+		if config.ClusterAddr == "" && os.Getenv("VAULT_CLUSTER_ADDR") == "" {
+			check.errorf("Cluster address must be set when using raft storage")
+		}
+
+		path := os.Getenv(raft.EnvVaultRaftPath)
+		if path == "" {
+			path = config.Storage.Config["path"]
+		}
+		switch info, err := os.Stat(path); {
+		case path == "":
+			check.errorf("raft storage: path must be set")
+		case err != nil:
+			check.errorf("raft storage: %s", err)
+		case !info.IsDir():
+			check.errorf("raft storage: path %s is not a directory", path)
+		default:
+			if _, warnings := diagnose.CheckFilePerms(info); len(warnings) > 0 {
+				check.warnf("raft storage: path %s has %s", path, strings.Join(warnings, ", "))
+			}
+		}
+	}
+
+	if config.HAStorage != nil {
+		switch {
+		case config.Storage.Type == storageTypeRaft && config.HAStorage.Type == storageTypeRaft:
+			check.errorf("Raft cannot be set both as 'storage' and 'ha_storage'")
+		case config.Storage.Type == storageTypeRaft:
+			check.errorf("HA storage cannot be declared when Raft is the storage type")
+		}
+		if _, ok := c.PhysicalBackends[config.HAStorage.Type]; !ok {
+			check.errorf("Unknown HA storage type %s", config.HAStorage.Type)
+		}
+	}
+
+	if config.ServiceRegistration != nil {
+		if _, ok := c.ServiceRegistrations[config.ServiceRegistration.Type]; !ok {
+			check.errorf("Unknown service_registration type %s", config.ServiceRegistration.Type)
+		}
+	}
+}
+
+func checkSealConfig(check *serverConfigCheck, config *server.Config) {
+	for _, seal := range config.Seals {
+		switch seal.Type {
+		case wrapping.Shamir, wrapping.AEAD, wrapping.AliCloudKMS, wrapping.AWSKMS, wrapping.AzureKeyVault,
+			wrapping.GCPCKMS, wrapping.OCIKMS, wrapping.Transit, tpm.WrapperType:
+		case wrapping.PKCS11:
+			check.errorf("KMS type 'pkcs11' requires the Vault Enterprise HSM binary")
+		default:
+			check.errorf("Unknown seal type %q", seal.Type)
+		}
+	}
+
+	// A single disabled seal is a migration to shamir
+	enabled := 0
+	for _, seal := range config.Seals {
+		if !seal.Disabled {
+			enabled++
+		}
+	}
+	if enabled == 0 && len(config.Seals) > 1 {
+		check.errorf("All the configured seals are disabled")
+	}
+}
+
+// checkListenerConfig checks the addresses of the listeners and loads their
+// TLS material, as is done when the server starts.
+func (c *ServerCommand) checkListenerConfig(check *serverConfigCheck, config *server.Config) {
+	if len(config.Listeners) == 0 {
+		check.errorf("No listener is configured")
+		return
+	}
+
+	for i, l := range config.Listeners {
+		name := fmt.Sprintf("listener %d (%s %s)", i+1, l.Type, l.Address)
+		if _, _, err := net.SplitHostPort(l.Address); l.Type == "tcp" && err != nil {
+			check.errorf("%s: invalid address: %s", name, err)
+		}
+		if l.TLSDisable {
+			continue
+		}
+
+		warnings, err := diagnose.TLSFileChecks(l.TLSCertFile, l.TLSKeyFile)
+		for _, warning := range warnings {
+			check.warnf("%s: %s", name, warning)
+		}
+		if err != nil {
+			check.errorf("%s: error loading TLS certificate: %s", name, err)
+			continue
+		}
+		if info, err := os.Stat(l.TLSKeyFile); err == nil && info.Mode().Perm()&0o004 != 0 {
+			check.warnf("%s: TLS key file %s is readable by any user", name, l.TLSKeyFile)
+		}
+
+		if _, _, err := listenerutil.TLSConfig(l, map[string]string{}, c.UI); err != nil {
+			check.errorf("%s: %s", name, err)
+		}
+	}
+}
+
+func checkAddrConfig(check *serverConfigCheck, name, addr string) {
+	if addr == "" {
+		return
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		check.errorf("%s: %s", name, err)
+		return
+	}
+	if u.Scheme == "" || u.Host == "" {
+		check.errorf("%s: %q must be a URL with a scheme and a host", name, addr)
+	}
+}
+
+func checkPluginDirectoryConfig(check *serverConfigCheck, dir string) {
+	if dir == "" {
+		return
+	}
+	info, err := os.Stat(dir)
+	switch {
+	case err != nil:
+		check.errorf("plugin_directory: %s", err)
+	case !info.IsDir():
+		check.errorf("plugin_directory: %s is not a directory", dir)
+	case info.Mode().Perm()&0o002 != 0:
+		check.warnf("plugin_directory: %s is writable by any user", dir)
+	default:
+		if _, err := ioutil.ReadDir(dir); err != nil {
+			check.errorf("plugin_directory: %s", err)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/hclutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// devBootstrap is the content of the file given with -dev-config, which sets
+// up a dev server once it is initialized, e.g.:
+//
+//	policy "app" {
+//	  file = "policies/app.hcl"
+//	}
+//
+//	secrets_engine "transit" {
+//	  type = "transit"
+//	}
+//
+//	auth_method "userpass" {
+//	  type = "userpass"
+//	}
+//
+//	secret "secret/app/config" {
+//	  data = {
+//	    username = "app"
+//	  }
+//	}
+//
+//	write "auth/userpass/users/alice" {
+//	  data = {
+//	    password = "training"
+//	    policies = "app"
+//	  }
+//	}
+//
+// The blocks are applied in that order: policies, then secrets engines, auth
+// methods, secrets and finally arbitrary writes, each in the order they are
+// given in the file.
+type devBootstrap struct {
+	Policies       []*devBootstrapPolicy `hcl:"policy"`
+	SecretsEngines []*devBootstrapMount  `hcl:"secrets_engine"`
+	AuthMethods    []*devBootstrapMount  `hcl:"auth_method"`
+	Secrets        []*devBootstrapWrite  `hcl:"secret"`
+	Writes         []*devBootstrapWrite  `hcl:"write"`
+}
+
+// devBootstrapPolicy is an ACL policy, whose rules are given inline or read
+// from a file relative to the bootstrap file.
+type devBootstrapPolicy struct {
+	Name  string `hcl:",key"`
+	Rules string `hcl:"rules"`
+	File  string `hcl:"file"`
+}
+
+// devBootstrapMount is a secrets engine or an auth method to enable at Path.
+type devBootstrapMount struct {
+	Path        string                 `hcl:",key"`
+	Type        string                 `hcl:"type"`
+	Description string                 `hcl:"description"`
+	Local       bool                   `hcl:"local"`
+	SealWrap    bool                   `hcl:"seal_wrap"`
+	Config      map[string]interface{} `hcl:"config"`
+	Options     map[string]string      `hcl:"options"`
+}
+
+// devBootstrapWrite is data written to Path. For secrets, Path is the path of
+// the secret in a KV secrets engine of either version.
+type devBootstrapWrite struct {
+	Path string                 `hcl:",key"`
+	Data map[string]interface{} `hcl:"data"`
+}
+
+// loadDevBootstrap reads the bootstrap file at path, which may be given with
+// a leading @ like the files of other commands. The policy files it refers to
+// are read as well, so that errors are reported before the server starts.
+func loadDevBootstrap(path string) (*devBootstrap, error) {
+	path = strings.TrimPrefix(path, "@")
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := parseDevBootstrap(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %q: %w", path, err)
+	}
+
+	for _, p := range b.Policies {
+		if p.File == "" {
+			continue
+		}
+		file := p.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+		rules, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the rules of policy %q: %w", p.Name, err)
+		}
+		p.Rules = string(rules)
+	}
+	return b, nil
+}
+
+func parseDevBootstrap(contents string) (*devBootstrap, error) {
+	root, err := hcl.Parse(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse bootstrap file; does not contain a root object")
+	}
+
+	valid := []string{
+		"policy",
+		"secrets_engine",
+		"auth_method",
+		"secret",
+		"write",
+	}
+	if err := hclutil.CheckHCLKeys(list, valid); err != nil {
+		return nil, err
+	}
+
+	var b devBootstrap
+	if err := hcl.DecodeObject(&b, list); err != nil {
+		return nil, err
+	}
+
+	for _, p := range b.Policies {
+		switch {
+		case p.Rules == "" && p.File == "":
+			return nil, fmt.Errorf("policy %q: one of rules or file must be set", p.Name)
+		case p.Rules != "" && p.File != "":
+			return nil, fmt.Errorf("policy %q: only one of rules or file can be set", p.Name)
+		}
+	}
+	for _, m := range append(b.SecretsEngines, b.AuthMethods...) {
+		if m.Type == "" {
+			return nil, fmt.Errorf("mount %q: type must be set", m.Path)
+		}
+	}
+	for _, s := range b.Secrets {
+		if len(s.Data) == 0 {
+			return nil, fmt.Errorf("secret %q: data must be set", s.Path)
+		}
+	}
+	for _, w := range append(b.Secrets, b.Writes...) {
+		for k, v := range w.Data {
+			w.Data[k] = devBootstrapValue(v)
+		}
+	}
+	return &b, nil
+}
+
+// devBootstrapValue turns the nested objects of data, which HCL decodes as
+// lists of objects, back into objects.
+func devBootstrapValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []map[string]interface{}:
+		if len(v) != 1 {
+			return v
+		}
+		for k, nested := range v[0] {
+			v[0][k] = devBootstrapValue(nested)
+		}
+		return v[0]
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = devBootstrapValue(nested)
+		}
+	}
+	return v
+}
+
+// apply sets up the dev server with the root token.
+func (b *devBootstrap) apply(core *vault.Core, rootToken string) error {
+	ctx := namespace.ContextWithNamespace(context.Background(), namespace.RootNamespace)
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		resp, err := core.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			ClientToken: rootToken,
+			Path:        path,
+			Data:        data,
+		})
+		if err == nil && resp.IsError() {
+			err = resp.Error()
+		}
+		return resp, err
+	}
+
+	for _, p := range b.Policies {
+		if _, err := request(logical.UpdateOperation, "sys/policies/acl/"+p.Name, map[string]interface{}{
+			"policy": p.Rules,
+		}); err != nil {
+			return fmt.Errorf("error writing policy %q: %w", p.Name, err)
+		}
+	}
+
+	for _, m := range b.SecretsEngines {
+		if _, err := request(logical.UpdateOperation, "sys/mounts/"+strings.Trim(m.Path, "/"), m.data()); err != nil {
+			return fmt.Errorf("error enabling secrets engine %q: %w", m.Path, err)
+		}
+	}
+
+	for _, m := range b.AuthMethods {
+		if _, err := request(logical.UpdateOperation, "sys/auth/"+strings.Trim(m.Path, "/"), m.data()); err != nil {
+			return fmt.Errorf("error enabling auth method %q: %w", m.Path, err)
+		}
+	}
+
+	for _, s := range b.Secrets {
+		path := strings.Trim(s.Path, "/")
+		resp, err := request(logical.ReadOperation, "sys/internal/ui/mounts/"+path, nil)
+		if err != nil {
+			return fmt.Errorf("error looking up the mount of secret %q: %w", s.Path, err)
+		}
+
+		data := s.Data
+		if resp != nil && resp.Data != nil {
+			mountPath, _ := resp.Data["path"].(string)
+			options, _ := resp.Data["options"].(map[string]string)
+			if resp.Data["type"] != "kv" {
+				return fmt.Errorf("secret %q is not in a KV secrets engine", s.Path)
+			}
+			if options["version"] == "2" {
+				path = mountPath + "data/" + strings.TrimPrefix(path, mountPath)
+				data = map[string]interface{}{"data": s.Data}
+			}
+		}
+		if _, err := request(logical.UpdateOperation, path, data); err != nil {
+			return fmt.Errorf("error writing secret %q: %w", s.Path, err)
+		}
+	}
+
+	for _, w := range b.Writes {
+		if _, err := request(logical.UpdateOperation, strings.Trim(w.Path, "/"), w.Data); err != nil {
+			return fmt.Errorf("error writing to %q: %w", w.Path, err)
+		}
+	}
+	return nil
+}
+
+// summary describes what the bootstrap file sets up.
+func (b *devBootstrap) summary() string {
+	return fmt.Sprintf("%d policies, %d secrets engines, %d auth methods, %d secrets, %d writes",
+		len(b.Policies), len(b.SecretsEngines), len(b.AuthMethods), len(b.Secrets), len(b.Writes))
+}
+
+func (m *devBootstrapMount) data() map[string]interface{} {
+	data := map[string]interface{}{
+		"type":        m.Type,
+		"description": m.Description,
+		"local":       m.Local,
+		"seal_wrap":   m.SealWrap,
+	}
+	if m.Config != nil {
+		data["config"] = m.Config
+	}
+	if m.Options != nil {
+		data["options"] = m.Options
+	}
+	return data
+}
@@ -0,0 +1,177 @@
+package command
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/vault"
+)
+
+const testDevBootstrap = `
+policy "app" {
+  file = "app.hcl"
+}
+
+policy "admin" {
+  rules = <<EOT
+path "*" {
+  capabilities = ["sudo"]
+}
+EOT
+}
+
+secrets_engine "kv1" {
+  type = "kv"
+}
+
+secrets_engine "kv2/" {
+  type        = "kv"
+  description = "versioned secrets"
+  options = {
+    version = "2"
+  }
+}
+
+secrets_engine "transit" {
+  type = "transit"
+  config = {
+    default_lease_ttl = "1h"
+  }
+}
+
+auth_method "userpass" {
+  type = "userpass"
+}
+
+secret "kv1/app/config" {
+  data = {
+    username = "app"
+  }
+}
+
+secret "kv2/app/config" {
+  data = {
+    username = "app"
+    port     = 5432
+    tls = {
+      enabled = true
+    }
+  }
+}
+
+write "auth/userpass/users/alice" {
+  data = {
+    password = "training"
+    policies = "app"
+  }
+}
+`
+
+func TestParseDevBootstrap(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bootstrap.hcl")
+	if err := ioutil.WriteFile(path, []byte(testDevBootstrap), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.hcl"), []byte(`path "kv2/data/app/*" { capabilities = ["read"] }`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := loadDevBootstrap("@" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary := b.summary(); summary != "2 policies, 3 secrets engines, 1 auth methods, 2 secrets, 1 writes" {
+		t.Fatalf("unexpected contents: %s", summary)
+	}
+	if !strings.Contains(b.Policies[0].Rules, "kv2/data/app/*") {
+		t.Fatalf("expected the rules of the policy to be read from its file, got %q", b.Policies[0].Rules)
+	}
+	if b.SecretsEngines[1].Path != "kv2/" || b.SecretsEngines[1].Options["version"] != "2" {
+		t.Fatalf("unexpected secrets engine: %#v", b.SecretsEngines[1])
+	}
+	if tls, ok := b.Secrets[1].Data["tls"].(map[string]interface{}); !ok || tls["enabled"] != true {
+		t.Fatalf("expected nested objects to be decoded as objects, got %#v", b.Secrets[1].Data)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		contents string
+		err      string
+	}{
+		{"invalid_key", `mount "kv" { type = "kv" }`, `invalid key "mount"`},
+		{"no_rules", `policy "app" {}`, "one of rules or file must be set"},
+		{"no_type", `auth_method "userpass" {}`, "type must be set"},
+		{"no_data", `secret "kv/app" {}`, "data must be set"},
+	} {
+		if _, err := parseDevBootstrap(tc.contents); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestDevBootstrap_Apply(t *testing.T) {
+	t.Parallel()
+
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		CredentialBackends: defaultVaultCredentialBackends,
+		LogicalBackends:    defaultVaultLogicalBackends,
+	}, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+		NumCores:    1,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	vault.TestWaitActive(t, core)
+
+	b, err := parseDevBootstrap(strings.Replace(testDevBootstrap, `file = "app.hcl"`, `rules = "path \"kv2/data/app/*\" { capabilities = [\"read\"] }"`, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.apply(core, cluster.RootToken); err != nil {
+		t.Fatal(err)
+	}
+
+	client := cluster.Cores[0].Client
+	client.SetToken(cluster.RootToken)
+
+	secret, err := client.Logical().Read("kv1/app/config")
+	if err != nil || secret == nil || secret.Data["username"] != "app" {
+		t.Fatalf("unexpected secret in kv1: %#v, %v", secret, err)
+	}
+
+	// The secrets of KV version 2 are written under data/
+	secret, err = client.Logical().Read("kv2/data/app/config")
+	if err != nil || secret == nil {
+		t.Fatalf("unexpected secret in kv2: %#v, %v", secret, err)
+	}
+	if data, _ := secret.Data["data"].(map[string]interface{}); data["username"] != "app" {
+		t.Fatalf("unexpected secret in kv2: %#v", secret.Data)
+	}
+
+	auths, err := client.Sys().ListAuth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := auths["userpass/"]; !ok {
+		t.Fatalf("expected userpass to be enabled, got %v", auths)
+	}
+
+	// The user can log in with the policy of the bootstrap file
+	login, err := client.Logical().Write("auth/userpass/login/alice", map[string]interface{}{
+		"password": "training",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policies := login.Auth.Policies; len(policies) != 2 || policies[0] != "app" && policies[1] != "app" {
+		t.Fatalf("unexpected policies: %v", policies)
+	}
+}
@@ -0,0 +1,207 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-secure-stdlib/reloadutil"
+	"github.com/hashicorp/vault/command/server"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/internalshared/listenerutil"
+	"github.com/hashicorp/vault/vault"
+)
+
+// listenerShutdownTimeout is how long the requests in flight on a removed
+// listener are waited for before their connections are closed.
+const listenerShutdownTimeout = 30 * time.Second
+
+// serverListener is a listener the server serves HTTP requests on.
+type serverListener struct {
+	listenerutil.Listener
+
+	server *http.Server
+}
+
+// listenerKey identifies a listener across config reloads, and the reload
+// function of its TLS certificates.
+func listenerKey(lnConfig *configutil.Listener) string {
+	return lnConfig.Type + "|" + lnConfig.Address
+}
+
+func listenerName(lnConfig *configutil.Listener) string {
+	return lnConfig.Type + " " + lnConfig.Address
+}
+
+// closeListeners closes the listeners of the server.
+func (c *ServerCommand) closeListeners() {
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	for _, ln := range c.listeners {
+		ln.Listener.Listener.Close()
+	}
+}
+
+// reloadListeners starts serving on the listeners of config which the server
+// does not listen on yet, and stops serving on the listeners which are no
+// longer in config, after the requests in flight on them complete. Listeners
+// are identified by their type and address. Other changes to a listener
+// still require a restart, apart from its TLS certificates which are
+// reloaded by the listener reload functions. It returns the names of the
+// added and removed listeners.
+func (c *ServerCommand) reloadListeners(core *vault.Core, config *server.Config) ([]string, []string, error) {
+	if len(config.Listeners) == 0 {
+		return nil, nil, fmt.Errorf("no listener configured, keeping the current listeners")
+	}
+
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	wanted := make(map[string]*configutil.Listener, len(config.Listeners))
+	for _, lnConfig := range config.Listeners {
+		wanted[listenerKey(lnConfig)] = lnConfig
+	}
+
+	var added, removed []string
+	var kept []*serverListener
+	current := make(map[string]bool, len(c.listeners))
+	for _, ln := range c.listeners {
+		key := listenerKey(ln.Config)
+		lnConfig, ok := wanted[key]
+		if ok {
+			if !reflect.DeepEqual(lnConfig.RawConfig, ln.Config.RawConfig) {
+				c.logger.Warn("listener configuration changed, restart to apply changes other than TLS certificates", "listener", listenerName(ln.Config))
+			}
+			kept = append(kept, ln)
+			current[key] = true
+			continue
+		}
+
+		c.reloadFuncsLock.Lock()
+		delete(*c.reloadFuncs, "listener|"+key)
+		c.reloadFuncsLock.Unlock()
+
+		go func(ln *serverListener) {
+			ctx, cancel := context.WithTimeout(context.Background(), listenerShutdownTimeout)
+			defer cancel()
+			if err := ln.server.Shutdown(ctx); err != nil {
+				c.logger.Warn("requests on removed listener did not complete, closing their connections", "listener", listenerName(ln.Config), "error", err)
+				ln.server.Close()
+			}
+		}(ln)
+
+		c.logger.Info("removed listener", "listener", listenerName(ln.Config))
+		removed = append(removed, listenerName(ln.Config))
+	}
+	c.listeners = kept
+
+	var errs *multierror.Error
+	for _, lnConfig := range config.Listeners {
+		key := listenerKey(lnConfig)
+		if current[key] {
+			continue
+		}
+		current[key] = true
+
+		ln, err := c.startListener(core, config, lnConfig)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("error starting listener %s: %w", listenerName(lnConfig), err))
+			continue
+		}
+		c.listeners = append(c.listeners, ln)
+
+		c.logger.Info("added listener", "listener", listenerName(lnConfig))
+		added = append(added, listenerName(lnConfig))
+	}
+
+	return added, removed, errs.ErrorOrNil()
+}
+
+// startListener opens a listener which was added to the configuration and
+// serves requests on it. Its cluster address is only used after a restart.
+func (c *ServerCommand) startListener(core *vault.Core, config *server.Config, lnConfig *configutil.Listener) (*serverListener, error) {
+	if lnConfig.MaxRequestSize == 0 {
+		lnConfig.MaxRequestSize = vaulthttp.DefaultMaxRequestSize
+	}
+	if lnConfig.MaxRequestDuration == 0 {
+		lnConfig.MaxRequestDuration = vault.DefaultMaxRequestDuration
+	}
+
+	l, _, reloadFunc, err := server.NewListener(lnConfig, c.gatedWriter, c.UI)
+	if err != nil {
+		return nil, err
+	}
+
+	ln := &serverListener{
+		Listener: listenerutil.Listener{
+			Listener: l,
+			Config:   lnConfig,
+		},
+	}
+	ln.server, err = newHTTPServer(c, core, config, ln.Listener)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	if reloadFunc != nil {
+		c.reloadFuncsLock.Lock()
+		(*c.reloadFuncs)["listener|"+listenerKey(lnConfig)] = []reloadutil.ReloadFunc{reloadFunc}
+		c.reloadFuncsLock.Unlock()
+	}
+
+	go ln.server.Serve(l)
+	return ln, nil
+}
+
+// loadReloadConfig loads and merges the configuration files of the server
+// when its configuration is reloaded.
+func (c *ServerCommand) loadReloadConfig() (*server.Config, error) {
+	var config *server.Config
+	for _, path := range c.flagConfigs {
+		current, err := server.LoadConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not reload config %q: %w", path, err)
+		}
+
+		if config == nil {
+			config = current
+		} else {
+			config = config.Merge(current)
+		}
+	}
+
+	// Ensure at least one config was found.
+	if config == nil {
+		return nil, fmt.Errorf("no config found at reload time")
+	}
+	return config, nil
+}
+
+// reloadListenerConfig reloads the listeners, and their custom response
+// headers, from the configuration files of the server. Unlike SIGHUP, it
+// leaves the rest of the configuration as it is.
+func (c *ServerCommand) reloadListenerConfig(core *vault.Core) ([]string, []string, error) {
+	config, err := c.loadReloadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(config.Listeners) == 0 {
+		return nil, nil, fmt.Errorf("no listener configured, keeping the current listeners")
+	}
+
+	config = core.SetListenersConfig(config.Listeners)
+	if config == nil {
+		return nil, nil, fmt.Errorf("no server configuration to reload the listeners of")
+	}
+	if err := core.ReloadCustomResponseHeaders(); err != nil {
+		c.logger.Error(err.Error())
+	}
+
+	return c.reloadListeners(core, config)
+}
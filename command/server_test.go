@@ -12,7 +12,9 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -196,6 +198,105 @@ func TestServer_ReloadListener(t *testing.T) {
 	wg.Wait()
 }
 
+func TestServer_ReloadListener_AddRemove(t *testing.T) {
+	t.Parallel()
+
+	listenerHCL := func(addrs ...string) []byte {
+		hcl := "backend \"inmem\" {}\ndisable_mlock = true\n"
+		for _, addr := range addrs {
+			hcl += fmt.Sprintf("listener \"tcp\" {\n  address     = %q\n  tls_disable = true\n}\n", addr)
+		}
+		return []byte(hcl)
+	}
+
+	path := filepath.Join(t.TempDir(), "reload.hcl")
+	if err := ioutil.WriteFile(path, listenerHCL("127.0.0.1:8204"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ui, cmd := testServerCommand(t)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		if code := cmd.Run([]string{"-config", path}); code != 0 {
+			output := ui.ErrorWriter.String() + ui.OutputWriter.String()
+			t.Errorf("got a non-zero exit status: %s", output)
+		}
+		wg.Done()
+	}()
+
+	select {
+	case <-cmd.startedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	testServing := func(addr string) error {
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get("http://" + addr + "/v1/sys/seal-status")
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	reload := func(addrs ...string) {
+		t.Helper()
+
+		if err := ioutil.WriteFile(path, listenerHCL(addrs...), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cmd.SighupCh <- struct{}{}
+		select {
+		case <-cmd.reloadedCh:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout")
+		}
+	}
+
+	if err := testServing("127.0.0.1:8204"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testServing("127.0.0.1:8205"); err == nil {
+		t.Fatal("expected no listener on 127.0.0.1:8205")
+	}
+
+	reload("127.0.0.1:8204", "127.0.0.1:8205")
+	for _, addr := range []string{"127.0.0.1:8204", "127.0.0.1:8205"} {
+		if err := testServing(addr); err != nil {
+			t.Fatalf("expected a listener on %s: %s", addr, err)
+		}
+	}
+
+	reload("127.0.0.1:8205")
+	if err := testServing("127.0.0.1:8205"); err != nil {
+		t.Fatal(err)
+	}
+	// Removed listeners are shut down in the background
+	var err error
+	for i := 0; i < 50; i++ {
+		if err = testServing("127.0.0.1:8204"); err != nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err == nil {
+		t.Fatal("expected the listener on 127.0.0.1:8204 to be removed")
+	}
+
+	// Removing all listeners keeps the current ones
+	reload()
+	if err := testServing("127.0.0.1:8205"); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd.ShutdownCh <- struct{}{}
+
+	wg.Wait()
+}
+
 func TestServer(t *testing.T) {
 	t.Parallel()
 
@@ -262,6 +363,34 @@ func TestServer(t *testing.T) {
 			1,
 			"-test-server-config",
 		},
+		{
+			"test_config",
+			testBaseHCL(t, "") + inmemHCL,
+			"Configuration is valid, with 0 warnings",
+			0,
+			"-test-config",
+		},
+		{
+			"test_config_unknown_storage",
+			testBaseHCL(t, "") + "\nstorage \"nope\" {}",
+			"Unknown storage type nope",
+			1,
+			"-test-config",
+		},
+		{
+			"test_config_unknown_field",
+			testBaseHCL(t, "") + inmemHCL + `foo = "bar"`,
+			"unknown or unsupported field foo found in configuration",
+			1,
+			"-test-config",
+		},
+		{
+			"test_config_bad_api_addr",
+			testBaseHCL(t, "") + inmemHCL + `api_addr = "vault.example.com"`,
+			"api_addr: \"vault.example.com\" must be a URL with a scheme and a host",
+			1,
+			"-test-config",
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,394 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/google/shlex"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+	"golang.org/x/term"
+)
+
+var (
+	_ cli.Command             = (*ShellCommand)(nil)
+	_ cli.CommandAutocomplete = (*ShellCommand)(nil)
+)
+
+// shellDefaultPager is the pager used to display long output when PAGER is
+// not set.
+const shellDefaultPager = "less -R"
+
+type ShellCommand struct {
+	*BaseCommand
+
+	flagNoPager bool
+
+	// history holds the lines entered during this session, in order.
+	history []string
+
+	testStdin  io.Reader // for tests
+	testStdout io.Writer // for tests
+}
+
+func (c *ShellCommand) Synopsis() string {
+	return "Start an interactive Vault session"
+}
+
+func (c *ShellCommand) Help() string {
+	helpText := `
+Usage: vault shell [options]
+
+  Starts an interactive session in which Vault commands can be run without
+  the "vault" prefix. The client, token, and namespace are kept between
+  commands, so a token obtained with "login" is used by the commands that
+  follow it.
+
+  Start a session against the configured Vault server:
+
+      $ vault shell
+
+  Within a session, the following built-in commands are available in
+  addition to the regular Vault commands:
+
+      namespace [NS]    Show or change the namespace used by later commands.
+                        Use "namespace -" to clear it.
+      history           Print the commands entered during this session.
+      help              Show this help.
+      exit, quit        End the session.
+
+  Pressing TAB completes command names and Vault paths. Output which does not
+  fit on the screen is displayed with $PAGER.
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ShellCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:       "no-pager",
+		Target:     &c.flagNoPager,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage:      "Print output directly instead of displaying it with $PAGER.",
+	})
+
+	return set
+}
+
+func (c *ShellCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ShellCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ShellCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	stdin := (io.Reader)(os.Stdin)
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+	stdout := (io.Writer)(os.Stdout)
+	if c.testStdout != nil {
+		stdout = c.testStdout
+	}
+
+	if c.testStdin == nil && term.IsTerminal(int(os.Stdin.Fd())) {
+		return c.runInteractive(client)
+	}
+
+	// Not attached to a terminal, so read one command per line without
+	// prompting or paging.
+	scanner := bufio.NewScanner(stdin)
+	code := 0
+	for scanner.Scan() {
+		var exit bool
+		code, exit = c.runLine(client, scanner.Text(), stdout, false)
+		if exit {
+			return code
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading input: %s", err), err)
+		return 1
+	}
+	return code
+}
+
+// runInteractive reads commands from the terminal with line editing, history
+// and completion until the user exits.
+func (c *ShellCommand) runInteractive(client *api.Client) int {
+	fd := int(os.Stdin.Fd())
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, c.prompt(client))
+	t.AutoCompleteCallback = c.autocomplete(client)
+
+	c.UI.Output("Type \"help\" for a list of built-in commands, \"exit\" to quit.")
+
+	code := 0
+	for {
+		// The terminal is only put in raw mode while reading a line so that
+		// commands which prompt for input (such as "login") work as usual.
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error configuring terminal: %s", err), err)
+			return 1
+		}
+		t.SetPrompt(c.prompt(client))
+		line, err := t.ReadLine()
+		term.Restore(fd, state)
+		if err == io.EOF {
+			return code
+		}
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error reading input: %s", err), err)
+			return 1
+		}
+
+		var exit bool
+		code, exit = c.runLine(client, line, os.Stdout, !c.flagNoPager)
+		if exit {
+			return code
+		}
+	}
+}
+
+// runLine runs a single line of input. It returns the exit code of the
+// command and whether the session should end.
+func (c *ShellCommand) runLine(client *api.Client, line string, stdout io.Writer, page bool) (int, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return 0, false
+	}
+
+	args, err := shlex.Split(line)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error parsing command: %s", err), err)
+		return 1, false
+	}
+	c.history = append(c.history, line)
+
+	// Allow commands to be pasted with their "vault" prefix.
+	if args[0] == "vault" {
+		args = args[1:]
+		if len(args) == 0 {
+			return 0, false
+		}
+	}
+
+	switch args[0] {
+	case "exit", "quit":
+		return 0, true
+	case "help":
+		if len(args) == 1 {
+			fmt.Fprintln(stdout, c.Help())
+			return 0, false
+		}
+	case "history":
+		for i, h := range c.history {
+			fmt.Fprintf(stdout, "%5d  %s\n", i+1, h)
+		}
+		return 0, false
+	case "namespace":
+		return c.runNamespace(client, args[1:], stdout), false
+	case "shell":
+		c.UI.Error("Already in a Vault shell")
+		return 1, false
+	}
+
+	var out bytes.Buffer
+	w := stdout
+	if page {
+		w = &out
+	}
+
+	code := RunCustom(args, &RunOptions{
+		TokenHelper: c.tokenHelper,
+		Client:      client,
+		Stdout:      w,
+		Stderr:      os.Stderr,
+	})
+	if page {
+		c.page(out.Bytes(), stdout)
+	}
+
+	// A successful login stores the new token with the token helper, so pick
+	// it up for the commands that follow.
+	if args[0] == "login" && code == 0 {
+		if helper, err := c.TokenHelper(); err == nil {
+			if token, err := helper.Get(); err == nil && token != "" {
+				client.SetToken(token)
+			}
+		}
+	}
+
+	return code, false
+}
+
+// runNamespace implements the "namespace" built-in.
+func (c *ShellCommand) runNamespace(client *api.Client, args []string, stdout io.Writer) int {
+	switch len(args) {
+	case 0:
+		if ns := shellNamespace(client); ns != "" {
+			fmt.Fprintln(stdout, ns)
+		}
+		return 0
+	case 1:
+		if args[0] == "-" {
+			client.ClearNamespace()
+			return 0
+		}
+		client.SetNamespace(namespace.Canonicalize(args[0]))
+		return 0
+	default:
+		c.UI.Error(fmt.Sprintf("Too many arguments to namespace (expected 0 or 1, got %d)", len(args)))
+		return 1
+	}
+}
+
+// shellNamespace returns the namespace the client is configured to use.
+func shellNamespace(client *api.Client) string {
+	return client.Headers().Get(consts.NamespaceHeaderName)
+}
+
+// prompt returns the prompt for the next line, which includes the current
+// namespace if one is set.
+func (c *ShellCommand) prompt(client *api.Client) string {
+	if ns := shellNamespace(client); ns != "" {
+		return fmt.Sprintf("vault [%s]> ", strings.TrimSuffix(ns, "/"))
+	}
+	return "vault> "
+}
+
+// page writes the given output, displaying it with the user's pager if it
+// does not fit on the screen.
+func (c *ShellCommand) page(out []byte, stdout io.Writer) {
+	_, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || bytes.Count(out, []byte("\n")) < height {
+		stdout.Write(out)
+		return
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = shellDefaultPager
+	}
+	parts, err := shlex.Split(pager)
+	if err != nil || len(parts) == 0 {
+		stdout.Write(out)
+		return
+	}
+
+	cmd := exec.Command(parts[0], parts[1:]...)
+	cmd.Stdin = bytes.NewReader(out)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		stdout.Write(out)
+	}
+}
+
+// autocomplete returns a callback which completes command names for the first
+// word on the line and Vault paths for the words after it.
+func (c *ShellCommand) autocomplete(client *api.Client) func(string, int, rune) (string, int, bool) {
+	p := NewPredict()
+	p.client = client
+
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		before := line[:pos]
+		start := strings.LastIndexAny(before, " \t") + 1
+		last := before[start:]
+
+		var candidates []string
+		if strings.TrimSpace(before[:start]) == "" {
+			for name := range Commands {
+				if !strings.Contains(name, " ") && strings.HasPrefix(name, last) {
+					candidates = append(candidates, name)
+				}
+			}
+			for _, name := range []string{"exit", "help", "history", "namespace", "quit"} {
+				if strings.HasPrefix(name, last) {
+					candidates = append(candidates, name)
+				}
+			}
+		} else {
+			// The predictor caches listings, so start with a fresh cache for
+			// each completion to pick up changes made by earlier commands.
+			p.cache = nil
+			candidates = p.vaultPaths(true)(complete.Args{
+				All:  []string{last},
+				Last: last,
+			})
+		}
+		if len(candidates) == 0 {
+			return "", 0, false
+		}
+
+		completion := longestCommonPrefix(candidates)
+		if len(completion) <= len(last) {
+			return "", 0, false
+		}
+		if len(candidates) == 1 && !strings.HasSuffix(completion, "/") {
+			completion += " "
+		}
+
+		newLine := before[:start] + completion + line[pos:]
+		return newLine, start + len(completion), true
+	}
+}
+
+// longestCommonPrefix returns the longest prefix shared by all of the given
+// strings.
+func longestCommonPrefix(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), list...)
+	sort.Strings(sorted)
+	first, last := sorted[0], sorted[len(sorted)-1]
+	i := 0
+	for i < len(first) && i < len(last) && first[i] == last[i] {
+		i++
+	}
+	return first[:i]
+}
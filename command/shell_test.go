@@ -0,0 +1,117 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testShellCommand(tb testing.TB) (*cli.MockUi, *ShellCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &ShellCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestShellCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("too_many_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testShellCommand(t)
+
+		code := cmd.Run([]string{"foo"})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Too many arguments"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("commands", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		_, cmd := testShellCommand(t)
+		cmd.client = client
+
+		var stdout bytes.Buffer
+		cmd.testStdout = &stdout
+		cmd.testStdin = strings.NewReader(strings.Join([]string{
+			"# comment",
+			"write secret/shell foo=bar",
+			"vault read -field=foo secret/shell",
+			"namespace ns1",
+			"namespace",
+			"namespace -",
+			"history",
+			"exit",
+			"read secret/never",
+		}, "\n"))
+
+		code := cmd.Run([]string{})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		out := stdout.String()
+		for _, expected := range []string{
+			"Success! Data written to: secret/shell",
+			"bar",
+			"ns1/",
+			"vault read -field=foo secret/shell",
+		} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("expected %q to contain %q", out, expected)
+			}
+		}
+
+		if ns := shellNamespace(client); ns != "" {
+			t.Errorf("expected namespace to be cleared, got %q", ns)
+		}
+		if len(cmd.history) != 7 {
+			t.Errorf("expected 7 history entries, got %d: %v", len(cmd.history), cmd.history)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testShellCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
+
+func TestLongestCommonPrefix(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in  []string
+		out string
+	}{
+		{nil, ""},
+		{[]string{"secret/"}, "secret/"},
+		{[]string{"secret/foo", "secret/bar"}, "secret/"},
+		{[]string{"sys/", "secret/"}, "s"},
+		{[]string{"kv/", "secret/"}, ""},
+	}
+
+	for _, tc := range cases {
+		if got := longestCommonPrefix(tc.in); got != tc.out {
+			t.Errorf("longestCommonPrefix(%v): expected %q to be %q", tc.in, got, tc.out)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"os/user"
+	"path/filepath"
 	"strings"
 	"syscall"
 
@@ -37,11 +38,14 @@ type SSHCommand struct {
 	flagUserKnownHostsFile    string
 
 	// SSH CA Mode options
-	flagPublicKeyPath     string
-	flagPrivateKeyPath    string
-	flagHostKeyMountPoint string
-	flagHostKeyHostnames  string
-	flagValidPrincipals   string
+	flagPublicKeyPath   string
+	flagPrivateKeyPath  string
+	flagValidPrincipals string
+
+	// Host key verification
+	flagHostKeyMountPoint     string
+	flagHostKeyHostnames      string
+	flagHostKeyKnownHostsFile string
 }
 
 func (c *SSHCommand) Synopsis() string {
@@ -75,6 +79,19 @@ Usage: vault ssh [options] username@ip [ssh options]
           -host-key-hostnames=example.com \
           user@example.com
 
+  SSH using the OTP mode, and trust the host CA in the user's known_hosts file
+  so that later connections verify host certificates too:
+
+      $ vault ssh \
+          -mode=otp \
+          -role=my-role \
+          -host-key-mount-point=host-signer \
+          -host-key-known-hosts-file=~/.ssh/known_hosts \
+          user@example.com
+
+  To add a signed certificate to the running SSH agent instead, so that it can
+  be used by any SSH client, see "vault ssh agent-load".
+
   For the full list of options and arguments, please see the documentation.
 
 ` + c.Flags().Help()
@@ -169,6 +186,28 @@ func (c *SSHCommand) Flags() *FlagSets {
 			"be the corresponding private key to -public-key-path.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "valid-principals",
+		Target:     &c.flagValidPrincipals,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage: "List of valid principal names to include in the generated " +
+			"user certificate. This is specified as a comma-separated list of values.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "ssh-executable",
+		Target:     &c.flagSSHExecutable,
+		Default:    "ssh",
+		EnvVar:     "VAULT_SSH_EXECUTABLE",
+		Completion: complete.PredictAnything,
+		Usage:      "Path to the SSH executable to use when connecting to the host",
+	})
+
+	// Host key verification
+	f = set.NewFlagSet("Host Key Verification Options")
+
 	f.StringVar(&StringVar{
 		Name:       "host-key-mount-point",
 		Target:     &c.flagHostKeyMountPoint,
@@ -178,10 +217,10 @@ func (c *SSHCommand) Flags() *FlagSets {
 		Usage: "Mount point to the SSH secrets engine where host keys are signed. " +
 			"When given a value, Vault will generate a custom \"known_hosts\" file " +
 			"with delegation to the CA at the provided mount point to verify the " +
-			"SSH connection's host keys against the provided CA. By default, host " +
-			"keys are validated against the user's local \"known_hosts\" file. " +
-			"This flag forces strict key host checking and ignores a custom user " +
-			"known hosts file.",
+			"SSH connection's host keys against the provided CA, in all modes. By " +
+			"default, host keys are validated against the user's local " +
+			"\"known_hosts\" file. This flag forces strict key host checking and " +
+			"ignores a custom user known hosts file.",
 	})
 
 	f.StringVar(&StringVar{
@@ -196,22 +235,17 @@ func (c *SSHCommand) Flags() *FlagSets {
 	})
 
 	f.StringVar(&StringVar{
-		Name:       "valid-principals",
-		Target:     &c.flagValidPrincipals,
+		Name:       "host-key-known-hosts-file",
+		Target:     &c.flagHostKeyKnownHostsFile,
 		Default:    "",
-		EnvVar:     "",
-		Completion: complete.PredictAnything,
-		Usage: "List of valid principal names to include in the generated " +
-			"user certificate. This is specified as a comma-separated list of values.",
-	})
-
-	f.StringVar(&StringVar{
-		Name:       "ssh-executable",
-		Target:     &c.flagSSHExecutable,
-		Default:    "ssh",
-		EnvVar:     "VAULT_SSH_EXECUTABLE",
-		Completion: complete.PredictAnything,
-		Usage:      "Path to the SSH executable to use when connecting to the host",
+		EnvVar:     "VAULT_SSH_HOST_KEY_KNOWN_HOSTS_FILE",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path of a \"known_hosts\" file, such as \"~/.ssh/known_hosts\", " +
+			"to add the \"@cert-authority\" line of the CA of " +
+			"-host-key-mount-point to, instead of generating a temporary " +
+			"\"known_hosts\" file. The line is only added if missing, so that " +
+			"later connections, including ones not made through Vault, verify " +
+			"host certificates too.",
 	})
 
 	return set
@@ -247,6 +281,7 @@ func (c *SSHCommand) Run(args []string) int {
 	c.flagUserKnownHostsFile = expandPath(c.flagUserKnownHostsFile)
 	c.flagPublicKeyPath = expandPath(c.flagPublicKeyPath)
 	c.flagPrivateKeyPath = expandPath(c.flagPrivateKeyPath)
+	c.flagHostKeyKnownHostsFile = expandPath(c.flagHostKeyKnownHostsFile)
 
 	args = f.Args()
 	if len(args) < 1 {
@@ -257,7 +292,7 @@ func (c *SSHCommand) Run(args []string) int {
 	// Extract the hostname, username and port from the ssh command
 	hostname, username, port, err := c.parseSSHCommand(args)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error parsing the ssh command: %q", err))
+		outputError(c.UI, fmt.Sprintf("Error parsing the ssh command: %q", err), err)
 		return 1
 	}
 
@@ -265,7 +300,7 @@ func (c *SSHCommand) Run(args []string) int {
 	if username == "" {
 		u, err := user.Current()
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error getting the current user: %q", err))
+			outputError(c.UI, fmt.Sprintf("Error getting the current user: %q", err), err)
 			return 1
 		}
 		username = u.Username
@@ -273,7 +308,7 @@ func (c *SSHCommand) Run(args []string) int {
 
 	ip, err := c.resolveHostname(hostname)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error resolving the ssh hostname: %q", err))
+		outputError(c.UI, fmt.Sprintf("Error resolving the ssh hostname: %q", err), err)
 		return 1
 	}
 
@@ -299,7 +334,7 @@ func (c *SSHCommand) Run(args []string) int {
 
 		role, err := c.defaultRole(c.flagMountPoint, ip)
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error choosing role: %v", err))
+			outputError(c.UI, fmt.Sprintf("Error choosing role: %v", err), err)
 			return 1
 		}
 		// Print the default role chosen so that user knows the role name
@@ -331,7 +366,7 @@ func (c *SSHCommand) Run(args []string) int {
 			if strings.Contains(err.Error(), "key type unknown") {
 				c.flagMode = ssh.KeyTypeCA
 			} else {
-				c.UI.Error(fmt.Sprintf("Error getting credential: %s", err))
+				outputError(c.UI, fmt.Sprintf("Error getting credential: %s", err), err)
 				return 1
 			}
 		} else {
@@ -425,33 +460,12 @@ func (c *SSHCommand) handleTypeCA(username, ip, port string, sshArgs []string) i
 	userKnownHostsFile := c.flagUserKnownHostsFile
 	strictHostKeyChecking := c.flagStrictHostKeyChecking
 
-	// Handle host key signing verification. If the user specified a mount point,
-	// download the public key, trust it with the given domains, and use that
-	// instead of the user's regular known_hosts file.
+	// Handle host key signing verification
 	if c.flagHostKeyMountPoint != "" {
-		secret, err := c.client.Logical().Read(c.flagHostKeyMountPoint + "/config/ca")
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("failed to get host signing key: %s", err))
-			return 2
-		}
-		if secret == nil || secret.Data == nil {
-			c.UI.Error("missing host signing key")
-			return 2
-		}
-		publicKey, ok := secret.Data["public_key"].(string)
-		if !ok || publicKey == "" {
-			c.UI.Error("host signing key is empty")
-			return 2
-		}
-
-		// Write the known_hosts file
-		name := fmt.Sprintf("vault_ssh_ca_known_hosts_%s_%s", username, ip)
-		data := fmt.Sprintf("@cert-authority %s %s", c.flagHostKeyHostnames, publicKey)
-		knownHosts, err, closer := c.writeTemporaryFile(name, []byte(data), 0o644)
+		knownHosts, code, closer := c.hostKeyKnownHosts(username, ip)
 		defer closer()
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("failed to write host public key: %s", err))
-			return 1
+		if code != 0 {
+			return code
 		}
 
 		// Update the variables
@@ -464,7 +478,7 @@ func (c *SSHCommand) handleTypeCA(username, ip, port string, sshArgs []string) i
 	signedPublicKeyPath, err, closer := c.writeTemporaryKey(name, []byte(key))
 	defer closer()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("failed to write signed public key: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to write signed public key: %s", err), err)
 		return 2
 	}
 
@@ -500,7 +514,7 @@ func (c *SSHCommand) handleTypeCA(username, ip, port string, sshArgs []string) i
 			}
 		}
 
-		c.UI.Error(fmt.Sprintf("failed to run ssh command: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to run ssh command: %s", err), err)
 		return exitCode
 	}
 
@@ -512,7 +526,7 @@ func (c *SSHCommand) handleTypeCA(username, ip, port string, sshArgs []string) i
 func (c *SSHCommand) handleTypeOTP(username, ip, port string, sshArgs []string) int {
 	secret, cred, err := c.generateCredential(username, ip)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("failed to generate credential: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to generate credential: %s", err), err)
 		return 2
 	}
 
@@ -524,6 +538,24 @@ func (c *SSHCommand) handleTypeOTP(username, ip, port string, sshArgs []string)
 		return OutputSecret(c.UI, secret)
 	}
 
+	// Capture the current value - this could be overwritten later if the user
+	// enabled host key signing verification.
+	userKnownHostsFile := c.flagUserKnownHostsFile
+	strictHostKeyChecking := c.flagStrictHostKeyChecking
+
+	// Handle host key signing verification
+	if c.flagHostKeyMountPoint != "" {
+		knownHosts, code, closer := c.hostKeyKnownHosts(username, ip)
+		defer closer()
+		if code != 0 {
+			return code
+		}
+
+		// Update the variables
+		userKnownHostsFile = knownHosts
+		strictHostKeyChecking = "yes"
+	}
+
 	var cmd *exec.Cmd
 
 	// Check if the application 'sshpass' is installed in the client machine. If
@@ -553,9 +585,9 @@ func (c *SSHCommand) handleTypeOTP(username, ip, port string, sshArgs []string)
 	}
 
 	// Only harcode the knownhostsfile path if it has been set
-	if c.flagUserKnownHostsFile != "" {
+	if userKnownHostsFile != "" {
 		args = append(args,
-			"-o UserKnownHostsFile="+c.flagUserKnownHostsFile,
+			"-o UserKnownHostsFile="+userKnownHostsFile,
 		)
 	}
 
@@ -565,7 +597,7 @@ func (c *SSHCommand) handleTypeOTP(username, ip, port string, sshArgs []string)
 	}
 
 	args = append(args,
-		"-o StrictHostKeyChecking="+c.flagStrictHostKeyChecking,
+		"-o StrictHostKeyChecking="+strictHostKeyChecking,
 	)
 
 	// Add the rest of the ssh args appended by the user
@@ -590,13 +622,13 @@ func (c *SSHCommand) handleTypeOTP(username, ip, port string, sshArgs []string)
 			}
 		}
 
-		c.UI.Error(fmt.Sprintf("failed to run ssh command: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to run ssh command: %s", err), err)
 		return exitCode
 	}
 
 	// Revoke the key if it's longer than expected
 	if err := c.client.Sys().Revoke(secret.LeaseID); err != nil {
-		c.UI.Error(fmt.Sprintf("failed to revoke key: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to revoke key: %s", err), err)
 		return 2
 	}
 
@@ -608,7 +640,7 @@ func (c *SSHCommand) handleTypeDynamic(username, ip, port string, sshArgs []stri
 	// Generate the credential
 	secret, cred, err := c.generateCredential(username, ip)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("failed to generate credential: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to generate credential: %s", err), err)
 		return 2
 	}
 
@@ -620,12 +652,30 @@ func (c *SSHCommand) handleTypeDynamic(username, ip, port string, sshArgs []stri
 		return OutputSecret(c.UI, secret)
 	}
 
+	// Capture the current value - this could be overwritten later if the user
+	// enabled host key signing verification.
+	userKnownHostsFile := c.flagUserKnownHostsFile
+	strictHostKeyChecking := c.flagStrictHostKeyChecking
+
+	// Handle host key signing verification
+	if c.flagHostKeyMountPoint != "" {
+		knownHosts, code, closer := c.hostKeyKnownHosts(username, ip)
+		defer closer()
+		if code != 0 {
+			return code
+		}
+
+		// Update the variables
+		userKnownHostsFile = knownHosts
+		strictHostKeyChecking = "yes"
+	}
+
 	// Write the dynamic key to disk
 	name := fmt.Sprintf("vault_ssh_dynamic_%s_%s", username, ip)
 	keyPath, err, closer := c.writeTemporaryKey(name, []byte(cred.Key))
 	defer closer()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("failed to write dynamic key: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to write dynamic key: %s", err), err)
 		return 1
 	}
 
@@ -637,8 +687,8 @@ func (c *SSHCommand) handleTypeDynamic(username, ip, port string, sshArgs []stri
 
 	args = append(args,
 		"-i", keyPath,
-		"-o UserKnownHostsFile="+c.flagUserKnownHostsFile,
-		"-o StrictHostKeyChecking="+c.flagStrictHostKeyChecking,
+		"-o UserKnownHostsFile="+userKnownHostsFile,
+		"-o StrictHostKeyChecking="+strictHostKeyChecking,
 	)
 
 	// Add extra user defined ssh arguments
@@ -661,13 +711,13 @@ func (c *SSHCommand) handleTypeDynamic(username, ip, port string, sshArgs []stri
 			}
 		}
 
-		c.UI.Error(fmt.Sprintf("failed to run ssh command: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to run ssh command: %s", err), err)
 		return exitCode
 	}
 
 	// Revoke the key if it's longer than expected
 	if err := c.client.Sys().Revoke(secret.LeaseID); err != nil {
-		c.UI.Error(fmt.Sprintf("failed to revoke key: %s", err))
+		outputError(c.UI, fmt.Sprintf("failed to revoke key: %s", err), err)
 		return 2
 	}
 
@@ -735,6 +785,80 @@ func (c *SSHCommand) writeTemporaryFile(name string, data []byte, perms os.FileM
 	return f.Name(), nil, closer
 }
 
+// hostKeyKnownHosts returns a "known_hosts" file trusting the CA of
+// -host-key-mount-point for the hostnames of -host-key-hostnames, along with
+// the exit code on failure. The CA is added to -host-key-known-hosts-file if
+// set, or else written to a temporary file. The caller should defer the closer
+// to cleanup the temporary file.
+func (c *SSHCommand) hostKeyKnownHosts(username, ip string) (string, int, func() error) {
+	// default closer to prevent panic
+	closer := func() error { return nil }
+
+	// Download the public key of the CA, and trust it with the given domains
+	secret, err := c.client.Logical().Read(c.flagHostKeyMountPoint + "/config/ca")
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("failed to get host signing key: %s", err), err)
+		return "", 2, closer
+	}
+	if secret == nil || secret.Data == nil {
+		c.UI.Error("missing host signing key")
+		return "", 2, closer
+	}
+	publicKey, ok := secret.Data["public_key"].(string)
+	if !ok || strings.TrimSpace(publicKey) == "" {
+		c.UI.Error("host signing key is empty")
+		return "", 2, closer
+	}
+	line := fmt.Sprintf("@cert-authority %s %s", c.flagHostKeyHostnames, strings.TrimSpace(publicKey))
+
+	if c.flagHostKeyKnownHostsFile != "" {
+		if err := addKnownHostsLine(c.flagHostKeyKnownHostsFile, line); err != nil {
+			outputError(c.UI, fmt.Sprintf("failed to add host public key to %s: %s", c.flagHostKeyKnownHostsFile, err), err)
+			return "", 1, closer
+		}
+		return c.flagHostKeyKnownHostsFile, 0, closer
+	}
+
+	// Write the known_hosts file
+	name := fmt.Sprintf("vault_ssh_ca_known_hosts_%s_%s", username, ip)
+	knownHosts, err, closer := c.writeTemporaryFile(name, []byte(line+"\n"), 0o644)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("failed to write host public key: %s", err), err)
+		return "", 1, closer
+	}
+	return knownHosts, 0, closer
+}
+
+// addKnownHostsLine adds the given line to the "known_hosts" file at path,
+// creating it if needed, unless the file already has the line.
+func addKnownHostsLine(path, line string) error {
+	existing, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, l := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(l) == line {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		line = "\n" + line
+	}
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // writeTemporaryKey writes the key to a temporary file and returns the path.
 // The caller should defer the closer to cleanup the key.
 func (c *SSHCommand) writeTemporaryKey(name string, data []byte) (string, error, func() error) {
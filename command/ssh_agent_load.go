@@ -0,0 +1,291 @@
+package command
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	_ cli.Command             = (*SSHAgentLoadCommand)(nil)
+	_ cli.CommandAutocomplete = (*SSHAgentLoadCommand)(nil)
+)
+
+type SSHAgentLoadCommand struct {
+	*BaseCommand
+
+	flagMountPoint      string
+	flagRole            string
+	flagPrivateKeyPath  string
+	flagValidPrincipals string
+	flagTTL             time.Duration
+	flagAgentSocket     string
+}
+
+func (c *SSHAgentLoadCommand) Synopsis() string {
+	return "Load a signed SSH certificate into the SSH agent"
+}
+
+func (c *SSHAgentLoadCommand) Help() string {
+	helpText := `
+Usage: vault ssh agent-load [options]
+
+  Signs the public key of an SSH private key with the CA of the SSH secrets
+  engine, and adds the private key and its certificate to the running SSH
+  agent, instead of writing the certificate to disk. The agent removes them
+  once the certificate expires, so that expired certificates don't pile up in
+  the agent.
+
+  The public key is derived from the private key. Passphrase protected
+  private keys are prompted for their passphrase.
+
+  Load a certificate signed with the "my-role" role:
+
+      $ vault ssh agent-load -role=my-role
+
+  Load a certificate for the "admin" principal, valid for 15 minutes:
+
+      $ vault ssh agent-load -role=my-role -valid-principals=admin -ttl=15m
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SSHAgentLoadCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount-point",
+		Target:     &c.flagMountPoint,
+		Default:    "ssh/",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage:      "Mount point to the SSH secrets engine.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "role",
+		Target:     &c.flagRole,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage:      "Name of the role to sign the public key with. This is required.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "private-key-path",
+		Target:     &c.flagPrivateKeyPath,
+		Default:    "~/.ssh/id_rsa",
+		EnvVar:     "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to the SSH private key to add to the agent. Its public key " +
+			"is sent to Vault for signing.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "valid-principals",
+		Target:     &c.flagValidPrincipals,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage: "List of valid principal names to include in the generated " +
+			"user certificate. This is specified as a comma-separated list of " +
+			"values. Defaults to the default user of the role.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "ttl",
+		Target:     &c.flagTTL,
+		Default:    0,
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage:      "Requested TTL of the certificate. Defaults to the TTL of the role.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "agent-socket",
+		Target:     &c.flagAgentSocket,
+		Default:    "",
+		EnvVar:     "SSH_AUTH_SOCK",
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path of the socket of the SSH agent.",
+	})
+
+	return set
+}
+
+func (c *SSHAgentLoadCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SSHAgentLoadCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *SSHAgentLoadCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) > 0:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	case c.flagRole == "":
+		c.UI.Error("-role is required")
+		return 1
+	case c.flagAgentSocket == "":
+		c.UI.Error("No SSH agent found: SSH_AUTH_SOCK is not set, please start an " +
+			"agent or set -agent-socket")
+		return 1
+	}
+
+	privateKeyPath := expandPath(c.flagPrivateKeyPath)
+	privateKey, err := c.readPrivateKey(privateKeyPath)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading private key %s: %s", privateKeyPath, err), err)
+		return 1
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading private key %s: %s", privateKeyPath, err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	data := map[string]interface{}{
+		"public_key": string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+		"cert_type":  "user",
+	}
+	if c.flagValidPrincipals != "" {
+		data["valid_principals"] = c.flagValidPrincipals
+	}
+	if c.flagTTL > 0 {
+		data["ttl"] = c.flagTTL.String()
+	}
+	secret, err := client.SSHWithMountPoint(c.flagMountPoint).SignKey(c.flagRole, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error signing public key: %s", err), err)
+		return 2
+	}
+	if secret == nil || secret.Data == nil {
+		c.UI.Error("Error signing public key: no certificate returned")
+		return 2
+	}
+	signedKey, _ := secret.Data["signed_key"].(string)
+	cert, err := parseSSHCertificate(signedKey)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading signed certificate: %s", err), err)
+		return 2
+	}
+
+	// The agent removes the key once the certificate expires
+	var lifetime uint32
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		remaining := time.Until(time.Unix(int64(cert.ValidBefore), 0))
+		if remaining <= 0 {
+			c.UI.Error("The signed certificate is already expired")
+			return 2
+		}
+		lifetime = uint32(remaining.Round(time.Second) / time.Second)
+	}
+
+	conn, err := net.Dial("unix", c.flagAgentSocket)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error connecting to the SSH agent: %s", err), err)
+		return 1
+	}
+	defer conn.Close()
+
+	comment := fmt.Sprintf("vault %s/sign/%s", sanitizePath(c.flagMountPoint), c.flagRole)
+	if err := agent.NewClient(conn).Add(agent.AddedKey{
+		PrivateKey:   privateKey,
+		Certificate:  cert,
+		Comment:      comment,
+		LifetimeSecs: lifetime,
+	}); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error adding the certificate to the SSH agent: %s", err), err)
+		return 1
+	}
+
+	validBefore := "never"
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		validBefore = time.Unix(int64(cert.ValidBefore), 0).UTC().Format(time.RFC3339)
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		c.UI.Output(fmt.Sprintf("Success! Added %s and its certificate to the SSH agent", privateKeyPath))
+		c.UI.Output("")
+		c.UI.Output(tableOutput([]string{
+			fmt.Sprintf("Serial Number | %d", cert.Serial),
+			fmt.Sprintf("Key ID | %s", cert.KeyId),
+			fmt.Sprintf("Principals | %s", strings.Join(cert.ValidPrincipals, ", ")),
+			fmt.Sprintf("Valid Before | %s", validBefore),
+		}, nil))
+		return 0
+	default:
+		return OutputData(c.UI, map[string]interface{}{
+			"serial_number":    secret.Data["serial_number"],
+			"key_id":           cert.KeyId,
+			"valid_principals": cert.ValidPrincipals,
+			"valid_before":     validBefore,
+			"lifetime":         lifetime,
+		})
+	}
+}
+
+// readPrivateKey reads an SSH private key, asking for its passphrase if it is
+// protected by one.
+func (c *SSHAgentLoadCommand) readPrivateKey(path string) (crypto.PrivateKey, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ssh.ParseRawPrivateKey(b)
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return key, err
+	}
+
+	passphrase, err := c.UI.AskSecret(fmt.Sprintf("Enter passphrase for %s:", path))
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ParseRawPrivateKeyWithPassphrase(b, []byte(passphrase))
+}
+
+// parseSSHCertificate parses a certificate in the authorized_keys format, as
+// returned by the signing endpoint of the SSH secrets engine.
+func parseSSHCertificate(signedKey string) (*ssh.Certificate, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(signedKey))
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("unexpected key type %s, expected a certificate", key.Type())
+	}
+	return cert, nil
+}
@@ -0,0 +1,245 @@
+package command
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func testSSHAgentLoadCommand(tb testing.TB) (*cli.MockUi, *SSHAgentLoadCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &SSHAgentLoadCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testSSHAgent serves an in-memory SSH agent on a unix socket.
+func testSSHAgent(tb testing.TB) (agent.Agent, string) {
+	tb.Helper()
+
+	socket := filepath.Join(tb.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { ln.Close() })
+
+	keyring := agent.NewKeyring()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	return keyring, socket
+}
+
+func testSSHPrivateKeyFile(tb testing.TB) string {
+	tb.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	path := filepath.Join(tb.TempDir(), "id_ecdsa")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestSSHAgentLoadCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"too_many_args",
+			[]string{"-role", "my-role", "-agent-socket", "agent.sock", "foo"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"no_role",
+			[]string{"-agent-socket", "agent.sock"},
+			"-role is required",
+			1,
+		},
+		{
+			"no_agent",
+			[]string{"-role", "my-role", "-agent-socket", ""},
+			"No SSH agent found",
+			1,
+		},
+		{
+			"missing_private_key",
+			[]string{"-role", "my-role", "-agent-socket", "agent.sock", "-private-key-path", "/nope/id_rsa"},
+			"Error reading private key",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testSSHAgentLoadCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("ssh", &api.MountInput{
+			Type: "ssh",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("ssh/config/ca", map[string]interface{}{
+			"generate_signing_key": true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("ssh/roles/my-role", map[string]interface{}{
+			"key_type":                "ca",
+			"allow_user_certificates": true,
+			"allowed_users":           "*",
+			"default_user":            "alice",
+			"ttl":                     "1h",
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		keyring, socket := testSSHAgent(t)
+		privateKeyPath := testSSHPrivateKeyFile(t)
+
+		ui, cmd := testSSHAgentLoadCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-role", "my-role",
+			"-private-key-path", privateKeyPath,
+			"-agent-socket", socket,
+			"-valid-principals", "bob",
+			"-ttl", "10m",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "Success! Added " + privateKeyPath + " and its certificate to the SSH agent"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		keys, err := keyring.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 {
+			t.Fatalf("expected 1 key in the agent, got %d", len(keys))
+		}
+		if keys[0].Comment != "vault ssh/sign/my-role" {
+			t.Errorf("expected %q to be %q", keys[0].Comment, "vault ssh/sign/my-role")
+		}
+		pub, err := ssh.ParsePublicKey(keys[0].Blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, ok := pub.(*ssh.Certificate)
+		if !ok {
+			t.Fatalf("expected a certificate, got %s", pub.Type())
+		}
+		if len(cert.ValidPrincipals) != 1 || cert.ValidPrincipals[0] != "bob" {
+			t.Errorf("expected %q to be %q", cert.ValidPrincipals, []string{"bob"})
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		_, socket := testSSHAgent(t)
+
+		ui, cmd := testSSHAgentLoadCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-role", "my-role",
+			"-private-key-path", testSSHPrivateKeyFile(t),
+			"-agent-socket", socket,
+		})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error signing public key: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testSSHAgentLoadCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -1,8 +1,13 @@
 package command
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -214,3 +219,107 @@ func TestIsSingleSSHArg(t *testing.T) {
 		})
 	}
 }
+
+func TestSSHCommand_HostKeyKnownHosts(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	if err := client.Sys().Mount("host-signer", &api.MountInput{
+		Type: "ssh",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := client.Logical().Write("host-signer/config/ca", map[string]interface{}{
+		"generate_signing_key": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "@cert-authority *.example.com " + strings.TrimSpace(secret.Data["public_key"].(string)) + "\n"
+
+	t.Run("temporary", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testSSHCommand(t)
+		cmd.client = client
+		cmd.flagHostKeyMountPoint = "host-signer"
+		cmd.flagHostKeyHostnames = "*.example.com"
+
+		knownHosts, code, closer := cmd.hostKeyKnownHosts("alice", "127.0.0.1")
+		if code != 0 {
+			t.Fatalf("expected %d to be %d", code, 0)
+		}
+		b, err := ioutil.ReadFile(knownHosts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expected {
+			t.Errorf("expected %q to be %q", string(b), expected)
+		}
+
+		if err := closer(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(knownHosts); !os.IsNotExist(err) {
+			t.Errorf("expected the temporary known_hosts file to be removed, got %v", err)
+		}
+	})
+
+	t.Run("known_hosts_file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), ".ssh", "known_hosts")
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		existing := "example.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"
+		if err := ioutil.WriteFile(path, []byte(existing), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		// The line is only added once
+		for i := 0; i < 2; i++ {
+			_, cmd := testSSHCommand(t)
+			cmd.client = client
+			cmd.flagHostKeyMountPoint = "host-signer"
+			cmd.flagHostKeyHostnames = "*.example.com"
+			cmd.flagHostKeyKnownHostsFile = path
+
+			knownHosts, code, closer := cmd.hostKeyKnownHosts("alice", "127.0.0.1")
+			defer closer()
+			if code != 0 {
+				t.Fatalf("expected %d to be %d", code, 0)
+			}
+			if knownHosts != path {
+				t.Errorf("expected %q to be %q", knownHosts, path)
+			}
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := existing + "\n" + expected; string(b) != exp {
+			t.Errorf("expected %q to be %q", string(b), exp)
+		}
+	})
+
+	t.Run("missing_ca", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testSSHCommand(t)
+		cmd.client = client
+		cmd.flagHostKeyMountPoint = "not-a-mount"
+
+		_, code, closer := cmd.hostKeyKnownHosts("alice", "127.0.0.1")
+		defer closer()
+		if code != 2 {
+			t.Errorf("expected %d to be %d", code, 2)
+		}
+		if !strings.Contains(ui.ErrorWriter.String(), "host signing key") {
+			t.Errorf("unexpected error %q", ui.ErrorWriter.String())
+		}
+	})
+}
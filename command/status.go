@@ -80,7 +80,7 @@ func (c *StatusCommand) Run(args []string) int {
 
 	status, err := client.Sys().SealStatus()
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error checking seal status: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error checking seal status: %s", err), err)
 		return 1
 	}
 
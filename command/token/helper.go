@@ -11,3 +11,13 @@ type TokenHelper interface {
 	Get() (string, error)
 	Store(string) error
 }
+
+// ScopedTokenHelper is implemented by token helpers which can store a
+// different token for each Vault server and namespace.
+type ScopedTokenHelper interface {
+	TokenHelper
+
+	// ForScope returns a token helper for the given server address and
+	// namespace.
+	ForScope(address, namespace string) TokenHelper
+}
@@ -2,6 +2,7 @@ package token
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -36,7 +37,10 @@ func ExternalTokenHelperPath(path string) (string, error) {
 	return path, nil
 }
 
-var _ TokenHelper = (*ExternalTokenHelper)(nil)
+var (
+	_ TokenHelper       = (*ExternalTokenHelper)(nil)
+	_ ScopedTokenHelper = (*ExternalTokenHelper)(nil)
+)
 
 // ExternalTokenHelper is the struct that has all the logic for storing and retrieving
 // tokens from the token helper. The API for the helpers is simple: the
@@ -50,13 +54,62 @@ var _ TokenHelper = (*ExternalTokenHelper)(nil)
 //
 // Any errors can be written on stdout. If the helper exits with a non-zero
 // exit code then the stderr will be made part of the error value.
+//
+// Helpers speaking version 2 of the protocol are executed without an
+// operation argument. Instead, they are given an ExternalTokenHelperRequest
+// as JSON on stdin, which includes the address of the Vault server and the
+// namespace, and must write an ExternalTokenHelperResponse as JSON to stdout.
+// This allows them to store a different token for each server and namespace.
 type ExternalTokenHelper struct {
 	BinaryPath string
 	Env        []string
+
+	// Protocol is the version of the protocol spoken by the helper. Zero
+	// means version 1.
+	Protocol int
+
+	// Address and Namespace are sent to helpers speaking version 2 of the
+	// protocol.
+	Address   string
+	Namespace string
+}
+
+// ExternalTokenHelperRequest is the request given on stdin to helpers
+// speaking version 2 of the protocol.
+type ExternalTokenHelperRequest struct {
+	Version   int    `json:"version"`
+	Operation string `json:"operation"`
+	Address   string `json:"vault_addr"`
+	Namespace string `json:"namespace"`
+
+	// Token is only set for the "store" operation.
+	Token string `json:"token,omitempty"`
+}
+
+// ExternalTokenHelperResponse is the response written to stdout by helpers
+// speaking version 2 of the protocol. The output may be empty for the "store"
+// and "erase" operations, and for "get" if there is no token.
+type ExternalTokenHelperResponse struct {
+	Token string `json:"token,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ForScope returns a copy of the helper which sends the given address and
+// namespace to helpers speaking version 2 of the protocol.
+func (h *ExternalTokenHelper) ForScope(address, namespace string) TokenHelper {
+	scoped := *h
+	scoped.Address = address
+	scoped.Namespace = namespace
+	return &scoped
 }
 
 // Erase deletes the contents from the helper.
 func (h *ExternalTokenHelper) Erase() error {
+	if h.Protocol >= 2 {
+		_, err := h.run("erase", "")
+		return err
+	}
+
 	cmd, err := h.cmd("erase")
 	if err != nil {
 		return err
@@ -69,6 +122,10 @@ func (h *ExternalTokenHelper) Erase() error {
 
 // Get gets the token value from the helper.
 func (h *ExternalTokenHelper) Get() (string, error) {
+	if h.Protocol >= 2 {
+		return h.run("get", "")
+	}
+
 	var buf, stderr bytes.Buffer
 	cmd, err := h.cmd("get")
 	if err != nil {
@@ -85,6 +142,11 @@ func (h *ExternalTokenHelper) Get() (string, error) {
 
 // Store stores the token value into the helper.
 func (h *ExternalTokenHelper) Store(v string) error {
+	if h.Protocol >= 2 {
+		_, err := h.run("store", v)
+		return err
+	}
+
 	buf := bytes.NewBufferString(v)
 	cmd, err := h.cmd("store")
 	if err != nil {
@@ -112,6 +174,48 @@ func (h *ExternalTokenHelper) cmd(op string) (*exec.Cmd, error) {
 	return cmd, nil
 }
 
+// run executes a helper speaking version 2 of the protocol and returns the
+// token from its response.
+func (h *ExternalTokenHelper) run(op, token string) (string, error) {
+	req, err := json.Marshal(&ExternalTokenHelperRequest{
+		Version:   2,
+		Operation: op,
+		Address:   h.Address,
+		Namespace: h.Namespace,
+		Token:     token,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	cmd, err := ExecScript(strings.Replace(h.BinaryPath, "\\", "\\\\", -1))
+	if err != nil {
+		return "", err
+	}
+	cmd.Env = h.Env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%q: %w", stderr.String(), err)
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return "", nil
+	}
+
+	var resp ExternalTokenHelperResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("error parsing token helper response: %w", err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("token helper error: %s", resp.Error)
+	}
+	return resp.Token, nil
+}
+
 // ExecScript returns a command to execute a script
 func ExecScript(script string) (*exec.Cmd, error) {
 	var shell, flag string
@@ -1,6 +1,7 @@
 package token
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -54,6 +55,37 @@ func TestExternalTokenHelper(t *testing.T) {
 	Test(t, testExternalTokenHelper(t))
 }
 
+func TestExternalTokenHelper_v2(t *testing.T) {
+	helper := &ExternalTokenHelper{BinaryPath: helperPath("helper-v2"), Env: helperEnv(), Protocol: 2}
+
+	prod := helper.ForScope("https://prod:8200", "")
+	Test(t, prod)
+	Test(t, helper.ForScope("https://prod:8200", "ns1/"))
+
+	if err := prod.Store("prod-token"); err != nil {
+		t.Fatal(err)
+	}
+	dev := helper.ForScope("https://dev:8200", "")
+	if err := dev.Store("dev-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	for h, expected := range map[TokenHelper]string{prod: "prod-token", dev: "dev-token"} {
+		v, err := h.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != expected {
+			t.Fatalf("expected %q, got %q", expected, v)
+		}
+	}
+
+	failing := &ExternalTokenHelper{BinaryPath: helperPath("helper-v2-error"), Env: helperEnv(), Protocol: 2}
+	if _, err := failing.Get(); err == nil || !strings.Contains(err.Error(), "no keychain") {
+		t.Fatalf("expected the helper error to be returned, got %v", err)
+	}
+}
+
 func testExternalTokenHelper(t *testing.T) *ExternalTokenHelper {
 	return &ExternalTokenHelper{BinaryPath: helperPath("helper"), Env: helperEnv()}
 }
@@ -131,6 +163,38 @@ func TestExternalTokenHelperProcess(*testing.T) {
 			defer f.Close()
 			io.Copy(f, os.Stdin)
 		}
+	case "helper-v2":
+		path := os.Getenv("GO_HELPER_PATH")
+
+		var req ExternalTokenHelperRequest
+		if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+			fmt.Fprintf(os.Stderr, "Err: %s\n", err)
+			os.Exit(1)
+		}
+
+		tokens := make(map[string]string)
+		if b, err := ioutil.ReadFile(path); err == nil && len(b) > 0 {
+			json.Unmarshal(b, &tokens)
+		}
+
+		key := req.Address + "|" + req.Namespace
+		switch req.Operation {
+		case "get":
+			json.NewEncoder(os.Stdout).Encode(&ExternalTokenHelperResponse{Token: tokens[key]})
+			return
+		case "store":
+			tokens[key] = req.Token
+		case "erase":
+			delete(tokens, key)
+		}
+
+		b, _ := json.Marshal(tokens)
+		if err := ioutil.WriteFile(path, b, 0o600); err != nil {
+			fmt.Fprintf(os.Stderr, "Err: %s\n", err)
+			os.Exit(1)
+		}
+	case "helper-v2-error":
+		json.NewEncoder(os.Stdout).Encode(&ExternalTokenHelperResponse{Error: "no keychain"})
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %q\n", cmd)
 		os.Exit(2)
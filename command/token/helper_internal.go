@@ -2,9 +2,12 @@ package token
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -12,13 +15,25 @@ import (
 	"github.com/natefinch/atomic"
 )
 
-var _ TokenHelper = (*InternalTokenHelper)(nil)
+var (
+	_ TokenHelper       = (*InternalTokenHelper)(nil)
+	_ ScopedTokenHelper = (*InternalTokenHelper)(nil)
+)
 
 // InternalTokenHelper fulfills the TokenHelper interface when no external
 // token-helper is configured, and avoids shelling out
+//
+// Once scoped with ForScope, the helper also keeps the token of each Vault
+// server and namespace in ~/.vault-tokens, and Get returns the token stored
+// for the server and the closest namespace. ~/.vault-token always holds the
+// most recently stored token, for tools which read it directly.
 type InternalTokenHelper struct {
 	tokenPath string
 	homeDir   string
+
+	scoped    bool
+	address   string
+	namespace string
 }
 
 func NewInternalTokenHelper() (*InternalTokenHelper, error) {
@@ -39,8 +54,45 @@ func (i *InternalTokenHelper) Path() string {
 	return i.tokenPath
 }
 
+// ForScope returns a copy of the helper which stores the token of the given
+// server address and namespace separately from the tokens of others.
+func (i *InternalTokenHelper) ForScope(address, namespace string) TokenHelper {
+	scoped := *i
+	scoped.scoped = true
+	scoped.address = address
+	scoped.namespace = namespace
+	return &scoped
+}
+
 // Get gets the value of the stored token, if any
 func (i *InternalTokenHelper) Get() (string, error) {
+	if i.scoped {
+		tokens, err := i.readScopedTokens()
+		if err != nil {
+			return "", err
+		}
+
+		// Tokens of a parent namespace can be used in its children
+		if len(tokens) > 0 {
+			ns := strings.Trim(i.namespace, "/")
+			for {
+				if token, ok := tokens[scopeKey(i.address, ns)]; ok {
+					return token, nil
+				}
+				if ns == "" {
+					return "", nil
+				}
+				ns = path.Dir(ns)
+				if ns == "." {
+					ns = ""
+				}
+			}
+		}
+
+		// Nothing was stored per server yet, so fall back to the token
+		// stored by older versions
+	}
+
 	i.populateTokenPath()
 	f, err := os.Open(i.tokenPath)
 	if os.IsNotExist(err) {
@@ -63,8 +115,25 @@ func (i *InternalTokenHelper) Get() (string, error) {
 // existing file atomically to ensure that ownership and permissions are set
 // appropriately.
 func (i *InternalTokenHelper) Store(input string) error {
+	if i.scoped {
+		tokens, err := i.readScopedTokens()
+		if err != nil {
+			return err
+		}
+		tokens[scopeKey(i.address, i.namespace)] = input
+		if err := i.writeScopedTokens(tokens); err != nil {
+			return err
+		}
+	}
+
 	i.populateTokenPath()
-	tmpFile := i.tokenPath + ".tmp"
+	return writeFileAtomic(i.tokenPath, []byte(input))
+}
+
+// writeFileAtomic writes data to a temporary file readable only by the
+// current user, and then moves it to path.
+func writeFileAtomic(path string, data []byte) error {
+	tmpFile := path + ".tmp"
 	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
 	if err != nil {
 		return err
@@ -72,7 +141,7 @@ func (i *InternalTokenHelper) Store(input string) error {
 	defer f.Close()
 	defer os.Remove(tmpFile)
 
-	_, err = io.WriteString(f, input)
+	_, err = f.Write(data)
 	if err != nil {
 		return err
 	}
@@ -87,11 +156,35 @@ func (i *InternalTokenHelper) Store(input string) error {
 	// to simply re-write it, and the simplest way to ensure that we don't
 	// damage an existing working file due to error is the write-rename pattern.
 	// os.Rename on Windows will return an error if the target already exists.
-	return atomic.ReplaceFile(tmpFile, i.tokenPath)
+	return atomic.ReplaceFile(tmpFile, path)
 }
 
 // Erase erases the value of the token
 func (i *InternalTokenHelper) Erase() error {
+	if i.scoped {
+		tokens, err := i.readScopedTokens()
+		if err != nil {
+			return err
+		}
+		if len(tokens) > 0 {
+			key := scopeKey(i.address, i.namespace)
+			token, ok := tokens[key]
+			if !ok {
+				return nil
+			}
+			delete(tokens, key)
+			if err := i.writeScopedTokens(tokens); err != nil {
+				return err
+			}
+
+			// Only erase the most recently stored token if it is the one of
+			// this server and namespace
+			if current, err := i.unscopedGet(); err != nil || current != token {
+				return err
+			}
+		}
+	}
+
 	i.populateTokenPath()
 	if err := os.Remove(i.tokenPath); err != nil && !os.IsNotExist(err) {
 		return err
@@ -99,3 +192,53 @@ func (i *InternalTokenHelper) Erase() error {
 
 	return nil
 }
+
+func (i *InternalTokenHelper) unscopedGet() (string, error) {
+	unscoped := *i
+	unscoped.scoped = false
+	return unscoped.Get()
+}
+
+func (i *InternalTokenHelper) scopedTokensPath() string {
+	return filepath.Join(i.homeDir, ".vault-tokens")
+}
+
+// readScopedTokens reads the tokens stored per server and namespace.
+func (i *InternalTokenHelper) readScopedTokens() (map[string]string, error) {
+	tokens := make(map[string]string)
+
+	b, err := ioutil.ReadFile(i.scopedTokensPath())
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return tokens, nil
+	}
+
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", i.scopedTokensPath(), err)
+	}
+	return tokens, nil
+}
+
+func (i *InternalTokenHelper) writeScopedTokens(tokens map[string]string) error {
+	b, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(i.scopedTokensPath(), b)
+}
+
+// scopeKey returns the key of the token of the given server address and
+// namespace in ~/.vault-tokens.
+func scopeKey(address, namespace string) string {
+	address = strings.TrimSuffix(address, "/")
+	namespace = strings.Trim(namespace, "/")
+	if namespace == "" {
+		return address
+	}
+	return address + "|" + namespace + "/"
+}
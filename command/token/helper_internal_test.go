@@ -60,3 +60,74 @@ func TestInternalHelperFilePerms(t *testing.T) {
 		t.Fatalf("expected no world-readable/writable permission bits, got: %o", fi.Mode().Perm())
 	}
 }
+
+func TestInternalHelper_scoped(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	helper, err := NewInternalTokenHelper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	helper.homeDir = tmpDir
+
+	// Tokens stored by older versions are used until a scoped one is stored
+	if err := helper.Store("legacy"); err != nil {
+		t.Fatal(err)
+	}
+	prod := helper.ForScope("https://prod:8200", "")
+	if v, err := prod.Get(); err != nil || v != "legacy" {
+		t.Fatalf("expected the legacy token, got %q: %v", v, err)
+	}
+
+	Test(t, prod)
+
+	prodNS := helper.ForScope("https://prod:8200", "ns1/")
+	dev := helper.ForScope("https://dev:8200", "")
+	for h, token := range map[TokenHelper]string{prod: "prod", prodNS: "prod-ns1", dev: "dev"} {
+		if err := h.Store(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for h, expected := range map[TokenHelper]string{
+		prod:   "prod",
+		prodNS: "prod-ns1",
+		dev:    "dev",
+		// Child namespaces use the token of the closest parent
+		helper.ForScope("https://prod:8200", "ns1/child/"): "prod-ns1",
+		helper.ForScope("https://dev:8200", "ns2"):         "dev",
+		helper.ForScope("https://other:8200", ""):          "",
+		// The unscoped helper returns the most recently stored token
+		helper: "dev",
+	} {
+		v, err := h.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != expected {
+			t.Errorf("%s: expected %q, got %q", h.Path(), expected, v)
+		}
+	}
+
+	// Erasing the token of another server keeps the most recent one
+	if err := prod.Erase(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := prod.Get(); v != "" {
+		t.Errorf("expected no token, got %q", v)
+	}
+	if v, _ := helper.Get(); v != "dev" {
+		t.Errorf("expected %q, got %q", "dev", v)
+	}
+
+	if err := dev.Erase(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := helper.Get(); v != "" {
+		t.Errorf("expected no token, got %q", v)
+	}
+}
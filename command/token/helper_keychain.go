@@ -0,0 +1,65 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+var _ TokenHelper = (*KeychainTokenHelper)(nil)
+
+const (
+	// DefaultKeychainService is the service name under which tokens are
+	// stored in the OS keychain.
+	DefaultKeychainService = "vault"
+
+	// DefaultKeychainAccount is the account name under which tokens are
+	// stored in the OS keychain.
+	DefaultKeychainAccount = "default"
+)
+
+// KeychainTokenHelper fulfills the TokenHelper interface by storing the token
+// in the credential store of the OS: the Keychain on macOS, the Credential
+// Manager on Windows, and the Secret Service (libsecret) elsewhere.
+type KeychainTokenHelper struct {
+	Service string
+	Account string
+}
+
+// NewKeychainTokenHelper returns a KeychainTokenHelper using the default
+// service and account names.
+func NewKeychainTokenHelper() *KeychainTokenHelper {
+	return &KeychainTokenHelper{
+		Service: DefaultKeychainService,
+		Account: DefaultKeychainAccount,
+	}
+}
+
+func (k *KeychainTokenHelper) Path() string {
+	return fmt.Sprintf("keychain (service %q, account %q)", k.Service, k.Account)
+}
+
+// Get gets the value of the stored token, if any
+func (k *KeychainTokenHelper) Get() (string, error) {
+	token, err := keychainGet(k.Service, k.Account)
+	if err != nil {
+		return "", fmt.Errorf("error reading token from the keychain: %w", err)
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// Store stores the token in the keychain, replacing any existing one
+func (k *KeychainTokenHelper) Store(input string) error {
+	if err := keychainStore(k.Service, k.Account, input); err != nil {
+		return fmt.Errorf("error storing token in the keychain: %w", err)
+	}
+	return nil
+}
+
+// Erase removes the token from the keychain. It is not an error if there
+// is no token stored.
+func (k *KeychainTokenHelper) Erase() error {
+	if err := keychainErase(k.Service, k.Account); err != nil {
+		return fmt.Errorf("error erasing token from the keychain: %w", err)
+	}
+	return nil
+}
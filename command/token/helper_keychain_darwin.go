@@ -0,0 +1,67 @@
+//go:build darwin
+
+package token
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit status of the security tool when no
+// matching item exists in the keychain.
+const errSecItemNotFound = 44
+
+func keychainGet(service, account string) (string, error) {
+	cmd := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if isExitStatus(err, errSecItemNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func keychainStore(service, account, token string) error {
+	// The token is passed to the interactive mode of the security tool on
+	// stdin rather than as an argument, so that it does not show up in the
+	// process list.
+	for _, v := range []string{service, account, token} {
+		if strings.ContainsAny(v, "\"\\\n") {
+			return errors.New("value contains characters which cannot be stored in the keychain")
+		}
+	}
+
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -w \"%s\"\n", service, account, token))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func keychainErase(service, account string) error {
+	cmd := exec.Command("security", "delete-generic-password", "-s", service, "-a", account)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if isExitStatus(err, errSecItemNotFound) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func isExitStatus(err error, status int) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == status
+}
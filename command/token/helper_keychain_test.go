@@ -0,0 +1,19 @@
+package token
+
+import (
+	"os"
+	"testing"
+)
+
+// TestKeychainTokenHelper stores a token in the real keychain of the OS, so
+// it only runs when VAULT_TEST_KEYCHAIN is set.
+func TestKeychainTokenHelper(t *testing.T) {
+	if os.Getenv("VAULT_TEST_KEYCHAIN") == "" {
+		t.Skip("set VAULT_TEST_KEYCHAIN to run keychain tests")
+	}
+
+	Test(t, &KeychainTokenHelper{
+		Service: "vault-test",
+		Account: t.Name(),
+	})
+}
@@ -2,10 +2,15 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
+	"github.com/mitchellh/mapstructure"
 	"github.com/posener/complete"
 )
 
@@ -14,8 +19,14 @@ var (
 	_ cli.CommandAutocomplete = (*TokenCapabilitiesCommand)(nil)
 )
 
+// capabilitiesBatchSize is the number of paths whose capabilities are
+// fetched in a single request when checking a tree of paths.
+const capabilitiesBatchSize = 100
+
 type TokenCapabilitiesCommand struct {
 	*BaseCommand
+
+	flagRecursive bool
 }
 
 func (c *TokenCapabilitiesCommand) Synopsis() string {
@@ -39,6 +50,14 @@ Usage: vault token capabilities [options] [TOKEN] PATH
 
       $ vault token capabilities 96ddf4bc-d217-f3ba-f9bd-017055595017 cubbyhole/foo
 
+  List capabilities for the local token on every secret below "secret/app/",
+  with the paths the token is denied highlighted:
+
+      $ vault token capabilities -recursive secret/app/
+
+  With -recursive, the paths are listed with the locally authenticated token.
+  For KV version 2 mounts, the capabilities on the "data/" paths are shown.
+
   For a full list of examples, please see the documentation.
 
 ` + c.Flags().Help()
@@ -47,7 +66,18 @@ Usage: vault token capabilities [options] [TOKEN] PATH
 }
 
 func (c *TokenCapabilitiesCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "recursive",
+		Target:  &c.flagRecursive,
+		Default: false,
+		Usage: "Treat PATH as a prefix, list every path below it and print the " +
+			"capabilities of the token on each of them in a table.",
+	})
+
+	return set
 }
 
 func (c *TokenCapabilitiesCommand) AutocompleteArgs() complete.Predictor {
@@ -88,6 +118,10 @@ func (c *TokenCapabilitiesCommand) Run(args []string) int {
 		return 2
 	}
 
+	if c.flagRecursive {
+		return c.runRecursive(client, token, path)
+	}
+
 	var capabilities []string
 	if token == "" {
 		capabilities, err = client.Sys().CapabilitiesSelf(path)
@@ -95,7 +129,7 @@ func (c *TokenCapabilitiesCommand) Run(args []string) int {
 		capabilities, err = client.Sys().Capabilities(token, path)
 	}
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error listing capabilities: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error listing capabilities: %s", err), err)
 		return 2
 	}
 	if capabilities == nil {
@@ -112,3 +146,106 @@ func (c *TokenCapabilitiesCommand) Run(args []string) int {
 		return OutputData(c.UI, capabilities)
 	}
 }
+
+// runRecursive prints the capabilities of token on every path below prefix.
+func (c *TokenCapabilitiesCommand) runRecursive(client *api.Client, token, prefix string) int {
+	prefix = ensureTrailingSlash(sanitizePath(prefix))
+	listPrefix, reportPrefix := prefix, prefix
+	if mountPath, v2, err := isKVv2(prefix, client); err == nil && v2 {
+		listPrefix = ensureTrailingSlash(addPrefixToKVPath(prefix, mountPath, "metadata"))
+		reportPrefix = ensureTrailingSlash(addPrefixToKVPath(prefix, mountPath, "data"))
+	}
+
+	paths, err := c.listTree(client, listPrefix, reportPrefix)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error listing paths: %s", err), err)
+		return 2
+	}
+	if len(paths) == 0 {
+		c.UI.Error(fmt.Sprintf("No paths found under %s", prefix))
+		return 2
+	}
+
+	capabilities := make(map[string][]string, len(paths))
+	for start := 0; start < len(paths); start += capabilitiesBatchSize {
+		end := start + capabilitiesBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+
+		reqPath, data := "sys/capabilities-self", map[string]interface{}{"paths": paths[start:end]}
+		if token != "" {
+			reqPath = "sys/capabilities"
+			data["token"] = token
+		}
+		secret, err := client.Logical().Write(reqPath, data)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error listing capabilities: %s", err), err)
+			return 2
+		}
+		if secret == nil || secret.Data == nil {
+			c.UI.Error("No capabilities found")
+			return 2
+		}
+		for _, p := range paths[start:end] {
+			var caps []string
+			if err := mapstructure.Decode(secret.Data[p], &caps); err != nil {
+				outputError(c.UI, fmt.Sprintf("Error decoding capabilities of %s: %s", p, err), err)
+				return 2
+			}
+			sort.Strings(caps)
+			capabilities[p] = caps
+		}
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, capabilities)
+	}
+
+	useColor := !color.NoColor && os.Getenv(EnvVaultCLINoColor) == ""
+	deny := color.New(color.FgRed)
+	table := []string{"Path | Capabilities"}
+	for _, p := range paths {
+		row := fmt.Sprintf("%s | %s", p, strings.Join(capabilities[p], ", "))
+		if useColor && strutil.StrListContains(capabilities[p], "deny") {
+			row = deny.Sprint(row)
+		}
+		table = append(table, row)
+	}
+	c.UI.Output(tableOutput(table, nil))
+	return 0
+}
+
+// listTree lists every leaf below listPrefix, returning their paths below
+// reportPrefix in order. Directories which cannot be listed are reported as
+// a warning and skipped.
+func (c *TokenCapabilitiesCommand) listTree(client *api.Client, listPrefix, reportPrefix string) ([]string, error) {
+	secret, err := client.Logical().List(listPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	keys, _ := extractListData(secret)
+	sorted := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if key, ok := k.(string); ok {
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		if !strings.HasSuffix(key, "/") {
+			paths = append(paths, reportPrefix+key)
+			continue
+		}
+		children, err := c.listTree(client, listPrefix+key, reportPrefix+key)
+		if err != nil {
+			c.UI.Warn(fmt.Sprintf("Could not list %s: %s", reportPrefix+key, err))
+			continue
+		}
+		paths = append(paths, children...)
+	}
+	return paths, nil
+}
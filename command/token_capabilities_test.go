@@ -3,6 +3,7 @@ package command
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/mitchellh/cli"
@@ -182,6 +183,96 @@ func TestTokenCapabilitiesCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("recursive", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		for _, path := range []string{"secret/app/a", "secret/app/b/c", "secret/other"} {
+			if _, err := client.Logical().Write(path, map[string]interface{}{"foo": "bar"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		policy := `
+path "secret/app/a" { capabilities = ["read", "update"] }
+path "secret/app/b/*" { capabilities = ["deny"] }
+`
+		if err := client.Sys().PutPolicy("policy", policy); err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+			Policies: []string{"policy"},
+			TTL:      "30m",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testTokenCapabilitiesCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-recursive", secret.Auth.ClientToken, "secret/app",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		output := ui.OutputWriter.String()
+		for _, expected := range []string{"secret/app/a      read, update", "secret/app/b/c    deny"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("expected %q to contain %q", output, expected)
+			}
+		}
+		if strings.Contains(output, "secret/other") {
+			t.Errorf("expected %q not to contain paths outside of the prefix", output)
+		}
+	})
+
+	t.Run("recursive_kv_v2", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("kv", &api.MountInput{
+			Type:    "kv",
+			Options: map[string]string{"version": "2"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		// Writes can fail until the KV v2 upgrade has finished.
+		var err error
+		for i := 0; i < 20; i++ {
+			if _, err = client.Logical().Write("kv/data/app/foo", map[string]interface{}{
+				"data": map[string]interface{}{"foo": "bar"},
+			}); err == nil {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testTokenCapabilitiesCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-recursive", "kv/app/",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "kv/data/app/foo    root"
+		if output := ui.OutputWriter.String(); !strings.Contains(output, expected) {
+			t.Errorf("expected %q to contain %q", output, expected)
+		}
+	})
+
 	t.Run("no_tabs", func(t *testing.T) {
 		t.Parallel()
 
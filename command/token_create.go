@@ -1,11 +1,13 @@
 package command
 
 import (
+	"flag"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 )
@@ -32,6 +34,7 @@ type TokenCreateCommand struct {
 	flagMetadata        map[string]string
 	flagPolicies        []string
 	flagEntityAlias     string
+	flagCount           int
 }
 
 func (c *TokenCreateCommand) Synopsis() string {
@@ -57,6 +60,11 @@ Usage: vault token create [options]
 
   If a role is specified, the role may override parameters specified here.
 
+  Several tokens with the same parameters can be created at once with "-count".
+  Unless a type is given, these are batch tokens if the other options allow it:
+
+      $ vault token create -count=20 -policy=fleet -ttl=24h -format=json
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -189,6 +197,16 @@ func (c *TokenCreateCommand) Flags() *FlagSets {
 			"the entity will not be inherited from the parent.",
 	})
 
+	f.IntVar(&IntVar{
+		Name:    "count",
+		Target:  &c.flagCount,
+		Default: 1,
+		Usage: "Number of tokens to create with these parameters. When more " +
+			"than one token is created and -type is not given, batch tokens " +
+			"are created unless -role, -period, -use-limit or -renewable are " +
+			"set, or the parent token does not allow it.",
+	})
+
 	return set
 }
 
@@ -214,6 +232,30 @@ func (c *TokenCreateCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagCount < 1 {
+		c.UI.Error("Count must be at least 1")
+		return 1
+	}
+	if c.flagCount > 1 && c.flagID != "" {
+		c.UI.Error("Cannot specify -id when creating more than one token")
+		return 1
+	}
+
+	// Prefer batch tokens when creating many tokens, unless an option
+	// which batch tokens do not support was given.
+	preferBatch := false
+	if c.flagCount > 1 {
+		preferBatch = c.flagRole == "" && c.flagPeriod == 0 && c.flagUseLimit == 0
+		f.Visit(func(fl *flag.Flag) {
+			if fl.Name == "type" || fl.Name == "renewable" {
+				preferBatch = false
+			}
+		})
+	}
+	if preferBatch {
+		c.flagType = "batch"
+	}
+
 	if c.flagType == "batch" {
 		c.flagRenewable = false
 	}
@@ -240,20 +282,98 @@ func (c *TokenCreateCommand) Run(args []string) int {
 		EntityAlias:     c.flagEntityAlias,
 	}
 
-	var secret *api.Secret
-	if c.flagRole != "" {
-		secret, err = client.Auth().Token().CreateWithRole(tcr, c.flagRole)
-	} else {
-		secret, err = client.Auth().Token().Create(tcr)
+	create := func() (*api.Secret, error) {
+		if c.flagRole != "" {
+			return client.Auth().Token().CreateWithRole(tcr, c.flagRole)
+		}
+		return client.Auth().Token().Create(tcr)
 	}
-	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error creating token: %s", err))
-		return 2
+
+	if c.flagCount == 1 {
+		secret, err := create()
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error creating token: %s", err), err)
+			return 2
+		}
+
+		if c.flagField != "" {
+			return PrintRawField(c.UI, secret, c.flagField)
+		}
+
+		return OutputSecret(c.UI, secret)
+	}
+
+	secrets := make([]*api.Secret, 0, c.flagCount)
+	for len(secrets) < c.flagCount {
+		secret, err := create()
+		if err != nil && preferBatch && len(secrets) == 0 {
+			// For example, batch tokens cannot be created by root tokens
+			// without a policy.
+			c.UI.Warn(fmt.Sprintf("Could not create batch tokens, creating service tokens instead: %s", err))
+			preferBatch = false
+			renewable := true
+			tcr.Type = "service"
+			tcr.Renewable = &renewable
+			continue
+		}
+		if err != nil {
+			// Output the tokens created so far, so that they are not lost.
+			if len(secrets) > 0 {
+				c.outputTokens(secrets)
+			}
+			outputError(c.UI, fmt.Sprintf("Error creating token %d of %d: %s", len(secrets)+1, c.flagCount, err), err)
+			return 2
+		}
+		secrets = append(secrets, secret)
 	}
 
+	return c.outputTokens(secrets)
+}
+
+// outputTokens prints several created tokens, as a list for structured
+// formats and as a table otherwise.
+func (c *TokenCreateCommand) outputTokens(secrets []*api.Secret) int {
 	if c.flagField != "" {
-		return PrintRawField(c.UI, secret, c.flagField)
+		values := make([]interface{}, 0, len(secrets))
+		for _, secret := range secrets {
+			val := RawField(secret, c.flagField)
+			if val == nil {
+				c.UI.Error(fmt.Sprintf("Field %q not present in secret", c.flagField))
+				return 1
+			}
+			values = append(values, val)
+		}
+		if format := Format(c.UI); format == "" || format == "table" {
+			for _, val := range values {
+				c.UI.Output(fmt.Sprintf("%v", val))
+			}
+			return 0
+		}
+		return OutputData(c.UI, values)
 	}
 
-	return OutputSecret(c.UI, secret)
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, secrets)
+	}
+
+	table := []string{"Token | Accessor | Type | TTL | Renewable | Policies"}
+	for _, secret := range secrets {
+		auth := secret.Auth
+		if auth == nil {
+			continue
+		}
+		accessor := auth.Accessor
+		if accessor == "" {
+			accessor = "n/a"
+		}
+		tokenType := "service"
+		if strings.HasPrefix(auth.ClientToken, consts.BatchTokenPrefix) || strings.HasPrefix(auth.ClientToken, consts.LegacyBatchTokenPrefix) {
+			tokenType = "batch"
+		}
+		table = append(table, fmt.Sprintf("%s | %s | %s | %s | %t | %s",
+			auth.ClientToken, accessor, tokenType, humanDurationInt(auth.LeaseDuration),
+			auth.Renewable, strings.Join(auth.TokenPolicies, ", ")))
+	}
+	c.UI.Output(tableOutput(table, nil))
+	return 0
 }
@@ -60,6 +60,24 @@ func TestTokenCreateCommand_Run(t *testing.T) {
 			"false",
 			0,
 		},
+		{
+			"count_zero",
+			[]string{"-count", "0"},
+			"Count must be at least 1",
+			1,
+		},
+		{
+			"count_with_id",
+			[]string{"-count", "2", "-id", "foo"},
+			"Cannot specify -id",
+			1,
+		},
+		{
+			"count_table",
+			[]string{"-count", "2", "-policy", "foo"},
+			"batch",
+			0,
+		},
 		{
 			"field_not_found",
 			[]string{
@@ -197,6 +215,80 @@ func TestTokenCreateCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("count", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []struct {
+			name   string
+			args   []string
+			prefix string
+			warn   string
+		}{
+			{
+				"batch",
+				[]string{"-policy", "foo"},
+				"hvb.",
+				"",
+			},
+			{
+				"service_type",
+				[]string{"-policy", "foo", "-type", "service"},
+				"hvs.",
+				"",
+			},
+			{
+				"service_use_limit",
+				[]string{"-policy", "foo", "-use-limit", "5"},
+				"hvs.",
+				"",
+			},
+			{
+				// Batch tokens cannot be root tokens
+				"root_fallback",
+				nil,
+				"hvs.",
+				"Could not create batch tokens",
+			},
+		}
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testTokenCreateCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(append(tc.args, "-count", "3", "-field", "token"))
+				if exp := 0; code != exp {
+					t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+				}
+
+				if !strings.Contains(ui.ErrorWriter.String(), tc.warn) {
+					t.Errorf("expected %q to contain %q", ui.ErrorWriter.String(), tc.warn)
+				}
+
+				tokens := strings.Fields(ui.OutputWriter.String())
+				if len(tokens) != 3 {
+					t.Fatalf("expected 3 tokens, got %q", tokens)
+				}
+				for _, token := range tokens {
+					if !strings.HasPrefix(token, tc.prefix) {
+						t.Errorf("expected %q to have prefix %q", token, tc.prefix)
+					}
+					secret, err := client.Auth().Token().Lookup(token)
+					if secret == nil || err != nil {
+						t.Fatal(err)
+					}
+				}
+			})
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
@@ -122,7 +122,7 @@ func (c *TokenLookupCommand) Run(args []string) int {
 	}
 
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error looking up token: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error looking up token: %s", err), err)
 		return 2
 	}
 
@@ -133,7 +133,7 @@ func (c *TokenRenewCommand) Run(args []string) int {
 		secret, err = client.Auth().Token().Renew(token, inc)
 	}
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error renewing token: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error renewing token: %s", err), err)
 		return 2
 	}
 
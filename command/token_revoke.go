@@ -167,7 +167,7 @@ func (c *TokenRevokeCommand) Run(args []string) int {
 	}
 
 	if err := revokeFn(token); err != nil {
-		c.UI.Error(fmt.Sprintf("Error revoking token: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error revoking token: %s", err), err)
 		return 2
 	}
 
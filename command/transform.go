@@ -0,0 +1,47 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*TransformCommand)(nil)
+
+type TransformCommand struct {
+	*BaseCommand
+}
+
+func (c *TransformCommand) Synopsis() string {
+	return "Interact with Vault's transform secrets engine"
+}
+
+func (c *TransformCommand) Help() string {
+	helpText := `
+Usage: vault transform <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with Vault's transform
+  secrets engine. Here are a few examples of the transform commands:
+
+  Encode a credit card number with the "payments" role:
+
+      $ vault transform encode -transformation=ccn payments 4111-1111-1111-1111
+
+  Decode it:
+
+      $ vault transform decode -transformation=ccn payments 6232-8761-2903-2834
+
+  Encode the "card" column of a CSV file:
+
+      $ vault transform encode -transformation=ccn -column=card \
+          -input=customers.csv -out=customers.encoded.csv payments
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransformCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransformDecodeCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransformDecodeCommand)(nil)
+)
+
+type TransformDecodeCommand struct {
+	*BaseCommand
+
+	op transformOperation
+}
+
+func (c *TransformDecodeCommand) Synopsis() string {
+	return "Decode values with a transform role"
+}
+
+func (c *TransformDecodeCommand) Help() string {
+	helpText := `
+Usage: vault transform decode [options] ROLE [VALUE]
+
+  Decodes a value, or the columns of a file of records, encoded with the given
+  role of the transform secrets engine. Masking transformations can't be
+  decoded.
+
+  With -input, the values of the columns given with -column are decoded for
+  every record of the file, in batches, and the records are written to the
+  standard output or to -out, in the format of the input. Files ending in
+  ".csv" are read as CSV with a header row naming the columns, all others as
+  newline delimited JSON objects.
+
+  Decode a credit card number with the "payments" role:
+
+      $ vault transform decode -transformation=ccn payments 6232-8761-2903-2834
+
+  Decode the "card" column of newline delimited JSON records:
+
+      $ vault transform decode -transformation=ccn -column=card \
+          -input=customers.ndjson payments
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransformDecodeCommand) Flags() *FlagSets {
+	c.op.operation = "decode"
+
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	c.op.addFlags(set.NewFlagSet("Command Options"))
+	return set
+}
+
+func (c *TransformDecodeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *TransformDecodeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransformDecodeCommand) Run(args []string) int {
+	return c.op.run(c.BaseCommand, c.Flags(), args)
+}
@@ -0,0 +1,169 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testTransformDecodeCommand(tb testing.TB) (*cli.MockUi, *TransformDecodeCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransformDecodeCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestTransformDecodeCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"payments", "ccn:4111", "ccn:4222"},
+			"Too many arguments",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testTransformDecodeCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("value", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := testTransformServer(t)
+
+		ui, cmd := testTransformDecodeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-transformation=ccn", "-field=decoded_value", "payments", "ccn:4111"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if exp, act := "4111", strings.TrimSpace(ui.OutputWriter.String()); exp != act {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := testTransformServer(t)
+
+		input := filepath.Join(t.TempDir(), "customers.ndjson")
+		if err := ioutil.WriteFile(input, []byte(`{"card":"ccn:4111","name":"alice"}`+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testTransformDecodeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-transformation=ccn", "-column=card", "-input=" + input, "payments"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := `{"card":"4111","name":"alice"}` + "\n"
+		if actual := ui.OutputWriter.String(); actual != expected {
+			t.Errorf("expected %q to be %q", actual, expected)
+		}
+	})
+
+	t.Run("batch_error", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := testTransformServer(t)
+
+		dir := t.TempDir()
+		input := filepath.Join(dir, "customers.csv")
+		out := filepath.Join(dir, "customers.decoded.csv")
+		if err := ioutil.WriteFile(input, []byte("card\nccn:4111\ninvalid\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testTransformDecodeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-transformation=ccn", "-column=card", "-input=" + input, "-out=" + out, "payments"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error decoding records 1 to 2: invalid value"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Errorf("expected no output file, got %v", err)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testTransformDecodeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"payments", "ccn:4111"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error decoding value: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransformDecodeCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
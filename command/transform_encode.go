@@ -0,0 +1,72 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransformEncodeCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransformEncodeCommand)(nil)
+)
+
+type TransformEncodeCommand struct {
+	*BaseCommand
+
+	op transformOperation
+}
+
+func (c *TransformEncodeCommand) Synopsis() string {
+	return "Encode values with a transform role"
+}
+
+func (c *TransformEncodeCommand) Help() string {
+	helpText := `
+Usage: vault transform encode [options] ROLE [VALUE]
+
+  Encodes a value, or the columns of a file of records, with the given role
+  of the transform secrets engine, using format preserving encryption (FPE),
+  tokenization or masking transformations.
+
+  With -input, the values of the columns given with -column are encoded for
+  every record of the file, in batches, and the records are written to the
+  standard output or to -out, in the format of the input. Files ending in
+  ".csv" are read as CSV with a header row naming the columns, all others as
+  newline delimited JSON objects.
+
+  Encode a credit card number with the "payments" role:
+
+      $ vault transform encode -transformation=ccn payments 4111-1111-1111-1111
+
+  Encode the "card" and "ssn" columns of a CSV file, with the "ccn" and
+  "us-ssn" transformations:
+
+      $ vault transform encode -column=card=ccn -column=ssn=us-ssn \
+          -input=customers.csv -out=customers.encoded.csv payments
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransformEncodeCommand) Flags() *FlagSets {
+	c.op.operation = "encode"
+
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	c.op.addFlags(set.NewFlagSet("Command Options"))
+	return set
+}
+
+func (c *TransformEncodeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *TransformEncodeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransformEncodeCommand) Run(args []string) int {
+	return c.op.run(c.BaseCommand, c.Flags(), args)
+}
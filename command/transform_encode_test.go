@@ -0,0 +1,298 @@
+package command
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testTransformEncodeCommand(tb testing.TB) (*cli.MockUi, *TransformEncodeCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransformEncodeCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testTransformServer returns a client of a server with the "payments" role
+// of the transform secrets engine, which encodes values by prefixing them
+// with their transformation, and a function returning the number of requests.
+// The value "invalid" fails to be transformed.
+func testTransformServer(tb testing.TB) (*api.Client, func() int32) {
+	tb.Helper()
+
+	transform := func(operation string, item map[string]interface{}) map[string]interface{} {
+		value, _ := item["value"].(string)
+		transformation, _ := item["transformation"].(string)
+		if value == "invalid" {
+			return map[string]interface{}{"error": "invalid value"}
+		}
+		if operation == "encode" {
+			return map[string]interface{}{"encoded_value": transformation + ":" + value}
+		}
+		return map[string]interface{}{"decoded_value": strings.TrimPrefix(value, transformation+":")}
+	}
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var operation string
+		switch r.URL.Path {
+		case "/v1/transform/encode/payments":
+			operation = "encode"
+		case "/v1/transform/decode/payments":
+			operation = "decode"
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&requests, 1)
+
+		var req struct {
+			Value          string                   `json:"value"`
+			Transformation string                   `json:"transformation"`
+			BatchInput     []map[string]interface{} `json:"batch_input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		data := map[string]interface{}{}
+		if req.BatchInput == nil {
+			data = transform(operation, map[string]interface{}{
+				"value":          req.Value,
+				"transformation": req.Transformation,
+			})
+			if data["error"] != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{"errors": []interface{}{data["error"]}})
+				return
+			}
+		} else {
+			var results []interface{}
+			for _, item := range req.BatchInput {
+				results = append(results, transform(operation, item))
+			}
+			data["batch_results"] = results
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	tb.Cleanup(server.Close)
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return client, func() int32 {
+		return atomic.LoadInt32(&requests)
+	}
+}
+
+func TestTransformEncodeCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "customers.csv")
+	csvInput := "name,card,ssn\nalice,4111,123\nbob,,456\ncarol,4222,789\n"
+	if err := ioutil.WriteFile(csvPath, []byte(csvInput), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"payments"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"payments", "4111", "4222"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"too_many_args_input",
+			[]string{"-input=" + csvPath, "-column=card", "payments", "4111"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"column_without_input",
+			[]string{"-column=card", "payments", "4111"},
+			"-column requires -input",
+			1,
+		},
+		{
+			"input_without_column",
+			[]string{"-input=" + csvPath, "payments"},
+			"-column is required",
+			1,
+		},
+		{
+			"bad_batch_size",
+			[]string{"-batch-size=0", "payments", "4111"},
+			"-batch-size must be at least 1",
+			1,
+		},
+		{
+			"missing_column",
+			[]string{"-input=" + csvPath, "-column=phone", "payments"},
+			"no \"phone\" column",
+			1,
+		},
+		{
+			"duplicate_column",
+			[]string{"-input=" + csvPath, "-column=card", "-column=card=ccn", "payments"},
+			"given more than once",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, _ := testTransformServer(t)
+
+				ui, cmd := testTransformEncodeCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("value", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := testTransformServer(t)
+
+		ui, cmd := testTransformEncodeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-transformation=ccn", "-field=encoded_value", "payments", "4111"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if exp, act := "ccn:4111", strings.TrimSpace(ui.OutputWriter.String()); exp != act {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+
+		client, requests := testTransformServer(t)
+
+		ui, cmd := testTransformEncodeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-transformation=ccn", "-column=card", "-column=ssn=us-ssn", "-batch-size=2",
+			"-input=" + csvPath, "payments",
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "name,card,ssn\nalice,ccn:4111,us-ssn:123\nbob,,us-ssn:456\ncarol,ccn:4222,us-ssn:789\n"
+		if actual := ui.OutputWriter.String(); actual != expected {
+			t.Errorf("expected %q to be %q", actual, expected)
+		}
+		if exp, act := int32(2), requests(); exp != act {
+			t.Errorf("expected %d requests, got %d", exp, act)
+		}
+	})
+
+	t.Run("ndjson_out", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := testTransformServer(t)
+
+		input := filepath.Join(t.TempDir(), "customers.ndjson")
+		out := input + ".encoded"
+		if err := ioutil.WriteFile(input, []byte(`{"name":"alice","card":"4111"}`+"\n\n"+`{"name":"bob","card":4222}`+"\n"+`{"name":"carol"}`+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testTransformEncodeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-transformation=ccn", "-column=card", "-input=" + input, "-out=" + out, "payments"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "Success! Encoded 2 values of 3 records to: " + out
+		if actual := ui.OutputWriter.String(); !strings.Contains(actual, expected) {
+			t.Errorf("expected %q to contain %q", actual, expected)
+		}
+
+		b, err := ioutil.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = `{"card":"ccn:4111","name":"alice"}` + "\n" + `{"card":"ccn:4222","name":"bob"}` + "\n" + `{"name":"carol"}` + "\n"
+		if actual := string(b); actual != expected {
+			t.Errorf("expected %q to be %q", actual, expected)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testTransformEncodeCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"payments", "4111"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error encoding value: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransformEncodeCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
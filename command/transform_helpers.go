@@ -0,0 +1,540 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/posener/complete"
+)
+
+// transformOperation holds the flags and the implementation shared by the
+// "transform encode" and "transform decode" commands. The operation is either
+// "encode" or "decode".
+type transformOperation struct {
+	operation string
+
+	flagMount          string
+	flagTransformation string
+	flagTweak          string
+	flagInput          string
+	flagOut            string
+	flagColumns        []string
+	flagBatchSize      int
+}
+
+func (o *transformOperation) addFlags(f *FlagSet) {
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &o.flagMount,
+		Default:    "transform",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the transform secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "transformation",
+		Target:     &o.flagTransformation,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Name of the transformation to " + o.operation + " with. This is " +
+			"required if the role has more than one transformation.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "tweak",
+		Target:     &o.flagTweak,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Base64 encoded tweak of FPE transformations with a supplied " +
+			"tweak source.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "input",
+		Target:     &o.flagInput,
+		Default:    "",
+		Completion: complete.PredictOr(complete.PredictFiles("*.csv"), complete.PredictFiles("*.ndjson")),
+		Usage: "Path of a file of records to " + o.operation + " in batches, " +
+			"instead of a single value. Files ending in \".csv\" are read as CSV " +
+			"with a header row, all others as newline delimited JSON objects.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "out",
+		Target:     &o.flagOut,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path of the file to write the records to, in the format of the " +
+			"input. Defaults to the standard output.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "column",
+		Target:     &o.flagColumns,
+		Completion: complete.PredictAnything,
+		Usage: "Column of the input records to " + o.operation + ", given as " +
+			"NAME, or NAME=TRANSFORMATION to use a transformation other than " +
+			"-transformation. This can be specified multiple times, and is " +
+			"required with -input.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:       "batch-size",
+		Target:     &o.flagBatchSize,
+		Default:    100,
+		Completion: complete.PredictAnything,
+		Usage:      "Maximum number of records sent to Vault in a single request.",
+	})
+}
+
+// valueField returns the field of the response holding the transformed value.
+func (o *transformOperation) valueField() string {
+	return o.operation + "d_value"
+}
+
+// gerund returns the operation in its -ing form, for messages.
+func (o *transformOperation) gerund() string {
+	return strings.TrimSuffix(o.operation, "e") + "ing"
+}
+
+func (o *transformOperation) run(c *BaseCommand, f *FlagSets, args []string) int {
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	expected := 2
+	if o.flagInput != "" {
+		expected = 1
+	}
+	switch {
+	case len(args) < expected:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected %d, got %d)", expected, len(args)))
+		return 1
+	case len(args) > expected:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected %d, got %d)", expected, len(args)))
+		return 1
+	case o.flagInput == "" && len(o.flagColumns) > 0:
+		c.UI.Error("-column requires -input")
+		return 1
+	case o.flagInput != "" && len(o.flagColumns) == 0:
+		c.UI.Error("-column is required with -input")
+		return 1
+	case o.flagBatchSize < 1:
+		c.UI.Error("-batch-size must be at least 1")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	path := fmt.Sprintf("%s/%s/%s", sanitizePath(o.flagMount), o.operation, sanitizePath(args[0]))
+
+	if o.flagInput == "" {
+		data := map[string]interface{}{
+			"value": args[1],
+		}
+		if o.flagTransformation != "" {
+			data["transformation"] = o.flagTransformation
+		}
+		if o.flagTweak != "" {
+			data["tweak"] = o.flagTweak
+		}
+		secret, err := client.Logical().Write(path, data)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error %s value: %s", o.gerund(), err), err)
+			return 2
+		}
+		if secret == nil {
+			c.UI.Error(fmt.Sprintf("No value returned by %s", path))
+			return 2
+		}
+
+		if c.flagField != "" {
+			return PrintRawField(c.UI, secret, c.flagField)
+		}
+		return OutputSecret(c.UI, secret)
+	}
+
+	return o.runBatch(c, client, path)
+}
+
+// runBatch transforms the columns of the records of the input file, sending
+// up to -batch-size records to Vault at once.
+func (o *transformOperation) runBatch(c *BaseCommand, client *api.Client, path string) int {
+	columns, transformations, err := parseTransformColumns(o.flagColumns, o.flagTransformation)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	in, err := os.Open(o.flagInput)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error opening input: %s", err), err)
+		return 1
+	}
+	defer in.Close()
+
+	var buf bytes.Buffer
+	var codec transformCodec
+	if strings.HasSuffix(strings.ToLower(o.flagInput), ".csv") {
+		codec, err = newTransformCSVCodec(in, &buf, columns)
+	} else {
+		codec = newTransformJSONCodec(in, &buf, columns)
+	}
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading input: %s", err), err)
+		return 1
+	}
+
+	var out *os.File
+	if o.flagOut != "" {
+		out, err = createOutputFile(o.flagOut, 0o600)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error creating output file: %s", err), err)
+			return 1
+		}
+		defer func() {
+			if out != nil {
+				out.Close()
+				os.Remove(out.Name())
+			}
+		}()
+	}
+
+	// emit writes the records encoded so far to the output
+	emit := func() error {
+		if err := codec.flush(); err != nil {
+			return err
+		}
+		defer buf.Reset()
+		if out == nil {
+			if buf.Len() > 0 {
+				c.UI.Output(strings.TrimSuffix(buf.String(), "\n"))
+			}
+			return nil
+		}
+		_, err := out.Write(buf.Bytes())
+		return err
+	}
+
+	var records, values int
+	for done := false; !done; {
+		var batch []*transformRecord
+		for len(batch) < o.flagBatchSize {
+			record, err := codec.read()
+			if err == io.EOF {
+				done = true
+				break
+			}
+			if err != nil {
+				outputError(c.UI, fmt.Sprintf("Error reading record %d: %s", records+len(batch)+1, err), err)
+				return 1
+			}
+			batch = append(batch, record)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		n, err := o.transformBatch(client, path, batch, transformations)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error %s records %d to %d: %s",
+				o.gerund(), records+1, records+len(batch), err))
+			return 2
+		}
+		records += len(batch)
+		values += n
+
+		for _, record := range batch {
+			if err := codec.write(record); err != nil {
+				outputError(c.UI, fmt.Sprintf("Error writing records: %s", err), err)
+				return 1
+			}
+		}
+		if err := emit(); err != nil {
+			outputError(c.UI, fmt.Sprintf("Error writing records: %s", err), err)
+			return 1
+		}
+	}
+	// The CSV header is still pending if there were no records
+	if err := emit(); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing records: %s", err), err)
+		return 1
+	}
+
+	if out != nil {
+		err := commitOutputFile(out, o.flagOut)
+		out = nil
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error writing output file: %s", err), err)
+			return 1
+		}
+		c.UI.Output(fmt.Sprintf("Success! %s %d values of %d records to: %s",
+			strings.ToUpper(o.operation[:1])+o.operation[1:]+"d", values, records, o.flagOut))
+	}
+	return 0
+}
+
+// transformBatch transforms the values of the given records in a single
+// request, and returns the number of values transformed.
+func (o *transformOperation) transformBatch(client *api.Client, path string, batch []*transformRecord, transformations []string) (int, error) {
+	type ref struct {
+		record *transformRecord
+		column int
+	}
+	var refs []ref
+	var input []map[string]interface{}
+	for _, record := range batch {
+		for i, value := range record.values {
+			if !record.present[i] {
+				continue
+			}
+			item := map[string]interface{}{
+				"value": value,
+			}
+			if transformations[i] != "" {
+				item["transformation"] = transformations[i]
+			}
+			if o.flagTweak != "" {
+				item["tweak"] = o.flagTweak
+			}
+			input = append(input, item)
+			refs = append(refs, ref{record: record, column: i})
+		}
+	}
+	if len(input) == 0 {
+		return 0, nil
+	}
+
+	secret, err := client.Logical().Write(path, map[string]interface{}{
+		"batch_input": input,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if secret == nil {
+		return 0, fmt.Errorf("no results returned by %s", path)
+	}
+	results, _ := secret.Data["batch_results"].([]interface{})
+	if len(results) != len(input) {
+		return 0, fmt.Errorf("expected %d results, got %d", len(input), len(results))
+	}
+
+	field := o.valueField()
+	for i, raw := range results {
+		result, _ := raw.(map[string]interface{})
+		if msg, ok := result["error"].(string); ok && msg != "" {
+			return 0, errors.New(msg)
+		}
+		value, ok := result[field].(string)
+		if !ok {
+			return 0, fmt.Errorf("no %s in result %d", field, i+1)
+		}
+		refs[i].record.values[refs[i].column] = value
+	}
+	return len(input), nil
+}
+
+// parseTransformColumns parses the -column flags into the names of the
+// columns and their transformations.
+func parseTransformColumns(flags []string, transformation string) ([]string, []string, error) {
+	columns := make([]string, 0, len(flags))
+	transformations := make([]string, 0, len(flags))
+	seen := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		name, t := flag, transformation
+		if i := strings.Index(flag, "="); i >= 0 {
+			name, t = flag[:i], flag[i+1:]
+		}
+		if name == "" {
+			return nil, nil, fmt.Errorf("invalid column %q", flag)
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("column %q given more than once", name)
+		}
+		seen[name] = true
+		columns = append(columns, name)
+		transformations = append(transformations, t)
+	}
+	return columns, transformations, nil
+}
+
+// transformRecord is a record of a batch input file. The values are those of
+// the columns given with -column, in order.
+type transformRecord struct {
+	values  []string
+	present []bool
+
+	// raw is the record as read from the input file
+	raw interface{}
+}
+
+// transformCodec reads the records of a batch input file, and writes them
+// back once transformed.
+type transformCodec interface {
+	read() (*transformRecord, error)
+	write(*transformRecord) error
+	flush() error
+}
+
+// transformCSVCodec reads and writes CSV records, whose first row is a header
+// naming the columns. Empty values are not transformed.
+type transformCSVCodec struct {
+	r       *csv.Reader
+	w       *csv.Writer
+	indexes []int
+}
+
+func newTransformCSVCodec(r io.Reader, w io.Writer, columns []string) (*transformCSVCodec, error) {
+	c := &transformCSVCodec{
+		r: csv.NewReader(r),
+		w: csv.NewWriter(w),
+	}
+	header, err := c.r.Read()
+	if err == io.EOF {
+		return nil, errors.New("no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, column := range columns {
+		index := -1
+		for i, name := range header {
+			if name == column {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("no %q column", column)
+		}
+		c.indexes = append(c.indexes, index)
+	}
+
+	return c, c.w.Write(header)
+}
+
+func (c *transformCSVCodec) read() (*transformRecord, error) {
+	row, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	record := &transformRecord{
+		values:  make([]string, len(c.indexes)),
+		present: make([]bool, len(c.indexes)),
+		raw:     row,
+	}
+	for i, index := range c.indexes {
+		record.values[i] = row[index]
+		record.present[i] = row[index] != ""
+	}
+	return record, nil
+}
+
+func (c *transformCSVCodec) write(record *transformRecord) error {
+	row := record.raw.([]string)
+	for i, index := range c.indexes {
+		row[index] = record.values[i]
+	}
+	return c.w.Write(row)
+}
+
+func (c *transformCSVCodec) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// transformJSONCodec reads and writes newline delimited JSON objects. Missing
+// and null fields are not transformed, and other values than strings are
+// transformed as their JSON representation.
+type transformJSONCodec struct {
+	scanner *bufio.Scanner
+	w       io.Writer
+	columns []string
+}
+
+func newTransformJSONCodec(r io.Reader, w io.Writer, columns []string) *transformJSONCodec {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &transformJSONCodec{
+		scanner: scanner,
+		w:       w,
+		columns: columns,
+	}
+}
+
+func (c *transformJSONCodec) read() (*transformRecord, error) {
+	var line []byte
+	for len(line) == 0 {
+		if !c.scanner.Scan() {
+			if err := c.scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		line = bytes.TrimSpace(c.scanner.Bytes())
+	}
+
+	var object map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, errors.New("not a JSON object")
+	}
+
+	record := &transformRecord{
+		values:  make([]string, len(c.columns)),
+		present: make([]bool, len(c.columns)),
+		raw:     object,
+	}
+	for i, column := range c.columns {
+		switch value := object[column].(type) {
+		case nil:
+		case string:
+			record.values[i], record.present[i] = value, true
+		default:
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			record.values[i], record.present[i] = string(b), true
+		}
+	}
+	return record, nil
+}
+
+func (c *transformJSONCodec) write(record *transformRecord) error {
+	object := record.raw.(map[string]interface{})
+	for i, column := range c.columns {
+		if record.present[i] {
+			object[column] = record.values[i]
+		}
+	}
+	b, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	_, err = c.w.Write(append(b, '\n'))
+	return err
+}
+
+func (c *transformJSONCodec) flush() error {
+	return nil
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+var _ cli.Command = (*TransitCommand)(nil)
+
+type TransitCommand struct {
+	*BaseCommand
+}
+
+func (c *TransitCommand) Synopsis() string {
+	return "Interact with Vault's transit secrets engine"
+}
+
+func (c *TransitCommand) Help() string {
+	helpText := `
+Usage: vault transit <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with Vault's transit secrets
+  engine. Here are a few examples of the transit commands:
+
+  Encrypt a file with the "backups" key:
+
+      $ vault transit encrypt-file backups db.dump
+
+  Decrypt the file:
+
+      $ vault transit decrypt-file backups db.dump.enc
+
+  Generate a data key for envelope encryption, and write it to files:
+
+      $ vault transit datakey -plaintext-out=key.bin -ciphertext-out=key.enc backups
+
+  Sign a release artifact, and verify its signature:
+
+      $ vault transit sign-file releases app.tar.gz
+      $ vault transit verify-file releases app.tar.gz
+
+  Please see the individual subcommand help for detailed usage information.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
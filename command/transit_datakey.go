@@ -0,0 +1,208 @@
+package command
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransitDatakeyCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransitDatakeyCommand)(nil)
+)
+
+type TransitDatakeyCommand struct {
+	*BaseCommand
+
+	flagMount         string
+	flagPlaintextOut  string
+	flagCiphertextOut string
+	flagBits          int
+	flagContext       string
+}
+
+func (c *TransitDatakeyCommand) Synopsis() string {
+	return "Generate a data key and write it to files"
+}
+
+func (c *TransitDatakeyCommand) Help() string {
+	helpText := `
+Usage: vault transit datakey [options] KEY
+
+  Generates a data key wrapped by the given key of the transit secrets engine,
+  for envelope encryption, and writes the plaintext data key and the wrapped
+  data key to separate files, only readable by their owner. The plaintext
+  data key is never printed.
+
+  The plaintext file holds the raw bytes of the data key, and the ciphertext
+  file holds the wrapped data key, which can be decrypted later with the
+  transit key.
+
+  Generate a data key with the "backups" key:
+
+      $ vault transit datakey -plaintext-out=key.bin -ciphertext-out=key.enc backups
+
+  Generate a data key, only writing its wrapped form:
+
+      $ vault transit datakey -ciphertext-out=key.enc backups
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitDatakeyCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "transit",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the transit secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "plaintext-out",
+		Target:     &c.flagPlaintextOut,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path of the file to write the raw plaintext data key to. If " +
+			"unset, only the wrapped data key is generated.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "ciphertext-out",
+		Target:     &c.flagCiphertextOut,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path of the file to write the wrapped data key to. This is required.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:       "bits",
+		Target:     &c.flagBits,
+		Default:    256,
+		Completion: complete.PredictSet("128", "256", "512"),
+		Usage:      "Number of bits of the data key: 128, 256 or 512.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "context",
+		Target:     &c.flagContext,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage:      "Base64 encoded context for key derivation, required for derived keys.",
+	})
+
+	return set
+}
+
+func (c *TransitDatakeyCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *TransitDatakeyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransitDatakeyCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 1:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
+		return 1
+	case len(args) > 1:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	case c.flagCiphertextOut == "":
+		c.UI.Error("-ciphertext-out is required")
+		return 1
+	case c.flagPlaintextOut == c.flagCiphertextOut:
+		c.UI.Error("-plaintext-out and -ciphertext-out must be different files")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	keyType := "wrapped"
+	if c.flagPlaintextOut != "" {
+		keyType = "plaintext"
+	}
+	data := map[string]interface{}{
+		"bits": c.flagBits,
+	}
+	if c.flagContext != "" {
+		data["context"] = c.flagContext
+	}
+
+	path := fmt.Sprintf("%s/datakey/%s/%s", sanitizePath(c.flagMount), keyType, sanitizePath(args[0]))
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error generating data key: %s", err), err)
+		return 2
+	}
+	var ciphertext string
+	if secret != nil {
+		ciphertext, _ = secret.Data["ciphertext"].(string)
+	}
+	if ciphertext == "" {
+		c.UI.Error(fmt.Sprintf("No data key returned by %s", path))
+		return 2
+	}
+
+	// Write the plaintext data key first, so that a wrapped data key is never
+	// left behind without it
+	if c.flagPlaintextOut != "" {
+		plaintextB64, _ := secret.Data["plaintext"].(string)
+		plaintext, err := base64.StdEncoding.DecodeString(plaintextB64)
+		if err != nil || len(plaintext) == 0 {
+			c.UI.Error("Error decoding the plaintext data key")
+			return 2
+		}
+		err = writeOutputFile(c.flagPlaintextOut, plaintext, 0o600)
+		zeroBytes(plaintext)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Error writing plaintext data key: %s", err), err)
+			return 2
+		}
+	}
+	if err := writeOutputFile(c.flagCiphertextOut, []byte(ciphertext+"\n"), 0o600); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing wrapped data key: %s", err), err)
+		return 2
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		if c.flagPlaintextOut != "" {
+			c.UI.Output(fmt.Sprintf("Success! Wrote the plaintext data key to: %s", c.flagPlaintextOut))
+		}
+		c.UI.Output(fmt.Sprintf("Success! Wrote the wrapped data key to: %s", c.flagCiphertextOut))
+		return 0
+	default:
+		out := map[string]interface{}{
+			"ciphertext":     ciphertext,
+			"key_version":    secret.Data["key_version"],
+			"ciphertext_out": c.flagCiphertextOut,
+		}
+		if c.flagPlaintextOut != "" {
+			out["plaintext_out"] = c.flagPlaintextOut
+		}
+		return OutputData(c.UI, out)
+	}
+}
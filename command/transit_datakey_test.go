@@ -0,0 +1,197 @@
+package command
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testTransitDatakeyCommand(tb testing.TB) (*cli.MockUi, *TransitDatakeyCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitDatakeyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestTransitDatakeyCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"-ciphertext-out=key.enc"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"-ciphertext-out=key.enc", "foo", "bar"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"no_ciphertext_out",
+			[]string{"-plaintext-out=key.bin", "foo"},
+			"-ciphertext-out is required",
+			1,
+		},
+		{
+			"same_files",
+			[]string{"-plaintext-out=key", "-ciphertext-out=key", "foo"},
+			"must be different files",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testTransitDatakeyCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		if err := client.Sys().Mount("transit", &api.MountInput{
+			Type: "transit",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Logical().Write("transit/keys/foo", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		dir := t.TempDir()
+		plaintextOut := filepath.Join(dir, "key.bin")
+		ciphertextOut := filepath.Join(dir, "key.enc")
+
+		ui, cmd := testTransitDatakeyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-format=json", "-plaintext-out=" + plaintextOut, "-ciphertext-out=" + ciphertextOut, "foo"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		for _, path := range []string{plaintextOut, ciphertextOut} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o600 {
+				t.Fatalf("expected %s to have mode 0600, got %v", path, info.Mode().Perm())
+			}
+		}
+
+		plaintext, err := ioutil.ReadFile(plaintextOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plaintext) != 32 {
+			t.Fatalf("expected a 32 bytes data key, got %d bytes", len(plaintext))
+		}
+		if strings.Contains(ui.OutputWriter.String(), base64.StdEncoding.EncodeToString(plaintext)) {
+			t.Fatal("the plaintext data key was printed")
+		}
+
+		// The wrapped data key decrypts to the plaintext data key
+		ciphertext, err := ioutil.ReadFile(ciphertextOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret, err := client.Logical().Write("transit/decrypt/foo", map[string]interface{}{
+			"ciphertext": strings.TrimSpace(string(ciphertext)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := base64.StdEncoding.DecodeString(secret.Data["plaintext"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Fatal("the wrapped data key doesn't match the plaintext data key")
+		}
+
+		// Only the wrapped data key is written without -plaintext-out
+		wrappedOut := filepath.Join(dir, "wrapped.enc")
+		ui, cmd = testTransitDatakeyCommand(t)
+		cmd.client = client
+
+		code = cmd.Run([]string{"-bits=512", "-ciphertext-out=" + wrappedOut, "foo"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		if _, err := os.Stat(wrappedOut); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(ui.OutputWriter.String(), "plaintext") {
+			t.Fatalf("unexpected output %q", ui.OutputWriter.String())
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testTransitDatakeyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-ciphertext-out=" + filepath.Join(t.TempDir(), "key.enc"), "foo"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error generating data key: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransitDatakeyCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -0,0 +1,194 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransitDecryptFileCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransitDecryptFileCommand)(nil)
+)
+
+type TransitDecryptFileCommand struct {
+	*BaseCommand
+
+	flagMount    string
+	flagOut      string
+	flagContext  string
+	flagProgress bool
+}
+
+func (c *TransitDecryptFileCommand) Synopsis() string {
+	return "Decrypt a file encrypted with a transit key"
+}
+
+func (c *TransitDecryptFileCommand) Help() string {
+	helpText := `
+Usage: vault transit decrypt-file [options] KEY FILE
+
+  Decrypts a file encrypted by "vault transit encrypt-file" with the given key
+  of the transit secrets engine. Vault unwraps the data key stored in the
+  file, and the file is decrypted locally with the data key, in chunks
+  streamed to the output file. Every chunk is authenticated, so that a
+  corrupted or truncated file fails to decrypt.
+
+  The output file is only written once the whole file is decrypted.
+
+  Decrypt db.dump.enc with the "backups" key to db.dump:
+
+      $ vault transit decrypt-file backups db.dump.enc
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitDecryptFileCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "transit",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the transit secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "out",
+		Target:     &c.flagOut,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path of the decrypted file. Defaults to the path of the file " +
+			"without its \".enc\" extension.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "context",
+		Target:     &c.flagContext,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Base64 encoded context for key derivation, as given when the " +
+			"file was encrypted.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "progress",
+		Target:     &c.flagProgress,
+		Default:    true,
+		Completion: complete.PredictNothing,
+		Usage:      "Report the progress of the decryption.",
+	})
+
+	return set
+}
+
+func (c *TransitDecryptFileCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*.enc")
+}
+
+func (c *TransitDecryptFileCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransitDecryptFileCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	key, input := args[0], args[1]
+	output := c.flagOut
+	if output == "" {
+		if !strings.HasSuffix(input, ".enc") || input == ".enc" {
+			c.UI.Error("-out is required when the file has no \".enc\" extension")
+			return 1
+		}
+		output = strings.TrimSuffix(input, ".enc")
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error opening file: %s", err), err)
+		return 1
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error opening file: %s", err), err)
+		return 1
+	}
+
+	header, err := readTransitFileHeader(in)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading file: %s", err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	data := map[string]interface{}{
+		"ciphertext": header.WrappedKey,
+	}
+	if c.flagContext != "" {
+		data["context"] = c.flagContext
+	}
+	path := fmt.Sprintf("%s/decrypt/%s", sanitizePath(c.flagMount), sanitizePath(key))
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error decrypting data key: %s", err), err)
+		return 2
+	}
+	dataKey, err := transitFileDataKey(secret)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error decrypting data key: %s", err), err)
+		return 2
+	}
+	defer zeroBytes(dataKey)
+
+	out, err := createOutputFile(output, 0o600)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error creating output file: %s", err), err)
+		return 2
+	}
+
+	progress := func(int64) {}
+	if c.flagProgress {
+		progress = transitFileProgress(c.UI.Info, "Decrypted", header.plaintextSize(info.Size()))
+	}
+	if err := decryptTransitFile(out, in, header, dataKey, progress); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		outputError(c.UI, fmt.Sprintf("Error decrypting file: %s", err), err)
+		return 2
+	}
+	if err := commitOutputFile(out, output); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing output file: %s", err), err)
+		return 2
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Decrypted %s to: %s", input, output))
+	return 0
+}
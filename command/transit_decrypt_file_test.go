@@ -0,0 +1,168 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testTransitDecryptFileCommand(tb testing.TB) (*cli.MockUi, *TransitDecryptFileCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitDecryptFileCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestTransitDecryptFileCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"foo"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar.enc", "baz"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"no_extension",
+			[]string{"foo", "bar"},
+			"-out is required",
+			1,
+		},
+		{
+			"not_encrypted",
+			[]string{"-out=" + os.DevNull, "foo", "transit_decrypt_file_test.go"},
+			"not a file encrypted with transit",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testTransitDecryptFileCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer, path, _ := testTransitFileServer(t)
+		defer closer()
+
+		_, encryptCmd := testTransitEncryptFileCommand(t)
+		encryptCmd.client = client
+		if code := encryptCmd.Run([]string{"-progress=false", "foo", path}); code != 0 {
+			t.Fatalf("expected %d to be 0", code)
+		}
+		encrypted, err := ioutil.ReadFile(path + ".enc")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, corrupted := range map[string][]byte{
+			"truncated": encrypted[:len(encrypted)-transitFileChunkSize],
+			"modified":  append(append([]byte{}, encrypted[:len(encrypted)-1]...), encrypted[len(encrypted)-1]^1),
+		} {
+			if err := ioutil.WriteFile(path+".enc", corrupted, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			ui, cmd := testTransitDecryptFileCommand(t)
+			cmd.client = client
+
+			code := cmd.Run([]string{"foo", path + ".enc"})
+			if exp := 2; code != exp {
+				t.Errorf("%s: expected %d to be %d", name, code, exp)
+			}
+
+			expected := "Error decrypting file: "
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, expected) {
+				t.Errorf("%s: expected %q to contain %q", name, combined, expected)
+			}
+
+			// Nothing is written when the decryption fails
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(contents) != 3*transitFileChunkSize+123 {
+				t.Fatalf("%s: the original file was replaced", name)
+			}
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		header, err := newTransitFileHeader("vault:v1:foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "data.enc")
+		if err := ioutil.WriteFile(path, header.raw, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testTransitDecryptFileCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"foo", path})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error decrypting data key: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransitDecryptFileCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
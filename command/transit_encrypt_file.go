@@ -0,0 +1,194 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransitEncryptFileCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransitEncryptFileCommand)(nil)
+)
+
+type TransitEncryptFileCommand struct {
+	*BaseCommand
+
+	flagMount    string
+	flagOut      string
+	flagContext  string
+	flagProgress bool
+}
+
+func (c *TransitEncryptFileCommand) Synopsis() string {
+	return "Encrypt a file with a transit key"
+}
+
+func (c *TransitEncryptFileCommand) Help() string {
+	helpText := `
+Usage: vault transit encrypt-file [options] KEY FILE
+
+  Encrypts a file of any size with the given key of the transit secrets
+  engine, using envelope encryption: Vault generates a data key wrapped by the
+  transit key, and the file is encrypted locally with the data key, in chunks
+  streamed to the output file. Only the wrapped data key is stored in the
+  output file, so decrypting it requires the transit key.
+
+  The output file is only written once the whole file is encrypted.
+
+  Encrypt db.dump with the "backups" key to db.dump.enc:
+
+      $ vault transit encrypt-file backups db.dump
+
+  Encrypt a file with a derived key:
+
+      $ vault transit encrypt-file -context=dGVuYW50LTE= -out=tenant-1.enc backups data.tar
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitEncryptFileCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "transit",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the transit secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "out",
+		Target:     &c.flagOut,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path of the encrypted file. Defaults to the path of the file " +
+			"with the \".enc\" extension added.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "context",
+		Target:     &c.flagContext,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage:      "Base64 encoded context for key derivation, required for derived keys.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "progress",
+		Target:     &c.flagProgress,
+		Default:    true,
+		Completion: complete.PredictNothing,
+		Usage:      "Report the progress of the encryption.",
+	})
+
+	return set
+}
+
+func (c *TransitEncryptFileCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *TransitEncryptFileCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransitEncryptFileCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	key, input := args[0], args[1]
+	output := c.flagOut
+	if output == "" {
+		output = input + ".enc"
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error opening file: %s", err), err)
+		return 1
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error opening file: %s", err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	data := map[string]interface{}{
+		"bits": 256,
+	}
+	if c.flagContext != "" {
+		data["context"] = c.flagContext
+	}
+	path := fmt.Sprintf("%s/datakey/plaintext/%s", sanitizePath(c.flagMount), sanitizePath(key))
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error generating data key: %s", err), err)
+		return 2
+	}
+	dataKey, err := transitFileDataKey(secret)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error generating data key: %s", err), err)
+		return 2
+	}
+	defer zeroBytes(dataKey)
+	wrappedKey, _ := secret.Data["ciphertext"].(string)
+
+	header, err := newTransitFileHeader(wrappedKey)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error encrypting file: %s", err), err)
+		return 2
+	}
+
+	out, err := createOutputFile(output, 0o644)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error creating output file: %s", err), err)
+		return 2
+	}
+
+	progress := func(int64) {}
+	if c.flagProgress {
+		progress = transitFileProgress(c.UI.Info, "Encrypted", info.Size())
+	}
+	if err := encryptTransitFile(out, in, header, dataKey, progress); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		outputError(c.UI, fmt.Sprintf("Error encrypting file: %s", err), err)
+		return 2
+	}
+	if err := commitOutputFile(out, output); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing output file: %s", err), err)
+		return 2
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Encrypted %s to: %s", input, output))
+	return 0
+}
@@ -0,0 +1,184 @@
+package command
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testTransitEncryptFileCommand(tb testing.TB) (*cli.MockUi, *TransitEncryptFileCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitEncryptFileCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testTransitFileServer returns a Vault server with a transit key named "foo",
+// and the path of a file spanning several chunks.
+func testTransitFileServer(tb testing.TB) (*api.Client, func(), string, []byte) {
+	tb.Helper()
+
+	client, closer := testVaultServer(tb)
+
+	if err := client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := client.Logical().Write("transit/keys/foo", nil); err != nil {
+		tb.Fatal(err)
+	}
+
+	contents := make([]byte, 3*transitFileChunkSize+123)
+	if _, err := rand.Read(contents); err != nil {
+		tb.Fatal(err)
+	}
+	path := filepath.Join(tb.TempDir(), "data")
+	if err := ioutil.WriteFile(path, contents, 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return client, closer, path, contents
+}
+
+func TestTransitEncryptFileCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"foo"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar", "baz"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"missing_file",
+			[]string{"foo", filepath.Join("testdata", "missing")},
+			"Error opening file",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testTransitEncryptFileCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer, path, contents := testTransitFileServer(t)
+		defer closer()
+
+		ui, cmd := testTransitEncryptFileCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"foo", path})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		for _, expected := range []string{"Encrypted 192 KiB of 192 KiB (100%)", "Success! Encrypted"} {
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+
+		encrypted, err := ioutil.ReadFile(path + ".enc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(encrypted, contents[:64]) {
+			t.Fatal("encrypted file contains the plaintext")
+		}
+
+		// Decrypting gives the original contents back
+		ui, decryptCmd := testTransitDecryptFileCommand(t)
+		decryptCmd.client = client
+
+		code = decryptCmd.Run([]string{"-out=" + path + ".dec", "foo", path + ".enc"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+		decrypted, err := ioutil.ReadFile(path + ".dec")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, contents) {
+			t.Fatal("decrypted file differs from the original")
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		path := filepath.Join(t.TempDir(), "data")
+		if err := ioutil.WriteFile(path, []byte("foo"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testTransitEncryptFileCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"foo", path})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error generating data key: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransitEncryptFileCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
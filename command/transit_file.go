@@ -0,0 +1,295 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+)
+
+// The files encrypted by "vault transit encrypt-file" start with a header
+// holding the data key wrapped by the transit key, followed by the contents
+// encrypted with the data key in chunks. Each chunk is sealed with AES-GCM,
+// with a nonce made of a random prefix, the index of the chunk and a flag
+// marking the last chunk, so that chunks can't be reordered, dropped or
+// truncated without failing the decryption. The header is the additional data
+// of every chunk.
+//
+//	magic (8 bytes) | chunk size (uint32) | nonce prefix (7 bytes) |
+//	wrapped key length (uint16) | wrapped key | chunks...
+const (
+	transitFileMagic           = "VLTTRN01"
+	transitFileChunkSize       = 64 * 1024
+	transitFileNoncePrefixSize = 7
+	transitFileTagSize         = 16
+)
+
+// transitFileProgressInterval is how often the progress of the encryption or
+// decryption of a file is reported.
+const transitFileProgressInterval = time.Second
+
+// transitFileHeader is the header of an encrypted file.
+type transitFileHeader struct {
+	ChunkSize   uint32
+	NoncePrefix []byte
+	WrappedKey  string
+
+	// raw is the encoded header, authenticated with every chunk
+	raw []byte
+}
+
+func newTransitFileHeader(wrappedKey string) (*transitFileHeader, error) {
+	if len(wrappedKey) > math.MaxUint16 {
+		return nil, errors.New("wrapped data key too long")
+	}
+
+	h := &transitFileHeader{
+		ChunkSize:   transitFileChunkSize,
+		NoncePrefix: make([]byte, transitFileNoncePrefixSize),
+		WrappedKey:  wrappedKey,
+	}
+	if _, err := rand.Read(h.NoncePrefix); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(transitFileMagic)
+	binary.Write(&buf, binary.BigEndian, h.ChunkSize)
+	buf.Write(h.NoncePrefix)
+	binary.Write(&buf, binary.BigEndian, uint16(len(wrappedKey)))
+	buf.WriteString(wrappedKey)
+	h.raw = buf.Bytes()
+	return h, nil
+}
+
+func readTransitFileHeader(r io.Reader) (*transitFileHeader, error) {
+	fixed := make([]byte, len(transitFileMagic)+4+transitFileNoncePrefixSize+2)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, errors.New("not a file encrypted with transit: header too short")
+	}
+	if string(fixed[:len(transitFileMagic)]) != transitFileMagic {
+		return nil, errors.New("not a file encrypted with transit: bad magic")
+	}
+
+	h := &transitFileHeader{}
+	rest := fixed[len(transitFileMagic):]
+	h.ChunkSize = binary.BigEndian.Uint32(rest)
+	h.NoncePrefix = rest[4 : 4+transitFileNoncePrefixSize]
+	keyLen := binary.BigEndian.Uint16(rest[4+transitFileNoncePrefixSize:])
+	if h.ChunkSize == 0 || h.ChunkSize > 16*1024*1024 {
+		return nil, fmt.Errorf("invalid chunk size %d", h.ChunkSize)
+	}
+
+	wrappedKey := make([]byte, keyLen)
+	if _, err := io.ReadFull(r, wrappedKey); err != nil {
+		return nil, errors.New("not a file encrypted with transit: header too short")
+	}
+	h.WrappedKey = string(wrappedKey)
+	h.raw = append(fixed, wrappedKey...)
+	return h, nil
+}
+
+// nonce returns the nonce of the chunk with the index.
+func (h *transitFileHeader) nonce(index uint64, last bool) ([]byte, error) {
+	if index > math.MaxUint32 {
+		return nil, errors.New("too many chunks")
+	}
+	nonce := make([]byte, 12)
+	copy(nonce, h.NoncePrefix)
+	binary.BigEndian.PutUint32(nonce[transitFileNoncePrefixSize:], uint32(index))
+	if last {
+		nonce[11] = 1
+	}
+	return nonce, nil
+}
+
+// plaintextSize returns the size of the decrypted contents of an encrypted file
+// of the given size, which has the header and an authentication tag per chunk
+// on top of the contents.
+func (h *transitFileHeader) plaintextSize(fileSize int64) int64 {
+	size := fileSize - int64(len(h.raw))
+	sealedChunkSize := int64(h.ChunkSize) + transitFileTagSize
+	chunks := (size + sealedChunkSize - 1) / sealedChunkSize
+	return size - chunks*transitFileTagSize
+}
+
+func transitFileAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptTransitFile writes the header and the contents of src encrypted with
+// key to dst. progress is called with the number of bytes read from src.
+func encryptTransitFile(dst io.Writer, src io.Reader, h *transitFileHeader, key []byte, progress func(int64)) error {
+	aead, err := transitFileAEAD(key)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(h.raw); err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, int(h.ChunkSize))
+	buf := make([]byte, h.ChunkSize)
+	out := make([]byte, 0, int(h.ChunkSize)+aead.Overhead())
+	var total int64
+	for index := uint64(0); ; index++ {
+		n, err := io.ReadFull(r, buf)
+		last := false
+		switch err {
+		case nil:
+			if _, err := r.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		case io.EOF, io.ErrUnexpectedEOF:
+			last = true
+		default:
+			return err
+		}
+
+		nonce, err := h.nonce(index, last)
+		if err != nil {
+			return err
+		}
+		if _, err := dst.Write(aead.Seal(out[:0], nonce, buf[:n], h.raw)); err != nil {
+			return err
+		}
+
+		total += int64(n)
+		progress(total)
+		if last {
+			return nil
+		}
+	}
+}
+
+// decryptTransitFile writes the contents of src, read past its header,
+// decrypted with key to dst. progress is called with the number of bytes
+// written to dst.
+func decryptTransitFile(dst io.Writer, src io.Reader, h *transitFileHeader, key []byte, progress func(int64)) error {
+	aead, err := transitFileAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := int(h.ChunkSize) + aead.Overhead()
+	r := bufio.NewReaderSize(src, chunkSize)
+	buf := make([]byte, chunkSize)
+	out := make([]byte, 0, h.ChunkSize)
+	var total int64
+	for index := uint64(0); ; index++ {
+		n, err := io.ReadFull(r, buf)
+		last := false
+		switch err {
+		case nil:
+			if _, err := r.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		case io.ErrUnexpectedEOF:
+			last = true
+		case io.EOF:
+			return errors.New("file is truncated")
+		default:
+			return err
+		}
+
+		nonce, err := h.nonce(index, last)
+		if err != nil {
+			return err
+		}
+		plaintext, err := aead.Open(out[:0], nonce, buf[:n], h.raw)
+		if err != nil {
+			return errors.New("file is corrupted or truncated: message authentication failed")
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+
+		total += int64(len(plaintext))
+		progress(total)
+		if last {
+			return nil
+		}
+	}
+}
+
+// transitFileDataKey returns the plaintext data key in the response of the
+// datakey or decrypt endpoints of the transit secrets engine.
+func transitFileDataKey(secret *api.Secret) ([]byte, error) {
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("no data key returned")
+	}
+	plaintext, _ := secret.Data["plaintext"].(string)
+	key, err := base64.StdEncoding.DecodeString(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding data key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid data key of %d bytes", len(key))
+	}
+	return key, nil
+}
+
+// transitFileProgress returns a progress callback reporting how many of the
+// total bytes have been processed, at most once per interval and when done.
+func transitFileProgress(report func(string), verb string, total int64) func(int64) {
+	var last time.Time
+	return func(done int64) {
+		if now := time.Now(); done == total || now.Sub(last) >= transitFileProgressInterval {
+			last = now
+			if total > 0 {
+				report(fmt.Sprintf("%s %s of %s (%d%%)", verb, humanize.IBytes(uint64(done)), humanize.IBytes(uint64(total)), done*100/total))
+			} else {
+				report(fmt.Sprintf("%s %s", verb, humanize.IBytes(uint64(done))))
+			}
+		}
+	}
+}
+
+// hashTransitFile streams the file at path through the hash algorithm, named
+// as by the sign and verify endpoints of the transit secrets engine.
+func hashTransitFile(path, algorithm string) ([]byte, error) {
+	hashType, ok := keysutil.HashTypeMap[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash algorithm %q", algorithm)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := keysutil.HashFuncMap[hashType]()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// zeroBytes overwrites b with zeros.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
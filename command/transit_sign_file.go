@@ -0,0 +1,224 @@
+package command
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransitSignFileCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransitSignFileCommand)(nil)
+)
+
+type TransitSignFileCommand struct {
+	*BaseCommand
+
+	flagMount               string
+	flagOut                 string
+	flagHashAlgorithm       string
+	flagSignatureAlgorithm  string
+	flagMarshalingAlgorithm string
+	flagKeyVersion          int
+	flagContext             string
+}
+
+func (c *TransitSignFileCommand) Synopsis() string {
+	return "Sign a file with a transit key"
+}
+
+func (c *TransitSignFileCommand) Help() string {
+	helpText := `
+Usage: vault transit sign-file [options] KEY FILE
+
+  Signs a file of any size with the given key of the transit secrets engine,
+  and writes the detached signature to a file. The file is hashed locally,
+  and only its digest is sent to Vault to be signed, so that the file never
+  leaves the machine.
+
+  The signature is written to FILE.sig by default, and can be verified with
+  "vault transit verify-file" using the same hash algorithm. Ed25519 keys
+  don't support prehashed input, so their signature is of the digest of the
+  file rather than of the file itself.
+
+  Sign a release artifact with the "releases" key:
+
+      $ vault transit sign-file releases app.tar.gz
+
+  Sign an artifact with SHA2-512 and RSA-PSS:
+
+      $ vault transit sign-file -hash-algorithm=sha2-512 \
+          -signature-algorithm=pss releases app.tar.gz
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitSignFileCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "transit",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the transit secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "out",
+		Target:     &c.flagOut,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path of the signature file. Defaults to the path of the file " +
+			"with the \".sig\" extension added.",
+	})
+
+	transitSignatureFlags(f, &c.flagHashAlgorithm, &c.flagSignatureAlgorithm, &c.flagMarshalingAlgorithm, &c.flagContext)
+
+	f.IntVar(&IntVar{
+		Name:       "key-version",
+		Target:     &c.flagKeyVersion,
+		Default:    0,
+		Completion: complete.PredictAnything,
+		Usage:      "Version of the key to sign with. Defaults to the latest version.",
+	})
+
+	return set
+}
+
+// transitSignatureFlags adds the flags shared by the sign-file and verify-file
+// commands.
+func transitSignatureFlags(f *FlagSet, hashAlgorithm, signatureAlgorithm, marshalingAlgorithm, context *string) {
+	f.StringVar(&StringVar{
+		Name:    "hash-algorithm",
+		Target:  hashAlgorithm,
+		Default: "sha2-256",
+		Completion: complete.PredictSet("sha1", "sha2-224", "sha2-256", "sha2-384",
+			"sha2-512", "sha3-224", "sha3-256", "sha3-384", "sha3-512"),
+		Usage: "Hash algorithm the file is hashed with.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "signature-algorithm",
+		Target:     signatureAlgorithm,
+		Default:    "",
+		Completion: complete.PredictSet("pss", "pkcs1v15"),
+		Usage:      "Signature algorithm of RSA keys: \"pss\" or \"pkcs1v15\". Defaults to \"pss\".",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "marshaling-algorithm",
+		Target:     marshalingAlgorithm,
+		Default:    "asn1",
+		Completion: complete.PredictSet("asn1", "jws"),
+		Usage:      "Marshaling of the signatures of ECDSA keys: \"asn1\" or \"jws\".",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "context",
+		Target:     context,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage:      "Base64 encoded context for key derivation, required for derived keys.",
+	})
+}
+
+func (c *TransitSignFileCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *TransitSignFileCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransitSignFileCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	key, input := args[0], args[1]
+	output := c.flagOut
+	if output == "" {
+		output = input + ".sig"
+	}
+
+	digest, err := hashTransitFile(input, c.flagHashAlgorithm)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error hashing file: %s", err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	data := map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"prehashed":            true,
+		"hash_algorithm":       c.flagHashAlgorithm,
+		"marshaling_algorithm": c.flagMarshalingAlgorithm,
+	}
+	if c.flagSignatureAlgorithm != "" {
+		data["signature_algorithm"] = c.flagSignatureAlgorithm
+	}
+	if c.flagKeyVersion != 0 {
+		data["key_version"] = c.flagKeyVersion
+	}
+	if c.flagContext != "" {
+		data["context"] = c.flagContext
+	}
+
+	path := fmt.Sprintf("%s/sign/%s", sanitizePath(c.flagMount), sanitizePath(key))
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error signing file: %s", err), err)
+		return 2
+	}
+	var signature string
+	if secret != nil {
+		signature, _ = secret.Data["signature"].(string)
+	}
+	if signature == "" {
+		c.UI.Error(fmt.Sprintf("No signature returned by %s", path))
+		return 2
+	}
+
+	if err := writeOutputFile(output, []byte(signature+"\n"), 0o644); err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing signature: %s", err), err)
+		return 2
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		c.UI.Output(fmt.Sprintf("Success! Signed %s, signature written to: %s", input, output))
+		return 0
+	default:
+		return OutputData(c.UI, map[string]interface{}{
+			"signature":      signature,
+			"key_version":    secret.Data["key_version"],
+			"hash_algorithm": c.flagHashAlgorithm,
+			"file":           output,
+		})
+	}
+}
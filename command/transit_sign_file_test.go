@@ -0,0 +1,176 @@
+package command
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+func testTransitSignFileCommand(tb testing.TB) (*cli.MockUi, *TransitSignFileCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitSignFileCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+// testTransitSigningServer returns a Vault server with transit signing keys
+// named after their types, and the path of a file to sign.
+func testTransitSigningServer(tb testing.TB) (*api.Client, func(), string) {
+	tb.Helper()
+
+	client, closer := testVaultServer(tb)
+
+	if err := client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	}); err != nil {
+		tb.Fatal(err)
+	}
+	for _, keyType := range []string{"ecdsa-p256", "rsa-2048"} {
+		if _, err := client.Logical().Write("transit/keys/"+keyType, map[string]interface{}{
+			"type": keyType,
+		}); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(tb.TempDir(), "artifact")
+	if err := ioutil.WriteFile(path, []byte(strings.Repeat("artifact", 100000)), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return client, closer, path
+}
+
+func TestTransitSignFileCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"foo"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar", "baz"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"missing_file",
+			[]string{"foo", filepath.Join("testdata", "missing")},
+			"Error hashing file",
+			1,
+		},
+		{
+			"bad_hash_algorithm",
+			[]string{"-hash-algorithm=md5", "foo", "transit_sign_file_test.go"},
+			"unsupported hash algorithm",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testTransitSignFileCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer, path := testTransitSigningServer(t)
+		defer closer()
+
+		for _, tc := range []struct {
+			key  string
+			args []string
+		}{
+			{"ecdsa-p256", nil},
+			{"rsa-2048", []string{"-hash-algorithm=sha2-512", "-signature-algorithm=pkcs1v15"}},
+		} {
+			ui, cmd := testTransitSignFileCommand(t)
+			cmd.client = client
+
+			code := cmd.Run(append(append([]string{"-out=" + path + "." + tc.key}, tc.args...), tc.key, path))
+			if exp := 0; code != exp {
+				t.Fatalf("%s: expected %d to be %d: %s", tc.key, code, exp, ui.ErrorWriter.String())
+			}
+
+			signature, err := ioutil.ReadFile(path + "." + tc.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(signature), "vault:v1:") {
+				t.Fatalf("%s: bad signature %q", tc.key, signature)
+			}
+
+			ui, verifyCmd := testTransitVerifyFileCommand(t)
+			verifyCmd.client = client
+
+			code = verifyCmd.Run(append(append([]string{"-signature=" + path + "." + tc.key}, tc.args...), tc.key, path))
+			if exp := 0; code != exp {
+				t.Fatalf("%s: expected %d to be %d: %s", tc.key, code, exp, ui.ErrorWriter.String())
+			}
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testTransitSignFileCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-out=" + filepath.Join(t.TempDir(), "sig"), "foo", "transit_sign_file_test.go"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error signing file: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransitSignFileCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
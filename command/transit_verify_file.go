@@ -0,0 +1,159 @@
+package command
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*TransitVerifyFileCommand)(nil)
+	_ cli.CommandAutocomplete = (*TransitVerifyFileCommand)(nil)
+)
+
+type TransitVerifyFileCommand struct {
+	*BaseCommand
+
+	flagMount               string
+	flagSignature           string
+	flagHashAlgorithm       string
+	flagSignatureAlgorithm  string
+	flagMarshalingAlgorithm string
+	flagContext             string
+}
+
+func (c *TransitVerifyFileCommand) Synopsis() string {
+	return "Verify the signature of a file with a transit key"
+}
+
+func (c *TransitVerifyFileCommand) Help() string {
+	helpText := `
+Usage: vault transit verify-file [options] KEY FILE
+
+  Verifies the detached signature of a file, written by "vault transit
+  sign-file", with the given key of the transit secrets engine. The file is
+  hashed locally, and only its digest is sent to Vault with the signature.
+
+  The command exits with 0 when the signature is valid, and 1 when it is not.
+
+  Verify a release artifact signed with the "releases" key, with the
+  signature read from app.tar.gz.sig:
+
+      $ vault transit verify-file releases app.tar.gz
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TransitVerifyFileCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "mount",
+		Target:     &c.flagMount,
+		Default:    "transit",
+		Completion: complete.PredictAnything,
+		Usage:      "Path at which the transit secrets engine is mounted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "signature",
+		Target:     &c.flagSignature,
+		Default:    "",
+		Completion: complete.PredictFiles("*.sig"),
+		Usage: "Path of the signature file. Defaults to the path of the file " +
+			"with the \".sig\" extension added.",
+	})
+
+	transitSignatureFlags(f, &c.flagHashAlgorithm, &c.flagSignatureAlgorithm, &c.flagMarshalingAlgorithm, &c.flagContext)
+
+	return set
+}
+
+func (c *TransitVerifyFileCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *TransitVerifyFileCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *TransitVerifyFileCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	key, input := args[0], args[1]
+	signaturePath := c.flagSignature
+	if signaturePath == "" {
+		signaturePath = input + ".sig"
+	}
+
+	signature, err := ioutil.ReadFile(signaturePath)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading signature: %s", err), err)
+		return 1
+	}
+	digest, err := hashTransitFile(input, c.flagHashAlgorithm)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error hashing file: %s", err), err)
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	data := map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"signature":            strings.TrimSpace(string(signature)),
+		"prehashed":            true,
+		"hash_algorithm":       c.flagHashAlgorithm,
+		"marshaling_algorithm": c.flagMarshalingAlgorithm,
+	}
+	if c.flagSignatureAlgorithm != "" {
+		data["signature_algorithm"] = c.flagSignatureAlgorithm
+	}
+	if c.flagContext != "" {
+		data["context"] = c.flagContext
+	}
+
+	path := fmt.Sprintf("%s/verify/%s", sanitizePath(c.flagMount), sanitizePath(key))
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error verifying signature: %s", err), err)
+		return 2
+	}
+	var valid bool
+	if secret != nil {
+		valid, _ = secret.Data["valid"].(bool)
+	}
+
+	if !valid {
+		c.UI.Error(fmt.Sprintf("The signature of %s is not valid", input))
+		return 1
+	}
+	c.UI.Output(fmt.Sprintf("Success! The signature of %s is valid", input))
+	return 0
+}
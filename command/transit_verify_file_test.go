@@ -0,0 +1,145 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func testTransitVerifyFileCommand(tb testing.TB) (*cli.MockUi, *TransitVerifyFileCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &TransitVerifyFileCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestTransitVerifyFileCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{"foo"},
+			"Not enough arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar", "baz"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"missing_signature",
+			[]string{"foo", "transit_verify_file_test.go"},
+			"Error reading signature",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testTransitVerifyFileCommand(t)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer, path := testTransitSigningServer(t)
+		defer closer()
+
+		_, signCmd := testTransitSignFileCommand(t)
+		signCmd.client = client
+		if code := signCmd.Run([]string{"ecdsa-p256", path}); code != 0 {
+			t.Fatalf("expected %d to be 0", code)
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteString("tampered"); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		ui, cmd := testTransitVerifyFileCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"ecdsa-p256", path})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "is not valid"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		signature := filepath.Join(t.TempDir(), "sig")
+		if err := ioutil.WriteFile(signature, []byte("vault:v1:foo"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testTransitVerifyFileCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-signature=" + signature, "foo", "transit_verify_file_test.go"})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error verifying signature: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testTransitVerifyFileCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
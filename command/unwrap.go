@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
@@ -17,6 +18,10 @@ var (
 // unwrapping cubbyhole-wrapped secrets
 type UnwrapCommand struct {
 	*BaseCommand
+
+	flagVerify       bool
+	flagExpectedPath string
+	flagMaxAge       time.Duration
 }
 
 func (c *UnwrapCommand) Synopsis() string {
@@ -40,6 +45,12 @@ Usage: vault unwrap [options] [TOKEN]
       $ vault login 848f9ccf-7176-098c-5e2b-75a0689d41cd
       $ vault unwrap # unwraps 848f9ccf...
 
+  Check that a token was created for an AppRole secret ID less than a minute
+  ago, and that nobody unwrapped it yet, before unwrapping it:
+
+      $ vault unwrap -verify -expected-path=auth/approle/role/my-role/secret-id \
+          -max-age=1m 3de9ece1-b347-e143-29b0-dc2dc31caafd
+
   For a full list of examples and paths, please see the online documentation.
 
 ` + c.Flags().Help()
@@ -48,7 +59,39 @@ Usage: vault unwrap [options] [TOKEN]
 }
 
 func (c *UnwrapCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputField | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "verify",
+		Target:  &c.flagVerify,
+		Default: false,
+		Usage: "Look up the wrapping token before unwrapping it, and fail " +
+			"without unwrapping if it does not exist, for instance because it " +
+			"was already unwrapped by someone else, or if it does not match " +
+			"-expected-path or -max-age.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "expected-path",
+		Target:     &c.flagExpectedPath,
+		Default:    "",
+		Completion: c.PredictVaultFiles(),
+		Usage: "When used with -verify, the path the wrapping token must " +
+			"have been created for.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "max-age",
+		Target:     &c.flagMaxAge,
+		Default:    0,
+		Completion: complete.PredictAnything,
+		Usage: "When used with -verify, the longest time since the wrapping " +
+			"token was created.",
+	})
+
+	return set
 }
 
 func (c *UnwrapCommand) AutocompleteArgs() complete.Predictor {
@@ -79,15 +122,30 @@ func (c *UnwrapCommand) Run(args []string) int {
 		return 1
 	}
 
+	if !c.flagVerify && (c.flagExpectedPath != "" || c.flagMaxAge != 0) {
+		c.UI.Error("-expected-path and -max-age require -verify")
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
+	if c.flagVerify {
+		if err := verifyWrappingToken(client, token, c.flagExpectedPath, c.flagMaxAge); err != nil {
+			outputError(c.UI, fmt.Sprintf("Error verifying wrapping token: %s", err), err)
+			c.UI.Error(wrapAtLength("\nThe token was not unwrapped. A wrapping " +
+				"token which was already unwrapped, or which does not match the " +
+				"expected path or age, may have been intercepted or tampered with."))
+			return 2
+		}
+	}
+
 	secret, err := client.Logical().Unwrap(token)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error unwrapping: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error unwrapping: %s", err), err)
 		return 2
 	}
 	if secret == nil {
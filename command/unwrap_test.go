@@ -65,6 +65,30 @@ func TestUnwrapCommand_Run(t *testing.T) {
 			"not present in secret",
 			1,
 		},
+		{
+			"verify",
+			[]string{"-verify", "-expected-path", "sys/wrapping/wrap", "-max-age", "1m"},
+			"bar",
+			0,
+		},
+		{
+			"verify_path_mismatch",
+			[]string{"-verify", "-expected-path", "auth/approle/login"},
+			"does not match expected path",
+			2,
+		},
+		{
+			"verify_max_age",
+			[]string{"-verify", "-max-age", "1ns"},
+			"more than the maximum age",
+			2,
+		},
+		{
+			"expected_path_without_verify",
+			[]string{"-expected-path", "sys/wrapping/wrap"},
+			"require -verify",
+			1,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -100,6 +124,34 @@ func TestUnwrapCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("verify_used_token", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		wrappedToken := testUnwrapWrappedToken(t, client, map[string]interface{}{
+			"foo": "bar",
+		})
+		if _, err := client.Logical().Unwrap(wrappedToken); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testUnwrapCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-verify", wrappedToken})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error verifying wrapping token"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
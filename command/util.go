@@ -1,9 +1,12 @@
 package command
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -102,6 +105,14 @@ func PrintRawField(ui cli.Ui, data interface{}, field string) int {
 		return 1
 	}
 
+	if vui, ok := ui.(*VaultUI); ok && vui.outputFile != "" {
+		if err := writeRawField(vui.outputFile, val, vui.outputDecodeBase64); err != nil {
+			ui.Error(fmt.Sprintf("Error writing field %q to file: %s", field, err))
+			return 1
+		}
+		return 0
+	}
+
 	format := Format(ui)
 	if format == "" || format == "table" {
 		return PrintRaw(ui, fmt.Sprintf("%v", val))
@@ -114,6 +125,11 @@ func PrintRawField(ui cli.Ui, data interface{}, field string) int {
 		return 1
 	}
 
+	if t, ok := formatter.(TemplateFormatter); ok && t.Template == "" {
+		t.Template = FormatTemplate(ui)
+		formatter = t
+	}
+
 	b, err := formatter.Format(val)
 	if err != nil {
 		ui.Error(fmt.Sprintf("Error formatting output: %s", err))
@@ -123,6 +139,34 @@ func PrintRawField(ui cli.Ui, data interface{}, field string) int {
 	return PrintRaw(ui, string(b))
 }
 
+// writeRawField writes the given field value to the file at path, replacing it
+// with a file only the current user can access. Non-string
+// values are written as JSON. If decodeBase64 is set, the value is
+// base64-decoded first.
+func writeRawField(path string, val interface{}, decodeBase64 bool) error {
+	var contents []byte
+	switch val := val.(type) {
+	case string:
+		contents = []byte(val)
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		contents = b
+	}
+
+	if decodeBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(contents)))
+		if err != nil {
+			return fmt.Errorf("failed to base64-decode value: %w", err)
+		}
+		contents = decoded
+	}
+
+	return writeOutputFile(expandPath(path), contents, 0o600)
+}
+
 // PrintRaw prints a raw value to the terminal. If the process is being "piped"
 // to something else, the "raw" value is printed without a newline character.
 // Otherwise the value is printed as normal.
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteRawField(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "vault-raw-field")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		name     string
+		val      interface{}
+		decode   bool
+		expected string
+		err      bool
+	}{
+		{"string", "foo", false, "foo", false},
+		{"non_string", []interface{}{"a", "b"}, false, `["a","b"]`, false},
+		{"base64", "AP9iYXI=\n", true, "\x00\xffbar", false},
+		{"base64_invalid", "not base64!", true, "", true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(dir, tc.name)
+			err := writeRawField(path, tc.val, tc.decode)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %t, got %v", tc.err, err)
+			}
+			if tc.err {
+				return
+			}
+
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(contents) != tc.expected {
+				t.Errorf("expected %q to be %q", contents, tc.expected)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm&0o077 != 0 {
+				t.Errorf("expected file to be private, got %o", perm)
+			}
+		})
+	}
+}
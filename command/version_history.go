@@ -69,7 +69,7 @@ func (c *VersionHistoryCommand) Run(args []string) int {
 
 	resp, err := client.Logical().List("sys/version-history")
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading version history: %s", err))
+		outputError(c.UI, fmt.Sprintf("Error reading version history: %s", err), err)
 		return 2
 	}
 
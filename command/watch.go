@@ -0,0 +1,200 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/cli"
+)
+
+// defaultWatchInterval is how often a watched secret is re-fetched if no
+// interval is given.
+const defaultWatchInterval = 10 * time.Second
+
+// secretWatcher repeatedly fetches a secret and outputs it whenever its data
+// changes.
+type secretWatcher struct {
+	UI       cli.Ui
+	Interval time.Duration
+
+	// Diff outputs the changes to the data after the first fetch instead of
+	// the whole secret.
+	Diff bool
+
+	// Fetch returns the current version of the secret.
+	Fetch func() (*api.Secret, error)
+
+	// Data returns the part of the secret which is compared between fetches.
+	// If nil, the secret's Data is used.
+	Data func(*api.Secret) map[string]interface{}
+
+	// Output prints the secret.
+	Output func(*api.Secret) int
+
+	// StopCh stops watching when closed.
+	StopCh <-chan struct{}
+}
+
+// Run watches the secret until StopCh is closed. Errors fetching the secret
+// are reported but do not stop the watch, so that a temporary outage does not
+// require restarting the command.
+func (w *secretWatcher) Run() int {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last map[string]interface{}
+	first := true
+	for {
+		secret, err := w.Fetch()
+		switch {
+		case err != nil:
+			w.UI.Error(err.Error())
+		case secret == nil:
+			if !first && last != nil {
+				w.UI.Warn(fmt.Sprintf("%s: secret no longer exists", time.Now().Format(time.RFC3339)))
+			}
+			last = nil
+		default:
+			data := secret.Data
+			if w.Data != nil {
+				data = w.Data(secret)
+			}
+
+			switch {
+			case first:
+				w.Output(secret)
+			case !reflect.DeepEqual(last, data):
+				w.UI.Info(fmt.Sprintf("\n%s: secret changed", time.Now().Format(time.RFC3339)))
+				if w.Diff {
+					for _, line := range secretDataDiff(last, data, false) {
+						w.UI.Output(line)
+					}
+				} else {
+					w.Output(secret)
+				}
+			}
+			last = data
+			first = false
+		}
+
+		select {
+		case <-w.StopCh:
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// dataChange describes a key which differs between two versions of a
+// secret's data.
+type dataChange struct {
+	Key    string      `json:"key"`
+	Change string      `json:"change"`
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
+
+const (
+	dataChangeAdded   = "added"
+	dataChangeRemoved = "removed"
+	dataChangeChanged = "changed"
+)
+
+// diffData returns the keys which were added, removed, or changed from old to
+// new, sorted by key.
+func diffData(old, new map[string]interface{}) []dataChange {
+	keys := make(map[string]struct{}, len(old)+len(new))
+	for k := range old {
+		keys[k] = struct{}{}
+	}
+	for k := range new {
+		keys[k] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []dataChange
+	for _, k := range sorted {
+		oldVal, inOld := old[k]
+		newVal, inNew := new[k]
+		switch {
+		case !inOld:
+			changes = append(changes, dataChange{Key: k, Change: dataChangeAdded, To: newVal})
+		case !inNew:
+			changes = append(changes, dataChange{Key: k, Change: dataChangeRemoved, From: oldVal})
+		case !reflect.DeepEqual(oldVal, newVal):
+			changes = append(changes, dataChange{Key: k, Change: dataChangeChanged, From: oldVal, To: newVal})
+		}
+	}
+	return changes
+}
+
+// secretDataDiff returns lines describing how the data changed from old to
+// new, sorted by key. Added keys are prefixed with "+", removed keys with "-"
+// and changed keys with "~". If mask is true, values are not included.
+func secretDataDiff(old, new map[string]interface{}, mask bool) []string {
+	value := func(v interface{}) string {
+		if mask {
+			return "(hidden)"
+		}
+		return diffValue(v)
+	}
+
+	var lines []string
+	for _, c := range diffData(old, new) {
+		switch c.Change {
+		case dataChangeAdded:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", c.Key, value(c.To)))
+		case dataChangeRemoved:
+			lines = append(lines, fmt.Sprintf("- %s: %s", c.Key, value(c.From)))
+		case dataChangeChanged:
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", c.Key, value(c.From), value(c.To)))
+		}
+	}
+	return lines
+}
+
+// outputDataDiff prints the lines of secretDataDiff, colored by the kind of
+// change unless color output is disabled.
+func outputDataDiff(ui cli.Ui, old, new map[string]interface{}, mask bool) {
+	useColor := !color.NoColor && os.Getenv(EnvVaultCLINoColor) == ""
+	colors := map[byte]*color.Color{
+		'+': color.New(color.FgGreen),
+		'-': color.New(color.FgRed),
+		'~': color.New(color.FgYellow),
+	}
+	for _, line := range secretDataDiff(old, new, mask) {
+		if c, ok := colors[line[0]]; ok && useColor {
+			line = c.Sprint(line)
+		}
+		ui.Output(line)
+	}
+}
+
+// diffValue formats a value for a diff line. Strings are printed as-is and
+// other values as JSON.
+func diffValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
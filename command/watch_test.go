@@ -0,0 +1,59 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecretDataDiff(t *testing.T) {
+	t.Parallel()
+
+	old := map[string]interface{}{
+		"same":    "value",
+		"changed": "old",
+		"removed": "gone",
+		"nested":  map[string]interface{}{"a": 1},
+	}
+	new := map[string]interface{}{
+		"same":    "value",
+		"changed": "new",
+		"added":   true,
+		"nested":  map[string]interface{}{"a": 2},
+	}
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+
+		exp := []string{
+			"+ added: true",
+			"~ changed: old -> new",
+			`~ nested: {"a":1} -> {"a":2}`,
+			"- removed: gone",
+		}
+		if act := secretDataDiff(old, new, false); !reflect.DeepEqual(act, exp) {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+	})
+
+	t.Run("masked", func(t *testing.T) {
+		t.Parallel()
+
+		exp := []string{
+			"+ added: (hidden)",
+			"~ changed: (hidden) -> (hidden)",
+			"~ nested: (hidden) -> (hidden)",
+			"- removed: (hidden)",
+		}
+		if act := secretDataDiff(old, new, true); !reflect.DeepEqual(act, exp) {
+			t.Errorf("expected %q to be %q", act, exp)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		if act := secretDataDiff(old, old, false); len(act) != 0 {
+			t.Errorf("expected no changes, got %q", act)
+		}
+	})
+}
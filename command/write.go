@@ -1,11 +1,20 @@
 package command
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/config"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mattn/go-isatty"
 	"github.com/mitchellh/cli"
@@ -28,9 +37,24 @@ type MFAMethodInfo struct {
 type WriteCommand struct {
 	*BaseCommand
 
-	flagForce bool
+	flagForce       bool
+	flagBatch       bool
+	flagConcurrency int
+	flagDryRun      bool
+	flagExpandKeys  bool
 
-	testStdin io.Reader // for tests
+	flagIdempotencyKey string
+
+	flagUnwrap           bool
+	flagUnwrapVerifyPath bool
+	flagQRTerminal       bool
+
+	flagEdit       bool
+	flagEditFormat string
+
+	testMFATOTP []*config.MFATOTP // for tests
+	testStdin   io.Reader         // for tests
+	testEditor  editorFunc        // for tests
 }
 
 func (c *WriteCommand) Synopsis() string {
@@ -47,7 +71,10 @@ Usage: vault write [options] PATH [DATA K=V...]
 
   Data is specified as "key=value" pairs. If the value begins with an "@", then
   it is loaded from a file. If the value is "-", Vault will read the value from
-  stdin.
+  stdin. If the value begins with "@b64:", the file is read and its contents
+  are base64-encoded before being sent. A value can be given an explicit type
+  by suffixing the key with one of ":int", ":float", ":bool", ":json", or
+  ":string".
 
   Persist data in the generic secrets engine:
 
@@ -61,10 +88,42 @@ Usage: vault write [options] PATH [DATA K=V...]
 
       $ vault write aws/roles/ops policy=@policy.json
 
+  Send typed values to an API that validates numbers and booleans:
+
+      $ vault write auth/approle/role/my-role \
+          token_ttl:int=300 bind_secret_id:bool=true
+
+  Encrypt a binary file with the transit secrets engine:
+
+      $ vault write transit/encrypt/my-key plaintext=@b64:file.bin
+
+  Build a nested request body from dotted keys:
+
+      $ vault write -expand-keys my-mount/config \
+          config.connection.url=postgres://db:5432 config.connection.user=vault
+
   Configure access to Consul by providing an access token:
 
       $ echo $MY_TOKEN | vault write consul/config/access token=-
 
+  Enable a secrets engine from a pipeline step which may be retried, without
+  failing if the step runs again:
+
+      $ vault write -idempotency-key="$BUILD_ID" sys/mounts/ci type=kv
+
+  Create a TOTP key, and scan its QR code from the terminal:
+
+      $ vault write -qr-terminal totp/keys/my-key generate=true \
+          issuer=Vault account_name=alice@example.com
+
+  Edit the current configuration of a role in an editor, and write it back:
+
+      $ vault write -edit auth/approle/role/my-role
+
+  Write many secrets at once from newline-delimited JSON records on stdin:
+
+      $ cat secrets.ndjson | vault write -batch
+
   For a full list of examples and paths, please see the documentation that
   corresponds to the secret engines in use.
 
@@ -88,13 +147,112 @@ func (c *WriteCommand) Flags() *FlagSets {
 			"allows writing to keys that do not need or expect data.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:       "batch",
+		Target:     &c.flagBatch,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Read newline-delimited JSON records from stdin and write each " +
+			"one in turn. Each record must be an object of the form " +
+			"{\"path\": \"...\", \"data\": {...}}. A status is reported for " +
+			"every record.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:       "concurrency",
+		Target:     &c.flagConcurrency,
+		Default:    1,
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage: "Number of records to write at once when -batch is set. By " +
+			"default, records are written one at a time, in order.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "expand-keys",
+		Target:     &c.flagExpandKeys,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Expand dotted or bracketed keys, such as " +
+			"\"config.connection.url=...\" or \"config[connection][url]=...\", " +
+			"into nested objects before sending the request.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "unwrap",
+		Target:     &c.flagUnwrap,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "If the response is wrapped, immediately unwrap it and output " +
+			"the inner secret instead of the wrapping token.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "unwrap-verify-path",
+		Target:     &c.flagUnwrapVerifyPath,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "When used with -unwrap, verify that the wrapping token was " +
+			"created for the requested path before unwrapping it.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "idempotency-key",
+		Target:     &c.flagIdempotencyKey,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage: "Client-generated key sent with the write. If a write with the " +
+			"same key, path and data was already made with the same token in " +
+			"the last 10 minutes, Vault returns its response instead of " +
+			"handling the write again. Writes returning a secret or a token " +
+			"are not replayed: repeating them fails.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "qr-terminal",
+		Target:     &c.flagQRTerminal,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Render the otpauth URL of the response, returned when creating " +
+			"a TOTP key or generating an MFA method secret, as a QR code in the " +
+			"terminal.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "edit",
+		Target:     &c.flagEdit,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Read the path, open the data in the editor set with VISUAL or " +
+			"EDITOR, and write the edited data back once the editor is closed. " +
+			"The write is cancelled if the data at the path changed in the " +
+			"meantime.",
+	})
+
+	addEditFormatFlag(f, &c.flagEditFormat)
+
+	f.BoolVar(&BoolVar{
+		Name:       "dry-run",
+		Target:     &c.flagDryRun,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Print the resolved path, parsed payload, and request headers " +
+			"without sending the request to Vault.",
+	})
+
 	return set
 }
 
 func (c *WriteCommand) AutocompleteArgs() complete.Predictor {
-	// Return an anything predictor here. Without a way to access help
-	// information, we don't know what paths we could write to.
-	return complete.PredictAnything
+	return c.PredictVaultFiles()
 }
 
 func (c *WriteCommand) AutocompleteFlags() complete.Flags {
@@ -109,39 +267,95 @@ func (c *WriteCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Pull our fake stdin if needed
+	stdin := (io.Reader)(os.Stdin)
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+
+	if c.flagQRTerminal && (c.flagField != "" || Format(c.UI) != "table") {
+		c.UI.Error("-qr-terminal can only be used with the table output format")
+		return 1
+	}
+
 	args = f.Args()
+	if c.flagBatch {
+		if c.flagQRTerminal {
+			c.UI.Error("-qr-terminal cannot be used with -batch")
+			return 1
+		}
+		if c.flagIdempotencyKey != "" {
+			c.UI.Error("-idempotency-key cannot be used with -batch")
+			return 1
+		}
+		if c.flagEdit {
+			c.UI.Error("-edit cannot be used with -batch")
+			return 1
+		}
+		if len(args) > 0 {
+			c.UI.Error(fmt.Sprintf("Too many arguments (expected 0 with -batch, got %d)", len(args)))
+			return 1
+		}
+		return c.runBatch(stdin)
+	}
+
 	switch {
 	case len(args) < 1:
 		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 1, got %d)", len(args)))
 		return 1
-	case len(args) == 1 && !c.flagForce:
+	case c.flagEdit && len(args) > 1:
+		c.UI.Error("-edit cannot be used with K=V data")
+		return 1
+	case c.flagEdit && c.flagDryRun:
+		c.UI.Error("-edit cannot be used with -dry-run")
+		return 1
+	case len(args) == 1 && !c.flagForce && !c.flagEdit:
 		c.UI.Error("Must supply data or use -force")
 		return 1
 	}
 
-	// Pull our fake stdin if needed
-	stdin := (io.Reader)(os.Stdin)
-	if c.testStdin != nil {
-		stdin = c.testStdin
-	}
-
 	path := sanitizePath(args[0])
 
+	if c.flagEdit {
+		return c.runEdit(path)
+	}
+
 	data, err := parseArgsData(stdin, args[1:])
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Failed to parse K=V data: %s", err))
+		outputError(c.UI, fmt.Sprintf("Failed to parse K=V data: %s", err), err)
 		return 1
 	}
 
+	if c.flagExpandKeys {
+		data, err = expandNestedKeys(data)
+		if err != nil {
+			outputError(c.UI, fmt.Sprintf("Failed to expand nested keys: %s", err), err)
+			return 1
+		}
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
-	secret, err := client.Logical().Write(path, data)
+	if c.flagDryRun {
+		return outputDryRun(c.UI, client, "PUT", path, data)
+	}
+
+	// The key is only sent with the write itself, not with any follow-up
+	// requests such as MFA validation
+	writeClient := client
+	if c.flagIdempotencyKey != "" {
+		writeClient = client.WithRequestCallbacks(func(r *api.Request) {
+			r.Headers.Set(api.HeaderIdempotencyKey, c.flagIdempotencyKey)
+		})
+	}
+
+	secret, err := writeClient.Logical().Write(path, data)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error writing data to %s: %s", path, err))
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
 		if secret != nil {
 			OutputSecret(c.UI, secret)
 		}
@@ -155,13 +369,44 @@ func (c *WriteCommand) Run(args []string) int {
 		return 0
 	}
 
+	if c.flagUnwrap {
+		verifyPath := ""
+		if c.flagUnwrapVerifyPath {
+			verifyPath = path
+		}
+		secret, err = unwrapResponse(client, secret, verifyPath)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+		if secret == nil {
+			if Format(c.UI) == "table" {
+				c.UI.Info("Successfully unwrapped. There was no data in the wrapped secret.")
+			}
+			return 0
+		}
+	}
+
 	if secret != nil && secret.Auth != nil && secret.Auth.MFARequirement != nil {
+		seeds := c.mfaTOTPSeeds()
+
+		// A login which can be validated entirely with TOTP methods whose
+		// seeds are configured locally needs no user interaction
+		if methods := selectLocalTOTPMethods(secret.Auth.MFARequirement.MFAConstraints, seeds); len(methods) > 0 {
+			return c.validateMFA(secret.Auth.MFARequirement.MFARequestID, methods, seeds)
+		}
+
 		if c.isInteractiveEnabled(len(secret.Auth.MFARequirement.MFAConstraints)) {
-			// Currently, if there is only one MFA method configured, the login
-			// request is validated interactively
-			methodInfo := c.getMFAMethodInfo(secret.Auth.MFARequirement.MFAConstraints)
-			if methodInfo.methodID != "" {
-				return c.validateMFA(secret.Auth.MFARequirement.MFARequestID, methodInfo)
+			// The user picks a method for each constraint which can be
+			// satisfied by more than one, and the login request is then
+			// validated interactively
+			methods, err := c.selectMFAMethods(secret.Auth.MFARequirement.MFAConstraints)
+			if err != nil {
+				outputError(c.UI, fmt.Sprintf("Error selecting MFA method: %s. Please validate the login by sending a request to sys/mfa/validate", err), err)
+				return 2
+			}
+			if len(methods) > 0 {
+				return c.validateMFA(secret.Auth.MFARequirement.MFARequestID, methods, seeds)
 			}
 		}
 		c.UI.Warn(wrapAtLength("A login request was issued that is subject to "+
@@ -174,11 +419,208 @@ func (c *WriteCommand) Run(args []string) int {
 		return PrintRawField(c.UI, secret, c.flagField)
 	}
 
+	code := OutputSecret(c.UI, secret)
+	if code == 0 && c.flagQRTerminal {
+		if err := outputQRTerminal(c.UI, secret); err != nil {
+			c.UI.Warn(fmt.Sprintf("Cannot render QR code: %s", err))
+		}
+	}
+	return code
+}
+
+// runEdit reads the data at path, opens it in an editor, and writes the edited
+// data back, unless the data at path changed in the meantime.
+func (c *WriteCommand) runEdit(path string) int {
+	editor := c.testEditor
+	if editor == nil {
+		editor = runEditor
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	read := func() (map[string]interface{}, error) {
+		secret, err := client.Logical().Read(path)
+		if err != nil || secret == nil {
+			return nil, err
+		}
+		return secret.Data, nil
+	}
+
+	current, err := read()
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading %s: %s", path, err), err)
+		return 2
+	}
+
+	data, err := editData(current, c.flagEditFormat, editor)
+	switch {
+	case err == errEditUnchanged:
+		c.UI.Info(fmt.Sprintf("No changes made to %s", path))
+		return 0
+	case err != nil:
+		outputError(c.UI, fmt.Sprintf("Error editing %s: %s", path, err), err)
+		return 1
+	}
+
+	// There is no generic check-and-set, so check that the data did not
+	// change right before writing
+	latest, err := read()
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error reading %s: %s", path, err), err)
+		return 2
+	}
+	if !reflect.DeepEqual(latest, current) {
+		c.UI.Error(fmt.Sprintf("%s was changed while it was being edited, nothing was written. Run the command again to edit the new data.", path))
+		return 2
+	}
+
+	secret, err := client.Logical().Write(path, data)
+	if err != nil {
+		outputError(c.UI, fmt.Sprintf("Error writing data to %s: %s", path, err), err)
+		if secret != nil {
+			OutputSecret(c.UI, secret)
+		}
+		return 2
+	}
+	if secret == nil {
+		// Don't output anything unless using the "table" format
+		if Format(c.UI) == "table" {
+			c.UI.Info(fmt.Sprintf("Success! Data written to: %s", path))
+		}
+		return 0
+	}
+
+	if c.flagField != "" {
+		return PrintRawField(c.UI, secret, c.flagField)
+	}
 	return OutputSecret(c.UI, secret)
 }
 
+// batchWriteRecord is a single line of input to "vault write -batch".
+type batchWriteRecord struct {
+	Path string                 `json:"path"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// batchWriteResult is the status of a single record written by
+// "vault write -batch".
+type batchWriteResult struct {
+	Line    int    `json:"line"`
+	Path    string `json:"path"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// runBatch reads newline-delimited JSON records from the given reader and
+// writes each of them, reporting the status of every record.
+func (c *WriteCommand) runBatch(stdin io.Reader) int {
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	results := []*batchWriteResult{}
+
+	// Records are written by up to -concurrency workers, and their results
+	// are reported in the order of the input
+	concurrency := c.flagConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	// Don't draw over the records while they are typed in
+	var recordsProgress *progress
+	if f, ok := stdin.(*os.File); !ok || !isatty.IsTerminal(f.Fd()) {
+		recordsProgress = newProgress(c.UI, "Writing records", 0)
+	}
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		result := &batchWriteResult{Line: line}
+		results = append(results, result)
+
+		var record batchWriteRecord
+		if err := json.Unmarshal([]byte(raw), &record); err != nil {
+			result.Error = fmt.Sprintf("failed to parse record: %s", err)
+		} else if result.Path = sanitizePath(record.Path); result.Path == "" {
+			result.Error = "record is missing a path"
+		} else if c.flagDryRun {
+			result.Success = true
+		} else {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				if _, err := client.Logical().Write(result.Path, record.Data); err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Success = true
+				}
+				recordsProgress.Add(1)
+			}()
+			continue
+		}
+		recordsProgress.Add(1)
+	}
+	wg.Wait()
+	recordsProgress.Stop()
+	if err := scanner.Err(); err != nil {
+		outputError(c.UI, fmt.Sprintf("Failed to read batch records: %s", err), err)
+		return 1
+	}
+
+	failed := false
+	for _, result := range results {
+		if !result.Success {
+			failed = true
+		}
+	}
+
+	if Format(c.UI) == "table" {
+		out := []string{"Line | Path | Status"}
+		for _, result := range results {
+			status := "success"
+			if c.flagDryRun {
+				status = "skipped (dry run)"
+			}
+			if !result.Success {
+				status = "error: " + result.Error
+			}
+			out = append(out, fmt.Sprintf("%d | %s | %s", result.Line, result.Path, status))
+		}
+		if len(results) == 0 {
+			c.UI.Warn("No records found on stdin")
+		} else {
+			c.UI.Output(tableOutput(out, nil))
+		}
+	} else if code := OutputData(c.UI, results); code != 0 {
+		return code
+	}
+
+	if failed {
+		return 2
+	}
+	return 0
+}
+
 func (c *WriteCommand) isInteractiveEnabled(mfaConstraintLen int) bool {
-	if mfaConstraintLen != 1 || !isatty.IsTerminal(os.Stdin.Fd()) {
+	if mfaConstraintLen < 1 || !isatty.IsTerminal(os.Stdin.Fd()) {
 		return false
 	}
 
@@ -189,41 +631,91 @@ func (c *WriteCommand) isInteractiveEnabled(mfaConstraintLen int) bool {
 	return false
 }
 
-// getMFAMethodInfo returns MFA method information only if one MFA method is
-// configured.
-func (c *WriteCommand) getMFAMethodInfo(mfaConstraintAny map[string]*logical.MFAConstraintAny) MFAMethodInfo {
-	for _, mfaConstraint := range mfaConstraintAny {
-		if len(mfaConstraint.Any) != 1 {
-			return MFAMethodInfo{}
+// selectMFAMethods returns the MFA method to use for each of the given
+// constraints, sorted by constraint name. If a constraint can be satisfied by
+// more than one method, the user is asked to choose one.
+func (c *WriteCommand) selectMFAMethods(mfaConstraintAny map[string]*logical.MFAConstraintAny) ([]MFAMethodInfo, error) {
+	names := make([]string, 0, len(mfaConstraintAny))
+	for name := range mfaConstraintAny {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var methods []MFAMethodInfo
+	for _, name := range names {
+		constraint := mfaConstraintAny[name]
+		if constraint == nil || len(constraint.Any) == 0 {
+			continue
 		}
 
-		return MFAMethodInfo{
-			methodType:  mfaConstraint.Any[0].Type,
-			methodID:    mfaConstraint.Any[0].ID,
-			usePasscode: mfaConstraint.Any[0].UsesPasscode,
+		selected := constraint.Any[0]
+		if len(constraint.Any) > 1 {
+			c.UI.Output(fmt.Sprintf("MFA constraint %q can be satisfied by any of the following methods:", name))
+			for i, method := range constraint.Any {
+				c.UI.Output(fmt.Sprintf("  %d. %s (method ID %q)", i+1, method.Type, method.ID))
+			}
+
+			answer, err := c.UI.Ask(fmt.Sprintf("Select a method [1-%d]:", len(constraint.Any)))
+			if err != nil {
+				return nil, err
+			}
+			i, err := strconv.Atoi(strings.TrimSpace(answer))
+			if err != nil || i < 1 || i > len(constraint.Any) {
+				return nil, fmt.Errorf("invalid selection %q", answer)
+			}
+			selected = constraint.Any[i-1]
 		}
+
+		methods = append(methods, MFAMethodInfo{
+			methodType:  selected.Type,
+			methodID:    selected.ID,
+			usePasscode: selected.UsesPasscode,
+		})
 	}
 
-	return MFAMethodInfo{}
+	return methods, nil
 }
 
-func (c *WriteCommand) validateMFA(reqID string, methodInfo MFAMethodInfo) int {
-	var passcode string
-	var err error
-	if methodInfo.usePasscode {
-		passcode, err = c.UI.AskSecret(fmt.Sprintf("Enter the passphrase for methodID %q of type %q:", methodInfo.methodID, methodInfo.methodType))
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("failed to read the passphrase with error %q. please validate the login by sending a request to sys/mfa/validate", err.Error()))
-			return 2
-		}
-	} else {
-		c.UI.Warn("Asking Vault to perform MFA validation with upstream service. " +
-			"You should receive a push notification in your authenticator app shortly")
+// mfaTOTPSeeds returns the TOTP seeds of MFA methods configured in the CLI
+// config, keyed by method ID.
+func (c *WriteCommand) mfaTOTPSeeds() map[string]*config.MFATOTP {
+	if c.testMFATOTP != nil {
+		return mfaTOTPSeeds(c.testMFATOTP)
 	}
 
-	// passcode could be an empty string
-	mfaPayload := map[string][]string{
-		methodInfo.methodID: {passcode},
+	conf, err := c.cliConfig()
+	if err != nil {
+		c.UI.Warn(fmt.Sprintf("Error loading configuration for MFA TOTP seeds: %s", err))
+		return nil
+	}
+	return mfaTOTPSeeds(conf.MFATOTP)
+}
+
+func (c *WriteCommand) validateMFA(reqID string, methods []MFAMethodInfo, seeds map[string]*config.MFATOTP) int {
+	mfaPayload := make(map[string][]string, len(methods))
+	for _, methodInfo := range methods {
+		var passcode string
+		var err error
+		if seed, ok := seeds[methodInfo.methodID]; ok && methodInfo.usePasscode {
+			passcode, err = mfaTOTPPasscode(seed, time.Now())
+			if err != nil {
+				outputError(c.UI, fmt.Sprintf("%s. please validate the login by sending a request to sys/mfa/validate", err), err)
+				return 2
+			}
+		} else if methodInfo.usePasscode {
+			passcode, err = c.UI.AskSecret(fmt.Sprintf("Enter the passphrase for methodID %q of type %q:", methodInfo.methodID, methodInfo.methodType))
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("failed to read the passphrase with error %q. please validate the login by sending a request to sys/mfa/validate", err.Error()))
+				return 2
+			}
+		} else {
+			c.UI.Warn(fmt.Sprintf("Asking Vault to perform MFA validation for methodID %q "+
+				"of type %q with upstream service. You should receive a push notification "+
+				"in your authenticator app shortly", methodInfo.methodID, methodInfo.methodType))
+		}
+
+		// passcode could be an empty string
+		mfaPayload[methodInfo.methodID] = []string{passcode}
 	}
 
 	client, err := c.Client()
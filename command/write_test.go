@@ -1,11 +1,17 @@
 package command
 
 import (
+	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/cli"
 )
 
@@ -41,6 +47,12 @@ func TestWriteCommand_Run(t *testing.T) {
 			"Must supply data or use -force",
 			1,
 		},
+		{
+			"edit_kvs",
+			[]string{"-edit", "secret/write/foo", "foo=bar"},
+			"-edit cannot be used with K=V data",
+			1,
+		},
 		{
 			"force_kvs",
 			[]string{"-force", "auth/token/create"},
@@ -80,6 +92,24 @@ func TestWriteCommand_Run(t *testing.T) {
 			"false",
 			0,
 		},
+		{
+			"idempotency_key_batch",
+			[]string{"-batch", "-idempotency-key=foo"},
+			"cannot be used with -batch",
+			1,
+		},
+		{
+			"qr_terminal_field",
+			[]string{"-qr-terminal", "-field=url", "totp/keys/foo", "generate=true"},
+			"-qr-terminal can only be used with the table output format",
+			1,
+		},
+		{
+			"qr_terminal_batch",
+			[]string{"-batch", "-qr-terminal"},
+			"cannot be used with -batch",
+			1,
+		},
 		{
 			"field_not_found",
 			[]string{
@@ -217,6 +247,167 @@ func TestWriteCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("batch", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		stdinR, stdinW := io.Pipe()
+		go func() {
+			stdinW.Write([]byte(`{"path":"secret/write/batch1","data":{"foo":"bar"}}` + "\n"))
+			stdinW.Write([]byte("\n"))
+			stdinW.Write([]byte(`{"path":"secret/write/batch2","data":{"zip":"zap"}}` + "\n"))
+			stdinW.Write([]byte(`not json` + "\n"))
+			stdinW.Close()
+		}()
+
+		ui, cmd := testWriteCommand(t)
+		cmd.client = client
+		cmd.testStdin = stdinR
+
+		code := cmd.Run([]string{"-batch"})
+		if exp := 2; code != exp {
+			t.Fatalf("expected %d to be %d: %q", code, exp, ui.ErrorWriter.String())
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, "failed to parse record") {
+			t.Errorf("expected %q to report the malformed record", combined)
+		}
+
+		for path, key := range map[string]string{
+			"secret/write/batch1": "foo",
+			"secret/write/batch2": "zip",
+		} {
+			secret, err := client.Logical().Read(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if secret == nil || secret.Data == nil || secret.Data[key] == nil {
+				t.Errorf("expected %s to have key %q", path, key)
+			}
+		}
+	})
+
+	t.Run("batch_concurrency", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		var records strings.Builder
+		for i := 0; i < 20; i++ {
+			fmt.Fprintf(&records, `{"path":"secret/write/batch%d","data":{"foo":"bar"}}`+"\n", i)
+		}
+
+		ui, cmd := testWriteCommand(t)
+		cmd.client = client
+		cmd.testStdin = strings.NewReader(records.String())
+
+		code := cmd.Run([]string{"-batch", "-concurrency=4"})
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d: %q", code, ui.ErrorWriter.String())
+		}
+
+		// The results are reported in the order of the records
+		out := ui.OutputWriter.String()
+		last := 0
+		for i := 0; i < 20; i++ {
+			row := fmt.Sprintf("secret/write/batch%d ", i)
+			idx := strings.Index(out, row)
+			if idx < last {
+				t.Fatalf("expected %q to be reported after the previous records: %s", row, out)
+			}
+			last = idx
+		}
+		if strings.Contains(out, "error") {
+			t.Errorf("expected all records to be written: %s", out)
+		}
+	})
+
+	t.Run("dry_run", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testWriteCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-dry-run", "secret/write/dry_run", "foo=bar",
+		})
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d: %q", code, ui.ErrorWriter.String())
+		}
+
+		combined := ui.OutputWriter.String()
+		for _, exp := range []string{"no request was sent", "secret/write/dry_run", `"foo": "bar"`} {
+			if !strings.Contains(combined, exp) {
+				t.Errorf("expected %q to contain %q", combined, exp)
+			}
+		}
+
+		secret, err := client.Logical().Read("secret/write/dry_run")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret != nil {
+			t.Errorf("expected no data to be written: %#v", secret)
+		}
+	})
+
+	t.Run("idempotency_key", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		random := func(key, bytes string) (string, int) {
+			ui, cmd := testWriteCommand(t)
+			cmd.client = client
+			code := cmd.Run([]string{
+				"-idempotency-key=" + key, "-field=random_bytes",
+				"sys/tools/random", "bytes=" + bytes,
+			})
+			return ui.OutputWriter.String(), code
+		}
+
+		first, code := random("step-1", "16")
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d", code)
+		}
+		replayed, code := random("step-1", "16")
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d", code)
+		}
+		if first != replayed {
+			t.Errorf("expected the same bytes to be returned, got %q and %q", first, replayed)
+		}
+
+		other, code := random("step-2", "16")
+		if code != 0 {
+			t.Fatalf("expected 0 to be %d", code)
+		}
+		if other == first {
+			t.Errorf("expected new bytes for a different key")
+		}
+
+		if _, code := random("step-1", "32"); code != 2 {
+			t.Errorf("expected reusing the key for a different request to fail, got %d", code)
+		}
+
+		// Tokens are never replayed
+		for i, exp := range []int{0, 2} {
+			_, cmd := testWriteCommand(t)
+			cmd.client = client
+			if code := cmd.Run([]string{"-idempotency-key=step-3", "auth/token/create"}); code != exp {
+				t.Errorf("attempt %d: expected %d to be %d", i, exp, code)
+			}
+		}
+	})
+
 	t.Run("integration", func(t *testing.T) {
 		t.Parallel()
 
@@ -278,3 +469,59 @@ func TestWriteCommand_Run(t *testing.T) {
 		assertNoTabs(t, cmd)
 	})
 }
+
+func TestWriteCommand_selectMFAMethods(t *testing.T) {
+	t.Parallel()
+
+	constraints := map[string]*logical.MFAConstraintAny{
+		"b_single": {
+			Any: []*logical.MFAMethodID{
+				{Type: "duo", ID: "duo-id", UsesPasscode: false},
+			},
+		},
+		"a_multiple": {
+			Any: []*logical.MFAMethodID{
+				{Type: "okta", ID: "okta-id", UsesPasscode: false},
+				{Type: "totp", ID: "totp-id", UsesPasscode: true},
+			},
+		},
+	}
+
+	t.Run("selected", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testWriteCommand(t)
+		ui.InputReader = strings.NewReader("2\n")
+
+		methods, err := cmd.selectMFAMethods(constraints)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exp := []MFAMethodInfo{
+			{methodType: "totp", methodID: "totp-id", usePasscode: true},
+			{methodType: "duo", methodID: "duo-id", usePasscode: false},
+		}
+		if !reflect.DeepEqual(methods, exp) {
+			t.Errorf("expected %#v to be %#v", methods, exp)
+		}
+
+		out := ui.OutputWriter.String()
+		for _, s := range []string{`"a_multiple"`, "1. okta", "2. totp"} {
+			if !strings.Contains(out, s) {
+				t.Errorf("expected %q to contain %q", out, s)
+			}
+		}
+	})
+
+	t.Run("invalid_selection", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testWriteCommand(t)
+		ui.InputReader = strings.NewReader("3\n")
+
+		if _, err := cmd.selectMFAMethods(constraints); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -32,6 +32,7 @@ import (
 	credOkta "github.com/hashicorp/vault/builtin/credential/okta"
 	credRadius "github.com/hashicorp/vault/builtin/credential/radius"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
+	credWebAuthn "github.com/hashicorp/vault/builtin/credential/webauthn"
 	logicalAws "github.com/hashicorp/vault/builtin/logical/aws"
 	logicalCass "github.com/hashicorp/vault/builtin/logical/cassandra"
 	logicalConsul "github.com/hashicorp/vault/builtin/logical/consul"
@@ -46,6 +47,7 @@ import (
 	logicalTotp "github.com/hashicorp/vault/builtin/logical/totp"
 	logicalTransit "github.com/hashicorp/vault/builtin/logical/transit"
 	dbCass "github.com/hashicorp/vault/plugins/database/cassandra"
+	dbClickhouse "github.com/hashicorp/vault/plugins/database/clickhouse"
 	dbHana "github.com/hashicorp/vault/plugins/database/hana"
 	dbInflux "github.com/hashicorp/vault/plugins/database/influxdb"
 	dbMongo "github.com/hashicorp/vault/plugins/database/mongodb"
@@ -90,6 +92,7 @@ func newRegistry() *registry {
 			"pcf":        credCF.Factory, // Deprecated.
 			"radius":     credRadius.Factory,
 			"userpass":   credUserpass.Factory,
+			"webauthn":   credWebAuthn.Factory,
 		},
 		databasePlugins: map[string]BuiltinFactory{
 			// These four plugins all use the same mysql implementation but with
@@ -100,6 +103,7 @@ func newRegistry() *registry {
 			"mysql-legacy-database-plugin": dbMysql.New(dbMysql.DefaultLegacyUserNameTemplate),
 
 			"cassandra-database-plugin":     dbCass.New,
+			"clickhouse-database-plugin":    dbClickhouse.New,
 			"couchbase-database-plugin":     dbCouchbase.New,
 			"elasticsearch-database-plugin": dbElastic.New,
 			"hana-database-plugin":          dbHana.New,
@@ -144,6 +148,12 @@ func newRegistry() *registry {
 
 func addExtPluginsImpl(r *registry) {}
 
+// deprecatedPlugins are the builtin plugins which are deprecated, by type.
+var deprecatedPlugins = map[consts.PluginType][]string{
+	consts.PluginTypeCredential: {"app-id", "pcf"},
+	consts.PluginTypeSecrets:    {"cassandra", "mongodb", "mssql", "mysql", "postgresql"},
+}
+
 type registry struct {
 	credentialBackends map[string]logical.Factory
 	databasePlugins    map[string]BuiltinFactory
@@ -197,6 +207,20 @@ func (r *registry) Contains(name string, pluginType consts.PluginType) bool {
 	return false
 }
 
+// DeprecationStatus returns the deprecation status of a builtin plugin, and
+// whether the plugin is builtin.
+func (r *registry) DeprecationStatus(name string, pluginType consts.PluginType) (consts.DeprecationStatus, bool) {
+	if !r.Contains(name, pluginType) {
+		return consts.Unknown, false
+	}
+	for _, deprecated := range deprecatedPlugins[pluginType] {
+		if deprecated == name {
+			return consts.Deprecated, true
+		}
+	}
+	return consts.Supported, true
+}
+
 func toFunc(ifc interface{}) func() (interface{}, error) {
 	return func() (interface{}, error) {
 		return ifc, nil
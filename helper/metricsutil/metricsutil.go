@@ -112,7 +112,27 @@ func (m *MetricsHelper) ResponseForFormat(format string) *logical.Response {
 	}
 }
 
+// ResponseForRequest is like ResponseForFormat, but negotiates the Prometheus
+// exposition format with the Accept header of req. Clients which accept
+// OpenMetrics get metrics in that format, which includes exemplars.
+func (m *MetricsHelper) ResponseForRequest(format string, req *logical.Request) *logical.Response {
+	if format != PrometheusMetricFormat {
+		return m.ResponseForFormat(format)
+	}
+
+	// Only negotiate OpenMetrics, so that clients which prefer the protobuf
+	// format still get the text format as before
+	if expfmt.NegotiateIncludingOpenMetrics(http.Header(req.Headers)) == expfmt.FmtOpenMetrics {
+		return m.prometheusResponse(expfmt.FmtOpenMetrics)
+	}
+	return m.prometheusResponse(expfmt.FmtText)
+}
+
 func (m *MetricsHelper) PrometheusResponse() *logical.Response {
+	return m.prometheusResponse(expfmt.FmtText)
+}
+
+func (m *MetricsHelper) prometheusResponse(format expfmt.Format) *logical.Response {
 	resp := &logical.Response{
 		Data: map[string]interface{}{
 			logical.HTTPContentType: ErrorContentType,
@@ -134,7 +154,7 @@ func (m *MetricsHelper) PrometheusResponse() *logical.Response {
 	buf := &bytes.Buffer{}
 	defer buf.Reset()
 
-	e := expfmt.NewEncoder(buf, expfmt.FmtText)
+	e := expfmt.NewEncoder(buf, format)
 	for _, mf := range metricsFamilies {
 		err := e.Encode(mf)
 		if err != nil {
@@ -142,7 +162,14 @@ func (m *MetricsHelper) PrometheusResponse() *logical.Response {
 			return resp
 		}
 	}
-	resp.Data[logical.HTTPContentType] = string(expfmt.FmtText)
+	// The OpenMetrics encoder ends the output with "# EOF" when closed
+	if closer, ok := e.(expfmt.Closer); ok {
+		if err := closer.Close(); err != nil {
+			resp.Data[logical.HTTPRawBody] = fmt.Sprintf("error during the encoding of metrics: %s", err)
+			return resp
+		}
+	}
+	resp.Data[logical.HTTPContentType] = string(format)
 	resp.Data[logical.HTTPRawBody] = buf.Bytes()
 	resp.Data[logical.HTTPStatusCode] = http.StatusOK
 	return resp
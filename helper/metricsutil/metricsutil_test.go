@@ -1,8 +1,12 @@
 package metricsutil
 
 import (
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -44,3 +48,68 @@ func TestFormatFromRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestResponseForRequest_OpenMetrics(t *testing.T) {
+	if err := EnableRequestDurationHistogram("vaulttest"); err != nil {
+		t.Fatal(err)
+	}
+	ObserveRequestDuration(20*time.Millisecond, map[string][]string{
+		"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	})
+
+	m := NewMetricsHelper(metrics.NewInmemSink(time.Second, time.Minute), true)
+
+	testCases := []struct {
+		accept      string
+		contentType string
+		exemplar    bool
+	}{
+		{"", "text/plain", false},
+		{"application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3", "text/plain", false},
+		{"application/openmetrics-text; version=0.0.1,text/plain;version=0.0.4;q=0.5,*/*;q=0.1", "application/openmetrics-text", true},
+	}
+
+	for _, tCase := range testCases {
+		resp := m.ResponseForRequest(PrometheusMetricFormat, &logical.Request{Headers: map[string][]string{
+			"Accept": {tCase.accept},
+		}})
+
+		if status := resp.Data[logical.HTTPStatusCode]; status != http.StatusOK {
+			t.Fatalf("%q: expected status 200, got %v: %s", tCase.accept, status, resp.Data[logical.HTTPRawBody])
+		}
+		if contentType := resp.Data[logical.HTTPContentType].(string); !strings.HasPrefix(contentType, tCase.contentType) {
+			t.Errorf("%q: expected content type %s, got %s", tCase.accept, tCase.contentType, contentType)
+		}
+
+		body := string(resp.Data[logical.HTTPRawBody].([]byte))
+		exemplar := `vaulttest_core_handle_request_duration_seconds_bucket{le="0.025"} 1 # {trace_id="4bf92f3577b34da6a3ce929d0e0e4736",span_id="00f067aa0ba902b7"} 0.02 `
+		if strings.Contains(body, exemplar) != tCase.exemplar {
+			t.Errorf("%q: expected exemplar %t in:\n%s", tCase.accept, tCase.exemplar, body)
+		}
+		if tCase.exemplar && !strings.HasSuffix(body, "# EOF\n") {
+			t.Errorf("%q: expected OpenMetrics output to end with # EOF", tCase.accept)
+		}
+	}
+}
+
+func TestSampledTraceParent(t *testing.T) {
+	testCases := []struct {
+		header string
+		ok     bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-03-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"", false},
+	}
+
+	for _, tCase := range testCases {
+		if _, _, ok := sampledTraceParent(tCase.header); ok != tCase.ok {
+			t.Errorf("%q: expected %t, got %t", tCase.header, tCase.ok, ok)
+		}
+	}
+}
@@ -0,0 +1,543 @@
+package metricsutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	log "github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	OTLPProtocolGRPC         = "grpc"
+	OTLPProtocolHTTPProtobuf = "http/protobuf"
+
+	OTLPDefaultExportInterval = time.Minute
+
+	otlpScopeName        = "vault"
+	otlpMetricsGRPCPath  = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+	otlpMetricsHTTPPath  = "/v1/metrics"
+	otlpExportTimeout    = 10 * time.Second
+	otlpDeltaTemporality = 1
+)
+
+// OTLPSinkConfig configures an OTLPSink.
+type OTLPSinkConfig struct {
+	// Endpoint is the URL of the collector. With the gRPC protocol, only its
+	// scheme, which selects TLS, and its host are used.
+	Endpoint string
+
+	// Protocol is either OTLPProtocolHTTPProtobuf, the default, or
+	// OTLPProtocolGRPC.
+	Protocol string
+
+	// ExportInterval is how often metrics are exported.
+	ExportInterval time.Duration
+
+	// Headers are sent with every export, as HTTP headers or gRPC metadata.
+	Headers map[string]string
+
+	// ResourceAttributes are added to the attributes of the resource, which
+	// default to service.name and host.name.
+	ResourceAttributes map[string]string
+
+	// TLSCAFile is a PEM file with the CA certificates used to verify the
+	// collector. By default, the system CAs are used.
+	TLSCAFile string
+
+	ServiceName string
+	Logger      log.Logger
+}
+
+// OTLPSink is a metrics sink which aggregates metrics over an interval and
+// pushes them to an OpenTelemetry collector using OTLP. Counters are exported
+// as delta sums, gauges as gauges, and samples as summaries with their
+// minimum and maximum.
+type OTLPSink struct {
+	logger   log.Logger
+	resource []byte
+	export   func(context.Context, []byte) error
+
+	mu       sync.Mutex
+	start    time.Time
+	gauges   map[string]*otlpPoint
+	counters map[string]*otlpPoint
+	samples  map[string]*otlpPoint
+}
+
+var _ metrics.MetricSink = (*OTLPSink)(nil)
+
+// otlpPoint is the aggregated value of a metric with given labels during
+// the current interval.
+type otlpPoint struct {
+	name   string
+	labels []metrics.Label
+
+	value         float64
+	count         uint64
+	sum, min, max float64
+}
+
+// NewOTLPSink returns a sink which exports metrics every
+// config.ExportInterval, until the process exits.
+func NewOTLPSink(config *OTLPSinkConfig) (*OTLPSink, error) {
+	u, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("OTLP endpoint must be an http or https URL")
+	}
+
+	var tlsConfig *tls.Config
+	if u.Scheme == "https" {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if config.TLSCAFile != "" {
+			data, err := ioutil.ReadFile(config.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read OTLP CA file: %w", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("failed to parse OTLP CA certificate")
+			}
+		}
+	}
+
+	s := &OTLPSink{
+		logger:   config.Logger,
+		resource: otlpResource(config.ServiceName, config.ResourceAttributes),
+	}
+	if s.logger == nil {
+		s.logger = log.NewNullLogger()
+	}
+	s.reset(time.Now())
+
+	switch config.Protocol {
+	case "", OTLPProtocolHTTPProtobuf:
+		s.export = otlpHTTPExporter(u, tlsConfig, config.Headers)
+	case OTLPProtocolGRPC:
+		s.export, err = otlpGRPCExporter(u, tlsConfig, config.Headers)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol %q", config.Protocol)
+	}
+
+	interval := config.ExportInterval
+	if interval <= 0 {
+		interval = OTLPDefaultExportInterval
+	}
+	go func() {
+		for range time.Tick(interval) {
+			s.Flush()
+		}
+	}()
+
+	return s, nil
+}
+
+func (s *OTLPSink) SetGauge(key []string, val float32) {
+	s.SetGaugeWithLabels(key, val, nil)
+}
+
+func (s *OTLPSink) SetGaugeWithLabels(key []string, val float32, labels []metrics.Label) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.point(s.gauges, key, labels).value = float64(val)
+}
+
+// EmitKey is not supported, like by the Prometheus sink.
+func (s *OTLPSink) EmitKey(key []string, val float32) {
+}
+
+func (s *OTLPSink) IncrCounter(key []string, val float32) {
+	s.IncrCounterWithLabels(key, val, nil)
+}
+
+func (s *OTLPSink) IncrCounterWithLabels(key []string, val float32, labels []metrics.Label) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.point(s.counters, key, labels).value += float64(val)
+}
+
+func (s *OTLPSink) AddSample(key []string, val float32) {
+	s.AddSampleWithLabels(key, val, nil)
+}
+
+func (s *OTLPSink) AddSampleWithLabels(key []string, val float32, labels []metrics.Label) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.point(s.samples, key, labels)
+	v := float64(val)
+	if p.count == 0 || v < p.min {
+		p.min = v
+	}
+	if p.count == 0 || v > p.max {
+		p.max = v
+	}
+	p.count++
+	p.sum += v
+}
+
+// point returns the point of the metric with the given key and labels in
+// points, adding it if needed. It must be called with the lock held.
+func (s *OTLPSink) point(points map[string]*otlpPoint, key []string, labels []metrics.Label) *otlpPoint {
+	name := strings.Join(key, ".")
+	id := name
+	for _, l := range labels {
+		id += ";" + l.Name + "=" + l.Value
+	}
+
+	p, ok := points[id]
+	if !ok {
+		p = &otlpPoint{name: name, labels: labels}
+		points[id] = p
+	}
+	return p
+}
+
+func (s *OTLPSink) reset(now time.Time) {
+	s.start = now
+	s.gauges = make(map[string]*otlpPoint)
+	s.counters = make(map[string]*otlpPoint)
+	s.samples = make(map[string]*otlpPoint)
+}
+
+// Flush exports the metrics of the current interval and starts a new one.
+// Metrics which cannot be exported are dropped.
+func (s *OTLPSink) Flush() {
+	now := time.Now()
+
+	s.mu.Lock()
+	req := s.encodeRequest(now)
+	s.reset(now)
+	s.mu.Unlock()
+
+	if req == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+	defer cancel()
+	if err := s.export(ctx, req); err != nil {
+		s.logger.Warn("failed to export metrics to OTLP endpoint", "error", err)
+	}
+}
+
+// encodeRequest encodes the metrics of the current interval as an
+// ExportMetricsServiceRequest, or returns nil if there are none. It must be
+// called with the lock held.
+func (s *OTLPSink) encodeRequest(now time.Time) []byte {
+	if len(s.gauges)+len(s.counters)+len(s.samples) == 0 {
+		return nil
+	}
+
+	start, end := uint64(s.start.UnixNano()), uint64(now.UnixNano())
+
+	// Metric: name = 1, gauge = 5, sum = 7, summary = 11
+	var metricsBuf []byte
+	for _, p := range sortedOTLPPoints(s.gauges) {
+		gauge := protowire.AppendTag(nil, 1, protowire.BytesType)
+		gauge = protowire.AppendBytes(gauge, p.numberDataPoint(start, end))
+		metricsBuf = appendOTLPMetric(metricsBuf, p.name, 5, gauge)
+	}
+	for _, p := range sortedOTLPPoints(s.counters) {
+		sum := protowire.AppendTag(nil, 1, protowire.BytesType)
+		sum = protowire.AppendBytes(sum, p.numberDataPoint(start, end))
+		sum = protowire.AppendTag(sum, 2, protowire.VarintType)
+		sum = protowire.AppendVarint(sum, otlpDeltaTemporality)
+		metricsBuf = appendOTLPMetric(metricsBuf, p.name, 7, sum)
+	}
+	for _, p := range sortedOTLPPoints(s.samples) {
+		summary := protowire.AppendTag(nil, 1, protowire.BytesType)
+		summary = protowire.AppendBytes(summary, p.summaryDataPoint(start, end))
+		metricsBuf = appendOTLPMetric(metricsBuf, p.name, 11, summary)
+	}
+
+	// ScopeMetrics: scope = 1, metrics = 2
+	scope := protowire.AppendTag(nil, 1, protowire.BytesType)
+	scope = protowire.AppendString(scope, otlpScopeName)
+	scopeMetrics := protowire.AppendTag(nil, 1, protowire.BytesType)
+	scopeMetrics = protowire.AppendBytes(scopeMetrics, scope)
+	scopeMetrics = append(scopeMetrics, metricsBuf...)
+
+	// ResourceMetrics: resource = 1, scope_metrics = 2
+	resourceMetrics := protowire.AppendTag(nil, 1, protowire.BytesType)
+	resourceMetrics = protowire.AppendBytes(resourceMetrics, s.resource)
+	resourceMetrics = protowire.AppendTag(resourceMetrics, 2, protowire.BytesType)
+	resourceMetrics = protowire.AppendBytes(resourceMetrics, scopeMetrics)
+
+	// ExportMetricsServiceRequest: resource_metrics = 1
+	req := protowire.AppendTag(nil, 1, protowire.BytesType)
+	return protowire.AppendBytes(req, resourceMetrics)
+}
+
+func sortedOTLPPoints(points map[string]*otlpPoint) []*otlpPoint {
+	ids := make([]string, 0, len(points))
+	for id := range points {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	sorted := make([]*otlpPoint, len(ids))
+	for i, id := range ids {
+		sorted[i] = points[id]
+	}
+	return sorted
+}
+
+func appendOTLPMetric(b []byte, name string, dataField protowire.Number, data []byte) []byte {
+	metric := protowire.AppendTag(nil, 1, protowire.BytesType)
+	metric = protowire.AppendString(metric, name)
+	metric = protowire.AppendTag(metric, dataField, protowire.BytesType)
+	metric = protowire.AppendBytes(metric, data)
+
+	// ScopeMetrics: metrics = 2
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendBytes(b, metric)
+}
+
+// numberDataPoint encodes a NumberDataPoint: start_time_unix_nano = 2,
+// time_unix_nano = 3, as_double = 4, attributes = 7.
+func (p *otlpPoint) numberDataPoint(start, end uint64) []byte {
+	b := appendOTLPTimes(nil, start, end)
+	b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(p.value))
+	return appendOTLPLabels(b, 7, p.labels)
+}
+
+// summaryDataPoint encodes a SummaryDataPoint: start_time_unix_nano = 2,
+// time_unix_nano = 3, count = 4, sum = 5, quantile_values = 6,
+// attributes = 7. The minimum and maximum are the quantiles 0 and 1.
+func (p *otlpPoint) summaryDataPoint(start, end uint64) []byte {
+	b := appendOTLPTimes(nil, start, end)
+	b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, p.count)
+	b = protowire.AppendTag(b, 5, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(p.sum))
+	for _, q := range [][2]float64{{0, p.min}, {1, p.max}} {
+		// ValueAtQuantile: quantile = 1, value = 2
+		v := protowire.AppendTag(nil, 1, protowire.Fixed64Type)
+		v = protowire.AppendFixed64(v, math.Float64bits(q[0]))
+		v = protowire.AppendTag(v, 2, protowire.Fixed64Type)
+		v = protowire.AppendFixed64(v, math.Float64bits(q[1]))
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendBytes(b, v)
+	}
+	return appendOTLPLabels(b, 7, p.labels)
+}
+
+func appendOTLPTimes(b []byte, start, end uint64) []byte {
+	b = protowire.AppendTag(b, 2, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, start)
+	b = protowire.AppendTag(b, 3, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, end)
+}
+
+// appendOTLPLabels encodes labels as KeyValue attributes: key = 1,
+// value = 2, with AnyValue string_value = 1.
+func appendOTLPLabels(b []byte, num protowire.Number, labels []metrics.Label) []byte {
+	for _, l := range labels {
+		value := protowire.AppendTag(nil, 1, protowire.BytesType)
+		value = protowire.AppendString(value, l.Value)
+
+		kv := protowire.AppendTag(nil, 1, protowire.BytesType)
+		kv = protowire.AppendString(kv, l.Name)
+		kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+		kv = protowire.AppendBytes(kv, value)
+
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, kv)
+	}
+	return b
+}
+
+// otlpResource encodes the Resource of the exported metrics, whose
+// attributes = 1.
+func otlpResource(serviceName string, attrs map[string]string) []byte {
+	all := make(map[string]string)
+	if serviceName != "" {
+		all["service.name"] = serviceName
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		all["host.name"] = hostname
+	}
+	for k, v := range attrs {
+		all[k] = v
+	}
+
+	labels := make([]metrics.Label, 0, len(all))
+	for k, v := range all {
+		labels = append(labels, metrics.Label{Name: k, Value: v})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+	return appendOTLPLabels(nil, 1, labels)
+}
+
+// otlpPartialSuccess returns an error if the ExportMetricsServiceResponse in
+// resp reports rejected data points: partial_success = 1, with
+// rejected_data_points = 1 and error_message = 2.
+func otlpPartialSuccess(resp []byte) error {
+	partial := otlpField(resp, 1)
+	if partial == nil {
+		return nil
+	}
+
+	var rejected uint64
+	var message string
+	for len(partial) > 0 {
+		num, typ, n := protowire.ConsumeTag(partial)
+		if n < 0 {
+			return nil
+		}
+		partial = partial[n:]
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			rejected, n = protowire.ConsumeVarint(partial)
+		case num == 2 && typ == protowire.BytesType:
+			message, n = protowire.ConsumeString(partial)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, partial)
+		}
+		if n < 0 {
+			return nil
+		}
+		partial = partial[n:]
+	}
+
+	if rejected == 0 {
+		return nil
+	}
+	return fmt.Errorf("OTLP endpoint rejected %d data points: %s", rejected, message)
+}
+
+// otlpField returns the value of the last length-delimited field num of
+// msg, or nil.
+func otlpField(msg []byte, num protowire.Number) []byte {
+	var value []byte
+	for len(msg) > 0 {
+		n, typ, l := protowire.ConsumeTag(msg)
+		if l < 0 {
+			return nil
+		}
+		msg = msg[l:]
+		if n == num && typ == protowire.BytesType {
+			value, l = protowire.ConsumeBytes(msg)
+		} else {
+			l = protowire.ConsumeFieldValue(n, typ, msg)
+		}
+		if l < 0 {
+			return nil
+		}
+		msg = msg[l:]
+	}
+	return value
+}
+
+func otlpHTTPExporter(u *url.URL, tlsConfig *tls.Config, headers map[string]string) func(context.Context, []byte) error {
+	endpoint := *u
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + otlpMetricsHTTPPath
+
+	client := cleanhttp.DefaultPooledClient()
+	client.Transport.(*http.Transport).TLSClientConfig = tlsConfig
+
+	return func(ctx context.Context, body []byte) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		req.Header.Set("Content-Type", "application/x-protobuf")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("OTLP endpoint returned %s", resp.Status)
+		}
+		return otlpPartialSuccess(respBody)
+	}
+}
+
+func otlpGRPCExporter(u *url.URL, tlsConfig *tls.Config, headers map[string]string) (func(context.Context, []byte) error, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	// The connection is established lazily, and re-established as needed
+	conn, err := grpc.Dial(u.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up OTLP gRPC connection: %w", err)
+	}
+	md := metadata.New(headers)
+
+	return func(ctx context.Context, body []byte) error {
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		var resp []byte
+		if err := conn.Invoke(ctx, otlpMetricsGRPCPath, body, &resp, grpc.ForceCodec(otlpRawCodec{})); err != nil {
+			return err
+		}
+		return otlpPartialSuccess(resp)
+	}, nil
+}
+
+// otlpRawCodec is a gRPC codec for messages which are already encoded, so
+// that OTLP does not require the generated protobuf types.
+type otlpRawCodec struct{}
+
+func (otlpRawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return b, nil
+}
+
+func (otlpRawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (otlpRawCodec) Name() string {
+	return "proto"
+}
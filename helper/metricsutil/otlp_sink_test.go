@@ -0,0 +1,282 @@
+package metricsutil
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// testOTLPMetric is a decoded Metric with a single data point.
+type testOTLPMetric struct {
+	kind   string
+	labels map[string]string
+	value  float64
+	count  uint64
+	sum    float64
+}
+
+// decodeTestOTLPRequest decodes the metrics of an
+// ExportMetricsServiceRequest by name, and the resource attributes.
+func decodeTestOTLPRequest(t *testing.T, req []byte) (map[string]testOTLPMetric, map[string]string) {
+	t.Helper()
+
+	resourceMetrics := otlpField(req, 1)
+	resource := testOTLPAttributes(t, otlpFields(resourceMetrics, 1)[0], 1)
+	scopeMetrics := otlpField(resourceMetrics, 2)
+	if name := string(otlpField(otlpField(scopeMetrics, 1), 1)); name != otlpScopeName {
+		t.Errorf("expected scope %q, got %q", otlpScopeName, name)
+	}
+
+	result := make(map[string]testOTLPMetric)
+	for _, metric := range otlpFields(scopeMetrics, 2) {
+		name := string(otlpField(metric, 1))
+
+		var m testOTLPMetric
+		var point []byte
+		for _, kind := range []struct {
+			name string
+			num  protowire.Number
+		}{{"gauge", 5}, {"sum", 7}, {"summary", 11}} {
+			if data := otlpField(metric, kind.num); data != nil {
+				m.kind = kind.name
+				point = otlpField(data, 1)
+			}
+		}
+
+		m.labels = testOTLPAttributes(t, point, 7)
+		for len(point) > 0 {
+			num, typ, n := protowire.ConsumeTag(point)
+			point = point[n:]
+			if typ != protowire.Fixed64Type {
+				n = protowire.ConsumeFieldValue(num, typ, point)
+				point = point[n:]
+				continue
+			}
+			v, n := protowire.ConsumeFixed64(point)
+			point = point[n:]
+			switch {
+			case num == 4 && m.kind == "summary":
+				m.count = v
+			case num == 4:
+				m.value = math.Float64frombits(v)
+			case num == 5:
+				m.sum = math.Float64frombits(v)
+			}
+		}
+		result[name] = m
+	}
+	return result, resource
+}
+
+// otlpFields returns the values of all length-delimited fields num of msg.
+func otlpFields(msg []byte, num protowire.Number) [][]byte {
+	var values [][]byte
+	for len(msg) > 0 {
+		n, typ, l := protowire.ConsumeTag(msg)
+		msg = msg[l:]
+		if n == num && typ == protowire.BytesType {
+			var v []byte
+			v, l = protowire.ConsumeBytes(msg)
+			values = append(values, v)
+		} else {
+			l = protowire.ConsumeFieldValue(n, typ, msg)
+		}
+		msg = msg[l:]
+	}
+	return values
+}
+
+func testOTLPAttributes(t *testing.T, msg []byte, num protowire.Number) map[string]string {
+	t.Helper()
+
+	attrs := make(map[string]string)
+	for _, kv := range otlpFields(msg, num) {
+		attrs[string(otlpField(kv, 1))] = string(otlpField(otlpField(kv, 2), 1))
+	}
+	return attrs
+}
+
+func testOTLPSink(t *testing.T, config *OTLPSinkConfig) *OTLPSink {
+	t.Helper()
+
+	// Metrics are flushed by the tests
+	config.ExportInterval = time.Hour
+	sink, err := NewOTLPSink(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sink
+}
+
+func addTestOTLPMetrics(sink *OTLPSink) {
+	sink.SetGauge([]string{"vault", "core", "active"}, 0)
+	sink.SetGauge([]string{"vault", "core", "active"}, 1)
+	sink.IncrCounterWithLabels([]string{"vault", "route", "create"}, 1, []metrics.Label{{Name: "mount", Value: "secret/"}})
+	sink.IncrCounterWithLabels([]string{"vault", "route", "create"}, 2, []metrics.Label{{Name: "mount", Value: "secret/"}})
+	sink.AddSample([]string{"vault", "core", "handle_request"}, 2)
+	sink.AddSample([]string{"vault", "core", "handle_request"}, 4)
+	sink.EmitKey([]string{"vault", "ignored"}, 1)
+}
+
+func checkTestOTLPMetrics(t *testing.T, req []byte) {
+	t.Helper()
+
+	metrics, resource := decodeTestOTLPRequest(t, req)
+	if resource["service.name"] != "vault" || resource["deployment.environment"] != "test" {
+		t.Errorf("unexpected resource attributes %v", resource)
+	}
+
+	if len(metrics) != 3 {
+		t.Fatalf("expected 3 metrics, got %v", metrics)
+	}
+	if m := metrics["vault.core.active"]; m.kind != "gauge" || m.value != 1 {
+		t.Errorf("unexpected gauge %+v", m)
+	}
+	if m := metrics["vault.route.create"]; m.kind != "sum" || m.value != 3 || m.labels["mount"] != "secret/" {
+		t.Errorf("unexpected counter %+v", m)
+	}
+	if m := metrics["vault.core.handle_request"]; m.kind != "summary" || m.count != 2 || m.sum != 6 {
+		t.Errorf("unexpected summary %+v", m)
+	}
+}
+
+func TestOTLPSink_HTTP(t *testing.T) {
+	var mu sync.Mutex
+	var requests [][]byte
+	status := http.StatusOK
+	var response []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/x-protobuf" || r.Header.Get("X-Api-Key") != "foo" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, body)
+		w.WriteHeader(status)
+		w.Write(response)
+	}))
+	defer srv.Close()
+
+	sink := testOTLPSink(t, &OTLPSinkConfig{
+		Endpoint:           srv.URL,
+		Headers:            map[string]string{"X-Api-Key": "foo"},
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+		ServiceName:        "vault",
+	})
+
+	addTestOTLPMetrics(sink)
+	sink.Flush()
+
+	// Intervals without metrics are not exported
+	sink.Flush()
+
+	mu.Lock()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	checkTestOTLPMetrics(t, requests[0])
+	mu.Unlock()
+
+	var partialSuccess []byte
+	partialSuccess = protowire.AppendTag(partialSuccess, 1, protowire.VarintType)
+	partialSuccess = protowire.AppendVarint(partialSuccess, 2)
+	partialSuccess = protowire.AppendTag(partialSuccess, 2, protowire.BytesType)
+	partialSuccess = protowire.AppendString(partialSuccess, "too old")
+	mu.Lock()
+	response = protowire.AppendTag(nil, 1, protowire.BytesType)
+	response = protowire.AppendBytes(response, partialSuccess)
+	mu.Unlock()
+
+	err := sink.export(context.Background(), requests[0])
+	if err == nil || !strings.Contains(err.Error(), "rejected 2 data points: too old") {
+		t.Fatalf("expected rejected data points, got %v", err)
+	}
+
+	mu.Lock()
+	status, response = http.StatusServiceUnavailable, nil
+	mu.Unlock()
+	err = sink.export(context.Background(), requests[0])
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected a 503 error, got %v", err)
+	}
+}
+
+func TestOTLPSink_GRPC(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqCh := make(chan []byte, 1)
+	srv := grpc.NewServer(
+		grpc.ForceServerCodec(otlpRawCodec{}),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			if method != otlpMetricsGRPCPath || len(md.Get("x-api-key")) != 1 {
+				t.Errorf("unexpected call %s %v", method, md)
+			}
+
+			var req []byte
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			reqCh <- req
+			return stream.SendMsg([]byte{})
+		}),
+	)
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	sink := testOTLPSink(t, &OTLPSinkConfig{
+		Endpoint:           "http://" + ln.Addr().String(),
+		Protocol:           OTLPProtocolGRPC,
+		Headers:            map[string]string{"X-Api-Key": "foo"},
+		ResourceAttributes: map[string]string{"deployment.environment": "test"},
+		ServiceName:        "vault",
+	})
+
+	addTestOTLPMetrics(sink)
+	req := sink.encodeRequest(time.Now())
+	if err := sink.export(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	checkTestOTLPMetrics(t, <-reqCh)
+}
+
+func TestNewOTLPSink_invalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		config OTLPSinkConfig
+		err    string
+	}{
+		{"no_scheme", OTLPSinkConfig{Endpoint: "collector:4318"}, "must be an http or https URL"},
+		{"protocol", OTLPSinkConfig{Endpoint: "http://collector:4318", Protocol: "http/json"}, "unknown OTLP protocol"},
+		{"ca_file", OTLPSinkConfig{Endpoint: "https://collector:4318", TLSCAFile: "/nonexistent/ca.pem"}, "failed to read OTLP CA file"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewOTLPSink(&tc.config)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package metricsutil
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestDuration holds the request duration histogram once it is enabled.
+var requestDuration atomic.Value
+
+// EnableRequestDurationHistogram registers the
+// <prefix>_core_handle_request_duration_seconds histogram with the default
+// Prometheus registry. Unlike the summaries of the Prometheus sink, its
+// buckets can have exemplars, which are only exposed in the OpenMetrics
+// format.
+func EnableRequestDurationHistogram(prefix string) error {
+	var histogram prometheus.Histogram = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: prefix,
+		Subsystem: "core",
+		Name:      "handle_request_duration_seconds",
+		Help:      "The duration of requests handled by the core, with the trace of a request in each bucket as exemplar.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	if err := prometheus.DefaultRegisterer.Register(histogram); err != nil {
+		// Tests set up telemetry more than once in the same process
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return err
+		}
+		histogram = are.ExistingCollector.(prometheus.Histogram)
+	}
+
+	requestDuration.Store(histogram)
+	return nil
+}
+
+// ObserveRequestDuration adds d to the request duration histogram, if it is
+// enabled. If the request headers have a W3C traceparent header of a sampled
+// trace, its trace and span ID are recorded as the exemplar of the bucket.
+func ObserveRequestDuration(d time.Duration, headers map[string][]string) {
+	histogram, ok := requestDuration.Load().(prometheus.Histogram)
+	if !ok {
+		return
+	}
+
+	if traceID, spanID, ok := sampledTraceParent(http.Header(headers).Get("Traceparent")); ok {
+		histogram.(prometheus.ExemplarObserver).ObserveWithExemplar(d.Seconds(), prometheus.Labels{
+			"trace_id": traceID,
+			"span_id":  spanID,
+		})
+		return
+	}
+	histogram.Observe(d.Seconds())
+}
+
+// sampledTraceParent returns the trace and span ID of a traceparent header
+// whose sampled flag is set.
+func sampledTraceParent(header string) (string, string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !lowerHex(version) || len(traceID) != 32 || !lowerHex(traceID) || len(spanID) != 16 || !lowerHex(spanID) || len(flags) != 2 || !lowerHex(flags) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+
+	// The sampled flag is the lowest bit of the flags
+	if !strings.ContainsRune("13579bdf", rune(flags[1])) {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+func lowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,263 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/hashicorp/go-hclog"
+)
+
+var (
+	filterSeparatorRe = regexp.MustCompile(`(?i)\s+and(\s+|$)`)
+	filterMatcherRe   = regexp.MustCompile(`^\s*([A-Za-z0-9_.@\-]+)\s*(==|!=|=~|!~|>=|<=|>|<)\s*(.*?)\s*$`)
+)
+
+// Filter selects the log entries streamed by a monitor. It is a list of
+// matchers that an entry must all satisfy, written as
+//
+//	subsystem == core.expiration and level >= warn and path =~ "^secret/"
+//
+// Matchers compare a field with a value using one of ==, !=, =~ and !~, the
+// latter two matching regular expressions. The level field can also be
+// compared with >=, <=, > and <. The fields are:
+//
+//   - subsystem: the name of the logger, such as core or core.expiration. ==
+//     and != also match the subsystems below the given one.
+//   - level: trace, debug, info, warn or error.
+//   - message: the log message.
+//   - namespace: the namespace field of the entry, with or without a
+//     trailing slash. The root namespace is matched by root.
+//   - any other name is matched against the field of the entry with that
+//     key. Entries without the field only match != and !~.
+//
+// Values can be quoted, in which case they follow the syntax of Go strings.
+type Filter struct {
+	matchers []filterMatcher
+}
+
+type filterMatcher struct {
+	expr  string
+	field string
+	op    string
+	value string
+	re    *regexp.Regexp
+	level log.Level
+}
+
+// ParseFilter parses a filter expression. An empty expression matches all
+// entries.
+func ParseFilter(expr string) (*Filter, error) {
+	f := &Filter{}
+	if strings.TrimSpace(expr) == "" {
+		return f, nil
+	}
+
+	for _, part := range splitFilter(strings.TrimSpace(expr)) {
+		m, err := parseFilterMatcher(part)
+		if err != nil {
+			return nil, err
+		}
+		f.matchers = append(f.matchers, m)
+	}
+	return f, nil
+}
+
+// splitFilter splits a filter expression into its matchers, which are
+// separated by "and" outside of quoted values.
+func splitFilter(expr string) []string {
+	var parts []string
+	start := 0
+	inQuotes := false
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\\' && inQuotes:
+			i++
+		case expr[i] == '"':
+			inQuotes = !inQuotes
+		case !inQuotes:
+			if loc := filterSeparatorRe.FindStringIndex(expr[i:]); loc != nil && loc[0] == 0 {
+				parts = append(parts, expr[start:i])
+				start = i + loc[1]
+				i = start - 1
+			}
+		}
+	}
+	return append(parts, expr[start:])
+}
+
+func parseFilterMatcher(expr string) (filterMatcher, error) {
+	parts := filterMatcherRe.FindStringSubmatch(expr)
+	if parts == nil {
+		return filterMatcher{}, fmt.Errorf("invalid filter matcher %q, expected <field> <operator> <value>", expr)
+	}
+
+	m := filterMatcher{
+		expr:  strings.TrimSpace(expr),
+		field: strings.ToLower(parts[1]),
+		op:    parts[2],
+		value: parts[3],
+	}
+	if strings.HasPrefix(m.value, `"`) {
+		value, err := strconv.Unquote(m.value)
+		if err != nil {
+			return filterMatcher{}, fmt.Errorf("invalid value in filter matcher %q: %w", expr, err)
+		}
+		m.value = value
+	}
+
+	switch m.op {
+	case "=~", "!~":
+		re, err := regexp.Compile(m.value)
+		if err != nil {
+			return filterMatcher{}, fmt.Errorf("invalid regular expression in filter matcher %q: %w", expr, err)
+		}
+		m.re = re
+	case ">=", "<=", ">", "<":
+		if m.field != "level" {
+			return filterMatcher{}, fmt.Errorf("invalid filter matcher %q, %s can only be used with the level field", expr, m.op)
+		}
+	}
+
+	switch m.field {
+	case "level":
+		if m.re == nil {
+			m.level = log.LevelFromString(m.value)
+			if m.level == log.NoLevel {
+				return filterMatcher{}, fmt.Errorf("invalid filter matcher %q, unknown log level %q", expr, m.value)
+			}
+		}
+	case "namespace":
+		m.value = filterNamespace(m.value)
+	}
+	return m, nil
+}
+
+// filterNamespace normalizes a namespace path, as the root namespace is
+// logged either as an empty path or as root.
+func filterNamespace(path string) string {
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return "root"
+	}
+	return path
+}
+
+// Match returns whether a log entry satisfies all the matchers of the filter.
+// The arguments of the entry alternate keys and values.
+func (f *Filter) Match(name string, level log.Level, msg string, args ...interface{}) bool {
+	_, _, found := f.Mismatch(name, level, msg, args...)
+	return !found
+}
+
+// Mismatch returns the first matcher of the filter that a log entry does not
+// satisfy, as written in the expression, along with the field it compares.
+// found is false if the entry satisfies all the matchers.
+func (f *Filter) Mismatch(name string, level log.Level, msg string, args ...interface{}) (matcher, field string, found bool) {
+	for _, m := range f.matchers {
+		if !m.match(name, level, msg, args) {
+			return m.expr, m.field, true
+		}
+	}
+	return "", "", false
+}
+
+// Fields returns the fields compared by the matchers of the filter.
+func (f *Filter) Fields() []string {
+	fields := make([]string, 0, len(f.matchers))
+	for _, m := range f.matchers {
+		fields = append(fields, m.field)
+	}
+	return fields
+}
+
+// Empty returns whether the filter matches all entries.
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.matchers) == 0
+}
+
+func (m *filterMatcher) match(name string, level log.Level, msg string, args []interface{}) bool {
+	switch m.field {
+	case "level":
+		if m.re != nil {
+			return m.re.MatchString(level.String()) == (m.op == "=~")
+		}
+		switch m.op {
+		case "==":
+			return level == m.level
+		case "!=":
+			return level != m.level
+		case ">=":
+			return level >= m.level
+		case "<=":
+			return level <= m.level
+		case ">":
+			return level > m.level
+		default:
+			return level < m.level
+		}
+	case "subsystem":
+		if m.re == nil {
+			isSubsystem := name == m.value || strings.HasPrefix(name, m.value+".")
+			return isSubsystem == (m.op == "==")
+		}
+		return m.compare(name, true)
+	case "message":
+		return m.compare(msg, true)
+	}
+
+	value, ok := filterField(args, m.field)
+	if ok && m.field == "namespace" {
+		value = filterNamespace(value)
+	}
+	return m.compare(value, ok)
+}
+
+// compare matches a field value, which an entry may not have.
+func (m *filterMatcher) compare(value string, ok bool) bool {
+	switch m.op {
+	case "==":
+		return ok && value == m.value
+	case "!=":
+		return !ok || value != m.value
+	case "=~":
+		return ok && m.re.MatchString(value)
+	default:
+		return !ok || !m.re.MatchString(value)
+	}
+}
+
+// filterField returns the value of the field of a log entry with the given
+// key.
+func filterField(args []interface{}, key string) (string, bool) {
+	for i := 0; i+1 < len(args); i += 2 {
+		k, ok := args[i].(string)
+		if !ok || strings.ToLower(k) != key {
+			continue
+		}
+		switch v := args[i+1].(type) {
+		case string:
+			return v, true
+		case error:
+			return v.Error(), true
+		case fmt.Stringer:
+			return v.String(), true
+		default:
+			return fmt.Sprint(v), true
+		}
+	}
+	return "", false
+}
+
+// filteredSink passes the log entries matching its filter to a sink.
+type filteredSink struct {
+	log.SinkAdapter
+	filter *Filter
+}
+
+func (s *filteredSink) Accept(name string, level log.Level, msg string, args ...interface{}) {
+	if s.filter.Match(name, level, msg, args...) {
+		s.SinkAdapter.Accept(name, level, msg, args...)
+	}
+}
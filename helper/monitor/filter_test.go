@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+)
+
+func TestFilter_Match(t *testing.T) {
+	t.Parallel()
+
+	type entry struct {
+		name  string
+		level log.Level
+		msg   string
+		args  []interface{}
+	}
+	expiration := entry{"core.expiration", log.Warn, "failed to revoke lease", []interface{}{"lease_id", "secret/creds/abc", "error", errors.New("permission denied"), "namespace", "ns1/"}}
+	mounts := entry{"core", log.Info, "successful mount", []interface{}{"namespace", "", "path", "secret/"}}
+
+	cases := []struct {
+		expr  string
+		match []entry
+		skip  []entry
+	}{
+		{"", []entry{expiration, mounts}, nil},
+		{"subsystem == core", []entry{expiration, mounts}, nil},
+		{"subsystem == core.expiration", []entry{expiration}, []entry{mounts}},
+		{"subsystem != core.expiration", []entry{mounts}, []entry{expiration}},
+		{"subsystem =~ ^core$", []entry{mounts}, []entry{expiration}},
+		{"level >= warn", []entry{expiration}, []entry{mounts}},
+		{"level < WARN", []entry{mounts}, []entry{expiration}},
+		{"level == info", []entry{mounts}, []entry{expiration}},
+		{"namespace == ns1", []entry{expiration}, []entry{mounts}},
+		{"namespace != ns1/", []entry{mounts}, []entry{expiration}},
+		{"namespace == root", []entry{mounts}, []entry{expiration}},
+		{"error =~ denied", []entry{expiration}, []entry{mounts}},
+		{"path !~ ^secret/", []entry{expiration}, []entry{mounts}},
+		{`message == "successful mount"`, []entry{mounts}, []entry{expiration}},
+		{`message =~ "revoke and|mount" and level == info`, []entry{mounts}, []entry{expiration}},
+		{"subsystem == core AND level >= warn and lease_id =~ ^secret/", []entry{expiration}, []entry{mounts}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := ParseFilter(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range tc.match {
+				if !f.Match(e.name, e.level, e.msg, e.args...) {
+					t.Errorf("expected %q to match %#v", tc.expr, e)
+				}
+			}
+			for _, e := range tc.skip {
+				if f.Match(e.name, e.level, e.msg, e.args...) {
+					t.Errorf("expected %q not to match %#v", tc.expr, e)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter_Mismatch(t *testing.T) {
+	t.Parallel()
+
+	f, err := ParseFilter(`level >= info and  path =~ "^secret/"  and namespace == ns1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, found := f.Mismatch("core", log.Info, "", "path", "secret/foo", "namespace", "ns1/"); found {
+		t.Error("expected the entry to match")
+	}
+
+	matcher, field, found := f.Mismatch("core", log.Info, "", "path", "sys/mounts", "namespace", "ns1/")
+	if !found || matcher != `path =~ "^secret/"` || field != "path" {
+		t.Errorf("expected the path matcher to fail, got %q, %q, %t", matcher, field, found)
+	}
+
+	if fields := f.Fields(); !reflect.DeepEqual(fields, []string{"level", "path", "namespace"}) {
+		t.Errorf("unexpected fields %q", fields)
+	}
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{
+		"subsystem",
+		"subsystem == core and",
+		"level >= verbose",
+		"path >= secret/",
+		"path =~ (",
+		`message == "unterminated`,
+	} {
+		if _, err := ParseFilter(expr); err == nil {
+			t.Errorf("expected an error parsing %q", expr)
+		}
+	}
+}
@@ -54,10 +54,16 @@ type monitor struct {
 // NewMonitor creates a new Monitor. Start must be called in order to actually start
 // streaming logs. buf is the buffer size of the channel that sends log messages.
 func NewMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (Monitor, error) {
-	return newMonitor(buf, logger, opts)
+	return newMonitor(buf, logger, opts, nil)
 }
 
-func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*monitor, error) {
+// NewFilteredMonitor creates a new Monitor that only streams the logs
+// matching filter.
+func NewFilteredMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions, filter *Filter) (Monitor, error) {
+	return newMonitor(buf, logger, opts, filter)
+}
+
+func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions, filter *Filter) (*monitor, error) {
 	if buf <= 0 {
 		return nil, fmt.Errorf("buf must be greater than zero")
 	}
@@ -75,6 +81,9 @@ func newMonitor(buf int, logger log.InterceptLogger, opts *log.LoggerOptions) (*
 	opts.Output = sw
 	sink := log.NewSinkAdapter(opts)
 	sw.sink = sink
+	if !filter.Empty() {
+		sw.sink = &filteredSink{SinkAdapter: sink, filter: filter}
+	}
 
 	return sw, nil
 }
@@ -68,7 +68,7 @@ func TestMonitor_DroppedMessages(t *testing.T) {
 
 	m, _ := newMonitor(5, logger, &log.LoggerOptions{
 		Level: log.Debug,
-	})
+	}, nil)
 	m.dropCheckInterval = 5 * time.Millisecond
 
 	logCh := m.Start()
@@ -94,3 +94,36 @@ func TestMonitor_DroppedMessages(t *testing.T) {
 		require.Fail(t, "expected to see warn dropped messages")
 	}
 }
+
+func TestMonitor_Start_Filtered(t *testing.T) {
+	t.Parallel()
+
+	logger := log.NewInterceptLogger(&log.LoggerOptions{
+		Level: log.Error,
+	})
+
+	filter, err := ParseFilter("subsystem == core and path =~ ^secret/")
+	require.NoError(t, err)
+
+	m, _ := NewFilteredMonitor(512, logger, &log.LoggerOptions{
+		Level: log.Debug,
+	}, filter)
+
+	logCh := m.Start()
+	defer m.Stop()
+
+	go func() {
+		logger.Named("core").Debug("filtered out", "path", "sys/mounts")
+		logger.Named("expiration").Debug("filtered out", "path", "secret/foo")
+		logger.Named("core").Named("expiration").Debug("test log", "path", "secret/foo")
+		time.Sleep(10 * time.Millisecond)
+	}()
+
+	select {
+	case l := <-logCh:
+		require.Contains(t, string(l), "[DEBUG] core.expiration: test log")
+		return
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected to receive from log channel")
+	}
+}
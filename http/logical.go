@@ -91,6 +91,8 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 		case path == "sys/monitor":
 			passHTTPReq = true
 			responseWriter = w
+		case strings.HasPrefix(path, "sys/audit-stream/"):
+			responseWriter = w
 		}
 
 	case "POST", "PUT":
@@ -104,10 +106,18 @@ func buildLogicalRequestNoAuth(perfStandby bool, w http.ResponseWriter, r *http.
 		// If we are uploading a snapshot we don't want to parse it. Instead
 		// we will simply add the HTTP request to the logical request object
 		// for later consumption.
-		if path == "sys/storage/raft/snapshot" || path == "sys/storage/raft/snapshot-force" {
+		switch path {
+		case "sys/storage/raft/snapshot", "sys/storage/raft/snapshot-force",
+			"sys/storage/raft/snapshot-chain", "sys/storage/raft/snapshot-chain-force":
 			passHTTPReq = true
 			origBody = r.Body
-		} else {
+		case "sys/storage/raft/snapshot-delta":
+			// The manifest of the parent snapshot is uploaded and the delta
+			// snapshot streamed back
+			passHTTPReq = true
+			origBody = r.Body
+			responseWriter = w
+		default:
 			// Sample the first bytes to determine whether this should be parsed as
 			// a form or as JSON. The amount to look ahead (512 bytes) is arbitrary
 			// but extremely tolerant (i.e. allowing 511 bytes of leading whitespace
@@ -333,12 +343,34 @@ func handleLogicalInternal(core *vault.Core, injectDataIntoTopLevel bool, noForw
 			return
 		}
 
+		// Replay the response to a write which was already handled with the
+		// same idempotency key, or reserve the key for this request. Replays
+		// are authorized and audited like any other request.
+		var idempotentReq *vault.IdempotentRequest
+		if key := r.Header.Get(IdempotencyKeyHeaderName); key != "" && isIdempotencyKeyOperation(req.Operation) {
+			idempotentReq, err = core.StartIdempotentRequest(r.Context(), req, key)
+			switch {
+			case err == vault.ErrIdempotencyKeyInProgress, err == vault.ErrIdempotencyReplayUnavailable:
+				respondError(w, http.StatusConflict, err)
+				return
+			case err == vault.ErrIdempotencyKeyMismatch:
+				respondError(w, http.StatusUnprocessableEntity, err)
+				return
+			case err != nil:
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			case idempotentReq.Replay:
+				r = r.WithContext(vault.ContextWithIdempotentReplay(r.Context(), idempotentReq))
+			}
+		}
+
 		// Make the internal request. We attach the connection info
 		// as well in case this is an authentication request that requires
 		// it. Vault core handles stripping this if we need to. This also
 		// handles all error cases; if we hit respondLogical, the request is a
 		// success.
 		resp, ok, needsForward := request(core, w, r, req)
+		core.FinishIdempotentRequest(idempotentReq, resp, ok && !needsForward)
 		switch {
 		case needsForward && noForward:
 			respondError(w, http.StatusBadRequest, vault.ErrCannotForwardLocalOnly)
@@ -356,12 +388,26 @@ func handleLogicalInternal(core *vault.Core, injectDataIntoTopLevel bool, noForw
 			return
 		default:
 			// Build and return the proper response if everything is fine.
+			if idempotentReq != nil && idempotentReq.Replay {
+				w.Header().Set(IdempotentReplayHeaderName, "true")
+			}
 			respondLogical(core, w, r, req, resp, injectDataIntoTopLevel)
 			return
 		}
 	})
 }
 
+// isIdempotencyKeyOperation returns whether requests with the given operation
+// can be deduplicated with an idempotency key.
+func isIdempotencyKeyOperation(op logical.Operation) bool {
+	switch op {
+	case logical.CreateOperation, logical.UpdateOperation, logical.PatchOperation, logical.DeleteOperation:
+		return true
+	default:
+		return false
+	}
+}
+
 func respondLogical(core *vault.Core, w http.ResponseWriter, r *http.Request, req *logical.Request, resp *logical.Response, injectDataIntoTopLevel bool) {
 	var httpResp *logical.HTTPResponse
 	var ret interface{}
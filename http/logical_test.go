@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -591,3 +592,120 @@ func TestLogical_AuditPort(t *testing.T) {
 		t.Fatalf("wrong number of audit entries: %d", count)
 	}
 }
+
+func TestLogical_IdempotencyKey(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{
+		AuditBackends: map[string]audit.Factory{
+			"file": auditFile.Factory,
+		},
+	})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	auditLogFile, err := ioutil.TempFile("", "idempotency")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(auditLogFile.Name())
+	resp := testHttpPut(t, token, addr+"/v1/sys/audit/file", map[string]interface{}{
+		"type": "file",
+		"options": map[string]interface{}{
+			"file_path": auditLogFile.Name(),
+		},
+	})
+	testResponseStatus(t, resp, 204)
+
+	write := func(token, key, path, body string) *http.Response {
+		req, err := http.NewRequest("POST", addr+"/v1/"+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(consts.AuthHeaderName, token)
+		req.Header.Set(IdempotencyKeyHeaderName, key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	randomBytes := func(resp *http.Response) string {
+		var actual map[string]interface{}
+		testResponseStatus(t, resp, 200)
+		testResponseBody(t, resp, &actual)
+		return actual["data"].(map[string]interface{})["random_bytes"].(string)
+	}
+
+	resp = write(token, "foo", "sys/tools/random", `{"bytes":16}`)
+	if resp.Header.Get(IdempotentReplayHeaderName) != "" {
+		t.Fatal("first request should not be a replay")
+	}
+	first := randomBytes(resp)
+
+	resp = write(token, "foo", "sys/tools/random", `{"bytes":16}`)
+	if resp.Header.Get(IdempotentReplayHeaderName) != "true" {
+		t.Fatal("expected the second request to be a replay")
+	}
+	if replayed := randomBytes(resp); replayed != first {
+		t.Fatalf("expected %q to be %q", replayed, first)
+	}
+
+	if other := randomBytes(write(token, "bar", "sys/tools/random", `{"bytes":16}`)); other == first {
+		t.Fatal("expected new bytes for a different key")
+	}
+
+	resp = write(token, "foo", "sys/tools/random", `{"bytes":32}`)
+	testResponseStatus(t, resp, http.StatusUnprocessableEntity)
+
+	// Replays are audited like any other request
+	var requests, responses int
+	decoder := json.NewDecoder(auditLogFile)
+	for {
+		var auditRecord map[string]interface{}
+		if decoder.Decode(&auditRecord) != nil {
+			break
+		}
+		req, ok := auditRecord["request"].(map[string]interface{})
+		if !ok || req["path"] != "sys/tools/random" {
+			continue
+		}
+		switch auditRecord["type"] {
+		case "request":
+			requests++
+		case "response":
+			responses++
+		}
+	}
+	if requests != 3 || responses != 3 {
+		t.Fatalf("expected 3 audited requests and responses, got %d and %d", requests, responses)
+	}
+
+	// Responses carrying a token are not replayed, and the token is not
+	// created again
+	resp = write(token, "foo", "auth/token/create", `{"display_name":"foo"}`)
+	testResponseStatus(t, resp, 200)
+	resp = write(token, "foo", "auth/token/create", `{"display_name":"foo"}`)
+	testResponseStatus(t, resp, http.StatusConflict)
+
+	// Replays are denied once the token is no longer allowed to make the
+	// request
+	resp = testHttpPut(t, token, addr+"/v1/sys/policy/random", map[string]interface{}{
+		"policy": `path "sys/tools/random" { capabilities = ["update"] }`,
+	})
+	testResponseStatus(t, resp, 204)
+	resp = testHttpPost(t, token, addr+"/v1/auth/token/create", map[string]interface{}{
+		"policies": []string{"random"},
+	})
+	var created map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &created)
+	limited := created["auth"].(map[string]interface{})["client_token"].(string)
+
+	randomBytes(write(limited, "foo", "sys/tools/random", `{"bytes":16}`))
+	resp = testHttpPut(t, token, addr+"/v1/sys/policy/random", map[string]interface{}{
+		"policy": `path "secret/*" { capabilities = ["read"] }`,
+	})
+	testResponseStatus(t, resp, 204)
+	resp = write(limited, "foo", "sys/tools/random", `{"bytes":16}`)
+	testResponseStatus(t, resp, http.StatusForbidden)
+}
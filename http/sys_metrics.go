@@ -32,7 +32,7 @@ func handleMetricsUnauthenticated(core *vault.Core) http.Handler {
 		}
 
 		// Define response
-		resp := core.MetricsHelper().ResponseForFormat(format)
+		resp := core.MetricsHelper().ResponseForRequest(format, req)
 
 		// Manually extract the logical response and send back the information
 		status := resp.Data[logical.HTTPStatusCode].(int)
@@ -35,6 +35,27 @@ func TestSysMonitorUnknownLogLevel(t *testing.T) {
 	}
 }
 
+func TestSysMonitorInvalidFilter(t *testing.T) {
+	cluster := vault.NewTestCluster(t, nil, &vault.TestClusterOptions{HandlerFunc: Handler})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	request := client.NewRequest("GET", "/v1/sys/monitor")
+	request.Params.Add("filter", "path >= secret/")
+	_, err := client.RawRequest(request)
+
+	if err == nil {
+		t.Fatal("expected to get an error, but didn't")
+	}
+	if !strings.Contains(err.Error(), "Code: 400") {
+		t.Fatalf("expected to receive a 400 error, but got %s instead", err)
+	}
+	if !strings.Contains(err.Error(), "invalid filter matcher") {
+		t.Fatalf("expected to receive a message indicating an invalid filter, but got %s instead", err)
+	}
+}
+
 func TestSysMonitorStreamingLogs(t *testing.T) {
 	logger := log.NewInterceptLogger(&log.LoggerOptions{
 		Output:     log.DefaultOutput,
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/tlsutil"
 	"github.com/hashicorp/vault/physical/raft"
@@ -95,6 +96,56 @@ func handleSysRaftJoinPost(core *vault.Core, w http.ResponseWriter, r *http.Requ
 	respondOk(w, resp)
 }
 
+// handleSysRaftSnapshotRestoreStatus reports the progress of the snapshot
+// restores of this node. It is served outside of the logical backends as a
+// restore shuts them down while it is applied.
+func handleSysRaftSnapshotRestoreStatus(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			handleSysRaftSnapshotRestoreStatusGet(core, w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+		}
+	})
+}
+
+func handleSysRaftSnapshotRestoreStatusGet(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	status := core.RaftSnapshotRestoreStatus()
+	now := time.Now()
+
+	resp := &SnapshotRestoreStatusResponse{
+		State:           status.State,
+		BytesTotal:      status.BytesTotal,
+		BytesProcessed:  status.BytesProcessed,
+		BytesPerSecond:  status.BytesPerSecond(now),
+		PercentComplete: status.PercentComplete(),
+		ETASeconds:      int64(status.EstimatedTimeRemaining(now).Seconds()),
+		Error:           status.Error,
+	}
+	if !status.StartTime.IsZero() {
+		resp.StartTime = status.StartTime.Format(time.RFC3339Nano)
+		resp.PhaseStartTime = status.PhaseStartTime.Format(time.RFC3339Nano)
+	}
+	if !status.EndTime.IsZero() {
+		resp.EndTime = status.EndTime.Format(time.RFC3339Nano)
+	}
+	respondOk(w, resp)
+}
+
+type SnapshotRestoreStatusResponse struct {
+	State           string  `json:"state"`
+	StartTime       string  `json:"start_time,omitempty"`
+	PhaseStartTime  string  `json:"phase_start_time,omitempty"`
+	EndTime         string  `json:"end_time,omitempty"`
+	BytesTotal      int64   `json:"bytes_total"`
+	BytesProcessed  int64   `json:"bytes_processed"`
+	BytesPerSecond  float64 `json:"bytes_per_second"`
+	PercentComplete float64 `json:"percent_complete"`
+	ETASeconds      int64   `json:"eta_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
 type JoinResponse struct {
 	Joined bool `json:"joined"`
 }
@@ -106,7 +106,7 @@ func ParseConfig(d string) (*SharedConfig, error) {
 
 	if o := list.Filter("seal"); len(o.Items) > 0 {
 		result.found("seal", "Seal")
-		if err := parseKMS(&result.Seals, o, "seal", 3); err != nil {
+		if err := parseKMS(&result.Seals, o, "seal", maxSeals); err != nil {
 			return nil, fmt.Errorf("error parsing 'seal': %w", err)
 		}
 	}
@@ -154,6 +154,7 @@ func ParseConfig(d string) (*SharedConfig, error) {
 // Specifically, the fields that this method strips are:
 // - KMS.Config
 // - Telemetry.CirconusAPIToken
+// - Telemetry.OTLPHeaders
 func (c *SharedConfig) Sanitized() map[string]interface{} {
 	if c == nil {
 		return nil
@@ -192,6 +193,7 @@ func (c *SharedConfig) Sanitized() map[string]interface{} {
 			cleanSeal := map[string]interface{}{
 				"type":     s.Type,
 				"disabled": s.Disabled,
+				"priority": s.Priority,
 			}
 			sanitizedSeals = append(sanitizedSeals, cleanSeal)
 		}
@@ -227,6 +229,12 @@ func (c *SharedConfig) Sanitized() map[string]interface{} {
 			"stackdriver_location":                   c.Telemetry.StackdriverLocation,
 			"stackdriver_namespace":                  c.Telemetry.StackdriverNamespace,
 			"stackdriver_debug_logs":                 c.Telemetry.StackdriverDebugLogs,
+			"otlp_endpoint":                          c.Telemetry.OTLPEndpoint,
+			"otlp_protocol":                          c.Telemetry.OTLPProtocol,
+			"otlp_export_interval":                   c.Telemetry.OTLPExportInterval,
+			"otlp_headers":                           "",
+			"otlp_resource_attributes":               c.Telemetry.OTLPResourceAttributes,
+			"otlp_tls_ca_file":                       c.Telemetry.OTLPTLSCAFile,
 			"lease_metrics_epsilon":                  c.Telemetry.LeaseMetricsEpsilon,
 			"num_lease_metrics_buckets":              c.Telemetry.NumLeaseMetricsTimeBuckets,
 			"add_lease_metrics_namespace_labels":     c.Telemetry.LeaseMetricsNameSpaceLabels,
@@ -2,6 +2,7 @@ package configutil
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -21,10 +22,13 @@ import (
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/seal/multi"
+	"github.com/hashicorp/vault/vault/seal/tpm"
 )
 
 var (
 	ConfigureWrapper             = configureWrapper
+	ConfigureFailoverWrapper     = configureFailoverWrapper
 	CreateSecureRandomReaderFunc = createSecureRandomReader
 )
 
@@ -40,6 +44,10 @@ type Entropy struct {
 	Mode EntropyMode
 }
 
+// maxSeals is the maximum number of seal blocks: the seals combined in a seal
+// that fails over between them, and the seal migrated from
+const maxSeals = 5
+
 // KMS contains KMS configuration for the server
 type KMS struct {
 	UnusedKeys []string `hcl:",unusedKeys"`
@@ -50,6 +58,9 @@ type KMS struct {
 	Purpose []string `hcl:"-"`
 
 	Disabled bool
+	// Priority orders the enabled seals combined in a single seal that fails
+	// over between them; seals with a lower value are tried first
+	Priority int
 	Config   map[string]string
 }
 
@@ -59,7 +70,7 @@ func (k *KMS) GoString() string {
 
 func parseKMS(result *[]*KMS, list *ast.ObjectList, blockName string, maxKMS int) error {
 	if len(list.Items) > maxKMS {
-		return fmt.Errorf("only two or less %q blocks are permitted", blockName)
+		return fmt.Errorf("only %d or less %q blocks are permitted", maxKMS, blockName)
 	}
 
 	seals := make([]*KMS, 0, len(list.Items))
@@ -98,6 +109,18 @@ func parseKMS(result *[]*KMS, list *ast.ObjectList, blockName string, maxKMS int
 			delete(m, "disabled")
 		}
 
+		var priority int64
+		if v, ok := m["priority"]; ok {
+			priority, err = parseutil.ParseInt(v)
+			if err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("%s.%s:", blockName, key))
+			}
+			if priority < 1 {
+				return multierror.Prefix(errors.New("'priority' must be at least 1"), fmt.Sprintf("%s.%s:", blockName, key))
+			}
+			delete(m, "priority")
+		}
+
 		strMap := make(map[string]string, len(m))
 		for k, v := range m {
 			s, err := parseutil.ParseString(v)
@@ -111,6 +134,7 @@ func parseKMS(result *[]*KMS, list *ast.ObjectList, blockName string, maxKMS int
 			Type:     strings.ToLower(key),
 			Purpose:  purpose,
 			Disabled: disabled,
+			Priority: int(priority),
 		}
 		if len(strMap) > 0 {
 			seal.Config = strMap
@@ -145,7 +169,7 @@ func ParseKMSes(d string) ([]*KMS, error) {
 	}
 
 	if o := list.Filter("seal"); len(o.Items) > 0 {
-		if err := parseKMS(&result.Seals, o, "seal", 3); err != nil {
+		if err := parseKMS(&result.Seals, o, "seal", maxSeals); err != nil {
 			return nil, fmt.Errorf("error parsing 'seal': %w", err)
 		}
 	}
@@ -193,6 +217,9 @@ func configureWrapper(configKMS *KMS, infoKeys *[]string, info *map[string]strin
 	case wrapping.Transit:
 		wrapper, kmsInfo, err = GetTransitKMSFunc(opts, configKMS)
 
+	case tpm.WrapperType:
+		wrapper, kmsInfo, err = GetTPMKMSFunc(opts, configKMS)
+
 	case wrapping.PKCS11:
 		return nil, fmt.Errorf("KMS type 'pkcs11' requires the Vault Enterprise HSM binary")
 
@@ -214,6 +241,46 @@ func configureWrapper(configKMS *KMS, infoKeys *[]string, info *map[string]strin
 	return wrapper, nil
 }
 
+// configureFailoverWrapper configures each of the KMSes, and combines them in
+// a wrapper that fails over between them by priority. The KMSes that can't be
+// configured are skipped, as long as one of them can be.
+func configureFailoverWrapper(configKMSes []*KMS, infoKeys *[]string, info *map[string]string, logger hclog.Logger) (wrapping.Wrapper, error) {
+	var members []*multi.Member
+	var errs *multierror.Error
+	for _, configKMS := range configKMSes {
+		if configKMS.Type == wrapping.Shamir {
+			return nil, errors.New("the shamir seal cannot be combined with other seals")
+		}
+
+		var kmsInfoKeys []string
+		kmsInfo := map[string]string{}
+		wrapper, err := configureWrapper(configKMS, &kmsInfoKeys, &kmsInfo, logger.Named(configKMS.Type))
+		if err != nil {
+			logger.Warn("failed to configure seal, skipping it", "seal_type", configKMS.Type, "priority", configKMS.Priority, "error", err)
+			errs = multierror.Append(errs, fmt.Errorf("%s seal with priority %d: %w", configKMS.Type, configKMS.Priority, err))
+			continue
+		}
+		members = append(members, &multi.Member{
+			Wrapper:  wrapper,
+			Priority: configKMS.Priority,
+		})
+
+		if infoKeys != nil && info != nil {
+			for _, k := range kmsInfoKeys {
+				key := fmt.Sprintf("%s (Priority %d)", k, configKMS.Priority)
+				*infoKeys = append(*infoKeys, key)
+				(*info)[key] = kmsInfo[k]
+			}
+		}
+	}
+
+	if len(members) == 0 {
+		return nil, fmt.Errorf("error configuring any of the seals: %w", errs)
+	}
+
+	return multi.NewWrapper(&wrapping.WrapperOptions{Logger: logger}, members), nil
+}
+
 func GetAEADKMSFunc(opts *wrapping.WrapperOptions, kms *KMS) (wrapping.Wrapper, map[string]string, error) {
 	wrapper := aeadwrapper.NewWrapper(opts)
 	wrapperInfo, err := wrapper.SetConfig(kms.Config)
@@ -345,6 +412,23 @@ var GetTransitKMSFunc = func(opts *wrapping.WrapperOptions, kms *KMS) (wrapping.
 	return wrapper, info, nil
 }
 
+func GetTPMKMSFunc(opts *wrapping.WrapperOptions, kms *KMS) (wrapping.Wrapper, map[string]string, error) {
+	wrapper := tpm.NewWrapper(opts)
+	wrapperInfo, err := wrapper.SetConfig(kms.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := make(map[string]string)
+	if wrapperInfo != nil {
+		info["TPM Device"] = wrapperInfo["device"]
+		info["TPM Key ID"] = wrapperInfo["key_id"]
+		if pcrs, ok := wrapperInfo["pcrs"]; ok {
+			info["TPM PCRs"] = fmt.Sprintf("%s (%s)", pcrs, wrapperInfo["pcr_bank"])
+		}
+	}
+	return wrapper, info, nil
+}
+
 func createSecureRandomReader(conf *SharedConfig, wrapper wrapping.Wrapper) (io.Reader, error) {
 	return rand.Reader, nil
 }
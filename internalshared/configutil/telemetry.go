@@ -15,6 +15,7 @@ import (
 	"github.com/armon/go-metrics/prometheus"
 	stackdriver "github.com/google/go-metrics-stackdriver"
 	stackdrivervault "github.com/google/go-metrics-stackdriver/vault"
+	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
@@ -142,6 +143,24 @@ type Telemetry struct {
 	// StackdriverDebugLogs will write additional stackdriver related debug logs to stderr.
 	StackdriverDebugLogs bool `hcl:"stackdriver_debug_logs"`
 
+	// OTLP:
+	// OTLPEndpoint is the URL of an OpenTelemetry collector to push metrics to.
+	OTLPEndpoint string `hcl:"otlp_endpoint"`
+	// OTLPProtocol is either "http/protobuf" or "grpc".
+	// Default: "http/protobuf"
+	OTLPProtocol string `hcl:"otlp_protocol"`
+	// OTLPExportInterval is how often metrics are pushed to the collector.
+	// Default: 1m
+	OTLPExportInterval    time.Duration `hcl:"-"`
+	OTLPExportIntervalRaw interface{}   `hcl:"otlp_export_interval"`
+	// OTLPHeaders are sent with every push, for instance for authentication.
+	OTLPHeaders map[string]string `hcl:"otlp_headers"`
+	// OTLPResourceAttributes are added to the attributes of the resource the
+	// metrics belong to.
+	OTLPResourceAttributes map[string]string `hcl:"otlp_resource_attributes"`
+	// OTLPTLSCAFile is the CA certificate file used to verify the collector.
+	OTLPTLSCAFile string `hcl:"otlp_tls_ca_file"`
+
 	// How often metrics for lease expiry will be aggregated
 	LeaseMetricsEpsilon    time.Duration
 	LeaseMetricsEpsilonRaw interface{} `hcl:"lease_metrics_epsilon"`
@@ -195,6 +214,22 @@ func parseTelemetry(result *SharedConfig, list *ast.ObjectList) error {
 		result.Telemetry.PrometheusRetentionTime = PrometheusDefaultRetentionTime
 	}
 
+	if result.Telemetry.OTLPExportIntervalRaw != nil {
+		var err error
+		if result.Telemetry.OTLPExportInterval, err = parseutil.ParseDurationSecond(result.Telemetry.OTLPExportIntervalRaw); err != nil {
+			return err
+		}
+		result.Telemetry.OTLPExportIntervalRaw = nil
+	} else {
+		result.Telemetry.OTLPExportInterval = metricsutil.OTLPDefaultExportInterval
+	}
+
+	switch result.Telemetry.OTLPProtocol {
+	case "", metricsutil.OTLPProtocolHTTPProtobuf, metricsutil.OTLPProtocolGRPC:
+	default:
+		return fmt.Errorf("invalid otlp_protocol %q, must be %q or %q", result.Telemetry.OTLPProtocol, metricsutil.OTLPProtocolHTTPProtobuf, metricsutil.OTLPProtocolGRPC)
+	}
+
 	if result.Telemetry.UsageGaugePeriodRaw != nil {
 		if result.Telemetry.UsageGaugePeriodRaw == "none" {
 			result.Telemetry.UsageGaugePeriod = 0
@@ -241,6 +276,7 @@ type SetupTelemetryOpts struct {
 	DisplayName string
 	UserAgent   string
 	ClusterName string
+	Logger      log.Logger
 }
 
 // SetupTelemetry is used to setup the telemetry sub-systems and returns the
@@ -288,6 +324,10 @@ func SetupTelemetry(opts *SetupTelemetryOpts) (*metrics.InmemSink, *metricsutil.
 			return nil, nil, false, err
 		}
 		fanout = append(fanout, sink)
+
+		if err := metricsutil.EnableRequestDurationHistogram(opts.ServiceName); err != nil {
+			return nil, nil, false, err
+		}
 	}
 
 	if opts.Config.StatsiteAddr != "" {
@@ -376,6 +416,24 @@ func SetupTelemetry(opts *SetupTelemetryOpts) (*metrics.InmemSink, *metricsutil.
 		fanout = append(fanout, sink)
 	}
 
+	// Configure the OTLP sink
+	if opts.Config.OTLPEndpoint != "" {
+		sink, err := metricsutil.NewOTLPSink(&metricsutil.OTLPSinkConfig{
+			Endpoint:           opts.Config.OTLPEndpoint,
+			Protocol:           opts.Config.OTLPProtocol,
+			ExportInterval:     opts.Config.OTLPExportInterval,
+			Headers:            opts.Config.OTLPHeaders,
+			ResourceAttributes: opts.Config.OTLPResourceAttributes,
+			TLSCAFile:          opts.Config.OTLPTLSCAFile,
+			ServiceName:        opts.ServiceName,
+			Logger:             opts.Logger,
+		})
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("failed to start OTLP sink: %w", err)
+		}
+		fanout = append(fanout, sink)
+	}
+
 	// Initialize the global sink
 	if len(fanout) > 1 {
 		// Hostname enabled will create poor quality metrics name for prometheus
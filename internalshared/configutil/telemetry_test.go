@@ -2,6 +2,7 @@ package configutil
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -51,3 +52,37 @@ func TestParsePrefixFilters(t *testing.T) {
 		}
 	})
 }
+
+func TestParseTelemetry_OTLP(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig(`
+telemetry {
+  otlp_endpoint        = "https://otel-collector:4317"
+  otlp_protocol        = "grpc"
+  otlp_export_interval = "30s"
+  otlp_headers = {
+    "x-api-key" = "foo"
+  }
+  otlp_resource_attributes = {
+    "deployment.environment" = "prod"
+  }
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "https://otel-collector:4317", config.Telemetry.OTLPEndpoint)
+	assert.Equal(t, "grpc", config.Telemetry.OTLPProtocol)
+	assert.Equal(t, 30*time.Second, config.Telemetry.OTLPExportInterval)
+	assert.Equal(t, map[string]string{"x-api-key": "foo"}, config.Telemetry.OTLPHeaders)
+	assert.Equal(t, map[string]string{"deployment.environment": "prod"}, config.Telemetry.OTLPResourceAttributes)
+	assert.Equal(t, "", config.Sanitized()["telemetry"].(map[string]interface{})["otlp_headers"])
+
+	_, err = ParseConfig(`
+telemetry {
+  otlp_endpoint = "http://otel-collector:4318"
+  otlp_protocol = "http/json"
+}`)
+	assert.EqualError(t, err, `error parsing 'telemetry': invalid otlp_protocol "http/json", must be "http/protobuf" or "grpc"`)
+}
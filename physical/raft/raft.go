@@ -1212,6 +1212,12 @@ func (b *RaftBackend) RestoreSnapshot(ctx context.Context, metadata raft.Snapsho
 		return err
 	}
 
+	return b.applyRestoreCallback(ctx)
+}
+
+// applyRestoreCallback applies a log telling every node to run the restore
+// callback function. Caller should hold the backend's read lock.
+func (b *RaftBackend) applyRestoreCallback(ctx context.Context) error {
 	// Apply a log that tells the follower nodes to run the restore callback
 	// function. This is done after the restore call so we can be sure the
 	// snapshot applied to a quorum of nodes.
@@ -0,0 +1,602 @@
+package raft
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/raft"
+	snapshot "github.com/hashicorp/raft-snapshot"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/plugin/pb"
+	"github.com/hashicorp/vault/vault/seal"
+	bolt "go.etcd.io/bbolt"
+)
+
+// A delta snapshot is a gzipped tar file holding the changes made to the
+// storage since a previous snapshot, its parent:
+//
+// delta.json        - JSON-encoded DeltaMetadata
+// delta.bin         - Delimited LogOperation messages putting and deleting keys
+// SHA256SUMS        - SHA-256 sums of the above two files
+// SHA256SUMS.sealed - SHA256SUMS encrypted with the seal, if available
+//
+// The file names differ from the ones of a full snapshot so a delta can never
+// be mistaken for one and restored on its own.
+const (
+	deltaMetaFile   = "delta.json"
+	deltaDataFile   = "delta.bin"
+	deltaSumsFile   = "SHA256SUMS"
+	deltaSealedFile = "SHA256SUMS.sealed"
+)
+
+// DeltaMetadata describes a delta snapshot.
+type DeltaMetadata struct {
+	// Parent is the ID of the snapshot this delta applies on top of.
+	Parent string `json:"parent"`
+
+	// Index and Term are the raft index and term of the storage when the
+	// delta was taken.
+	Index uint64 `json:"index"`
+	Term  uint64 `json:"term"`
+
+	Created time.Time `json:"created"`
+	Puts    int       `json:"puts"`
+	Deletes int       `json:"deletes"`
+}
+
+// DeltaManifest maps each key of the storage to the SHA-256 sum of its value.
+// It describes the state of the storage at a given snapshot so that a delta
+// against it can be computed without sending the snapshot itself.
+type DeltaManifest map[string][sha256.Size]byte
+
+// NewDeltaManifest reads a snapshot chain, a full snapshot followed by the
+// deltas taken after it in order, and returns the manifest of the storage at
+// the end of the chain along with the ID of the last snapshot. The integrity
+// of every snapshot and the linkage of the chain are verified, but as no seal
+// is available the sealed hashes are not.
+func NewDeltaManifest(base io.Reader, deltas ...io.Reader) (DeltaManifest, string, error) {
+	manifest := make(DeltaManifest)
+
+	h := sha256.New()
+	in := io.TeeReader(base, h)
+	err := pipeSnapshotData(func(w io.Writer) error {
+		_, err := snapshot.Parse(in, w)
+		return err
+	}, func(r io.Reader) error {
+		protoReader := NewDelimitedReader(r, math.MaxInt32)
+		entry := new(pb.StorageEntry)
+		for {
+			if err := protoReader.ReadMsg(entry); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			manifest[entry.Key] = sha256.Sum256(entry.Value)
+		}
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read base snapshot: %w", err)
+	}
+	if _, err := io.Copy(ioutil.Discard, in); err != nil {
+		return nil, "", fmt.Errorf("failed to read base snapshot: %w", err)
+	}
+	id := hex.EncodeToString(h.Sum(nil))
+
+	for i, delta := range deltas {
+		h := sha256.New()
+		in := io.TeeReader(delta, h)
+
+		var metadata *DeltaMetadata
+		err := pipeSnapshotData(func(w io.Writer) error {
+			var err error
+			metadata, err = readDelta(in, nil, w)
+			return err
+		}, func(r io.Reader) error {
+			return readDeltaOperations(r, func(op *LogOperation) error {
+				switch op.OpType {
+				case putOp:
+					manifest[op.Key] = sha256.Sum256(op.Value)
+				case deleteOp:
+					delete(manifest, op.Key)
+				default:
+					return fmt.Errorf("unexpected operation %d", op.OpType)
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read delta snapshot %d: %w", i+1, err)
+		}
+		if metadata.Parent != id {
+			return nil, "", fmt.Errorf("delta snapshot %d does not apply to the snapshot before it in the chain", i+1)
+		}
+		if _, err := io.Copy(ioutil.Discard, in); err != nil {
+			return nil, "", fmt.Errorf("failed to read delta snapshot %d: %w", i+1, err)
+		}
+		id = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return manifest, id, nil
+}
+
+// Encode writes the manifest as a gzipped stream of delimited StorageEntry
+// messages whose values are the sums of the original values.
+func (m DeltaManifest) Encode(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	protoWriter := NewDelimitedWriter(gz)
+	for key, sum := range m {
+		if err := protoWriter.WriteMsg(&pb.StorageEntry{
+			Key:   key,
+			Value: sum[:],
+		}); err != nil {
+			return err
+		}
+	}
+	return gz.Close()
+}
+
+// DecodeDeltaManifest reads a manifest written by DeltaManifest.Encode.
+func DecodeDeltaManifest(r io.Reader) (DeltaManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress manifest: %w", err)
+	}
+	defer gz.Close()
+
+	manifest := make(DeltaManifest)
+	protoReader := NewDelimitedReader(gz, math.MaxInt32)
+	entry := new(pb.StorageEntry)
+	for {
+		if err := protoReader.ReadMsg(entry); err != nil {
+			if err == io.EOF {
+				return manifest, nil
+			}
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		if len(entry.Value) != sha256.Size {
+			return nil, fmt.Errorf("invalid sum for key %q in manifest", entry.Key)
+		}
+		var sum [sha256.Size]byte
+		copy(sum[:], entry.Value)
+		manifest[entry.Key] = sum
+	}
+}
+
+// writeDeltaTo writes to the sink the operations bringing the storage
+// described by the manifest to the current state of the FSM. The data bucket
+// and the latest index are read in the same transaction so they are
+// consistent with each other.
+func (f *FSM) writeDeltaTo(manifest DeltaManifest, sink io.Writer) (*DeltaMetadata, error) {
+	defer metrics.MeasureSince([]string{"raft_storage", "fsm", "write_delta_snapshot"}, time.Now())
+
+	protoWriter := NewDelimitedWriter(sink)
+	metadata := new(DeltaMetadata)
+
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	err := f.db.View(func(tx *bolt.Tx) error {
+		if val := tx.Bucket(configBucketName).Get(latestIndexKey); val != nil {
+			var latest IndexValue
+			if err := proto.Unmarshal(val, &latest); err != nil {
+				return err
+			}
+			metadata.Index = latest.Index
+			metadata.Term = latest.Term
+		}
+
+		b := tx.Bucket(dataBucketName)
+
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if sum, ok := manifest[string(k)]; ok && sum == sha256.Sum256(v) {
+				continue
+			}
+			err := protoWriter.WriteMsg(&LogOperation{
+				OpType: putOp,
+				Key:    string(k),
+				Value:  v,
+			})
+			if err != nil {
+				return err
+			}
+			metadata.Puts++
+		}
+
+		for key := range manifest {
+			if b.Get([]byte(key)) != nil {
+				continue
+			}
+			err := protoWriter.WriteMsg(&LogOperation{
+				OpType: deleteOp,
+				Key:    key,
+			})
+			if err != nil {
+				return err
+			}
+			metadata.Deletes++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// SnapshotDeltaHTTP is a wrapper for SnapshotDelta that sets the correct
+// HTTP headers.
+func (b *RaftBackend) SnapshotDeltaHTTP(out *logical.HTTPResponseWriter, parent string, manifest DeltaManifest, access *seal.Access) error {
+	out.Header().Add("Content-Disposition", "attachment")
+	out.Header().Add("Content-Type", "application/gzip")
+
+	return b.SnapshotDelta(out, parent, manifest, access)
+}
+
+// SnapshotDelta writes to the provided writer a delta snapshot archive holding
+// the changes made since the parent snapshot, whose storage is described by
+// the manifest. As with full snapshots, seal access is used to encrypt the
+// SHASUM file so the delta can only be restored with the same root keys.
+func (b *RaftBackend) SnapshotDelta(out io.Writer, parent string, manifest DeltaManifest, access *seal.Access) error {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.raft == nil {
+		return errors.New("raft storage is sealed")
+	}
+
+	// If we have access to the seal create a sealer object
+	var s snapshot.Sealer
+	if access != nil {
+		s = &sealer{
+			access: access,
+		}
+	}
+
+	// The size of the operations has to be known before they can be added to
+	// the archive so they are staged in a temporary file.
+	data, err := ioutil.TempFile("", "delta")
+	if err != nil {
+		return fmt.Errorf("failed to create temp delta file: %w", err)
+	}
+	defer func() {
+		data.Close()
+		os.Remove(data.Name())
+	}()
+
+	buffered := bufio.NewWriter(data)
+	metadata, err := b.fsm.writeDeltaTo(manifest, buffered)
+	if err != nil {
+		return fmt.Errorf("failed to compute delta: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write delta: %w", err)
+	}
+	size, err := data.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	metadata.Parent = parent
+	metadata.Created = time.Now().UTC()
+
+	b.logger.Named("snapshot").Info("writing delta snapshot", "parent", parent, "index", metadata.Index, "puts", metadata.Puts, "deletes", metadata.Deletes)
+
+	return writeDelta(out, metadata, bufio.NewReader(data), size, s)
+}
+
+// WriteDeltaToTemp reads a delta snapshot archive off the provided reader,
+// verifies it and writes its operations to a temporary file to be applied with
+// RestoreSnapshotChain. As with WriteSnapshotToTemp, the seal access is used to
+// check the delta was taken with the same root key as the running instance and
+// the check is skipped when it is nil.
+func (b *RaftBackend) WriteDeltaToTemp(in io.Reader, access *seal.Access) (*os.File, func(), *DeltaMetadata, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.raft == nil {
+		return nil, nil, nil, errors.New("raft storage is sealed")
+	}
+
+	// If we have access to the seal create a sealer object
+	var s snapshot.Sealer
+	if access != nil {
+		s = &sealer{
+			access: access,
+		}
+	}
+
+	data, err := ioutil.TempFile("", "delta")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create temp delta file: %w", err)
+	}
+	cleanup := func() {
+		data.Close()
+		os.Remove(data.Name())
+	}
+
+	buffered := bufio.NewWriter(data)
+	metadata, err := readDelta(in, s, buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err == nil {
+		_, err = data.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+
+	return data, cleanup, metadata, nil
+}
+
+// RestoreSnapshotChain restores the full snapshot and then applies the deltas,
+// written by WriteDeltaToTemp, on top of it. The operations of the deltas go
+// through the raft log in batches so they are replicated like any other
+// write.
+func (b *RaftBackend) RestoreSnapshotChain(ctx context.Context, metadata raft.SnapshotMeta, snap io.Reader, deltas ...io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.raft == nil {
+		return errors.New("raft storage is not initialized")
+	}
+
+	if err := b.raft.Restore(&metadata, snap, 0); err != nil {
+		b.logger.Named("snapshot").Error("failed to restore snapshot", "error", err)
+		return err
+	}
+
+	for i, delta := range deltas {
+		if err := b.applyDelta(ctx, delta); err != nil {
+			b.logger.Named("snapshot").Error("failed to apply delta snapshot", "delta", i+1, "error", err)
+			return fmt.Errorf("failed to apply delta snapshot %d: %w", i+1, err)
+		}
+	}
+
+	return b.applyRestoreCallback(ctx)
+}
+
+// applyDelta applies the operations of a delta through the raft log, batching
+// as many of them as fit in a single entry. Caller should hold the backend's
+// read lock.
+func (b *RaftBackend) applyDelta(ctx context.Context, delta io.Reader) error {
+	command := &LogData{}
+	var size uint64
+	flush := func() error {
+		if len(command.Operations) == 0 {
+			return nil
+		}
+		if err := b.applyLog(ctx, command); err != nil {
+			return err
+		}
+		command = &LogData{}
+		size = 0
+		return nil
+	}
+
+	err := readDeltaOperations(delta, func(op *LogOperation) error {
+		if op.OpType != putOp && op.OpType != deleteOp {
+			return fmt.Errorf("unexpected operation %d", op.OpType)
+		}
+
+		// Leave some room for the framing of the operations in the entry
+		opSize := uint64(proto.Size(op)) + 16
+		if size+opSize > b.maxEntrySize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		command.Operations = append(command.Operations, op)
+		size += opSize
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// readDeltaOperations calls fn with every operation in the delta data,
+// allocating a new operation each time so they can be retained.
+func readDeltaOperations(r io.Reader, fn func(*LogOperation) error) error {
+	protoReader := NewDelimitedReader(ioutil.NopCloser(r), math.MaxInt32)
+	for {
+		op := new(LogOperation)
+		if err := protoReader.ReadMsg(op); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := fn(op); err != nil {
+			return err
+		}
+	}
+}
+
+// pipeSnapshotData runs parse in the background and hands the data it writes
+// to consume, so the content of an archive can be processed while it is read
+// and verified.
+func pipeSnapshotData(parse func(io.Writer) error, consume func(io.Reader) error) error {
+	r, w := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		err := parse(w)
+		w.CloseWithError(err)
+		errCh <- err
+	}()
+
+	err := consume(r)
+	if err != nil {
+		r.CloseWithError(err)
+	} else {
+		// Let parse reach the end of the archive and verify it
+		_, err = io.Copy(ioutil.Discard, r)
+	}
+	if parseErr := <-errCh; parseErr != nil {
+		return parseErr
+	}
+	return err
+}
+
+// writeDelta creates a delta snapshot archive with the metadata, the
+// operations and the sums used to check their integrity.
+func writeDelta(out io.Writer, metadata *DeltaMetadata, data io.Reader, size int64, sealer snapshot.Sealer) error {
+	now := time.Now()
+	gz := gzip.NewWriter(out)
+	archive := tar.NewWriter(gz)
+
+	writeFile := func(name string, size int64, r io.Reader) error {
+		if err := archive.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0o600,
+			Size:    size,
+			ModTime: now,
+		}); err != nil {
+			return fmt.Errorf("failed to write delta %s header: %w", name, err)
+		}
+		if _, err := io.CopyN(archive, r, size); err != nil {
+			return fmt.Errorf("failed to write delta %s: %w", name, err)
+		}
+		return nil
+	}
+
+	metaBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode delta metadata: %w", err)
+	}
+	metaHash := sha256.New()
+	if err := writeFile(deltaMetaFile, int64(len(metaBytes)), io.TeeReader(bytes.NewReader(metaBytes), metaHash)); err != nil {
+		return err
+	}
+
+	dataHash := sha256.New()
+	if err := writeFile(deltaDataFile, size, io.TeeReader(data, dataHash)); err != nil {
+		return err
+	}
+
+	sums := []byte(fmt.Sprintf("%x  %s\n%x  %s\n", metaHash.Sum(nil), deltaMetaFile, dataHash.Sum(nil), deltaDataFile))
+	if err := writeFile(deltaSumsFile, int64(len(sums)), bytes.NewReader(sums)); err != nil {
+		return err
+	}
+
+	if sealer != nil {
+		sealed, err := sealer.Seal(context.Background(), sums)
+		if err != nil {
+			return fmt.Errorf("failed to seal delta hashes: %w", err)
+		}
+		if err := writeFile(deltaSealedFile, int64(len(sealed)), bytes.NewReader(sealed)); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finalize delta: %w", err)
+	}
+	return gz.Close()
+}
+
+// readDelta reads a delta snapshot archive, writing its operations to out and
+// checking their integrity. When a sealer is given the sealed hashes must be
+// present and match as well.
+func readDelta(in io.Reader, sealer snapshot.Sealer, out io.Writer) (*DeltaMetadata, error) {
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress delta: %w", err)
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+
+	var metadata *DeltaMetadata
+	metaHash := sha256.New()
+	dataHash := sha256.New()
+	var sums, sealedSums []byte
+	for {
+		hdr, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed reading delta: %w", err)
+		}
+
+		switch hdr.Name {
+		case deltaMetaFile:
+			metaBytes, err := ioutil.ReadAll(io.LimitReader(archive, 8192))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read delta metadata: %w", err)
+			}
+			metaHash.Write(metaBytes)
+			if err := json.Unmarshal(metaBytes, &metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode delta metadata: %w", err)
+			}
+
+		case deltaDataFile:
+			if _, err := io.Copy(io.MultiWriter(out, dataHash), archive); err != nil {
+				return nil, fmt.Errorf("failed to read or write delta data: %w", err)
+			}
+
+		case deltaSumsFile:
+			if sums, err = ioutil.ReadAll(io.LimitReader(archive, 8192)); err != nil {
+				return nil, fmt.Errorf("failed to read delta hashes: %w", err)
+			}
+
+		case deltaSealedFile:
+			if sealedSums, err = ioutil.ReadAll(io.LimitReader(archive, 8192)); err != nil {
+				return nil, fmt.Errorf("failed to read delta hashes: %w", err)
+			}
+
+		default:
+			return nil, fmt.Errorf("unexpected file %q in delta", hdr.Name)
+		}
+	}
+
+	if metadata == nil {
+		return nil, fmt.Errorf("missing %s in delta", deltaMetaFile)
+	}
+
+	expected := []byte(fmt.Sprintf("%x  %s\n%x  %s\n", metaHash.Sum(nil), deltaMetaFile, dataHash.Sum(nil), deltaDataFile))
+	if sealer != nil {
+		opened, err := sealer.Open(context.Background(), sealedSums)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the sealed hashes: %w", err)
+		}
+		if !bytes.Equal(opened, expected) {
+			return nil, errors.New("failed checking integrity of delta: sealed hashes do not match")
+		}
+	}
+	if !bytes.Equal(sums, expected) {
+		return nil, errors.New("failed checking integrity of delta: hashes do not match")
+	}
+
+	return metadata, nil
+}
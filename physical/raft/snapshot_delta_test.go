@@ -0,0 +1,164 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+func TestRaft_Snapshot_Delta(t *testing.T) {
+	raft1, dir := getRaft(t, true, false)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	put := func(key, value string) {
+		t.Helper()
+		if err := raft1.Put(ctx, &physical.Entry{Key: key, Value: []byte(value)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	del := func(key string) {
+		t.Helper()
+		if err := raft1.Delete(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshotID := func(b []byte) string {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:])
+	}
+
+	for i := 0; i < 100; i++ {
+		put(fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i))
+	}
+
+	var base bytes.Buffer
+	if err := raft1.Snapshot(&base, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, parent, err := NewDeltaManifest(bytes.NewReader(base.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 100 {
+		t.Fatalf("expected 100 keys in the manifest, got %d", len(manifest))
+	}
+	if parent != snapshotID(base.Bytes()) {
+		t.Fatalf("unexpected parent %q", parent)
+	}
+
+	// The manifest is sent over the wire
+	var encoded bytes.Buffer
+	if err := manifest.Encode(&encoded); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err = DecodeDeltaManifest(&encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		put(fmt.Sprintf("key-%d", i), "updated")
+	}
+	for i := 10; i < 20; i++ {
+		del(fmt.Sprintf("key-%d", i))
+	}
+
+	var delta1 bytes.Buffer
+	if err := raft1.SnapshotDelta(&delta1, parent, manifest, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := readDelta(bytes.NewReader(delta1.Bytes()), nil, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Parent != parent || metadata.Puts != 10 || metadata.Deletes != 10 {
+		t.Fatalf("unexpected delta metadata %#v", metadata)
+	}
+
+	manifest, parent, err = NewDeltaManifest(bytes.NewReader(base.Bytes()), bytes.NewReader(delta1.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != 90 {
+		t.Fatalf("expected 90 keys in the manifest, got %d", len(manifest))
+	}
+
+	for i := 100; i < 110; i++ {
+		put(fmt.Sprintf("key-%d", i), fmt.Sprintf("value-%d", i))
+	}
+
+	var delta2 bytes.Buffer
+	if err := raft1.SnapshotDelta(&delta2, parent, manifest, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deltas must be given in order
+	_, _, err = NewDeltaManifest(bytes.NewReader(base.Bytes()), bytes.NewReader(delta2.Bytes()))
+	if err == nil || !strings.Contains(err.Error(), "does not apply") {
+		t.Fatalf("expected the chain to be rejected, got %v", err)
+	}
+
+	// A delta can't be restored as a full snapshot
+	if _, _, _, err := raft1.WriteSnapshotToTemp(ioutil.NopCloser(bytes.NewReader(delta1.Bytes())), nil); err == nil {
+		t.Fatal("expected the delta to be rejected as a full snapshot")
+	}
+
+	// Write some more data that the restore should discard
+	for i := 0; i < 120; i++ {
+		put(fmt.Sprintf("key-%d", i), "discarded")
+	}
+
+	snapFile, cleanup, snapMetadata, err := raft1.WriteSnapshotToTemp(ioutil.NopCloser(bytes.NewReader(base.Bytes())), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	var deltas []io.Reader
+	for _, delta := range []*bytes.Buffer{&delta1, &delta2} {
+		deltaFile, deltaCleanup, _, err := raft1.WriteDeltaToTemp(delta, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer deltaCleanup()
+		deltas = append(deltas, deltaFile)
+	}
+
+	if err := raft1.RestoreSnapshotChain(ctx, snapMetadata, snapFile, deltas...); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 120; i++ {
+		var expected string
+		switch {
+		case i < 10:
+			expected = "updated"
+		case i < 20, i >= 110:
+		default:
+			expected = fmt.Sprintf("value-%d", i)
+		}
+
+		entry, err := raft1.Get(ctx, fmt.Sprintf("key-%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case expected == "" && entry != nil:
+			t.Fatalf("expected key-%d to be deleted", i)
+		case expected != "" && (entry == nil || string(entry.Value) != expected):
+			t.Fatalf("expected key-%d to be %q, got %#v", i, expected, entry)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/physical"
+
+	// The SQLite driver requires cgo
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Verify SQLiteBackend satisfies the correct interfaces
+var (
+	_ physical.Backend       = (*SQLiteBackend)(nil)
+	_ physical.Transactional = (*SQLiteBackend)(nil)
+)
+
+const (
+	defaultTableName = "vault_kv_store"
+
+	// defaultBusyTimeout is how long a write waits for the lock of the
+	// database, held by another connection, before failing.
+	defaultBusyTimeout = 5 * time.Second
+)
+
+// SQLiteBackend is a physical backend that stores data in a single SQLite
+// database file. It can be used for durable single server situations, or to
+// develop locally.
+type SQLiteBackend struct {
+	table      string
+	client     *sql.DB
+	statements map[string]*sql.Stmt
+	logger     log.Logger
+	permitPool *physical.PermitPool
+}
+
+// NewSQLiteBackend constructs a SQLite backend storing its data in the
+// database file at the given path, which is created if it does not exist.
+func NewSQLiteBackend(conf map[string]string, logger log.Logger) (physical.Backend, error) {
+	path, ok := conf["path"]
+	if !ok || path == "" {
+		return nil, fmt.Errorf("'path' must be set")
+	}
+
+	dbTable := conf["table"]
+	if dbTable == "" {
+		dbTable = defaultTableName
+	}
+	if err := validateDBTable(dbTable); err != nil {
+		return nil, fmt.Errorf("invalid table: %w", err)
+	}
+
+	var err error
+	maxParInt := physical.DefaultParallelOperations
+	if maxParStr, ok := conf["max_parallel"]; ok {
+		maxParInt, err = strconv.Atoi(maxParStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing max_parallel parameter: %w", err)
+		}
+		if logger.IsDebug() {
+			logger.Debug("max_parallel set", "max_parallel", maxParInt)
+		}
+	}
+
+	busyTimeout := defaultBusyTimeout
+	if busyTimeoutStr, ok := conf["busy_timeout"]; ok {
+		busyTimeout, err = time.ParseDuration(busyTimeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing busy_timeout parameter: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the directory of the database file: %w", err)
+	}
+
+	// The write-ahead log lets reads run alongside a write, and a full sync
+	// makes every committed write durable, like the file backend. Write
+	// transactions take the database lock when they begin, so that they
+	// wait for each other for up to the busy timeout instead of failing.
+	params := url.Values{}
+	params.Set("_journal_mode", "WAL")
+	params.Set("_synchronous", "FULL")
+	params.Set("_busy_timeout", strconv.FormatInt(busyTimeout.Milliseconds(), 10))
+	params.Set("_txlock", "immediate")
+	db, err := sql.Open("sqlite3", sqliteDSN(path, params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	// Create the required table if it doesn't exists.
+	createQuery := "CREATE TABLE IF NOT EXISTS " + dbTable +
+		" (path TEXT NOT NULL PRIMARY KEY, value BLOB) WITHOUT ROWID"
+	if _, err := db.Exec(createQuery); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite table: %w", err)
+	}
+
+	s := &SQLiteBackend{
+		table:      dbTable,
+		client:     db,
+		statements: make(map[string]*sql.Stmt),
+		logger:     logger,
+		permitPool: physical.NewPermitPool(maxParInt),
+	}
+
+	// Prepare all the statements required
+	statements := map[string]string{
+		"put": "INSERT INTO " + dbTable + " (path, value) VALUES (?, ?)" +
+			" ON CONFLICT (path) DO UPDATE SET value = excluded.value",
+		"get":    "SELECT value FROM " + dbTable + " WHERE path = ?",
+		"delete": "DELETE FROM " + dbTable + " WHERE path = ?",
+		// Prefixes are matched with a range rather than LIKE, which is case
+		// insensitive and treats % and _ as wildcards.
+		"list":     "SELECT path FROM " + dbTable + " WHERE path >= ? AND path < ? ORDER BY path",
+		"list_all": "SELECT path FROM " + dbTable + " WHERE path >= ? ORDER BY path",
+	}
+	for name, query := range statements {
+		if err := s.prepare(name, query); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// prepare is a helper to prepare a query for future execution
+func (s *SQLiteBackend) prepare(name, query string) error {
+	stmt, err := s.client.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare %q: %w", name, err)
+	}
+	s.statements[name] = stmt
+	return nil
+}
+
+// Put is used to insert or update an entry.
+func (s *SQLiteBackend) Put(ctx context.Context, entry *physical.Entry) error {
+	defer metrics.MeasureSince([]string{"sqlite", "put"}, time.Now())
+
+	s.permitPool.Acquire()
+	defer s.permitPool.Release()
+
+	_, err := s.statements["put"].ExecContext(ctx, entry.Key, entry.Value)
+	return err
+}
+
+// Get is used to fetch an entry.
+func (s *SQLiteBackend) Get(ctx context.Context, key string) (*physical.Entry, error) {
+	defer metrics.MeasureSince([]string{"sqlite", "get"}, time.Now())
+
+	s.permitPool.Acquire()
+	defer s.permitPool.Release()
+
+	var result []byte
+	err := s.statements["get"].QueryRowContext(ctx, key).Scan(&result)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ent := &physical.Entry{
+		Key:   key,
+		Value: result,
+	}
+	return ent, nil
+}
+
+// Delete is used to permanently delete an entry
+func (s *SQLiteBackend) Delete(ctx context.Context, key string) error {
+	defer metrics.MeasureSince([]string{"sqlite", "delete"}, time.Now())
+
+	s.permitPool.Acquire()
+	defer s.permitPool.Release()
+
+	_, err := s.statements["delete"].ExecContext(ctx, key)
+	return err
+}
+
+// List is used to list all the keys under a given
+// prefix, up to the next prefix.
+func (s *SQLiteBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"sqlite", "list"}, time.Now())
+
+	s.permitPool.Acquire()
+	defer s.permitPool.Release()
+
+	var rows *sql.Rows
+	var err error
+	if end, ok := prefixEnd(prefix); ok {
+		rows, err = s.statements["list"].QueryContext(ctx, prefix, end)
+	} else {
+		rows, err = s.statements["list_all"].QueryContext(ctx, prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan rows: %w", err)
+		}
+
+		key = strings.TrimPrefix(key, prefix)
+		if i := strings.Index(key, "/"); i == -1 {
+			// Add objects only from the current 'folder'
+			keys = append(keys, key)
+		} else if folder := key[:i+1]; len(keys) == 0 || keys[len(keys)-1] != folder {
+			// Add truncated 'folder' paths, the keys under a folder are
+			// listed one after another
+			keys = append(keys, folder)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Transaction is used to run multiple entries via a transaction
+func (s *SQLiteBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	defer metrics.MeasureSince([]string{"sqlite", "transaction"}, time.Now())
+	if len(txns) == 0 {
+		return nil
+	}
+
+	s.permitPool.Acquire()
+	defer s.permitPool.Release()
+
+	tx, err := s.client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := s.transaction(ctx, tx, txns); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteBackend) transaction(ctx context.Context, tx *sql.Tx, txns []*physical.TxnEntry) error {
+	deleteStmt := tx.StmtContext(ctx, s.statements["delete"])
+	putStmt := tx.StmtContext(ctx, s.statements["put"])
+
+	var err error
+	for _, op := range txns {
+		switch op.Operation {
+		case physical.DeleteOperation:
+			_, err = deleteStmt.ExecContext(ctx, op.Entry.Key)
+		case physical.PutOperation:
+			_, err = putStmt.ExecContext(ctx, op.Entry.Key, op.Entry.Value)
+		default:
+			return fmt.Errorf("%q is not a supported transaction operation", op.Operation)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefixEnd returns the smallest key greater than all the keys starting
+// with prefix, or false if there is no such key.
+func prefixEnd(prefix string) (string, bool) {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1]), true
+		}
+	}
+	return "", false
+}
+
+// validateDBTable checks that the table name is a plain SQL identifier, so
+// that it can be used in queries without quoting.
+func validateDBTable(dbTable string) error {
+	for i, r := range dbTable {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return fmt.Errorf("must only contain letters, underscores and digits, and not start with a digit")
+		}
+	}
+	return nil
+}
+
+// sqliteDSN returns the URI filename opening the database file at path with
+// the given parameters. The path is escaped, as SQLite decodes percent
+// escapes in URI filenames and stops them at ? and #.
+func sqliteDSN(path string, params url.Values) string {
+	u := &url.URL{Path: filepath.ToSlash(path)}
+	return "file:" + u.EscapedPath() + "?" + params.Encode()
+}
@@ -0,0 +1,128 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+func TestSQLiteBackend(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+	path := filepath.Join(t.TempDir(), "data", "vault.db")
+
+	b, err := NewSQLiteBackend(map[string]string{
+		"path": path,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create new backend: %v", err)
+	}
+
+	physical.ExerciseBackend(t, b)
+	physical.ExerciseBackend_ListPrefix(t, b)
+	physical.ExerciseTransactionalBackend(t, b)
+
+	// The data is read back from the file
+	b2, err := NewSQLiteBackend(map[string]string{
+		"path": path,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to reopen backend: %v", err)
+	}
+	if err := b.Put(context.Background(), &physical.Entry{Key: "persisted", Value: []byte("value")}); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := b2.Get(context.Background(), "persisted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || string(entry.Value) != "value" {
+		t.Fatalf("bad: %#v", entry)
+	}
+}
+
+func TestSQLiteBackend_PathEscaping(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+	dir := filepath.Join(t.TempDir(), "a?b#c%41 d")
+	path := filepath.Join(dir, "vault.db")
+
+	b, err := NewSQLiteBackend(map[string]string{
+		"path": path,
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create new backend: %v", err)
+	}
+	if err := b.Put(context.Background(), &physical.Entry{Key: "foo", Value: []byte("bar")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The database is created at the path as given
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteBackend_ListWildcards(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	b, err := NewSQLiteBackend(map[string]string{
+		"path": filepath.Join(t.TempDir(), "vault.db"),
+	}, logger)
+	if err != nil {
+		t.Fatalf("Failed to create new backend: %v", err)
+	}
+
+	// LIKE would match the keys of the other prefixes
+	for _, key := range []string{"foo_/a", "foox/b", "FOO_/c", "foo_/d/e", "foo_/d/f", "foo_/g"} {
+		if err := b.Put(context.Background(), &physical.Entry{Key: key, Value: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := b.List(context.Background(), "foo_/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"a", "d/", "g"}; !reflect.DeepEqual(keys, exp) {
+		t.Fatalf("got %v, expected %v", keys, exp)
+	}
+}
+
+func TestSQLiteBackend_Table(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	for _, table := range []string{"vault; DROP TABLE x", "1vault", "vault-kv"} {
+		_, err := NewSQLiteBackend(map[string]string{
+			"path":  filepath.Join(t.TempDir(), "vault.db"),
+			"table": table,
+		}, logger)
+		if err == nil {
+			t.Fatalf("expected an error for table %q", table)
+		}
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	cases := map[string]struct {
+		end string
+		ok  bool
+	}{
+		"":         {"", false},
+		"foo/":     {"foo0", true},
+		"a\xff":    {"b", true},
+		"\xff\xff": {"", false},
+	}
+	for prefix, exp := range cases {
+		end, ok := prefixEnd(prefix)
+		if end != exp.end || ok != exp.ok {
+			t.Fatalf("prefix %q: got %q %v, expected %q %v", prefix, end, ok, exp.end, exp.ok)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/dbutil"
+	"github.com/hashicorp/vault/sdk/helper/template"
+)
+
+const (
+	defaultUserCreationSQL           = `CREATE USER "{{username}}"{{on_cluster}} IDENTIFIED WITH sha256_password BY '{{password}}';`
+	defaultUserDeletionSQL           = `DROP USER IF EXISTS "{{username}}"{{on_cluster}};`
+	defaultRootCredentialRotationSQL = `ALTER USER "{{username}}"{{on_cluster}} IDENTIFIED WITH sha256_password BY '{{password}}';`
+	clickhouseTypeName               = "clickhouse"
+
+	defaultUserNameTemplate = `{{ printf "v_%s_%s_%s_%s" (.DisplayName | truncate 15) (.RoleName | truncate 15) (random 20) (unix_time) | truncate 100 | replace "-" "_" | lowercase }}`
+)
+
+var _ dbplugin.Database = &Clickhouse{}
+
+// Clickhouse is an implementation of Database interface
+type Clickhouse struct {
+	*clickhouseConnectionProducer
+
+	usernameProducer template.StringTemplate
+}
+
+// New returns a new Clickhouse instance
+func New() (interface{}, error) {
+	db := new()
+	dbType := dbplugin.NewDatabaseErrorSanitizerMiddleware(db, db.secretValues)
+
+	return dbType, nil
+}
+
+func new() *Clickhouse {
+	connProducer := &clickhouseConnectionProducer{}
+	connProducer.Type = clickhouseTypeName
+
+	return &Clickhouse{
+		clickhouseConnectionProducer: connProducer,
+	}
+}
+
+// Type returns the TypeName for this backend
+func (c *Clickhouse) Type() (string, error) {
+	return clickhouseTypeName, nil
+}
+
+func (c *Clickhouse) getConnection(ctx context.Context) (*clickhouseClient, error) {
+	cli, err := c.Connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cli.(*clickhouseClient), nil
+}
+
+func (c *Clickhouse) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (resp dbplugin.InitializeResponse, err error) {
+	usernameTemplate, err := strutil.GetString(req.Config, "username_template")
+	if err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("failed to retrieve username_template: %w", err)
+	}
+	if usernameTemplate == "" {
+		usernameTemplate = defaultUserNameTemplate
+	}
+
+	up, err := template.NewTemplate(template.Template(usernameTemplate))
+	if err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("unable to initialize username template: %w", err)
+	}
+	c.usernameProducer = up
+
+	_, err = c.usernameProducer.Generate(dbplugin.UsernameMetadata{})
+	if err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("invalid username template: %w", err)
+	}
+
+	return c.clickhouseConnectionProducer.Initialize(ctx, req)
+}
+
+// NewUser generates the username/password on the underlying ClickHouse
+// server as instructed by the statements provided. Roles are granted to the
+// user with GRANT statements in the creation statements.
+func (c *Clickhouse) NewUser(ctx context.Context, req dbplugin.NewUserRequest) (resp dbplugin.NewUserResponse, err error) {
+	c.Lock()
+	defer c.Unlock()
+
+	cli, err := c.getConnection(ctx)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, fmt.Errorf("unable to get connection: %w", err)
+	}
+
+	creationSQL := req.Statements.Commands
+	if len(creationSQL) == 0 {
+		creationSQL = []string{defaultUserCreationSQL}
+	}
+
+	rollbackSQL := req.RollbackStatements.Commands
+	if len(rollbackSQL) == 0 {
+		rollbackSQL = []string{defaultUserDeletionSQL}
+	}
+
+	username, err := c.usernameProducer.Generate(req.UsernameConfig)
+	if err != nil {
+		return dbplugin.NewUserResponse{}, err
+	}
+
+	m := c.queryVariables(username, req.Password)
+	if err := c.execStatements(ctx, cli, creationSQL, m); err != nil {
+		// The user may have been created before a later statement, such as a
+		// grant, failed
+		c.execStatements(ctx, cli, rollbackSQL, m)
+		return dbplugin.NewUserResponse{}, fmt.Errorf("failed to run query in ClickHouse: %w", err)
+	}
+
+	resp = dbplugin.NewUserResponse{
+		Username: username,
+	}
+	return resp, nil
+}
+
+func (c *Clickhouse) DeleteUser(ctx context.Context, req dbplugin.DeleteUserRequest) (dbplugin.DeleteUserResponse, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	cli, err := c.getConnection(ctx)
+	if err != nil {
+		return dbplugin.DeleteUserResponse{}, fmt.Errorf("unable to get connection: %w", err)
+	}
+
+	revocationSQL := req.Statements.Commands
+	if len(revocationSQL) == 0 {
+		revocationSQL = []string{defaultUserDeletionSQL}
+	}
+
+	// Run every statement even if one fails, to remove as much access as
+	// possible
+	var result *multierror.Error
+	for _, stmt := range revocationSQL {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+			m := c.queryVariables(req.Username, "")
+			result = multierror.Append(result, cli.exec(ctx, dbutil.QueryHelper(query, m)))
+		}
+	}
+	if result.ErrorOrNil() != nil {
+		return dbplugin.DeleteUserResponse{}, fmt.Errorf("failed to delete user cleanly: %w", result.ErrorOrNil())
+	}
+	return dbplugin.DeleteUserResponse{}, nil
+}
+
+func (c *Clickhouse) UpdateUser(ctx context.Context, req dbplugin.UpdateUserRequest) (dbplugin.UpdateUserResponse, error) {
+	if req.Password == nil && req.Expiration == nil {
+		return dbplugin.UpdateUserResponse{}, fmt.Errorf("no changes requested")
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if req.Password != nil {
+		err := c.changeUserPassword(ctx, req.Username, req.Password)
+		if err != nil {
+			return dbplugin.UpdateUserResponse{}, fmt.Errorf("failed to change %q password: %w", req.Username, err)
+		}
+	}
+	// Expiration is a no-op
+	return dbplugin.UpdateUserResponse{}, nil
+}
+
+func (c *Clickhouse) changeUserPassword(ctx context.Context, username string, changePassword *dbplugin.ChangePassword) error {
+	if username == "" || changePassword.NewPassword == "" {
+		return fmt.Errorf("must provide both username and a new password to update user password")
+	}
+
+	cli, err := c.getConnection(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get connection: %w", err)
+	}
+
+	rotateSQL := changePassword.Statements.Commands
+	if len(rotateSQL) == 0 {
+		rotateSQL = []string{defaultRootCredentialRotationSQL}
+	}
+
+	m := c.queryVariables(username, changePassword.NewPassword)
+	if err := c.execStatements(ctx, cli, rotateSQL, m); err != nil {
+		return fmt.Errorf("failed to execute rotation queries: %w", err)
+	}
+
+	return nil
+}
+
+// execStatements runs each query of the given statements, stopping at the
+// first failure.
+func (c *Clickhouse) execStatements(ctx context.Context, cli *clickhouseClient, statements []string, m map[string]string) error {
+	for _, stmt := range statements {
+		for _, query := range strutil.ParseArbitraryStringSlice(stmt, ";") {
+			query = strings.TrimSpace(query)
+			if len(query) == 0 {
+				continue
+			}
+			if err := cli.exec(ctx, dbutil.QueryHelper(query, m)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// queryVariables returns the variables of the statements for the given user.
+// The password is escaped to be used in a string literal, and on_cluster is
+// the ON CLUSTER clause of the configured cluster, if any.
+func (c *Clickhouse) queryVariables(username, password string) map[string]string {
+	m := map[string]string{
+		"name":       username,
+		"username":   username,
+		"password":   escapeString(password),
+		"cluster":    c.Cluster,
+		"on_cluster": "",
+	}
+	if c.Cluster != "" {
+		m["on_cluster"] = fmt.Sprintf(` ON CLUSTER "%s"`, escapeIdentifier(c.Cluster))
+	}
+	return m
+}
+
+// escapeString escapes s to be used in a ClickHouse string literal.
+func escapeString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+// escapeIdentifier escapes s to be used in a ClickHouse quoted identifier.
+func escapeIdentifier(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
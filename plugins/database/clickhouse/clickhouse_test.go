@@ -0,0 +1,437 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/testhelpers/docker"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	dbtesting "github.com/hashicorp/vault/sdk/database/dbplugin/v5/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const createUserStatements = `CREATE USER "{{username}}" IDENTIFIED WITH sha256_password BY '{{password}}';GRANT SELECT ON system.* TO "{{username}}";`
+
+type Config struct {
+	docker.ServiceURL
+	Username string
+	Password string
+}
+
+var _ docker.ServiceConfig = &Config{}
+
+func (c *Config) connectionParams() map[string]interface{} {
+	host, port, _ := net.SplitHostPort(c.Address())
+	return map[string]interface{}{
+		"host":     host,
+		"port":     port,
+		"username": c.Username,
+		"password": c.Password,
+	}
+}
+
+func prepareClickhouseTestContainer(t *testing.T) (func(), *Config) {
+	c := &Config{
+		Username: "vault",
+		Password: "secret",
+	}
+	if host := os.Getenv("CLICKHOUSE_HOST"); host != "" {
+		c.ServiceURL = *docker.NewServiceURL(url.URL{Scheme: "http", Host: host})
+		return func() {}, c
+	}
+
+	runner, err := docker.NewServiceRunner(docker.RunOptions{
+		ImageRepo: "clickhouse/clickhouse-server",
+		ImageTag:  "22.3",
+		Env: []string{
+			"CLICKHOUSE_USER=" + c.Username,
+			"CLICKHOUSE_PASSWORD=" + c.Password,
+			"CLICKHOUSE_DEFAULT_ACCESS_MANAGEMENT=1",
+		},
+		Ports: []string{"8123/tcp"},
+	})
+	if err != nil {
+		t.Fatalf("Could not start docker ClickHouse: %s", err)
+	}
+	svc, err := runner.StartService(context.Background(), func(ctx context.Context, host string, port int) (docker.ServiceConfig, error) {
+		c.ServiceURL = *docker.NewServiceURL(url.URL{
+			Scheme: "http",
+			Host:   fmt.Sprintf("%s:%d", host, port),
+		})
+		if err := testCredsExist(c.URL().String(), c.Username, c.Password); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+	if err != nil {
+		t.Fatalf("Could not start docker ClickHouse: %s", err)
+	}
+
+	return svc.Cleanup, svc.Config.(*Config)
+}
+
+func TestClickhouse_Initialize(t *testing.T) {
+	cleanup, config := prepareClickhouseTestContainer(t)
+	defer cleanup()
+
+	type testCase struct {
+		req       dbplugin.InitializeRequest
+		expectErr bool
+	}
+
+	tests := map[string]testCase{
+		"valid config": {
+			req: dbplugin.InitializeRequest{
+				Config:           config.connectionParams(),
+				VerifyConnection: true,
+			},
+			expectErr: false,
+		},
+		"missing config": {
+			req: dbplugin.InitializeRequest{
+				Config:           nil,
+				VerifyConnection: true,
+			},
+			expectErr: true,
+		},
+		"wrong password": {
+			req: dbplugin.InitializeRequest{
+				Config: map[string]interface{}{
+					"host":     config.connectionParams()["host"],
+					"port":     config.connectionParams()["port"],
+					"username": config.Username,
+					"password": "wrong",
+				},
+				VerifyConnection: true,
+			},
+			expectErr: true,
+		},
+		"invalid username template": {
+			req: dbplugin.InitializeRequest{
+				Config: map[string]interface{}{
+					"host":              config.connectionParams()["host"],
+					"port":              config.connectionParams()["port"],
+					"username":          config.Username,
+					"password":          config.Password,
+					"username_template": "{{.FieldThatDoesNotExist}}",
+				},
+				VerifyConnection: true,
+			},
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := new()
+			defer dbtesting.AssertClose(t, db)
+
+			_, err := db.Initialize(context.Background(), test.req)
+			if test.expectErr && err == nil {
+				t.Fatalf("err expected, got nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+			if !test.expectErr && !db.Initialized {
+				t.Fatalf("expected the database to be initialized")
+			}
+		})
+	}
+}
+
+func TestClickhouse_NewUser(t *testing.T) {
+	cleanup, config := prepareClickhouseTestContainer(t)
+	defer cleanup()
+
+	db := new()
+	req := dbplugin.InitializeRequest{
+		Config:           config.connectionParams(),
+		VerifyConnection: true,
+	}
+	dbtesting.AssertInitialize(t, db, req)
+
+	for name, statements := range map[string][]string{
+		"default statements": nil,
+		"role grants":        {createUserStatements},
+	} {
+		t.Run(name, func(t *testing.T) {
+			password := "y8fva_sdVA3rasf'\\n"
+			newUserReq := dbplugin.NewUserRequest{
+				UsernameConfig: dbplugin.UsernameMetadata{
+					DisplayName: "token",
+					RoleName:    "mylongrolenamewithmanycharacters",
+				},
+				Statements: dbplugin.Statements{
+					Commands: statements,
+				},
+				Password:   password,
+				Expiration: time.Now().Add(1 * time.Minute),
+			}
+			resp := dbtesting.AssertNewUser(t, db, newUserReq)
+
+			require.Regexp(t, `^v_token_mylongrolenamew_[a-z0-9]{20}_[0-9]{10}$`, resp.Username)
+			assertCredsExist(t, config.URL().String(), resp.Username, password)
+		})
+	}
+}
+
+func TestClickhouse_NewUser_RollbackOnFailure(t *testing.T) {
+	cleanup, config := prepareClickhouseTestContainer(t)
+	defer cleanup()
+
+	db := new()
+	conf := config.connectionParams()
+	conf["username_template"] = "{{.DisplayName}}_{{random 10}}"
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config:           conf,
+		VerifyConnection: true,
+	})
+
+	password := "y8fva_sdVA3rasf"
+	_, err := db.NewUser(context.Background(), dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "token",
+		},
+		Statements: dbplugin.Statements{
+			Commands: []string{`CREATE USER "{{username}}" IDENTIFIED BY '{{password}}';GRANT "role_that_does_not_exist" TO "{{username}}";`},
+		},
+		Password:   password,
+		Expiration: time.Now().Add(1 * time.Minute),
+	})
+	if err == nil {
+		t.Fatalf("err expected, got nil")
+	}
+
+	// The user created before the grant failed is dropped
+	cli, err := db.getConnection(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.exec(context.Background(), `SELECT throwIf(count() > 0) FROM system.users WHERE name LIKE 'token\_%'`); err != nil {
+		t.Fatalf("expected the user to be rolled back: %s", err)
+	}
+}
+
+func TestClickhouse_UpdateUser_Password(t *testing.T) {
+	cleanup, config := prepareClickhouseTestContainer(t)
+	defer cleanup()
+
+	db := new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config:           config.connectionParams(),
+		VerifyConnection: true,
+	})
+
+	initialPassword := "myreallysecurepassword"
+	resp := dbtesting.AssertNewUser(t, db, dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "test",
+			RoleName:    "test",
+		},
+		Password:   initialPassword,
+		Expiration: time.Now().Add(1 * time.Minute),
+	})
+	assertCredsExist(t, config.URL().String(), resp.Username, initialPassword)
+
+	newPassword := "somenewpassword"
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: resp.Username,
+		Password: &dbplugin.ChangePassword{
+			NewPassword: newPassword,
+		},
+	})
+	assertCredsDoNotExist(t, config.URL().String(), resp.Username, initialPassword)
+	assertCredsExist(t, config.URL().String(), resp.Username, newPassword)
+
+	// The expiration is a no-op
+	dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+		Username: resp.Username,
+		Expiration: &dbplugin.ChangeExpiration{
+			NewExpiration: time.Now().Add(5 * time.Minute),
+		},
+	})
+	assertCredsExist(t, config.URL().String(), resp.Username, newPassword)
+}
+
+func TestClickhouse_DeleteUser(t *testing.T) {
+	cleanup, config := prepareClickhouseTestContainer(t)
+	defer cleanup()
+
+	db := new()
+	dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+		Config:           config.connectionParams(),
+		VerifyConnection: true,
+	})
+
+	password := "myreallysecurepassword"
+	resp := dbtesting.AssertNewUser(t, db, dbplugin.NewUserRequest{
+		UsernameConfig: dbplugin.UsernameMetadata{
+			DisplayName: "test",
+			RoleName:    "test",
+		},
+		Password:   password,
+		Expiration: time.Now().Add(1 * time.Minute),
+	})
+	assertCredsExist(t, config.URL().String(), resp.Username, password)
+
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+	})
+	assertCredsDoNotExist(t, config.URL().String(), resp.Username, password)
+
+	// Deleting a user which doesn't exist anymore succeeds
+	dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{
+		Username: resp.Username,
+	})
+}
+
+// testQueryRecorder starts a fake ClickHouse HTTP interface which records the
+// queries it receives, and fails those containing "fail".
+func testQueryRecorder(t *testing.T) (map[string]interface{}, func() []string) {
+	t.Helper()
+
+	var (
+		lock    sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-ClickHouse-User") != "vault" || r.Header.Get("X-ClickHouse-Key") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "Code: 516. DB::Exception: vault: Authentication failed")
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		query := string(body)
+		if query == "SELECT 1" {
+			return
+		}
+
+		lock.Lock()
+		queries = append(queries, query)
+		lock.Unlock()
+
+		if strings.Contains(query, "fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "Code: 511. DB::Exception: There is no role `fail` in user directories")
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	return map[string]interface{}{
+		"host":              host,
+		"port":              port,
+		"username":          "vault",
+		"password":          "secret",
+		"username_template": "{{.DisplayName}}",
+	}, func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+func TestClickhouse_Statements(t *testing.T) {
+	t.Run("cluster", func(t *testing.T) {
+		conf, queries := testQueryRecorder(t)
+		conf["cluster"] = "analytics"
+
+		db := new()
+		dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+			Config:           conf,
+			VerifyConnection: true,
+		})
+		dbtesting.AssertNewUser(t, db, dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{DisplayName: "alice"},
+			Password:       `it's\secret`,
+		})
+		dbtesting.AssertUpdateUser(t, db, dbplugin.UpdateUserRequest{
+			Username: "alice",
+			Password: &dbplugin.ChangePassword{NewPassword: "new"},
+		})
+		dbtesting.AssertDeleteUser(t, db, dbplugin.DeleteUserRequest{Username: "alice"})
+
+		expected := []string{
+			`CREATE USER "alice" ON CLUSTER "analytics" IDENTIFIED WITH sha256_password BY 'it\'s\\secret'`,
+			`ALTER USER "alice" ON CLUSTER "analytics" IDENTIFIED WITH sha256_password BY 'new'`,
+			`DROP USER IF EXISTS "alice" ON CLUSTER "analytics"`,
+		}
+		require.Equal(t, expected, queries())
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		conf, queries := testQueryRecorder(t)
+
+		db := new()
+		dbtesting.AssertInitialize(t, db, dbplugin.InitializeRequest{
+			Config:           conf,
+			VerifyConnection: true,
+		})
+		_, err := db.NewUser(context.Background(), dbplugin.NewUserRequest{
+			UsernameConfig: dbplugin.UsernameMetadata{DisplayName: "alice"},
+			Statements: dbplugin.Statements{
+				Commands: []string{`CREATE USER "{{username}}" IDENTIFIED BY '{{password}}'; GRANT fail TO "{{username}}"`},
+			},
+			Password: "secret",
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "There is no role `fail`")
+
+		expected := []string{
+			`CREATE USER "alice" IDENTIFIED BY 'secret'`,
+			`GRANT fail TO "alice"`,
+			`DROP USER IF EXISTS "alice"`,
+		}
+		require.Equal(t, expected, queries())
+	})
+
+	t.Run("authentication_failure", func(t *testing.T) {
+		conf, _ := testQueryRecorder(t)
+		conf["password"] = "wrong"
+
+		db := new()
+		_, err := db.Initialize(context.Background(), dbplugin.InitializeRequest{
+			Config:           conf,
+			VerifyConnection: true,
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Authentication failed")
+	})
+}
+
+func assertCredsExist(t testing.TB, address, username, password string) {
+	t.Helper()
+	err := testCredsExist(address, username, password)
+	if err != nil {
+		t.Fatalf("Could not log in as %q: %s", username, err)
+	}
+}
+
+func assertCredsDoNotExist(t testing.TB, address, username, password string) {
+	t.Helper()
+	err := testCredsExist(address, username, password)
+	if err == nil {
+		t.Fatalf("Able to log in as %q when it shouldn't", username)
+	}
+}
+
+func testCredsExist(address, username, password string) error {
+	cli := &clickhouseClient{
+		url:        address + "/",
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+	return cli.exec(context.Background(), "SELECT 1")
+}
@@ -0,0 +1,289 @@
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/go-secure-stdlib/tlsutil"
+	dbplugin "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/database/helper/connutil"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/mitchellh/mapstructure"
+)
+
+// clickhouseConnectionProducer implements ConnectionProducer and provides an
+// interface for ClickHouse databases to make connections. Queries are sent
+// over the HTTP interface of ClickHouse.
+type clickhouseConnectionProducer struct {
+	Host              string      `json:"host" structs:"host" mapstructure:"host"`
+	Port              string      `json:"port" structs:"port" mapstructure:"port"` // default to 8123, or 8443 with TLS
+	Username          string      `json:"username" structs:"username" mapstructure:"username"`
+	Password          string      `json:"password" structs:"password" mapstructure:"password"`
+	Cluster           string      `json:"cluster" structs:"cluster" mapstructure:"cluster"`
+	TLS               bool        `json:"tls" structs:"tls" mapstructure:"tls"`
+	InsecureTLS       bool        `json:"insecure_tls" structs:"insecure_tls" mapstructure:"insecure_tls"`
+	TLSServerName     string      `json:"tls_server_name" structs:"tls_server_name" mapstructure:"tls_server_name"`
+	TLSMinVersion     string      `json:"tls_min_version" structs:"tls_min_version" mapstructure:"tls_min_version"`
+	PemBundle         string      `json:"pem_bundle" structs:"pem_bundle" mapstructure:"pem_bundle"`
+	PemJSON           string      `json:"pem_json" structs:"pem_json" mapstructure:"pem_json"`
+	ConnectTimeoutRaw interface{} `json:"connect_timeout" structs:"connect_timeout" mapstructure:"connect_timeout"`
+
+	connectTimeout time.Duration
+	certificate    string
+	privateKey     string
+	issuingCA      string
+	rawConfig      map[string]interface{}
+
+	Initialized bool
+	Type        string
+	client      *clickhouseClient
+	sync.Mutex
+}
+
+func (c *clickhouseConnectionProducer) Initialize(ctx context.Context, req dbplugin.InitializeRequest) (dbplugin.InitializeResponse, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.rawConfig = req.Config
+
+	err := mapstructure.WeakDecode(req.Config, c)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, err
+	}
+
+	if c.ConnectTimeoutRaw == nil {
+		c.ConnectTimeoutRaw = "5s"
+	}
+	c.connectTimeout, err = parseutil.ParseDurationSecond(c.ConnectTimeoutRaw)
+	if err != nil {
+		return dbplugin.InitializeResponse{}, fmt.Errorf("invalid connect_timeout: %w", err)
+	}
+
+	switch {
+	case len(c.Host) == 0:
+		return dbplugin.InitializeResponse{}, fmt.Errorf("host cannot be empty")
+	case len(c.Username) == 0:
+		return dbplugin.InitializeResponse{}, fmt.Errorf("username cannot be empty")
+	}
+
+	var certBundle *certutil.CertBundle
+	var parsedCertBundle *certutil.ParsedCertBundle
+	switch {
+	case len(c.PemJSON) != 0:
+		parsedCertBundle, err = certutil.ParsePKIJSON([]byte(c.PemJSON))
+		if err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("could not parse given JSON; it must be in the format of the output of the PKI backend certificate issuing command: %w", err)
+		}
+		certBundle, err = parsedCertBundle.ToCertBundle()
+		if err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error marshaling PEM information: %w", err)
+		}
+		c.certificate = certBundle.Certificate
+		c.privateKey = certBundle.PrivateKey
+		c.issuingCA = certBundle.IssuingCA
+		c.TLS = true
+
+	case len(c.PemBundle) != 0:
+		parsedCertBundle, err = certutil.ParsePEMBundle(c.PemBundle)
+		if err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error parsing the given PEM information: %w", err)
+		}
+		certBundle, err = parsedCertBundle.ToCertBundle()
+		if err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error marshaling PEM information: %w", err)
+		}
+		c.certificate = certBundle.Certificate
+		c.privateKey = certBundle.PrivateKey
+		c.issuingCA = certBundle.IssuingCA
+		c.TLS = true
+	}
+
+	if c.Port == "" {
+		c.Port = "8123"
+		if c.TLS {
+			c.Port = "8443"
+		}
+	}
+
+	// Set initialized to true at this point since all fields are set,
+	// and the connection can be established at a later time.
+	c.Initialized = true
+
+	if req.VerifyConnection {
+		if _, err := c.Connection(ctx); err != nil {
+			return dbplugin.InitializeResponse{}, fmt.Errorf("error verifying connection: %w", err)
+		}
+	}
+
+	resp := dbplugin.InitializeResponse{
+		Config: req.Config,
+	}
+
+	return resp, nil
+}
+
+func (c *clickhouseConnectionProducer) Connection(ctx context.Context) (interface{}, error) {
+	if !c.Initialized {
+		return nil, connutil.ErrNotInitialized
+	}
+
+	// If we already have a client, return it
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	cli, err := c.createClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	//  Store the client in backend for reuse
+	c.client = cli
+
+	return cli, nil
+}
+
+func (c *clickhouseConnectionProducer) Close() error {
+	// Grab the write lock
+	c.Lock()
+	defer c.Unlock()
+
+	if c.client != nil {
+		c.client.httpClient.CloseIdleConnections()
+	}
+
+	c.client = nil
+
+	return nil
+}
+
+func (c *clickhouseConnectionProducer) createClient(ctx context.Context) (*clickhouseClient, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: c.connectTimeout,
+		}).DialContext,
+		TLSHandshakeTimeout: c.connectTimeout,
+	}
+	scheme := "http"
+
+	if c.TLS {
+		tlsConfig := &tls.Config{}
+		if len(c.certificate) > 0 || len(c.issuingCA) > 0 {
+			if len(c.certificate) > 0 && len(c.privateKey) == 0 {
+				return nil, fmt.Errorf("found certificate for TLS authentication but no private key")
+			}
+
+			certBundle := &certutil.CertBundle{}
+			if len(c.certificate) > 0 {
+				certBundle.Certificate = c.certificate
+				certBundle.PrivateKey = c.privateKey
+			}
+			if len(c.issuingCA) > 0 {
+				certBundle.IssuingCA = c.issuingCA
+			}
+
+			parsedCertBundle, err := certBundle.ToParsedCertBundle()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse certificate bundle: %w", err)
+			}
+
+			tlsConfig, err = parsedCertBundle.GetTLSConfig(certutil.TLSClient)
+			if err != nil || tlsConfig == nil {
+				return nil, fmt.Errorf("failed to get TLS configuration: tlsConfig:%#v err:%w", tlsConfig, err)
+			}
+		}
+
+		tlsConfig.InsecureSkipVerify = c.InsecureTLS
+		tlsConfig.ServerName = c.TLSServerName
+
+		if c.TLSMinVersion != "" {
+			var ok bool
+			tlsConfig.MinVersion, ok = tlsutil.TLSLookup[c.TLSMinVersion]
+			if !ok {
+				return nil, fmt.Errorf("invalid 'tls_min_version' in config")
+			}
+		}
+
+		transport.TLSClientConfig = tlsConfig
+		scheme = "https"
+	}
+
+	cli := &clickhouseClient{
+		url: (&url.URL{
+			Scheme: scheme,
+			Host:   net.JoinHostPort(c.Host, c.Port),
+			Path:   "/",
+		}).String(),
+		username:   c.Username,
+		password:   c.Password,
+		httpClient: &http.Client{Transport: transport},
+	}
+
+	// Checking server status
+	pingCtx, cancel := context.WithTimeout(ctx, c.connectTimeout)
+	defer cancel()
+	if err := cli.exec(pingCtx, "SELECT 1"); err != nil {
+		return nil, fmt.Errorf("error checking server status: %w", err)
+	}
+
+	return cli, nil
+}
+
+func (c *clickhouseConnectionProducer) secretValues() map[string]string {
+	return map[string]string{
+		c.Password:  "[password]",
+		c.PemBundle: "[pem_bundle]",
+		c.PemJSON:   "[pem_json]",
+	}
+}
+
+// clickhouseClient runs queries over the HTTP interface of ClickHouse.
+type clickhouseClient struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// exec runs a single query, discarding its result.
+func (c *clickhouseClient) exec(ctx context.Context, query string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, strings.NewReader(query))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-ClickHouse-User", c.username)
+	req.Header.Set("X-ClickHouse-Key", c.password)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+
+	// ClickHouse returns the exception as the body of the response, e.g.
+	// "Code: 516. DB::Exception: ..."
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := string(bytes.TrimSpace(body))
+	if message == "" {
+		message = resp.Status
+	}
+	return fmt.Errorf("query failed: %s", message)
+}
@@ -0,0 +1,23 @@
+package consts
+
+// DeprecationStatus is the deprecation status of a builtin plugin.
+type DeprecationStatus uint32
+
+// These are the deprecation statuses of builtin plugins. As with PluginType,
+// new statuses should be added to the end of the list.
+const (
+	Unknown DeprecationStatus = iota
+	Supported
+	Deprecated
+)
+
+func (s DeprecationStatus) String() string {
+	switch s {
+	case Supported:
+		return "supported"
+	case Deprecated:
+		return "deprecated"
+	default:
+		return ""
+	}
+}
@@ -54,6 +54,11 @@ type ACLResults struct {
 	MFAMethods         []string
 	ControlGroup       *ControlGroup
 	CapabilitiesBitmap uint32
+
+	// MatchedPath is the path of the rule which determined the result, as
+	// written in the policies, e.g. "secret/*". It is empty if no rule
+	// matched.
+	MatchedPath string
 }
 
 // NewACL is used to construct a policy based ACL from a set of policies.
@@ -356,10 +361,12 @@ func (a *ACL) AllowOperation(ctx context.Context, req *logical.Request, capCheck
 
 	// Find an exact matching rule, look for prefix if no match
 	var capabilities uint32
+	var matchedPath string
 	raw, ok := a.exactRules.Get(path)
 	if ok {
 		permissions = raw.(*ACLPermissions)
 		capabilities = permissions.CapabilitiesBitmap
+		matchedPath = path
 		goto CHECK
 	}
 	if op == logical.ListOperation {
@@ -367,11 +374,12 @@ func (a *ACL) AllowOperation(ctx context.Context, req *logical.Request, capCheck
 		if ok {
 			permissions = raw.(*ACLPermissions)
 			capabilities = permissions.CapabilitiesBitmap
+			matchedPath = strings.TrimSuffix(path, "/")
 			goto CHECK
 		}
 	}
 
-	permissions = a.CheckAllowedFromNonExactPaths(path, false)
+	permissions, matchedPath = a.checkAllowedFromNonExactPaths(path, false)
 	if permissions != nil {
 		capabilities = permissions.CapabilitiesBitmap
 		goto CHECK
@@ -386,6 +394,7 @@ CHECK:
 	// If "deny" has been explicitly set, only deny will be in the map, so we
 	// only need to check for the existence of other values
 	ret.RootPrivs = capabilities&SudoCapabilityInt > 0
+	ret.MatchedPath = matchedPath
 
 	// This is after the RootPrivs check so we can gate on it being from sudo
 	// rather than policy root
@@ -514,6 +523,7 @@ type wcPathDescr struct {
 	wildcards     int
 	isPrefix      bool
 	wcPath        string
+	fullWCPath    string
 	perms         *ACLPermissions
 }
 
@@ -523,6 +533,13 @@ type wcPathDescr struct {
 // of permissions from some allowed path underneath the mount (for use in mount
 // access checks), or nil indicating no non-deny permissions were found.
 func (a *ACL) CheckAllowedFromNonExactPaths(path string, bareMount bool) *ACLPermissions {
+	permissions, _ := a.checkAllowedFromNonExactPaths(path, bareMount)
+	return permissions
+}
+
+// checkAllowedFromNonExactPaths is CheckAllowedFromNonExactPaths, but also
+// returns the path of the matching rule.
+func (a *ACL) checkAllowedFromNonExactPaths(path string, bareMount bool) (*ACLPermissions, string) {
 	wcPathDescrs := make([]wcPathDescr, 0, len(a.segmentWildcardPaths)+1)
 
 	less := func(i, j int) bool {
@@ -586,11 +603,12 @@ func (a *ACL) CheckAllowedFromNonExactPaths(path string, bareMount bool) *ACLPer
 		prefix, raw, ok := a.prefixRules.LongestPrefix(path)
 		if ok {
 			if len(a.segmentWildcardPaths) == 0 {
-				return raw.(*ACLPermissions)
+				return raw.(*ACLPermissions), prefix + "*"
 			}
 			wcPathDescrs = append(wcPathDescrs, wcPathDescr{
 				firstWCOrGlob: len(prefix),
 				wcPath:        prefix,
+				fullWCPath:    prefix + "*",
 				isPrefix:      true,
 				perms:         raw.(*ACLPermissions),
 			})
@@ -598,7 +616,7 @@ func (a *ACL) CheckAllowedFromNonExactPaths(path string, bareMount bool) *ACLPer
 	}
 
 	if len(a.segmentWildcardPaths) == 0 {
-		return nil
+		return nil, ""
 	}
 
 	pathParts := strings.Split(path, "/")
@@ -608,7 +626,7 @@ SWCPATH:
 		if fullWCPath == "" {
 			continue
 		}
-		pd := wcPathDescr{firstWCOrGlob: strings.Index(fullWCPath, "+")}
+		pd := wcPathDescr{firstWCOrGlob: strings.Index(fullWCPath, "+"), fullWCPath: fullWCPath}
 
 		currWCPath := fullWCPath
 		if currWCPath[len(currWCPath)-1] == '*' {
@@ -654,7 +672,7 @@ SWCPATH:
 				if strings.HasPrefix(joinedPath, path) {
 					permissions := a.segmentWildcardPaths[fullWCPath].(*ACLPermissions)
 					if permissions.CapabilitiesBitmap&DenyCapabilityInt == 0 && permissions.CapabilitiesBitmap > 0 {
-						return permissions
+						return permissions, fullWCPath
 					}
 				}
 				continue SWCPATH
@@ -665,14 +683,15 @@ SWCPATH:
 	}
 
 	if bareMount || len(wcPathDescrs) == 0 {
-		return nil
+		return nil, ""
 	}
 
 	// We don't do this in the bare mount check because we don't care about
 	// priority, we only care about any capability at all.
 	sort.Slice(wcPathDescrs, less)
 
-	return wcPathDescrs[len(wcPathDescrs)-1].perms
+	match := wcPathDescrs[len(wcPathDescrs)-1]
+	return match.perms, match.fullWCPath
 }
 
 func (c *Core) performPolicyChecks(ctx context.Context, acl *ACL, te *logical.TokenEntry, req *logical.Request, inEntity *identity.Entity, opts *PolicyCheckOpts) *AuthResults {
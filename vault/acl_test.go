@@ -724,6 +724,51 @@ path "foo/bar/+/ba*" { capabilities = ["update"] }
 	}
 }
 
+func TestACL_MatchedPath(t *testing.T) {
+	ns := namespace.RootNamespace
+	ctx := namespace.ContextWithNamespace(context.Background(), ns)
+
+	policy, err := ParseACLPolicy(ns, `
+path "secret/*" { capabilities = ["deny"] }
+path "secret/app/*" { capabilities = ["read"] }
+path "secret/app/config" { capabilities = ["update"] }
+path "secret/+/shared" { capabilities = ["read"] }
+path "sys/policies/acl" { capabilities = ["list"] }
+`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	acl, err := NewACL(ctx, []*Policy{policy})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tcases := []struct {
+		op      logical.Operation
+		path    string
+		matched string
+	}{
+		{logical.ReadOperation, "secret/foo", "secret/*"},
+		{logical.ReadOperation, "secret/app/foo", "secret/app/*"},
+		{logical.UpdateOperation, "secret/app/config", "secret/app/config"},
+		{logical.ReadOperation, "secret/team/shared", "secret/+/shared"},
+		{logical.ListOperation, "sys/policies/acl/", "sys/policies/acl"},
+		{logical.ReadOperation, "cubbyhole/foo", ""},
+	}
+
+	for _, tc := range tcases {
+		request := &logical.Request{
+			Operation: tc.op,
+			Path:      tc.path,
+		}
+		authResults := acl.AllowOperation(ctx, request, false)
+		if authResults.MatchedPath != tc.matched {
+			t.Fatalf("bad: %s %s: expected matched path %q, got %q", tc.op, tc.path, tc.matched, authResults.MatchedPath)
+		}
+	}
+}
+
 func TestACL_SegmentWildcardPriority_BareMount(t *testing.T) {
 	ns := namespace.RootNamespace
 	ctx := namespace.ContextWithNamespace(context.Background(), ns)
@@ -518,6 +518,18 @@ func (c *Core) newAuditBackend(ctx context.Context, entry *MountEntry, view logi
 				auditLogger.Debug("socket backend options", "path", entry.Path, "address", entry.Options["address"], "socket type", entry.Options["socket_type"])
 			}
 		}
+	case "kafka":
+		if auditLogger.IsDebug() {
+			if entry.Options != nil {
+				auditLogger.Debug("kafka backend options", "path", entry.Path, "brokers", entry.Options["brokers"], "topic", entry.Options["topic"])
+			}
+		}
+	case "otlp":
+		if auditLogger.IsDebug() {
+			if entry.Options != nil {
+				auditLogger.Debug("otlp backend options", "path", entry.Path, "endpoint", entry.Options["endpoint"])
+			}
+		}
 	case "syslog":
 		if auditLogger.IsDebug() {
 			if entry.Options != nil {
@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sync"
@@ -10,6 +11,7 @@ import (
 	log "github.com/hashicorp/go-hclog"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/logical"
 )
 
@@ -25,6 +27,22 @@ type AuditBroker struct {
 	sync.RWMutex
 	backends map[string]backendEntry
 	logger   log.Logger
+
+	// streams holds the streams of each backend, by backend name
+	streamsLock sync.RWMutex
+	streams     map[string]map[*auditStream]struct{}
+}
+
+// auditSalter is implemented by the audit backends which hash with a
+// persistent salt, such as the builtin file, socket and syslog backends.
+type auditSalter interface {
+	Salt(context.Context) (*salt.Salt, error)
+}
+
+// auditStream receives the entries logged by an audit backend.
+type auditStream struct {
+	formatter *audit.AuditFormatter
+	ch        chan []byte
 }
 
 // NewAuditBroker creates a new audit broker
@@ -52,6 +70,83 @@ func (a *AuditBroker) Deregister(name string) {
 	a.Lock()
 	defer a.Unlock()
 	delete(a.backends, name)
+
+	a.streamsLock.Lock()
+	defer a.streamsLock.Unlock()
+	for s := range a.streams[name] {
+		close(s.ch)
+	}
+	delete(a.streams, name)
+}
+
+// Stream returns a channel which receives the entries the given audit backend
+// logs from now on, as JSON lines hashed with the backend's salt, and a
+// function which stops the stream. Entries are dropped while the channel is
+// full. The channel is closed if the backend is deregistered.
+func (a *AuditBroker) Stream(name string, size int) (<-chan []byte, func(), error) {
+	a.RLock()
+	be, ok := a.backends[name]
+	a.RUnlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown audit backend %q", name)
+	}
+	salter, ok := be.backend.(auditSalter)
+	if !ok {
+		return nil, nil, fmt.Errorf("audit backend %q does not support streaming", name)
+	}
+
+	s := &auditStream{
+		formatter: &audit.AuditFormatter{
+			AuditFormatWriter: &audit.JSONFormatWriter{
+				SaltFunc: salter.Salt,
+			},
+		},
+		ch: make(chan []byte, size),
+	}
+
+	a.streamsLock.Lock()
+	defer a.streamsLock.Unlock()
+	if a.streams == nil {
+		a.streams = make(map[string]map[*auditStream]struct{})
+	}
+	if a.streams[name] == nil {
+		a.streams[name] = make(map[*auditStream]struct{})
+	}
+	a.streams[name][s] = struct{}{}
+
+	stop := func() {
+		a.streamsLock.Lock()
+		defer a.streamsLock.Unlock()
+		delete(a.streams[name], s)
+	}
+	return s.ch, stop, nil
+}
+
+// publish sends the entry the given backend logged for in to the streams of
+// the backend.
+func (a *AuditBroker) publish(ctx context.Context, name string, in *logical.LogInput, response bool) {
+	a.streamsLock.RLock()
+	defer a.streamsLock.RUnlock()
+
+	config := audit.FormatterConfig{HMACAccessor: true}
+	for s := range a.streams[name] {
+		var buf bytes.Buffer
+		var err error
+		if response {
+			err = s.formatter.FormatResponse(ctx, &buf, config, in)
+		} else {
+			err = s.formatter.FormatRequest(ctx, &buf, config, in)
+		}
+		if err != nil {
+			a.logger.Error("failed to format audit entry for stream", "backend", name, "error", err)
+			continue
+		}
+
+		select {
+		case s.ch <- buf.Bytes():
+		default:
+		}
+	}
 }
 
 // IsRegistered is used to check if a given audit backend is registered
@@ -147,6 +242,7 @@ func (a *AuditBroker) LogRequest(ctx context.Context, in *logical.LogInput, head
 			a.logger.Error("backend failed to log request", "backend", name, "error", lrErr)
 		} else {
 			anyLogged = true
+			a.publish(ctx, name, in, false)
 		}
 	}
 	if !anyLogged && len(a.backends) > 0 {
@@ -212,6 +308,7 @@ func (a *AuditBroker) LogResponse(ctx context.Context, in *logical.LogInput, hea
 			a.logger.Error("backend failed to log response", "backend", name, "error", lrErr)
 		} else {
 			anyLogged = true
+			a.publish(ctx, name, in, true)
 		}
 	}
 	if !anyLogged && len(a.backends) > 0 {
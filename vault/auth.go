@@ -948,6 +948,10 @@ func (c *Core) newCredentialBackend(ctx context.Context, entry *MountEntry, sysV
 		return nil, err
 	}
 
+	if !ok {
+		c.setRunningPlugin(ctx, entry, conf["plugin_name"], consts.PluginTypeCredential)
+	}
+
 	return b, nil
 }
 
@@ -37,6 +37,7 @@ import (
 	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/physical/raft"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
@@ -262,6 +263,14 @@ type Core struct {
 	migrationInfo     *migrationInformation
 	sealMigrationDone *uint32
 
+	// sealRewrap tracks the rewrap of the storage entries that follows a seal
+	// migration.
+	sealRewrap *sealRewrapTracker
+
+	// raftSnapshotRestore tracks the progress of the raft snapshot restores
+	// run by this node.
+	raftSnapshotRestore *raftSnapshotRestoreTracker
+
 	// barrier is the security barrier wrapping the physical backend
 	barrier SecurityBarrier
 
@@ -443,6 +452,9 @@ type Core struct {
 	clusterListenerAddrs []*net.TCPAddr
 	// The handler to use for request forwarding
 	clusterHandler http.Handler
+	// listenerReloader reloads the listeners from the server configuration,
+	// returning the names of the added and removed listeners
+	listenerReloader func() ([]string, []string, error)
 	// Write lock used to ensure that we don't have multiple connections adjust
 	// this value at the same time
 	requestForwardingConnectionLock sync.RWMutex
@@ -453,6 +465,8 @@ type Core struct {
 	clusterLeaderParams *atomic.Value
 	// Info on cluster members
 	clusterPeerClusterAddrsCache *cache.Cache
+	// Responses to requests sent with an idempotency key
+	idempotencyCache *cache.Cache
 	// The context for the client
 	rpcClientConnContext context.Context
 	// The function for canceling the client connection
@@ -569,6 +583,10 @@ type Core struct {
 
 	quotaManager *quotas.Manager
 
+	// webhooks delivers notifications of events to webhooks. It is only set
+	// while the node is active.
+	webhooks *webhookManager
+
 	clusterHeartbeatInterval time.Duration
 
 	activityLogConfig ActivityLogCoreConfig
@@ -824,6 +842,8 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		router:               NewRouter(),
 		sealed:               new(uint32),
 		sealMigrationDone:    new(uint32),
+		sealRewrap:           new(sealRewrapTracker),
+		raftSnapshotRestore:  new(raftSnapshotRestoreTracker),
 		standby:              true,
 		standbyStopCh:        new(atomic.Value),
 		baseLogger:           conf.Logger,
@@ -836,6 +856,7 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		clusterName:                    conf.ClusterName,
 		clusterNetworkLayer:            conf.ClusterNetworkLayer,
 		clusterPeerClusterAddrsCache:   cache.New(3*clusterHeartbeatInterval, time.Second),
+		idempotencyCache:               cache.New(idempotencyWindow, time.Minute),
 		enableMlock:                    !conf.DisableMlock,
 		rawEnabled:                     conf.EnableRaw,
 		shutdownDoneCh:                 make(chan struct{}),
@@ -2117,6 +2138,9 @@ func (s standardUnsealStrategy) unseal(ctx context.Context, logger log.Logger, c
 		if err := c.startRollback(); err != nil {
 			return err
 		}
+		if err := c.setupWebhooks(ctx); err != nil {
+			return err
+		}
 		if err := c.setupExpiration(expireLeaseStrategyFairsharing); err != nil {
 			return err
 		}
@@ -2289,6 +2313,7 @@ func (c *Core) preSeal() error {
 	if err := c.stopExpiration(); err != nil {
 		result = multierror.Append(result, fmt.Errorf("error stopping expiration: %w", err))
 	}
+	c.teardownWebhooks()
 	c.stopActivityLog()
 	// Clear any cached auth response
 	c.mfaResponseAuthQueueLock.Lock()
@@ -2784,6 +2809,32 @@ func (c *Core) SetConfig(conf *server.Config) {
 	c.logger.Debug("set config", "sanitized config", string(bz))
 }
 
+// SetListenersConfig replaces the listeners of core's config object, keeping
+// the rest of the config as it is, and returns the updated config. It returns
+// nil if core has no config object.
+func (c *Core) SetListenersConfig(listeners []*configutil.Listener) *server.Config {
+	conf, ok := c.rawConfig.Load().(*server.Config)
+	if !ok || conf == nil {
+		return nil
+	}
+
+	updated := *conf
+	shared := new(configutil.SharedConfig)
+	if conf.SharedConfig != nil {
+		*shared = *conf.SharedConfig
+	}
+	shared.Listeners = listeners
+	updated.SharedConfig = shared
+	c.SetConfig(&updated)
+	return &updated
+}
+
+// SetListenerReloader sets the function used by sys/config/reload/listeners
+// to add and remove listeners. It must be called before requests are served.
+func (c *Core) SetListenerReloader(reloader func() ([]string, []string, error)) {
+	c.listenerReloader = reloader
+}
+
 func (c *Core) GetListenerCustomResponseHeaders(listenerAdd string) *ListenerCustomHeaders {
 	customHeaders := c.customListenerHeader.Load()
 	if customHeaders == nil {
@@ -2879,6 +2930,7 @@ type BuiltinRegistry interface {
 	Contains(name string, pluginType consts.PluginType) bool
 	Get(name string, pluginType consts.PluginType) (func() (interface{}, error), bool)
 	Keys(pluginType consts.PluginType) []string
+	DeprecationStatus(name string, pluginType consts.PluginType) (consts.DeprecationStatus, bool)
 }
 
 func (c *Core) AuditLogger() AuditLogger {
@@ -14,7 +14,9 @@ import (
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -45,6 +47,45 @@ func TestNewCore_badRedirectAddr(t *testing.T) {
 	}
 }
 
+func TestCore_SetListenersConfig(t *testing.T) {
+	inm, err := inmem.NewInmem(nil, logging.NewVaultLogger(log.Trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := &server.Config{
+		SharedConfig: &configutil.SharedConfig{
+			Listeners: []*configutil.Listener{{Type: "tcp", Address: "127.0.0.1:8200"}},
+			LogLevel:  "debug",
+		},
+		DisablePrintableCheck: true,
+	}
+	c, err := NewCore(&CoreConfig{
+		Physical:     inm,
+		DisableMlock: true,
+		RawConfig:    raw,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the listeners are replaced
+	listeners := []*configutil.Listener{{Type: "tcp", Address: "127.0.0.1:8300"}}
+	updated := c.SetListenersConfig(listeners)
+	if updated == nil || !reflect.DeepEqual(updated.Listeners, listeners) {
+		t.Fatalf("expected the new listeners, got %#v", updated)
+	}
+	if updated.LogLevel != "debug" || !updated.DisablePrintableCheck {
+		t.Fatalf("expected the rest of the config to be kept, got %#v", updated)
+	}
+	if c.rawConfig.Load().(*server.Config) != updated {
+		t.Fatal("expected the config of the core to be updated")
+	}
+	if raw.Listeners[0].Address != "127.0.0.1:8200" {
+		t.Fatal("expected the previous config to be left untouched")
+	}
+}
+
 func TestSealConfig_Invalid(t *testing.T) {
 	s := &SealConfig{
 		SecretShares:    2,
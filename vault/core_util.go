@@ -163,7 +163,9 @@ func (c *Core) perfStandbyClusterHandler() (*replication.Cluster, chan struct{},
 
 func (c *Core) initSealsForMigration() {}
 
-func (c *Core) postSealMigration(ctx context.Context) error { return nil }
+func (c *Core) postSealMigration(ctx context.Context) error {
+	return c.startSealRewrap(ctx)
+}
 
 func (c *Core) applyLeaseCountQuota(_ context.Context, in *quotas.Request) (*quotas.Response, error) {
 	return &quotas.Response{Allowed: true}, nil
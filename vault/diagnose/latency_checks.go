@@ -0,0 +1,225 @@
+package diagnose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+// Recommended latencies, above which the benchmarks warn. The 99th
+// percentile is compared so that a single slow sample does not trigger a
+// warning.
+const (
+	StorageLatencyThreshold time.Duration = 100 * time.Millisecond
+	FsyncLatencyThreshold   time.Duration = 10 * time.Millisecond
+	SealLatencyThreshold    time.Duration = 500 * time.Millisecond
+
+	storageBenchmarkPrefix string = "diagnose/benchmark/"
+	fsyncBenchmarkSize     int    = 4096
+)
+
+// LatencyStats summarizes the durations of a benchmarked operation.
+type LatencyStats struct {
+	Samples int
+	Min     time.Duration
+	P50     time.Duration
+	P99     time.Duration
+	Max     time.Duration
+}
+
+// NewLatencyStats computes the statistics of the given durations.
+func NewLatencyStats(durations []time.Duration) LatencyStats {
+	if len(durations) == 0 {
+		return LatencyStats{}
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	percentile := func(p int) time.Duration {
+		i := (len(sorted)*p+99)/100 - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i]
+	}
+
+	return LatencyStats{
+		Samples: len(sorted),
+		Min:     sorted[0],
+		P50:     percentile(50),
+		P99:     percentile(99),
+		Max:     sorted[len(sorted)-1],
+	}
+}
+
+func (s LatencyStats) String() string {
+	return fmt.Sprintf("p50: %s, p99: %s, max: %s over %d samples.", roundLatency(s.P50), roundLatency(s.P99), roundLatency(s.Max), s.Samples)
+}
+
+// roundLatency keeps the precision that matters for each order of magnitude.
+func roundLatency(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
+
+// LatencyResult reports the statistics of a benchmarked operation as a spot
+// check, warning if the 99th percentile is above the threshold.
+func LatencyResult(ctx context.Context, checkName string, stats LatencyStats, threshold time.Duration, advice string) {
+	if stats.P99 > threshold {
+		SpotWarn(ctx, checkName, fmt.Sprintf("Latency above the recommended %s: %s", threshold, stats), Advice(advice))
+		return
+	}
+	SpotOk(ctx, checkName, stats.String())
+}
+
+// StorageLatencyBenchmark times writes, reads, lists and deletes of samples
+// entries under a dedicated prefix of the storage backend, and returns the
+// statistics of each operation. The entries are removed even if an operation
+// fails.
+func StorageLatencyBenchmark(ctx context.Context, b physical.Backend, uuid string, samples int) (map[string]LatencyStats, error) {
+	prefix := storageBenchmarkPrefix + uuid + "/"
+	keys := make([]string, samples)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+
+	defer func() {
+		for _, key := range keys {
+			b.Delete(context.Background(), key)
+		}
+	}()
+
+	timings := make(map[string][]time.Duration)
+	timed := func(op string, f func() error) error {
+		start := time.Now()
+		err := f()
+		timings[op] = append(timings[op], time.Since(start))
+		if err != nil {
+			return fmt.Errorf("Storage %s failed during the latency benchmark: %w.", op, err)
+		}
+		return nil
+	}
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		err := timed("write", func() error {
+			return b.Put(ctx, &physical.Entry{Key: key, Value: []byte(secretVal)})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range keys {
+		err := timed("read", func() error {
+			entry, err := b.Get(ctx, key)
+			if err == nil && (entry == nil || string(entry.Value) != secretVal) {
+				err = fmt.Errorf(wrongRWValsPrefix+"expecting %s for %s", secretVal, key)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for range keys {
+		err := timed("list", func() error {
+			listed, err := b.List(ctx, prefix)
+			if err == nil && len(listed) != len(keys) {
+				err = fmt.Errorf("expecting %d keys under %s but got %d", len(keys), prefix, len(listed))
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range keys {
+		err := timed("delete", func() error {
+			return b.Delete(ctx, key)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stats := make(map[string]LatencyStats, len(timings))
+	for op, durations := range timings {
+		stats[op] = NewLatencyStats(durations)
+	}
+	return stats, nil
+}
+
+// FsyncLatencyBenchmark times writing and syncing blocks to a temporary file
+// in dir, which is how raft persists its log and its database.
+func FsyncLatencyBenchmark(ctx context.Context, dir string, samples int) (LatencyStats, error) {
+	f, err := ioutil.TempFile(dir, "diagnose-fsync-")
+	if err != nil {
+		return LatencyStats{}, fmt.Errorf("Could not create a file to benchmark fsync: %w.", err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	block := bytes.Repeat([]byte{0}, fsyncBenchmarkSize)
+	durations := make([]time.Duration, 0, samples)
+	for i := 0; i < samples; i++ {
+		if err := ctx.Err(); err != nil {
+			return LatencyStats{}, err
+		}
+		start := time.Now()
+		if _, err := f.Write(block); err != nil {
+			return LatencyStats{}, fmt.Errorf("Could not write to the fsync benchmark file: %w.", err)
+		}
+		if err := f.Sync(); err != nil {
+			return LatencyStats{}, fmt.Errorf("Could not fsync the benchmark file: %w.", err)
+		}
+		durations = append(durations, time.Since(start))
+	}
+
+	return NewLatencyStats(durations), nil
+}
+
+// SealLatencyBenchmark times round trips to the seal, each of them encrypting
+// and then decrypting a value.
+func SealLatencyBenchmark(ctx context.Context, w wrapping.Wrapper, samples int) (LatencyStats, error) {
+	value := []byte("diagnose-" + secretVal)
+	durations := make([]time.Duration, 0, samples)
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		ciphertext, err := w.Encrypt(ctx, value, nil)
+		if err != nil {
+			return LatencyStats{}, fmt.Errorf("Error encrypting with seal barrier: %w.", err)
+		}
+		plaintext, err := w.Decrypt(ctx, ciphertext, nil)
+		if err != nil {
+			return LatencyStats{}, fmt.Errorf("Error decrypting with seal barrier: %w.", err)
+		}
+		durations = append(durations, time.Since(start))
+		if !bytes.Equal(plaintext, value) {
+			return LatencyStats{}, fmt.Errorf("Barrier returned incorrect decrypted value for mock data.")
+		}
+	}
+
+	return NewLatencyStats(durations), nil
+}
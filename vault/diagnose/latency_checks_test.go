@@ -0,0 +1,84 @@
+package diagnose
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/physical/inmem"
+)
+
+func TestNewLatencyStats(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	stats := NewLatencyStats(durations)
+	if stats.Samples != 100 {
+		t.Fatalf("expected 100 samples, got %d", stats.Samples)
+	}
+	if stats.Min != time.Millisecond || stats.Max != 100*time.Millisecond {
+		t.Fatalf("wrong min or max: %s, %s", stats.Min, stats.Max)
+	}
+	if stats.P50 != 50*time.Millisecond || stats.P99 != 99*time.Millisecond {
+		t.Fatalf("wrong percentiles: %s, %s", stats.P50, stats.P99)
+	}
+
+	if stats := NewLatencyStats(nil); stats.Samples != 0 || stats.P99 != 0 {
+		t.Fatalf("expected empty stats, got %#v", stats)
+	}
+}
+
+func TestStorageLatencyBenchmark(t *testing.T) {
+	b, err := inmem.NewInmem(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := StorageLatencyBenchmark(context.Background(), b, "foo", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{"write", "read", "list", "delete"} {
+		if stats[op].Samples != 5 {
+			t.Errorf("expected 5 %s samples, got %d", op, stats[op].Samples)
+		}
+	}
+
+	keys, err := b.List(context.Background(), storageBenchmarkPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected the benchmark entries to be removed, found %v", keys)
+	}
+
+	// Failures are reported along with the operation that failed
+	_, err = StorageLatencyBenchmark(context.Background(), mockStorageBackend{callType: errCallRead}, "foo", 5)
+	if err == nil || !strings.Contains(err.Error(), storageErrStringRead) {
+		t.Fatalf("expected a read error, got %v", err)
+	}
+}
+
+func TestFsyncLatencyBenchmark(t *testing.T) {
+	dir := t.TempDir()
+
+	stats, err := FsyncLatencyBenchmark(context.Background(), dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Samples != 3 || stats.Min <= 0 {
+		t.Fatalf("unexpected stats: %#v", stats)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatalf("expected the benchmark file to be removed, found %v", files)
+	}
+}
@@ -1012,6 +1012,11 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 	if m.logger.IsInfo() && !skipToken && m.logLeaseExpirations {
 		m.logger.Info("revoked lease", "lease_id", leaseID)
 	}
+	if le.Secret != nil {
+		m.core.webhooks.notify(le.namespace, WebhookEventLeaseRevoke, le.Path, "", map[string]interface{}{
+			"lease_id": leaseID,
+		})
+	}
 	if m.logger.IsWarn() && !skipToken && le.isIncorrectlyNonExpiring() {
 		var accessor string
 		if le.Auth != nil {
@@ -2641,6 +2646,117 @@ func (m *ExpirationManager) listIrrevocableLeases(ctx context.Context, includeCh
 	return resp, warning, nil
 }
 
+// leaseMetadataFilter selects the leases returned by listLeaseMetadata. Zero
+// values do not filter.
+type leaseMetadataFilter struct {
+	prefix        string
+	expiresBefore time.Time
+	issuedAfter   time.Time
+	issuedBefore  time.Time
+}
+
+func (f *leaseMetadataFilter) matches(leaseID string, le *leaseEntry) bool {
+	switch {
+	case !strings.HasPrefix(leaseID, f.prefix):
+		return false
+	case !f.expiresBefore.IsZero() && (le.ExpireTime.IsZero() || !le.ExpireTime.Before(f.expiresBefore)):
+		return false
+	case !f.issuedAfter.IsZero() && le.IssueTime.Before(f.issuedAfter):
+		return false
+	case !f.issuedBefore.IsZero() && !le.IssueTime.Before(f.issuedBefore):
+		return false
+	}
+	return true
+}
+
+// listLeaseMetadata returns the metadata of the leases in the request's
+// namespace which match filter, from the in-memory lease information. Leases
+// are sorted by increasing expire time, with non-expiring leases last.
+// Returns a warning string, if applicable.
+func (m *ExpirationManager) listLeaseMetadata(ctx context.Context, filter *leaseMetadataFilter, returnAll bool, limit int) (map[string]interface{}, string, error) {
+	if m.inRestoreMode() {
+		return nil, "", ErrInRestoreMode
+	}
+
+	requestNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		m.logger.Error("could not get namespace from context", "error", err)
+		return nil, "", err
+	}
+
+	type match struct {
+		leaseID string
+		lease   *leaseEntry
+	}
+	var matches []match
+	callback := func(k, v interface{}) bool {
+		leaseID := k.(string)
+		lease := v.(pendingInfo).cachedLeaseInfo
+		if lease == nil || !filter.matches(leaseID, lease) {
+			return true
+		}
+
+		leaseNS, err := m.getNamespaceFromLeaseID(ctx, leaseID)
+		if err != nil {
+			m.logger.Warn("could not get lease namespace from ID", "error", err)
+			return true
+		}
+		if leaseNS != requestNS {
+			return true
+		}
+
+		matches = append(matches, match{leaseID: leaseID, lease: lease})
+		return true
+	}
+	m.pending.Range(callback)
+	m.nonexpiring.Range(callback)
+
+	sort.Slice(matches, func(i, j int) bool {
+		ei, ej := matches[i].lease.ExpireTime, matches[j].lease.ExpireTime
+		switch {
+		case ei.Equal(ej):
+			return matches[i].leaseID < matches[j].leaseID
+		case ei.IsZero():
+			return false
+		case ej.IsZero():
+			return true
+		}
+		return ei.Before(ej)
+	})
+
+	var warning string
+	if !returnAll && len(matches) > limit {
+		warning = fmt.Sprintf("Only the first %d of %d matching leases are returned", limit, len(matches))
+		matches = matches[:limit]
+	}
+
+	leases := make([]map[string]interface{}, 0, len(matches))
+	for _, match := range matches {
+		lease := map[string]interface{}{
+			"lease_id":     match.leaseID,
+			"issue_time":   match.lease.IssueTime,
+			"expire_time":  nil,
+			"last_renewal": nil,
+			"ttl":          int64(0),
+		}
+		renewable, _ := match.lease.renewable()
+		lease["renewable"] = renewable
+		if !match.lease.LastRenewalTime.IsZero() {
+			lease["last_renewal"] = match.lease.LastRenewalTime
+		}
+		if !match.lease.ExpireTime.IsZero() {
+			lease["expire_time"] = match.lease.ExpireTime
+			lease["ttl"] = match.lease.ttl()
+		}
+		leases = append(leases, lease)
+	}
+
+	return map[string]interface{}{
+		"lease_count": len(leases),
+		"leases":      leases,
+	}, warning, nil
+}
+
 // leaseEntry is used to structure the values the expiration
 // manager stores. This is used to handle renew and revocation.
 type leaseEntry struct {
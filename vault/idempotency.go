@@ -0,0 +1,196 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/copystructure"
+)
+
+// idempotencyWindow is how long the response to a request sent with an
+// idempotency key is kept to be replayed.
+const idempotencyWindow = 10 * time.Minute
+
+var (
+	// ErrIdempotencyKeyInProgress is returned when a request reuses the
+	// idempotency key of a request which has not completed yet.
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is already in progress")
+
+	// ErrIdempotencyKeyMismatch is returned when a request reuses the
+	// idempotency key of a request with a different operation, path or data.
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used for a different request")
+
+	// ErrIdempotencyReplayUnavailable is returned when a request reuses the
+	// idempotency key of a request whose response carried a secret, a token
+	// or a wrapping token, which are never kept to be replayed.
+	ErrIdempotencyReplayUnavailable = errors.New("the response to the request with this idempotency key contains credentials and can't be replayed")
+)
+
+type idempotentReplayKey struct{}
+
+// ContextWithIdempotentReplay returns a context which makes HandleRequest
+// replay the response recorded for ir instead of routing the request. The
+// request is still authorized and audited like any other request.
+func ContextWithIdempotentReplay(ctx context.Context, ir *IdempotentRequest) context.Context {
+	return context.WithValue(ctx, idempotentReplayKey{}, ir)
+}
+
+func idempotentReplayFromContext(ctx context.Context) (*IdempotentRequest, bool) {
+	ir, ok := ctx.Value(idempotentReplayKey{}).(*IdempotentRequest)
+	return ir, ok && ir != nil && ir.Replay
+}
+
+// IdempotentRequest tracks a request sent with an idempotency key. If Replay
+// is set, the request was already handled and must be passed to HandleRequest
+// with ContextWithIdempotentReplay instead of being handled again.
+type IdempotentRequest struct {
+	Replay bool
+
+	resp        *logical.Response
+	cacheKey    string
+	fingerprint string
+}
+
+type idempotencyEntry struct {
+	fingerprint string
+	done        bool
+	sensitive   bool
+	resp        *logical.Response
+}
+
+// StartIdempotentRequest looks up the request made with the given idempotency
+// key by the same client token in the same namespace. If there is none, the
+// key is reserved until FinishIdempotentRequest is called.
+func (c *Core) StartIdempotentRequest(ctx context.Context, req *logical.Request, key string) (*IdempotentRequest, error) {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := idempotencyFingerprint(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ir := &IdempotentRequest{
+		cacheKey:    idempotencyHash(ns.ID, req.ClientToken, key),
+		fingerprint: fingerprint,
+	}
+
+	entry := &idempotencyEntry{fingerprint: fingerprint}
+	if err := c.idempotencyCache.Add(ir.cacheKey, entry, idempotencyWindow); err == nil {
+		return ir, nil
+	}
+
+	raw, ok := c.idempotencyCache.Get(ir.cacheKey)
+	if !ok {
+		// The entry expired in the meantime
+		c.idempotencyCache.Set(ir.cacheKey, entry, idempotencyWindow)
+		return ir, nil
+	}
+
+	existing := raw.(*idempotencyEntry)
+	switch {
+	case existing.fingerprint != fingerprint:
+		return nil, ErrIdempotencyKeyMismatch
+	case !existing.done:
+		return nil, ErrIdempotencyKeyInProgress
+	case existing.sensitive:
+		return nil, ErrIdempotencyReplayUnavailable
+	}
+
+	ir.Replay = true
+	ir.resp = existing.resp
+	return ir, nil
+}
+
+// FinishIdempotentRequest records the response to a request started with
+// StartIdempotentRequest. If the request did not succeed, the idempotency key
+// is released so that the request can be retried. Responses carrying a
+// secret, a token or a wrapping token are not kept: the key stays used, but
+// the request can't be replayed.
+func (c *Core) FinishIdempotentRequest(ir *IdempotentRequest, resp *logical.Response, success bool) {
+	if ir == nil || ir.Replay {
+		return
+	}
+
+	if !success {
+		c.idempotencyCache.Delete(ir.cacheKey)
+		return
+	}
+
+	entry := &idempotencyEntry{
+		fingerprint: ir.fingerprint,
+		done:        true,
+	}
+	switch {
+	case resp == nil:
+	case resp.Secret != nil, resp.Auth != nil, resp.WrapInfo != nil:
+		entry.sensitive = true
+	default:
+		copied, err := copyIdempotentResponse(resp)
+		if err != nil {
+			c.logger.Warn("failed to record the response to an idempotent request", "error", err)
+			c.idempotencyCache.Delete(ir.cacheKey)
+			return
+		}
+		entry.resp = copied
+	}
+	c.idempotencyCache.Set(ir.cacheKey, entry, idempotencyWindow)
+}
+
+// replayedResponse returns a copy of the response recorded for ir, so that
+// replays don't share it.
+func (ir *IdempotentRequest) replayedResponse() (*logical.Response, error) {
+	if ir.resp == nil {
+		return nil, nil
+	}
+	return copyIdempotentResponse(ir.resp)
+}
+
+// copyIdempotentResponse deep copies a response which carries no secret,
+// token or wrapping token.
+func copyIdempotentResponse(resp *logical.Response) (*logical.Response, error) {
+	data, err := copystructure.Copy(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := copystructure.Copy(resp.Headers)
+	if err != nil {
+		return nil, err
+	}
+	return &logical.Response{
+		Data:     data.(map[string]interface{}),
+		Headers:  headers.(map[string][]string),
+		Redirect: resp.Redirect,
+		Warnings: append([]string(nil), resp.Warnings...),
+	}, nil
+}
+
+// idempotencyFingerprint identifies the operation, path and data of req, so
+// that reusing an idempotency key for a different request can be detected.
+func idempotencyFingerprint(req *logical.Request) (string, error) {
+	data, err := json.Marshal(req.Data)
+	if err != nil {
+		return "", err
+	}
+	return idempotencyHash(string(req.Operation), req.Path, string(data)), nil
+}
+
+func idempotencyHash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
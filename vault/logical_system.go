@@ -30,6 +30,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/go-uuid"
+	semver "github.com/hashicorp/go-version"
 	"github.com/hashicorp/vault/helper/hostutil"
 	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/metricsutil"
@@ -91,6 +92,7 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"remount",
 				"audit",
 				"audit/*",
+				"audit-stream/*",
 				"raw",
 				"raw/*",
 				"replication/primary/secondary-token",
@@ -109,6 +111,7 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 				"leases/revoke-prefix/*",
 				"leases/revoke-force/*",
 				"leases/lookup/*",
+				"leases/metadata",
 				"storage/raft/snapshot-auto/config/*",
 				"leases",
 			},
@@ -186,6 +189,7 @@ func NewSystemBackend(core *Core, logger log.Logger) *SystemBackend {
 	b.Backend.Paths = append(b.Backend.Paths, b.inFlightRequestPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.hostInfoPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.quotasPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.webhooksPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.rootActivityPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.loginMFAPaths()...)
 
@@ -250,11 +254,39 @@ func (b *SystemBackend) handleConfigReload(ctx context.Context, req *logical.Req
 	switch subsystem {
 	case "license":
 		return handleLicenseReload(b)(ctx, req, data)
+	case "listeners":
+		return b.handleListenersReload(ctx, req, data)
 	}
 
 	return nil, logical.ErrUnsupportedPath
 }
 
+// handleListenersReload adds and removes listeners to match the server
+// configuration files.
+func (b *SystemBackend) handleListenersReload(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if b.Core.listenerReloader == nil {
+		return logical.ErrorResponse("listeners can only be reloaded on a server started from configuration files"), logical.ErrInvalidRequest
+	}
+
+	added, removed, err := b.Core.listenerReloader()
+	if err != nil {
+		return nil, fmt.Errorf("error reloading listeners: %w", err)
+	}
+
+	if added == nil {
+		added = []string{}
+	}
+	if removed == nil {
+		removed = []string{}
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"added":   added,
+			"removed": removed,
+		},
+	}, nil
+}
+
 // handleCORSRead returns the current CORS configuration
 func (b *SystemBackend) handleCORSRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	corsConf := b.Core.corsConfig
@@ -390,6 +422,40 @@ func (b *SystemBackend) handleLeaseList(ctx context.Context, req *logical.Reques
 	return resp, nil
 }
 
+func (b *SystemBackend) handleLeaseMetadataList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	filter := &leaseMetadataFilter{
+		prefix: d.Get("prefix").(string),
+	}
+	if expiresWithin := d.Get("expires_within").(int); expiresWithin > 0 {
+		filter.expiresBefore = time.Now().Add(time.Duration(expiresWithin) * time.Second)
+	}
+	if issuedAfter, ok := d.GetOk("issued_after"); ok {
+		filter.issuedAfter = issuedAfter.(time.Time)
+	}
+	if issuedBefore, ok := d.GetOk("issued_before"); ok {
+		filter.issuedBefore = issuedBefore.(time.Time)
+	}
+
+	includeAll, maxResults, err := processLimit(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	leases, warning, err := b.Core.expiration.listLeaseMetadata(ctx, filter, includeAll, maxResults)
+	if err != nil {
+		return handleErrorNoReadOnlyForward(err)
+	}
+
+	resp := &logical.Response{
+		Data: leases,
+	}
+	if warning != "" {
+		resp.AddWarning(warning)
+	}
+
+	return resp, nil
+}
+
 func (b *SystemBackend) handlePluginCatalogTypedList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	pluginType, err := consts.ParsePluginType(d.Get("type").(string))
 	if err != nil {
@@ -415,11 +481,65 @@ func (b *SystemBackend) handlePluginCatalogUntypedList(ctx context.Context, req
 			pluginsByType[pluginType.String()] = plugins
 		}
 	}
+
+	// The details are only returned on request, since clients which parse
+	// every key of the response as a plugin type would fail on them
+	if d.Get("detailed").(bool) {
+		detailed, err := b.pluginCatalogDetails(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pluginsByType["detailed"] = detailed
+	}
+
 	return &logical.Response{
 		Data: pluginsByType,
 	}, nil
 }
 
+// pluginCatalogDetails returns the details of every plugin of the catalog,
+// along with the mounts of the secrets engines and auth methods which run
+// it. A mount is outdated when the plugin it runs was registered again since
+// it was mounted or reloaded, which includes a builtin plugin overridden by
+// an external plugin.
+func (b *SystemBackend) pluginCatalogDetails(ctx context.Context) ([]map[string]interface{}, error) {
+	mounts := b.Core.pluginMounts()
+
+	var detailed []map[string]interface{}
+	for _, pluginType := range consts.PluginTypes {
+		details, err := b.Core.pluginCatalog.ListDetails(ctx, pluginType)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, plugin := range details {
+			paths := []string{}
+			outdated := []string{}
+			for _, mount := range mounts[pluginType][plugin.Name] {
+				paths = append(paths, mount.Path)
+				if mount.RunningVersion == "" && mount.RunningSha256 == "" {
+					// The backend of the mount was not set up from the catalog
+					continue
+				}
+				if mount.RunningSha256 != plugin.Sha256 || mount.RunningVersion != plugin.Version {
+					outdated = append(outdated, mount.Path)
+				}
+			}
+
+			detailed = append(detailed, map[string]interface{}{
+				"name":               plugin.Name,
+				"type":               plugin.Type.String(),
+				"builtin":            plugin.Builtin,
+				"version":            plugin.Version,
+				"deprecation_status": plugin.DeprecationStatus.String(),
+				"mounts":             paths,
+				"outdated_mounts":    outdated,
+			})
+		}
+	}
+	return detailed, nil
+}
+
 func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	pluginName := d.Get("name").(string)
 	if pluginName == "" {
@@ -469,7 +589,21 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, req *logi
 		return logical.ErrorResponse("Could not decode SHA-256 value from Hex"), err
 	}
 
-	err = b.Core.pluginCatalog.Set(ctx, pluginName, pluginType, parts[0], args, env, sha256Bytes)
+	// The version is optional, but must be a semantic version if given. It is
+	// stored with a "v" prefix, as the versions of builtin plugins are.
+	pluginVersion := d.Get("version").(string)
+	if pluginVersion != "" {
+		semanticVersion, err := semver.NewSemver(pluginVersion)
+		if err != nil {
+			return logical.ErrorResponse("version %q is not a valid semantic version: %s", pluginVersion, err), nil
+		}
+		if semanticVersion.Metadata() == "builtin" {
+			return logical.ErrorResponse("version %q is reserved for builtin plugins", pluginVersion), nil
+		}
+		pluginVersion = "v" + semanticVersion.String()
+	}
+
+	err = b.Core.pluginCatalog.Set(ctx, pluginName, pluginType, parts[0], args, env, sha256Bytes, pluginVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -520,6 +654,12 @@ func (b *SystemBackend) handlePluginCatalogRead(ctx context.Context, req *logica
 		"command": command,
 		"sha256":  hex.EncodeToString(plugin.Sha256),
 		"builtin": plugin.Builtin,
+		"version": plugin.Version,
+	}
+	if plugin.Builtin {
+		data["version"] = builtinPluginVersion()
+		status, _ := b.Core.builtinRegistry.DeprecationStatus(plugin.Name, pluginType)
+		data["deprecation_status"] = status.String()
 	}
 
 	return &logical.Response{
@@ -2724,6 +2864,70 @@ func (b *SystemBackend) handleAuditHash(ctx context.Context, req *logical.Reques
 	}, nil
 }
 
+// handleAuditStream streams the entries logged by an audit backend
+func (b *SystemBackend) handleAuditStream(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+	w := req.ResponseWriter
+	if w == nil {
+		return logical.ErrorResponse("streaming not supported"), nil
+	}
+
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if !ok {
+		// http.ResponseWriter is wrapped in wrapGenericHandler, so let's
+		// access the underlying functionality
+		nw, ok := w.ResponseWriter.(logical.WrappingResponseWriter)
+		if !ok {
+			return logical.ErrorResponse("streaming not supported"), nil
+		}
+		flusher, ok = nw.Wrapped().(http.Flusher)
+		if !ok {
+			return logical.ErrorResponse("streaming not supported"), nil
+		}
+	}
+
+	entries, stop, err := b.Core.auditBroker.Stream(path, 512)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	defer stop()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	// 0 byte write is needed before the Flush call so that if we are using
+	// a gzip stream it will go ahead and write out the HTTP response header
+	if _, err := w.Write([]byte("")); err != nil {
+		return nil, fmt.Errorf("error seeding flusher: %w", err)
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	// Stream entries until the connection is closed, the backend is
+	// disabled or the core is sealed. Errors are ignored upstream, since the
+	// response was already sent.
+	for {
+		select {
+		case <-ticker.C:
+			if b.Core.Sealed() {
+				return nil, nil
+			}
+		case <-ctx.Done():
+			return nil, nil
+		case entry, ok := <-entries:
+			if !ok {
+				return nil, nil
+			}
+			if _, err := w.Write(entry); err != nil {
+				return nil, fmt.Errorf("error streaming audit entry: %w", err)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
 // handleEnableAudit is used to enable a new audit backend
 func (b *SystemBackend) handleEnableAudit(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	repState := b.Core.ReplicationState()
@@ -2902,6 +3106,38 @@ func (b *SystemBackend) handleKeyStatus(ctx context.Context, req *logical.Reques
 	return resp, nil
 }
 
+// handleSealMigrationStatus returns the progress of the rewrap of the storage
+// entries after a seal migration
+func (b *SystemBackend) handleSealMigrationStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	status := b.Core.SealRewrapStatus()
+	now := time.Now()
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"state": status.State,
+			"entries": map[string]interface{}{
+				"total":     status.EntriesTotal,
+				"processed": status.EntriesProcessed,
+				"rewrapped": status.EntriesRewrapped,
+				"failed":    status.EntriesFailed,
+			},
+			"entries_per_second": status.EntriesPerSecond(now),
+			"percent_complete":   status.PercentComplete(),
+			"eta_seconds":        int64(status.EstimatedTimeRemaining(now).Seconds()),
+		},
+	}
+	if !status.StartTime.IsZero() {
+		resp.Data["start_time"] = status.StartTime.Format(time.RFC3339Nano)
+	}
+	if !status.EndTime.IsZero() {
+		resp.Data["end_time"] = status.EndTime.Format(time.RFC3339Nano)
+	}
+	if status.Error != "" {
+		resp.Data["error"] = status.Error
+	}
+	return resp, nil
+}
+
 // handleKeyRotationConfigRead returns the barrier key rotation config
 func (b *SystemBackend) handleKeyRotationConfigRead(_ context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
 	// Get the key info
@@ -3185,7 +3421,7 @@ func (b *SystemBackend) handleMetrics(ctx context.Context, req *logical.Request,
 	if format == "" {
 		format = metricsutil.FormatFromRequest(req)
 	}
-	return b.Core.metricsHelper.ResponseForFormat(format), nil
+	return b.Core.metricsHelper.ResponseForRequest(format, req), nil
 }
 
 func (b *SystemBackend) handleInFlightRequestData(_ context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -3223,6 +3459,11 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse("unknown log level"), nil
 	}
 
+	filter, err := monitor.ParseFilter(data.Get("filter").(string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
 	flusher, ok := w.ResponseWriter.(http.Flusher)
 	if !ok {
 		// http.ResponseWriter is wrapped in wrapGenericHandler, so let's
@@ -3240,10 +3481,10 @@ func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request,
 	isJson := b.Core.LogFormat() == "json"
 	logger := b.Core.Logger().(log.InterceptLogger)
 
-	mon, err := monitor.NewMonitor(512, logger, &log.LoggerOptions{
+	mon, err := monitor.NewFilteredMonitor(512, logger, &log.LoggerOptions{
 		Level:      logLevel,
 		JSONFormat: isJson,
-	})
+	}, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -4859,6 +5100,16 @@ or delete a policy.
 		"",
 	},
 
+	"audit-stream": {
+		"Stream the entries logged by the given audit backend.",
+		`
+Streams the entries the given audit backend logs for requests handled by this
+node, as JSON lines, until the connection is closed. Entries are hashed with
+the salt of the backend, so that hashes match its log. Entries are dropped if
+the client does not read them fast enough.
+		`,
+	},
+
 	"audit-table": {
 		"List the currently enabled audit backends.",
 		`
@@ -4916,6 +5167,32 @@ Enable a new audit backend or disable an existing backend.
 		`,
 	},
 
+	"webhooks-list": {
+		"Lists the webhooks of the namespace.",
+		`
+		Lists the names of the webhooks notified of the events of the namespace.
+		`,
+	},
+
+	"webhooks": {
+		"Configures a webhook notified of the events of the namespace.",
+		`
+		Webhooks are URLs which are sent a signed POST request when the events
+		they subscribed to occur in the namespace: KV writes, lease revocations
+		and mount changes. Failed deliveries are retried with an exponential
+		backoff, and reading a webhook returns its delivery statistics.
+		`,
+	},
+
+	"sealwrap-migration-status": {
+		"Reports the progress of the rewrap of the storage entries after a seal migration.",
+		`
+		Reports the state of the rewrap of the storage entries that follows a seal
+		migration, the number of entries processed and rewrapped, the rate at
+		which they are processed and the estimated time remaining.
+		`,
+	},
+
 	"rotate-config": {
 		"Configures settings related to the backend encryption key management.",
 		`
@@ -5028,6 +5305,10 @@ This path responds to the following HTTP methods.
 This path responds to the following HTTP methods.
 		LIST /
 			Returns a list of names of configured plugins.
+
+		GET /?detailed=true
+			Also returns the details of the plugins: whether they are builtin,
+			their version and deprecation status, and the mounts using them.
 		`,
 	},
 	"plugin-catalog": {
@@ -5075,6 +5356,15 @@ plugin directory.`,
 Each entry is of the form "key=value".`,
 		"",
 	},
+	"plugin-catalog_version": {
+		`The semantic version of the plugin.`,
+		"",
+	},
+	"plugin-catalog_detailed": {
+		`Whether to return the details of the plugins along with
+their names.`,
+		"",
+	},
 	"leases": {
 		`View or list lease metadata.`,
 		`
@@ -5203,6 +5493,15 @@ This path responds to the following HTTP methods.
 		"List leases associated with this Vault cluster",
 		"Requires sudo capability. List leases associated with this Vault cluster",
 	},
+	"list-lease-metadata": {
+		"List the metadata of leases matching the given filters.",
+		`
+Requires sudo capability. Returns the ID, issue time, expire time, last
+renewal time, TTL and renewability of every lease in the namespace which
+matches the prefix, expires_within, issued_after and issued_before filters.
+Leases are sorted by increasing expire time, with non-expiring leases last.
+		`,
+	},
 	"version-history": {
 		"List historical version changes sorted by installation time in ascending order.",
 		`
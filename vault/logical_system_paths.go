@@ -528,6 +528,24 @@ func (b *SystemBackend) auditPaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["audit-hash"][1]),
 		},
 
+		{
+			Pattern: "audit-stream/(?P<path>.+)",
+
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["audit_path"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleAuditStream,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["audit-stream"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["audit-stream"][1]),
+		},
+
 		{
 			Pattern: "audit$",
 
@@ -686,6 +704,20 @@ func (b *SystemBackend) sealPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["rotate"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["rotate"][1]),
 		},
+
+		{
+			Pattern: "sealwrap/migration-status$",
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleSealMigrationStatus,
+					Summary:  "Report the progress of the rewrap of the storage entries after a seal migration.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sealwrap-migration-status"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sealwrap-migration-status"][1]),
+		},
 	}
 }
 
@@ -722,6 +754,10 @@ func (b *SystemBackend) pluginsCatalogCRUDPath() *framework.Path {
 				Type:        framework.TypeStringSlice,
 				Description: strings.TrimSpace(sysHelp["plugin-catalog_env"][0]),
 			},
+			"version": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_version"][0]),
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -769,6 +805,13 @@ func (b *SystemBackend) pluginsCatalogListPaths() []*framework.Path {
 		{
 			Pattern: "plugins/catalog/?$",
 
+			Fields: map[string]*framework.FieldSchema{
+				"detailed": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["plugin-catalog_detailed"][0]),
+				},
+			},
+
 			Callbacks: map[logical.Operation]framework.OperationFunc{
 				logical.ReadOperation: b.handlePluginCatalogUntypedList,
 			},
@@ -1277,6 +1320,43 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["count-leases"][1]),
 		},
 
+		{
+			Pattern: "leases/metadata$",
+			Fields: map[string]*framework.FieldSchema{
+				"prefix": {
+					Type:        framework.TypeString,
+					Description: "Only return leases whose ID starts with this prefix, such as the path of a mount.",
+				},
+				"expires_within": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Only return leases which expire within this duration.",
+				},
+				"issued_after": {
+					Type:        framework.TypeTime,
+					Description: "Only return leases issued at or after this time, in RFC3339 format.",
+				},
+				"issued_before": {
+					Type:        framework.TypeTime,
+					Description: "Only return leases issued before this time, in RFC3339 format.",
+				},
+				"limit": {
+					Type:        framework.TypeString,
+					Default:     "",
+					Description: "Set to a positive integer of the maximum number of entries to return. If you want all results, set to 'none'. If not set, you will get a maximum of 10,000 results returned.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleLeaseMetadataList,
+					Summary:  "Returns the metadata of leases matching the given filters.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["list-lease-metadata"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["list-lease-metadata"][1]),
+		},
+
 		{
 			Pattern: "leases$",
 			Fields: map[string]*framework.FieldSchema{
@@ -1382,6 +1462,11 @@ func (b *SystemBackend) monitorPath() *framework.Path {
 				Description: "Log level to view system logs at. Currently supported values are \"trace\", \"debug\", \"info\", \"warn\", \"error\".",
 				Query:       true,
 			},
+			"filter": {
+				Type:        framework.TypeString,
+				Description: "Filter expression selecting the log entries to stream, such as \"subsystem == core.expiration and level >= warn\". Matchers on the subsystem, level, message, namespace or any other field of the entries are joined with \"and\".",
+				Query:       true,
+			},
 		},
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation: b.handleMonitor,
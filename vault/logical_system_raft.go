@@ -1,11 +1,17 @@
 package vault
 
 import (
+	"archive/tar"
 	"context"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"strings"
 	"time"
 
@@ -16,6 +22,7 @@ import (
 	proto "github.com/golang/protobuf/proto"
 	wrapping "github.com/hashicorp/go-kms-wrapping"
 	uuid "github.com/hashicorp/go-uuid"
+	raftlib "github.com/hashicorp/raft"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/physical/raft"
 )
@@ -148,6 +155,42 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-force"][0]),
 			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-force"][1]),
 		},
+		{
+			Pattern: "storage/raft/snapshot-delta",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotDeltaWrite(),
+					Summary:  "Returns a delta snapshot holding the changes made since the snapshot described by the provided manifest.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-delta"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-delta"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-chain",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotChainWrite(false),
+					Summary:  "Installs the provided snapshot and the delta snapshots taken after it, returning the cluster to the state defined by the last one.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-chain"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-chain"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-chain-force",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotChainWrite(true),
+					Summary:  "Installs the provided snapshot and the delta snapshots taken after it. This bypasses checks ensuring the current Autounseal or Shamir keys are consistent with the snapshot data.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-chain-force"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-chain-force"][1]),
+		},
 		{
 			Pattern: "storage/raft/autopilot/state",
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -546,104 +589,270 @@ func (b *SystemBackend) handleStorageRaftSnapshotWrite(force bool) framework.Ope
 			access = nil
 		}
 
+		if err := b.Core.raftSnapshotRestore.start(req.HTTPRequest.ContentLength); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
 		// We want to buffer the http request reader into a temp file here so we
 		// don't have to hold the full snapshot in memory. We also want to do
 		// the restore in two parts so we can restore the snapshot while the
 		// stateLock is write locked.
-		snapFile, cleanup, metadata, err := raftStorage.WriteSnapshotToTemp(req.HTTPRequest.Body, access)
-		switch {
-		case err == nil:
-		case strings.Contains(err.Error(), "failed to open the sealed hashes"):
-			switch b.Core.seal.BarrierType() {
-			case wrapping.Shamir:
-				return logical.ErrorResponse("could not verify hash file, possibly the snapshot is using a different set of unseal keys; use the snapshot-force API to bypass this check"), logical.ErrInvalidRequest
-			default:
-				return logical.ErrorResponse("could not verify hash file, possibly the snapshot is using a different autoseal key; use the snapshot-force API to bypass this check"), logical.ErrInvalidRequest
-			}
-		case err != nil:
-			b.Core.logger.Error("raft snapshot restore: failed to write snapshot", "error", err)
+		body := ioutil.NopCloser(b.Core.raftSnapshotRestore.reader(req.HTTPRequest.Body))
+		snapFile, cleanup, metadata, err := raftStorage.WriteSnapshotToTemp(body, access)
+		if err != nil {
+			b.Core.raftSnapshotRestore.finish(err)
+			return b.raftSnapshotReadError(err)
+		}
+
+		b.restoreRaftSnapshot(cleanup, raftSnapshotSize(snapFile), func(ctx context.Context) error {
+			return raftStorage.RestoreSnapshot(ctx, metadata, b.Core.raftSnapshotRestore.reader(snapFile))
+		})
+
+		return nil, nil
+	}
+}
+
+// handleStorageRaftSnapshotDeltaWrite streams back a delta snapshot holding
+// the changes made since the snapshot described by the manifest in the
+// request body.
+func (b *SystemBackend) handleStorageRaftSnapshotDeltaWrite() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		raftStorage, ok := b.Core.underlyingPhysical.(*raft.RaftBackend)
+		if !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+		if req.HTTPRequest == nil || req.HTTPRequest.Body == nil {
+			return nil, errors.New("no reader for request")
+		}
+		if req.ResponseWriter == nil {
+			return nil, errors.New("no writer for request")
+		}
+
+		parent := req.HTTPRequest.URL.Query().Get("parent")
+		if parent == "" {
+			return logical.ErrorResponse("missing parent snapshot ID"), logical.ErrInvalidRequest
+		}
+
+		manifest, err := raft.DecodeDeltaManifest(req.HTTPRequest.Body)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		if err := raftStorage.SnapshotDeltaHTTP(req.ResponseWriter, parent, manifest, b.Core.seal.GetAccess()); err != nil {
 			return nil, err
 		}
 
-		// We want to do this in a go routine so we can upgrade the lock and
-		// allow the client to disconnect.
-		go func() (retErr error) {
-			// Cleanup the temp file
-			defer cleanup()
+		return nil, nil
+	}
+}
 
-			// Grab statelock
-			if stopped := grabLockOrStop(b.Core.stateLock.Lock, b.Core.stateLock.Unlock, b.Core.standbyStopCh.Load().(chan struct{})); stopped {
-				b.Core.logger.Error("not applying snapshot; shutting down")
-				return
-			}
-			defer b.Core.stateLock.Unlock()
-
-			// If we failed to restore the snapshot we should seal this node as
-			// it's in an unknown state
-			defer func() {
-				if retErr != nil {
-					if err := b.Core.sealInternalWithOptions(false, false, true); err != nil {
-						b.Core.logger.Error("failed to seal node", "error", err)
-					}
-				}
-			}()
+// handleStorageRaftSnapshotChainWrite restores a snapshot chain: a tar stream
+// holding a full snapshot followed by the delta snapshots to apply on top of
+// it, in order.
+func (b *SystemBackend) handleStorageRaftSnapshotChainWrite(force bool) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		raftStorage, ok := b.Core.underlyingPhysical.(*raft.RaftBackend)
+		if !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+		if req.HTTPRequest == nil || req.HTTPRequest.Body == nil {
+			return nil, errors.New("no reader for request")
+		}
 
-			ctx, ctxCancel := context.WithCancel(namespace.RootContext(nil))
+		access := b.Core.seal.GetAccess()
+		if force {
+			access = nil
+		}
 
-			// We are calling the callback function synchronously here while we
-			// have the lock. So set it to nil and restore the callback when we
-			// finish.
-			raftStorage.SetRestoreCallback(nil)
-			defer raftStorage.SetRestoreCallback(b.Core.raftSnapshotRestoreCallback(true, true))
+		if err := b.Core.raftSnapshotRestore.start(req.HTTPRequest.ContentLength); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
 
-			// Do a preSeal to clear vault's in-memory caches and shut down any
-			// systems that might be holding the encryption access.
-			b.Core.logger.Info("shutting down prior to restoring snapshot")
-			if err := b.Core.preSeal(); err != nil {
-				b.Core.logger.Error("raft snapshot restore failed preSeal", "error", err)
-				return err
+		var cleanups []func()
+		cleanup := func() {
+			for _, cleanup := range cleanups {
+				cleanup()
 			}
+		}
 
-			b.Core.logger.Info("applying snapshot")
-			if err := raftStorage.RestoreSnapshot(ctx, metadata, snapFile); err != nil {
-				b.Core.logger.Error("error while restoring raft snapshot", "error", err)
-				return err
+		// The restore is only tracked by restoreRaftSnapshot once the whole
+		// chain has been received
+		received := false
+		defer func() {
+			if !received {
+				b.Core.raftSnapshotRestore.finish(errors.New("failed to receive the snapshot chain"))
 			}
+		}()
 
-			// Run invalidation logic synchronously here
-			callback := b.Core.raftSnapshotRestoreCallback(false, false)
-			if err := callback(ctx); err != nil {
-				return err
+		var snapFile *os.File
+		var metadata raftlib.SnapshotMeta
+		var deltas []io.Reader
+		var parent string
+		chain := tar.NewReader(b.Core.raftSnapshotRestore.reader(req.HTTPRequest.Body))
+		for i := 0; ; i++ {
+			_, err := chain.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				cleanup()
+				return logical.ErrorResponse(fmt.Sprintf("failed to read snapshot chain: %s", err)), logical.ErrInvalidRequest
 			}
 
-			{
-				// If the snapshot was taken while another node was leader we
-				// need to reset the leader information to this node.
-				if err := b.Core.underlyingPhysical.Put(ctx, &physical.Entry{
-					Key:   CoreLockPath,
-					Value: []byte(b.Core.leaderUUID),
-				}); err != nil {
-					b.Core.logger.Error("cluster setup failed", "error", err)
-					return err
+			// The ID of each snapshot is the sum of its content, which the
+			// next delta in the chain must refer to as its parent.
+			h := sha256.New()
+			in := io.TeeReader(chain, h)
+
+			if i == 0 {
+				var snapCleanup func()
+				snapFile, snapCleanup, metadata, err = raftStorage.WriteSnapshotToTemp(ioutil.NopCloser(in), access)
+				if err != nil {
+					cleanup()
+					return b.raftSnapshotReadError(err)
+				}
+				cleanups = append(cleanups, snapCleanup)
+			} else {
+				deltaFile, deltaCleanup, deltaMetadata, err := raftStorage.WriteDeltaToTemp(in, access)
+				if err != nil {
+					cleanup()
+					return b.raftSnapshotReadError(err)
 				}
-				// re-advertise our cluster information
-				if err := b.Core.advertiseLeader(ctx, b.Core.leaderUUID, nil); err != nil {
-					b.Core.logger.Error("cluster setup failed", "error", err)
-					return err
+				cleanups = append(cleanups, deltaCleanup)
+				if deltaMetadata.Parent != parent {
+					cleanup()
+					return logical.ErrorResponse(fmt.Sprintf("delta snapshot %d does not apply to the snapshot before it in the chain", i)), logical.ErrInvalidRequest
 				}
+				deltas = append(deltas, deltaFile)
 			}
-			if err := b.Core.postUnseal(ctx, ctxCancel, standardUnsealStrategy{}); err != nil {
-				b.Core.logger.Error("raft snapshot restore failed postUnseal", "error", err)
-				return err
+
+			if _, err := io.Copy(ioutil.Discard, in); err != nil {
+				cleanup()
+				return nil, err
 			}
+			parent = hex.EncodeToString(h.Sum(nil))
+		}
+		if snapFile == nil {
+			return logical.ErrorResponse("snapshot chain is empty"), logical.ErrInvalidRequest
+		}
 
-			return nil
-		}()
+		received = true
+		size := raftSnapshotSize(append([]io.Reader{snapFile}, deltas...)...)
+		for i, delta := range deltas {
+			deltas[i] = b.Core.raftSnapshotRestore.reader(delta)
+		}
+		b.restoreRaftSnapshot(cleanup, size, func(ctx context.Context) error {
+			return raftStorage.RestoreSnapshotChain(ctx, metadata, b.Core.raftSnapshotRestore.reader(snapFile), deltas...)
+		})
 
 		return nil, nil
 	}
 }
 
+// raftSnapshotReadError turns an error reading a snapshot into a response,
+// pointing at the force variant of the API when the seal could not verify it.
+func (b *SystemBackend) raftSnapshotReadError(err error) (*logical.Response, error) {
+	switch {
+	case strings.Contains(err.Error(), "failed to open the sealed hashes"):
+		switch b.Core.seal.BarrierType() {
+		case wrapping.Shamir:
+			return logical.ErrorResponse("could not verify hash file, possibly the snapshot is using a different set of unseal keys; use the snapshot-force API to bypass this check"), logical.ErrInvalidRequest
+		default:
+			return logical.ErrorResponse("could not verify hash file, possibly the snapshot is using a different autoseal key; use the snapshot-force API to bypass this check"), logical.ErrInvalidRequest
+		}
+	default:
+		b.Core.logger.Error("raft snapshot restore: failed to write snapshot", "error", err)
+		return nil, err
+	}
+}
+
+// restoreRaftSnapshot runs restore in the background, once it has upgraded
+// the state lock, and then brings the node back up with the restored data.
+func (b *SystemBackend) restoreRaftSnapshot(cleanup func(), size int64, restore func(context.Context) error) {
+	raftStorage := b.Core.underlyingPhysical.(*raft.RaftBackend)
+
+	// We want to do this in a go routine so we can upgrade the lock and
+	// allow the client to disconnect.
+	go func() (retErr error) {
+		// Cleanup the temp file
+		defer cleanup()
+
+		// Record the outcome of the restore
+		defer func() {
+			b.Core.raftSnapshotRestore.finish(retErr)
+		}()
+
+		// Grab statelock
+		if stopped := grabLockOrStop(b.Core.stateLock.Lock, b.Core.stateLock.Unlock, b.Core.standbyStopCh.Load().(chan struct{})); stopped {
+			b.Core.logger.Error("not applying snapshot; shutting down")
+			return errors.New("not applying snapshot; shutting down")
+		}
+		defer b.Core.stateLock.Unlock()
+
+		// If we failed to restore the snapshot we should seal this node as
+		// it's in an unknown state
+		defer func() {
+			if retErr != nil {
+				if err := b.Core.sealInternalWithOptions(false, false, true); err != nil {
+					b.Core.logger.Error("failed to seal node", "error", err)
+				}
+			}
+		}()
+
+		ctx, ctxCancel := context.WithCancel(namespace.RootContext(nil))
+
+		// We are calling the callback function synchronously here while we
+		// have the lock. So set it to nil and restore the callback when we
+		// finish.
+		raftStorage.SetRestoreCallback(nil)
+		defer raftStorage.SetRestoreCallback(b.Core.raftSnapshotRestoreCallback(true, true))
+
+		// Do a preSeal to clear vault's in-memory caches and shut down any
+		// systems that might be holding the encryption access.
+		b.Core.logger.Info("shutting down prior to restoring snapshot")
+		if err := b.Core.preSeal(); err != nil {
+			b.Core.logger.Error("raft snapshot restore failed preSeal", "error", err)
+			return err
+		}
+
+		b.Core.logger.Info("applying snapshot")
+		b.Core.raftSnapshotRestore.applying(size)
+		if err := restore(ctx); err != nil {
+			b.Core.logger.Error("error while restoring raft snapshot", "error", err)
+			return err
+		}
+
+		// Run invalidation logic synchronously here
+		callback := b.Core.raftSnapshotRestoreCallback(false, false)
+		if err := callback(ctx); err != nil {
+			return err
+		}
+
+		{
+			// If the snapshot was taken while another node was leader we
+			// need to reset the leader information to this node.
+			if err := b.Core.underlyingPhysical.Put(ctx, &physical.Entry{
+				Key:   CoreLockPath,
+				Value: []byte(b.Core.leaderUUID),
+			}); err != nil {
+				b.Core.logger.Error("cluster setup failed", "error", err)
+				return err
+			}
+			// re-advertise our cluster information
+			if err := b.Core.advertiseLeader(ctx, b.Core.leaderUUID, nil); err != nil {
+				b.Core.logger.Error("cluster setup failed", "error", err)
+				return err
+			}
+		}
+		if err := b.Core.postUnseal(ctx, ctxCancel, standardUnsealStrategy{}); err != nil {
+			b.Core.logger.Error("raft snapshot restore failed postUnseal", "error", err)
+			return err
+		}
+
+		return nil
+	}()
+}
+
 var sysRaftHelp = map[string][2]string{
 	"raft-bootstrap-challenge": {
 		"Creates a challenge for the new peer to be joined to the raft cluster.",
@@ -670,6 +879,21 @@ var sysRaftHelp = map[string][2]string{
 		"Force restore a raft cluster snapshot",
 		"",
 	},
+	"raft-snapshot-delta": {
+		"Saves a delta snapshot from the raft cluster.",
+		`The request body is the manifest of the parent snapshot, the gzipped
+		list of its keys along with the SHA-256 sums of their values, and the
+		parent query parameter is the ID of that snapshot.`,
+	},
+	"raft-snapshot-chain": {
+		"Restores a raft cluster snapshot followed by delta snapshots.",
+		`The request body is a tar stream holding the full snapshot followed by
+		the delta snapshots to apply on top of it, in order.`,
+	},
+	"raft-snapshot-chain-force": {
+		"Force restore a raft cluster snapshot followed by delta snapshots",
+		"",
+	},
 	"raft-autopilot-state": {
 		"Returns the state of the raft cluster under integrated storage as seen by autopilot.",
 		"",
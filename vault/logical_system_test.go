@@ -40,6 +40,7 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 		"remount",
 		"audit",
 		"audit/*",
+		"audit-stream/*",
 		"raw",
 		"raw/*",
 		"replication/primary/secondary-token",
@@ -58,6 +59,7 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 		"leases/revoke-prefix/*",
 		"leases/revoke-force/*",
 		"leases/lookup/*",
+		"leases/metadata",
 		"storage/raft/snapshot-auto/config/*",
 		"leases",
 	}
@@ -1045,6 +1047,107 @@ func TestSystemBackend_leases(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_leases_metadata(t *testing.T) {
+	core, b, root := testCoreSystemBackend(t)
+
+	// Create two leases with different TTLs
+	leaseIDs := make(map[string]string)
+	for path, ttl := range map[string]string{"secret/long": "1h", "secret/short": "10m"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.Data["foo"] = "bar"
+		req.Data["ttl"] = ttl
+		req.ClientToken = root
+		if _, err := core.HandleRequest(namespace.RootContext(nil), req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		req = logical.TestRequest(t, logical.ReadOperation, path)
+		req.ClientToken = root
+		if err := core.PopulateTokenEntry(namespace.RootContext(nil), req); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp, err := core.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp == nil || resp.Secret == nil || resp.Secret.LeaseID == "" {
+			t.Fatalf("bad: %#v", resp)
+		}
+		leaseIDs[path] = resp.Secret.LeaseID
+	}
+
+	testCases := []struct {
+		name     string
+		data     map[string]interface{}
+		expected []string
+		warning  bool
+	}{
+		{
+			name:     "prefix",
+			data:     map[string]interface{}{"prefix": "secret/"},
+			expected: []string{leaseIDs["secret/short"], leaseIDs["secret/long"]},
+		},
+		{
+			name:     "other_prefix",
+			data:     map[string]interface{}{"prefix": "database/"},
+			expected: []string{},
+		},
+		{
+			name:     "expires_within",
+			data:     map[string]interface{}{"prefix": "secret/", "expires_within": "30m"},
+			expected: []string{leaseIDs["secret/short"]},
+		},
+		{
+			name:     "issued_after",
+			data:     map[string]interface{}{"prefix": "secret/", "issued_after": time.Now().Add(time.Hour).Format(time.RFC3339)},
+			expected: []string{},
+		},
+		{
+			name:     "issued_before",
+			data:     map[string]interface{}{"prefix": "secret/", "issued_before": time.Now().Add(time.Hour).Format(time.RFC3339)},
+			expected: []string{leaseIDs["secret/short"], leaseIDs["secret/long"]},
+		},
+		{
+			name:     "limit",
+			data:     map[string]interface{}{"prefix": "secret/", "limit": "1"},
+			expected: []string{leaseIDs["secret/short"]},
+			warning:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := logical.TestRequest(t, logical.ReadOperation, "leases/metadata")
+			req.Data = tc.data
+			resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if resp == nil || resp.Data == nil {
+				t.Fatalf("bad: %#v", resp)
+			}
+
+			leases := resp.Data["leases"].([]map[string]interface{})
+			actual := make([]string, 0, len(leases))
+			for _, lease := range leases {
+				actual = append(actual, lease["lease_id"].(string))
+				if lease["renewable"] != true || lease["ttl"].(int64) <= 0 {
+					t.Fatalf("bad lease: %#v", lease)
+				}
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("expected leases %v, got %v", tc.expected, actual)
+			}
+			if resp.Data["lease_count"] != len(tc.expected) {
+				t.Fatalf("bad lease_count: %v", resp.Data["lease_count"])
+			}
+			if tc.warning != (len(resp.Warnings) > 0) {
+				t.Fatalf("unexpected warnings: %v", resp.Warnings)
+			}
+		})
+	}
+}
+
 func TestSystemBackend_leases_list(t *testing.T) {
 	core, b, root := testCoreSystemBackend(t)
 
@@ -2787,6 +2890,31 @@ func TestSystemBackend_keyStatus(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_sealMigrationStatus(t *testing.T) {
+	b := testSystemBackend(t)
+	req := logical.TestRequest(t, logical.ReadOperation, "sealwrap/migration-status")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	exp := map[string]interface{}{
+		"state": SealRewrapStateNotStarted,
+		"entries": map[string]interface{}{
+			"total":     0,
+			"processed": 0,
+			"rewrapped": 0,
+			"failed":    0,
+		},
+		"entries_per_second": float64(0),
+		"percent_complete":   float64(0),
+		"eta_seconds":        int64(0),
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
+	}
+}
+
 func TestSystemBackend_rotateConfig(t *testing.T) {
 	b := testSystemBackend(t)
 	req := logical.TestRequest(t, logical.ReadOperation, "rotate/config")
@@ -2858,6 +2986,40 @@ func TestSystemBackend_rotate(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_configReloadListeners(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "config/reload/listeners")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != logical.ErrInvalidRequest || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error without a listener reloader, got %v: %v", resp, err)
+	}
+
+	c.SetListenerReloader(func() ([]string, []string, error) {
+		return []string{"tcp 127.0.0.1:8300"}, nil, nil
+	})
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	exp := map[string]interface{}{
+		"added":   []string{"tcp 127.0.0.1:8300"},
+		"removed": []string{},
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
+	}
+
+	c.SetListenerReloader(func() ([]string, []string, error) {
+		return nil, nil, fmt.Errorf("no config found at reload time")
+	})
+	_, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err == nil || !strings.Contains(err.Error(), "no config found at reload time") {
+		t.Fatalf("expected the reload error, got %v", err)
+	}
+}
+
 func testSystemBackend(t *testing.T) logical.Backend {
 	c, _, _ := TestCoreUnsealed(t)
 	return c.systemBackend
@@ -2905,11 +3067,13 @@ func TestSystemBackend_PluginCatalog_CRUD(t *testing.T) {
 
 	actualRespData := resp.Data
 	expectedRespData := map[string]interface{}{
-		"name":    "mysql-database-plugin",
-		"command": "",
-		"args":    []string(nil),
-		"sha256":  "",
-		"builtin": true,
+		"name":               "mysql-database-plugin",
+		"command":            "",
+		"args":               []string(nil),
+		"sha256":             "",
+		"builtin":            true,
+		"version":            builtinPluginVersion(),
+		"deprecation_status": "supported",
 	}
 	if !reflect.DeepEqual(actualRespData, expectedRespData) {
 		t.Fatalf("expected did not match actual, got %#v\n expected %#v\n", actualRespData, expectedRespData)
@@ -2955,11 +3119,38 @@ func TestSystemBackend_PluginCatalog_CRUD(t *testing.T) {
 		"args":    []string{"--test"},
 		"sha256":  "31",
 		"builtin": false,
+		"version": "",
 	}
 	if !reflect.DeepEqual(actual, expected) {
 		t.Fatalf("expected did not match actual, got %#v\n expected %#v\n", actual, expected)
 	}
 
+	// Set a version, which must be a semantic version
+	req = logical.TestRequest(t, logical.UpdateOperation, "plugins/catalog/database/test-plugin")
+	req.Data["sha256"] = hex.EncodeToString([]byte{'1'})
+	req.Data["command"] = command
+	for _, invalid := range []string{"latest", "1.2.3+builtin"} {
+		req.Data["version"] = invalid
+		resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil || !resp.IsError() {
+			t.Fatalf("expected version %q to be rejected, got resp: %#v, err: %v", invalid, resp, err)
+		}
+	}
+	req.Data["version"] = "1.2.3"
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp.Error() != nil {
+		t.Fatalf("err: %v %v", err, resp.Error())
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "plugins/catalog/database/test-plugin")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["version"] != "v1.2.3" {
+		t.Fatalf("expected version %q, got %q", "v1.2.3", resp.Data["version"])
+	}
+
 	// Delete plugin
 	req = logical.TestRequest(t, logical.DeleteOperation, "plugins/catalog/database/test-plugin")
 	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
@@ -2974,6 +3165,91 @@ func TestSystemBackend_PluginCatalog_CRUD(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_PluginCatalog_Detailed(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	// Bootstrap the pluginCatalog
+	sym, err := filepath.EvalSymlinks(os.TempDir())
+	if err != nil {
+		t.Fatalf("error: %v", err)
+	}
+	c.pluginCatalog.directory = sym
+
+	file, err := ioutil.TempFile(os.TempDir(), "temp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// Mount kv twice besides secret/, with kv-a/ as if it ran the builtin
+	// plugin, and then override kv with an external plugin
+	for _, path := range []string{"kv-a/", "kv-b/"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "mounts/"+path)
+		req.Data["type"] = "kv"
+		resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+		if err != nil || resp.IsError() {
+			t.Fatalf("err: %v %v", err, resp.Error())
+		}
+	}
+	c.mountsLock.Lock()
+	c.router.MatchingMountEntry(namespace.RootContext(nil), "kv-a/").RunningVersion = builtinPluginVersion()
+	c.mountsLock.Unlock()
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "plugins/catalog/secret/kv")
+	req.Data["sha256"] = hex.EncodeToString([]byte{'1'})
+	req.Data["command"] = filepath.Base(file.Name())
+	req.Data["version"] = "v0.11.0"
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp.Error() != nil {
+		t.Fatalf("err: %v %v", err, resp.Error())
+	}
+
+	// The details are only returned on request
+	req = logical.TestRequest(t, logical.ReadOperation, "plugins/catalog")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := resp.Data["detailed"]; ok {
+		t.Fatal("expected no details")
+	}
+
+	req.Data["detailed"] = true
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	details := make(map[string]map[string]interface{})
+	for _, plugin := range resp.Data["detailed"].([]map[string]interface{}) {
+		details[plugin["type"].(string)+"/"+plugin["name"].(string)] = plugin
+	}
+
+	expected := map[string]map[string]interface{}{
+		"database/mysql-database-plugin": {
+			"name":               "mysql-database-plugin",
+			"type":               "database",
+			"builtin":            true,
+			"version":            builtinPluginVersion(),
+			"deprecation_status": "supported",
+			"mounts":             []string{},
+			"outdated_mounts":    []string{},
+		},
+		"secret/kv": {
+			"name":               "kv",
+			"type":               "secret",
+			"builtin":            false,
+			"version":            "v0.11.0",
+			"deprecation_status": "",
+			"mounts":             []string{"secret/", "kv-a/", "kv-b/"},
+			"outdated_mounts":    []string{"kv-a/"},
+		},
+	}
+	for key, plugin := range expected {
+		if !reflect.DeepEqual(details[key], plugin) {
+			t.Fatalf("expected %#v to be %#v", details[key], plugin)
+		}
+	}
+}
+
 func TestSystemBackend_ToolsHash(t *testing.T) {
 	b := testSystemBackend(t)
 	req := logical.TestRequest(t, logical.UpdateOperation, "tools/hash")
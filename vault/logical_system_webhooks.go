@@ -0,0 +1,175 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// webhooksPaths returns the paths managing the webhooks of a namespace
+func (b *SystemBackend) webhooksPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "webhooks/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleWebhooksList,
+					Summary:  "List the webhooks of the namespace.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["webhooks-list"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["webhooks-list"][1]),
+		},
+		{
+			Pattern: "webhooks/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the webhook.",
+				},
+				"url": {
+					Type:        framework.TypeString,
+					Description: "The http or https URL the notifications are POSTed to.",
+				},
+				"secret": {
+					Type:        framework.TypeString,
+					Description: "The key of the HMAC-SHA256 signature of the notifications. It can't be read back.",
+				},
+				"events": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "The events the webhook is notified of: kv-write, lease-revoke and mount.",
+				},
+				"path_prefix": {
+					Type:        framework.TypeString,
+					Description: "If set, the webhook is only notified of the events whose path, relative to the namespace, starts with this prefix.",
+				},
+				"max_retries": {
+					Type:        framework.TypeInt,
+					Default:     WebhookDefaultMaxRetries,
+					Description: "The number of times a failed delivery is retried, with an exponential backoff, before the notification is dropped.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleWebhookRead,
+					Summary:  "Read the configuration and the delivery statistics of a webhook.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleWebhookUpdate,
+					Summary:  "Create or update a webhook.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleWebhookDelete,
+					Summary:  "Delete a webhook.",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["webhooks"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["webhooks"][1]),
+		},
+	}
+}
+
+// requestWebhooks returns the webhooks and the namespace of the request.
+func (b *SystemBackend) requestWebhooks(ctx context.Context) (*webhookManager, *namespace.Namespace, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b.Core.webhooks == nil {
+		return nil, nil, errors.New("webhooks are not available on this node")
+	}
+	return b.Core.webhooks, ns, nil
+}
+
+func (b *SystemBackend) handleWebhooksList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m, ns, err := b.requestWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(m.WebhookNames(ns.ID)), nil
+}
+
+func (b *SystemBackend) handleWebhookRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m, ns, err := b.requestWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w, stats := m.Webhook(ns.ID, d.Get("name").(string))
+	if w == nil {
+		return nil, nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"name":         w.Name,
+			"url":          w.URL,
+			"events":       w.Events,
+			"path_prefix":  w.PathPrefix,
+			"max_retries":  w.MaxRetries,
+			"delivered":    stats.Delivered,
+			"retries":      stats.Retries,
+			"dead_letters": stats.DeadLetters,
+		},
+	}
+	if stats.LastError != "" {
+		resp.Data["last_error"] = stats.LastError
+		resp.Data["last_error_time"] = stats.LastErrorTime.Format(time.RFC3339Nano)
+	}
+	return resp, nil
+}
+
+func (b *SystemBackend) handleWebhookUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m, ns, err := b.requestWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := d.Get("name").(string)
+
+	w, _ := m.Webhook(ns.ID, name)
+	if w == nil {
+		w = &Webhook{
+			Name:        name,
+			NamespaceID: ns.ID,
+			MaxRetries:  d.Get("max_retries").(int),
+		}
+	}
+	if raw, ok := d.GetOk("url"); ok {
+		w.URL = raw.(string)
+	}
+	if raw, ok := d.GetOk("secret"); ok {
+		w.Secret = raw.(string)
+	}
+	if raw, ok := d.GetOk("events"); ok {
+		w.Events = raw.([]string)
+	}
+	if raw, ok := d.GetOk("path_prefix"); ok {
+		w.PathPrefix = strings.TrimPrefix(raw.(string), "/")
+	}
+	if raw, ok := d.GetOk("max_retries"); ok {
+		w.MaxRetries = raw.(int)
+	}
+	if err := w.Validate(); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	if err := m.SetWebhook(ctx, w); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (b *SystemBackend) handleWebhookDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	m, ns, err := b.requestWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.DeleteWebhook(ctx, ns.ID, d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
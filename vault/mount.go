@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
@@ -304,6 +305,8 @@ type MountEntry struct {
 	Tainted               bool              `json:"tainted,omitempty"`                 // Set as a Write-Ahead flag for unmount/remount
 	MountState            string            `json:"mount_state,omitempty"`             // The current mount state.  The only non-empty mount state right now is "unmounting"
 	NamespaceID           string            `json:"namespace_id"`
+	RunningVersion        string            `json:"running_plugin_version,omitempty"` // Version of the plugin the backend runs
+	RunningSha256         string            `json:"running_sha256,omitempty"`         // SHA256 of the external plugin the backend runs
 
 	// namespace contains the populated namespace
 	namespace *namespace.Namespace
@@ -1433,9 +1436,81 @@ func (c *Core) newLogicalBackend(ctx context.Context, entry *MountEntry, sysView
 	}
 	addLicenseCallback(c, b)
 
+	if !ok {
+		c.setRunningPlugin(ctx, entry, conf["plugin_name"], consts.PluginTypeSecrets)
+	}
+
 	return b, nil
 }
 
+// setRunningPlugin records on entry the version and the SHA256 of the plugin
+// of the catalog its backend runs, so that the mounts which run an older
+// registration of a plugin can be told apart. The SHA256 of builtin plugins
+// is empty.
+func (c *Core) setRunningPlugin(ctx context.Context, entry *MountEntry, name string, pluginType consts.PluginType) {
+	if c.pluginCatalog == nil {
+		return
+	}
+	runner, err := c.pluginCatalog.Get(ctx, name, pluginType)
+	if err != nil || runner == nil {
+		return
+	}
+
+	entry.RunningVersion = runner.Version
+	entry.RunningSha256 = hex.EncodeToString(runner.Sha256)
+	if runner.Builtin {
+		entry.RunningVersion = builtinPluginVersion()
+	}
+}
+
+// pluginMount is a mount of a secrets engine or an auth method, along with the
+// plugin its backend runs.
+type pluginMount struct {
+	Path           string
+	RunningVersion string
+	RunningSha256  string
+}
+
+// pluginMounts returns the mounts of the secrets engines and auth methods of
+// every namespace, by plugin type and by the name of the plugin in the
+// catalog they are mounted with.
+func (c *Core) pluginMounts() map[consts.PluginType]map[string][]pluginMount {
+	mounts := map[consts.PluginType]map[string][]pluginMount{
+		consts.PluginTypeSecrets:    make(map[string][]pluginMount),
+		consts.PluginTypeCredential: make(map[string][]pluginMount),
+	}
+
+	add := func(pluginType consts.PluginType, aliases map[string]string, table *MountTable) {
+		if table == nil {
+			return
+		}
+		for _, entry := range table.Entries {
+			name := entry.Type
+			if alias, ok := aliases[name]; ok {
+				name = alias
+			}
+			if entry.Type == "plugin" {
+				name = entry.Config.PluginName
+			}
+			mounts[pluginType][name] = append(mounts[pluginType][name], pluginMount{
+				Path:           entry.APIPath(),
+				RunningVersion: entry.RunningVersion,
+				RunningSha256:  entry.RunningSha256,
+			})
+		}
+	}
+
+	c.mountsLock.RLock()
+	add(consts.PluginTypeSecrets, mountAliases, c.mounts)
+	c.mountsLock.RUnlock()
+
+	c.authLock.RLock()
+	add(consts.PluginTypeCredential, credentialAliases, c.auth)
+	c.authLock.RUnlock()
+
+	return mounts
+}
+
 // defaultMountTable creates a default mount table
 func (c *Core) defaultMountTable() *MountTable {
 	table := &MountTable{
@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -21,6 +22,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/pluginutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	backendplugin "github.com/hashicorp/vault/sdk/plugin"
+	"github.com/hashicorp/vault/sdk/version"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 )
@@ -436,7 +438,7 @@ func (c *PluginCatalog) UpgradePlugins(ctx context.Context, logger log.Logger) e
 		plugin.Command = filepath.Join(c.directory, plugin.Command)
 
 		// Upgrade the storage. At this point we don't know what type of plugin this is so pass in the unkonwn type.
-		runner, err := c.setInternal(ctx, pluginName, consts.PluginTypeUnknown, cmdOld, plugin.Args, plugin.Env, plugin.Sha256)
+		runner, err := c.setInternal(ctx, pluginName, consts.PluginTypeUnknown, cmdOld, plugin.Args, plugin.Env, plugin.Sha256, plugin.Version)
 		if err != nil {
 			if errors.Is(err, ErrPluginBadType) {
 				retErr = multierror.Append(retErr, fmt.Errorf("could not upgrade plugin %s: plugin of unknown type", pluginName))
@@ -514,7 +516,7 @@ func (c *PluginCatalog) get(ctx context.Context, name string, pluginType consts.
 
 // Set registers a new external plugin with the catalog, or updates an existing
 // external plugin. It takes the name, command and SHA256 of the plugin.
-func (c *PluginCatalog) Set(ctx context.Context, name string, pluginType consts.PluginType, command string, args []string, env []string, sha256 []byte) error {
+func (c *PluginCatalog) Set(ctx context.Context, name string, pluginType consts.PluginType, command string, args []string, env []string, sha256 []byte, pluginVersion string) error {
 	if c.directory == "" {
 		return ErrDirectoryNotConfigured
 	}
@@ -529,11 +531,11 @@ func (c *PluginCatalog) Set(ctx context.Context, name string, pluginType consts.
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	_, err := c.setInternal(ctx, name, pluginType, command, args, env, sha256)
+	_, err := c.setInternal(ctx, name, pluginType, command, args, env, sha256, pluginVersion)
 	return err
 }
 
-func (c *PluginCatalog) setInternal(ctx context.Context, name string, pluginType consts.PluginType, command string, args []string, env []string, sha256 []byte) (*pluginutil.PluginRunner, error) {
+func (c *PluginCatalog) setInternal(ctx context.Context, name string, pluginType consts.PluginType, command string, args []string, env []string, sha256 []byte, pluginVersion string) (*pluginutil.PluginRunner, error) {
 	// Best effort check to make sure the command isn't breaking out of the
 	// configured plugin directory.
 	commandFull := filepath.Join(c.directory, command)
@@ -579,6 +581,7 @@ func (c *PluginCatalog) setInternal(ctx context.Context, name string, pluginType
 		Args:    args,
 		Env:     env,
 		Sha256:  sha256,
+		Version: pluginVersion,
 		Builtin: false,
 	}
 
@@ -662,3 +665,57 @@ func (c *PluginCatalog) List(ctx context.Context, pluginType consts.PluginType)
 
 	return retList, nil
 }
+
+// pluginDetails are the details of a plugin of the catalog.
+type pluginDetails struct {
+	Name              string
+	Type              consts.PluginType
+	Builtin           bool
+	Version           string
+	DeprecationStatus consts.DeprecationStatus
+	Sha256            string
+}
+
+// ListDetails returns the details of the plugins of the given type, sorted by
+// name. External plugins take precedence over builtin plugins of the same
+// name, as with Get.
+func (c *PluginCatalog) ListDetails(ctx context.Context, pluginType consts.PluginType) ([]*pluginDetails, error) {
+	names, err := c.List(ctx, pluginType)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	details := make([]*pluginDetails, 0, len(names))
+	for _, name := range names {
+		runner, err := c.get(ctx, name, pluginType)
+		if err != nil {
+			return nil, err
+		}
+		if runner == nil {
+			continue
+		}
+
+		plugin := &pluginDetails{
+			Name:    name,
+			Type:    pluginType,
+			Builtin: runner.Builtin,
+			Version: runner.Version,
+			Sha256:  hex.EncodeToString(runner.Sha256),
+		}
+		if runner.Builtin {
+			plugin.Version = builtinPluginVersion()
+			plugin.DeprecationStatus, _ = c.builtinRegistry.DeprecationStatus(name, pluginType)
+		}
+		details = append(details, plugin)
+	}
+	return details, nil
+}
+
+// builtinPluginVersion returns the version of builtin plugins, which are
+// versioned with Vault.
+func builtinPluginVersion() string {
+	return "v" + version.GetVersion().Version + "+builtin"
+}
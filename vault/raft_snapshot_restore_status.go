@@ -0,0 +1,187 @@
+package vault
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	RaftSnapshotRestoreStateNotStarted = "not_started"
+	RaftSnapshotRestoreStateReceiving  = "receiving"
+	RaftSnapshotRestoreStateApplying   = "applying"
+	RaftSnapshotRestoreStateCompleted  = "completed"
+	RaftSnapshotRestoreStateFailed     = "failed"
+)
+
+var errRaftSnapshotRestoreInProgress = errors.New("a snapshot restore is already in progress")
+
+// RaftSnapshotRestoreStatus reports the progress of the last raft snapshot
+// restore run by this node. A restore first receives the snapshot from the
+// client, then applies it to raft once the node stopped serving requests; the
+// bytes are counted again for each of these phases.
+type RaftSnapshotRestoreStatus struct {
+	State          string
+	StartTime      time.Time
+	PhaseStartTime time.Time
+	EndTime        time.Time
+
+	// BytesTotal is the size of the data of the current phase, or -1 if the
+	// client did not send the size of the snapshot.
+	BytesTotal     int64
+	BytesProcessed int64
+	Error          string
+}
+
+// BytesPerSecond returns the rate at which the data of the current phase has
+// been processed.
+func (s *RaftSnapshotRestoreStatus) BytesPerSecond(now time.Time) float64 {
+	if s.PhaseStartTime.IsZero() || s.BytesProcessed == 0 {
+		return 0
+	}
+	end := now
+	if !s.EndTime.IsZero() {
+		end = s.EndTime
+	}
+	elapsed := end.Sub(s.PhaseStartTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.BytesProcessed) / elapsed
+}
+
+// PercentComplete returns how much of the data of the current phase has been
+// processed, or -1 if it is not known.
+func (s *RaftSnapshotRestoreStatus) PercentComplete() float64 {
+	switch {
+	case s.State == RaftSnapshotRestoreStateCompleted:
+		return 100
+	case s.BytesTotal < 0:
+		return -1
+	case s.BytesTotal == 0:
+		return 0
+	}
+	return float64(s.BytesProcessed) * 100 / float64(s.BytesTotal)
+}
+
+// EstimatedTimeRemaining returns the time left to process the data of the
+// current phase at the current rate, or zero if it is not known.
+func (s *RaftSnapshotRestoreStatus) EstimatedTimeRemaining(now time.Time) time.Duration {
+	if s.State != RaftSnapshotRestoreStateReceiving && s.State != RaftSnapshotRestoreStateApplying {
+		return 0
+	}
+	rate := s.BytesPerSecond(now)
+	if rate == 0 || s.BytesTotal <= s.BytesProcessed {
+		return 0
+	}
+	return time.Duration(float64(s.BytesTotal-s.BytesProcessed) / rate * float64(time.Second))
+}
+
+// raftSnapshotRestoreTracker tracks the state of the raft snapshot restores
+// of this node. It does not use the state lock, which restores hold while
+// they are applied, so that the status can be read during the restore.
+type raftSnapshotRestoreTracker struct {
+	l      sync.RWMutex
+	status RaftSnapshotRestoreStatus
+}
+
+// start records the beginning of a restore whose upload is size bytes long,
+// or -1 if unknown. It fails if another restore is in progress.
+func (t *raftSnapshotRestoreTracker) start(size int64) error {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	switch t.status.State {
+	case RaftSnapshotRestoreStateReceiving, RaftSnapshotRestoreStateApplying:
+		return errRaftSnapshotRestoreInProgress
+	}
+	now := time.Now()
+	t.status = RaftSnapshotRestoreStatus{
+		State:          RaftSnapshotRestoreStateReceiving,
+		StartTime:      now,
+		PhaseStartTime: now,
+		BytesTotal:     size,
+	}
+	return nil
+}
+
+// applying records that the received snapshot, of size bytes, is being
+// applied to raft.
+func (t *raftSnapshotRestoreTracker) applying(size int64) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	t.status.State = RaftSnapshotRestoreStateApplying
+	t.status.PhaseStartTime = time.Now()
+	t.status.BytesTotal = size
+	t.status.BytesProcessed = 0
+}
+
+// finish records the end of the restore, which failed if err is not nil.
+func (t *raftSnapshotRestoreTracker) finish(err error) {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	t.status.EndTime = time.Now()
+	if err != nil {
+		t.status.State = RaftSnapshotRestoreStateFailed
+		t.status.Error = err.Error()
+		return
+	}
+	t.status.State = RaftSnapshotRestoreStateCompleted
+	t.status.BytesProcessed = t.status.BytesTotal
+}
+
+func (t *raftSnapshotRestoreTracker) get() RaftSnapshotRestoreStatus {
+	t.l.RLock()
+	defer t.l.RUnlock()
+
+	status := t.status
+	if status.State == "" {
+		status.State = RaftSnapshotRestoreStateNotStarted
+	}
+	return status
+}
+
+// reader returns a reader counting the bytes read from r as processed by the
+// current phase.
+func (t *raftSnapshotRestoreTracker) reader(r io.Reader) io.Reader {
+	return &raftSnapshotRestoreReader{r: r, t: t}
+}
+
+type raftSnapshotRestoreReader struct {
+	r io.Reader
+	t *raftSnapshotRestoreTracker
+}
+
+func (r *raftSnapshotRestoreReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.t.l.Lock()
+		r.t.status.BytesProcessed += int64(n)
+		r.t.l.Unlock()
+	}
+	return n, err
+}
+
+// RaftSnapshotRestoreStatus returns the progress of the last raft snapshot
+// restore run by this node.
+func (c *Core) RaftSnapshotRestoreStatus() RaftSnapshotRestoreStatus {
+	return c.raftSnapshotRestore.get()
+}
+
+// raftSnapshotSize returns the size of the snapshot files received for a
+// restore.
+func raftSnapshotSize(files ...io.Reader) int64 {
+	var size int64
+	for _, f := range files {
+		if f, ok := f.(*os.File); ok {
+			if info, err := f.Stat(); err == nil {
+				size += info.Size()
+			}
+		}
+	}
+	return size
+}
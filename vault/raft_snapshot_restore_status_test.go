@@ -0,0 +1,62 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRaftSnapshotRestoreTracker(t *testing.T) {
+	tracker := new(raftSnapshotRestoreTracker)
+	if status := tracker.get(); status.State != RaftSnapshotRestoreStateNotStarted {
+		t.Fatalf("expected state %q, got %q", RaftSnapshotRestoreStateNotStarted, status.State)
+	}
+
+	if err := tracker.start(-1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.start(-1); err != errRaftSnapshotRestoreInProgress {
+		t.Fatalf("expected a second restore to be rejected, got %v", err)
+	}
+
+	if _, err := io.Copy(ioutil.Discard, tracker.reader(bytes.NewReader(make([]byte, 100)))); err != nil {
+		t.Fatal(err)
+	}
+	status := tracker.get()
+	if status.State != RaftSnapshotRestoreStateReceiving || status.BytesProcessed != 100 {
+		t.Fatalf("unexpected status: %#v", status)
+	}
+	if percent := status.PercentComplete(); percent != -1 {
+		t.Fatalf("expected an unknown percentage without the size of the upload, got %f", percent)
+	}
+
+	tracker.applying(400)
+	if _, err := io.CopyN(ioutil.Discard, tracker.reader(bytes.NewReader(make([]byte, 400))), 100); err != nil {
+		t.Fatal(err)
+	}
+	status = tracker.get()
+	if status.State != RaftSnapshotRestoreStateApplying || status.BytesProcessed != 100 || status.BytesTotal != 400 {
+		t.Fatalf("unexpected status: %#v", status)
+	}
+	if percent := status.PercentComplete(); percent != 25 {
+		t.Fatalf("expected 25%% to be applied, got %f", percent)
+	}
+
+	tracker.finish(nil)
+	status = tracker.get()
+	if status.State != RaftSnapshotRestoreStateCompleted || status.PercentComplete() != 100 || status.EndTime.IsZero() {
+		t.Fatalf("unexpected status: %#v", status)
+	}
+
+	// A new restore can start once the previous one is over
+	if err := tracker.start(10); err != nil {
+		t.Fatal(err)
+	}
+	tracker.finish(errors.New("failed to read snapshot"))
+	status = tracker.get()
+	if status.State != RaftSnapshotRestoreStateFailed || status.Error != "failed to read snapshot" {
+		t.Fatalf("unexpected status: %#v", status)
+	}
+}
@@ -456,6 +456,9 @@ func (c *Core) switchedLockHandleRequest(httpCtx context.Context, req *logical.R
 	if ok {
 		ctx = context.WithValue(ctx, logical.CtxKeyInFlightRequestID{}, inFlightReqID)
 	}
+	if ir, ok := idempotentReplayFromContext(httpCtx); ok {
+		ctx = ContextWithIdempotentReplay(ctx, ir)
+	}
 	resp, err = c.handleCancelableRequest(ctx, req)
 	req.SetTokenEntry(nil)
 	cancel()
@@ -637,6 +640,10 @@ func (c *Core) handleCancelableRequest(ctx context.Context, req *logical.Request
 	ctx = logical.IndexStateContext(ctx, walState)
 	var auth *logical.Auth
 	if c.isLoginRequest(ctx, req) {
+		// Logins are never replayed: their responses carry a token
+		if _, ok := idempotentReplayFromContext(ctx); ok {
+			return nil, ErrIdempotencyReplayUnavailable
+		}
 		resp, auth, err = c.handleLoginRequest(ctx, req)
 	} else {
 		resp, auth, err = c.handleRequest(ctx, req)
@@ -762,6 +769,10 @@ func (c *Core) handleCancelableRequest(ctx context.Context, req *logical.Request
 		}
 	}
 
+	if _, replay := idempotentReplayFromContext(ctx); err == nil && !resp.IsError() && !isControlGroupRun(req) && !replay {
+		c.notifyRequestWebhooks(ctx, req, entry)
+	}
+
 	if walState.LocalIndex != 0 || walState.ReplicatedIndex != 0 {
 		walState.ClusterID = c.clusterID.Load()
 		if walState.LocalIndex == 0 {
@@ -803,7 +814,11 @@ func (c *Core) isLoginRequest(ctx context.Context, req *logical.Request) bool {
 }
 
 func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
-	defer metrics.MeasureSince([]string{"core", "handle_request"}, time.Now())
+	start := time.Now()
+	defer func() {
+		metrics.MeasureSince([]string{"core", "handle_request"}, start)
+		metricsutil.ObserveRequestDuration(time.Since(start), req.Headers)
+	}()
 
 	var nonHMACReqDataKeys []string
 	entry := c.router.MatchingMountEntry(ctx, req.Path)
@@ -976,8 +991,15 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		}
 	}()
 
-	// Route the request
-	resp, routeErr := c.doRouting(ctx, req)
+	// Route the request, or replay the response recorded for a request sent
+	// with the same idempotency key
+	var resp *logical.Response
+	var routeErr error
+	if ir, ok := idempotentReplayFromContext(ctx); ok {
+		resp, routeErr = ir.replayedResponse()
+	} else {
+		resp, routeErr = c.doRouting(ctx, req)
+	}
 	if resp != nil {
 
 		// If wrapping is used, use the shortest between the request and response
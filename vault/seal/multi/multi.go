@@ -0,0 +1,241 @@
+package multi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	proto "github.com/golang/protobuf/proto"
+	"github.com/hashicorp/go-hclog"
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	"github.com/hashicorp/go-multierror"
+)
+
+// WrapperType is the type of the wrapper combining several seals
+const WrapperType = "multi"
+
+// mechanismMultiWrapped marks the blobs whose data key is encrypted by
+// several wrappers
+const mechanismMultiWrapped uint64 = 0x6d756c7469
+
+// Member is one of the wrappers combined by a Wrapper. Members with a lower
+// priority value are tried first.
+type Member struct {
+	Wrapper  wrapping.Wrapper
+	Priority int
+}
+
+// Wrapper is a wrapper that encrypts data with a random data key, and the data
+// key with each of its members, so that the data can be decrypted as long as
+// one of the members is available.
+type Wrapper struct {
+	members  []*Member
+	logger   hclog.Logger
+	envelope *wrapping.Envelope
+}
+
+var _ wrapping.Wrapper = (*Wrapper)(nil)
+
+// wrappedKey is the data key encrypted by one of the members. The key ID tells
+// apart the members of the same type; it is empty in the keys encrypted
+// before it was recorded.
+type wrappedKey struct {
+	Type  string `json:"type"`
+	KeyID string `json:"key_id,omitempty"`
+	Blob  []byte `json:"blob"`
+}
+
+// NewWrapper creates a new wrapper combining the members.
+func NewWrapper(opts *wrapping.WrapperOptions, members []*Member) *Wrapper {
+	if opts == nil {
+		opts = new(wrapping.WrapperOptions)
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = hclog.NewNullLogger()
+	}
+
+	sorted := make([]*Member, len(members))
+	copy(sorted, members)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	return &Wrapper{
+		members:  sorted,
+		logger:   logger,
+		envelope: wrapping.NewEnvelope(nil),
+	}
+}
+
+// Members returns the members of the wrapper, by priority.
+func (w *Wrapper) Members() []*Member {
+	return w.members
+}
+
+// Type returns the type for this particular wrapper implementation
+func (w *Wrapper) Type() string {
+	return WrapperType
+}
+
+// KeyID returns the key IDs of the members, by priority. The key ID of the
+// data encrypted while a member was unavailable lacks the key ID of that
+// member.
+func (w *Wrapper) KeyID() string {
+	keyIDs := make([]string, 0, len(w.members))
+	for _, m := range w.members {
+		keyIDs = append(keyIDs, m.Wrapper.KeyID())
+	}
+	return strings.Join(keyIDs, ",")
+}
+
+// HMACKeyID returns nothing, it's here to satisfy the interface
+func (w *Wrapper) HMACKeyID() string {
+	return ""
+}
+
+// Init initializes the members. It fails only if none of them can be
+// initialized.
+func (w *Wrapper) Init(ctx context.Context) error {
+	var errs *multierror.Error
+	for _, m := range w.members {
+		if err := m.Wrapper.Init(ctx); err != nil {
+			w.logger.Warn("failed to initialize seal", "seal_type", m.Wrapper.Type(), "priority", m.Priority, "error", err)
+			errs = multierror.Append(errs, err)
+		}
+	}
+	if errs != nil && len(errs.Errors) == len(w.members) {
+		return errs
+	}
+	return nil
+}
+
+// Finalize finalizes the members.
+func (w *Wrapper) Finalize(ctx context.Context) error {
+	var errs *multierror.Error
+	for _, m := range w.members {
+		if err := m.Wrapper.Finalize(ctx); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Encrypt encrypts the plaintext with a random data key, and the data key with
+// each of the available members. It fails only if none of the members is
+// available.
+func (w *Wrapper) Encrypt(ctx context.Context, plaintext []byte, aad []byte) (*wrapping.EncryptedBlobInfo, error) {
+	if plaintext == nil {
+		return nil, errors.New("given plaintext for encryption is nil")
+	}
+
+	env, err := w.envelope.Encrypt(plaintext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping data: %w", err)
+	}
+
+	var keys []wrappedKey
+	var keyIDs []string
+	var errs *multierror.Error
+	for _, m := range w.members {
+		blob, err := m.Wrapper.Encrypt(ctx, env.Key, nil)
+		if err == nil {
+			var b []byte
+			if b, err = proto.Marshal(blob); err == nil {
+				keys = append(keys, wrappedKey{Type: m.Wrapper.Type(), KeyID: m.Wrapper.KeyID(), Blob: b})
+				keyIDs = append(keyIDs, m.Wrapper.KeyID())
+				continue
+			}
+		}
+		w.logger.Warn("failed to encrypt data key with seal", "seal_type", m.Wrapper.Type(), "priority", m.Priority, "error", err)
+		errs = multierror.Append(errs, fmt.Errorf("%s seal: %w", m.Wrapper.Type(), err))
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("error encrypting data key with any seal: %w", errs)
+	}
+
+	wrapped, err := json.Marshal(keys)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding data keys: %w", err)
+	}
+
+	return &wrapping.EncryptedBlobInfo{
+		Ciphertext: env.Ciphertext,
+		IV:         env.IV,
+		KeyInfo: &wrapping.KeyInfo{
+			KeyID:      strings.Join(keyIDs, ","),
+			WrappedKey: wrapped,
+			Mechanism:  mechanismMultiWrapped,
+		},
+	}, nil
+}
+
+// Decrypt decrypts the data key with the first available member, by priority,
+// and decrypts the ciphertext with it. Each data key is only decrypted by the
+// members of its type and key ID. Data encrypted by a single seal, before it
+// was combined with others, is decrypted by the member of the same type.
+func (w *Wrapper) Decrypt(ctx context.Context, in *wrapping.EncryptedBlobInfo, aad []byte) ([]byte, error) {
+	if in == nil {
+		return nil, errors.New("given input for decryption is nil")
+	}
+
+	if in.KeyInfo == nil || in.KeyInfo.Mechanism != mechanismMultiWrapped {
+		return w.decryptSingle(ctx, in, aad)
+	}
+
+	var keys []wrappedKey
+	if err := json.Unmarshal(in.KeyInfo.WrappedKey, &keys); err != nil {
+		return nil, fmt.Errorf("error decoding data keys: %w", err)
+	}
+
+	var errs *multierror.Error
+	for _, m := range w.members {
+		for _, k := range keys {
+			if k.Type != m.Wrapper.Type() || (k.KeyID != "" && k.KeyID != m.Wrapper.KeyID()) {
+				continue
+			}
+
+			blob := new(wrapping.EncryptedBlobInfo)
+			if err := proto.Unmarshal(k.Blob, blob); err != nil {
+				return nil, fmt.Errorf("error decoding data key: %w", err)
+			}
+			key, err := m.Wrapper.Decrypt(ctx, blob, nil)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("%s seal: %w", m.Wrapper.Type(), err))
+				continue
+			}
+
+			plaintext, err := w.envelope.Decrypt(&wrapping.EnvelopeInfo{
+				Key:        key,
+				IV:         in.IV,
+				Ciphertext: in.Ciphertext,
+			}, aad)
+			if err != nil {
+				// Another member of the same type may have encrypted a key
+				// without key ID
+				errs = multierror.Append(errs, fmt.Errorf("%s seal: error decrypting data: %w", m.Wrapper.Type(), err))
+				continue
+			}
+			return plaintext, nil
+		}
+	}
+	if errs == nil {
+		return nil, errors.New("error decrypting data key: none of the seals encrypted it")
+	}
+	return nil, fmt.Errorf("error decrypting data key with any seal: %w", errs)
+}
+
+func (w *Wrapper) decryptSingle(ctx context.Context, in *wrapping.EncryptedBlobInfo, aad []byte) ([]byte, error) {
+	var errs *multierror.Error
+	for _, m := range w.members {
+		plaintext, err := m.Wrapper.Decrypt(ctx, in, aad)
+		if err == nil {
+			return plaintext, nil
+		}
+		errs = multierror.Append(errs, fmt.Errorf("%s seal: %w", m.Wrapper.Type(), err))
+	}
+	return nil, fmt.Errorf("error decrypting data with any seal: %w", errs)
+}
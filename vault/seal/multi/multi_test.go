@@ -0,0 +1,165 @@
+package multi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+	"github.com/hashicorp/vault/vault/seal"
+)
+
+func testWrapper(t *testing.T) (*Wrapper, *seal.ToggleableWrapper, *seal.ToggleableWrapper) {
+	t.Helper()
+
+	primary := &seal.ToggleableWrapper{Wrapper: wrapping.NewTestWrapper([]byte("primary"))}
+	secondary := &seal.ToggleableWrapper{Wrapper: wrapping.NewTestWrapper([]byte("secondary"))}
+	w := NewWrapper(nil, []*Member{
+		{Wrapper: secondary, Priority: 2},
+		{Wrapper: primary, Priority: 1},
+	})
+	if members := w.Members(); members[0].Wrapper != primary || members[1].Wrapper != secondary {
+		t.Fatal("members not sorted by priority")
+	}
+	return w, primary, secondary
+}
+
+func TestWrapper_Failover(t *testing.T) {
+	ctx := context.Background()
+	w, primary, secondary := testWrapper(t)
+
+	input := []byte("foo")
+	swi, err := w.Encrypt(ctx, input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swi.KeyInfo.KeyID != w.KeyID() {
+		t.Fatalf("got key ID %q, expected %q", swi.KeyInfo.KeyID, w.KeyID())
+	}
+
+	// Either seal decrypts the data
+	for _, down := range []*seal.ToggleableWrapper{primary, secondary} {
+		down.SetError(errors.New("unavailable"))
+		pt, err := w.Decrypt(ctx, swi, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(input, pt) {
+			t.Fatalf("expected %s, got %s", input, pt)
+		}
+		down.SetError(nil)
+	}
+
+	// The data encrypted while the primary seal is down gets a different key ID,
+	// and only the secondary seal decrypts it
+	primary.SetError(errors.New("unavailable"))
+	swi, err = w.Encrypt(ctx, input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swi.KeyInfo.KeyID == w.KeyID() {
+		t.Fatalf("expected a key ID different from %q", w.KeyID())
+	}
+	primary.SetError(nil)
+	secondary.SetError(errors.New("unavailable"))
+	if _, err := w.Decrypt(ctx, swi, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// Nothing can be encrypted without any seal
+	primary.SetError(errors.New("unavailable"))
+	if _, err := w.Encrypt(ctx, input, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestWrapper_DecryptSingle(t *testing.T) {
+	ctx := context.Background()
+	w, primary, secondary := testWrapper(t)
+
+	// Data encrypted by a seal before it was combined with others
+	input := []byte("foo")
+	swi, err := secondary.Encrypt(ctx, input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primary.SetError(errors.New("unavailable"))
+	pt, err := w.Decrypt(ctx, swi, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(input, pt) {
+		t.Fatalf("expected %s, got %s", input, pt)
+	}
+}
+
+// countingWrapper counts the calls to Decrypt
+type countingWrapper struct {
+	*wrapping.TestWrapper
+	decrypts int
+}
+
+func (c *countingWrapper) Decrypt(ctx context.Context, in *wrapping.EncryptedBlobInfo, aad []byte) ([]byte, error) {
+	c.decrypts++
+	return c.TestWrapper.Decrypt(ctx, in, aad)
+}
+
+func TestWrapper_SameType(t *testing.T) {
+	ctx := context.Background()
+
+	// Two members of the same type, with different keys
+	newMember := func(keyID string, priority int) *Member {
+		w := wrapping.NewTestWrapper([]byte(keyID))
+		w.SetKeyID(keyID)
+		return &Member{Wrapper: &countingWrapper{TestWrapper: w}, Priority: priority}
+	}
+	first, second := newMember("key-a", 1), newMember("key-b", 2)
+	w := NewWrapper(nil, []*Member{first, second})
+	counts := func() (int, int) {
+		return first.Wrapper.(*countingWrapper).decrypts, second.Wrapper.(*countingWrapper).decrypts
+	}
+
+	// The data key encrypted by the second member is only decrypted by it
+	input := []byte("foo")
+	swi, err := NewWrapper(nil, []*Member{second}).Encrypt(ctx, input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := w.Decrypt(ctx, swi, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(input, pt) {
+		t.Fatalf("expected %s, got %s", input, pt)
+	}
+	if a, b := counts(); a != 0 || b != 1 {
+		t.Fatalf("expected a single decryption by the second member, got %d and %d", a, b)
+	}
+
+	// Data keys encrypted without key ID are tried with every member of the
+	// type
+	var keys []wrappedKey
+	if err := json.Unmarshal(swi.KeyInfo.WrappedKey, &keys); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].KeyID != "key-b" {
+		t.Fatalf("expected a data key with key ID key-b, got %v", keys)
+	}
+	keys[0].KeyID = ""
+	if swi.KeyInfo.WrappedKey, err = json.Marshal(keys); err != nil {
+		t.Fatal(err)
+	}
+	pt, err = w.Decrypt(ctx, swi, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(input, pt) {
+		t.Fatalf("expected %s, got %s", input, pt)
+	}
+	if a, b := counts(); a != 1 || b != 2 {
+		t.Fatalf("expected a decryption by each member, got %d and %d", a, b)
+	}
+}
@@ -0,0 +1,437 @@
+// Package tpm provides a seal wrapper which protects the root key with a
+// TPM 2.0 device, so that a node can auto-unseal without a network KMS.
+package tpm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpmutil"
+	"github.com/hashicorp/go-hclog"
+	wrapping "github.com/hashicorp/go-kms-wrapping"
+)
+
+const (
+	// WrapperType is the seal type of the TPM wrapper.
+	WrapperType = "tpm"
+
+	// DefaultDevice is the TPM resource manager device of the Linux kernel.
+	DefaultDevice = "/dev/tpmrm0"
+
+	// maxPCR is the highest PCR index of a TPM 2.0 PC client platform.
+	maxPCR = 23
+)
+
+// srkTemplate is the template of the storage root key the data keys are
+// sealed under. The TPM derives the same key from its owner hierarchy seed
+// each time the template is used, so the key does not need to be persisted.
+var srkTemplate = tpm2.Public{
+	Type:    tpm2.AlgECC,
+	NameAlg: tpm2.AlgSHA256,
+	Attributes: tpm2.FlagFixedTPM | tpm2.FlagFixedParent | tpm2.FlagSensitiveDataOrigin |
+		tpm2.FlagUserWithAuth | tpm2.FlagRestricted | tpm2.FlagDecrypt | tpm2.FlagNoDA,
+	ECCParameters: &tpm2.ECCParams{
+		Symmetric: &tpm2.SymScheme{
+			Alg:     tpm2.AlgAES,
+			KeyBits: 128,
+			Mode:    tpm2.AlgCFB,
+		},
+		CurveID: tpm2.CurveNISTP256,
+	},
+}
+
+// openTPM opens the TPM device, it is replaced in tests.
+var openTPM = func(device string) (io.ReadWriteCloser, error) {
+	return tpm2.OpenTPM(device)
+}
+
+// Wrapper is a wrapping.Wrapper which encrypts each value with a random data
+// key, which is sealed by the TPM. The sealed data key is stored alongside
+// the ciphertext, and can only be unsealed by the same TPM, while the
+// configured PCRs hold the values they held when the key was sealed.
+type Wrapper struct {
+	// l serializes the commands sent to the TPM
+	l sync.Mutex
+
+	device    string
+	ownerAuth string
+	pcrs      tpm2.PCRSelection
+	keyID     string
+	logger    hclog.Logger
+	envelope  *wrapping.Envelope
+}
+
+var _ wrapping.Wrapper = (*Wrapper)(nil)
+
+// NewWrapper creates a new TPM wrapper.
+func NewWrapper(opts *wrapping.WrapperOptions) *Wrapper {
+	if opts == nil {
+		opts = new(wrapping.WrapperOptions)
+	}
+	return &Wrapper{
+		logger:   opts.Logger,
+		envelope: wrapping.NewEnvelope(nil),
+	}
+}
+
+// SetConfig sets the fields on the Wrapper object based on values from the
+// config parameter, and checks that the TPM can be used. Environment
+// variables take precedence over the config.
+//
+// Supported configuration:
+//   - Environment variable VAULT_TPM_DEVICE or config "device", the path to
+//     the TPM device, "/dev/tpmrm0" by default
+//   - Environment variable VAULT_TPM_PCRS or config "pcrs", a comma
+//     separated list of the PCRs the data keys are bound to
+//   - Environment variable VAULT_TPM_PCR_BANK or config "pcr_bank", the hash
+//     algorithm of the PCR bank, "sha256" by default
+//   - Environment variable VAULT_TPM_OWNER_AUTH or config "owner_auth", the
+//     authorization value of the owner hierarchy
+func (w *Wrapper) SetConfig(config map[string]string) (map[string]string, error) {
+	if config == nil {
+		config = map[string]string{}
+	}
+
+	w.device = DefaultDevice
+	switch {
+	case os.Getenv("VAULT_TPM_DEVICE") != "":
+		w.device = os.Getenv("VAULT_TPM_DEVICE")
+	case config["device"] != "":
+		w.device = config["device"]
+	}
+
+	switch {
+	case os.Getenv("VAULT_TPM_OWNER_AUTH") != "":
+		w.ownerAuth = os.Getenv("VAULT_TPM_OWNER_AUTH")
+	default:
+		w.ownerAuth = config["owner_auth"]
+	}
+
+	bank := "sha256"
+	switch {
+	case os.Getenv("VAULT_TPM_PCR_BANK") != "":
+		bank = os.Getenv("VAULT_TPM_PCR_BANK")
+	case config["pcr_bank"] != "":
+		bank = config["pcr_bank"]
+	}
+	hash, err := parsePCRBank(bank)
+	if err != nil {
+		return nil, err
+	}
+
+	pcrs := config["pcrs"]
+	if os.Getenv("VAULT_TPM_PCRS") != "" {
+		pcrs = os.Getenv("VAULT_TPM_PCRS")
+	}
+	pcrList, err := parsePCRs(pcrs)
+	if err != nil {
+		return nil, err
+	}
+	w.pcrs = tpm2.PCRSelection{Hash: hash, PCRs: pcrList}
+
+	// Check that the storage root key can be created, and identify the TPM
+	// by its public key
+	err = w.withSRK(func(rw io.ReadWriter, srk tpmutil.Handle, srkPublic []byte) error {
+		sum := sha256.Sum256(srkPublic)
+		w.keyID = hex.EncodeToString(sum[:8])
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error using TPM device %q: %w", w.device, err)
+	}
+
+	wrapperInfo := map[string]string{
+		"device":   w.device,
+		"pcr_bank": bank,
+		"key_id":   w.keyID,
+	}
+	if len(pcrList) > 0 {
+		wrapperInfo["pcrs"] = formatPCRs(pcrList)
+	}
+	return wrapperInfo, nil
+}
+
+// Type returns the type for this particular wrapper implementation
+func (w *Wrapper) Type() string {
+	return WrapperType
+}
+
+// KeyID returns the identifier of the storage root key of the TPM
+func (w *Wrapper) KeyID() string {
+	return w.keyID
+}
+
+// HMACKeyID returns nothing, it's here to satisfy the interface
+func (w *Wrapper) HMACKeyID() string {
+	return ""
+}
+
+// Init is called during core.Initialize. No-op at the moment.
+func (w *Wrapper) Init(_ context.Context) error {
+	return nil
+}
+
+// Finalize is called during shutdown. This is a no-op since the TPM device
+// is only opened for the duration of each operation.
+func (w *Wrapper) Finalize(_ context.Context) error {
+	return nil
+}
+
+// Encrypt encrypts the plaintext with a random data key, and seals the data
+// key with the TPM.
+func (w *Wrapper) Encrypt(_ context.Context, plaintext []byte, aad []byte) (*wrapping.EncryptedBlobInfo, error) {
+	if plaintext == nil {
+		return nil, errors.New("given plaintext for encryption is nil")
+	}
+
+	env, err := w.envelope.Encrypt(plaintext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("error wrapping data: %w", err)
+	}
+
+	var sealed []byte
+	err = w.withSRK(func(rw io.ReadWriter, srk tpmutil.Handle, _ []byte) error {
+		policy, err := w.policyDigest(rw)
+		if err != nil {
+			return err
+		}
+
+		private, public, err := tpm2.Seal(rw, srk, "", "", policy, env.Key)
+		if err != nil {
+			return fmt.Errorf("error sealing data key: %w", err)
+		}
+		sealed = marshalSealedKey(public, private)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrapping.EncryptedBlobInfo{
+		Ciphertext: env.Ciphertext,
+		IV:         env.IV,
+		KeyInfo: &wrapping.KeyInfo{
+			KeyID:      w.keyID,
+			WrappedKey: sealed,
+		},
+	}, nil
+}
+
+// Decrypt unseals the data key with the TPM, and decrypts the ciphertext
+// with it.
+func (w *Wrapper) Decrypt(_ context.Context, in *wrapping.EncryptedBlobInfo, aad []byte) ([]byte, error) {
+	if in == nil {
+		return nil, errors.New("given input for decryption is nil")
+	}
+	if in.KeyInfo == nil {
+		return nil, errors.New("key info is nil")
+	}
+
+	public, private, err := unmarshalSealedKey(in.KeyInfo.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	err = w.withSRK(func(rw io.ReadWriter, srk tpmutil.Handle, _ []byte) error {
+		item, _, err := tpm2.Load(rw, srk, "", public, private)
+		if err != nil {
+			return fmt.Errorf("error loading data key, the key was sealed by another TPM: %w", err)
+		}
+		defer tpm2.FlushContext(rw, item)
+
+		key, err = w.unseal(rw, item)
+		if err != nil {
+			return fmt.Errorf("error unsealing data key: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := w.envelope.Decrypt(&wrapping.EnvelopeInfo{
+		Key:        key,
+		IV:         in.IV,
+		Ciphertext: in.Ciphertext,
+	}, aad)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data: %w", err)
+	}
+	return plaintext, nil
+}
+
+// withSRK opens the TPM and creates the storage root key, and calls f with
+// them.
+func (w *Wrapper) withSRK(f func(rw io.ReadWriter, srk tpmutil.Handle, srkPublic []byte) error) error {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	rw, err := openTPM(w.device)
+	if err != nil {
+		return fmt.Errorf("error opening TPM: %w", err)
+	}
+	defer rw.Close()
+
+	srk, _, err := tpm2.CreatePrimary(rw, tpm2.HandleOwner, tpm2.PCRSelection{}, "", w.ownerAuth, srkTemplate)
+	if err != nil {
+		return fmt.Errorf("error creating storage root key: %w", err)
+	}
+	defer tpm2.FlushContext(rw, srk)
+
+	srkPublic, _, _, err := tpm2.ReadPublic(rw, srk)
+	if err != nil {
+		return fmt.Errorf("error reading storage root key: %w", err)
+	}
+	encoded, err := srkPublic.Encode()
+	if err != nil {
+		return fmt.Errorf("error encoding storage root key: %w", err)
+	}
+
+	return f(rw, srk, encoded)
+}
+
+// policyDigest returns the digest of the policy the data keys are sealed
+// with. It requires the configured PCRs, if any, to hold their current values.
+func (w *Wrapper) policyDigest(rw io.ReadWriter) ([]byte, error) {
+	session, err := startSession(rw, tpm2.SessionTrial)
+	if err != nil {
+		return nil, err
+	}
+	defer tpm2.FlushContext(rw, session)
+
+	if err := w.applyPolicy(rw, session); err != nil {
+		return nil, err
+	}
+	return tpm2.PolicyGetDigest(rw, session)
+}
+
+// unseal unseals a data key in a session which satisfies its policy.
+func (w *Wrapper) unseal(rw io.ReadWriter, item tpmutil.Handle) ([]byte, error) {
+	session, err := startSession(rw, tpm2.SessionPolicy)
+	if err != nil {
+		return nil, err
+	}
+	defer tpm2.FlushContext(rw, session)
+
+	if err := w.applyPolicy(rw, session); err != nil {
+		return nil, err
+	}
+	return tpm2.UnsealWithSession(rw, session, item, "")
+}
+
+// applyPolicy runs the commands of the policy of the data keys in session.
+// The empty authorization value of the data keys is checked with
+// PolicyPassword, since sealed objects are only usable with a policy.
+func (w *Wrapper) applyPolicy(rw io.ReadWriter, session tpmutil.Handle) error {
+	if err := tpm2.PolicyPassword(rw, session); err != nil {
+		return fmt.Errorf("error applying password policy: %w", err)
+	}
+	if len(w.pcrs.PCRs) == 0 {
+		return nil
+	}
+	if err := tpm2.PolicyPCR(rw, session, nil, w.pcrs); err != nil {
+		return fmt.Errorf("error applying PCR policy: %w", err)
+	}
+	return nil
+}
+
+func startSession(rw io.ReadWriter, sessionType tpm2.SessionType) (tpmutil.Handle, error) {
+	session, _, err := tpm2.StartAuthSession(rw,
+		tpm2.HandleNull,
+		tpm2.HandleNull,
+		make([]byte, sha256.Size),
+		nil,
+		sessionType,
+		tpm2.AlgNull,
+		tpm2.AlgSHA256)
+	if err != nil {
+		return 0, fmt.Errorf("error starting policy session: %w", err)
+	}
+	return session, nil
+}
+
+// marshalSealedKey encodes the public and private areas of a sealed data key
+// as two sized buffers.
+func marshalSealedKey(public, private []byte) []byte {
+	buf := new(bytes.Buffer)
+	for _, b := range [][]byte{public, private} {
+		binary.Write(buf, binary.BigEndian, uint16(len(b)))
+		buf.Write(b)
+	}
+	return buf.Bytes()
+}
+
+func unmarshalSealedKey(sealed []byte) ([]byte, []byte, error) {
+	var parts [2][]byte
+	for i := range parts {
+		if len(sealed) < 2 {
+			return nil, nil, errors.New("sealed data key is truncated")
+		}
+		size := int(binary.BigEndian.Uint16(sealed))
+		sealed = sealed[2:]
+		if len(sealed) < size {
+			return nil, nil, errors.New("sealed data key is truncated")
+		}
+		parts[i] = sealed[:size]
+		sealed = sealed[size:]
+	}
+	if len(sealed) != 0 {
+		return nil, nil, errors.New("sealed data key has trailing data")
+	}
+	return parts[0], parts[1], nil
+}
+
+func parsePCRBank(bank string) (tpm2.Algorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(bank)) {
+	case "sha1":
+		return tpm2.AlgSHA1, nil
+	case "sha256":
+		return tpm2.AlgSHA256, nil
+	case "sha384":
+		return tpm2.AlgSHA384, nil
+	default:
+		return 0, fmt.Errorf("unsupported pcr_bank %q", bank)
+	}
+}
+
+// parsePCRs parses a comma separated list of PCR indexes.
+func parsePCRs(pcrs string) ([]int, error) {
+	var result []int
+	seen := make(map[int]bool)
+	for _, pcr := range strings.Split(pcrs, ",") {
+		pcr = strings.TrimSpace(pcr)
+		if pcr == "" {
+			continue
+		}
+
+		i, err := strconv.Atoi(pcr)
+		if err != nil || i < 0 || i > maxPCR {
+			return nil, fmt.Errorf("invalid PCR %q, PCRs must be between 0 and %d", pcr, maxPCR)
+		}
+		if !seen[i] {
+			seen[i] = true
+			result = append(result, i)
+		}
+	}
+	return result, nil
+}
+
+func formatPCRs(pcrs []int) string {
+	s := make([]string, len(pcrs))
+	for i, pcr := range pcrs {
+		s[i] = strconv.Itoa(pcr)
+	}
+	return strings.Join(s, ",")
+}
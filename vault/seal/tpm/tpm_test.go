@@ -0,0 +1,110 @@
+package tpm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWrapper_SetConfig(t *testing.T) {
+	openTPMOrig := openTPM
+	defer func() { openTPM = openTPMOrig }()
+
+	var device string
+	openTPM = func(d string) (io.ReadWriteCloser, error) {
+		device = d
+		return nil, errors.New("no TPM")
+	}
+
+	w := NewWrapper(nil)
+	if _, err := w.SetConfig(map[string]string{"device": "/dev/tpm0"}); err == nil {
+		t.Fatal("expected an error without a TPM")
+	}
+	if device != "/dev/tpm0" {
+		t.Fatalf("expected /dev/tpm0 to be opened, got %q", device)
+	}
+
+	for _, config := range []map[string]string{
+		{"pcrs": "0,24"},
+		{"pcrs": "a"},
+		{"pcr_bank": "md5"},
+	} {
+		device = ""
+		if _, err := w.SetConfig(config); err == nil {
+			t.Fatalf("expected an error for %v", config)
+		}
+		if device != "" {
+			t.Fatalf("expected the TPM not to be opened for %v", config)
+		}
+	}
+}
+
+func TestParsePCRs(t *testing.T) {
+	pcrs, err := parsePCRs(" 0, 2,4,7,2,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []int{0, 2, 4, 7}; !reflect.DeepEqual(pcrs, exp) {
+		t.Fatalf("got %v, expected %v", pcrs, exp)
+	}
+	if formatted := formatPCRs(pcrs); formatted != "0,2,4,7" {
+		t.Fatalf("got %q", formatted)
+	}
+
+	pcrs, err = parsePCRs("")
+	if err != nil || len(pcrs) != 0 {
+		t.Fatalf("got %v, %v", pcrs, err)
+	}
+}
+
+func TestSealedKey(t *testing.T) {
+	public, private := []byte("public"), []byte("private area")
+	sealed := marshalSealedKey(public, private)
+
+	gotPublic, gotPrivate, err := unmarshalSealedKey(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotPublic, public) || !bytes.Equal(gotPrivate, private) {
+		t.Fatalf("got %q %q", gotPublic, gotPrivate)
+	}
+
+	for _, bad := range [][]byte{nil, sealed[:5], sealed[:len(sealed)-1], append(sealed, 0)} {
+		if _, _, err := unmarshalSealedKey(bad); err == nil {
+			t.Fatalf("expected an error for %v", bad)
+		}
+	}
+}
+
+// TestWrapper_Lifecycle needs a TPM, or a TPM simulator, at VAULT_TPM_DEVICE.
+func TestWrapper_Lifecycle(t *testing.T) {
+	if os.Getenv("VAULT_TPM_DEVICE") == "" {
+		t.Skip("VAULT_TPM_DEVICE not set")
+	}
+
+	w := NewWrapper(nil)
+	if _, err := w.SetConfig(map[string]string{"pcrs": "7"}); err != nil {
+		t.Fatal(err)
+	}
+
+	input := []byte("foo")
+	swi, err := w.Encrypt(context.Background(), input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swi.KeyInfo.KeyID != w.KeyID() {
+		t.Fatalf("got key ID %q, expected %q", swi.KeyInfo.KeyID, w.KeyID())
+	}
+
+	pt, err := w.Decrypt(context.Background(), swi, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(input, pt) {
+		t.Fatalf("expected %s, got %s", input, pt)
+	}
+}
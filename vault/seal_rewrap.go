@@ -0,0 +1,229 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SealRewrapStateNotStarted = "not_started"
+	SealRewrapStateCounting   = "counting"
+	SealRewrapStateRewrapping = "rewrapping"
+	SealRewrapStateCompleted  = "completed"
+	SealRewrapStateFailed     = "failed"
+)
+
+// sealRewrapProgressInterval is how often the progress of the seal rewrap is
+// logged.
+const sealRewrapProgressInterval = 30 * time.Second
+
+// sealEntryRewrapper is implemented by the seal unwrappers that can rewrite a
+// single storage entry after a seal migration.
+type sealEntryRewrapper interface {
+	rewrapEntry(ctx context.Context, key string) (bool, error)
+}
+
+// SealRewrapStatus reports the progress of the rewrap of the storage entries
+// that follows a seal migration.
+type SealRewrapStatus struct {
+	State            string
+	StartTime        time.Time
+	EndTime          time.Time
+	EntriesTotal     int
+	EntriesProcessed int
+	EntriesRewrapped int
+	EntriesFailed    int
+	Error            string
+}
+
+// EntriesPerSecond returns the rate at which the entries have been processed.
+func (s *SealRewrapStatus) EntriesPerSecond(now time.Time) float64 {
+	if s.StartTime.IsZero() || s.EntriesProcessed == 0 {
+		return 0
+	}
+	end := now
+	if !s.EndTime.IsZero() {
+		end = s.EndTime
+	}
+	elapsed := end.Sub(s.StartTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.EntriesProcessed) / elapsed
+}
+
+// PercentComplete returns how many of the entries have been processed.
+func (s *SealRewrapStatus) PercentComplete() float64 {
+	switch {
+	case s.State == SealRewrapStateCompleted:
+		return 100
+	case s.EntriesTotal == 0:
+		return 0
+	}
+	return float64(s.EntriesProcessed) * 100 / float64(s.EntriesTotal)
+}
+
+// EstimatedTimeRemaining returns the time left to process the remaining
+// entries at the current rate, or zero if it is not known.
+func (s *SealRewrapStatus) EstimatedTimeRemaining(now time.Time) time.Duration {
+	if s.State != SealRewrapStateRewrapping {
+		return 0
+	}
+	rate := s.EntriesPerSecond(now)
+	if rate == 0 || s.EntriesTotal <= s.EntriesProcessed {
+		return 0
+	}
+	return time.Duration(float64(s.EntriesTotal-s.EntriesProcessed) / rate * float64(time.Second))
+}
+
+// sealRewrapTracker tracks the state of the seal rewrap of this node.
+type sealRewrapTracker struct {
+	l      sync.RWMutex
+	status SealRewrapStatus
+}
+
+func (t *sealRewrapTracker) start() bool {
+	t.l.Lock()
+	defer t.l.Unlock()
+
+	switch t.status.State {
+	case SealRewrapStateCounting, SealRewrapStateRewrapping:
+		return false
+	}
+	t.status = SealRewrapStatus{
+		State:     SealRewrapStateCounting,
+		StartTime: time.Now(),
+	}
+	return true
+}
+
+func (t *sealRewrapTracker) update(f func(s *SealRewrapStatus)) {
+	t.l.Lock()
+	defer t.l.Unlock()
+	f(&t.status)
+}
+
+func (t *sealRewrapTracker) get() SealRewrapStatus {
+	t.l.RLock()
+	defer t.l.RUnlock()
+
+	status := t.status
+	if status.State == "" {
+		status.State = SealRewrapStateNotStarted
+	}
+	return status
+}
+
+// SealRewrapStatus returns the progress of the rewrap of the storage entries
+// that follows a seal migration.
+func (c *Core) SealRewrapStatus() SealRewrapStatus {
+	return c.sealRewrap.get()
+}
+
+// startSealRewrap starts rewriting the storage entries that the previous seal
+// wrapped in the background. The rewrap stops when ctx is canceled.
+func (c *Core) startSealRewrap(ctx context.Context) error {
+	rewrapper, ok := c.sealUnwrapper.(sealEntryRewrapper)
+	if !ok {
+		return nil
+	}
+	if !c.sealRewrap.start() {
+		return errors.New("seal rewrap already running")
+	}
+
+	go func() {
+		err := c.runSealRewrap(ctx, rewrapper)
+		c.sealRewrap.update(func(s *SealRewrapStatus) {
+			s.EndTime = time.Now()
+			if err != nil {
+				s.State = SealRewrapStateFailed
+				s.Error = err.Error()
+				return
+			}
+			s.State = SealRewrapStateCompleted
+		})
+
+		status := c.sealRewrap.get()
+		if err != nil {
+			c.logger.Error("seal rewrap failed", "error", err, "processed", status.EntriesProcessed, "rewrapped", status.EntriesRewrapped)
+			return
+		}
+		c.logger.Info("seal rewrap complete", "processed", status.EntriesProcessed, "rewrapped", status.EntriesRewrapped, "failed", status.EntriesFailed)
+	}()
+	return nil
+}
+
+func (c *Core) runSealRewrap(ctx context.Context, rewrapper sealEntryRewrapper) error {
+	c.logger.Info("seal rewrap starting")
+
+	// Count the entries first so that the progress can be reported
+	var total int
+	err := c.walkPhysical(ctx, "", func(string) error {
+		total++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	c.sealRewrap.update(func(s *SealRewrapStatus) {
+		s.State = SealRewrapStateRewrapping
+		s.EntriesTotal = total
+	})
+
+	lastReport := time.Now()
+	return c.walkPhysical(ctx, "", func(key string) error {
+		if now := time.Now(); now.Sub(lastReport) >= sealRewrapProgressInterval {
+			lastReport = now
+			status := c.sealRewrap.get()
+			c.logger.Info("seal rewrap progress", "processed", status.EntriesProcessed, "total", status.EntriesTotal,
+				"percent", fmt.Sprintf("%.1f%%", status.PercentComplete()),
+				"eta", status.EstimatedTimeRemaining(now).Round(time.Second).String())
+		}
+
+		rewrapped, err := rewrapper.rewrapEntry(ctx, key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.logger.Warn("failed to rewrap storage entry", "key", key, "error", err)
+		}
+		c.sealRewrap.update(func(s *SealRewrapStatus) {
+			s.EntriesProcessed++
+			switch {
+			case err != nil:
+				s.EntriesFailed++
+			case rewrapped:
+				s.EntriesRewrapped++
+			}
+		})
+		return nil
+	})
+}
+
+// walkPhysical calls f with every key of the underlying physical storage
+// under prefix.
+func (c *Core) walkPhysical(ctx context.Context, prefix string, f func(key string) error) error {
+	keys, err := c.sealUnwrapper.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasSuffix(key, "/") {
+			if err := c.walkPhysical(ctx, prefix+key, f); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := f(prefix + key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
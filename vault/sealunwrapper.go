@@ -181,3 +181,29 @@ func (d *sealUnwrapper) runUnwraps() {
 	// primary
 	atomic.StoreUint32(d.allowUnwraps, 1)
 }
+
+// rewrapEntry rewrites the entry stored at key in the unwrapped format if it
+// is still stored in a proto message, and reports whether it was rewritten.
+func (d *sealUnwrapper) rewrapEntry(ctx context.Context, key string) (bool, error) {
+	entry, err := d.underlying.Get(ctx, key)
+	if err != nil || entry == nil {
+		return false, err
+	}
+
+	eLen := len(entry.Value)
+	if eLen == 0 || entry.Value[eLen-1] != 's' {
+		return false, nil
+	}
+	if err := proto.Unmarshal(entry.Value[:eLen-1], &wrapping.EncryptedBlobInfo{}); err != nil {
+		return false, nil
+	}
+	if atomic.LoadUint32(d.allowUnwraps) != 1 {
+		return false, fmt.Errorf("cannot rewrap storage entry %q on a standby", key)
+	}
+
+	// Get persists the entry back in the unwrapped format
+	if _, err := d.Get(ctx, key); err != nil {
+		return false, err
+	}
+	return true, nil
+}
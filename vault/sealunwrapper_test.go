@@ -7,6 +7,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	proto "github.com/golang/protobuf/proto"
 	log "github.com/hashicorp/go-hclog"
@@ -109,3 +110,53 @@ func performTestSealUnwrapper(t *testing.T, phys physical.Backend, logger log.Lo
 	checkValue(cluster.Cores[1].Core, true)
 	checkValue(cluster.Cores[0].Core, false)
 }
+
+func TestSealUnwrapper_Rewrap(t *testing.T) {
+	ctx := context.Background()
+	c, _, _ := TestCoreUnsealed(t)
+
+	value := []byte("ciphertext")
+	seb, err := proto.Marshal(&wrapping.EncryptedBlobInfo{
+		Ciphertext: value,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"rewrap/foo", "rewrap/nested/bar"} {
+		if err := c.underlyingPhysical.Put(ctx, &physical.Entry{Key: key, Value: append(seb, 's')}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if status := c.SealRewrapStatus(); status.State != SealRewrapStateNotStarted {
+		t.Fatalf("bad state: %q", status.State)
+	}
+	if err := c.startSealRewrap(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var status SealRewrapStatus
+	for i := 0; i < 100; i++ {
+		status = c.SealRewrapStatus()
+		if status.State == SealRewrapStateCompleted || status.State == SealRewrapStateFailed {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if status.State != SealRewrapStateCompleted {
+		t.Fatalf("bad status: %#v", status)
+	}
+	if status.EntriesRewrapped != 2 || status.EntriesFailed != 0 || status.EntriesProcessed != status.EntriesTotal {
+		t.Fatalf("bad status: %#v", status)
+	}
+
+	for _, key := range []string{"rewrap/foo", "rewrap/nested/bar"} {
+		entry, err := c.underlyingPhysical.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(entry.Value, value) {
+			t.Fatalf("entry %q not rewrapped: %v", key, entry.Value)
+		}
+	}
+}
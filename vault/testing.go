@@ -555,7 +555,7 @@ func TestAddTestPlugin(t testing.T, c *Core, name string, pluginType consts.Plug
 	c.pluginCatalog.directory = fullPath
 
 	args := []string{fmt.Sprintf("--test.run=%s", testFunc)}
-	err = c.pluginCatalog.Set(context.Background(), name, pluginType, fileName, args, env, sum)
+	err = c.pluginCatalog.Set(context.Background(), name, pluginType, fileName, args, env, sum, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2179,6 +2179,7 @@ func (m *mockBuiltinRegistry) Keys(pluginType consts.PluginType) []string {
 		"mysql-legacy-database-plugin",
 
 		"cassandra-database-plugin",
+		"clickhouse-database-plugin",
 		"couchbase-database-plugin",
 		"elasticsearch-database-plugin",
 		"hana-database-plugin",
@@ -2196,6 +2197,13 @@ func (m *mockBuiltinRegistry) Contains(name string, pluginType consts.PluginType
 	return false
 }
 
+func (m *mockBuiltinRegistry) DeprecationStatus(name string, pluginType consts.PluginType) (consts.DeprecationStatus, bool) {
+	if testPluginType, ok := m.forTesting[name]; ok && testPluginType == pluginType {
+		return consts.Supported, true
+	}
+	return consts.Unknown, false
+}
+
 type NoopAudit struct {
 	Config         *audit.BackendConfig
 	ReqErr         error
@@ -0,0 +1,574 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-cleanhttp"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// The events webhooks can subscribe to
+const (
+	// WebhookEventKVWrite is sent when a key of a KV mount is written, patched
+	// or deleted.
+	WebhookEventKVWrite = "kv-write"
+
+	// WebhookEventLeaseRevoke is sent when the lease of a secret is revoked,
+	// explicitly or because it expired.
+	WebhookEventLeaseRevoke = "lease-revoke"
+
+	// WebhookEventMount is sent when a secrets engine or an auth method is
+	// enabled, disabled, tuned or moved.
+	WebhookEventMount = "mount"
+)
+
+// WebhookEventTypes are the valid events of a webhook.
+var WebhookEventTypes = []string{WebhookEventKVWrite, WebhookEventLeaseRevoke, WebhookEventMount}
+
+const (
+	// webhookSubPath is the sub-path used for the webhooks in the system
+	// view. Webhooks are stored by namespace ID and name.
+	webhookSubPath = "webhooks/"
+
+	// webhookQueueSize is the number of notifications which can wait for a
+	// delivery. Notifications are dead-lettered when the queue is full, so
+	// that requests are never slowed down by slow webhooks.
+	webhookQueueSize = 1024
+
+	// webhookWorkers is the number of notifications delivered concurrently.
+	webhookWorkers = 4
+
+	// webhookRequestTimeout is the time a webhook has to respond.
+	webhookRequestTimeout = 10 * time.Second
+
+	// webhookMaxRetryBackoff caps the delay between two attempts.
+	webhookMaxRetryBackoff = time.Minute
+
+	// WebhookDefaultMaxRetries is the number of retries of a failed delivery
+	// if the webhook doesn't set it.
+	WebhookDefaultMaxRetries = 3
+
+	// WebhookMaxMaxRetries is the maximum number of retries of a webhook.
+	WebhookMaxMaxRetries = 10
+)
+
+// The headers sent with the notifications
+const (
+	WebhookHeaderEvent     = "X-Vault-Webhook-Event"
+	WebhookHeaderID        = "X-Vault-Webhook-ID"
+	WebhookHeaderTimestamp = "X-Vault-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Vault-Webhook-Signature"
+)
+
+// webhookRetryBackoff is the delay before the first retry of a delivery, which
+// is doubled for each following retry. It is a variable so that tests don't
+// have to wait.
+var webhookRetryBackoff = time.Second
+
+// Webhook is a URL notified of the events of a namespace.
+type Webhook struct {
+	Name        string `json:"name"`
+	NamespaceID string `json:"namespace_id"`
+	URL         string `json:"url"`
+
+	// Secret is the key of the HMAC-SHA256 signature of the notifications
+	Secret string `json:"secret"`
+
+	// Events are the events the webhook subscribed to, and PathPrefix the
+	// prefix of the paths of these events, relative to the namespace.
+	Events     []string `json:"events"`
+	PathPrefix string   `json:"path_prefix"`
+
+	MaxRetries int `json:"max_retries"`
+}
+
+// Validate checks the webhook and returns an error which can be shown to the
+// user if it is invalid.
+func (w *Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q, must be an absolute http or https URL", w.URL)
+	}
+	if w.Secret == "" {
+		return fmt.Errorf("a secret is required to sign the notifications")
+	}
+	if len(w.Events) == 0 {
+		return fmt.Errorf("at least one event is required, valid events are %s", strings.Join(WebhookEventTypes, ", "))
+	}
+	for _, event := range w.Events {
+		if !strutil.StrListContains(WebhookEventTypes, event) {
+			return fmt.Errorf("invalid event %q, valid events are %s", event, strings.Join(WebhookEventTypes, ", "))
+		}
+	}
+	if w.MaxRetries < 0 || w.MaxRetries > WebhookMaxMaxRetries {
+		return fmt.Errorf("max_retries must be between 0 and %d", WebhookMaxMaxRetries)
+	}
+	return nil
+}
+
+// subscribed returns whether the webhook is notified of the event.
+func (w *Webhook) subscribed(event *WebhookEvent) bool {
+	return strutil.StrListContains(w.Events, event.Type) && strings.HasPrefix(event.Path, w.PathPrefix)
+}
+
+// WebhookEvent is the body of the notifications sent to webhooks. It never
+// contains secret data.
+type WebhookEvent struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Namespace string                 `json:"namespace"`
+	Path      string                 `json:"path"`
+	Operation string                 `json:"operation,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// WebhookStats are the delivery statistics of a webhook since the node became
+// active.
+type WebhookStats struct {
+	Delivered     uint64
+	Retries       uint64
+	DeadLetters   uint64
+	LastError     string
+	LastErrorTime time.Time
+}
+
+// webhookDelivery is a notification waiting to be delivered to a webhook.
+type webhookDelivery struct {
+	webhook *Webhook
+	event   *WebhookEvent
+	body    []byte
+	labels  []metrics.Label
+}
+
+// webhookManager stores the webhooks and delivers the notifications of the
+// events they subscribed to. It only runs on the active node, which handles
+// all the writes.
+type webhookManager struct {
+	core   *Core
+	logger log.Logger
+	view   *BarrierView
+	client *http.Client
+
+	l        sync.RWMutex
+	webhooks map[string]*Webhook // by webhookKey
+	stats    map[string]*WebhookStats
+
+	queue  chan *webhookDelivery
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func webhookKey(nsID, name string) string {
+	return nsID + "/" + name
+}
+
+// setupWebhooks loads the webhooks and starts delivering notifications. This
+// should only be called with the core state lock held for writing.
+func (c *Core) setupWebhooks(ctx context.Context) error {
+	logger := c.baseLogger.Named("webhooks")
+	c.AddLogger(logger)
+
+	m := &webhookManager{
+		core:     c,
+		logger:   logger,
+		view:     c.systemBarrierView.SubView(webhookSubPath),
+		client:   cleanhttp.DefaultPooledClient(),
+		webhooks: make(map[string]*Webhook),
+		stats:    make(map[string]*WebhookStats),
+		queue:    make(chan *webhookDelivery, webhookQueueSize),
+	}
+	if err := m.load(ctx); err != nil {
+		return fmt.Errorf("failed to load webhooks: %w", err)
+	}
+
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	for i := 0; i < webhookWorkers; i++ {
+		m.wg.Add(1)
+		go m.run()
+	}
+	c.webhooks = m
+	return nil
+}
+
+// teardownWebhooks stops delivering notifications. Pending notifications are
+// dead-lettered. This should only be called with the core state lock held for
+// writing.
+func (c *Core) teardownWebhooks() {
+	m := c.webhooks
+	if m == nil {
+		return
+	}
+	c.webhooks = nil
+
+	m.cancel()
+	m.wg.Wait()
+	for {
+		select {
+		case d := <-m.queue:
+			m.deadLetter(d, "sealed", nil)
+		default:
+			return
+		}
+	}
+}
+
+func (m *webhookManager) load(ctx context.Context) error {
+	keys, err := logical.CollectKeys(ctx, m.view)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		entry, err := m.view.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		var w Webhook
+		if err := entry.DecodeJSON(&w); err != nil {
+			return fmt.Errorf("failed to decode webhook %q: %w", key, err)
+		}
+		m.webhooks[key] = &w
+	}
+	return nil
+}
+
+// Webhook returns a copy of the webhook of the namespace with the given name
+// and its statistics, or nil if it doesn't exist.
+func (m *webhookManager) Webhook(nsID, name string) (*Webhook, *WebhookStats) {
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	key := webhookKey(nsID, name)
+	w, ok := m.webhooks[key]
+	if !ok {
+		return nil, nil
+	}
+	webhook := *w
+	webhook.Events = append([]string(nil), w.Events...)
+	stats := new(WebhookStats)
+	if s, ok := m.stats[key]; ok {
+		*stats = *s
+	}
+	return &webhook, stats
+}
+
+// WebhookNames returns the sorted names of the webhooks of the namespace.
+func (m *webhookManager) WebhookNames(nsID string) []string {
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	var names []string
+	for _, w := range m.webhooks {
+		if w.NamespaceID == nsID {
+			names = append(names, w.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetWebhook creates or replaces a webhook.
+func (m *webhookManager) SetWebhook(ctx context.Context, w *Webhook) error {
+	key := webhookKey(w.NamespaceID, w.Name)
+	entry, err := logical.StorageEntryJSON(key, w)
+	if err != nil {
+		return err
+	}
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if err := m.view.Put(ctx, entry); err != nil {
+		return err
+	}
+	m.webhooks[key] = w
+	return nil
+}
+
+// DeleteWebhook deletes a webhook. Notifications waiting to be delivered to
+// it are still sent.
+func (m *webhookManager) DeleteWebhook(ctx context.Context, nsID, name string) error {
+	key := webhookKey(nsID, name)
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if err := m.view.Delete(ctx, key); err != nil {
+		return err
+	}
+	delete(m.webhooks, key)
+	delete(m.stats, key)
+	return nil
+}
+
+// notify queues the notification of an event of the namespace to the webhooks
+// which subscribed to it. path is relative to the namespace. It never blocks:
+// notifications are dead-lettered if the queue is full.
+func (m *webhookManager) notify(ns *namespace.Namespace, eventType, path, operation string, data map[string]interface{}) {
+	if m == nil || ns == nil {
+		return
+	}
+
+	event := &WebhookEvent{
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Namespace: ns.Path,
+		Path:      path,
+		Operation: operation,
+		Data:      data,
+	}
+
+	m.l.RLock()
+	var webhooks []*Webhook
+	for _, w := range m.webhooks {
+		if w.NamespaceID == ns.ID && w.subscribed(event) {
+			webhooks = append(webhooks, w)
+		}
+	}
+	m.l.RUnlock()
+	if len(webhooks) == 0 {
+		return
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		m.logger.Error("failed to generate webhook notification ID", "error", err)
+		return
+	}
+	event.ID = id
+	body, err := json.Marshal(event)
+	if err != nil {
+		m.logger.Error("failed to encode webhook notification", "error", err)
+		return
+	}
+
+	for _, w := range webhooks {
+		d := &webhookDelivery{
+			webhook: w,
+			event:   event,
+			body:    body,
+			labels: []metrics.Label{
+				{Name: "webhook", Value: w.Name},
+				{Name: "event", Value: eventType},
+				metricsutil.NamespaceLabel(ns),
+			},
+		}
+		select {
+		case m.queue <- d:
+		default:
+			m.deadLetter(d, "queue_full", nil)
+		}
+	}
+}
+
+func (m *webhookManager) run() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case d := <-m.queue:
+			m.deliver(d)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver sends a notification, retrying with an exponential backoff until
+// the webhook accepts it or the retries of the webhook are exhausted.
+func (m *webhookManager) deliver(d *webhookDelivery) {
+	backoff := webhookRetryBackoff
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		err := m.send(d)
+		m.core.metricSink.MeasureSinceWithLabels([]string{"webhook", "send"}, start, d.labels)
+		if err == nil {
+			m.updateStats(d.webhook, func(s *WebhookStats) { s.Delivered++ })
+			m.core.metricSink.IncrCounterWithLabels([]string{"webhook", "delivered"}, 1, d.labels)
+			return
+		}
+
+		if attempt >= d.webhook.MaxRetries {
+			m.deadLetter(d, "retries_exhausted", err)
+			return
+		}
+		if m.logger.IsDebug() {
+			m.logger.Debug("failed to deliver webhook notification, retrying", "webhook", d.webhook.Name, "id", d.event.ID, "attempt", attempt+1, "error", err)
+		}
+		m.updateStats(d.webhook, func(s *WebhookStats) {
+			s.Retries++
+			s.LastError, s.LastErrorTime = err.Error(), time.Now()
+		})
+		m.core.metricSink.IncrCounterWithLabels([]string{"webhook", "retry"}, 1, d.labels)
+
+		select {
+		case <-time.After(backoff):
+		case <-m.ctx.Done():
+			m.deadLetter(d, "sealed", err)
+			return
+		}
+		if backoff *= 2; backoff > webhookMaxRetryBackoff {
+			backoff = webhookMaxRetryBackoff
+		}
+	}
+}
+
+// send makes a single attempt to deliver a notification. The signature is the
+// hex-encoded HMAC-SHA256 of the timestamp, a dot and the body, so that
+// receivers can reject replayed notifications.
+func (m *webhookManager) send(d *webhookDelivery) error {
+	ctx, cancel := context.WithTimeout(m.ctx, webhookRequestTimeout)
+	defer cancel()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.webhook.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookHeaderEvent, d.event.Type)
+	req.Header.Set(WebhookHeaderID, d.event.ID)
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, "sha256="+WebhookSignature(d.webhook.Secret, timestamp, d.body))
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WebhookSignature returns the signature of a notification sent at the given
+// timestamp, as sent in the X-Vault-Webhook-Signature header after "sha256=".
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deadLetter gives up on the delivery of a notification.
+func (m *webhookManager) deadLetter(d *webhookDelivery, reason string, err error) {
+	m.logger.Warn("dropping webhook notification", "webhook", d.webhook.Name, "id", d.event.ID, "event", d.event.Type, "reason", reason, "error", err)
+	m.updateStats(d.webhook, func(s *WebhookStats) {
+		s.DeadLetters++
+		if err != nil {
+			s.LastError, s.LastErrorTime = err.Error(), time.Now()
+		}
+	})
+	labels := append([]metrics.Label{{Name: "reason", Value: reason}}, d.labels...)
+	m.core.metricSink.IncrCounterWithLabels([]string{"webhook", "dead_letter"}, 1, labels)
+}
+
+// updateStats updates the statistics of a webhook, unless it was deleted or
+// replaced in the meantime.
+func (m *webhookManager) updateStats(w *Webhook, f func(*WebhookStats)) {
+	key := webhookKey(w.NamespaceID, w.Name)
+
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.webhooks[key] != w {
+		return
+	}
+	s, ok := m.stats[key]
+	if !ok {
+		s = new(WebhookStats)
+		m.stats[key] = s
+	}
+	f(s)
+}
+
+// notifyRequestWebhooks notifies the webhooks of the KV writes and mount
+// changes made by a successful request.
+func (c *Core) notifyRequestWebhooks(ctx context.Context, req *logical.Request, entry *MountEntry) {
+	if c.webhooks == nil || entry == nil {
+		return
+	}
+	switch req.Operation {
+	case logical.CreateOperation, logical.UpdateOperation, logical.PatchOperation, logical.DeleteOperation:
+	default:
+		return
+	}
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	switch entry.Type {
+	case "kv":
+		c.webhooks.notify(ns, WebhookEventKVWrite, req.Path, string(req.Operation), map[string]interface{}{
+			"mount_path": entry.Path,
+		})
+
+	case "system":
+		var path, action string
+		data := map[string]interface{}{}
+		switch {
+		case req.Path == "sys/remount" && req.Operation != logical.DeleteOperation:
+			from, _ := req.Data["from"].(string)
+			to, _ := req.Data["to"].(string)
+			path, action = sanitizePath(to), "remount"
+			data["from"] = sanitizePath(from)
+		case strings.HasPrefix(req.Path, "sys/mounts/"):
+			path, action = webhookMountAction(strings.TrimPrefix(req.Path, "sys/mounts/"), req.Operation)
+		case strings.HasPrefix(req.Path, "sys/auth/"):
+			path, action = webhookMountAction(strings.TrimPrefix(req.Path, "sys/auth/"), req.Operation)
+			path = credentialRoutePrefix + path
+		}
+		if path == "" || action == "" {
+			return
+		}
+		if t, ok := req.Data["type"].(string); ok && action == "enable" {
+			data["type"] = t
+		}
+		c.webhooks.notify(ns, WebhookEventMount, path, action, data)
+	}
+}
+
+// webhookMountAction returns the mount path and the action of a request to
+// sys/mounts or sys/auth, without the prefix of the endpoint.
+func webhookMountAction(path string, op logical.Operation) (string, string) {
+	if strings.HasSuffix(path, "/tune") {
+		if op == logical.DeleteOperation {
+			return "", ""
+		}
+		return sanitizePath(strings.TrimSuffix(path, "/tune")), "tune"
+	}
+	if op == logical.DeleteOperation {
+		return sanitizePath(path), "disable"
+	}
+	return sanitizePath(path), "enable"
+}
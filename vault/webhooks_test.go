@@ -0,0 +1,253 @@
+package vault
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+type testWebhookNotification struct {
+	header http.Header
+	body   []byte
+	event  *WebhookEvent
+}
+
+// testWebhookServer returns a server receiving webhook notifications, which
+// responds with the status code returned by status for each request.
+func testWebhookServer(t *testing.T, status func(n int) int) (*httptest.Server, chan *testWebhookNotification) {
+	t.Helper()
+
+	var count int64
+	ch := make(chan *testWebhookNotification, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("err: %v", err)
+		}
+		code := status(int(atomic.AddInt64(&count, 1)))
+		w.WriteHeader(code)
+		if code != http.StatusOK {
+			return
+		}
+
+		n := &testWebhookNotification{header: r.Header, body: body}
+		if err := json.Unmarshal(body, &n.event); err != nil {
+			t.Errorf("err: %v", err)
+		}
+		ch <- n
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch
+}
+
+func testWebhookRequest(t *testing.T, c *Core, root string, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	t.Helper()
+
+	req := logical.TestRequest(t, op, path)
+	req.ClientToken = root
+	req.Data = data
+	resp, err := c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("%s %s: err: %v, resp: %#v", op, path, err, resp)
+	}
+	return resp
+}
+
+func testWebhookNext(t *testing.T, ch chan *testWebhookNotification, eventType, path string) *testWebhookNotification {
+	t.Helper()
+
+	select {
+	case n := <-ch:
+		if n.event.Type != eventType || n.event.Path != path {
+			t.Fatalf("expected a %s event for %s, got %#v", eventType, path, n.event)
+		}
+		ts := n.header.Get(WebhookHeaderTimestamp)
+		if exp, got := "sha256="+WebhookSignature("s3cr3t", ts, n.body), n.header.Get(WebhookHeaderSignature); got != exp {
+			t.Fatalf("bad signature: expected %q, got %q", exp, got)
+		}
+		if n.header.Get(WebhookHeaderEvent) != eventType || n.header.Get(WebhookHeaderID) != n.event.ID {
+			t.Fatalf("bad headers: %#v", n.header)
+		}
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for a %s event for %s", eventType, path)
+	}
+	return nil
+}
+
+func testWebhookStats(t *testing.T, c *Core, root, name string, done func(data map[string]interface{}) bool) map[string]interface{} {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp := testWebhookRequest(t, c, root, logical.ReadOperation, "sys/webhooks/"+name, nil)
+		if done(resp.Data) {
+			return resp.Data
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the delivery statistics of %s: %#v", name, resp.Data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	srv, ch := testWebhookServer(t, func(int) int { return http.StatusOK })
+
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "sys/webhooks/test", map[string]interface{}{
+		"url":         srv.URL,
+		"secret":      "s3cr3t",
+		"events":      "kv-write,lease-revoke,mount",
+		"path_prefix": "secret/",
+	})
+
+	// Mount changes
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "sys/mounts/other", map[string]interface{}{
+		"type": "kv",
+	})
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "sys/mounts/secret", map[string]interface{}{
+		"type": "kv",
+	})
+	n := testWebhookNext(t, ch, WebhookEventMount, "secret/")
+	if n.event.Operation != "enable" || n.event.Data["type"] != "kv" || n.event.Namespace != "" {
+		t.Fatalf("bad event: %#v", n.event)
+	}
+
+	// KV writes, without the data
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "other/foo", map[string]interface{}{
+		"foo": "bar",
+	})
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"foo": "bar",
+		"ttl": "1h",
+	})
+	n = testWebhookNext(t, ch, WebhookEventKVWrite, "secret/foo")
+	if n.event.Data["mount_path"] != "secret/" || len(n.event.Data) != 1 {
+		t.Fatalf("bad event: %#v", n.event)
+	}
+
+	// Lease revocations
+	resp := testWebhookRequest(t, c, root, logical.ReadOperation, "secret/foo", nil)
+	if resp.Secret == nil || resp.Secret.LeaseID == "" {
+		t.Fatalf("expected a lease: %#v", resp)
+	}
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "sys/leases/revoke", map[string]interface{}{
+		"lease_id": resp.Secret.LeaseID,
+	})
+	n = testWebhookNext(t, ch, WebhookEventLeaseRevoke, "secret/foo")
+	if n.event.Data["lease_id"] != resp.Secret.LeaseID {
+		t.Fatalf("bad event: %#v", n.event)
+	}
+
+	select {
+	case n := <-ch:
+		t.Fatalf("unexpected event: %#v", n.event)
+	default:
+	}
+
+	data := testWebhookStats(t, c, root, "test", func(data map[string]interface{}) bool {
+		return data["delivered"] == uint64(3)
+	})
+	if _, ok := data["secret"]; ok {
+		t.Fatal("the secret should not be returned")
+	}
+
+	resp = testWebhookRequest(t, c, root, logical.ListOperation, "sys/webhooks", nil)
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != "test" {
+		t.Fatalf("bad keys: %#v", keys)
+	}
+
+	testWebhookRequest(t, c, root, logical.DeleteOperation, "sys/webhooks/test", nil)
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/webhooks/test")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp != nil {
+		t.Fatalf("expected the webhook to be deleted, got %#v, %v", resp, err)
+	}
+}
+
+func TestWebhooks_retry(t *testing.T) {
+	backoff := webhookRetryBackoff
+	webhookRetryBackoff = time.Millisecond
+	defer func() { webhookRetryBackoff = backoff }()
+
+	c, _, root := TestCoreUnsealed(t)
+
+	flaky, ch := testWebhookServer(t, func(n int) int {
+		if n <= 2 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "sys/webhooks/flaky", map[string]interface{}{
+		"url":    flaky.URL,
+		"secret": "s3cr3t",
+		"events": "mount",
+	})
+	failing, _ := testWebhookServer(t, func(int) int { return http.StatusInternalServerError })
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "sys/webhooks/failing", map[string]interface{}{
+		"url":         failing.URL,
+		"secret":      "s3cr3t",
+		"events":      "mount",
+		"max_retries": 1,
+	})
+
+	testWebhookRequest(t, c, root, logical.UpdateOperation, "sys/mounts/secret", map[string]interface{}{
+		"type": "kv",
+	})
+	testWebhookNext(t, ch, WebhookEventMount, "secret/")
+
+	testWebhookStats(t, c, root, "flaky", func(data map[string]interface{}) bool {
+		return data["delivered"] == uint64(1) && data["retries"] == uint64(2) && data["dead_letters"] == uint64(0)
+	})
+	data := testWebhookStats(t, c, root, "failing", func(data map[string]interface{}) bool {
+		return data["dead_letters"] == uint64(1)
+	})
+	if data["delivered"] != uint64(0) || data["retries"] != uint64(1) || data["last_error"] != "unexpected status code 500" {
+		t.Fatalf("bad statistics: %#v", data)
+	}
+}
+
+func TestWebhooks_invalid(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	cases := map[string]map[string]interface{}{
+		"url": {
+			"url":    "example.com/hook",
+			"secret": "s3cr3t",
+			"events": "mount",
+		},
+		"secret": {
+			"url":    "https://example.com/hook",
+			"events": "mount",
+		},
+		"events": {
+			"url":    "https://example.com/hook",
+			"secret": "s3cr3t",
+			"events": "mount,kv-read",
+		},
+		"max_retries": {
+			"url":         "https://example.com/hook",
+			"secret":      "s3cr3t",
+			"events":      "mount",
+			"max_retries": 100,
+		},
+	}
+	for name, data := range cases {
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/webhooks/test")
+		req.ClientToken = root
+		req.Data = data
+		resp, _ := c.HandleRequest(namespace.RootContext(nil), req)
+		if !resp.IsError() {
+			t.Fatalf("%s: expected an error, got %#v", name, resp)
+		}
+	}
+}